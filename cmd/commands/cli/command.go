@@ -22,15 +22,19 @@ import (
 	"fmt"
 	"io"
 	stdlog "log"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/chzyer/readline"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v2"
 
-	"github.com/mysteriumnetwork/node/cmd"
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/config/urfavecli/clicontext"
 	"github.com/mysteriumnetwork/node/core/connection"
@@ -38,10 +42,12 @@ import (
 	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/metadata"
 	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/node/p2p"
 	"github.com/mysteriumnetwork/node/services"
 	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
 	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/mysteriumnetwork/node/utils"
+	"github.com/mysteriumnetwork/payments/crypto"
 )
 
 const cliCommandName = "cli"
@@ -50,8 +56,10 @@ const serviceHelp = `service <action> [args]
 	start	<ProviderID> <ServiceType> [options]
 	stop	<ServiceID>
 	status	<ServiceID>
+	price	<ServiceID> <PricePerGB> <PricePerMinute>
 	list
 	sessions
+	kick	<ServiceID> <SessionID>
 
 	example: service start 0x7d5ee3557775aed0b85d691b036769c17349db23 openvpn --openvpn.port=1194 --openvpn.proto=UDP`
 
@@ -66,9 +74,10 @@ func NewCommand() *cli.Command {
 			nodeOptions := node.GetOptions()
 			cmdCLI := &cliApp{
 				historyFile: filepath.Join(nodeOptions.Directories.Data, ".cli_history"),
+				state:       newCLIState(filepath.Join(nodeOptions.Directories.Data, ".cli_state")),
 				tequilapi:   tequilapi_client.NewClient(nodeOptions.TequilapiAddress, nodeOptions.TequilapiPort),
 			}
-			cmd.RegisterSignalCallback(utils.SoftKiller(cmdCLI.Kill))
+			cmdCLI.handleSignals()
 
 			return describeQuit(cmdCLI.Run(ctx.Args()))
 		},
@@ -86,13 +95,18 @@ func describeQuit(err error) error {
 
 // cliApp describes CLI based Mysterium UI
 type cliApp struct {
-	historyFile      string
-	tequilapi        *tequilapi_client.Client
-	fetchedProposals []contract.ProposalDTO
-	completer        *readline.PrefixCompleter
-	reader           *readline.Instance
+	historyFile   string
+	state         *cliState
+	tequilapi     *tequilapi_client.Client
+	proposalCache *proposalCache
+	completer     *readline.PrefixCompleter
+	reader        *readline.Instance
 
 	currentConsumerID string
+
+	connectMu        sync.Mutex
+	connecting       bool
+	connectCancelled bool
 }
 
 const redColor = "\033[31m%s\033[0m"
@@ -106,8 +120,12 @@ var versionSummary = metadata.VersionAsSummary(metadata.LicenseCopyright(
 
 // Run runs CLI interface synchronously, in the same thread while blocking it
 func (c *cliApp) Run(args cli.Args) (err error) {
-	c.completer = newAutocompleter(c.tequilapi, c.fetchedProposals)
-	c.fetchedProposals = c.fetchProposals()
+	if err := c.state.load(); err != nil {
+		warn(err)
+	}
+	c.proposalCache = newProposalCache(c.fetchProposals, proposalCacheTTL)
+	c.proposalCache.refresh()
+	c.completer = newAutocompleter(c.tequilapi, c.state, c.proposalCache)
 
 	if args.Len() > 0 {
 		c.handleActions(strings.Join(args.Slice(), " "))
@@ -142,8 +160,60 @@ func (c *cliApp) Run(args cli.Args) (err error) {
 	}
 }
 
+// handleSignals cancels in-flight connect on interrupt, any other signal or interrupt stops cli
+func (c *cliApp) handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	go func() {
+		for sig := range signals {
+			if sig == os.Interrupt && c.cancelConnect() {
+				continue
+			}
+			utils.SoftKiller(c.Kill)()
+			return
+		}
+	}()
+}
+
+// cancelConnect cancels in-flight connect, returns false if there is nothing to cancel
+func (c *cliApp) cancelConnect() bool {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+
+	if !c.connecting {
+		return false
+	}
+	if c.connectCancelled {
+		return true
+	}
+	c.connectCancelled = true
+
+	fmt.Println()
+	status("CANCELLING", "waiting for connection to be cancelled")
+	go func() {
+		if err := c.tequilapi.ConnectionDestroy(); err != nil {
+			warn("Failed to cancel connection:", err)
+		}
+	}()
+	return true
+}
+
+func (c *cliApp) setConnecting(connecting bool) (cancelled bool) {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+
+	cancelled = c.connectCancelled
+	c.connecting = connecting
+	c.connectCancelled = false
+	return cancelled
+}
+
 // Kill stops cli
 func (c *cliApp) Kill() error {
+	if c.reader == nil {
+		return nil
+	}
 	c.reader.Clean()
 	return c.reader.Close()
 }
@@ -161,6 +231,8 @@ func (c *cliApp) handleActions(line string) {
 		{"status", c.status},
 		{"healthcheck", c.healthcheck},
 		{"nat", c.natStatus},
+		{"nat diagnostics", c.p2pDiagnostics},
+		{"nat mappings", c.natPortMappings},
 		{"location", c.location},
 		{"disconnect", c.disconnect},
 		{"stop", c.stopClient},
@@ -179,6 +251,9 @@ func (c *cliApp) handleActions(line string) {
 		{"service", c.service},
 		{"stake", c.stake},
 		{"mmn", c.mmnApiKey},
+		{"favorite", c.favorite},
+		{"profile", c.profile},
+		{"acl", c.acl},
 	}
 
 	for _, cmd := range staticCmds {
@@ -228,10 +303,22 @@ func (c *cliApp) service(argsString string) {
 			return
 		}
 		c.serviceGet(args[1])
+	case "price":
+		if len(args) < 4 {
+			fmt.Println(serviceHelp)
+			return
+		}
+		c.servicePrice(args[1], args[2], args[3])
 	case "list":
 		c.serviceList()
 	case "sessions":
 		c.serviceSessions()
+	case "kick":
+		if len(args) < 3 {
+			fmt.Println(serviceHelp)
+			return
+		}
+		c.serviceKick(args[1], args[2])
 	default:
 		info(fmt.Sprintf("Unknown action provided: %s", action))
 		fmt.Println(serviceHelp)
@@ -275,6 +362,42 @@ func (c *cliApp) serviceStop(id string) {
 	status("Stopping", "ID: "+id)
 }
 
+func (c *cliApp) serviceKick(id, sessionID string) {
+	if err := c.tequilapi.ServiceSessionTerminate(id, sessionID); err != nil {
+		info("Failed to terminate session: ", err)
+		return
+	}
+
+	status("Terminated", "ID: "+id, "SessionID: "+sessionID)
+}
+
+func (c *cliApp) servicePrice(id, pricePerGB, pricePerMinute string) {
+	priceGB, err := strconv.ParseFloat(pricePerGB, 64)
+	if err != nil || priceGB < 0 {
+		warnf("Invalid price per GiB %q\n", pricePerGB)
+		return
+	}
+	priceMinute, err := strconv.ParseFloat(pricePerMinute, 64)
+	if err != nil || priceMinute < 0 {
+		warnf("Invalid price per minute %q\n", pricePerMinute)
+		return
+	}
+
+	service, err := c.tequilapi.ServicePaymentMethodUpdate(id, contract.ServicePaymentMethod{
+		PriceGB:     crypto.FloatToBigMyst(priceGB),
+		PriceMinute: crypto.FloatToBigMyst(priceMinute),
+	})
+	if err != nil {
+		info("Failed to update service prices: ", err)
+		return
+	}
+
+	status(service.Status,
+		"ID: "+service.ID,
+		"ProviderID: "+service.Proposal.ProviderID,
+		"Type: "+service.Proposal.ServiceType)
+}
+
 func (c *cliApp) serviceList() {
 	services, err := c.tequilapi.Services()
 	if err != nil {
@@ -324,18 +447,48 @@ func (c *cliApp) serviceGet(id string) {
 func (c *cliApp) connect(argsString string) {
 	args := strings.Fields(argsString)
 
-	helpMsg := "Please type in the provider identity. connect <consumer-identity> <provider-identity> <service-type> [dns=auto|provider|system|1.1.1.1] [disable-kill-switch]"
-	if len(args) < 3 {
+	if len(args) > 0 && args[0] == "profile" {
+		c.connectProfile(args[1:])
+		return
+	}
+
+	helpMsg := "Please type in the provider identity. connect <consumer-identity> <provider-identity|@favorite> <service-type> [dns=auto|provider|system|1.1.1.1] [disable-kill-switch] [entry=<provider-identity|@favorite>]"
+	if len(args) < 2 {
 		info(helpMsg)
 		return
 	}
 
-	consumerID, providerID, serviceType := args[0], args[1], args[2]
+	consumerID := args[0]
+	fav, err := c.resolveFavorite(args[1])
+	if err != nil {
+		warn(err)
+		return
+	}
+	providerID, serviceType := fav.ProviderID, fav.ServiceType
+
+	optionArgs := args[2:]
+	if len(args) > 2 && !isConnectOption(args[2]) {
+		serviceType = args[2]
+		optionArgs = args[3:]
+	}
+	if serviceType == "" {
+		info(helpMsg)
+		return
+	}
 
 	var disableKillSwitch bool
 	var dns connection.DNSOption
-	var err error
-	for _, arg := range args[3:] {
+	var entryProviderID string
+	for _, arg := range optionArgs {
+		if strings.HasPrefix(arg, "entry=") {
+			entry, err := c.resolveFavorite(strings.TrimPrefix(arg, "entry="))
+			if err != nil {
+				warn(err)
+				return
+			}
+			entryProviderID = entry.ProviderID
+			continue
+		}
 		if strings.HasPrefix(arg, "dns=") {
 			kv := strings.Split(arg, "=")
 			dns, err = connection.NewDNSOption(kv[1])
@@ -359,6 +512,7 @@ func (c *cliApp) connect(argsString string) {
 	connectOptions := contract.ConnectOptions{
 		DNS:               dns,
 		DisableKillSwitch: disableKillSwitch,
+		EntryProviderID:   entryProviderID,
 	}
 
 	if consumerID == "new" {
@@ -374,7 +528,12 @@ func (c *cliApp) connect(argsString string) {
 	status("CONNECTING", "from:", consumerID, "to:", providerID)
 
 	hermesID := config.GetString(config.FlagHermesID)
+	c.setConnecting(true)
 	_, err = c.tequilapi.ConnectionCreate(consumerID, providerID, hermesID, serviceType, connectOptions)
+	if cancelled := c.setConnecting(false); cancelled {
+		info("Connection cancelled.")
+		return
+	}
 	if err != nil {
 		warn(err)
 		return
@@ -385,6 +544,10 @@ func (c *cliApp) connect(argsString string) {
 	success("Connected.")
 }
 
+func isConnectOption(arg string) bool {
+	return strings.HasPrefix(arg, "dns=") || strings.HasPrefix(arg, "entry=") || arg == "disable-kill-switch"
+}
+
 func (c *cliApp) payout(argsString string) {
 	args := strings.Fields(argsString)
 
@@ -485,6 +648,15 @@ func (c *cliApp) status() {
 
 	if status.Status == statusConnected {
 		info("Proposal:", status.Proposal)
+		if status.EntryProposal != nil {
+			info("Entry proposal:", status.EntryProposal)
+		}
+		if status.DNSLeakDetected {
+			warn("DNS leak detected, DNS queries bypass the tunnel")
+		}
+		if status.Relayed {
+			warn("Provider could not be reached directly, connection is relayed")
+		}
 
 		statistics, err := c.tequilapi.ConnectionStatistics()
 		if err != nil {
@@ -526,9 +698,77 @@ func (c *cliApp) natStatus() {
 	}
 }
 
+func (c *cliApp) p2pDiagnostics() {
+	diagnostics, err := c.tequilapi.P2PDiagnostics()
+	if err != nil {
+		warn("Failed to retrieve p2p diagnostics:", err)
+		return
+	}
+	if len(diagnostics.Reports) == 0 {
+		info("No p2p channels were established yet")
+		return
+	}
+
+	for _, report := range diagnostics.Reports {
+		info(fmt.Sprintf("Last p2p channel as %s (%s) started at %s, took %dms", report.Role, report.ServiceType, report.StartedAt, report.Duration))
+		info(fmt.Sprintf("Public IP: %s, peer public IP: %s", report.PublicIP, report.PeerPublicIP))
+		for _, step := range report.Steps {
+			if step.Error == "" {
+				info(fmt.Sprintf("  %s: %dms", step.Name, step.Duration))
+			} else {
+				warn(fmt.Sprintf("  %s: %dms, error: %s", step.Name, step.Duration, step.Error))
+			}
+		}
+		for _, attempt := range report.Attempts {
+			target := attempt.PeerIP
+			if attempt.Method == p2p.TraversalMethodRelay {
+				target = attempt.Relay
+			}
+			msg := fmt.Sprintf("  %s attempt to %s through %d candidate pairs: %dms", attempt.Method, target, len(attempt.CandidatePairs), attempt.Duration)
+			if attempt.Error == "" {
+				info(msg)
+			} else {
+				warn(msg + ", error: " + attempt.Error)
+			}
+		}
+		if report.Relayed {
+			warn("Channel is relayed")
+		}
+		if report.Error != "" {
+			warn("Failed:", report.Error)
+		}
+	}
+}
+
+func (c *cliApp) natPortMappings() {
+	mappings, err := c.tequilapi.NATPortMappings()
+	if err != nil {
+		warn("Failed to retrieve port mappings:", err)
+		return
+	}
+	if len(mappings.Mappings) == 0 {
+		info("No ports are mapped on the router")
+		return
+	}
+
+	for _, m := range mappings.Mappings {
+		msg := fmt.Sprintf("%s %d (%s) mapped via %s, renewed at %s", m.Protocol, m.Port, m.Name, m.Method, m.RenewedAt)
+		if m.Permanent {
+			msg += ", permanent lease"
+		} else {
+			msg += ", expires at " + m.ExpiresAt
+		}
+		if m.Error == "" {
+			info(msg)
+		} else {
+			warn(msg + ", renewal error: " + m.Error)
+		}
+	}
+}
+
 func (c *cliApp) proposals(filter string) {
 	proposals := c.fetchProposals()
-	c.fetchedProposals = proposals
+	c.proposalCache.set(proposals)
 
 	filterMsg := ""
 	if filter != "" {
@@ -632,36 +872,49 @@ func getIdentityOptionList(tequilapi *tequilapi_client.Client) func(string) []st
 	}
 }
 
-func getProposalOptionList(proposals []contract.ProposalDTO) func(string) []string {
-	return func(line string) []string {
-		var providerIDS []string
-		for _, proposal := range proposals {
-			providerIDS = append(providerIDS, proposal.ProviderID)
-		}
-		return providerIDS
-	}
-}
-
-func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []contract.ProposalDTO) *readline.PrefixCompleter {
+func newAutocompleter(tequilapi *tequilapi_client.Client, state *cliState, proposals *proposalCache) *readline.PrefixCompleter {
 	connectOpts := []readline.PrefixCompleterInterface{
 		readline.PcItem("dns=auto"),
 		readline.PcItem("dns=provider"),
 		readline.PcItem("dns=system"),
 		readline.PcItem("dns=1.1.1.1"),
 	}
+	serviceTypes := []readline.PrefixCompleterInterface{
+		readline.PcItem("noop", connectOpts...),
+		readline.PcItem("openvpn", connectOpts...),
+		readline.PcItem("wireguard", connectOpts...),
+		readline.PcItem("http-proxy", connectOpts...),
+		readline.PcItem("shadowsocks", connectOpts...),
+	}
 	return readline.NewPrefixCompleter(
 		readline.PcItem(
 			"connect",
+			readline.PcItem("profile", readline.PcItemDynamic(getProfileOptionList(tequilapi))),
 			readline.PcItemDynamic(
 				getIdentityOptionList(tequilapi),
-				readline.PcItemDynamic(
-					getProposalOptionList(proposals),
-					readline.PcItem("noop", connectOpts...),
-					readline.PcItem("openvpn", connectOpts...),
-					readline.PcItem("wireguard", connectOpts...),
-				),
+				newProviderCompleter(proposals, 2, serviceTypes...),
+				readline.PcItemDynamic(getFavoriteOptionList(state), serviceTypes...),
 			),
 		),
+		readline.PcItem(
+			"profile",
+			readline.PcItem("add"),
+			readline.PcItem("remove", readline.PcItemDynamic(getProfileOptionList(tequilapi))),
+			readline.PcItem("list"),
+		),
+		readline.PcItem(
+			"acl",
+			readline.PcItem("allow"),
+			readline.PcItem("block"),
+			readline.PcItem("remove", readline.PcItemDynamic(getConsumerACLOptionList(tequilapi))),
+			readline.PcItem("list"),
+		),
+		readline.PcItem(
+			"favorite",
+			readline.PcItem("add"),
+			readline.PcItem("remove", readline.PcItemDynamic(getFavoriteOptionList(state))),
+			readline.PcItem("list"),
+		),
 		readline.PcItem(
 			"service",
 			readline.PcItem("start", readline.PcItemDynamic(
@@ -669,19 +922,28 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []contract.P
 				readline.PcItem("noop"),
 				readline.PcItem("openvpn"),
 				readline.PcItem("wireguard"),
+				readline.PcItem("http-proxy"),
+				readline.PcItem("shadowsocks"),
 			)),
 			readline.PcItem("stop"),
 			readline.PcItem("list"),
 			readline.PcItem("status"),
+			readline.PcItem("price"),
 			readline.PcItem("sessions"),
+			readline.PcItem("kick"),
 		),
 		readline.PcItem(
 			"identities",
 			readline.PcItem("list"),
 			readline.PcItem("get", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
 			readline.PcItem("new"),
+			readline.PcItem("new-mnemonic"),
+			readline.PcItem("import"),
 			readline.PcItem("unlock", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
+			readline.PcItem("change-passphrase", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
+			readline.PcItem("payment-config", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
 			readline.PcItem("register", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
+			readline.PcItem("register-sponsored", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
 			readline.PcItem("beneficiary", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
 			readline.PcItem("settle", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
 			readline.PcItem("referralcode", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
@@ -693,7 +955,7 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []contract.P
 			readline.PcItem("decrease"),
 		),
 		readline.PcItem("healthcheck"),
-		readline.PcItem("nat"),
+		readline.PcItem("nat", readline.PcItem("diagnostics"), readline.PcItem("mappings")),
 		readline.PcItem("proposals"),
 		readline.PcItem("location"),
 		readline.PcItem("disconnect"),
@@ -719,6 +981,8 @@ func parseStartFlags(serviceType string, args ...string) (services.StartOptions,
 	config.RegisterFlagsServiceOpenvpn(&flags)
 	config.RegisterFlagsServiceWireguard(&flags)
 	config.RegisterFlagsServiceNoop(&flags)
+	config.RegisterFlagsServiceHTTPProxy(&flags)
+	config.RegisterFlagsServiceShadowsocks(&flags)
 
 	set := flag.NewFlagSet("", flag.ContinueOnError)
 	for _, f := range flags {
@@ -733,6 +997,8 @@ func parseStartFlags(serviceType string, args ...string) (services.StartOptions,
 	config.ParseFlagsServiceOpenvpn(ctx)
 	config.ParseFlagsServiceWireguard(ctx)
 	config.ParseFlagsServiceNoop(ctx)
+	config.ParseFlagsServiceHTTPProxy(ctx)
+	config.ParseFlagsServiceShadowsocks(ctx)
 
 	return services.GetStartOptions(serviceType)
 }
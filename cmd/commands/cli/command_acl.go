@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/core/acl"
+	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+)
+
+func (c *cliApp) acl(argsString string) {
+	var usage = strings.Join([]string{
+		"Usage: acl <action> [args]",
+		"Available actions:",
+		"  " + usageAllowConsumer,
+		"  " + usageBlockConsumer,
+		"  " + usageRemoveConsumerACL,
+		"  " + usageListConsumerACL,
+	}, "\n")
+
+	if len(argsString) == 0 {
+		info(usage)
+		return
+	}
+
+	args := strings.Fields(argsString)
+	action := args[0]
+	actionArgs := args[1:]
+
+	switch action {
+	case "allow":
+		c.saveConsumerACL(acl.ListAllow, usageAllowConsumer, actionArgs)
+	case "block":
+		c.saveConsumerACL(acl.ListBlock, usageBlockConsumer, actionArgs)
+	case "remove":
+		c.removeConsumerACL(actionArgs)
+	case "list":
+		c.listConsumerACL(actionArgs)
+	default:
+		warnf("Unknown sub-command '%s'\n", argsString)
+		fmt.Println(usage)
+	}
+}
+
+const (
+	usageAllowConsumer     = "allow <consumer-identity>"
+	usageBlockConsumer     = "block <consumer-identity>"
+	usageRemoveConsumerACL = "remove <consumer-identity>"
+	usageListConsumerACL   = "list"
+)
+
+func (c *cliApp) saveConsumerACL(list acl.List, usage string, args []string) {
+	if len(args) != 1 {
+		info("Usage: " + usage)
+		return
+	}
+
+	if err := c.tequilapi.ConsumerACLSave(contract.ConsumerACLEntryDTO{Identity: args[0], List: list}); err != nil {
+		warn(err)
+		return
+	}
+	success(fmt.Sprintf("Consumer %s added to %s list.", args[0], list))
+}
+
+func (c *cliApp) removeConsumerACL(args []string) {
+	if len(args) != 1 {
+		info("Usage: " + usageRemoveConsumerACL)
+		return
+	}
+
+	if err := c.tequilapi.ConsumerACLDelete(args[0]); err != nil {
+		warn(err)
+		return
+	}
+	success(fmt.Sprintf("Consumer %s removed.", args[0]))
+}
+
+func (c *cliApp) listConsumerACL(args []string) {
+	if len(args) > 0 {
+		info("Usage: " + usageListConsumerACL)
+		return
+	}
+
+	entries, err := c.tequilapi.ConsumerACL()
+	if err != nil {
+		warn(err)
+		return
+	}
+	for _, e := range entries {
+		status(string(e.List), e.Identity)
+	}
+}
+
+func getConsumerACLOptionList(tequilapi *tequilapi_client.Client) func(string) []string {
+	return func(line string) []string {
+		entries, err := tequilapi.ConsumerACL()
+		if err != nil {
+			return nil
+		}
+		var identities []string
+		for _, e := range entries {
+			identities = append(identities, e.Identity)
+		}
+		return identities
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const favoriteAliasPrefix = "@"
+
+func (c *cliApp) favorite(argsString string) {
+	var usage = strings.Join([]string{
+		"Usage: favorite <action> [args]",
+		"Available actions:",
+		"  " + usageAddFavorite,
+		"  " + usageRemoveFavorite,
+		"  " + usageListFavorites,
+	}, "\n")
+
+	if len(argsString) == 0 {
+		info(usage)
+		return
+	}
+
+	args := strings.Fields(argsString)
+	action := args[0]
+	actionArgs := args[1:]
+
+	switch action {
+	case "add":
+		c.addFavorite(actionArgs)
+	case "remove":
+		c.removeFavorite(actionArgs)
+	case "list":
+		c.listFavorites(actionArgs)
+	default:
+		warnf("Unknown sub-command '%s'\n", argsString)
+		fmt.Println(usage)
+	}
+}
+
+const usageAddFavorite = "add <alias> <provider-id> [service-type]"
+
+func (c *cliApp) addFavorite(args []string) {
+	if len(args) < 2 || len(args) > 3 {
+		info("Usage: " + usageAddFavorite)
+		return
+	}
+
+	alias := strings.TrimPrefix(args[0], favoriteAliasPrefix)
+	if alias == "" {
+		warn("Alias can not be empty")
+		return
+	}
+
+	fav := favorite{ProviderID: args[1]}
+	if len(args) == 3 {
+		fav.ServiceType = args[2]
+	}
+	if err := c.state.addFavorite(alias, fav); err != nil {
+		warn(err)
+		return
+	}
+	success(fmt.Sprintf("Favorite %s%s saved.", favoriteAliasPrefix, alias))
+}
+
+const usageRemoveFavorite = "remove <alias>"
+
+func (c *cliApp) removeFavorite(args []string) {
+	if len(args) != 1 {
+		info("Usage: " + usageRemoveFavorite)
+		return
+	}
+
+	alias := strings.TrimPrefix(args[0], favoriteAliasPrefix)
+	removed, err := c.state.removeFavorite(alias)
+	if err != nil {
+		warn(err)
+		return
+	}
+	if !removed {
+		warnf("Favorite %s%s not found\n", favoriteAliasPrefix, alias)
+		return
+	}
+	success(fmt.Sprintf("Favorite %s%s removed.", favoriteAliasPrefix, alias))
+}
+
+const usageListFavorites = "list"
+
+func (c *cliApp) listFavorites(args []string) {
+	if len(args) > 0 {
+		info("Usage: " + usageListFavorites)
+		return
+	}
+
+	for _, alias := range c.state.favoriteAliases() {
+		fav, _ := c.state.favorite(alias)
+		if fav.ServiceType == "" {
+			status(favoriteAliasPrefix+alias, fav.ProviderID)
+		} else {
+			status(favoriteAliasPrefix+alias, fav.ProviderID, fav.ServiceType)
+		}
+	}
+}
+
+// resolveFavorite resolves provider argument, which is either a provider ID or a favorite alias prefixed with '@'
+func (c *cliApp) resolveFavorite(providerArg string) (favorite, error) {
+	if !strings.HasPrefix(providerArg, favoriteAliasPrefix) {
+		return favorite{ProviderID: providerIDFromArg(providerArg)}, nil
+	}
+
+	fav, ok := c.state.favorite(strings.TrimPrefix(providerArg, favoriteAliasPrefix))
+	if !ok {
+		return favorite{}, errors.Errorf("unknown favorite %s", providerArg)
+	}
+	return fav, nil
+}
+
+func getFavoriteOptionList(state *cliState) func(string) []string {
+	return func(line string) []string {
+		var aliases []string
+		for _, alias := range state.favoriteAliases() {
+			aliases = append(aliases, favoriteAliasPrefix+alias)
+		}
+		return aliases
+	}
+}
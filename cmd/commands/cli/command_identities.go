@@ -23,7 +23,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/money"
 	"github.com/pkg/errors"
@@ -36,8 +36,13 @@ func (c *cliApp) identities(argsString string) {
 		"  " + usageListIdentities,
 		"  " + usageGetIdentity,
 		"  " + usageNewIdentity,
+		"  " + usageNewIdentityWithMnemonic,
+		"  " + usageImportIdentity,
 		"  " + usageUnlockIdentity,
+		"  " + usageChangePassphrase,
+		"  " + usagePaymentConfig,
 		"  " + usageRegisterIdentity,
+		"  " + usageRegisterIdentitySponsored,
 		"  " + usageSettle,
 		"  " + usageGetReferralCode,
 	}, "\n")
@@ -58,10 +63,20 @@ func (c *cliApp) identities(argsString string) {
 		c.getIdentity(actionArgs)
 	case "new":
 		c.newIdentity(actionArgs)
+	case "new-mnemonic":
+		c.newIdentityWithMnemonic(actionArgs)
+	case "import":
+		c.importIdentity(actionArgs)
 	case "unlock":
 		c.unlockIdentity(actionArgs)
+	case "change-passphrase":
+		c.changePassphrase(actionArgs)
+	case "payment-config":
+		c.paymentConfig(actionArgs)
 	case "register":
 		c.registerIdentity(actionArgs)
+	case "register-sponsored":
+		c.registerIdentitySponsored(actionArgs)
 	case "beneficiary":
 		c.setBeneficiary(actionArgs)
 	case "settle":
@@ -89,6 +104,23 @@ func (c *cliApp) listIdentities(args []string) {
 
 	for _, id := range ids {
 		status("+", id.Address)
+		if id.Usage == nil {
+			continue
+		}
+		info(fmt.Sprintf(
+			"  Consumed: %d sessions, data %s/%s, spent %s",
+			id.Usage.Consumed.Count,
+			datasize.FromBytes(id.Usage.Consumed.SumBytesReceived),
+			datasize.FromBytes(id.Usage.Consumed.SumBytesSent),
+			money.NewMoney(id.Usage.Consumed.SumTokens, money.CurrencyMyst),
+		))
+		info(fmt.Sprintf(
+			"  Provided: %d sessions, data %s/%s, earned %s",
+			id.Usage.Provided.Count,
+			datasize.FromBytes(id.Usage.Provided.SumBytesReceived),
+			datasize.FromBytes(id.Usage.Provided.SumBytesSent),
+			money.NewMoney(id.Usage.Provided.SumTokens, money.CurrencyMyst),
+		))
 	}
 }
 
@@ -133,6 +165,50 @@ func (c *cliApp) newIdentity(args []string) {
 	success("New identity created:", id.Address)
 }
 
+const usageNewIdentityWithMnemonic = "new-mnemonic [passphrase]"
+
+func (c *cliApp) newIdentityWithMnemonic(args []string) {
+	if len(args) > 1 {
+		info("Usage: " + usageNewIdentityWithMnemonic)
+		return
+	}
+	passphrase := identityDefaultPassphrase
+	if len(args) == 1 {
+		passphrase = args[0]
+	}
+
+	id, err := c.tequilapi.NewIdentityWithMnemonic(passphrase)
+	if err != nil {
+		warn(err)
+		return
+	}
+	success("New identity created:", id.Address)
+	info("Write down the seed phrase, it restores the identity and will not be shown again:")
+	info(id.Mnemonic)
+}
+
+const usageImportIdentity = "import <seed phrase words> [passphrase]"
+
+func (c *cliApp) importIdentity(args []string) {
+	// Seed phrases consist of 12, 15, 18, 21 or 24 words, an extra argument is the passphrase.
+	if len(args) < 12 || len(args)%3 == 2 {
+		info("Usage: " + usageImportIdentity)
+		return
+	}
+	passphrase := identityDefaultPassphrase
+	if len(args)%3 == 1 {
+		passphrase = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	id, err := c.tequilapi.ImportIdentity(strings.Join(args, " "), passphrase)
+	if err != nil {
+		warn(err)
+		return
+	}
+	success("Identity imported:", id.Address)
+}
+
 const usageUnlockIdentity = "unlock <identity> [passphrase]"
 
 func (c *cliApp) unlockIdentity(actionArgs []string) {
@@ -157,6 +233,51 @@ func (c *cliApp) unlockIdentity(actionArgs []string) {
 	success(fmt.Sprintf("Identity %s unlocked.", address))
 }
 
+const usageChangePassphrase = "change-passphrase <identity> <current passphrase> <new passphrase>"
+
+func (c *cliApp) changePassphrase(actionArgs []string) {
+	if len(actionArgs) != 3 {
+		info("Usage: " + usageChangePassphrase)
+		return
+	}
+
+	address := actionArgs[0]
+	err := c.tequilapi.ChangePassphrase(address, actionArgs[1], actionArgs[2])
+	if err != nil {
+		warn(err)
+		return
+	}
+
+	success(fmt.Sprintf("Passphrase of identity %s changed.", address))
+}
+
+const usagePaymentConfig = "payment-config <identity> [hermes-id]"
+
+func (c *cliApp) paymentConfig(actionArgs []string) {
+	if len(actionArgs) < 1 || len(actionArgs) > 2 {
+		info("Usage: " + usagePaymentConfig)
+		return
+	}
+
+	address := actionArgs[0]
+	if len(actionArgs) == 1 {
+		paymentConfig, err := c.tequilapi.GetPaymentConfig(address)
+		if err != nil {
+			warn(err)
+			return
+		}
+		info("Hermes ID:", paymentConfig.HermesID)
+		return
+	}
+
+	paymentConfig, err := c.tequilapi.SetPaymentConfig(address, actionArgs[1])
+	if err != nil {
+		warn(err)
+		return
+	}
+	success(fmt.Sprintf("Identity %s now uses hermes %s.", address, paymentConfig.HermesID))
+}
+
 const usageRegisterIdentity = "register <identity> [stake] [beneficiary] [referralcode]"
 
 func (c *cliApp) registerIdentity(actionArgs []string) {
@@ -199,6 +320,31 @@ func (c *cliApp) registerIdentity(actionArgs []string) {
 	info("Registration successful, you can now connect.")
 }
 
+const usageRegisterIdentitySponsored = "register-sponsored <identity> [sponsor] [beneficiary]"
+
+func (c *cliApp) registerIdentitySponsored(actionArgs []string) {
+	if len(actionArgs) < 1 || len(actionArgs) > 2 {
+		info("Usage: " + usageRegisterIdentitySponsored)
+		return
+	}
+
+	var sponsor, beneficiary string
+	if len(actionArgs) >= 2 {
+		sponsor = actionArgs[1]
+	}
+	if len(actionArgs) >= 3 {
+		beneficiary = actionArgs[2]
+	}
+
+	err := c.tequilapi.RegisterIdentitySponsored(actionArgs[0], beneficiary, sponsor)
+	if err != nil {
+		warn(errors.Wrap(err, "could not register identity"))
+		return
+	}
+
+	info("Sponsored registration submitted, you can connect once it is confirmed.")
+}
+
 const usageSettle = "settle <providerIdentity>"
 
 func (c *cliApp) settle(args []string) {
@@ -214,12 +360,12 @@ func (c *cliApp) settle(args []string) {
 		info(fmt.Sprintf("Hermes fee: %v MYST", hermesFee.String()))
 		return
 	}
-	hermesID := config.GetString(config.FlagHermesID)
 	info("Waiting for settlement to complete")
 	errChan := make(chan error)
 
 	go func() {
-		errChan <- c.tequilapi.Settle(identity.FromAddress(args[0]), identity.FromAddress(hermesID), true)
+		// hermes is omitted, so node settles with the preferred one of identity
+		errChan <- c.tequilapi.Settle(identity.FromAddress(args[0]), identity.Identity{}, true)
 	}()
 
 	timeout := time.After(time.Minute * 2)
@@ -271,9 +417,8 @@ func (c *cliApp) setBeneficiary(actionArgs []string) {
 
 	address := actionArgs[0]
 	beneficiary := actionArgs[1]
-	hermesID := config.GetString(config.FlagHermesID)
 
-	err := c.tequilapi.SettleWithBeneficiary(address, beneficiary, hermesID)
+	err := c.tequilapi.SettleWithBeneficiary(address, beneficiary, "")
 	if err != nil {
 		warn(errors.Wrap(err, "could not set beneficiary"))
 		return
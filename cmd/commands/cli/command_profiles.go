@@ -0,0 +1,222 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mysteriumnetwork/payments/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/money"
+	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+)
+
+func (c *cliApp) profile(argsString string) {
+	var usage = strings.Join([]string{
+		"Usage: profile <action> [args]",
+		"Available actions:",
+		"  " + usageAddProfile,
+		"  " + usageRemoveProfile,
+		"  " + usageListProfiles,
+	}, "\n")
+
+	if len(argsString) == 0 {
+		info(usage)
+		return
+	}
+
+	args := strings.Fields(argsString)
+	action := args[0]
+	actionArgs := args[1:]
+
+	switch action {
+	case "add":
+		c.addProfile(actionArgs)
+	case "remove":
+		c.removeProfile(actionArgs)
+	case "list":
+		c.listProfiles(actionArgs)
+	default:
+		warnf("Unknown sub-command '%s'\n", argsString)
+		fmt.Println(usage)
+	}
+}
+
+const usageAddProfile = "add <name> <provider-identity|@favorite|country=DE> <service-type> [dns=auto|provider|system|1.1.1.1] [disable-kill-switch] [spend-cap=<MYST>]"
+
+func (c *cliApp) addProfile(args []string) {
+	if len(args) < 3 {
+		info("Usage: " + usageAddProfile)
+		return
+	}
+
+	p := contract.ProfileDTO{Name: args[0], ServiceType: args[2]}
+	if strings.HasPrefix(args[1], "country=") {
+		p.Country = strings.ToUpper(strings.TrimPrefix(args[1], "country="))
+	} else {
+		fav, err := c.resolveFavorite(args[1])
+		if err != nil {
+			warn(err)
+			return
+		}
+		p.ProviderID = fav.ProviderID
+	}
+
+	for _, arg := range args[3:] {
+		if err := parseProfileOption(&p, arg); err != nil {
+			warn(err)
+			info("Usage: " + usageAddProfile)
+			return
+		}
+	}
+
+	if err := c.tequilapi.ProfileSave(p); err != nil {
+		warn(err)
+		return
+	}
+	success(fmt.Sprintf("Profile %s saved.", p.Name))
+}
+
+func parseProfileOption(p *contract.ProfileDTO, arg string) error {
+	switch {
+	case strings.HasPrefix(arg, "dns="):
+		dns, err := connection.NewDNSOption(strings.TrimPrefix(arg, "dns="))
+		if err != nil {
+			return errors.Wrap(err, "invalid value")
+		}
+		p.DNS = dns
+	case strings.HasPrefix(arg, "spend-cap="):
+		myst, err := strconv.ParseFloat(strings.TrimPrefix(arg, "spend-cap="), 64)
+		if err != nil || myst <= 0 {
+			return errors.Errorf("invalid spend cap %q", arg)
+		}
+		p.SpendCap = crypto.FloatToBigMyst(myst)
+	case arg == "disable-kill-switch":
+		p.DisableKillSwitch = true
+	default:
+		return errors.Errorf("unexpected arg: %s", arg)
+	}
+	return nil
+}
+
+const usageRemoveProfile = "remove <name>"
+
+func (c *cliApp) removeProfile(args []string) {
+	if len(args) != 1 {
+		info("Usage: " + usageRemoveProfile)
+		return
+	}
+
+	if err := c.tequilapi.ProfileDelete(args[0]); err != nil {
+		warn(err)
+		return
+	}
+	success(fmt.Sprintf("Profile %s removed.", args[0]))
+}
+
+const usageListProfiles = "list"
+
+func (c *cliApp) listProfiles(args []string) {
+	if len(args) > 0 {
+		info("Usage: " + usageListProfiles)
+		return
+	}
+
+	profiles, err := c.tequilapi.Profiles()
+	if err != nil {
+		warn(err)
+		return
+	}
+	for _, p := range profiles {
+		target := p.ProviderID
+		if target == "" {
+			target = "country=" + p.Country
+		}
+		details := []interface{}{target, p.ServiceType}
+		if p.DNS != "" {
+			details = append(details, "dns="+string(p.DNS))
+		}
+		if p.DisableKillSwitch {
+			details = append(details, "disable-kill-switch")
+		}
+		if p.SpendCap != nil {
+			details = append(details, "spend-cap="+money.NewMoney(p.SpendCap, money.CurrencyMyst).String())
+		}
+		status(p.Name, details...)
+	}
+}
+
+// connectProfile connects with the saved profile, using the given or the current consumer identity.
+func (c *cliApp) connectProfile(args []string) {
+	const usage = "connect profile <name> [consumer-identity]"
+	if len(args) < 1 || len(args) > 2 {
+		info("Usage: " + usage)
+		return
+	}
+
+	name := args[0]
+	consumerID := c.currentConsumerID
+	if len(args) == 2 {
+		consumerID = args[1]
+	}
+	if consumerID == "" {
+		id, err := c.tequilapi.CurrentIdentity("", "")
+		if err != nil {
+			warn(err)
+			return
+		}
+		consumerID = id.Address
+	}
+
+	status("CONNECTING", "from:", consumerID, "with profile:", name)
+
+	c.setConnecting(true)
+	_, err := c.tequilapi.ProfileConnect(name, consumerID, config.GetString(config.FlagHermesID))
+	if cancelled := c.setConnecting(false); cancelled {
+		info("Connection cancelled.")
+		return
+	}
+	if err != nil {
+		warn(err)
+		return
+	}
+
+	c.currentConsumerID = consumerID
+
+	success("Connected.")
+}
+
+func getProfileOptionList(tequilapi *tequilapi_client.Client) func(string) []string {
+	return func(line string) []string {
+		profiles, err := tequilapi.Profiles()
+		if err != nil {
+			return nil
+		}
+		var names []string
+		for _, p := range profiles {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+}
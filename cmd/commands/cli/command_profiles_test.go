@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+)
+
+func Test_parseProfileOption(t *testing.T) {
+	var p contract.ProfileDTO
+
+	assert.NoError(t, parseProfileOption(&p, "dns=provider"))
+	assert.NoError(t, parseProfileOption(&p, "disable-kill-switch"))
+	assert.NoError(t, parseProfileOption(&p, "spend-cap=0.5"))
+	assert.Equal(t, contract.ProfileDTO{
+		DNS:               connection.DNSOptionProvider,
+		DisableKillSwitch: true,
+		SpendCap:          big.NewInt(500000000000000000),
+	}, p)
+
+	assert.Error(t, parseProfileOption(&p, "spend-cap=-1"))
+	assert.Error(t, parseProfileOption(&p, "spend-cap=lots"))
+	assert.Error(t, parseProfileOption(&p, "dns=invalid"))
+	assert.Error(t, parseProfileOption(&p, "entry=0x1"))
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chzyer/readline"
+
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+)
+
+const proposalCacheTTL = time.Minute
+
+// proposalCache keeps fetched proposals for autocompletion and refreshes them in the background,
+// so that completion never waits on a tequilapi call.
+type proposalCache struct {
+	fetch func() []contract.ProposalDTO
+	ttl   time.Duration
+
+	mu         sync.Mutex
+	proposals  []contract.ProposalDTO
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+func newProposalCache(fetch func() []contract.ProposalDTO, ttl time.Duration) *proposalCache {
+	return &proposalCache{
+		fetch: fetch,
+		ttl:   ttl,
+	}
+}
+
+// get returns cached proposals and schedules a background refresh when they are stale.
+func (pc *proposalCache) get() []contract.ProposalDTO {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if time.Since(pc.fetchedAt) > pc.ttl {
+		pc.refreshLocked()
+	}
+	return pc.proposals
+}
+
+// refresh schedules a background fetch of proposals.
+func (pc *proposalCache) refresh() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.refreshLocked()
+}
+
+// set replaces cached proposals with freshly fetched ones.
+func (pc *proposalCache) set(proposals []contract.ProposalDTO) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.proposals = proposals
+	pc.fetchedAt = time.Now()
+}
+
+func (pc *proposalCache) refreshLocked() {
+	if pc.refreshing {
+		return
+	}
+	pc.refreshing = true
+
+	go func() {
+		proposals := pc.fetch()
+
+		pc.mu.Lock()
+		defer pc.mu.Unlock()
+		pc.proposals = proposals
+		pc.fetchedAt = time.Now()
+		pc.refreshing = false
+	}()
+}
+
+// matchProposals returns provider IDs of proposals matching given query.
+// Query matches when it is a substring of provider ID or country, or a subsequence of provider ID.
+func matchProposals(proposals []contract.ProposalDTO, query string) []string {
+	query = strings.ToLower(query)
+
+	var providerIDs []string
+	seen := make(map[string]bool)
+	for _, proposal := range proposals {
+		if seen[proposal.ProviderID] {
+			continue
+		}
+
+		providerID := strings.ToLower(proposal.ProviderID)
+		country := strings.ToLower(proposal.ServiceDefinition.LocationOriginate.Country)
+		if strings.Contains(providerID, query) || strings.Contains(country, query) || isSubsequence(query, providerID) {
+			seen[proposal.ProviderID] = true
+			providerIDs = append(providerIDs, proposal.ProviderID)
+		}
+	}
+	return providerIDs
+}
+
+func isSubsequence(query, value string) bool {
+	if query == "" {
+		return true
+	}
+
+	queryRunes := []rune(query)
+	i := 0
+	for _, r := range value {
+		if r == queryRunes[i] {
+			i++
+			if i == len(queryRunes) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// providerQuerySeparator separates a fuzzy query from the provider ID it was completed to,
+// since readline completes by appending to the typed text and never replaces it.
+const providerQuerySeparator = ":"
+
+// providerIDFromArg returns provider ID of an argument completed from a fuzzy query.
+func providerIDFromArg(arg string) string {
+	if i := strings.LastIndex(arg, providerQuerySeparator); i >= 0 {
+		return arg[i+1:]
+	}
+	return arg
+}
+
+// providerCompleter completes provider IDs from cached proposals.
+// Besides regular prefix completion, a query which is not a prefix of any provider ID
+// is completed to every provider it fuzzy matches, in the form of <query>:<provider ID>.
+type providerCompleter struct {
+	*readline.PrefixCompleter
+	proposals *proposalCache
+	argIndex  int
+}
+
+func newProviderCompleter(proposals *proposalCache, argIndex int, pc ...readline.PrefixCompleterInterface) *providerCompleter {
+	completer := &providerCompleter{
+		proposals: proposals,
+		argIndex:  argIndex,
+	}
+	completer.PrefixCompleter = readline.PcItemDynamic(completer.providerIDs, pc...)
+	return completer
+}
+
+// GetDynamicNames returns provider IDs and fuzzy query completions, which readline filters by prefix.
+func (pc *providerCompleter) GetDynamicNames(line []rune) [][]rune {
+	var names [][]rune
+	for _, providerID := range pc.providerIDs(string(line)) {
+		names = append(names, []rune(providerID+" "))
+	}
+	return names
+}
+
+func (pc *providerCompleter) providerIDs(line string) []string {
+	proposals := pc.proposals.get()
+
+	var providerIDs []string
+	seen := make(map[string]bool)
+	for _, proposal := range proposals {
+		if !seen[proposal.ProviderID] {
+			seen[proposal.ProviderID] = true
+			providerIDs = append(providerIDs, proposal.ProviderID)
+		}
+	}
+
+	query, ok := pc.currentArg(line)
+	if !ok || query == "" {
+		return providerIDs
+	}
+	if i := strings.Index(query, providerQuerySeparator); i >= 0 {
+		query = query[:i]
+	} else {
+		for _, providerID := range providerIDs {
+			if strings.HasPrefix(providerID, query) {
+				return providerIDs
+			}
+		}
+	}
+
+	for _, providerID := range matchProposals(proposals, query) {
+		providerIDs = append(providerIDs, query+providerQuerySeparator+providerID)
+	}
+	return providerIDs
+}
+
+// currentArg returns the argument at the provider position, if it is typed already.
+func (pc *providerCompleter) currentArg(line string) (string, bool) {
+	args := strings.Fields(line)
+	if len(args) <= pc.argIndex {
+		return "", false
+	}
+	return args[pc.argIndex], true
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+)
+
+func proposalFrom(providerID, country string) contract.ProposalDTO {
+	return contract.ProposalDTO{
+		ProviderID: providerID,
+		ServiceDefinition: contract.ServiceDefinitionDTO{
+			LocationOriginate: contract.ServiceLocationDTO{Country: country},
+		},
+	}
+}
+
+func TestMatchProposals(t *testing.T) {
+	proposals := []contract.ProposalDTO{
+		proposalFrom("0x123abc", "DE"),
+		proposalFrom("0x123abc", "DE"),
+		proposalFrom("0x456fff", "LT"),
+	}
+
+	assert.Equal(t, []string{"0x123abc"}, matchProposals(proposals, "12a"))
+	assert.Equal(t, []string{"0x123abc"}, matchProposals(proposals, "de"))
+	assert.Equal(t, []string{"0x456fff"}, matchProposals(proposals, "lt"))
+	assert.Equal(t, []string{"0x456fff"}, matchProposals(proposals, "4f"))
+	assert.Equal(t, []string{"0x123abc", "0x456fff"}, matchProposals(proposals, "0x"))
+	assert.Empty(t, matchProposals(proposals, "zz"))
+}
+
+func TestProviderCompleter_CompletesFuzzyMatches(t *testing.T) {
+	cache := newProposalCache(nil, time.Hour)
+	cache.set([]contract.ProposalDTO{
+		proposalFrom("0x123abc", "DE"),
+		proposalFrom("0x456fff", "DE"),
+		proposalFrom("0x789eee", "LT"),
+	})
+	completer := readline.NewPrefixCompleter(
+		readline.PcItem(
+			"connect",
+			readline.PcItemDynamic(
+				func(string) []string { return []string{"0xme"} },
+				newProviderCompleter(cache, 2, readline.PcItem("openvpn")),
+			),
+		),
+	)
+	complete := func(line string) ([]string, int) {
+		candidates, offset := completer.Do([]rune(line), len(line))
+		var result []string
+		for _, candidate := range candidates {
+			result = append(result, string(candidate))
+		}
+		return result, offset
+	}
+
+	candidates, offset := complete("connect 0xme 0x1")
+	assert.Equal(t, []string{"23abc "}, candidates)
+	assert.Equal(t, 3, offset)
+
+	candidates, offset = complete("connect 0xme de")
+	assert.Equal(t, []string{":0x123abc ", ":0x456fff "}, candidates)
+	assert.Equal(t, 2, offset)
+
+	candidates, offset = complete("connect 0xme de:0x4")
+	assert.Equal(t, []string{"56fff "}, candidates)
+	assert.Equal(t, 6, offset)
+
+	candidates, _ = complete("connect 0xme lt:0x789eee op")
+	assert.Equal(t, []string{"envpn "}, candidates)
+}
+
+func TestProviderIDFromArg(t *testing.T) {
+	assert.Equal(t, "0x123abc", providerIDFromArg("0x123abc"))
+	assert.Equal(t, "0x123abc", providerIDFromArg("de:0x123abc"))
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// cliState is the CLI's local state persisted between runs
+type cliState struct {
+	file string
+
+	mu   sync.Mutex
+	data cliStateData
+}
+
+type cliStateData struct {
+	Favorites map[string]favorite `json:"favorites"`
+}
+
+// favorite is a provider saved under an alias
+type favorite struct {
+	ProviderID  string `json:"provider_id"`
+	ServiceType string `json:"service_type,omitempty"`
+}
+
+func newCLIState(file string) *cliState {
+	return &cliState{
+		file: file,
+		data: cliStateData{Favorites: make(map[string]favorite)},
+	}
+}
+
+// load reads state from the state file, missing file is treated as empty state
+func (s *cliState) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, err := ioutil.ReadFile(s.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not read CLI state")
+	}
+
+	data := cliStateData{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return errors.Wrap(err, "could not parse CLI state")
+	}
+	if data.Favorites == nil {
+		data.Favorites = make(map[string]favorite)
+	}
+	s.data = data
+	return nil
+}
+
+func (s *cliState) saveLocked() error {
+	content, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "could not serialize CLI state")
+	}
+	return errors.Wrap(ioutil.WriteFile(s.file, content, 0600), "could not write CLI state")
+}
+
+// addFavorite stores provider under given alias, replacing the previous one
+func (s *cliState) addFavorite(alias string, fav favorite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Favorites[alias] = fav
+	return s.saveLocked()
+}
+
+// removeFavorite removes alias, reporting whether it existed
+func (s *cliState) removeFavorite(alias string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Favorites[alias]; !ok {
+		return false, nil
+	}
+	delete(s.data.Favorites, alias)
+	return true, s.saveLocked()
+}
+
+// favorite returns favorite stored under given alias
+func (s *cliState) favorite(alias string) (favorite, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fav, ok := s.data.Favorites[alias]
+	return fav, ok
+}
+
+// favoriteAliases returns sorted aliases of all favorites
+func (s *cliState) favoriteAliases() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	aliases := make([]string, 0, len(s.data.Favorites))
+	for alias := range s.data.Favorites {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCLIState_FavoritesArePersisted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, ".cli_state")
+
+	state := newCLIState(file)
+	assert.NoError(t, state.load())
+	assert.NoError(t, state.addFavorite("home-de", favorite{ProviderID: "0x1", ServiceType: "wireguard"}))
+	assert.NoError(t, state.addFavorite("work", favorite{ProviderID: "0x2"}))
+
+	restored := newCLIState(file)
+	assert.NoError(t, restored.load())
+	assert.Equal(t, []string{"home-de", "work"}, restored.favoriteAliases())
+	fav, ok := restored.favorite("home-de")
+	assert.True(t, ok)
+	assert.Equal(t, favorite{ProviderID: "0x1", ServiceType: "wireguard"}, fav)
+
+	removed, err := restored.removeFavorite("work")
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = restored.removeFavorite("work")
+	assert.NoError(t, err)
+	assert.False(t, removed)
+}
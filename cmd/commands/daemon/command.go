@@ -41,6 +41,8 @@ func NewCommand() *cli.Command {
 			config.ParseFlagsServiceOpenvpn(ctx)
 			config.ParseFlagsServiceWireguard(ctx)
 			config.ParseFlagsServiceNoop(ctx)
+			config.ParseFlagsServiceHTTPProxy(ctx)
+			config.ParseFlagsServiceShadowsocks(ctx)
 			config.ParseFlagsNode(ctx)
 
 			nodeOptions := node.GetOptions()
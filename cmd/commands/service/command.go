@@ -56,6 +56,8 @@ func NewCommand(licenseCommandName string) *cli.Command {
 			config.ParseFlagsServiceOpenvpn(ctx)
 			config.ParseFlagsServiceWireguard(ctx)
 			config.ParseFlagsServiceNoop(ctx)
+			config.ParseFlagsServiceHTTPProxy(ctx)
+			config.ParseFlagsServiceShadowsocks(ctx)
 			config.ParseFlagsNode(ctx)
 
 			nodeOptions := node.GetOptions()
@@ -86,6 +88,8 @@ func NewCommand(licenseCommandName string) *cli.Command {
 	config.RegisterFlagsServiceOpenvpn(&command.Flags)
 	config.RegisterFlagsServiceWireguard(&command.Flags)
 	config.RegisterFlagsServiceNoop(&command.Flags)
+	config.RegisterFlagsServiceHTTPProxy(&command.Flags)
+	config.RegisterFlagsServiceShadowsocks(&command.Flags)
 
 	return command
 }
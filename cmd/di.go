@@ -33,24 +33,43 @@ import (
 	"github.com/mysteriumnetwork/node/communication/nats"
 	"github.com/mysteriumnetwork/node/config"
 	appconfig "github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/consumer/autoconnect"
+	"github.com/mysteriumnetwork/node/consumer/autoswitch"
 	"github.com/mysteriumnetwork/node/consumer/bandwidth"
+	"github.com/mysteriumnetwork/node/consumer/netchange"
+	"github.com/mysteriumnetwork/node/consumer/profile"
+	"github.com/mysteriumnetwork/node/consumer/schedule"
 	consumer_session "github.com/mysteriumnetwork/node/consumer/session"
 	"github.com/mysteriumnetwork/node/consumer/statistics"
+	"github.com/mysteriumnetwork/node/core/abuse"
+	"github.com/mysteriumnetwork/node/core/accounting"
+	"github.com/mysteriumnetwork/node/core/acl"
 	"github.com/mysteriumnetwork/node/core/auth"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/apidiscovery"
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/discovery/selection"
+	"github.com/mysteriumnetwork/node/core/dnsleak"
+	"github.com/mysteriumnetwork/node/core/hours"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/location"
 	"github.com/mysteriumnetwork/node/core/node"
 	nodevent "github.com/mysteriumnetwork/node/core/node/event"
 	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/core/pricing"
 	"github.com/mysteriumnetwork/node/core/quality"
+	"github.com/mysteriumnetwork/node/core/quota"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/speedtest"
 	"github.com/mysteriumnetwork/node/core/state"
+	"github.com/mysteriumnetwork/node/core/stats"
 	"github.com/mysteriumnetwork/node/core/storage/boltdb"
 	"github.com/mysteriumnetwork/node/core/storage/boltdb/migrations/history"
 	"github.com/mysteriumnetwork/node/core/storage/boltdb/migrator"
+	"github.com/mysteriumnetwork/node/core/throttle"
+	"github.com/mysteriumnetwork/node/core/webhook"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/feedback"
 	"github.com/mysteriumnetwork/node/firewall"
@@ -63,6 +82,7 @@ import (
 	"github.com/mysteriumnetwork/node/metadata"
 	"github.com/mysteriumnetwork/node/mmn"
 	"github.com/mysteriumnetwork/node/nat"
+	"github.com/mysteriumnetwork/node/nat/behavior"
 	"github.com/mysteriumnetwork/node/nat/event"
 	"github.com/mysteriumnetwork/node/nat/mapping"
 	"github.com/mysteriumnetwork/node/nat/traversal"
@@ -97,6 +117,8 @@ type Dependencies struct {
 	Node *Node
 
 	HTTPClient *requests.HTTPClient
+	// ControlHTTPClient sends control traffic to discovery, transactor and hermes, through the upstream proxy when it is configured.
+	ControlHTTPClient *requests.HTTPClient
 
 	NetworkDefinition metadata.NetworkDefinition
 	MysteriumAPI      *mysterium.MysteriumAPI
@@ -108,17 +130,29 @@ type Dependencies struct {
 
 	NATService       nat.NATService
 	Storage          *boltdb.Bolt
-	Keystore         *identity.Keystore
+	Keystore         identity.KeystoreProvider
+	KeystorePKCS11   *identity.KeystorePKCS11
 	IdentityManager  identity.Manager
 	SignerFactory    identity.SignerFactory
+	SessionKeys      *identity.SessionKeys
 	IdentityRegistry identity_registry.IdentityRegistry
 	IdentitySelector identity_selector.Handler
 
 	DiscoveryFactory   service.DiscoveryFactory
 	ProposalRepository proposal.Repository
 	DiscoveryWorker    discovery.Worker
+	// DiscoveryAPI fails over between discovery API endpoints, nil when a single one is configured
+	DiscoveryAPI *apidiscovery.FailoverAPI
+
+	SelectionEngine *selection.Engine
+	// RankedProposalRepository returns proposals ranked by selection engine, best one first
+	RankedProposalRepository proposal.Repository
+	// SavedFilterStorage persists named proposal filters used by proposals listing and selection
+	SavedFilterStorage *proposal.SavedFilterStorage
 
 	QualityClient *quality.MysteriumMORQA
+	// QualityMetrics caches proposals metrics fetched from Quality Oracle in bulk
+	QualityMetrics *quality.MetricsCache
 
 	IPResolver       ip.Resolver
 	LocationResolver *location.Cache
@@ -127,27 +161,46 @@ type Dependencies struct {
 
 	StatisticsReporter               *statistics.SessionStatisticsReporter
 	SessionStorage                   *consumer_session.Storage
+	ProfileStorage                   *profile.Storage
+	ScheduleStorage                  *schedule.Storage
 	SessionConnectivityStatusStorage connectivity.StatusStorage
 
 	EventBus eventbus.EventBus
 
-	ConnectionManager  connection.Manager
-	ConnectionRegistry *connection.Registry
+	ConnectionManager   connection.Manager
+	MultiSessionManager *connection.MultiSessionManager
+	ConnectionRegistry  *connection.Registry
+	AutoConnect         *autoconnect.AutoConnect
+	AutoSwitch          *autoswitch.AutoSwitch
+	NetworkWatcher      *netchange.Watcher
+	Scheduler           *schedule.Scheduler
 
 	ServicesManager *service.Manager
 	ServiceRegistry *service.Registry
 	ServiceSessions *service.SessionPool
 	ServiceFirewall firewall.IncomingTrafficFirewall
-
-	NATPinger  traversal.NATPinger
-	NATTracker *event.Tracker
-	PortPool   *port.Pool
-	PortMapper mapping.PortMapper
+	TrafficQuota    *quota.TrafficQuota
+	TrafficLedger   *accounting.Ledger
+	ServiceStats    *stats.Collector
+	DynamicPricing  *pricing.DynamicPricing
+	SpeedTest       *speedtest.SpeedTest
+	Webhook         *webhook.Webhook
+	OperatingHours  *hours.OperatingHours
+	ResourceMonitor *throttle.Monitor
+	AbuseDetector   *abuse.Detector
+	ConsumerACL     *acl.Storage
+
+	NATPinger       traversal.NATPinger
+	NATTracker      *event.Tracker
+	NATTypeDetector *behavior.Detector
+	PortPool        *port.Pool
+	PortMapper      mapping.PortMapper
 
 	StateKeeper *state.Keeper
 
-	P2PDialer   p2p.Dialer
-	P2PListener p2p.Listener
+	P2PDialer      p2p.Dialer
+	P2PListener    p2p.Listener
+	P2PDiagnostics *p2p.Diagnostics
 
 	Authenticator     *auth.Authenticator
 	JWTAuthenticator  *auth.JWTAuthenticator
@@ -156,6 +209,9 @@ type Dependencies struct {
 	BCHelper          *paymentClient.BlockchainWithRetries
 	ProviderRegistrar *registry.ProviderRegistrar
 
+	RegistrationRetrier *registry.RegistrationRetrier
+	PaymentDefaults     *registry.PaymentDefaults
+
 	LogCollector *logconfig.Collector
 	Reporter     *feedback.Reporter
 
@@ -181,11 +237,13 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 	netutil.LogNetworkStats()
 
 	p2p.RegisterContactUnserializer()
-	di.BrokerConnector = nats.NewBrokerConnector()
 
 	log.Info().Msg("Starting Mysterium Node " + metadata.VersionAsString())
 
 	di.HTTPClient = requests.NewHTTPClient(nodeOptions.BindAddress, requests.DefaultTimeout)
+	if err := di.bootstrapUpstreamProxy(nodeOptions); err != nil {
+		return err
+	}
 
 	// Check early for presence of an already running node
 	tequilaListener, err := di.createTequilaListener(nodeOptions)
@@ -213,7 +271,9 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 		return err
 	}
 
-	di.bootstrapIdentityComponents(nodeOptions)
+	if err := di.bootstrapIdentityComponents(nodeOptions); err != nil {
+		return err
+	}
 
 	if err := di.bootstrapDiscoveryComponents(nodeOptions.Discovery); err != nil {
 		return err
@@ -241,7 +301,7 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 		di.PortMapper = mapping.NewNoopPortMapper(di.EventBus)
 	}
 
-	di.bootstrapP2P(nodeOptions.P2PPorts)
+	di.bootstrapP2P(nodeOptions.P2PPorts, nodeOptions.P2PPortRange, nodeOptions.P2PRelay, nodeOptions.P2PKeyRotationInterval, nodeOptions.P2PObfuscation)
 	di.SessionConnectivityStatusStorage = connectivity.NewStatusStorage()
 
 	if err := di.bootstrapServices(nodeOptions); err != nil {
@@ -252,6 +312,10 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 		return err
 	}
 
+	if err := di.bootstrapSelection(nodeOptions.Discovery.Selection); err != nil {
+		return err
+	}
+
 	if err := di.bootstrapNodeComponents(nodeOptions, tequilaListener); err != nil {
 		return err
 	}
@@ -266,11 +330,83 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 
 	appconfig.Current.EnableEventPublishing(di.EventBus)
 
+	if err := di.bootstrapAutoConnect(nodeOptions.AutoConnect); err != nil {
+		return err
+	}
+	if err := di.bootstrapAutoSwitch(nodeOptions.AutoSwitch); err != nil {
+		return err
+	}
+	di.bootstrapNetworkWatcher(nodeOptions.ConnectionNetworkCheckInterval)
+
+	di.Scheduler = schedule.NewScheduler(di.ScheduleStorage, di.ProfileStorage, di.RankedProposalRepository, di.IdentitySelector, di.ConnectionManager, di.PaymentDefaults)
+	go di.Scheduler.Start()
+
 	log.Info().Msg("Mysterium node started!")
 	return nil
 }
 
-func (di *Dependencies) bootstrapP2P(p2pPorts *port.Range) {
+func (di *Dependencies) bootstrapAutoConnect(options node.OptionsAutoConnect) error {
+	if !options.Enabled {
+		return nil
+	}
+	if options.ProviderID == "" && options.Country == "" {
+		return errors.New("auto connect requires provider or country to be set")
+	}
+
+	di.AutoConnect = autoconnect.NewAutoConnect(
+		di.ConnectionManager,
+		di.RankedProposalRepository,
+		di.IdentitySelector,
+		di.PaymentDefaults,
+		proposal.Target{
+			ProviderID:  options.ProviderID,
+			Country:     options.Country,
+			ServiceType: options.ServiceType,
+		},
+		autoconnect.Options{
+			Identity:   config.GetString(config.FlagIdentity),
+			Passphrase: config.GetString(config.FlagIdentityPassphrase),
+		},
+	)
+	if err := di.AutoConnect.Subscribe(di.EventBus); err != nil {
+		return err
+	}
+	go di.AutoConnect.Start()
+	return nil
+}
+
+func (di *Dependencies) bootstrapAutoSwitch(options node.OptionsAutoSwitch) error {
+	if !options.Enabled {
+		return nil
+	}
+
+	di.AutoSwitch = autoswitch.NewAutoSwitch(
+		di.MultiSessionManager,
+		di.RankedProposalRepository,
+		di.EventBus,
+		autoswitch.Thresholds{
+			MaxPacketLoss: options.MaxPacketLoss,
+			MaxLatency:    options.MaxLatency,
+			Period:        options.Period,
+		},
+	)
+	if err := di.AutoSwitch.Subscribe(di.EventBus); err != nil {
+		return err
+	}
+	go di.AutoSwitch.Start()
+	return nil
+}
+
+func (di *Dependencies) bootstrapNetworkWatcher(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	di.NetworkWatcher = netchange.NewWatcher(di.ConnectionManager, di.EventBus, interval)
+	go di.NetworkWatcher.Start()
+}
+
+func (di *Dependencies) bootstrapP2P(p2pPorts, p2pPortRange *port.Range, relayOptions node.OptionsP2PRelay, keyRotationInterval time.Duration, obfuscation bool) {
 	portPool := di.PortPool
 	natPinger := di.NATPinger
 	identityVerifier := identity.NewVerifierSigned()
@@ -278,10 +414,22 @@ func (di *Dependencies) bootstrapP2P(p2pPorts *port.Range) {
 		log.Info().Msgf("Fixed p2p service port range (%s) configured, using custom port pool", p2pPorts)
 		portPool = port.NewFixedRangePool(*p2pPorts)
 		natPinger = traversal.NewNoopPinger()
+	} else if p2pPortRange.IsSpecified() {
+		log.Info().Msgf("P2P port range (%s) configured, using custom port pool", p2pPortRange)
+		portPool = port.NewFixedRangePool(*p2pPortRange)
+	} else if obfuscation {
+		log.Info().Msg("P2P obfuscation enabled, using random ports from the whole port range")
+		portPool = port.NewFixedRangePool(port.Range{Start: 1024, End: 65535})
+	}
+
+	relay := p2p.RelayConfig{Address: relayOptions.Address, ServiceTraffic: relayOptions.ServiceTraffic}
+	if relay.Address != "" {
+		log.Info().Msgf("P2P relay %s configured, it is used when peer can't be reached directly", relay.Address)
 	}
 
-	di.P2PListener = p2p.NewListener(di.BrokerConnection, di.SignerFactory, identityVerifier, di.IPResolver, natPinger, portPool, di.PortMapper)
-	di.P2PDialer = p2p.NewDialer(di.BrokerConnector, di.SignerFactory, identityVerifier, di.IPResolver, natPinger, portPool)
+	di.P2PDiagnostics = p2p.NewDiagnostics(di.EventBus, di.NATTypeDetector)
+	di.P2PListener = p2p.NewListener(di.BrokerConnection, di.SignerFactory, identityVerifier, di.IPResolver, natPinger, portPool, di.PortMapper, relay, di.P2PDiagnostics, obfuscation)
+	di.P2PDialer = p2p.NewDialer(di.BrokerConnector, di.SignerFactory, identityVerifier, di.IPResolver, natPinger, portPool, relay, di.P2PDiagnostics, keyRotationInterval, obfuscation)
 }
 
 func (di *Dependencies) createTequilaListener(nodeOptions node.Options) (net.Listener, error) {
@@ -350,6 +498,24 @@ func (di *Dependencies) Shutdown() (err error) {
 		}
 	}()
 
+	// Stop auto connect, auto switch, network watcher and scheduler first, so that they do not reconnect while node is being killed.
+	if di.AutoConnect != nil {
+		di.AutoConnect.Stop()
+	}
+	if di.AutoSwitch != nil {
+		di.AutoSwitch.Stop()
+	}
+	if di.NetworkWatcher != nil {
+		di.NetworkWatcher.Stop()
+	}
+	if di.Scheduler != nil {
+		di.Scheduler.Stop()
+	}
+
+	if di.MultiSessionManager != nil {
+		di.MultiSessionManager.DisconnectAll()
+	}
+
 	// Kill node first which includes current active VPN connection cleanup.
 	if di.Node != nil {
 		if err := di.Node.Kill(); err != nil {
@@ -357,12 +523,35 @@ func (di *Dependencies) Shutdown() (err error) {
 		}
 	}
 
+	if di.TrafficQuota != nil {
+		di.TrafficQuota.Stop()
+	}
+	if di.DynamicPricing != nil {
+		di.DynamicPricing.Stop()
+	}
+	if di.SpeedTest != nil {
+		di.SpeedTest.Stop()
+	}
+	if di.OperatingHours != nil {
+		di.OperatingHours.Stop()
+	}
+	if di.ResourceMonitor != nil {
+		di.ResourceMonitor.Stop()
+	}
+	if di.AbuseDetector != nil {
+		di.AbuseDetector.Stop()
+	}
 	if di.ServicesManager != nil {
 		if err := di.ServicesManager.Kill(); err != nil {
 			errs = append(errs, err)
 		}
 	}
 
+	// Stopped after services, so that ended sessions are delivered.
+	if di.Webhook != nil {
+		di.Webhook.Stop()
+	}
+
 	if di.PolicyOracle != nil {
 		di.PolicyOracle.Stop()
 	}
@@ -372,6 +561,9 @@ func (di *Dependencies) Shutdown() (err error) {
 			errs = append(errs, err)
 		}
 	}
+	if di.DiscoveryAPI != nil {
+		di.DiscoveryAPI.Stop()
+	}
 	if di.DiscoveryWorker != nil {
 		di.DiscoveryWorker.Stop()
 	}
@@ -388,11 +580,22 @@ func (di *Dependencies) Shutdown() (err error) {
 	}
 	firewall.Reset()
 
+	if di.TrafficLedger != nil {
+		di.TrafficLedger.Stop()
+	}
+	if di.ServiceStats != nil {
+		di.ServiceStats.Stop()
+	}
 	if di.Storage != nil {
 		if err := di.Storage.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
+	if di.KeystorePKCS11 != nil {
+		if err := di.KeystorePKCS11.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
 	return nil
 }
@@ -420,7 +623,22 @@ func (di *Dependencies) bootstrapStorage(path string) error {
 	di.ConsumerTotalsStorage = pingpong.NewConsumerTotalsStorage(di.Storage, di.EventBus)
 	di.HermesPromiseStorage = pingpong.NewHermesPromiseStorage(di.Storage)
 	di.SessionStorage = consumer_session.NewSessionStorage(di.Storage)
+	di.ProfileStorage = profile.NewStorage(di.Storage)
+	di.ScheduleStorage = schedule.NewStorage(di.Storage)
+	di.ConsumerACL = acl.NewStorage(di.Storage)
 	di.SettlementHistoryStorage = pingpong.NewSettlementHistoryStorage(di.Storage)
+	di.TrafficLedger = accounting.NewLedger(di.Storage, time.Minute)
+	di.ServiceStats = stats.NewCollector(di.Storage, time.Minute)
+	if err := di.SessionStorage.RestoreInterrupted(); err != nil {
+		log.Warn().Err(err).Msg("Failed to restore sessions interrupted by node restart")
+	}
+	if err := di.TrafficLedger.Subscribe(di.EventBus); err != nil {
+		return err
+	}
+	if err := di.ServiceStats.Subscribe(di.EventBus); err != nil {
+		return err
+	}
+	di.ServiceStats.Start()
 	return di.SessionStorage.Subscribe(di.EventBus)
 }
 
@@ -438,7 +656,7 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 	}
 
 	di.Transactor = registry.NewTransactor(
-		di.HTTPClient,
+		di.ControlHTTPClient,
 		nodeOptions.Transactor.TransactorEndpointAddress,
 		nodeOptions.Transactor.RegistryAddress,
 		nodeOptions.Hermes.HermesID,
@@ -448,6 +666,22 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 		di.BCHelper,
 	)
 
+	di.RegistrationRetrier = registry.NewRegistrationRetrier(
+		di.Transactor,
+		di.IdentityRegistry,
+		registry.NewRegistrationStatusStorage(di.Storage),
+		di.EventBus,
+		registry.RegistrationRetrierConfig{
+			MaxAttempts:  nodeOptions.Transactor.RegistrationRetryMaxAttempts,
+			InitialDelay: nodeOptions.Transactor.RegistrationRetryDelay,
+			MaxDelay:     nodeOptions.Transactor.RegistrationRetryMaxDelay,
+			StuckTimeout: nodeOptions.Transactor.RegistrationRetryStuckTimeout,
+		},
+	)
+	if err := di.RegistrationRetrier.Subscribe(di.EventBus); err != nil {
+		return err
+	}
+
 	if err := di.bootstrapHermesPromiseSettler(nodeOptions); err != nil {
 		return err
 	}
@@ -467,7 +701,7 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 		return err
 	}
 
-	di.HermesCaller = pingpong.NewHermesCaller(di.HTTPClient, hermesURL)
+	di.HermesCaller = pingpong.NewHermesCaller(di.ControlHTTPClient, hermesURL)
 	di.ConsumerBalanceTracker = pingpong.NewConsumerBalanceTracker(
 		di.EventBus,
 		common.HexToAddress(nodeOptions.Payments.MystSCAddress),
@@ -488,7 +722,7 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 	di.HermesPromiseHandler = pingpong.NewHermesPromiseHandler(pingpong.HermesPromiseHandlerDeps{
 		HermesPromiseStorage: di.HermesPromiseStorage,
 		HermesCallerFactory: func(hermesURL string) pingpong.HermesHTTPRequester {
-			return pingpong.NewHermesCaller(di.HTTPClient, hermesURL)
+			return pingpong.NewHermesCaller(di.ControlHTTPClient, hermesURL)
 		},
 		HermesURLGetter: di.HermesURLGetter,
 		FeeProvider:     di.Transactor,
@@ -501,28 +735,111 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 	}
 
 	di.ConnectionRegistry = connection.NewRegistry()
-	di.ConnectionManager = connection.NewManager(
-		pingpong.ExchangeFactoryFunc(
-			di.Keystore,
-			di.SignerFactory,
-			di.ConsumerTotalsStorage,
-			nodeOptions.Transactor.ChannelImplementation,
-			nodeOptions.Transactor.RegistryAddress,
-			di.EventBus,
-			nodeOptions.Payments.ConsumerDataLeewayMegabytes,
-		),
-		di.ConnectionRegistry.CreateConnection,
+	var dnsLeakDetector dnsleak.Detector
+	if nodeOptions.Location.DNSLeakDetectorURL != "" {
+		dnsLeakDetector = dnsleak.NewDetector(di.HTTPClient, nodeOptions.Location.DNSLeakCanaryDomain, nodeOptions.Location.DNSLeakDetectorURL)
+	}
+	connectionConfig := connection.DefaultConfig()
+	connectionConfig.Timeouts = connection.TimeoutConfig{
+		Connect:       nodeOptions.ConnectTimeouts.Connect,
+		ProposalFetch: nodeOptions.ConnectTimeouts.ProposalFetch,
+		P2PDial:       nodeOptions.ConnectTimeouts.P2PDial,
+		SessionCreate: nodeOptions.ConnectTimeouts.SessionCreate,
+		TunnelUp:      nodeOptions.ConnectTimeouts.TunnelUp,
+	}
+	connectionConfig.Retry = connection.RetryPolicy{
+		Attempts: nodeOptions.ConnectRetry.Attempts,
+		Backoff:  nodeOptions.ConnectRetry.Backoff,
+		Jitter:   nodeOptions.ConnectRetry.Jitter,
+	}
+	if err := connectionConfig.Retry.Validate(); err != nil {
+		return errors.Wrap(err, "invalid connect retry options")
+	}
+	connectionConfig.Probe = connection.ProbeConfig{
+		Candidates: nodeOptions.ConnectProbe.Candidates,
+		Timeout:    nodeOptions.ConnectProbe.Timeout,
+	}
+	if nodeOptions.ConnectionMaxBandwidth != "" {
+		maxBandwidth, err := datasize.ParseBitSpeed(nodeOptions.ConnectionMaxBandwidth)
+		if err != nil {
+			return errors.Wrap(err, "invalid connection bandwidth limit")
+		}
+		connectionConfig.MaxBandwidth = maxBandwidth
+	}
+	connectionConfig.IdleTimeout = nodeOptions.ConnectionIdleTimeout
+	connectionConfig.KeyRotation.Interval = nodeOptions.ConnectionKeyRotationInterval
+	if nodeOptions.P2PKeepAlive.Interval > 0 {
+		connectionConfig.KeepAlive.SendInterval = nodeOptions.P2PKeepAlive.Interval
+	}
+	if nodeOptions.P2PKeepAlive.Timeout > 0 {
+		connectionConfig.KeepAlive.SendTimeout = nodeOptions.P2PKeepAlive.Timeout
+	}
+	if nodeOptions.P2PKeepAlive.MaxFailures > 0 {
+		connectionConfig.KeepAlive.MaxSendErrCount = nodeOptions.P2PKeepAlive.MaxFailures
+	}
+	newConnectionManager := func(eventBus eventbus.EventBus, detector dnsleak.Detector, connectionConfig connection.Config) (connection.Manager, error) {
+		manager := connection.NewManager(
+			pingpong.ExchangeFactoryFunc(
+				di.Keystore,
+				di.SignerFactory,
+				di.ConsumerTotalsStorage,
+				nodeOptions.Transactor.ChannelImplementation,
+				nodeOptions.Transactor.RegistryAddress,
+				di.EventBus,
+				nodeOptions.Payments.ConsumerDataLeewayMegabytes,
+			),
+			di.ConnectionRegistry.CreateConnection,
+			eventBus,
+			di.IPResolver,
+			di.LocationResolver,
+			connectionConfig,
+			connection.DefaultStatsReportInterval,
+			connection.NewValidator(
+				di.ConsumerBalanceTracker,
+				di.IdentityManager,
+			),
+			di.P2PDialer,
+			detector,
+			di.SessionStorage,
+		)
+		if err := manager.Subscribe(di.EventBus); err != nil {
+			return nil, errors.Wrap(err, "could not subscribe connection manager to relevant events")
+		}
+		return manager, nil
+	}
+	// DNS queries and consumer traffic go through the exit hop, entry hop is neither checked for leaks nor limited.
+	// Entry hop is torn down together with the exit hop, so it does not need idle timeout either.
+	entryConnectionConfig := connectionConfig
+	entryConnectionConfig.MaxBandwidth = 0
+	entryConnectionConfig.IdleTimeout = 0
+	entryManager, err := newConnectionManager(connection.NewEntryHopEventBus(di.EventBus), nil, entryConnectionConfig)
+	if err != nil {
+		return err
+	}
+	exitManager, err := newConnectionManager(di.EventBus, dnsLeakDetector, connectionConfig)
+	if err != nil {
+		return err
+	}
+	multiHopManager := connection.NewMultiHopManager(entryManager, exitManager)
+	if err := multiHopManager.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe multi-hop connection manager to relevant events")
+	}
+	// Additional sessions keep the main connection routes and DNS, so they are not checked for DNS leaks.
+	// Standby connections take over the main connection when switching providers, so they are.
+	di.MultiSessionManager = connection.NewMultiSessionManager(
+		multiHopManager,
 		di.EventBus,
-		di.IPResolver,
-		di.LocationResolver,
-		connection.DefaultConfig(),
-		connection.DefaultStatsReportInterval,
-		connection.NewValidator(
-			di.ConsumerBalanceTracker,
-			di.IdentityManager,
-		),
-		di.P2PDialer,
+		func(bus eventbus.EventBus) (connection.Manager, error) {
+			return newConnectionManager(bus, nil, connectionConfig)
+		},
+		func(bus eventbus.EventBus) (connection.Manager, error) {
+			return newConnectionManager(bus, dnsLeakDetector, connectionConfig)
+		},
 	)
+	if err := di.MultiSessionManager.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe multi-session connection manager to relevant events")
+	}
+	di.ConnectionManager = di.MultiSessionManager
 
 	di.LogCollector = logconfig.NewCollector(&logconfig.CurrentLogOptions)
 	reporter, err := feedback.NewReporter(di.LogCollector, di.IdentityManager, nodeOptions.FeedbackURL)
@@ -562,28 +879,40 @@ func (di *Dependencies) bootstrapTequilapi(nodeOptions node.Options, listener ne
 		return tequilapi.NewNoopAPIServer(), nil
 	}
 
-	router := tequilapi.NewAPIRouter()
+	router := tequilapi.NewAPIRouter(di.DiscoveryAPI)
 	tequilapi_endpoints.AddRoutesForDocs(router)
 	tequilapi_endpoints.AddRouteForStop(router, utils.SoftKiller(di.Shutdown))
 	tequilapi_endpoints.AddRoutesForAuthentication(router, di.Authenticator, di.JWTAuthenticator)
-	tequilapi_endpoints.AddRoutesForIdentities(router, di.IdentityManager, di.IdentitySelector, di.IdentityRegistry, di.ConsumerBalanceTracker, di.ChannelAddressCalculator, di.HermesChannelRepository, di.BCHelper, di.Transactor)
-	tequilapi_endpoints.AddRoutesForConnection(router, di.ConnectionManager, di.StateKeeper, di.ProposalRepository, di.IdentityRegistry)
+	tequilapi_endpoints.AddRoutesForIdentities(router, di.IdentityManager, di.IdentitySelector, di.IdentityRegistry, di.ConsumerBalanceTracker, di.ChannelAddressCalculator, di.HermesChannelRepository, di.BCHelper, di.Transactor, di.StateKeeper, di.PaymentDefaults, di.SessionStorage)
+	tequilapi_endpoints.AddRoutesForPaymentConfig(router, di.IdentityManager, di.PaymentDefaults)
+	tequilapi_endpoints.AddRoutesForConnection(router, di.ConnectionManager, di.StateKeeper, di.ProposalRepository, di.IdentityRegistry, nodeOptions.ConnectTimeouts.ProposalFetch, di.PaymentDefaults)
+	tequilapi_endpoints.AddRoutesForConnectionSessions(router, di.MultiSessionManager, di.StateKeeper, di.ProposalRepository, di.IdentityRegistry, nodeOptions.ConnectTimeouts.ProposalFetch, di.PaymentDefaults)
+	tequilapi_endpoints.AddRoutesForConnectionSwitch(router, di.MultiSessionManager, di.StateKeeper, di.ProposalRepository, di.IdentityRegistry, nodeOptions.ConnectTimeouts.ProposalFetch, di.PaymentDefaults)
+	tequilapi_endpoints.AddRoutesForProfiles(router, di.ProfileStorage, di.ConnectionManager, di.StateKeeper, di.RankedProposalRepository, di.IdentityRegistry, di.PaymentDefaults)
+	tequilapi_endpoints.AddRoutesForSchedules(router, di.ScheduleStorage, di.ProfileStorage)
+	tequilapi_endpoints.AddRoutesForSelection(router, di.SelectionEngine, config.Current)
 	tequilapi_endpoints.AddRoutesForSessions(router, di.SessionStorage)
 	tequilapi_endpoints.AddRoutesForConnectionLocation(router, di.IPResolver, di.LocationResolver, di.LocationResolver)
-	tequilapi_endpoints.AddRoutesForProposals(router, di.ProposalRepository, di.QualityClient)
+	tequilapi_endpoints.AddRoutesForProposals(router, di.ProposalRepository, di.QualityMetrics, di.SavedFilterStorage)
 	tequilapi_endpoints.AddRoutesForService(router, di.ServicesManager, services.JSONParsersByType)
+	tequilapi_endpoints.AddRoutesForServiceStats(router, di.ServiceStats)
+	tequilapi_endpoints.AddRoutesForConsumerACL(router, di.ConsumerACL)
 	tequilapi_endpoints.AddRoutesForPayout(router, di.IdentityManager, di.SignerFactory, di.MysteriumAPI)
 	tequilapi_endpoints.AddRoutesForAccessPolicies(di.HTTPClient, router, config.GetString(config.FlagAccessPolicyAddress))
-	tequilapi_endpoints.AddRoutesForNAT(router, di.StateKeeper)
-	tequilapi_endpoints.AddRoutesForTransactor(router, di.Transactor, di.HermesPromiseSettler, di.SettlementHistoryStorage, common.HexToAddress(nodeOptions.Hermes.HermesID))
+	tequilapi_endpoints.AddRoutesForNAT(router, di.StateKeeper, di.P2PDiagnostics, di.PortMapper)
+	tequilapi_endpoints.AddRoutesForTransactor(router, di.Transactor, di.HermesPromiseSettler, di.SettlementHistoryStorage, common.HexToAddress(nodeOptions.Hermes.HermesID), di.PaymentDefaults)
 	tequilapi_endpoints.AddRoutesForConfig(router)
 	tequilapi_endpoints.AddRoutesForMMN(router, di.MMN)
 	tequilapi_endpoints.AddRoutesForFeedback(router, di.Reporter)
+	tequilapi_endpoints.AddRoutesForLogs(router, logconfig.LogStreams)
 	tequilapi_endpoints.AddRoutesForConnectivityStatus(router, di.SessionConnectivityStatusStorage)
 	tequilapi_endpoints.AddRoutesForCurrencyExchange(router, di.Exchange)
 	if err := tequilapi_endpoints.AddRoutesForSSE(router, di.StateKeeper, di.EventBus); err != nil {
 		return nil, err
 	}
+	if err := tequilapi_endpoints.AddRoutesForRegistrationWatch(router, di.IdentityManager, di.IdentityRegistry, di.EventBus); err != nil {
+		return nil, err
+	}
 
 	if config.GetBool(config.FlagPProfEnable) {
 		tequilapi_endpoints.AddRoutesForPProf(router)
@@ -622,16 +951,20 @@ func (di *Dependencies) bootstrapNetworkComponents(options node.Options) (err er
 
 	di.NetworkDefinition = network
 
-	di.MysteriumAPI = mysterium.NewClient(di.HTTPClient, network.MysteriumAPIAddress)
+	di.MysteriumAPI = mysterium.NewClient(di.ControlHTTPClient, network.MysteriumAPIAddress)
 
-	brokerURL, err := nats.ParseServerURI(di.NetworkDefinition.BrokerAddress)
-	if err != nil {
-		return err
+	var brokerURLs []string
+	for _, address := range append([]string{di.NetworkDefinition.BrokerAddress}, optionsNetwork.BrokerFallbackAddresses...) {
+		brokerURL, err := nats.ParseServerURI(address)
+		if err != nil {
+			return err
+		}
+		brokerURLs = append(brokerURLs, brokerURL.String())
 	}
-	if _, err := di.ServiceFirewall.AllowURLAccess(brokerURL.String()); err != nil {
+	if _, err := di.ServiceFirewall.AllowURLAccess(brokerURLs...); err != nil {
 		return err
 	}
-	if di.BrokerConnection, err = di.BrokerConnector.Connect(brokerURL.String()); err != nil {
+	if di.BrokerConnection, err = di.BrokerConnector.Connect(brokerURLs...); err != nil {
 		return err
 	}
 
@@ -673,6 +1006,11 @@ func (di *Dependencies) bootstrapNetworkComponents(options node.Options) (err er
 		return err
 	}
 
+	di.PaymentDefaults = registry.NewPaymentDefaults(registry.NewPaymentPreferencesStorage(di.Storage), common.HexToAddress(options.Hermes.HermesID))
+	if err := di.PaymentDefaults.Subscribe(di.EventBus); err != nil {
+		return err
+	}
+
 	return di.IdentityRegistry.Subscribe(di.EventBus)
 }
 
@@ -680,28 +1018,87 @@ func (di *Dependencies) bootstrapEventBus() {
 	di.EventBus = eventbus.New()
 }
 
-func (di *Dependencies) bootstrapIdentityComponents(options node.Options) {
-	var ks *keystore.KeyStore
-	if options.Keystore.UseLightweight {
-		log.Debug().Msg("Using lightweight keystore")
-		ks = keystore.NewKeyStore(options.Directories.Keystore, keystore.LightScryptN, keystore.LightScryptP)
-	} else {
-		log.Debug().Msg("Using heavyweight keystore")
-		ks = keystore.NewKeyStore(options.Directories.Keystore, keystore.StandardScryptN, keystore.StandardScryptP)
+func (di *Dependencies) bootstrapIdentityComponents(options node.Options) error {
+	if err := di.bootstrapKeystore(options.Keystore, options.Directories.Keystore); err != nil {
+		return err
 	}
-
-	di.Keystore = identity.NewKeystoreFilesystem(options.Directories.Keystore, ks)
 	di.IdentityManager = identity.NewIdentityManager(di.Keystore, di.EventBus)
 	di.SignerFactory = func(id identity.Identity) identity.Signer {
 		return identity.NewSigner(di.Keystore, id)
 	}
+	// Invoices are always signed, as nodes announce p2p.CapabilitySignedInvoices to consumers.
+	sessionKeyTTL := options.Payments.ProviderSessionKeyTTL
+	if sessionKeyTTL <= 0 {
+		sessionKeyTTL = config.FlagPaymentsProviderSessionKeyTTL.Value
+		log.Warn().Msgf("Provider session key TTL %s is not positive, using %s", options.Payments.ProviderSessionKeyTTL, sessionKeyTTL)
+	}
+	di.SessionKeys = identity.NewSessionKeys(di.SignerFactory, sessionKeyTTL)
+	if err := di.SessionKeys.Subscribe(di.EventBus); err != nil {
+		return err
+	}
 	di.IdentitySelector = identity_selector.NewHandler(
 		di.IdentityManager,
 		di.MysteriumAPI,
 		identity.NewIdentityCache(options.Directories.Keystore, "remember.json"),
 		di.SignerFactory,
 	)
+	return nil
+}
+
+func (di *Dependencies) bootstrapKeystore(options node.OptionsKeystore, directory string) error {
+	switch options.Provider {
+	case identity.KeystoreProviderFilesystem, "":
+		return di.bootstrapKeystoreFilesystem(options, directory)
+	}
+
+	if options.RemoteSigner.URL != "" {
+		return fmt.Errorf("remote signer can be used with %s keystore only", identity.KeystoreProviderFilesystem)
+	}
 
+	switch options.Provider {
+	case identity.KeystoreProviderMemory:
+		log.Warn().Msg("Using in-memory keystore, identities will be lost once node stops")
+		di.Keystore = identity.NewKeystoreMemory()
+	case identity.KeystoreProviderPKCS11:
+		ks, err := identity.OpenKeystorePKCS11(options.PKCS11.Module, options.PKCS11.Token, options.PKCS11.PIN)
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("Using keystore kept in HSM by %s", options.PKCS11.Module)
+		di.KeystorePKCS11 = ks
+		di.Keystore = ks
+	default:
+		return fmt.Errorf("unknown keystore provider: %q", options.Provider)
+	}
+	return nil
+}
+
+func (di *Dependencies) bootstrapKeystoreFilesystem(options node.OptionsKeystore, directory string) error {
+	scryptN, scryptP, err := identity.ScryptParams(options.UseLightweight, options.ScryptN, options.ScryptP)
+	if err != nil {
+		return err
+	}
+	log.Debug().Msgf("Using keystore with scrypt N=%d, p=%d", scryptN, scryptP)
+	ks := keystore.NewKeyStore(directory, scryptN, scryptP)
+
+	fsKeystore := identity.NewKeystoreFilesystem(directory, ks)
+	di.Keystore = fsKeystore
+	if remote := options.RemoteSigner; remote.URL != "" {
+		if !common.IsHexAddress(remote.Identity) {
+			return fmt.Errorf("invalid remote signer identity: %q", remote.Identity)
+		}
+		signer, err := identity.NewRemoteSigner(remote.URL, common.HexToAddress(remote.Identity), identity.RemoteSignerTLS{
+			CertFile: remote.CertFile,
+			KeyFile:  remote.KeyFile,
+			CAFile:   remote.CAFile,
+		})
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("Using identity %s kept by remote signer %s", remote.Identity, remote.URL)
+		fsKeystore.UseExternalSigner(signer)
+	}
+	return nil
 }
 
 func (di *Dependencies) bootstrapQualityComponents(bindAddress string, options node.OptionsQuality) (err error) {
@@ -713,6 +1110,7 @@ func (di *Dependencies) bootstrapQualityComponents(bindAddress string, options n
 	}
 	di.QualityClient = quality.NewMorqaClient(bindAddress, options.Address, di.SignerFactory, 10*time.Second)
 	go di.QualityClient.Start()
+	di.QualityMetrics = quality.NewMetricsCache(di.QualityClient, options.MetricsTTL)
 
 	var transport quality.Transport
 	switch options.Type {
@@ -820,6 +1218,10 @@ func (di *Dependencies) bootstrapNATComponents(options node.Options) error {
 	} else {
 		di.NATPinger = &traversal.NoopPinger{}
 	}
+
+	di.NATTypeDetector = behavior.NewDetector(config.GetStringSlice(config.FlagNATSTUNServers), time.Hour)
+	// Detect NAT type in advance, so that neither starting services nor ranking proposals waits for it.
+	go di.NATTypeDetector.NATType()
 	return nil
 }
 
@@ -848,6 +1250,30 @@ func (di *Dependencies) bootstrapFirewall(options node.OptionsFirewall) error {
 	return nil
 }
 
+// bootstrapUpstreamProxy sends control traffic and broker connections through the upstream proxy when it is configured,
+// other traffic, e.g. public IP detection, keeps going directly.
+func (di *Dependencies) bootstrapUpstreamProxy(nodeOptions node.Options) error {
+	if nodeOptions.UpstreamProxy == "" {
+		di.ControlHTTPClient = di.HTTPClient
+		di.BrokerConnector = nats.NewBrokerConnector()
+		return nil
+	}
+
+	proxyURL, err := requests.ParseProxyURL(nodeOptions.UpstreamProxy)
+	if err != nil {
+		return err
+	}
+	dialer, err := requests.NewProxyDialer(proxyURL, &net.Dialer{Timeout: 5 * time.Second})
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Sending control traffic through upstream proxy %s", proxyURL.Host)
+	di.ControlHTTPClient = requests.NewProxiedHTTPClient(nodeOptions.BindAddress, proxyURL, requests.DefaultTimeout)
+	di.BrokerConnector = nats.NewBrokerConnectorWithDialer(dialer)
+	return nil
+}
+
 func (di *Dependencies) handleConnStateChange() error {
 	if di.HTTPClient == nil {
 		return errors.New("HTTPClient is not initialized")
@@ -867,6 +1293,9 @@ func (di *Dependencies) handleConnStateChange() error {
 
 			log.Info().Msg("Reconnecting HTTP clients due to VPN connection state change")
 			di.HTTPClient.Reconnect()
+			if di.ControlHTTPClient != di.HTTPClient {
+				di.ControlHTTPClient.Reconnect()
+			}
 			di.QualityClient.Reconnect()
 
 			if err := di.EtherClient.Reconnect(); err != nil {
@@ -18,26 +18,41 @@
 package cmd
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/core/abuse"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/hours"
 	"github.com/mysteriumnetwork/node/core/node"
 	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/core/pricing"
+	"github.com/mysteriumnetwork/node/core/quota"
 	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/core/speedtest"
+	"github.com/mysteriumnetwork/node/core/throttle"
+	"github.com/mysteriumnetwork/node/core/webhook"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity/registry"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/mmn"
 	"github.com/mysteriumnetwork/node/nat"
 	"github.com/mysteriumnetwork/node/p2p"
+	service_httpproxy "github.com/mysteriumnetwork/node/services/httpproxy"
+	httpproxy_connection "github.com/mysteriumnetwork/node/services/httpproxy/connection"
+	httpproxy_service "github.com/mysteriumnetwork/node/services/httpproxy/service"
 	service_noop "github.com/mysteriumnetwork/node/services/noop"
 	service_openvpn "github.com/mysteriumnetwork/node/services/openvpn"
 	openvpn_discovery "github.com/mysteriumnetwork/node/services/openvpn/discovery"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn/service"
+	service_shadowsocks "github.com/mysteriumnetwork/node/services/shadowsocks"
+	shadowsocks_connection "github.com/mysteriumnetwork/node/services/shadowsocks/connection"
+	shadowsocks_service "github.com/mysteriumnetwork/node/services/shadowsocks/service"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 	wireguard_connection "github.com/mysteriumnetwork/node/services/wireguard/connection"
 	"github.com/mysteriumnetwork/node/services/wireguard/endpoint"
@@ -47,6 +62,7 @@ import (
 	pingpong_noop "github.com/mysteriumnetwork/node/session/pingpong/noop"
 	"github.com/mysteriumnetwork/node/ui"
 	uinoop "github.com/mysteriumnetwork/node/ui/noop"
+	"github.com/mysteriumnetwork/node/utils/stringutil"
 
 	"github.com/rs/zerolog/log"
 
@@ -68,6 +84,8 @@ func (di *Dependencies) bootstrapServices(nodeOptions node.Options) error {
 	di.bootstrapServiceOpenvpn(nodeOptions)
 	di.bootstrapServiceNoop(nodeOptions)
 	di.bootstrapServiceWireguard(nodeOptions)
+	di.bootstrapServiceHTTPProxy(nodeOptions)
+	di.bootstrapServiceShadowsocks(nodeOptions)
 
 	return nil
 }
@@ -81,16 +99,21 @@ func (di *Dependencies) bootstrapServiceWireguard(nodeOptions node.Options) {
 				return nil, market.ServiceProposal{}, err
 			}
 
+			sessionBandwidth, advertisedBandwidth, err := parseSessionBandwidth(nodeOptions)
+			if err != nil {
+				return nil, market.ServiceProposal{}, err
+			}
+
 			wgOptions := serviceOptions.(wireguard_service.Options)
+			if wgOptions.SessionBandwidth == 0 {
+				wgOptions.SessionBandwidth = sessionBandwidth
+			}
 
 			// TODO: Use global port pool once migrated to p2p.
-			var portPool port.ServicePortSupplier
 			if wgOptions.Ports.IsSpecified() {
 				log.Info().Msgf("Fixed service port range (%s) configured, using custom port pool", wgOptions.Ports)
-				portPool = port.NewFixedRangePool(*wgOptions.Ports)
-			} else {
-				portPool = port.NewPool()
 			}
+			portPool := wireguard_service.NewPortPool(wgOptions.Ports)
 
 			svc := wireguard_service.NewManager(
 				di.IPResolver,
@@ -101,8 +124,9 @@ func (di *Dependencies) bootstrapServiceWireguard(nodeOptions node.Options) {
 				wgOptions,
 				portPool,
 				di.ServiceFirewall,
+				di.PortMapper,
 			)
-			return svc, wireguard_service.GetProposal(loc), nil
+			return svc, wireguard_service.GetProposal(loc, advertisedBandwidth), nil
 		},
 	)
 }
@@ -118,8 +142,14 @@ func (di *Dependencies) bootstrapServiceOpenvpn(nodeOptions node.Options) {
 			return nil, market.ServiceProposal{}, err
 		}
 
+		sessionBandwidth, advertisedBandwidth, err := parseSessionBandwidth(nodeOptions)
+		if err != nil {
+			return nil, market.ServiceProposal{}, err
+		}
+
 		transportOptions := serviceOptions.(openvpn_service.Options)
-		proposal := openvpn_discovery.NewServiceProposalWithLocation(loc, transportOptions.Protocol)
+		transportOptions.SessionBandwidth = sessionBandwidth
+		proposal := openvpn_discovery.NewServiceProposalWithLocation(loc, transportOptions.Protocol, advertisedBandwidth)
 
 		// TODO: Use global port pool once migrated to p2p.
 		var portPool port.ServicePortSupplier
@@ -146,6 +176,22 @@ func (di *Dependencies) bootstrapServiceOpenvpn(nodeOptions node.Options) {
 	di.ServiceRegistry.Register(service_openvpn.ServiceType, createService)
 }
 
+// parseSessionBandwidth returns speed limit of each provided session and the limit to advertise in proposals.
+func parseSessionBandwidth(nodeOptions node.Options) (limit, advertised datasize.BitSpeed, err error) {
+	if nodeOptions.SessionBandwidth == "" {
+		return 0, 0, nil
+	}
+
+	limit, err = datasize.ParseBitSpeed(nodeOptions.SessionBandwidth)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "invalid session bandwidth")
+	}
+	if nodeOptions.SessionBandwidthAdvertise {
+		advertised = limit
+	}
+	return limit, advertised, nil
+}
+
 func (di *Dependencies) bootstrapServiceNoop(nodeOptions node.Options) {
 	di.ServiceRegistry.Register(
 		service_noop.ServiceType,
@@ -160,6 +206,47 @@ func (di *Dependencies) bootstrapServiceNoop(nodeOptions node.Options) {
 	)
 }
 
+func (di *Dependencies) bootstrapServiceHTTPProxy(nodeOptions node.Options) {
+	di.ServiceRegistry.Register(
+		service_httpproxy.ServiceType,
+		func(serviceOptions service.Options) (service.Service, market.ServiceProposal, error) {
+			loc, err := di.LocationResolver.DetectLocation()
+			if err != nil {
+				return nil, market.ServiceProposal{}, err
+			}
+
+			svc := httpproxy_service.NewManager(
+				di.IPResolver,
+				di.EventBus,
+				serviceOptions.(httpproxy_service.Options),
+				stringutil.Split(config.GetString(config.FlagFirewallProtectedNetworks), ','),
+			)
+			return svc, httpproxy_service.GetProposal(loc), nil
+		},
+	)
+}
+
+func (di *Dependencies) bootstrapServiceShadowsocks(nodeOptions node.Options) {
+	di.ServiceRegistry.Register(
+		service_shadowsocks.ServiceType,
+		func(serviceOptions service.Options) (service.Service, market.ServiceProposal, error) {
+			loc, err := di.LocationResolver.DetectLocation()
+			if err != nil {
+				return nil, market.ServiceProposal{}, err
+			}
+
+			opts := serviceOptions.(shadowsocks_service.Options)
+			svc := shadowsocks_service.NewManager(
+				di.IPResolver,
+				di.EventBus,
+				opts,
+				stringutil.Split(config.GetString(config.FlagFirewallProtectedNetworks), ','),
+			)
+			return svc, shadowsocks_service.GetProposal(loc, opts.Cipher), nil
+		},
+	)
+}
+
 func (di *Dependencies) bootstrapProviderRegistrar(nodeOptions node.Options) error {
 	if nodeOptions.Consumer {
 		log.Debug().Msg("Skipping provider registrar for consumer mode")
@@ -198,7 +285,6 @@ func (di *Dependencies) bootstrapHermesPromiseSettler(nodeOptions node.Options)
 		di.Keystore,
 		di.SettlementHistoryStorage,
 		pingpong.HermesPromiseSettlerConfig{
-			HermesAddress:        common.HexToAddress(nodeOptions.Hermes.HermesID),
 			Threshold:            nodeOptions.Payments.HermesPromiseSettlingThreshold,
 			MaxWaitForSettlement: nodeOptions.Payments.SettlementTimeout,
 		},
@@ -222,12 +308,27 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) err
 	di.ServiceSessions = service.NewSessionPool(di.EventBus)
 
 	di.PolicyOracle = policy.NewOracle(
-		di.HTTPClient,
+		di.ControlHTTPClient,
 		config.GetString(config.FlagAccessPolicyAddress),
 		config.GetDuration(config.FlagAccessPolicyFetchInterval),
+		di.Storage,
+		config.GetDuration(config.FlagAccessPolicyMaxStale),
 	)
 	go di.PolicyOracle.Start()
 
+	sessionConfig := service.DefaultConfig()
+	sessionConfig.MaxSessions = config.GetInt(config.FlagServiceMaxSessions)
+	sessionConfig.MaxConsumerSessions = config.GetInt(config.FlagServiceMaxConsumerSessions)
+	if nodeOptions.P2PKeepAlive.Interval > 0 {
+		sessionConfig.KeepAlive.SendInterval = nodeOptions.P2PKeepAlive.Interval
+	}
+	if nodeOptions.P2PKeepAlive.Timeout > 0 {
+		sessionConfig.KeepAlive.SendTimeout = nodeOptions.P2PKeepAlive.Timeout
+	}
+	if nodeOptions.P2PKeepAlive.MaxFailures > 0 {
+		sessionConfig.KeepAlive.MaxSendErrCount = nodeOptions.P2PKeepAlive.MaxFailures
+	}
+
 	newP2PSessionHandler := func(serviceInstance *service.Instance, channel p2p.Channel) *service.SessionManager {
 		paymentEngineFactory := pingpong.InvoiceFactoryCreator(
 			channel, nodeOptions.Payments.ProviderInvoiceFrequency,
@@ -239,9 +340,10 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) err
 			nodeOptions.Payments.MaxUnpaidInvoiceValue,
 			di.BCHelper,
 			di.EventBus,
-			serviceInstance.Proposal,
+			serviceInstance.CopyProposal(),
 			di.HermesPromiseHandler,
-			common.HexToAddress(nodeOptions.Hermes.HermesID),
+			di.PaymentDefaults,
+			di.SessionKeys,
 		)
 		return service.NewSessionManager(
 			serviceInstance,
@@ -250,7 +352,7 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) err
 			di.NATTracker,
 			di.EventBus,
 			channel,
-			service.DefaultConfig(),
+			sessionConfig,
 		)
 	}
 
@@ -262,8 +364,33 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) err
 		di.P2PListener,
 		newP2PSessionHandler,
 		di.SessionConnectivityStatusStorage,
+		di.ConsumerACL,
+		config.GetDuration(config.FlagServiceShutdownDrain),
+		di.NATTypeDetector,
 	)
 
+	if err := di.bootstrapTrafficQuota(nodeOptions); err != nil {
+		return err
+	}
+	if err := di.bootstrapDynamicPricing(nodeOptions); err != nil {
+		return err
+	}
+	if err := di.bootstrapOperatingHours(nodeOptions); err != nil {
+		return err
+	}
+	if err := di.bootstrapResourceMonitor(nodeOptions); err != nil {
+		return err
+	}
+	if err := di.bootstrapWebhook(nodeOptions); err != nil {
+		return err
+	}
+	if err := di.bootstrapSpeedTest(nodeOptions); err != nil {
+		return err
+	}
+	if err := di.bootstrapAbuseDetector(nodeOptions); err != nil {
+		return err
+	}
+
 	serviceCleaner := service.Cleaner{SessionStorage: di.ServiceSessions}
 	if err := di.EventBus.Subscribe(servicestate.AppTopicServiceStatus, serviceCleaner.HandleServiceStatus); err != nil {
 		log.Error().Err(err).Msg("Failed to subscribe service cleaner")
@@ -272,10 +399,198 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) err
 	return nil
 }
 
+func (di *Dependencies) bootstrapTrafficQuota(nodeOptions node.Options) error {
+	if nodeOptions.TrafficQuotaGiB == 0 {
+		return nil
+	}
+
+	limit := datasize.BitSize(nodeOptions.TrafficQuotaGiB) * datasize.GiB
+	di.TrafficQuota = quota.NewTrafficQuota(limit, time.Minute, di.Storage, di.ServicesManager, di.ServiceSessions)
+	if err := di.TrafficQuota.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe traffic quota to events")
+	}
+	di.TrafficQuota.Start()
+	return nil
+}
+
+func (di *Dependencies) bootstrapWebhook(nodeOptions node.Options) error {
+	opts := nodeOptions.Webhook
+	if opts.URL == "" {
+		return nil
+	}
+
+	di.Webhook = webhook.NewWebhook(opts.URL, opts.Secret, di.HTTPClient)
+	if err := di.Webhook.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe webhook to events")
+	}
+	di.Webhook.Start()
+	return nil
+}
+
+func (di *Dependencies) bootstrapSpeedTest(nodeOptions node.Options) error {
+	opts := nodeOptions.SpeedTest
+	if len(opts.Targets) == 0 {
+		return nil
+	}
+
+	di.SpeedTest = speedtest.NewSpeedTest(speedtest.Config{
+		Targets:  opts.Targets,
+		Interval: opts.Interval,
+		Duration: opts.Duration,
+	}, di.ServicesManager, di.HTTPClient)
+	if err := di.SpeedTest.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe speed test to events")
+	}
+	di.SpeedTest.Start()
+	return nil
+}
+
+func (di *Dependencies) bootstrapDynamicPricing(nodeOptions node.Options) error {
+	opts := nodeOptions.DynamicPricing
+	if !opts.Enabled {
+		return nil
+	}
+
+	var maxBandwidth datasize.BitSpeed
+	if opts.MaxBandwidth != "" {
+		var err error
+		if maxBandwidth, err = datasize.ParseBitSpeed(opts.MaxBandwidth); err != nil {
+			return errors.Wrap(err, "invalid dynamic pricing bandwidth")
+		}
+	}
+
+	di.DynamicPricing = pricing.NewDynamicPricing(pricing.Config{
+		Bounds: pricing.Bounds{
+			PricePerGBMin:     opts.PricePerGBMin,
+			PricePerGBMax:     opts.PricePerGBMax,
+			PricePerMinuteMin: opts.PricePerMinuteMin,
+			PricePerMinuteMax: opts.PricePerMinuteMax,
+		},
+		MaxSessions:   opts.MaxSessions,
+		MaxBandwidth:  maxBandwidth,
+		Steps:         5,
+		CheckInterval: time.Minute,
+	}, di.ServicesManager, di.ServiceSessions)
+	if err := di.DynamicPricing.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe dynamic pricing to events")
+	}
+	di.DynamicPricing.Start()
+	return nil
+}
+
+func (di *Dependencies) bootstrapOperatingHours(nodeOptions node.Options) error {
+	windows, err := hours.ParseWindows(nodeOptions.OperatingHours)
+	if err != nil {
+		return errors.Wrap(err, "invalid operating hours")
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+
+	di.OperatingHours = hours.NewOperatingHours(windows, nodeOptions.OperatingHoursDrain, time.Minute, di.ServicesManager, di.ServiceSessions)
+	if err := di.OperatingHours.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe operating hours to events")
+	}
+	di.OperatingHours.Start()
+	return nil
+}
+
+func (di *Dependencies) bootstrapResourceMonitor(nodeOptions node.Options) error {
+	limits := throttle.Limits{
+		CPU:             nodeOptions.Throttle.CPUPercent / 100,
+		Memory:          nodeOptions.Throttle.MemoryPercent / 100,
+		FileDescriptors: nodeOptions.Throttle.FileDescriptorsPercent / 100,
+	}
+	if limits.IsZero() {
+		return nil
+	}
+
+	di.ResourceMonitor = throttle.NewMonitor(limits, 30*time.Second, throttle.NewSampler(), di.ServicesManager)
+	if err := di.ResourceMonitor.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe resource monitor to events")
+	}
+	di.ResourceMonitor.Start()
+	return nil
+}
+
+func (di *Dependencies) bootstrapAbuseDetector(nodeOptions node.Options) error {
+	opts := nodeOptions.AbuseDetection
+	if !opts.Enabled {
+		return nil
+	}
+
+	action, err := abuse.NewAction(opts.Action)
+	if err != nil {
+		return errors.Wrap(err, "invalid abuse detection action")
+	}
+	throttleBandwidth, err := datasize.ParseBitSpeed(opts.ThrottleBandwidth)
+	if err != nil {
+		return errors.Wrap(err, "invalid abuse detection throttle bandwidth")
+	}
+	spamPorts := make([]int, len(opts.SpamPorts))
+	for i, p := range opts.SpamPorts {
+		if spamPorts[i], err = strconv.Atoi(p); err != nil {
+			return errors.Wrapf(err, "invalid abuse detection spam port %q", p)
+		}
+	}
+
+	di.AbuseDetector = abuse.NewDetector(abuse.Config{
+		Rules: abuse.Rules{
+			SpamPorts:                  spamPorts,
+			PortScanPorts:              opts.PortScanPorts,
+			MaxNewConnectionsPerMinute: opts.MaxNewConnectionsPerMinute,
+		},
+		Action:            action,
+		ThrottleBandwidth: throttleBandwidth,
+		BanDuration:       opts.BanDuration,
+		Exempt:            opts.Exempt,
+		CheckInterval:     10 * time.Second,
+	}, abuse.NewSampler(), di.ServicesManager, di.ConsumerACL, di.EventBus)
+	if err := di.AbuseDetector.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe abuse detector to events")
+	}
+	di.AbuseDetector.Start()
+	return nil
+}
+
 func (di *Dependencies) registerConnections(nodeOptions node.Options) {
 	di.registerOpenvpnConnection(nodeOptions)
 	di.registerNoopConnection()
 	di.registerWireguardConnection(nodeOptions)
+	di.registerHTTPProxyConnection()
+	di.registerShadowsocksConnection()
+}
+
+func (di *Dependencies) registerHTTPProxyConnection() {
+	service_httpproxy.Bootstrap()
+	listenAddress := config.GetString(config.FlagHTTPProxyLocalAddress)
+	if listenAddress == "" {
+		listenAddress = config.FlagHTTPProxyLocalAddress.Value
+	}
+	connFactory := func() (connection.Connection, error) {
+		opts := httpproxy_connection.Options{
+			ListenAddress: listenAddress,
+			DialTimeout:   30 * time.Second,
+		}
+		return httpproxy_connection.NewConnection(opts)
+	}
+	di.ConnectionRegistry.Register(service_httpproxy.ServiceType, connFactory)
+}
+
+func (di *Dependencies) registerShadowsocksConnection() {
+	service_shadowsocks.Bootstrap()
+	listenAddress := config.GetString(config.FlagShadowsocksLocalAddress)
+	if listenAddress == "" {
+		listenAddress = config.FlagShadowsocksLocalAddress.Value
+	}
+	connFactory := func() (connection.Connection, error) {
+		opts := shadowsocks_connection.Options{
+			ListenAddress: listenAddress,
+			DialTimeout:   30 * time.Second,
+		}
+		return shadowsocks_connection.NewConnection(opts)
+	}
+	di.ConnectionRegistry.Register(service_shadowsocks.ServiceType, connFactory)
 }
 
 func (di *Dependencies) registerWireguardConnection(nodeOptions node.Options) {
@@ -283,7 +598,7 @@ func (di *Dependencies) registerWireguardConnection(nodeOptions node.Options) {
 	handshakeWaiter := wireguard_connection.NewHandshakeWaiter()
 	endpointFactory := func() (wireguard.ConnectionEndpoint, error) {
 		resourceAllocator := resources.NewAllocator(nil, wireguard_service.DefaultOptions.Subnet)
-		return endpoint.NewConnectionEndpoint(resourceAllocator)
+		return endpoint.NewConnectionEndpoint(resourceAllocator, config.GetString(config.FlagWireguardBackend))
 	}
 	connFactory := func() (connection.Connection, error) {
 		opts := wireguard_connection.Options{
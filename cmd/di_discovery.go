@@ -21,12 +21,18 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/core/discovery"
 	"github.com/mysteriumnetwork/node/core/discovery/apidiscovery"
 	"github.com/mysteriumnetwork/node/core/discovery/brokerdiscovery"
 	"github.com/mysteriumnetwork/node/core/discovery/dhtdiscovery"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/discovery/selection"
 	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/core/quality"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/firewall"
+	"github.com/mysteriumnetwork/node/market/mysterium"
 	"github.com/pkg/errors"
 )
 
@@ -35,16 +41,31 @@ func (di *Dependencies) bootstrapDiscoveryComponents(options node.OptionsDiscove
 	proposalRegistry := discovery.NewRegistry()
 	discoveryWorker := discovery.NewWorker()
 
+	// with broker push, API is only used to seed broker repository instead of being queried on each request
+	brokerPush := options.BrokerPush && hasDiscoveryType(options.Types, node.DiscoveryTypeBroker)
+	verifier := proposal.NewSignatureVerifier(options.RequireSignature)
+
+	proposalsAPI, err := di.bootstrapDiscoveryAPI(options)
+	if err != nil {
+		return err
+	}
+
 	for _, discoveryType := range options.Types {
 		switch discoveryType {
 		case node.DiscoveryTypeAPI:
 			proposalRegistry.AddRegistry(apidiscovery.NewRegistry(di.MysteriumAPI))
-			proposalRepository.Add(apidiscovery.NewRepository(di.MysteriumAPI))
+			if !brokerPush {
+				proposalRepository.Add(apidiscovery.NewRepository(proposalsAPI, verifier))
+			}
 
 		case node.DiscoveryTypeBroker:
+			var seed proposal.Repository
+			if brokerPush && hasDiscoveryType(options.Types, node.DiscoveryTypeAPI) {
+				seed = apidiscovery.NewRepository(proposalsAPI, verifier)
+			}
 			storage := brokerdiscovery.NewStorage(di.EventBus)
-			brokerRepository := brokerdiscovery.NewRepository(di.BrokerConnection, storage, options.PingInterval+time.Second, 1*time.Second)
-			if options.FetchEnabled {
+			brokerRepository := brokerdiscovery.NewRepository(di.BrokerConnection, storage, options.PingInterval+time.Second, 1*time.Second, seed, verifier)
+			if options.FetchEnabled || brokerPush {
 				discoveryWorker.AddWorker(brokerRepository)
 			}
 
@@ -55,14 +76,16 @@ func (di *Dependencies) bootstrapDiscoveryComponents(options node.OptionsDiscove
 			dhtNode, err := dhtdiscovery.NewNode(
 				fmt.Sprintf("/ip4/%s/%s/%d", options.DHT.Address, options.DHT.Protocol, options.DHT.Port),
 				options.DHT.BootstrapPeers,
+				2*options.PingInterval,
 			)
 			if err != nil {
 				return errors.Wrap(err, "failed to configure DHT node")
 			}
 			discoveryWorker.AddWorker(dhtNode)
 
-			proposalRegistry.AddRegistry(dhtdiscovery.NewRegistry())
-			proposalRepository.Add(dhtdiscovery.NewRepository())
+			// DHT is experimental, consumers query it only when other discovery adapters fail
+			proposalRegistry.AddRegistry(dhtdiscovery.NewRegistry(dhtNode))
+			proposalRepository.AddFallback(dhtdiscovery.NewRepository(dhtNode, 5*time.Second))
 
 		default:
 			return errors.Errorf("unknown discovery adapter: %s", discoveryType)
@@ -74,9 +97,105 @@ func (di *Dependencies) bootstrapDiscoveryComponents(options node.OptionsDiscove
 		return errors.Wrap(err, "failed to start discovery")
 	}
 
-	di.ProposalRepository = proposalRepository
+	if options.CacheTTL > 0 {
+		di.ProposalRepository = proposal.NewCache(proposalRepository, di.Storage, options.CacheTTL)
+	} else {
+		di.ProposalRepository = proposalRepository
+	}
+	if options.PriceMaxRatio > 0 {
+		di.ProposalRepository = proposal.NewPriceSanity(di.ProposalRepository, options.PriceMaxRatio, options.PriceHideOutliers)
+	}
 	di.DiscoveryFactory = func() service.Discovery {
 		return discovery.NewService(di.IdentityRegistry, proposalRegistry, options.PingInterval, di.SignerFactory, di.EventBus)
 	}
 	return nil
 }
+
+func hasDiscoveryType(types []node.DiscoveryType, discoveryType node.DiscoveryType) bool {
+	for _, t := range types {
+		if t == discoveryType {
+			return true
+		}
+	}
+	return false
+}
+
+func (di *Dependencies) bootstrapSelection(options node.OptionsSelection) error {
+	latency := selection.NewLatencyCriterion()
+	if err := latency.Subscribe(di.EventBus); err != nil {
+		return err
+	}
+
+	di.SelectionEngine = selection.NewEngine()
+	di.SelectionEngine.Register(selection.CriterionPrice, selection.PriceCriterion{})
+	di.SelectionEngine.Register(selection.CriterionQuality, selection.NewQualityCriterion(di.QualityMetrics))
+	di.SelectionEngine.Register(selection.CriterionLatency, latency)
+	di.SelectionEngine.Register(selection.CriterionCountry, selection.CountryCriterion{})
+	di.SelectionEngine.Register(selection.CriterionHistory, selection.NewHistoryCriterion(di.SessionStorage))
+	di.SelectionEngine.Register(selection.CriterionNAT, selection.NewNATCriterion(di.NATTypeDetector))
+	err := di.SelectionEngine.SetPolicy(selection.Policy{
+		Weights: map[string]float64{
+			selection.CriterionPrice:   options.PriceWeight,
+			selection.CriterionQuality: options.QualityWeight,
+			selection.CriterionLatency: options.LatencyWeight,
+			selection.CriterionCountry: options.CountryWeight,
+			selection.CriterionHistory: options.HistoryWeight,
+			selection.CriterionNAT:     options.NATWeight,
+		},
+		Countries: options.Countries,
+		Filter:    options.Filter,
+	})
+	if err != nil {
+		return errors.Wrap(err, "invalid provider selection options")
+	}
+
+	di.SavedFilterStorage = proposal.NewSavedFilterStorage(di.Storage)
+	di.ProposalRepository = quality.NewProposalRepository(di.ProposalRepository, di.QualityMetrics)
+	di.ProposalRepository = selection.NewQualityFilter(di.ProposalRepository, di.QualityMetrics)
+	if options.LatencyProbe.Count > 0 {
+		prober := connection.NewLatencyProber(di.P2PDialer, di.IdentityManager, options.LatencyProbe.Timeout)
+		di.ProposalRepository = selection.NewLatencyRepository(di.ProposalRepository, di.SelectionEngine, prober, options.LatencyProbe.Count, options.LatencyProbe.TTL)
+	}
+	di.RankedProposalRepository = selection.NewRepository(di.ProposalRepository, di.SelectionEngine, di.SavedFilterStorage)
+	return nil
+}
+
+// bootstrapDiscoveryAPI creates discovery API client, failing over between configured endpoints in priority order:
+// discovery API v3, Mysterium API and fallback addresses.
+func (di *Dependencies) bootstrapDiscoveryAPI(options node.OptionsDiscovery) (apidiscovery.ProposalsAPI, error) {
+	var endpoints []apidiscovery.Endpoint
+	var addresses []string
+	if options.APIv3Address != "" {
+		endpoints = append(endpoints, apidiscovery.Endpoint{
+			Address: options.APIv3Address,
+			API:     mysterium.NewProposalsClientV3(di.ControlHTTPClient, options.APIv3Address, options.APIv3PageSize),
+		})
+		addresses = append(addresses, options.APIv3Address)
+	}
+	endpoints = append(endpoints, apidiscovery.Endpoint{
+		Address: di.NetworkDefinition.MysteriumAPIAddress,
+		API:     di.MysteriumAPI,
+	})
+	for _, address := range options.APIFallbackAddresses {
+		endpoints = append(endpoints, apidiscovery.Endpoint{
+			Address: address,
+			API:     mysterium.NewClient(di.ControlHTTPClient, address),
+		})
+		addresses = append(addresses, address)
+	}
+
+	if len(endpoints) == 1 {
+		return di.MysteriumAPI, nil
+	}
+
+	if _, err := firewall.AllowURLAccess(addresses...); err != nil {
+		return nil, err
+	}
+	if _, err := di.ServiceFirewall.AllowURLAccess(addresses...); err != nil {
+		return nil, err
+	}
+
+	di.DiscoveryAPI = apidiscovery.NewFailoverAPI(endpoints, options.APIHealthCheckInterval)
+	di.DiscoveryAPI.Start()
+	return di.DiscoveryAPI, nil
+}
@@ -19,8 +19,10 @@ package nats
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	nats_lib "github.com/nats-io/nats.go"
@@ -31,6 +33,9 @@ import (
 const (
 	// DefaultBrokerPort broker port.
 	DefaultBrokerPort = 4222
+
+	// preferredServerProbeInterval is how often connection to fallback server checks whether preferred server is back.
+	preferredServerProbeInterval = time.Minute
 )
 
 // ParseServerURI validates given NATS server address.
@@ -51,10 +56,17 @@ func ParseServerURI(serverURI string) (*url.URL, error) {
 	return serverURL, nil
 }
 
+// Dialer establishes TCP connections to NATS servers.
+type Dialer interface {
+	Dial(network, address string) (net.Conn, error)
+}
+
 func newConnection(serverURIs ...string) (*ConnectionWrap, error) {
 	connection := &ConnectionWrap{
-		servers: make([]string, len(serverURIs)),
-		onClose: func() {},
+		servers:       make([]string, len(serverURIs)),
+		onClose:       func() {},
+		probeInterval: preferredServerProbeInterval,
+		stop:          make(chan struct{}),
 	}
 
 	for i, server := range serverURIs {
@@ -69,15 +81,26 @@ func newConnection(serverURIs ...string) (*ConnectionWrap, error) {
 }
 
 // ConnectionWrap defines wrapped connection to NATS server(s).
+// First of the servers is the preferred one, other servers are used only while it is unreachable.
 type ConnectionWrap struct {
 	*nats_lib.Conn
 	servers []string
+	dialer  Dialer
 	onClose func()
+
+	probeInterval time.Duration
+	stop          chan struct{}
+	stopOnce      sync.Once
+
+	// netConn is the current network connection to the server, closing it makes NATS reconnect.
+	netConn   net.Conn
+	netConnMu sync.Mutex
 }
 
 func (c *ConnectionWrap) connectOptions() nats_lib.Options {
 	options := nats_lib.GetDefaultOptions()
 	options.Servers = c.servers
+	options.NoRandomize = true
 	options.MaxReconnect = -1
 	options.ReconnectWait = 1 * time.Second
 	options.Timeout = 5 * time.Second
@@ -85,9 +108,71 @@ func (c *ConnectionWrap) connectOptions() nats_lib.Options {
 	options.ClosedCB = func(conn *nats_lib.Conn) { log.Warn().Msg("NATS: connection closed") }
 	options.DisconnectedCB = func(nc *nats_lib.Conn) { log.Warn().Msg("NATS: disconnected") }
 	options.ReconnectedCB = func(nc *nats_lib.Conn) { log.Warn().Msg("NATS: reconnected") }
+	options.CustomDialer = c
 	return options
 }
 
+// Dial connects to NATS server keeping the connection, so that connection to fallback server can be dropped
+// once preferred server is back.
+func (c *ConnectionWrap) Dial(network, address string) (net.Conn, error) {
+	conn, err := c.dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	c.netConnMu.Lock()
+	defer c.netConnMu.Unlock()
+	c.netConn = conn
+	return conn, nil
+}
+
+func (c *ConnectionWrap) dial(network, address string) (net.Conn, error) {
+	if c.dialer != nil {
+		return c.dialer.Dial(network, address)
+	}
+	return net.DialTimeout(network, address, c.connectOptions().Timeout)
+}
+
+// probePreferredServer periodically checks whether preferred server is reachable while connected to fallback one
+// and reconnects to the preferred server once it is.
+func (c *ConnectionWrap) probePreferredServer() {
+	preferred, err := url.Parse(c.servers[0])
+	if err != nil {
+		log.Err(err).Msg("NATS: could not parse preferred server")
+		return
+	}
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(c.probeInterval):
+		}
+
+		if !c.Conn.IsConnected() {
+			continue
+		}
+		connected, err := url.Parse(c.Conn.ConnectedUrl())
+		if err != nil || connected.Host == preferred.Host {
+			continue
+		}
+
+		probe, err := c.dial("tcp", preferred.Host)
+		if err != nil {
+			log.Debug().Err(err).Msgf("NATS: preferred server %s is still unreachable", preferred.Host)
+			continue
+		}
+		probe.Close()
+
+		log.Info().Msgf("NATS: preferred server %s is reachable again, reconnecting from %s", preferred.Host, connected.Host)
+		c.netConnMu.Lock()
+		if c.netConn != nil {
+			c.netConn.Close()
+		}
+		c.netConnMu.Unlock()
+	}
+}
+
 // Open starts the connection: left for test compatibility.
 // Deprecated: Use nats.BrokerConnector#Connect() instead.
 func (c *ConnectionWrap) Open() (err error) {
@@ -96,11 +181,15 @@ func (c *ConnectionWrap) Open() (err error) {
 		return fmt.Errorf("failed to connect to NATS servers %v: %w", c.connectOptions().Servers, err)
 	}
 
+	if len(c.servers) > 1 {
+		go c.probePreferredServer()
+	}
 	return nil
 }
 
 // Close destructs the connection.
 func (c *ConnectionWrap) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
 	if c.Conn != nil {
 		c.Conn.Close()
 	}
@@ -20,7 +20,9 @@ package nats
 import (
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/nats-io/nats-server/v2/server"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
@@ -80,3 +82,27 @@ func TestConnectionWrap_Servers(t *testing.T) {
 	connection, _ := newConnection("nats://far-server:1234")
 	assert.Equal(t, []string{"nats://far-server:1234"}, connection.Servers())
 }
+
+func TestConnectionWrap_ReconnectsToPreferredServer(t *testing.T) {
+	preferredOptions := &server.Options{Port: 44225}
+	fallback := server.New(&server.Options{Port: 44226})
+	go fallback.Start()
+	defer fallback.Shutdown()
+	assert.True(t, fallback.ReadyForConnections(2*time.Second))
+
+	conn, err := newConnection("nats://127.0.0.1:44225", "nats://127.0.0.1:44226")
+	assert.NoError(t, err)
+	conn.probeInterval = 100 * time.Millisecond
+	assert.NoError(t, conn.Open())
+	defer conn.Close()
+	assert.Equal(t, "nats://127.0.0.1:44226", conn.ConnectedUrl())
+
+	preferred := server.New(preferredOptions)
+	go preferred.Start()
+	defer preferred.Shutdown()
+	assert.True(t, preferred.ReadyForConnections(2*time.Second))
+
+	assert.Eventually(t, func() bool {
+		return conn.ConnectedUrl() == "nats://127.0.0.1:44225"
+	}, 5*time.Second, 100*time.Millisecond)
+}
@@ -27,6 +27,7 @@ import (
 
 // BrokerConnector establishes new connections to NATS servers and handles reconnects.
 type BrokerConnector struct {
+	dialer Dialer
 }
 
 // NewBrokerConnector creates a new BrokerConnector.
@@ -34,6 +35,12 @@ func NewBrokerConnector() *BrokerConnector {
 	return &BrokerConnector{}
 }
 
+// NewBrokerConnectorWithDialer creates a new BrokerConnector connecting to NATS servers using the given dialer,
+// e.g. through the upstream proxy.
+func NewBrokerConnectorWithDialer(dialer Dialer) *BrokerConnector {
+	return &BrokerConnector{dialer: dialer}
+}
+
 // Connect establishes a new connection to the broker(s).
 func (b *BrokerConnector) Connect(serverURIs ...string) (Connection, error) {
 	log.Debug().Msg("Connecting to NATS servers: " + strings.Join(serverURIs, ","))
@@ -42,6 +49,7 @@ func (b *BrokerConnector) Connect(serverURIs ...string) (Connection, error) {
 	if err != nil {
 		return nil, err
 	}
+	conn.dialer = b.dialer
 
 	removeFirewallRule, err := firewall.AllowURLAccess(conn.servers...)
 	if err != nil {
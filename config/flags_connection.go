@@ -0,0 +1,213 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// FlagConnectTimeout limits the whole consumer connect flow.
+	FlagConnectTimeout = cli.DurationFlag{
+		Name:  "connect.timeout",
+		Usage: `Overall consumer connect timeout, 0 disables it { "30s", "3m" }`,
+		Value: 2 * time.Minute,
+	}
+	// FlagConnectTimeoutProposalFetch limits fetching of provider's proposal.
+	FlagConnectTimeoutProposalFetch = cli.DurationFlag{
+		Name:  "connect.timeout.proposal-fetch",
+		Usage: "Timeout of provider's proposal fetch, 0 disables it",
+		Value: 20 * time.Second,
+	}
+	// FlagConnectTimeoutP2PDial limits establishing of p2p channel with provider.
+	FlagConnectTimeoutP2PDial = cli.DurationFlag{
+		Name:  "connect.timeout.p2p-dial",
+		Usage: "Timeout of p2p channel establishment with provider, 0 disables it",
+		Value: 60 * time.Second,
+	}
+	// FlagConnectTimeoutSessionCreate limits session creation with provider.
+	FlagConnectTimeoutSessionCreate = cli.DurationFlag{
+		Name:  "connect.timeout.session-create",
+		Usage: "Timeout of session creation with provider, 0 disables it",
+		Value: 20 * time.Second,
+	}
+	// FlagConnectTimeoutTunnelUp limits waiting for tunnel to come up.
+	FlagConnectTimeoutTunnelUp = cli.DurationFlag{
+		Name:  "connect.timeout.tunnel-up",
+		Usage: "Timeout of waiting for tunnel to come up, 0 disables it",
+		Value: 60 * time.Second,
+	}
+	// FlagConnectRetryAttempts sets count of connect attempts to each provider.
+	FlagConnectRetryAttempts = cli.IntFlag{
+		Name:  "connect.retry.attempts",
+		Usage: "Count of connect attempts to each provider before failing over or giving up, 1 disables retries",
+		Value: 3,
+	}
+	// FlagConnectRetryBackoff sets delay before retrying failed connect.
+	FlagConnectRetryBackoff = cli.DurationFlag{
+		Name:  "connect.retry.backoff",
+		Usage: "Delay before the second connect attempt, doubled before each next attempt",
+		Value: 2 * time.Second,
+	}
+	// FlagConnectRetryJitter randomizes delays between connect attempts.
+	FlagConnectRetryJitter = cli.Float64Flag{
+		Name:  "connect.retry.jitter",
+		Usage: "Fraction of the delay between connect attempts to randomize it by, from 0 to 1",
+		Value: 0.2,
+	}
+	// FlagConnectProbeCandidates sets count of candidate providers probed before connecting.
+	FlagConnectProbeCandidates = cli.IntFlag{
+		Name:  "connect.probe.candidates",
+		Usage: "Count of candidate providers (the provider and its fallbacks) to probe before connecting to the fastest one, values below 2 disable probing",
+		Value: 0,
+	}
+	// FlagConnectProbeTimeout limits probing of candidate providers.
+	FlagConnectProbeTimeout = cli.DurationFlag{
+		Name:  "connect.probe.timeout",
+		Usage: "Timeout of probing candidate providers before connecting",
+		Value: 10 * time.Second,
+	}
+	// FlagConnectionMaxBandwidth limits consumer tunnel speed.
+	FlagConnectionMaxBandwidth = cli.StringFlag{
+		Name:  "connection.max-bandwidth",
+		Usage: `Consumer tunnel speed limit (Linux only), empty value means unlimited { "512kbps", "10mbps" }`,
+		Value: "",
+	}
+	// FlagConnectionIdleTimeout disconnects consumer when the tunnel is not used.
+	FlagConnectionIdleTimeout = cli.DurationFlag{
+		Name:  "connection.idle-timeout",
+		Usage: `Disconnect when no traffic flows through the tunnel for given time, 0 disables it { "15m", "1h" }`,
+		Value: 0,
+	}
+	// FlagConnectionKeyRotationInterval rotates tunnel keys of the established session periodically.
+	FlagConnectionKeyRotationInterval = cli.DurationFlag{
+		Name:  "connection.key-rotation-interval",
+		Usage: `Renegotiate tunnel keys with the provider every given time without reconnecting, 0 disables it { "1h" }`,
+		Value: 0,
+	}
+	// FlagConnectionAutoConnect keeps consumer connected to the auto connect target.
+	FlagConnectionAutoConnect = cli.BoolFlag{
+		Name:  "connection.auto-connect",
+		Usage: "Connect to the auto connect target on node start and reconnect after the connection is lost",
+		Value: false,
+	}
+	// FlagConnectionAutoConnectProvider selects provider to auto connect to.
+	FlagConnectionAutoConnectProvider = cli.StringFlag{
+		Name:  "connection.auto-connect.provider",
+		Usage: "Provider ID to auto connect to, takes precedence over country",
+		Value: "",
+	}
+	// FlagConnectionAutoConnectCountry selects country of providers to auto connect to.
+	FlagConnectionAutoConnectCountry = cli.StringFlag{
+		Name:  "connection.auto-connect.country",
+		Usage: `Auto connect to any provider located in the country { "DE", "US" }`,
+		Value: "",
+	}
+	// FlagConnectionAutoConnectServiceType selects service type to auto connect with.
+	FlagConnectionAutoConnectServiceType = cli.StringFlag{
+		Name:  "connection.auto-connect.service-type",
+		Usage: "Service type to auto connect with",
+		Value: "wireguard",
+	}
+	// FlagConnectionAutoSwitch switches consumer connection to the next best provider when tunnel health degrades.
+	FlagConnectionAutoSwitch = cli.BoolFlag{
+		Name:  "connection.auto-switch",
+		Usage: "Switch connection to the next best provider when tunnel packet loss or latency exceeds the threshold for the period",
+		Value: false,
+	}
+	// FlagConnectionAutoSwitchMaxPacketLoss sets packet loss threshold of the auto switch.
+	FlagConnectionAutoSwitchMaxPacketLoss = cli.Float64Flag{
+		Name:  "connection.auto-switch.max-packet-loss",
+		Usage: "Fraction of lost tunnel pings, from 0 to 1, above which tunnel health is degraded, 0 disables the threshold",
+		Value: 0.2,
+	}
+	// FlagConnectionAutoSwitchMaxLatency sets latency threshold of the auto switch.
+	FlagConnectionAutoSwitchMaxLatency = cli.DurationFlag{
+		Name:  "connection.auto-switch.max-latency",
+		Usage: "Average tunnel round trip time above which tunnel health is degraded, 0 disables the threshold",
+		Value: 500 * time.Millisecond,
+	}
+	// FlagConnectionAutoSwitchPeriod sets how long tunnel health has to stay degraded before the switch.
+	FlagConnectionAutoSwitchPeriod = cli.DurationFlag{
+		Name:  "connection.auto-switch.period",
+		Usage: "How long tunnel health has to stay degraded before switching to another provider",
+		Value: 3 * time.Minute,
+	}
+	// FlagConnectionNetworkCheckInterval sets how often host network is checked for changes requiring reconnect.
+	FlagConnectionNetworkCheckInterval = cli.DurationFlag{
+		Name:  "connection.network-check.interval",
+		Usage: "How often to check if host moved to another network (e.g. from Wi-Fi to LTE) and reconnect, 0 disables it",
+		Value: 5 * time.Second,
+	}
+)
+
+// RegisterFlagsConnection function registers consumer connection flags to flag list.
+func RegisterFlagsConnection(flags *[]cli.Flag) {
+	*flags = append(*flags,
+		&FlagConnectTimeout,
+		&FlagConnectTimeoutProposalFetch,
+		&FlagConnectTimeoutP2PDial,
+		&FlagConnectTimeoutSessionCreate,
+		&FlagConnectTimeoutTunnelUp,
+		&FlagConnectRetryAttempts,
+		&FlagConnectRetryBackoff,
+		&FlagConnectRetryJitter,
+		&FlagConnectProbeCandidates,
+		&FlagConnectProbeTimeout,
+		&FlagConnectionMaxBandwidth,
+		&FlagConnectionIdleTimeout,
+		&FlagConnectionKeyRotationInterval,
+		&FlagConnectionAutoConnect,
+		&FlagConnectionAutoConnectProvider,
+		&FlagConnectionAutoConnectCountry,
+		&FlagConnectionAutoConnectServiceType,
+		&FlagConnectionAutoSwitch,
+		&FlagConnectionAutoSwitchMaxPacketLoss,
+		&FlagConnectionAutoSwitchMaxLatency,
+		&FlagConnectionAutoSwitchPeriod,
+		&FlagConnectionNetworkCheckInterval,
+	)
+}
+
+// ParseFlagsConnection function fills in consumer connection options from CLI context.
+func ParseFlagsConnection(ctx *cli.Context) {
+	Current.ParseDurationFlag(ctx, FlagConnectTimeout)
+	Current.ParseDurationFlag(ctx, FlagConnectTimeoutProposalFetch)
+	Current.ParseDurationFlag(ctx, FlagConnectTimeoutP2PDial)
+	Current.ParseDurationFlag(ctx, FlagConnectTimeoutSessionCreate)
+	Current.ParseDurationFlag(ctx, FlagConnectTimeoutTunnelUp)
+	Current.ParseIntFlag(ctx, FlagConnectRetryAttempts)
+	Current.ParseDurationFlag(ctx, FlagConnectRetryBackoff)
+	Current.ParseFloat64Flag(ctx, FlagConnectRetryJitter)
+	Current.ParseIntFlag(ctx, FlagConnectProbeCandidates)
+	Current.ParseDurationFlag(ctx, FlagConnectProbeTimeout)
+	Current.ParseStringFlag(ctx, FlagConnectionMaxBandwidth)
+	Current.ParseDurationFlag(ctx, FlagConnectionIdleTimeout)
+	Current.ParseDurationFlag(ctx, FlagConnectionKeyRotationInterval)
+	Current.ParseBoolFlag(ctx, FlagConnectionAutoConnect)
+	Current.ParseStringFlag(ctx, FlagConnectionAutoConnectProvider)
+	Current.ParseStringFlag(ctx, FlagConnectionAutoConnectCountry)
+	Current.ParseStringFlag(ctx, FlagConnectionAutoConnectServiceType)
+	Current.ParseBoolFlag(ctx, FlagConnectionAutoSwitch)
+	Current.ParseFloat64Flag(ctx, FlagConnectionAutoSwitchMaxPacketLoss)
+	Current.ParseDurationFlag(ctx, FlagConnectionAutoSwitchMaxLatency)
+	Current.ParseDurationFlag(ctx, FlagConnectionAutoSwitchPeriod)
+	Current.ParseDurationFlag(ctx, FlagConnectionNetworkCheckInterval)
+}
@@ -30,6 +30,18 @@ var (
 		Usage: "Address (URL form) of IP detection service",
 		Value: "https://testnet-location.mysterium.network/api/v1/location",
 	}
+	// FlagDNSLeakDetectorURL URL of DNS leak detection service.
+	FlagDNSLeakDetectorURL = cli.StringFlag{
+		Name:  "dns-leak-detector",
+		Usage: "Address (URL form) of DNS leak detection service, DNS leak check is disabled if empty",
+		Value: "",
+	}
+	// FlagDNSLeakCanaryDomain domain served by DNS leak detection service.
+	FlagDNSLeakCanaryDomain = cli.StringFlag{
+		Name:  "dns-leak-detector.canary-domain",
+		Usage: "Domain whose name server is operated by DNS leak detection service",
+		Value: "",
+	}
 	// FlagLocationType location detector type.
 	FlagLocationType = cli.StringFlag{
 		Name:  "location.type",
@@ -66,6 +78,8 @@ var (
 func RegisterFlagsLocation(flags *[]cli.Flag) {
 	*flags = append(*flags,
 		&FlagIPDetectorURL,
+		&FlagDNSLeakDetectorURL,
+		&FlagDNSLeakCanaryDomain,
 		&FlagLocationType,
 		&FlagLocationAddress,
 		&FlagLocationCountry,
@@ -77,6 +91,8 @@ func RegisterFlagsLocation(flags *[]cli.Flag) {
 // ParseFlagsLocation function fills in location options from CLI context.
 func ParseFlagsLocation(ctx *cli.Context) {
 	Current.ParseStringFlag(ctx, FlagIPDetectorURL)
+	Current.ParseStringFlag(ctx, FlagDNSLeakDetectorURL)
+	Current.ParseStringFlag(ctx, FlagDNSLeakCanaryDomain)
 	Current.ParseStringFlag(ctx, FlagLocationType)
 	Current.ParseStringFlag(ctx, FlagLocationAddress)
 	Current.ParseStringFlag(ctx, FlagLocationCountry)
@@ -50,6 +50,12 @@ var (
 		Usage: "URI of message broker",
 		Value: metadata.DefaultNetwork.BrokerAddress,
 	}
+	// FlagBrokerFallbackAddresses lists message brokers used while the main one is unreachable.
+	FlagBrokerFallbackAddresses = cli.StringSliceFlag{
+		Name:  "broker-fallback-addresses",
+		Usage: "URIs of message brokers used while the main one is unreachable, the main one is reconnected to once it is back",
+		Value: cli.NewStringSlice(),
+	}
 	// FlagEtherRPC URL or IPC socket to connect to Ethereum node.
 	FlagEtherRPC = cli.StringFlag{
 		Name:  "ether.client.rpc",
@@ -68,6 +74,12 @@ var (
 		Usage: "Enables NAT port mapping",
 		Value: true,
 	}
+	// FlagNATSTUNServers lists STUN servers used to detect NAT type of the node.
+	FlagNATSTUNServers = cli.StringSliceFlag{
+		Name:  "nat.stun-servers",
+		Usage: "STUN servers used to detect NAT type announced in service proposals, at least two are required to tell NAT type, none disables detection",
+		Value: cli.NewStringSlice("stun.l.google.com:19302", "stun1.l.google.com:19302"),
+	}
 	// FlagIncomingFirewall enables incoming traffic filtering.
 	FlagIncomingFirewall = cli.BoolFlag{
 		Name:  "incoming-firewall",
@@ -90,8 +102,10 @@ func RegisterFlagsNetwork(flags *[]cli.Flag) {
 		&FlagLocalnet,
 		&FlagPortMapping,
 		&FlagNATPunching,
+		&FlagNATSTUNServers,
 		&FlagAPIAddress,
 		&FlagBrokerAddress,
+		&FlagBrokerFallbackAddresses,
 		&FlagEtherRPC,
 		&FlagIncomingFirewall,
 		&FlagOutgoingFirewall,
@@ -106,9 +120,11 @@ func ParseFlagsNetwork(ctx *cli.Context) {
 	Current.ParseBoolFlag(ctx, FlagBetanet)
 	Current.ParseStringFlag(ctx, FlagAPIAddress)
 	Current.ParseStringFlag(ctx, FlagBrokerAddress)
+	Current.ParseStringSliceFlag(ctx, FlagBrokerFallbackAddresses)
 	Current.ParseStringFlag(ctx, FlagEtherRPC)
 	Current.ParseBoolFlag(ctx, FlagPortMapping)
 	Current.ParseBoolFlag(ctx, FlagNATPunching)
+	Current.ParseStringSliceFlag(ctx, FlagNATSTUNServers)
 	Current.ParseBoolFlag(ctx, FlagIncomingFirewall)
 	Current.ParseBoolFlag(ctx, FlagOutgoingFirewall)
 }
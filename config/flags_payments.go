@@ -109,6 +109,12 @@ var (
 		Usage: "sets the upper limit of session payment value before forcing an invoice. If this value is exceeded before a payment interval is reached, an invoice is sent.",
 		Value: "30000000000000000",
 	}
+	// FlagPaymentsProviderSessionKeyTTL sets the lifetime of the session key used to sign provider invoices.
+	FlagPaymentsProviderSessionKeyTTL = cli.DurationFlag{
+		Name:  "payments.provider.session-key-ttl",
+		Usage: "Lifetime of the short-lived session key signing provider invoices. It does not replace the identity key, which still signs promises, settlements and registration",
+		Value: time.Hour,
+	}
 )
 
 // RegisterFlagsPayments function register payments flags to flag list.
@@ -127,6 +133,7 @@ func RegisterFlagsPayments(flags *[]cli.Flag) {
 		&FlagPaymentsConsumerPricePerGBLowerBound,
 		&FlagPaymentsConsumerDataLeewayMegabytes,
 		&FlagPaymentsMaxUnpaidInvoiceValue,
+		&FlagPaymentsProviderSessionKeyTTL,
 		&FlagPaymentsWethAddress,
 		&FlagPaymentsDaiAddress,
 	)
@@ -146,6 +153,7 @@ func ParseFlagsPayments(ctx *cli.Context) {
 	Current.ParseStringFlag(ctx, FlagPaymentsConsumerPricePerGBLowerBound)
 	Current.ParseUInt64Flag(ctx, FlagPaymentsConsumerDataLeewayMegabytes)
 	Current.ParseStringFlag(ctx, FlagPaymentsMaxUnpaidInvoiceValue)
+	Current.ParseDurationFlag(ctx, FlagPaymentsProviderSessionKeyTTL)
 	Current.ParseStringFlag(ctx, FlagPaymentsWethAddress)
 	Current.ParseStringFlag(ctx, FlagPaymentsDaiAddress)
 }
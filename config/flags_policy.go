@@ -37,6 +37,12 @@ var (
 		Usage: `Proposal fetch interval { "30s", "3m", "1h20m30s" }`,
 		Value: 10 * time.Minute,
 	}
+	// FlagAccessPolicyMaxStale limits the age of cached policy lists used while trust oracle is unreachable.
+	FlagAccessPolicyMaxStale = cli.DurationFlag{
+		Name:  "access-policy.max-stale",
+		Usage: "How long cached access policy lists are used while trust oracle is unreachable, 0 disables cached lists",
+		Value: 24 * time.Hour,
+	}
 )
 
 // RegisterFlagsPolicy function registers Policy Oracle flags to flag list.
@@ -44,6 +50,7 @@ func RegisterFlagsPolicy(flags *[]cli.Flag) {
 	*flags = append(*flags,
 		&FlagAccessPolicyAddress,
 		&FlagAccessPolicyFetchInterval,
+		&FlagAccessPolicyMaxStale,
 	)
 }
 
@@ -51,4 +58,5 @@ func RegisterFlagsPolicy(flags *[]cli.Flag) {
 func ParseFlagsPolicy(ctx *cli.Context) {
 	Current.ParseStringFlag(ctx, FlagAccessPolicyAddress)
 	Current.ParseDurationFlag(ctx, FlagAccessPolicyFetchInterval)
+	Current.ParseDurationFlag(ctx, FlagAccessPolicyMaxStale)
 }
@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// FlagHTTPProxyPort sets the port HTTP proxy service accepts consumer connections on.
+	FlagHTTPProxyPort = cli.IntFlag{
+		Name:  "http-proxy.port",
+		Usage: "TCP port HTTP proxy service accepts consumer connections on, it has to be reachable from the internet",
+		Value: 4480,
+	}
+	// FlagHTTPProxyLocalAddress sets the address consumer side HTTP proxy listens on for local applications.
+	FlagHTTPProxyLocalAddress = cli.StringFlag{
+		Name:  "http-proxy.local-address",
+		Usage: "Address the HTTP proxy listens on for local applications (e.g. browser) while connected to HTTP proxy service",
+		Value: "127.0.0.1:4481",
+	}
+	// FlagHTTPProxyPriceMinute sets the price per minute for provided HTTP proxy service.
+	FlagHTTPProxyPriceMinute = cli.Float64Flag{
+		Name:  "http-proxy.price-minute",
+		Usage: "Sets the price of the HTTP proxy service per minute.",
+	}
+	// FlagHTTPProxyPriceGB sets the price per GiB for provided HTTP proxy service.
+	FlagHTTPProxyPriceGB = cli.Float64Flag{
+		Name:  "http-proxy.price-gb",
+		Usage: "Sets the price of the HTTP proxy service per GiB.",
+	}
+	// FlagHTTPProxyAccessPolicies a comma-separated list of access policies that determines allowed identities to use the service.
+	FlagHTTPProxyAccessPolicies = cli.StringFlag{
+		Name:  "http-proxy.access-policies",
+		Usage: "Comma separated list that determines the access policies of the HTTP proxy service.",
+	}
+)
+
+// RegisterFlagsServiceHTTPProxy function register HTTP proxy flags to flag list
+func RegisterFlagsServiceHTTPProxy(flags *[]cli.Flag) {
+	*flags = append(*flags,
+		&FlagHTTPProxyPort,
+		&FlagHTTPProxyLocalAddress,
+		&FlagHTTPProxyPriceMinute,
+		&FlagHTTPProxyPriceGB,
+		&FlagHTTPProxyAccessPolicies,
+	)
+}
+
+// ParseFlagsServiceHTTPProxy parses CLI flags and registers value to configuration
+func ParseFlagsServiceHTTPProxy(ctx *cli.Context) {
+	Current.ParseIntFlag(ctx, FlagHTTPProxyPort)
+	Current.ParseStringFlag(ctx, FlagHTTPProxyLocalAddress)
+	Current.ParseFloat64Flag(ctx, FlagHTTPProxyPriceMinute)
+	Current.ParseFloat64Flag(ctx, FlagHTTPProxyPriceGB)
+	Current.ParseStringFlag(ctx, FlagHTTPProxyAccessPolicies)
+}
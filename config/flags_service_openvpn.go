@@ -46,6 +46,26 @@ var (
 		Usage: "OpenVPN subnet netmask",
 		Value: "255.255.255.0",
 	}
+	// FlagOpenvpnCipher data channel cipher.
+	FlagOpenvpnCipher = cli.StringFlag{
+		Name:  "openvpn.cipher",
+		Usage: "OpenVPN data channel cipher. Options: { AES-256-GCM, AES-128-GCM, CHACHA20-POLY1305, AES-256-CBC }, AES-256-GCM if not specified",
+	}
+	// FlagOpenvpnAuth HMAC digest authenticating data channel packets.
+	FlagOpenvpnAuth = cli.StringFlag{
+		Name:  "openvpn.auth",
+		Usage: "OpenVPN data channel auth digest, required by non-AEAD ciphers. Options: { none, SHA256, SHA384, SHA512 }, none if not specified",
+	}
+	// FlagOpenvpnCompression data channel compression.
+	FlagOpenvpnCompression = cli.StringFlag{
+		Name:  "openvpn.compression",
+		Usage: "OpenVPN data channel compression, disabled if not specified. Options: { lz4-v2, lzo }",
+	}
+	// FlagOpenvpnTLSVersionMin minimum TLS version of control channel.
+	FlagOpenvpnTLSVersionMin = cli.StringFlag{
+		Name:  "openvpn.tls-version-min",
+		Usage: "OpenVPN minimum TLS version of control channel. Options: { 1.2, 1.3 }, 1.2 if not specified",
+	}
 	// FlagOpenVPNPriceMinute sets the price per minute for provided OpenVPN service.
 	FlagOpenVPNPriceMinute = cli.Float64Flag{
 		Name:  "openvpn.price-minute",
@@ -70,6 +90,10 @@ func RegisterFlagsServiceOpenvpn(flags *[]cli.Flag) {
 		&FlagOpenvpnPort,
 		&FlagOpenvpnSubnet,
 		&FlagOpenvpnNetmask,
+		&FlagOpenvpnCipher,
+		&FlagOpenvpnAuth,
+		&FlagOpenvpnCompression,
+		&FlagOpenvpnTLSVersionMin,
 		&FlagOpenVPNPriceMinute,
 		&FlagOpenVPNPriceGB,
 		&FlagOpenVPNAccessPolicies,
@@ -82,6 +106,10 @@ func ParseFlagsServiceOpenvpn(ctx *cli.Context) {
 	Current.ParseIntFlag(ctx, FlagOpenvpnPort)
 	Current.ParseStringFlag(ctx, FlagOpenvpnSubnet)
 	Current.ParseStringFlag(ctx, FlagOpenvpnNetmask)
+	Current.ParseStringFlag(ctx, FlagOpenvpnCipher)
+	Current.ParseStringFlag(ctx, FlagOpenvpnAuth)
+	Current.ParseStringFlag(ctx, FlagOpenvpnCompression)
+	Current.ParseStringFlag(ctx, FlagOpenvpnTLSVersionMin)
 	Current.ParseFloat64Flag(ctx, FlagOpenVPNPriceMinute)
 	Current.ParseFloat64Flag(ctx, FlagOpenVPNPriceGB)
 	Current.ParseStringFlag(ctx, FlagOpenVPNAccessPolicies)
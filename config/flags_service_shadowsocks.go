@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// FlagShadowsocksPort sets the port shadowsocks service accepts consumer connections on.
+	FlagShadowsocksPort = cli.IntFlag{
+		Name:  "shadowsocks.port",
+		Usage: "TCP port shadowsocks service accepts consumer connections on, it has to be reachable from the internet",
+		Value: 8388,
+	}
+	// FlagShadowsocksCipher sets the cipher shadowsocks service encrypts connections with.
+	FlagShadowsocksCipher = cli.StringFlag{
+		Name:  "shadowsocks.cipher",
+		Usage: "Cipher of the shadowsocks service: chacha20-ietf-poly1305, aes-256-gcm or aes-128-gcm",
+		Value: "chacha20-ietf-poly1305",
+	}
+	// FlagShadowsocksLocalAddress sets the address consumer side SOCKS5 proxy listens on for local applications.
+	FlagShadowsocksLocalAddress = cli.StringFlag{
+		Name:  "shadowsocks.local-address",
+		Usage: "Address the SOCKS5 proxy listens on for local applications while connected to shadowsocks service",
+		Value: "127.0.0.1:1080",
+	}
+	// FlagShadowsocksPriceMinute sets the price per minute for provided shadowsocks service.
+	FlagShadowsocksPriceMinute = cli.Float64Flag{
+		Name:  "shadowsocks.price-minute",
+		Usage: "Sets the price of the shadowsocks service per minute.",
+	}
+	// FlagShadowsocksPriceGB sets the price per GiB for provided shadowsocks service.
+	FlagShadowsocksPriceGB = cli.Float64Flag{
+		Name:  "shadowsocks.price-gb",
+		Usage: "Sets the price of the shadowsocks service per GiB.",
+	}
+	// FlagShadowsocksAccessPolicies a comma-separated list of access policies that determines allowed identities to use the service.
+	FlagShadowsocksAccessPolicies = cli.StringFlag{
+		Name:  "shadowsocks.access-policies",
+		Usage: "Comma separated list that determines the access policies of the shadowsocks service.",
+	}
+)
+
+// RegisterFlagsServiceShadowsocks function register shadowsocks flags to flag list
+func RegisterFlagsServiceShadowsocks(flags *[]cli.Flag) {
+	*flags = append(*flags,
+		&FlagShadowsocksPort,
+		&FlagShadowsocksCipher,
+		&FlagShadowsocksLocalAddress,
+		&FlagShadowsocksPriceMinute,
+		&FlagShadowsocksPriceGB,
+		&FlagShadowsocksAccessPolicies,
+	)
+}
+
+// ParseFlagsServiceShadowsocks parses CLI flags and registers value to configuration
+func ParseFlagsServiceShadowsocks(ctx *cli.Context) {
+	Current.ParseIntFlag(ctx, FlagShadowsocksPort)
+	Current.ParseStringFlag(ctx, FlagShadowsocksCipher)
+	Current.ParseStringFlag(ctx, FlagShadowsocksLocalAddress)
+	Current.ParseFloat64Flag(ctx, FlagShadowsocksPriceMinute)
+	Current.ParseFloat64Flag(ctx, FlagShadowsocksPriceGB)
+	Current.ParseStringFlag(ctx, FlagShadowsocksAccessPolicies)
+}
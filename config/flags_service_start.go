@@ -18,6 +18,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/urfave/cli/v2"
 )
 
@@ -59,6 +61,192 @@ var (
 		Usage: "Sets the price per minute applied to provider service.",
 		Value: 0.00001,
 	}
+	// FlagPaymentDynamicPricing enables demand based pricing of provided services.
+	FlagPaymentDynamicPricing = cli.BoolFlag{
+		Name:  "payment.dynamic-pricing",
+		Usage: "Adjusts prices of provided services to the provider utilization within dynamic pricing bounds",
+		Value: false,
+	}
+	// FlagPaymentDynamicPricingPricePerGBMin sets the price per GiB of idle provider.
+	FlagPaymentDynamicPricingPricePerGBMin = cli.Float64Flag{
+		Name:  "payment.dynamic-pricing.price-gb-min",
+		Usage: "Price per GiB applied when provider is idle",
+		Value: 0.2,
+	}
+	// FlagPaymentDynamicPricingPricePerGBMax sets the price per GiB of fully utilized provider.
+	FlagPaymentDynamicPricingPricePerGBMax = cli.Float64Flag{
+		Name:  "payment.dynamic-pricing.price-gb-max",
+		Usage: "Price per GiB applied when provider is fully utilized",
+		Value: 0.4,
+	}
+	// FlagPaymentDynamicPricingPricePerMinuteMin sets the price per minute of idle provider.
+	FlagPaymentDynamicPricingPricePerMinuteMin = cli.Float64Flag{
+		Name:  "payment.dynamic-pricing.price-minute-min",
+		Usage: "Price per minute applied when provider is idle",
+		Value: 0.00001,
+	}
+	// FlagPaymentDynamicPricingPricePerMinuteMax sets the price per minute of fully utilized provider.
+	FlagPaymentDynamicPricingPricePerMinuteMax = cli.Float64Flag{
+		Name:  "payment.dynamic-pricing.price-minute-max",
+		Usage: "Price per minute applied when provider is fully utilized",
+		Value: 0.00002,
+	}
+	// FlagPaymentDynamicPricingSessions sets the number of sessions at which provider is fully utilized.
+	FlagPaymentDynamicPricingSessions = cli.IntFlag{
+		Name:  "payment.dynamic-pricing.sessions",
+		Usage: "Number of active sessions at which provider is considered fully utilized",
+		Value: 10,
+	}
+	// FlagPaymentDynamicPricingBandwidth sets the traffic speed at which provider is fully utilized.
+	FlagPaymentDynamicPricingBandwidth = cli.StringFlag{
+		Name:  "payment.dynamic-pricing.bandwidth",
+		Usage: "Traffic speed at which provider is considered fully utilized, e.g. 100mbps, empty value ignores traffic",
+		Value: "",
+	}
+	// FlagServiceMaxSessions limits concurrent sessions of each provided service.
+	FlagServiceMaxSessions = cli.IntFlag{
+		Name:  "service.max-sessions",
+		Usage: "Maximum number of concurrent sessions served by each service, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagServiceMaxConsumerSessions limits concurrent sessions of a single consumer.
+	FlagServiceMaxConsumerSessions = cli.IntFlag{
+		Name:  "service.max-consumer-sessions",
+		Usage: "Maximum number of concurrent sessions of a single consumer across all services, 0 means unlimited",
+		Value: 2,
+	}
+	// FlagServiceTrafficQuota limits monthly traffic of all provided services.
+	FlagServiceTrafficQuota = cli.Uint64Flag{
+		Name:  "service.traffic-quota",
+		Usage: "Monthly traffic quota of all services in GiB, services are paused once it is used up until the next month, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagServiceWebhookURL sets the URL receiving provider session lifecycle events.
+	FlagServiceWebhookURL = cli.StringFlag{
+		Name:  "service.webhook.url",
+		Usage: "URL to POST session started and ended events of provided services to, empty value disables webhooks",
+		Value: "",
+	}
+	// FlagServiceWebhookSecret sets the key used to sign webhook requests.
+	FlagServiceWebhookSecret = cli.StringFlag{
+		Name:  "service.webhook.secret",
+		Usage: "Key used to sign webhook requests with HMAC-SHA256 in X-Mysterium-Signature header, empty value leaves them unsigned",
+		Value: "",
+	}
+	// FlagServiceOperatingHours limits time when services are provided.
+	FlagServiceOperatingHours = cli.StringFlag{
+		Name:  "service.operating-hours",
+		Usage: "Time windows when services are provided separated by ';', e.g. 'mon-fri 18:00-08:00; sat,sun 00:00-00:00', empty value means always",
+		Value: "",
+	}
+	// FlagServiceOperatingHoursDrain sets the time given to sessions to finish once operating hours end.
+	FlagServiceOperatingHoursDrain = cli.DurationFlag{
+		Name:  "service.operating-hours.drain",
+		Usage: "Time given to established sessions to finish once operating hours end",
+		Value: 5 * time.Minute,
+	}
+	// FlagServiceShutdownDrain sets the time given to consumers to close their sessions when a service is stopped.
+	FlagServiceShutdownDrain = cli.DurationFlag{
+		Name:  "service.shutdown.drain",
+		Usage: "Time given to consumers to close their sessions when a service or node is stopped, 0 stops services immediately",
+		Value: 10 * time.Second,
+	}
+	// FlagServiceThrottleCPU pauses services while CPU usage is above the limit.
+	FlagServiceThrottleCPU = cli.Float64Flag{
+		Name:  "service.throttle.cpu",
+		Usage: "CPU usage in percent above which services are paused until it drops, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagServiceThrottleMemory pauses services while memory usage is above the limit.
+	FlagServiceThrottleMemory = cli.Float64Flag{
+		Name:  "service.throttle.memory",
+		Usage: "Memory usage in percent above which services are paused until it drops, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagServiceThrottleFileDescriptors pauses services while open file descriptors are above the limit.
+	FlagServiceThrottleFileDescriptors = cli.Float64Flag{
+		Name:  "service.throttle.fds",
+		Usage: "Open file descriptors in percent of the process limit above which services are paused until they drop, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagServiceSpeedTestTargets sets URLs downloaded to measure provider bandwidth.
+	FlagServiceSpeedTestTargets = cli.StringSliceFlag{
+		Name:  "service.speed-test.targets",
+		Usage: "URLs of large files downloaded to measure bandwidth announced in service proposals, none disables speed test",
+		Value: cli.NewStringSlice(),
+	}
+	// FlagServiceSpeedTestInterval sets the period of provider bandwidth measurement.
+	FlagServiceSpeedTestInterval = cli.DurationFlag{
+		Name:  "service.speed-test.interval",
+		Usage: "Period of provider bandwidth measurement",
+		Value: 6 * time.Hour,
+	}
+	// FlagServiceSpeedTestDuration limits download from a single speed test target.
+	FlagServiceSpeedTestDuration = cli.DurationFlag{
+		Name:  "service.speed-test.duration",
+		Usage: "Time limit of download from a single speed test target",
+		Value: 10 * time.Second,
+	}
+	// FlagServiceAbuseDetection enables detection of consumers abusing provider connection.
+	FlagServiceAbuseDetection = cli.BoolFlag{
+		Name:  "service.abuse-detection",
+		Usage: "Throttle or terminate sessions of consumers sending spam, scanning ports or opening connections excessively fast, temporarily ban consumers of terminated sessions (Linux only)",
+		Value: false,
+	}
+	// FlagServiceAbuseDetectionSpamPorts sets destination ports of mail servers consumers are not allowed to connect to.
+	FlagServiceAbuseDetectionSpamPorts = cli.StringSliceFlag{
+		Name:  "service.abuse-detection.spam-ports",
+		Usage: "Destination ports of mail servers consumers are not allowed to connect to",
+		Value: cli.NewStringSlice("25"),
+	}
+	// FlagServiceAbuseDetectionPortScanPorts sets the number of ports of a single host which is considered a port scan.
+	FlagServiceAbuseDetectionPortScanPorts = cli.IntFlag{
+		Name:  "service.abuse-detection.port-scan-ports",
+		Usage: "Number of distinct ports of a single host connected to at once which is considered a port scan, 0 disables the rule",
+		Value: 100,
+	}
+	// FlagServiceAbuseDetectionMaxNewConnections sets the new connection rate which is considered excessive.
+	FlagServiceAbuseDetectionMaxNewConnections = cli.IntFlag{
+		Name:  "service.abuse-detection.max-new-connections",
+		Usage: "Maximum number of new connections of a session per minute, 0 disables the rule",
+		Value: 1000,
+	}
+	// FlagServiceAbuseDetectionAction sets the action taken against abusive sessions.
+	FlagServiceAbuseDetectionAction = cli.StringFlag{
+		Name:  "service.abuse-detection.action",
+		Usage: "Action taken against abusive sessions: 'throttle' (terminated if abuse continues) or 'terminate'",
+		Value: "throttle",
+	}
+	// FlagServiceAbuseDetectionThrottleBandwidth sets the speed abusive sessions are limited to.
+	FlagServiceAbuseDetectionThrottleBandwidth = cli.StringFlag{
+		Name:  "service.abuse-detection.throttle-bandwidth",
+		Usage: "Speed abusive sessions are limited to, e.g. 1mbps",
+		Value: "1mbps",
+	}
+	// FlagServiceAbuseDetectionBan sets the time consumers of terminated sessions are not allowed to create sessions.
+	FlagServiceAbuseDetectionBan = cli.DurationFlag{
+		Name:  "service.abuse-detection.ban",
+		Usage: "Time consumers of sessions terminated for abuse are not allowed to create sessions, 0 disables bans",
+		Value: 24 * time.Hour,
+	}
+	// FlagServiceAbuseDetectionExempt sets trusted consumers which are never penalized.
+	FlagServiceAbuseDetectionExempt = cli.StringSliceFlag{
+		Name:  "service.abuse-detection.exempt",
+		Usage: "Identities of trusted consumers which are never penalized by abuse detection",
+		Value: cli.NewStringSlice(),
+	}
+	// FlagServiceSessionBandwidth limits speed of each provided session.
+	FlagServiceSessionBandwidth = cli.StringFlag{
+		Name:  "service.session-bandwidth",
+		Usage: "Speed limit of each consumer session, e.g. 10mbps, empty value means unlimited",
+		Value: "",
+	}
+	// FlagServiceSessionBandwidthAdvertise advertises session speed limit in service proposals.
+	FlagServiceSessionBandwidthAdvertise = cli.BoolFlag{
+		Name:  "service.session-bandwidth.advertise",
+		Usage: "Advertise session speed limit in service proposals",
+		Value: false,
+	}
 )
 
 // RegisterFlagsServiceStart registers CLI flags used to start a service.
@@ -70,6 +258,37 @@ func RegisterFlagsServiceStart(flags *[]cli.Flag) {
 		&FlagPaymentPricePerGB,
 		&FlagPaymentPricePerMinute,
 		&FlagAccessPolicyList,
+		&FlagPaymentDynamicPricing,
+		&FlagPaymentDynamicPricingPricePerGBMin,
+		&FlagPaymentDynamicPricingPricePerGBMax,
+		&FlagPaymentDynamicPricingPricePerMinuteMin,
+		&FlagPaymentDynamicPricingPricePerMinuteMax,
+		&FlagPaymentDynamicPricingSessions,
+		&FlagPaymentDynamicPricingBandwidth,
+		&FlagServiceMaxSessions,
+		&FlagServiceMaxConsumerSessions,
+		&FlagServiceSessionBandwidth,
+		&FlagServiceSessionBandwidthAdvertise,
+		&FlagServiceTrafficQuota,
+		&FlagServiceWebhookURL,
+		&FlagServiceWebhookSecret,
+		&FlagServiceOperatingHours,
+		&FlagServiceOperatingHoursDrain,
+		&FlagServiceShutdownDrain,
+		&FlagServiceThrottleCPU,
+		&FlagServiceThrottleMemory,
+		&FlagServiceThrottleFileDescriptors,
+		&FlagServiceSpeedTestTargets,
+		&FlagServiceSpeedTestInterval,
+		&FlagServiceSpeedTestDuration,
+		&FlagServiceAbuseDetection,
+		&FlagServiceAbuseDetectionSpamPorts,
+		&FlagServiceAbuseDetectionPortScanPorts,
+		&FlagServiceAbuseDetectionMaxNewConnections,
+		&FlagServiceAbuseDetectionAction,
+		&FlagServiceAbuseDetectionThrottleBandwidth,
+		&FlagServiceAbuseDetectionBan,
+		&FlagServiceAbuseDetectionExempt,
 	)
 }
 
@@ -81,4 +300,35 @@ func ParseFlagsServiceStart(ctx *cli.Context) {
 	Current.ParseFloat64Flag(ctx, FlagPaymentPricePerGB)
 	Current.ParseFloat64Flag(ctx, FlagPaymentPricePerMinute)
 	Current.ParseStringFlag(ctx, FlagAccessPolicyList)
+	Current.ParseBoolFlag(ctx, FlagPaymentDynamicPricing)
+	Current.ParseFloat64Flag(ctx, FlagPaymentDynamicPricingPricePerGBMin)
+	Current.ParseFloat64Flag(ctx, FlagPaymentDynamicPricingPricePerGBMax)
+	Current.ParseFloat64Flag(ctx, FlagPaymentDynamicPricingPricePerMinuteMin)
+	Current.ParseFloat64Flag(ctx, FlagPaymentDynamicPricingPricePerMinuteMax)
+	Current.ParseIntFlag(ctx, FlagPaymentDynamicPricingSessions)
+	Current.ParseStringFlag(ctx, FlagPaymentDynamicPricingBandwidth)
+	Current.ParseIntFlag(ctx, FlagServiceMaxSessions)
+	Current.ParseIntFlag(ctx, FlagServiceMaxConsumerSessions)
+	Current.ParseStringFlag(ctx, FlagServiceSessionBandwidth)
+	Current.ParseBoolFlag(ctx, FlagServiceSessionBandwidthAdvertise)
+	Current.ParseUInt64Flag(ctx, FlagServiceTrafficQuota)
+	Current.ParseStringFlag(ctx, FlagServiceWebhookURL)
+	Current.ParseStringFlag(ctx, FlagServiceWebhookSecret)
+	Current.ParseStringFlag(ctx, FlagServiceOperatingHours)
+	Current.ParseDurationFlag(ctx, FlagServiceOperatingHoursDrain)
+	Current.ParseDurationFlag(ctx, FlagServiceShutdownDrain)
+	Current.ParseFloat64Flag(ctx, FlagServiceThrottleCPU)
+	Current.ParseFloat64Flag(ctx, FlagServiceThrottleMemory)
+	Current.ParseFloat64Flag(ctx, FlagServiceThrottleFileDescriptors)
+	Current.ParseStringSliceFlag(ctx, FlagServiceSpeedTestTargets)
+	Current.ParseDurationFlag(ctx, FlagServiceSpeedTestInterval)
+	Current.ParseDurationFlag(ctx, FlagServiceSpeedTestDuration)
+	Current.ParseBoolFlag(ctx, FlagServiceAbuseDetection)
+	Current.ParseStringSliceFlag(ctx, FlagServiceAbuseDetectionSpamPorts)
+	Current.ParseIntFlag(ctx, FlagServiceAbuseDetectionPortScanPorts)
+	Current.ParseIntFlag(ctx, FlagServiceAbuseDetectionMaxNewConnections)
+	Current.ParseStringFlag(ctx, FlagServiceAbuseDetectionAction)
+	Current.ParseStringFlag(ctx, FlagServiceAbuseDetectionThrottleBandwidth)
+	Current.ParseDurationFlag(ctx, FlagServiceAbuseDetectionBan)
+	Current.ParseStringSliceFlag(ctx, FlagServiceAbuseDetectionExempt)
 }
@@ -34,6 +34,12 @@ var (
 		Usage: "Subnet to be used by the wireguard service",
 		Value: "10.182.0.0/16",
 	}
+	// FlagWireguardBackend selects WireGuard implementation.
+	FlagWireguardBackend = cli.StringFlag{
+		Name:  "wireguard.backend",
+		Usage: "WireGuard implementation: kernel, userspace or auto to use kernel module when it is available",
+		Value: "auto",
+	}
 	// FlagWireguardPriceMinute sets the price per minute for provided wireguard service.
 	FlagWireguardPriceMinute = cli.Float64Flag{
 		Name:  "wireguard.price-minute",
@@ -56,6 +62,7 @@ func RegisterFlagsServiceWireguard(flags *[]cli.Flag) {
 	*flags = append(*flags,
 		&FlagWireguardListenPorts,
 		&FlagWireguardListenSubnet,
+		&FlagWireguardBackend,
 		&FlagWireguardPriceMinute,
 		&FlagWireguardPriceGB,
 		&FlagWireguardAccessPolicies,
@@ -66,6 +73,7 @@ func RegisterFlagsServiceWireguard(flags *[]cli.Flag) {
 func ParseFlagsServiceWireguard(ctx *cli.Context) {
 	Current.ParseStringFlag(ctx, FlagWireguardListenPorts)
 	Current.ParseStringFlag(ctx, FlagWireguardListenSubnet)
+	Current.ParseStringFlag(ctx, FlagWireguardBackend)
 	Current.ParseFloat64Flag(ctx, FlagWireguardPriceMinute)
 	Current.ParseFloat64Flag(ctx, FlagWireguardPriceGB)
 	Current.ParseStringFlag(ctx, FlagWireguardAccessPolicies)
@@ -61,6 +61,30 @@ var (
 		Usage: "the stake we'll use when registering provider",
 		Value: "50000000000000000000",
 	}
+	// FlagTransactorRegistrationRetryMaxAttempts determines how many times failed or stuck identity registration is re-submitted.
+	FlagTransactorRegistrationRetryMaxAttempts = cli.IntFlag{
+		Name:  "transactor.registration-retry.max-attempts",
+		Usage: "the max attempts to re-submit failed or stuck identity registration, 0 disables retries",
+		Value: 10,
+	}
+	// FlagTransactorRegistrationRetryDelay determines the delay before the first registration re-submission.
+	FlagTransactorRegistrationRetryDelay = cli.DurationFlag{
+		Name:  "transactor.registration-retry.delay",
+		Usage: "the initial delay before failed identity registration is re-submitted, doubled after each attempt",
+		Value: time.Minute,
+	}
+	// FlagTransactorRegistrationRetryMaxDelay caps the delay between registration re-submissions.
+	FlagTransactorRegistrationRetryMaxDelay = cli.DurationFlag{
+		Name:  "transactor.registration-retry.max-delay",
+		Usage: "the max delay between identity registration re-submissions",
+		Value: time.Hour,
+	}
+	// FlagTransactorRegistrationRetryStuckTimeout determines how long registration may stay in progress before it is re-submitted.
+	FlagTransactorRegistrationRetryStuckTimeout = cli.DurationFlag{
+		Name:  "transactor.registration-retry.stuck-timeout",
+		Usage: "the duration after which in progress identity registration is considered stuck and re-submitted",
+		Value: time.Minute * 30,
+	}
 )
 
 // RegisterFlagsTransactor function register network flags to flag list
@@ -73,6 +97,10 @@ func RegisterFlagsTransactor(flags *[]cli.Flag) {
 		&FlagTransactorProviderMaxRegistrationAttempts,
 		&FlagTransactorProviderRegistrationRetryDelay,
 		&FlagTransactorProviderRegistrationStake,
+		&FlagTransactorRegistrationRetryMaxAttempts,
+		&FlagTransactorRegistrationRetryDelay,
+		&FlagTransactorRegistrationRetryMaxDelay,
+		&FlagTransactorRegistrationRetryStuckTimeout,
 	)
 }
 
@@ -84,4 +112,8 @@ func ParseFlagsTransactor(ctx *cli.Context) {
 	Current.ParseIntFlag(ctx, FlagTransactorProviderMaxRegistrationAttempts)
 	Current.ParseDurationFlag(ctx, FlagTransactorProviderRegistrationRetryDelay)
 	Current.ParseStringFlag(ctx, FlagTransactorProviderRegistrationStake)
+	Current.ParseIntFlag(ctx, FlagTransactorRegistrationRetryMaxAttempts)
+	Current.ParseDurationFlag(ctx, FlagTransactorRegistrationRetryDelay)
+	Current.ParseDurationFlag(ctx, FlagTransactorRegistrationRetryMaxDelay)
+	Current.ParseDurationFlag(ctx, FlagTransactorRegistrationRetryStuckTimeout)
 }
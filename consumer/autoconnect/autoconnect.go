@@ -0,0 +1,177 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autoconnect
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/identity/selector"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// maxFallbacks limits how many providers matching country policy are kept for failover
+const maxFallbacks = 3
+
+// ErrNoProposals is returned when no proposal matches auto connect target
+var ErrNoProposals = errors.New("no proposals match auto connect target")
+
+// Options describes consumer identity used by auto connect
+type Options struct {
+	Identity   string
+	Passphrase string
+}
+
+// hermesResolver resolves hermes the consumer pays with.
+type hermesResolver interface {
+	HermesID(id identity.Identity) common.Address
+}
+
+type connectionManager interface {
+	Connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params connection.ConnectParams) error
+	Status() connectionstate.Status
+}
+
+// AutoConnect connects consumer to the target on node start
+// and reconnects after the connection is lost, until stopped.
+type AutoConnect struct {
+	manager    connectionManager
+	proposals  proposal.Repository
+	identities selector.Handler
+	hermes     hermesResolver
+	target     proposal.Target
+	opts       Options
+
+	retryMin time.Duration
+	retryMax time.Duration
+
+	reconnect chan struct{}
+	stopOnce  sync.Once
+	stop      chan struct{}
+}
+
+// NewAutoConnect creates auto connect for the given target
+func NewAutoConnect(manager connectionManager, proposals proposal.Repository, identities selector.Handler, hermes hermesResolver, target proposal.Target, opts Options) *AutoConnect {
+	return &AutoConnect{
+		manager:    manager,
+		proposals:  proposals,
+		identities: identities,
+		hermes:     hermes,
+		target:     target,
+		opts:       opts,
+		retryMin:   10 * time.Second,
+		retryMax:   5 * time.Minute,
+		reconnect:  make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to connection session events to notice lost connections.
+func (ac *AutoConnect) Subscribe(bus eventbus.Subscriber) error {
+	return bus.SubscribeAsync(connectionstate.AppTopicConnectionSession, ac.consumeSessionEvent)
+}
+
+func (ac *AutoConnect) consumeSessionEvent(e connectionstate.AppEventConnectionSession) {
+	if e.Status != connectionstate.SessionEndedStatus || !e.SessionInfo.DisconnectReason.Unexpected() {
+		return
+	}
+
+	select {
+	case ac.reconnect <- struct{}{}:
+	default:
+	}
+}
+
+// Start connects to the target and keeps reconnecting after the connection is lost. Blocks until stopped.
+func (ac *AutoConnect) Start() {
+	ac.connect()
+	for {
+		select {
+		case <-ac.stop:
+			return
+		case <-ac.reconnect:
+			log.Info().Msg("Connection lost, auto connecting")
+			ac.connect()
+		}
+	}
+}
+
+// Stop stops reconnecting, current connection is left intact.
+func (ac *AutoConnect) Stop() {
+	ac.stopOnce.Do(func() {
+		close(ac.stop)
+	})
+}
+
+// connect retries until connected, stopped or connection is established by someone else.
+func (ac *AutoConnect) connect() {
+	delay := ac.retryMin
+	for {
+		if ac.manager.Status().State != connectionstate.NotConnected {
+			return
+		}
+
+		err := ac.connectOnce()
+		if err == nil {
+			return
+		}
+		log.Warn().Err(err).Msgf("Auto connect failed, retrying in %s", delay)
+
+		select {
+		case <-ac.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > ac.retryMax {
+			delay = ac.retryMax
+		}
+	}
+}
+
+func (ac *AutoConnect) connectOnce() error {
+	consumerID, err := ac.identities.UseOrCreate(ac.opts.Identity, ac.opts.Passphrase)
+	if err != nil {
+		return errors.Wrap(err, "could not unlock identity")
+	}
+
+	proposals, err := ac.target.Find(ac.proposals, maxFallbacks+1)
+	if err != nil {
+		return errors.Wrap(err, "could not find proposals")
+	}
+	if len(proposals) == 0 {
+		return ErrNoProposals
+	}
+
+	params := connection.ConnectParams{
+		DNS:               connection.DNSOptionAuto,
+		FallbackProposals: proposals[1:],
+	}
+	log.Info().Msgf("Auto connecting to provider %s", proposals[0].ProviderID)
+	return ac.manager.Connect(consumerID, ac.hermes.HermesID(consumerID), proposals[0], params)
+}
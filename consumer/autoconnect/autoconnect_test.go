@@ -0,0 +1,236 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autoconnect
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+var (
+	proposalDE1 = market.ServiceProposal{ProviderID: "0x1", ServiceType: "wireguard"}
+	proposalDE2 = market.ServiceProposal{ProviderID: "0x2", ServiceType: "wireguard"}
+)
+
+func Test_AutoConnect_ConnectsToProvider(t *testing.T) {
+	manager := &managerFake{}
+	repo := &repositoryFake{proposals: []market.ServiceProposal{proposalDE1, proposalDE2}}
+	ac := newTestAutoConnect(manager, repo, proposal.Target{ProviderID: "0x2", ServiceType: "wireguard"})
+	defer ac.Stop()
+
+	go ac.Start()
+
+	assert.Eventually(t, func() bool { return manager.connectCount() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "0x2", manager.lastProposal().ProviderID)
+	assert.Equal(t, "0x2", repo.requestedID.ProviderID)
+	assert.Equal(t, identity.FromAddress("0xconsumer"), manager.lastConsumer())
+	assert.Equal(t, common.HexToAddress("0x3"), manager.last().hermesID)
+}
+
+func Test_AutoConnect_ConnectsToCountryWithFallbacks(t *testing.T) {
+	manager := &managerFake{}
+	repo := &repositoryFake{proposals: []market.ServiceProposal{proposalDE1, proposalDE2}}
+	ac := newTestAutoConnect(manager, repo, proposal.Target{Country: "DE", ServiceType: "wireguard"})
+	defer ac.Stop()
+
+	go ac.Start()
+
+	assert.Eventually(t, func() bool { return manager.connectCount() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "0x1", manager.lastProposal().ProviderID)
+	assert.Equal(t, []market.ServiceProposal{proposalDE2}, manager.lastParams().FallbackProposals)
+	assert.Equal(t, &proposal.Filter{ServiceType: "wireguard", LocationCountry: "DE", ExcludeUnsupported: true}, repo.requestedFilter)
+}
+
+func Test_AutoConnect_RetriesUntilConnected(t *testing.T) {
+	manager := &managerFake{failures: 2}
+	repo := &repositoryFake{proposals: []market.ServiceProposal{proposalDE1}}
+	ac := newTestAutoConnect(manager, repo, proposal.Target{Country: "DE", ServiceType: "wireguard"})
+	defer ac.Stop()
+
+	go ac.Start()
+
+	assert.Eventually(t, func() bool { return manager.connectCount() == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, connectionstate.Connected, manager.Status().State)
+}
+
+func Test_AutoConnect_ReconnectsAfterConnectionLost(t *testing.T) {
+	manager := &managerFake{}
+	repo := &repositoryFake{proposals: []market.ServiceProposal{proposalDE1}}
+	ac := newTestAutoConnect(manager, repo, proposal.Target{Country: "DE", ServiceType: "wireguard"})
+	defer ac.Stop()
+
+	go ac.Start()
+	assert.Eventually(t, func() bool { return manager.connectCount() == 1 }, time.Second, 5*time.Millisecond)
+
+	// disconnected by user
+	manager.setState(connectionstate.NotConnected)
+	ac.consumeSessionEvent(connectionstate.AppEventConnectionSession{
+		Status:      connectionstate.SessionEndedStatus,
+		SessionInfo: connectionstate.Status{DisconnectReason: connectionstate.DisconnectReasonUserRequested},
+	})
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, manager.connectCount())
+
+	ac.consumeSessionEvent(connectionstate.AppEventConnectionSession{
+		Status:      connectionstate.SessionEndedStatus,
+		SessionInfo: connectionstate.Status{DisconnectReason: connectionstate.DisconnectReasonConnectionLost},
+	})
+	assert.Eventually(t, func() bool { return manager.connectCount() == 2 }, time.Second, 5*time.Millisecond)
+
+	manager.setState(connectionstate.NotConnected)
+	ac.consumeSessionEvent(connectionstate.AppEventConnectionSession{
+		Status:      connectionstate.SessionEndedStatus,
+		SessionInfo: connectionstate.Status{DisconnectReason: connectionstate.DisconnectReasonProviderGone},
+	})
+	assert.Eventually(t, func() bool { return manager.connectCount() == 3 }, time.Second, 5*time.Millisecond)
+}
+
+func Test_AutoConnect_SkipsWhenAlreadyConnected(t *testing.T) {
+	manager := &managerFake{}
+	manager.setState(connectionstate.Connected)
+	repo := &repositoryFake{proposals: []market.ServiceProposal{proposalDE1}}
+	ac := newTestAutoConnect(manager, repo, proposal.Target{Country: "DE", ServiceType: "wireguard"})
+
+	ac.connect()
+
+	assert.Equal(t, 0, manager.connectCount())
+}
+
+func newTestAutoConnect(manager *managerFake, repo *repositoryFake, target proposal.Target) *AutoConnect {
+	ac := NewAutoConnect(manager, repo, &identitiesFake{}, &hermesResolverFake{hermesID: common.HexToAddress("0x3")}, target, Options{Identity: "0xconsumer"})
+	ac.retryMin = time.Millisecond
+	ac.retryMax = time.Millisecond
+	return ac
+}
+
+type managerFake struct {
+	lock     sync.Mutex
+	state    connectionstate.State
+	failures int
+	connects []connectRequest
+}
+
+type connectRequest struct {
+	consumerID identity.Identity
+	hermesID   common.Address
+	proposal   market.ServiceProposal
+	params     connection.ConnectParams
+}
+
+func (m *managerFake) Connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params connection.ConnectParams) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.connects = append(m.connects, connectRequest{consumerID: consumerID, hermesID: hermesID, proposal: proposal, params: params})
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("connection failed")
+	}
+	m.state = connectionstate.Connected
+	return nil
+}
+
+func (m *managerFake) Status() connectionstate.Status {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	state := m.state
+	if state == "" {
+		state = connectionstate.NotConnected
+	}
+	return connectionstate.Status{State: state}
+}
+
+func (m *managerFake) setState(state connectionstate.State) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.state = state
+}
+
+func (m *managerFake) connectCount() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return len(m.connects)
+}
+
+func (m *managerFake) last() connectRequest {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.connects[len(m.connects)-1]
+}
+
+func (m *managerFake) lastProposal() market.ServiceProposal {
+	return m.last().proposal
+}
+
+func (m *managerFake) lastParams() connection.ConnectParams {
+	return m.last().params
+}
+
+func (m *managerFake) lastConsumer() identity.Identity {
+	return m.last().consumerID
+}
+
+type repositoryFake struct {
+	proposals       []market.ServiceProposal
+	requestedID     market.ProposalID
+	requestedFilter *proposal.Filter
+}
+
+func (r *repositoryFake) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	r.requestedID = id
+	for _, p := range r.proposals {
+		if p.ProviderID == id.ProviderID && p.ServiceType == id.ServiceType {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *repositoryFake) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	r.requestedFilter = filter
+	return r.proposals, nil
+}
+
+type hermesResolverFake struct {
+	hermesID common.Address
+}
+
+func (h *hermesResolverFake) HermesID(_ identity.Identity) common.Address {
+	return h.hermesID
+}
+
+type identitiesFake struct{}
+
+func (i *identitiesFake) UseOrCreate(address, _ string) (identity.Identity, error) {
+	return identity.FromAddress(address), nil
+}
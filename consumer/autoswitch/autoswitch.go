@@ -0,0 +1,251 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autoswitch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/session"
+)
+
+// AppTopicAutoSwitch represents the topic of connection switches caused by degraded tunnel health
+const AppTopicAutoSwitch = "AutoSwitch"
+
+// maxFallbacks limits how many next best providers are kept for failover
+const maxFallbacks = 3
+
+// ErrNoProposals is returned when there is no other provider to switch to
+var ErrNoProposals = errors.New("no other provider to switch to")
+
+// Reason names tunnel health threshold which was exceeded
+type Reason string
+
+const (
+	// ReasonPacketLoss means that too many tunnel pings were lost
+	ReasonPacketLoss = Reason("PacketLoss")
+	// ReasonLatency means that tunnel round trip time was too long
+	ReasonLatency = Reason("Latency")
+)
+
+// AppEventAutoSwitch explains why connection was switched to another provider
+type AppEventAutoSwitch struct {
+	// From is the provider connection was switched from
+	From string
+	// To is the provider connection was switched to
+	To string
+	// Reason names the exceeded threshold
+	Reason Reason
+	// Health is the tunnel health which triggered the switch
+	Health connectionstate.Health
+	// Degraded is how long tunnel health exceeded the threshold
+	Degraded time.Duration
+}
+
+// Thresholds describe degraded tunnel health, zero value of a threshold disables it
+type Thresholds struct {
+	MaxPacketLoss float64
+	MaxLatency    time.Duration
+	// Period is how long tunnel health has to exceed thresholds before the switch
+	Period time.Duration
+}
+
+type connectionSwitcher interface {
+	Switch(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params connection.ConnectParams) error
+	Status() connectionstate.Status
+}
+
+type switchRequest struct {
+	status   connectionstate.Status
+	reason   Reason
+	health   connectionstate.Health
+	degraded time.Duration
+}
+
+// AutoSwitch watches tunnel health of the consumer connection
+// and switches it to the next best provider once health stays degraded for the threshold period.
+type AutoSwitch struct {
+	switcher   connectionSwitcher
+	proposals  proposal.Repository
+	publisher  eventbus.Publisher
+	thresholds Thresholds
+	timeGetter func() time.Time
+
+	lock          sync.Mutex
+	sessionID     session.ID
+	degradedSince time.Time
+
+	requests chan switchRequest
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewAutoSwitch creates auto switch choosing providers from the ranked proposal repository
+func NewAutoSwitch(switcher connectionSwitcher, proposals proposal.Repository, publisher eventbus.Publisher, thresholds Thresholds) *AutoSwitch {
+	return &AutoSwitch{
+		switcher:   switcher,
+		proposals:  proposals,
+		publisher:  publisher,
+		thresholds: thresholds,
+		timeGetter: time.Now,
+		requests:   make(chan switchRequest, 1),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to tunnel health events.
+func (as *AutoSwitch) Subscribe(bus eventbus.Subscriber) error {
+	return bus.SubscribeAsync(connectionstate.AppTopicConnectionHealth, as.consumeHealthEvent)
+}
+
+func (as *AutoSwitch) consumeHealthEvent(e connectionstate.AppEventConnectionHealth) {
+	as.lock.Lock()
+	defer as.lock.Unlock()
+
+	reason := as.degradation(e.Health)
+	if reason == "" || e.SessionInfo.SessionID != as.sessionID {
+		as.sessionID = e.SessionInfo.SessionID
+		as.degradedSince = time.Time{}
+	}
+	if reason == "" {
+		return
+	}
+
+	now := as.timeGetter()
+	if as.degradedSince.IsZero() {
+		as.degradedSince = now
+		log.Info().Msgf("Tunnel health degraded: packet loss %.2f, latency %s", e.Health.PacketLoss, e.Health.RTT)
+	}
+	degraded := now.Sub(as.degradedSince)
+	if degraded < as.thresholds.Period {
+		return
+	}
+
+	// Degradation is counted anew, in case the switch fails.
+	as.degradedSince = time.Time{}
+	select {
+	case as.requests <- switchRequest{status: e.SessionInfo, reason: reason, health: e.Health, degraded: degraded}:
+	default:
+	}
+}
+
+func (as *AutoSwitch) degradation(health connectionstate.Health) Reason {
+	if health.Samples == 0 {
+		return ""
+	}
+	if as.thresholds.MaxPacketLoss > 0 && health.PacketLoss > as.thresholds.MaxPacketLoss {
+		return ReasonPacketLoss
+	}
+	if as.thresholds.MaxLatency > 0 && health.RTT > as.thresholds.MaxLatency {
+		return ReasonLatency
+	}
+	return ""
+}
+
+// Start switches connections with degraded tunnel health. Blocks until stopped.
+func (as *AutoSwitch) Start() {
+	for {
+		select {
+		case <-as.stop:
+			return
+		case req := <-as.requests:
+			if err := as.switchProvider(req); err != nil {
+				log.Warn().Err(err).Msg("Could not switch connection with degraded tunnel health")
+			}
+		}
+	}
+}
+
+// Stop stops switching, current connection is left intact.
+func (as *AutoSwitch) Stop() {
+	as.stopOnce.Do(func() {
+		close(as.stop)
+	})
+}
+
+func (as *AutoSwitch) switchProvider(req switchRequest) error {
+	status := as.switcher.Status()
+	if status.State != connectionstate.Connected || status.SessionID != req.status.SessionID {
+		log.Debug().Msg("Connection with degraded tunnel health is gone, skipping switch")
+		return nil
+	}
+
+	proposals, err := as.candidates(status.Proposal)
+	if err != nil {
+		return errors.Wrap(err, "could not find proposals")
+	}
+	if len(proposals) == 0 {
+		return ErrNoProposals
+	}
+
+	log.Info().Msgf("Switching from provider %s to %s, tunnel health degraded for %s: %s", status.Proposal.ProviderID, proposals[0].ProviderID, req.degraded, req.reason)
+	params := connection.ConnectParams{
+		DNS:               connection.DNSOptionAuto,
+		FallbackProposals: proposals[1:],
+	}
+	if err := as.switcher.Switch(status.ConsumerID, status.HermesID, proposals[0], params); err != nil {
+		return err
+	}
+
+	as.publisher.Publish(AppTopicAutoSwitch, AppEventAutoSwitch{
+		From:     status.Proposal.ProviderID,
+		To:       as.switcher.Status().Proposal.ProviderID,
+		Reason:   req.reason,
+		Health:   req.health,
+		Degraded: req.degraded,
+	})
+	return nil
+}
+
+// candidates returns best ranked providers of the same service type and country, except the current one.
+func (as *AutoSwitch) candidates(current market.ServiceProposal) ([]market.ServiceProposal, error) {
+	filter := &proposal.Filter{
+		ServiceType:        current.ServiceType,
+		ExcludeUnsupported: true,
+	}
+	if current.ServiceDefinition != nil {
+		filter.LocationCountry = current.ServiceDefinition.GetLocation().Country
+	}
+
+	proposals, err := as.proposals.Proposals(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []market.ServiceProposal
+	for _, p := range proposals {
+		if p.ProviderID == current.ProviderID {
+			continue
+		}
+		res = append(res, p)
+		if len(res) > maxFallbacks {
+			break
+		}
+	}
+	return res, nil
+}
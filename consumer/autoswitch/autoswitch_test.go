@@ -0,0 +1,268 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autoswitch
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/session"
+)
+
+var (
+	proposal1 = market.ServiceProposal{ProviderID: "0x1", ServiceType: "wireguard"}
+	proposal2 = market.ServiceProposal{ProviderID: "0x2", ServiceType: "wireguard"}
+	proposal3 = market.ServiceProposal{ProviderID: "0x3", ServiceType: "wireguard"}
+
+	thresholds = Thresholds{MaxPacketLoss: 0.2, MaxLatency: 500 * time.Millisecond, Period: 3 * time.Minute}
+
+	lossyHealth   = connectionstate.Health{PacketLoss: 0.5, RTT: 100 * time.Millisecond, Samples: 10}
+	healthyHealth = connectionstate.Health{PacketLoss: 0, RTT: 100 * time.Millisecond, Samples: 10}
+)
+
+func Test_AutoSwitch_SwitchesAfterDegradedPeriod(t *testing.T) {
+	switcher := &switcherFake{status: connectedTo("session1", proposal1)}
+	repo := &repositoryFake{proposals: []market.ServiceProposal{proposal1, proposal2, proposal3}}
+	publisher := &publisherFake{}
+	as, clock := newTestAutoSwitch(switcher, repo, publisher)
+	defer as.Stop()
+	go as.Start()
+
+	as.consumeHealthEvent(healthEvent("session1", lossyHealth))
+	clock.advance(2 * time.Minute)
+	as.consumeHealthEvent(healthEvent("session1", lossyHealth))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, switcher.switchCount())
+
+	clock.advance(time.Minute)
+	as.consumeHealthEvent(healthEvent("session1", lossyHealth))
+
+	assert.Eventually(t, func() bool { return switcher.switchCount() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, proposal2, switcher.lastProposal())
+	assert.Equal(t, []market.ServiceProposal{proposal3}, switcher.lastParams().FallbackProposals)
+	assert.Equal(t, &proposal.Filter{ServiceType: "wireguard", ExcludeUnsupported: true}, repo.requestedFilter)
+	assert.Eventually(t, func() bool { return publisher.published() != nil }, time.Second, 5*time.Millisecond)
+	assert.Equal(
+		t,
+		&AppEventAutoSwitch{From: "0x1", To: "0x2", Reason: ReasonPacketLoss, Health: lossyHealth, Degraded: 3 * time.Minute},
+		publisher.published(),
+	)
+}
+
+func Test_AutoSwitch_RecoveredHealthResetsPeriod(t *testing.T) {
+	switcher := &switcherFake{status: connectedTo("session1", proposal1)}
+	repo := &repositoryFake{proposals: []market.ServiceProposal{proposal1, proposal2}}
+	as, clock := newTestAutoSwitch(switcher, repo, &publisherFake{})
+	defer as.Stop()
+	go as.Start()
+
+	as.consumeHealthEvent(healthEvent("session1", lossyHealth))
+	clock.advance(2 * time.Minute)
+	as.consumeHealthEvent(healthEvent("session1", healthyHealth))
+	clock.advance(time.Minute)
+	as.consumeHealthEvent(healthEvent("session1", lossyHealth))
+	clock.advance(2 * time.Minute)
+	as.consumeHealthEvent(healthEvent("session1", lossyHealth))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, switcher.switchCount())
+}
+
+func Test_AutoSwitch_NewSessionResetsPeriod(t *testing.T) {
+	switcher := &switcherFake{status: connectedTo("session2", proposal2)}
+	repo := &repositoryFake{proposals: []market.ServiceProposal{proposal1, proposal2}}
+	as, clock := newTestAutoSwitch(switcher, repo, &publisherFake{})
+	defer as.Stop()
+	go as.Start()
+
+	as.consumeHealthEvent(healthEvent("session1", lossyHealth))
+	clock.advance(3 * time.Minute)
+	as.consumeHealthEvent(healthEvent("session2", lossyHealth))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, switcher.switchCount())
+}
+
+func Test_AutoSwitch_DetectsLatency(t *testing.T) {
+	as, _ := newTestAutoSwitch(&switcherFake{}, &repositoryFake{}, &publisherFake{})
+
+	assert.Equal(t, ReasonLatency, as.degradation(connectionstate.Health{RTT: time.Second, Samples: 10}))
+	assert.Equal(t, Reason(""), as.degradation(healthyHealth))
+	assert.Equal(t, Reason(""), as.degradation(connectionstate.Health{}))
+}
+
+func Test_AutoSwitch_SkipsSwitchWithoutOtherProviders(t *testing.T) {
+	switcher := &switcherFake{status: connectedTo("session1", proposal1)}
+	repo := &repositoryFake{proposals: []market.ServiceProposal{proposal1}}
+	as, _ := newTestAutoSwitch(switcher, repo, &publisherFake{})
+
+	err := as.switchProvider(switchRequest{status: connectedTo("session1", proposal1), reason: ReasonPacketLoss})
+
+	assert.Equal(t, ErrNoProposals, err)
+	assert.Equal(t, 0, switcher.switchCount())
+}
+
+func Test_AutoSwitch_SkipsSwitchOfEndedSession(t *testing.T) {
+	switcher := &switcherFake{status: connectedTo("session2", proposal2)}
+	repo := &repositoryFake{proposals: []market.ServiceProposal{proposal1, proposal2, proposal3}}
+	as, _ := newTestAutoSwitch(switcher, repo, &publisherFake{})
+
+	err := as.switchProvider(switchRequest{status: connectedTo("session1", proposal1), reason: ReasonPacketLoss})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, switcher.switchCount())
+}
+
+func newTestAutoSwitch(switcher *switcherFake, repo *repositoryFake, publisher *publisherFake) (*AutoSwitch, *clockFake) {
+	clock := &clockFake{now: time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)}
+	as := NewAutoSwitch(switcher, repo, publisher, thresholds)
+	as.timeGetter = clock.Now
+	return as, clock
+}
+
+func connectedTo(sessionID string, proposal market.ServiceProposal) connectionstate.Status {
+	return connectionstate.Status{
+		State:      connectionstate.Connected,
+		SessionID:  session.ID(sessionID),
+		ConsumerID: identity.FromAddress("0xconsumer"),
+		Proposal:   proposal,
+	}
+}
+
+func healthEvent(sessionID string, health connectionstate.Health) connectionstate.AppEventConnectionHealth {
+	return connectionstate.AppEventConnectionHealth{
+		Health:      health,
+		SessionInfo: connectedTo(sessionID, proposal1),
+	}
+}
+
+type clockFake struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func (c *clockFake) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+func (c *clockFake) advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+type switchRequestFake struct {
+	proposal market.ServiceProposal
+	params   connection.ConnectParams
+}
+
+type switcherFake struct {
+	lock     sync.Mutex
+	status   connectionstate.Status
+	switches []switchRequestFake
+}
+
+func (s *switcherFake) Switch(consumerID identity.Identity, _ common.Address, proposal market.ServiceProposal, params connection.ConnectParams) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.switches = append(s.switches, switchRequestFake{proposal: proposal, params: params})
+	s.status = connectionstate.Status{
+		State:      connectionstate.Connected,
+		SessionID:  session.ID("switched"),
+		ConsumerID: consumerID,
+		Proposal:   proposal,
+	}
+	return nil
+}
+
+func (s *switcherFake) Status() connectionstate.Status {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.status
+}
+
+func (s *switcherFake) switchCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.switches)
+}
+
+func (s *switcherFake) last() switchRequestFake {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.switches[len(s.switches)-1]
+}
+
+func (s *switcherFake) lastProposal() market.ServiceProposal {
+	return s.last().proposal
+}
+
+func (s *switcherFake) lastParams() connection.ConnectParams {
+	return s.last().params
+}
+
+type repositoryFake struct {
+	proposals       []market.ServiceProposal
+	requestedFilter *proposal.Filter
+}
+
+func (r *repositoryFake) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	return nil, nil
+}
+
+func (r *repositoryFake) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	r.requestedFilter = filter
+	return r.proposals, nil
+}
+
+type publisherFake struct {
+	lock  sync.Mutex
+	event *AppEventAutoSwitch
+}
+
+func (p *publisherFake) Publish(_ string, data interface{}) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	event := data.(AppEventAutoSwitch)
+	p.event = &event
+}
+
+func (p *publisherFake) published() *AppEventAutoSwitch {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.event
+}
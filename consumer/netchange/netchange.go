@@ -0,0 +1,162 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netchange
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jackpal/gateway"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/eventbus"
+)
+
+// AppTopicNetworkChange represents the topic of host network changes
+const AppTopicNetworkChange = "NetworkChange"
+
+// rebindTimeout limits how long watcher waits for established connection to move to the new network.
+const rebindTimeout = 10 * time.Second
+
+// Network identifies the network host is attached to
+type Network struct {
+	// Gateway is the default gateway of the host
+	Gateway net.IP
+	// LocalIP is the address host reaches the gateway from
+	LocalIP net.IP
+}
+
+func (n Network) known() bool {
+	return n.Gateway != nil
+}
+
+func (n Network) equal(other Network) bool {
+	return n.Gateway.Equal(other.Gateway) && n.LocalIP.Equal(other.LocalIP)
+}
+
+// AppEventNetworkChange is published when host moves to another network, e.g. from Wi-Fi to LTE
+type AppEventNetworkChange struct {
+	Previous Network
+	Current  Network
+}
+
+type connectionManager interface {
+	Status() connectionstate.Status
+	Reconnect()
+	Rebind(ctx context.Context) error
+}
+
+// Watcher polls the network host is attached to and moves established connection to the new network once it changes.
+// NAT mappings of the previous network are gone, so p2p channel is rebound to let provider learn the new address.
+// Connection is re-established from scratch if channel can not be rebound, e.g. when it goes through a relay.
+type Watcher struct {
+	manager   connectionManager
+	publisher eventbus.Publisher
+	interval  time.Duration
+	resolve   func() (Network, error)
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewWatcher creates network watcher polling network with the given interval
+func NewWatcher(manager connectionManager, publisher eventbus.Publisher, interval time.Duration) *Watcher {
+	return &Watcher{
+		manager:   manager,
+		publisher: publisher,
+		interval:  interval,
+		resolve:   resolveNetwork,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start polls network until stopped.
+// While host is offline the last known network is kept, so that reattaching to the same network does not cause a reconnect.
+func (w *Watcher) Start() {
+	current, _ := w.resolve()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(w.interval):
+		}
+
+		network, err := w.resolve()
+		if err != nil {
+			log.Trace().Err(err).Msg("Could not resolve host network")
+			continue
+		}
+		if !current.known() {
+			current = network
+			continue
+		}
+		if network.equal(current) {
+			continue
+		}
+
+		previous := current
+		current = network
+		w.handleChange(previous, current)
+	}
+}
+
+// Stop stops polling network.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+}
+
+func (w *Watcher) handleChange(previous, current Network) {
+	log.Info().Msgf("Host network changed from %s via %s to %s via %s", previous.LocalIP, previous.Gateway, current.LocalIP, current.Gateway)
+	w.publisher.Publish(AppTopicNetworkChange, AppEventNetworkChange{Previous: previous, Current: current})
+
+	if w.manager.Status().State != connectionstate.Connected {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rebindTimeout)
+	defer cancel()
+	err := w.manager.Rebind(ctx)
+	if err == nil {
+		log.Info().Msg("Connection resumed on the new network")
+		return
+	}
+	log.Warn().Err(err).Msg("Could not resume connection on the new network")
+	log.Info().Msg("Reconnecting after network change")
+	w.manager.Reconnect()
+}
+
+func resolveNetwork() (Network, error) {
+	gw, err := gateway.DiscoverGateway()
+	if err != nil {
+		return Network{}, err
+	}
+
+	// Connecting UDP socket sends nothing, it only selects the local address routing picks for the gateway.
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: gw, Port: 53})
+	if err != nil {
+		return Network{}, err
+	}
+	defer conn.Close()
+
+	return Network{Gateway: gw, LocalIP: conn.LocalAddr().(*net.UDPAddr).IP}, nil
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netchange
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+)
+
+var (
+	wifi = Network{Gateway: net.ParseIP("192.168.1.1"), LocalIP: net.ParseIP("192.168.1.10")}
+	lte  = Network{Gateway: net.ParseIP("10.64.0.1"), LocalIP: net.ParseIP("10.64.0.20")}
+	// Another Wi-Fi network often has the same gateway address, but assigns a different address to the host.
+	otherWifi = Network{Gateway: net.ParseIP("192.168.1.1"), LocalIP: net.ParseIP("192.168.1.77")}
+
+	offline = resolution{err: errors.New("no default gateway")}
+
+	errRebind = errors.New("channel is relayed")
+)
+
+func Test_Watcher_RebindsChannelAfterNetworkChange(t *testing.T) {
+	manager := &managerFake{state: connectionstate.Connected}
+	publisher := &publisherFake{}
+	w := newTestWatcher(manager, publisher, online(wifi), online(lte))
+	defer w.Stop()
+	go w.Start()
+
+	assert.Eventually(t, func() bool { return manager.rebindCount() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, manager.reconnectCount())
+}
+
+func Test_Watcher_ReconnectsAfterNetworkChange(t *testing.T) {
+	manager := &managerFake{state: connectionstate.Connected, rebindErr: errRebind}
+	publisher := &publisherFake{}
+	w := newTestWatcher(manager, publisher, online(wifi), online(wifi), online(lte))
+	defer w.Stop()
+	go w.Start()
+
+	assert.Eventually(t, func() bool { return manager.reconnectCount() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []AppEventNetworkChange{{Previous: wifi, Current: lte}}, publisher.published())
+}
+
+func Test_Watcher_ReconnectsWhenHostAddressChanges(t *testing.T) {
+	manager := &managerFake{state: connectionstate.Connected, rebindErr: errRebind}
+	w := newTestWatcher(manager, &publisherFake{}, online(wifi), online(otherWifi))
+	defer w.Stop()
+	go w.Start()
+
+	assert.Eventually(t, func() bool { return manager.reconnectCount() == 1 }, time.Second, time.Millisecond)
+}
+
+func Test_Watcher_KeepsConnectionWhenSameNetworkComesBack(t *testing.T) {
+	manager := &managerFake{state: connectionstate.Connected}
+	publisher := &publisherFake{}
+	w := newTestWatcher(manager, publisher, online(wifi), offline, offline, online(wifi))
+	defer w.Stop()
+	go w.Start()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, manager.reconnectCount())
+	assert.Empty(t, publisher.published())
+}
+
+func Test_Watcher_ComparesToNetworkKnownBeforeGoingOffline(t *testing.T) {
+	manager := &managerFake{state: connectionstate.Connected, rebindErr: errRebind}
+	w := newTestWatcher(manager, &publisherFake{}, online(wifi), offline, online(lte))
+	defer w.Stop()
+	go w.Start()
+
+	assert.Eventually(t, func() bool { return manager.reconnectCount() == 1 }, time.Second, time.Millisecond)
+}
+
+func Test_Watcher_DoesNotReconnectWhenNotConnected(t *testing.T) {
+	manager := &managerFake{state: connectionstate.NotConnected}
+	publisher := &publisherFake{}
+	w := newTestWatcher(manager, publisher, online(wifi), online(lte))
+	defer w.Stop()
+	go w.Start()
+
+	assert.Eventually(t, func() bool { return len(publisher.published()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, manager.reconnectCount())
+}
+
+type resolution struct {
+	network Network
+	err     error
+}
+
+func online(network Network) resolution {
+	return resolution{network: network}
+}
+
+func newTestWatcher(manager *managerFake, publisher *publisherFake, resolutions ...resolution) *Watcher {
+	w := NewWatcher(manager, publisher, time.Millisecond)
+
+	var lock sync.Mutex
+	w.resolve = func() (Network, error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		// The last resolution repeats once the sequence is over.
+		next := resolutions[0]
+		if len(resolutions) > 1 {
+			resolutions = resolutions[1:]
+		}
+		return next.network, next.err
+	}
+	return w
+}
+
+type managerFake struct {
+	lock       sync.Mutex
+	state      connectionstate.State
+	reconnects int
+	rebinds    int
+	rebindErr  error
+}
+
+func (m *managerFake) Status() connectionstate.Status {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return connectionstate.Status{State: m.state}
+}
+
+func (m *managerFake) Reconnect() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.reconnects++
+}
+
+func (m *managerFake) Rebind(context.Context) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.rebinds++
+	return m.rebindErr
+}
+
+func (m *managerFake) rebindCount() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.rebinds
+}
+
+func (m *managerFake) reconnectCount() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.reconnects
+}
+
+type publisherFake struct {
+	lock   sync.Mutex
+	events []AppEventNetworkChange
+}
+
+func (p *publisherFake) Publish(topic string, data interface{}) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.events = append(p.events, data.(AppEventNetworkChange))
+}
+
+func (p *publisherFake) published() []AppEventNetworkChange {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.events
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// maxFallbacks limits how many providers matching profile country are kept for failover
+const maxFallbacks = 3
+
+// ErrNoProposals is returned when no proposal matches the profile
+var ErrNoProposals = errors.New("no service proposals match the profile")
+
+// Profile is a named connection configuration, connecting with it reproduces the same setup
+type Profile struct {
+	Name string `storm:"id"`
+	// ProviderID selects a single provider, takes precedence over Country
+	ProviderID string
+	// Country selects any provider located in the given country
+	Country           string
+	ServiceType       string
+	DNS               connection.DNSOption
+	DisableKillSwitch bool
+	// SpendCap disconnects once consumer pays provider at least the given amount during the session
+	SpendCap *big.Int
+}
+
+// Validate checks if profile is complete
+func (p Profile) Validate() error {
+	if p.Name == "" {
+		return errors.New("profile name is required")
+	}
+	if p.ProviderID == "" && p.Country == "" {
+		return errors.New("profile requires provider or country")
+	}
+	if p.ServiceType == "" {
+		return errors.New("profile service type is required")
+	}
+	if p.DNS != "" {
+		if _, err := connection.NewDNSOption(string(p.DNS)); err != nil {
+			return fmt.Errorf("invalid profile DNS: %w", err)
+		}
+	}
+	if p.SpendCap != nil && p.SpendCap.Sign() <= 0 {
+		return errors.New("profile spend cap must be positive")
+	}
+	return nil
+}
+
+// Target returns proposals which profile connects to
+func (p Profile) Target() proposal.Target {
+	return proposal.Target{
+		ProviderID:  p.ProviderID,
+		Country:     p.Country,
+		ServiceType: p.ServiceType,
+	}
+}
+
+// Proposals returns proposals to connect to, the first one is primary and the rest are fallbacks
+func (p Profile) Proposals(repository proposal.Repository) ([]market.ServiceProposal, error) {
+	proposals, err := p.Target().Find(repository, maxFallbacks+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(proposals) == 0 {
+		return nil, ErrNoProposals
+	}
+	return proposals, nil
+}
+
+// ConnectParams returns connection params of the profile
+func (p Profile) ConnectParams() connection.ConnectParams {
+	dns := connection.DNSOptionAuto
+	if p.DNS != "" {
+		dns = p.DNS
+	}
+	return connection.ConnectParams{
+		DisableKillSwitch: p.DisableKillSwitch,
+		DNS:               dns,
+		SpendCap:          p.SpendCap,
+	}
+}
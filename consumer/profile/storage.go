@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package profile
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const profileBucket = "connection-profiles"
+
+type persistentStorage interface {
+	Store(bucket string, data interface{}) error
+	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
+	GetAllFrom(bucket string, data interface{}) error
+	Delete(bucket string, data interface{}) error
+}
+
+var errBoltNotFound = "not found"
+
+// ErrNotFound represents an error where profile with the given name does not exist
+var ErrNotFound = errors.New("profile not found")
+
+// Storage keeps connection profiles.
+type Storage struct {
+	lock sync.Mutex
+	bolt persistentStorage
+}
+
+// NewStorage returns a new instance of the profile storage
+func NewStorage(bolt persistentStorage) *Storage {
+	return &Storage{
+		bolt: bolt,
+	}
+}
+
+// Save validates and stores the profile, replacing the existing profile with the same name.
+func (s *Storage) Save(p Profile) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return errors.Wrap(s.bolt.Store(profileBucket, &p), "could not store profile")
+}
+
+// Get returns the profile by its name.
+func (s *Storage) Get(name string) (Profile, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.get(name)
+}
+
+func (s *Storage) get(name string) (Profile, error) {
+	result := Profile{}
+	err := s.bolt.GetOneByField(profileBucket, "Name", name, &result)
+	if err != nil {
+		if err.Error() == errBoltNotFound {
+			return result, ErrNotFound
+		}
+		return result, errors.Wrap(err, "could not get profile")
+	}
+	return result, nil
+}
+
+// List returns all profiles ordered by name.
+func (s *Storage) List() ([]Profile, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	res := []Profile{}
+	err := s.bolt.GetAllFrom(profileBucket, &res)
+	if err != nil && err.Error() != errBoltNotFound {
+		return nil, errors.Wrap(err, "could not get profiles")
+	}
+	return res, nil
+}
+
+// Delete removes the profile by its name.
+func (s *Storage) Delete(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(s.bolt.Delete(profileBucket, &p), "could not delete profile")
+}
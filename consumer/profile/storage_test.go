@@ -0,0 +1,89 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package profile
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+)
+
+func TestStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profileStorageTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	defer bolt.Close()
+	storage := NewStorage(bolt)
+
+	profiles, err := storage.List()
+	assert.NoError(t, err)
+	assert.Empty(t, profiles)
+
+	_, err = storage.Get("work")
+	assert.Equal(t, ErrNotFound, err)
+
+	work := Profile{Name: "work", ProviderID: "0x1", ServiceType: "wireguard", DNS: connection.DNSOptionProvider, SpendCap: big.NewInt(100)}
+	home := Profile{Name: "home", Country: "DE", ServiceType: "wireguard", DisableKillSwitch: true}
+	assert.NoError(t, storage.Save(work))
+	assert.NoError(t, storage.Save(home))
+
+	res, err := storage.Get("work")
+	assert.NoError(t, err)
+	assert.Equal(t, work, res)
+
+	profiles, err = storage.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []Profile{home, work}, profiles)
+
+	work.ProviderID = "0x2"
+	assert.NoError(t, storage.Save(work))
+	res, err = storage.Get("work")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2", res.ProviderID)
+
+	assert.NoError(t, storage.Delete("work"))
+	assert.Equal(t, ErrNotFound, storage.Delete("work"))
+	profiles, err = storage.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []Profile{home}, profiles)
+}
+
+func TestStorage_RejectsInvalidProfile(t *testing.T) {
+	storage := NewStorage(nil)
+
+	assert.Error(t, storage.Save(Profile{ProviderID: "0x1", ServiceType: "wireguard"}))
+	assert.Error(t, storage.Save(Profile{Name: "work", ServiceType: "wireguard"}))
+	assert.Error(t, storage.Save(Profile{Name: "work", ProviderID: "0x1"}))
+	assert.Error(t, storage.Save(Profile{Name: "work", ProviderID: "0x1", ServiceType: "wireguard", DNS: "invalid"}))
+	assert.Error(t, storage.Save(Profile{Name: "work", ProviderID: "0x1", ServiceType: "wireguard", SpendCap: big.NewInt(0)}))
+}
+
+func TestProfile_ConnectParams(t *testing.T) {
+	p := Profile{Name: "work", ProviderID: "0x1", ServiceType: "wireguard", DisableKillSwitch: true, SpendCap: big.NewInt(100)}
+
+	assert.Equal(t, connection.ConnectParams{DNS: connection.DNSOptionAuto, DisableKillSwitch: true, SpendCap: big.NewInt(100)}, p.ConnectParams())
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeOfDay is a local wall clock time
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+}
+
+// ParseTimeOfDay parses time of day in "15:04" format
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return TimeOfDay{}, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return TimeOfDay{Hour: t.Hour(), Minute: t.Minute()}, nil
+}
+
+// String returns time of day in "15:04" format
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// on returns the time of day at the date of the given day
+func (t TimeOfDay) on(day time.Time) time.Time {
+	year, month, date := day.Date()
+	return time.Date(year, month, date, t.Hour, t.Minute, 0, 0, day.Location())
+}
+
+// ParseWeekday parses full or abbreviated English week day name, e.g. "monday" or "mon"
+func ParseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(s)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if s == name || s == name[:3] {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid week day %q", s)
+}
+
+// Schedule connects and disconnects consumer at the given times of the given week days.
+// Schedule with both times keeps consumer connected in between, e.g. on weekdays from 09:00 to 17:00.
+type Schedule struct {
+	Name string `storm:"id"`
+	// Days limits both connect and disconnect to the given week days, empty value means every day
+	Days []time.Weekday
+	// ConnectAt is the time to connect at, nil means schedule does not connect
+	ConnectAt *TimeOfDay
+	// DisconnectAt is the time to disconnect at, nil means schedule does not disconnect
+	DisconnectAt *TimeOfDay
+	// Profile is the saved connection profile to connect with
+	Profile string
+	// ConsumerID is the identity to connect with, empty value means the last used identity
+	ConsumerID string
+}
+
+// Validate checks if schedule is complete
+func (s Schedule) Validate() error {
+	if s.Name == "" {
+		return errors.New("schedule name is required")
+	}
+	if s.ConnectAt == nil && s.DisconnectAt == nil {
+		return errors.New("schedule requires connect or disconnect time")
+	}
+	if s.ConnectAt != nil && s.Profile == "" {
+		return errors.New("schedule which connects requires profile")
+	}
+	for _, t := range []*TimeOfDay{s.ConnectAt, s.DisconnectAt} {
+		if t != nil && (t.Hour < 0 || t.Hour > 23 || t.Minute < 0 || t.Minute > 59) {
+			return fmt.Errorf("invalid time of day %s", t)
+		}
+	}
+	return nil
+}
+
+func (s Schedule) isScheduledOn(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, d := range s.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// lastOccurrence returns the latest scheduled occurrence of the time of day not later than now, zero time if there is none.
+func (s Schedule) lastOccurrence(t *TimeOfDay, now time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	for days := 0; days <= 7; days++ {
+		day := now.AddDate(0, 0, -days)
+		at := t.on(day)
+		if !at.After(now) && s.isScheduledOn(day.Weekday()) {
+			return at
+		}
+	}
+	return time.Time{}
+}
+
+// isConnectedAt checks whether schedule keeps consumer connected at the given time.
+func (s Schedule) isConnectedAt(now time.Time) bool {
+	if s.ConnectAt == nil || s.DisconnectAt == nil {
+		return false
+	}
+	connectAt := s.lastOccurrence(s.ConnectAt, now)
+	return !connectAt.IsZero() && connectAt.After(s.lastOccurrence(s.DisconnectAt, now))
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// monday returns time on Monday, 2020-10-12
+func monday(hour, minute int) time.Time {
+	return time.Date(2020, 10, 12, hour, minute, 0, 0, time.UTC)
+}
+
+func Test_ParseTimeOfDay(t *testing.T) {
+	tod, err := ParseTimeOfDay("09:30")
+	assert.NoError(t, err)
+	assert.Equal(t, TimeOfDay{Hour: 9, Minute: 30}, tod)
+	assert.Equal(t, "09:30", tod.String())
+
+	_, err = ParseTimeOfDay("25:00")
+	assert.Error(t, err)
+	_, err = ParseTimeOfDay("9am")
+	assert.Error(t, err)
+}
+
+func Test_ParseWeekday(t *testing.T) {
+	day, err := ParseWeekday("Monday")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Monday, day)
+
+	day, err = ParseWeekday("sat")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Saturday, day)
+
+	_, err = ParseWeekday("someday")
+	assert.Error(t, err)
+}
+
+func Test_Schedule_Validate(t *testing.T) {
+	at := &TimeOfDay{Hour: 9}
+
+	assert.NoError(t, Schedule{Name: "work", ConnectAt: at, Profile: "work"}.Validate())
+	assert.NoError(t, Schedule{Name: "night", DisconnectAt: at}.Validate())
+	assert.Error(t, Schedule{ConnectAt: at, Profile: "work"}.Validate())
+	assert.Error(t, Schedule{Name: "work"}.Validate())
+	assert.Error(t, Schedule{Name: "work", ConnectAt: at}.Validate())
+	assert.Error(t, Schedule{Name: "night", DisconnectAt: &TimeOfDay{Hour: 24}}.Validate())
+}
+
+func Test_Schedule_LastOccurrence(t *testing.T) {
+	sc := Schedule{Days: weekdays, ConnectAt: &TimeOfDay{Hour: 9}}
+
+	assert.Equal(t, monday(9, 0), sc.lastOccurrence(sc.ConnectAt, monday(9, 0)))
+	assert.Equal(t, monday(9, 0), sc.lastOccurrence(sc.ConnectAt, monday(12, 0)))
+	// Friday before
+	assert.Equal(t, monday(9, 0).AddDate(0, 0, -3), sc.lastOccurrence(sc.ConnectAt, monday(8, 59)))
+	assert.True(t, sc.lastOccurrence(sc.DisconnectAt, monday(12, 0)).IsZero())
+}
+
+func Test_Schedule_IsConnectedAt(t *testing.T) {
+	sc := Schedule{Days: weekdays, ConnectAt: &TimeOfDay{Hour: 9}, DisconnectAt: &TimeOfDay{Hour: 17}}
+
+	assert.False(t, sc.isConnectedAt(monday(8, 59)))
+	assert.True(t, sc.isConnectedAt(monday(9, 0)))
+	assert.True(t, sc.isConnectedAt(monday(16, 59)))
+	assert.False(t, sc.isConnectedAt(monday(17, 0)))
+	// Sunday
+	assert.False(t, sc.isConnectedAt(monday(12, 0).AddDate(0, 0, -1)))
+
+	overnight := Schedule{ConnectAt: &TimeOfDay{Hour: 22}, DisconnectAt: &TimeOfDay{Hour: 6}}
+	assert.True(t, overnight.isConnectedAt(monday(23, 0)))
+	assert.True(t, overnight.isConnectedAt(monday(5, 0)))
+	assert.False(t, overnight.isConnectedAt(monday(12, 0)))
+
+	connectOnly := Schedule{ConnectAt: &TimeOfDay{Hour: 9}}
+	assert.False(t, connectOnly.isConnectedAt(monday(12, 0)))
+}
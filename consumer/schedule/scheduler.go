@@ -0,0 +1,182 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/consumer/profile"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/identity/selector"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// checkInterval defines how often schedules are checked
+const checkInterval = 30 * time.Second
+
+type connectionManager interface {
+	Connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params connection.ConnectParams) error
+	Disconnect() error
+}
+
+// hermesResolver resolves hermes the consumer pays with.
+type hermesResolver interface {
+	HermesID(id identity.Identity) common.Address
+}
+
+type scheduleStorage interface {
+	List() ([]Schedule, error)
+}
+
+type profileStorage interface {
+	Get(name string) (profile.Profile, error)
+}
+
+// Scheduler connects and disconnects consumer according to the stored schedules
+type Scheduler struct {
+	schedules  scheduleStorage
+	profiles   profileStorage
+	proposals  proposal.Repository
+	identities selector.Handler
+	manager    connectionManager
+	hermes     hermesResolver
+	timeGetter func() time.Time
+	interval   time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewScheduler creates scheduler of the stored schedules
+func NewScheduler(schedules scheduleStorage, profiles profileStorage, proposals proposal.Repository, identities selector.Handler, manager connectionManager, hermes hermesResolver) *Scheduler {
+	return &Scheduler{
+		schedules:  schedules,
+		profiles:   profiles,
+		proposals:  proposals,
+		identities: identities,
+		manager:    manager,
+		hermes:     hermes,
+		timeGetter: time.Now,
+		interval:   checkInterval,
+		stop:       make(chan struct{}),
+	}
+}
+
+// Start connects if a schedule keeps consumer connected at the moment, then follows the schedules. Blocks until stopped.
+func (s *Scheduler) Start() {
+	prev := s.timeGetter()
+	s.resume(prev)
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(s.interval):
+		}
+
+		now := s.timeGetter()
+		s.check(prev, now)
+		prev = now
+	}
+}
+
+// Stop stops following the schedules, current connection is left intact.
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *Scheduler) list() []Schedule {
+	schedules, err := s.schedules.List()
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get connection schedules")
+	}
+	return schedules
+}
+
+// resume connects if node was started in the middle of a scheduled connection.
+func (s *Scheduler) resume(now time.Time) {
+	for _, sc := range s.list() {
+		if sc.isConnectedAt(now) {
+			s.connect(sc)
+			return
+		}
+	}
+}
+
+// check performs connects and disconnects scheduled after prev and not later than now, disconnects go first.
+func (s *Scheduler) check(prev, now time.Time) {
+	schedules := s.list()
+	for _, sc := range schedules {
+		if sc.lastOccurrence(sc.DisconnectAt, now).After(prev) {
+			s.disconnect(sc)
+		}
+	}
+	for _, sc := range schedules {
+		if sc.lastOccurrence(sc.ConnectAt, now).After(prev) {
+			s.connect(sc)
+		}
+	}
+}
+
+func (s *Scheduler) connect(sc Schedule) {
+	log.Info().Msgf("Connecting with profile %s as scheduled by %s", sc.Profile, sc.Name)
+	if err := s.connectWithProfile(sc); err != nil {
+		if err == connection.ErrAlreadyExists {
+			log.Info().Msgf("Already connected, skipping connect scheduled by %s", sc.Name)
+			return
+		}
+		log.Error().Err(err).Msgf("Scheduled connect of %s failed", sc.Name)
+	}
+}
+
+func (s *Scheduler) connectWithProfile(sc Schedule) error {
+	p, err := s.profiles.Get(sc.Profile)
+	if err != nil {
+		return errors.Wrapf(err, "could not get profile %s", sc.Profile)
+	}
+
+	consumerID, err := s.identities.UseOrCreate(sc.ConsumerID, "")
+	if err != nil {
+		return errors.Wrap(err, "could not unlock identity")
+	}
+
+	proposals, err := p.Proposals(s.proposals)
+	if err != nil {
+		return err
+	}
+
+	params := p.ConnectParams()
+	params.FallbackProposals = proposals[1:]
+	return s.manager.Connect(consumerID, s.hermes.HermesID(consumerID), proposals[0], params)
+}
+
+func (s *Scheduler) disconnect(sc Schedule) {
+	log.Info().Msgf("Disconnecting as scheduled by %s", sc.Name)
+	if err := s.manager.Disconnect(); err != nil && err != connection.ErrNoConnection {
+		log.Error().Err(err).Msgf("Scheduled disconnect of %s failed", sc.Name)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/consumer/profile"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+var workHours = Schedule{
+	Name:         "work-hours",
+	Days:         weekdays,
+	ConnectAt:    &TimeOfDay{Hour: 9},
+	DisconnectAt: &TimeOfDay{Hour: 17},
+	Profile:      "work",
+	ConsumerID:   "0xconsumer",
+}
+
+func Test_Scheduler_ConnectsAndDisconnectsOnSchedule(t *testing.T) {
+	manager := &managerFake{}
+	scheduler := newTestScheduler(manager, workHours)
+
+	scheduler.check(monday(8, 0), monday(8, 30))
+	assert.Equal(t, 0, manager.connects)
+
+	scheduler.check(monday(8, 30), monday(9, 0))
+	assert.Equal(t, 1, manager.connects)
+	assert.Equal(t, identity.FromAddress("0xconsumer"), manager.consumerID)
+	assert.Equal(t, common.HexToAddress("0x3"), manager.hermesID)
+	assert.Equal(t, "0x1", manager.proposal.ProviderID)
+	assert.Equal(t, connection.DNSOptionProvider, manager.params.DNS)
+
+	scheduler.check(monday(9, 0), monday(16, 30))
+	assert.Equal(t, 1, manager.connects)
+	assert.Equal(t, 0, manager.disconnects)
+
+	scheduler.check(monday(16, 30), monday(17, 0))
+	assert.Equal(t, 1, manager.disconnects)
+}
+
+func Test_Scheduler_DisconnectsAtGivenTime(t *testing.T) {
+	manager := &managerFake{}
+	scheduler := newTestScheduler(manager, Schedule{Name: "night", DisconnectAt: &TimeOfDay{Hour: 23}})
+
+	scheduler.check(monday(22, 30), monday(23, 0))
+
+	assert.Equal(t, 0, manager.connects)
+	assert.Equal(t, 1, manager.disconnects)
+}
+
+func Test_Scheduler_ResumesScheduledConnection(t *testing.T) {
+	manager := &managerFake{}
+	scheduler := newTestScheduler(manager, workHours)
+
+	scheduler.resume(monday(18, 0))
+	assert.Equal(t, 0, manager.connects)
+
+	scheduler.resume(monday(12, 0))
+	assert.Equal(t, 1, manager.connects)
+}
+
+func newTestScheduler(manager *managerFake, schedules ...Schedule) *Scheduler {
+	profiles := &profileStorageFake{profile.Profile{Name: "work", ProviderID: "0x1", ServiceType: "wireguard", DNS: connection.DNSOptionProvider}}
+	repository := &repositoryFake{proposals: []market.ServiceProposal{{ProviderID: "0x1", ServiceType: "wireguard"}}}
+	return NewScheduler(&scheduleStorageFake{schedules}, profiles, repository, &identitiesFake{}, manager, &hermesResolverFake{hermesID: common.HexToAddress("0x3")})
+}
+
+type managerFake struct {
+	connects    int
+	disconnects int
+	consumerID  identity.Identity
+	hermesID    common.Address
+	proposal    market.ServiceProposal
+	params      connection.ConnectParams
+}
+
+func (m *managerFake) Connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params connection.ConnectParams) error {
+	m.connects++
+	m.consumerID = consumerID
+	m.hermesID = hermesID
+	m.proposal = proposal
+	m.params = params
+	return nil
+}
+
+func (m *managerFake) Disconnect() error {
+	m.disconnects++
+	return nil
+}
+
+type scheduleStorageFake struct {
+	schedules []Schedule
+}
+
+func (s *scheduleStorageFake) List() ([]Schedule, error) {
+	return s.schedules, nil
+}
+
+type profileStorageFake struct {
+	profile profile.Profile
+}
+
+func (s *profileStorageFake) Get(name string) (profile.Profile, error) {
+	if name != s.profile.Name {
+		return profile.Profile{}, profile.ErrNotFound
+	}
+	return s.profile, nil
+}
+
+type repositoryFake struct {
+	proposals []market.ServiceProposal
+}
+
+func (r *repositoryFake) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	for _, p := range r.proposals {
+		if p.ProviderID == id.ProviderID && p.ServiceType == id.ServiceType {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *repositoryFake) Proposals(_ *proposal.Filter) ([]market.ServiceProposal, error) {
+	return r.proposals, nil
+}
+
+type hermesResolverFake struct {
+	hermesID common.Address
+}
+
+func (h *hermesResolverFake) HermesID(_ identity.Identity) common.Address {
+	return h.hermesID
+}
+
+type identitiesFake struct{}
+
+func (i *identitiesFake) UseOrCreate(address, _ string) (identity.Identity, error) {
+	return identity.FromAddress(address), nil
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const scheduleBucket = "connection-schedules"
+
+type persistentStorage interface {
+	Store(bucket string, data interface{}) error
+	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
+	GetAllFrom(bucket string, data interface{}) error
+	Delete(bucket string, data interface{}) error
+}
+
+var errBoltNotFound = "not found"
+
+// ErrNotFound represents an error where schedule with the given name does not exist
+var ErrNotFound = errors.New("schedule not found")
+
+// Storage keeps connect and disconnect schedules.
+type Storage struct {
+	lock sync.Mutex
+	bolt persistentStorage
+}
+
+// NewStorage returns a new instance of the schedule storage
+func NewStorage(bolt persistentStorage) *Storage {
+	return &Storage{
+		bolt: bolt,
+	}
+}
+
+// Save validates and stores the schedule, replacing the existing schedule with the same name.
+func (s *Storage) Save(sc Schedule) error {
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return errors.Wrap(s.bolt.Store(scheduleBucket, &sc), "could not store schedule")
+}
+
+// Get returns the schedule by its name.
+func (s *Storage) Get(name string) (Schedule, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.get(name)
+}
+
+func (s *Storage) get(name string) (Schedule, error) {
+	result := Schedule{}
+	err := s.bolt.GetOneByField(scheduleBucket, "Name", name, &result)
+	if err != nil {
+		if err.Error() == errBoltNotFound {
+			return result, ErrNotFound
+		}
+		return result, errors.Wrap(err, "could not get schedule")
+	}
+	return result, nil
+}
+
+// List returns all schedules ordered by name.
+func (s *Storage) List() ([]Schedule, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	res := []Schedule{}
+	err := s.bolt.GetAllFrom(scheduleBucket, &res)
+	if err != nil && err.Error() != errBoltNotFound {
+		return nil, errors.Wrap(err, "could not get schedules")
+	}
+	return res, nil
+}
+
+// Delete removes the schedule by its name.
+func (s *Storage) Delete(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sc, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(s.bolt.Delete(scheduleBucket, &sc), "could not delete schedule")
+}
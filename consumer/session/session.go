@@ -32,6 +32,9 @@ const (
 	StatusCompleted = "Completed"
 )
 
+// DisconnectReasonInterrupted means that session was cut short by node restart, its statistics come from the last checkpoint
+const DisconnectReasonInterrupted = "Interrupted"
+
 const (
 	// DirectionConsumed marks traffic transaction where node participated as consumer.
 	DirectionConsumed = "Consumed"
@@ -56,6 +59,8 @@ type History struct {
 	Status  string
 	Started time.Time
 	Updated time.Time
+	// DisconnectReason is set when node closed the session by itself, e.g. after data cap was reached
+	DisconnectReason string
 }
 
 // GetDuration returns delta in seconds (TimeUpdated - TimeStarted)
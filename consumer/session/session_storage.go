@@ -36,12 +36,16 @@ import (
 
 const sessionStorageBucketName = "session-history"
 
+// checkpointInterval is how often statistics of active sessions are persisted, to survive node restart.
+const checkpointInterval = time.Minute
+
 type timeGetter func() time.Time
 
 // Storage contains functions for storing, getting session objects.
 type Storage struct {
-	storage    *boltdb.Bolt
-	timeGetter timeGetter
+	storage            *boltdb.Bolt
+	timeGetter         timeGetter
+	checkpointInterval time.Duration
 
 	mu             sync.RWMutex
 	sessionsActive map[session_node.ID]History
@@ -50,8 +54,9 @@ type Storage struct {
 // NewSessionStorage creates session repository with given dependencies.
 func NewSessionStorage(storage *boltdb.Bolt) *Storage {
 	return &Storage{
-		storage:    storage,
-		timeGetter: time.Now,
+		storage:            storage,
+		timeGetter:         time.Now,
+		checkpointInterval: checkpointInterval,
 
 		sessionsActive: make(map[session_node.ID]History),
 	}
@@ -117,6 +122,43 @@ func (repo *Storage) Stats(filter *Filter) (result Stats, err error) {
 	return result, err
 }
 
+// StatsByIdentity retrieves aggregated statistics grouped by the local identity participating in the session.
+func (repo *Storage) StatsByIdentity(filter *Filter) (result map[identity.Identity]IdentityStats, err error) {
+	query := repo.storage.DB().
+		From(sessionStorageBucketName).
+		Select(filter.toMatcher()).
+		OrderBy("Started").
+		Reverse()
+
+	result = make(map[identity.Identity]IdentityStats)
+	err = query.Each(new(History), func(record interface{}) error {
+		session := record.(*History)
+
+		id := session.ProviderID
+		if session.Direction == DirectionConsumed {
+			id = session.ConsumerID
+		}
+		stats, found := result[id]
+		if !found {
+			stats = NewIdentityStats()
+		}
+		stats.Add(*session)
+		result[id] = stats
+
+		return nil
+	})
+	return result, err
+}
+
+// DataConsumedSince returns count of bytes sent and received by consumer sessions started since given time.
+func (repo *Storage) DataConsumedSince(since time.Time) (uint64, error) {
+	stats, err := repo.Stats(NewFilter().SetStartedFrom(since).SetDirection(DirectionConsumed))
+	if err != nil {
+		return 0, err
+	}
+	return stats.SumDataSent + stats.SumDataReceived, nil
+}
+
 const stepDay = 24 * time.Hour
 
 // StatsByDay retrieves aggregated statistics grouped by day to Filter.StatsByDay.
@@ -154,7 +196,7 @@ func (repo *Storage) consumeServiceSessionEvent(e session_event.AppEventSession)
 
 	switch e.Status {
 	case session_event.RemovedStatus:
-		repo.handleEndedEvent(sessionID)
+		repo.handleEndedEvent(sessionID, "")
 	case session_event.CreatedStatus:
 		repo.mu.Lock()
 		repo.sessionsActive[sessionID] = History{
@@ -188,6 +230,7 @@ func (repo *Storage) consumeServiceSessionStatisticsEvent(e session_event.AppEve
 
 	row.DataSent = e.Down
 	row.DataReceived = e.Up
+	repo.checkpoint(&row)
 	repo.sessionsActive[sessionID] = row
 }
 
@@ -212,7 +255,7 @@ func (repo *Storage) consumeConnectionSessionEvent(e connectionstate.AppEventCon
 
 	switch e.Status {
 	case connectionstate.SessionEndedStatus:
-		repo.handleEndedEvent(sessionID)
+		repo.handleEndedEvent(sessionID, string(e.SessionInfo.DisconnectReason))
 	case connectionstate.SessionCreatedStatus:
 		repo.mu.Lock()
 		repo.sessionsActive[sessionID] = History{
@@ -245,6 +288,7 @@ func (repo *Storage) consumeConnectionStatisticsEvent(e connectionstate.AppEvent
 
 	row.DataSent = e.Stats.BytesSent
 	row.DataReceived = e.Stats.BytesReceived
+	repo.checkpoint(&row)
 	repo.sessionsActive[e.SessionInfo.SessionID] = row
 }
 
@@ -271,7 +315,50 @@ func (repo *Storage) consumeConnectionSpendingEvent(e pingpong_event.AppEventInv
 	log.Debug().Msgf("Session %v updated", sessionID)
 }
 
-func (repo *Storage) handleEndedEvent(sessionID session_node.ID) {
+// checkpoint persists statistics of the active session once per checkpoint interval,
+// so that session history keeps them in case node is restarted before the session ends.
+func (repo *Storage) checkpoint(row *History) {
+	now := repo.timeGetter().UTC()
+	if now.Sub(row.Updated) < repo.checkpointInterval {
+		return
+	}
+
+	row.Updated = now
+	if err := repo.storage.Update(sessionStorageBucketName, row); err != nil {
+		log.Error().Err(err).Msgf("Session %v checkpoint failed", row.SessionID)
+	}
+}
+
+// RestoreInterrupted completes sessions which were still active when node was stopped.
+// Their statistics are restored from the last checkpoint, and the last checkpoint time is kept as the end time.
+func (repo *Storage) RestoreInterrupted() error {
+	sessions, err := repo.List(NewFilter().SetStatus(StatusNew))
+	if err != nil {
+		return err
+	}
+
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	for _, row := range sessions {
+		if _, ok := repo.sessionsActive[row.SessionID]; ok {
+			continue
+		}
+
+		row.Status = StatusCompleted
+		row.DisconnectReason = DisconnectReasonInterrupted
+		if row.Updated.IsZero() {
+			row.Updated = row.Started
+		}
+		if err := repo.storage.Update(sessionStorageBucketName, &row); err != nil {
+			return err
+		}
+		log.Info().Msgf("Session %v interrupted by node restart, restored from the last checkpoint", row.SessionID)
+	}
+	return nil
+}
+
+func (repo *Storage) handleEndedEvent(sessionID session_node.ID, disconnectReason string) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
@@ -282,6 +369,7 @@ func (repo *Storage) handleEndedEvent(sessionID session_node.ID) {
 	}
 	row.Updated = repo.timeGetter().UTC()
 	row.Status = StatusCompleted
+	row.DisconnectReason = disconnectReason
 
 	err := repo.storage.Update(sessionStorageBucketName, &row)
 	if err != nil {
@@ -176,6 +176,104 @@ func TestSessionStorage_Stats(t *testing.T) {
 	assert.Equal(t, NewStats(), result)
 }
 
+func TestSessionStorage_StatsByIdentity(t *testing.T) {
+	// given
+	storage, storageCleanup := newStorageWithSessions(
+		History{
+			SessionID:    session_node.ID("session1"),
+			Direction:    DirectionConsumed,
+			ConsumerID:   identity.FromAddress("identity1"),
+			ProviderID:   identity.FromAddress("provider1"),
+			DataSent:     10,
+			DataReceived: 100,
+			Tokens:       big.NewInt(5),
+			Started:      time.Date(2020, 6, 17, 10, 0, 0, 0, time.UTC),
+			Updated:      time.Date(2020, 6, 17, 10, 0, 10, 0, time.UTC),
+		},
+		History{
+			SessionID:    session_node.ID("session2"),
+			Direction:    DirectionProvided,
+			ConsumerID:   identity.FromAddress("consumer1"),
+			ProviderID:   identity.FromAddress("identity1"),
+			DataSent:     20,
+			DataReceived: 200,
+			Tokens:       big.NewInt(7),
+			Started:      time.Date(2020, 6, 17, 11, 0, 0, 0, time.UTC),
+			Updated:      time.Date(2020, 6, 17, 11, 0, 20, 0, time.UTC),
+		},
+		History{
+			SessionID:    session_node.ID("session3"),
+			Direction:    DirectionProvided,
+			ConsumerID:   identity.FromAddress("consumer1"),
+			ProviderID:   identity.FromAddress("identity2"),
+			DataSent:     30,
+			DataReceived: 300,
+			Tokens:       big.NewInt(9),
+			Started:      time.Date(2020, 6, 17, 12, 0, 0, 0, time.UTC),
+			Updated:      time.Date(2020, 6, 17, 12, 0, 30, 0, time.UTC),
+		},
+	)
+	defer storageCleanup()
+
+	// when
+	result, err := storage.StatsByIdentity(NewFilter())
+
+	// then
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+
+	stats1 := result[identity.FromAddress("identity1")]
+	assert.Equal(t, 1, stats1.Consumed.Count)
+	assert.Equal(t, uint64(110), stats1.Consumed.SumDataSent+stats1.Consumed.SumDataReceived)
+	assert.Equal(t, big.NewInt(5), stats1.Consumed.SumTokens)
+	assert.Equal(t, 1, stats1.Provided.Count)
+	assert.Equal(t, big.NewInt(7), stats1.Provided.SumTokens)
+	assert.Equal(t, 20*time.Second, stats1.Provided.SumDuration)
+
+	stats2 := result[identity.FromAddress("identity2")]
+	assert.Equal(t, NewStats(), stats2.Consumed)
+	assert.Equal(t, 1, stats2.Provided.Count)
+	assert.Equal(t, big.NewInt(9), stats2.Provided.SumTokens)
+}
+
+func TestSessionStorage_DataConsumedSince(t *testing.T) {
+	// given
+	storage, storageCleanup := newStorageWithSessions(
+		History{
+			SessionID:    session_node.ID("session1"),
+			Direction:    DirectionConsumed,
+			DataSent:     10,
+			DataReceived: 100,
+			Started:      time.Date(2020, 6, 16, 23, 0, 0, 0, time.UTC),
+			Tokens:       big.NewInt(0),
+		},
+		History{
+			SessionID:    session_node.ID("session2"),
+			Direction:    DirectionConsumed,
+			DataSent:     20,
+			DataReceived: 200,
+			Started:      time.Date(2020, 6, 17, 10, 0, 0, 0, time.UTC),
+			Tokens:       big.NewInt(0),
+		},
+		History{
+			SessionID:    session_node.ID("session3"),
+			Direction:    DirectionProvided,
+			DataSent:     30,
+			DataReceived: 300,
+			Started:      time.Date(2020, 6, 17, 11, 0, 0, 0, time.UTC),
+			Tokens:       big.NewInt(0),
+		},
+	)
+	defer storageCleanup()
+
+	// when
+	consumed, err := storage.DataConsumedSince(time.Date(2020, 6, 17, 0, 0, 0, 0, time.UTC))
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(220), consumed)
+}
+
 func TestSessionStorage_StatsByDay(t *testing.T) {
 	// given
 	sessionExpected := History{
@@ -373,6 +471,126 @@ func TestSessionStorage_consumeEventEndedOK(t *testing.T) {
 	)
 }
 
+func TestSessionStorage_consumeEventEndedKeepsDisconnectReason(t *testing.T) {
+	// given
+	storage, storageCleanup := newStorage()
+	defer storageCleanup()
+	endedSession := connectionSessionMock
+	endedSession.DisconnectReason = connectionstate.DisconnectReasonDataCap
+
+	// when
+	storage.consumeConnectionSessionEvent(connectionstate.AppEventConnectionSession{
+		Status:      connectionstate.SessionCreatedStatus,
+		SessionInfo: connectionSessionMock,
+	})
+	storage.consumeConnectionSessionEvent(connectionstate.AppEventConnectionSession{
+		Status:      connectionstate.SessionEndedStatus,
+		SessionInfo: endedSession,
+	})
+
+	// then
+	sessions, err := storage.GetAll()
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "DataCapReached", sessions[0].DisconnectReason)
+}
+
+func TestSessionStorage_checkpointsStatistics(t *testing.T) {
+	// given
+	now := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+	storage, storageCleanup := newStorage()
+	storage.timeGetter = func() time.Time {
+		return now
+	}
+	defer storageCleanup()
+
+	storage.consumeConnectionSessionEvent(connectionstate.AppEventConnectionSession{
+		Status:      connectionstate.SessionCreatedStatus,
+		SessionInfo: connectionSessionMock,
+	})
+
+	// when
+	storage.consumeConnectionStatisticsEvent(connectionstate.AppEventConnectionStatistics{
+		Stats:       connectionstate.Statistics{BytesReceived: 10, BytesSent: 5},
+		SessionInfo: connectionSessionMock,
+	})
+	now = now.Add(time.Second)
+	storage.consumeConnectionStatisticsEvent(connectionstate.AppEventConnectionStatistics{
+		Stats:       connectionStatsMock,
+		SessionInfo: connectionSessionMock,
+	})
+
+	// then
+	sessions, err := storage.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, uint64(10), sessions[0].DataReceived)
+	assert.Equal(t, uint64(5), sessions[0].DataSent)
+	assert.Equal(t, time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC), sessions[0].Updated)
+
+	// when
+	now = now.Add(checkpointInterval)
+	storage.consumeConnectionStatisticsEvent(connectionstate.AppEventConnectionStatistics{
+		Stats:       connectionStatsMock,
+		SessionInfo: connectionSessionMock,
+	})
+
+	// then
+	sessions, err = storage.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, connectionStatsMock.BytesReceived, sessions[0].DataReceived)
+	assert.Equal(t, connectionStatsMock.BytesSent, sessions[0].DataSent)
+	assert.Equal(t, now, sessions[0].Updated)
+	assert.Equal(t, StatusNew, sessions[0].Status)
+}
+
+func TestSessionStorage_RestoreInterrupted(t *testing.T) {
+	// given
+	interrupted := History{
+		SessionID:    session_node.ID("interrupted"),
+		Direction:    DirectionConsumed,
+		Started:      time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC),
+		Updated:      time.Date(2020, 4, 1, 11, 0, 0, 0, time.UTC),
+		DataSent:     50,
+		DataReceived: 100,
+		Status:       StatusNew,
+	}
+	completed := History{
+		SessionID: session_node.ID("completed"),
+		Direction: DirectionConsumed,
+		Started:   time.Date(2020, 4, 1, 9, 0, 0, 0, time.UTC),
+		Updated:   time.Date(2020, 4, 1, 9, 30, 0, 0, time.UTC),
+		Status:    StatusCompleted,
+	}
+	storage, storageCleanup := newStorageWithSessions(interrupted, completed)
+	defer storageCleanup()
+
+	storage.consumeConnectionSessionEvent(connectionstate.AppEventConnectionSession{
+		Status:      connectionstate.SessionCreatedStatus,
+		SessionInfo: connectionSessionMock,
+	})
+
+	// when
+	err := storage.RestoreInterrupted()
+
+	// then
+	assert.NoError(t, err)
+	sessions, err := storage.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 3)
+	assert.Equal(t, session_node.ID("sessionID"), sessions[0].SessionID)
+	assert.Equal(t, StatusNew, sessions[0].Status)
+	assert.Equal(t, session_node.ID("interrupted"), sessions[1].SessionID)
+	assert.Equal(t, StatusCompleted, sessions[1].Status)
+	assert.Equal(t, DisconnectReasonInterrupted, sessions[1].DisconnectReason)
+	assert.Equal(t, uint64(50), sessions[1].DataSent)
+	assert.Equal(t, uint64(100), sessions[1].DataReceived)
+	assert.Equal(t, interrupted.Updated, sessions[1].Updated)
+	assert.Equal(t, session_node.ID("completed"), sessions[2].SessionID)
+	assert.Empty(t, sessions[2].DisconnectReason)
+}
+
 func TestSessionStorage_consumeEventConnectedOK(t *testing.T) {
 	// given
 	storage, storageCleanup := newStorage()
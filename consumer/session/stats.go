@@ -57,3 +57,28 @@ func (s *Stats) Add(session History) {
 	s.SumDuration += session.GetDuration()
 	s.SumTokens = new(big.Int).Add(s.SumTokens, session.Tokens)
 }
+
+// NewIdentityStats initiates zero IdentityStats instance.
+func NewIdentityStats() IdentityStats {
+	return IdentityStats{
+		Consumed: NewStats(),
+		Provided: NewStats(),
+	}
+}
+
+// IdentityStats holds aggregate session statistics of a single identity.
+type IdentityStats struct {
+	// Consumed aggregates sessions where identity was a consumer, tokens are the spendings.
+	Consumed Stats
+	// Provided aggregates sessions where identity was a provider, tokens are the earnings.
+	Provided Stats
+}
+
+// Add accumulates given session to statistics of the matching direction.
+func (s *IdentityStats) Add(session History) {
+	if session.Direction == DirectionConsumed {
+		s.Consumed.Add(session)
+	} else {
+		s.Provided.Add(session)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package abuse
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrNotSupported indicates that connections can not be tracked on the host OS.
+var ErrNotSupported = errors.New("connection tracking is not supported")
+
+// Connection is a connection tracked by the host firewall, source is the address of the originating side.
+type Connection struct {
+	Protocol        string
+	Source          net.IP
+	SourcePort      int
+	Destination     net.IP
+	DestinationPort int
+}
+
+// key identifies the connection between samples.
+func (c Connection) key() string {
+	return c.Protocol + " " + c.Source.String() + ":" + strconv.Itoa(c.SourcePort) + " " + c.Destination.String() + ":" + strconv.Itoa(c.DestinationPort)
+}
+
+// Sampler lists connections currently tracked by the host.
+type Sampler interface {
+	Connections() ([]Connection, error)
+}
+
+// parseConntrack parses connection tracking table in /proc/net/nf_conntrack format,
+// only the original direction of each connection is taken.
+func parseConntrack(r io.Reader) ([]Connection, error) {
+	var conns []Connection
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+
+		conn := Connection{Protocol: fields[2]}
+		for _, field := range fields[3:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "src":
+				if conn.Source == nil {
+					conn.Source = net.ParseIP(kv[1])
+				}
+			case "dst":
+				if conn.Destination == nil {
+					conn.Destination = net.ParseIP(kv[1])
+				}
+			case "sport":
+				if conn.SourcePort == 0 {
+					conn.SourcePort, _ = strconv.Atoi(kv[1])
+				}
+			case "dport":
+				if conn.DestinationPort == 0 {
+					conn.DestinationPort, _ = strconv.Atoi(kv[1])
+				}
+			}
+		}
+		if conn.Source == nil || conn.Destination == nil {
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	return conns, scanner.Err()
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package abuse
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConntrack(t *testing.T) {
+	table := `ipv4     2 tcp      6 431999 ESTABLISHED src=10.182.0.2 dst=93.184.216.34 sport=51234 dport=443 src=93.184.216.34 dst=192.168.1.5 sport=443 dport=51234 [ASSURED] mark=0 zone=0 use=2
+ipv4     2 udp      17 29 src=10.182.0.2 dst=8.8.8.8 sport=40000 dport=53 [UNREPLIED] src=8.8.8.8 dst=192.168.1.5 sport=53 dport=40000 mark=0 zone=0 use=2
+garbage
+`
+
+	conns, err := parseConntrack(strings.NewReader(table))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Connection{
+		{Protocol: "tcp", Source: net.ParseIP("10.182.0.2"), SourcePort: 51234, Destination: net.ParseIP("93.184.216.34"), DestinationPort: 443},
+		{Protocol: "udp", Source: net.ParseIP("10.182.0.2"), SourcePort: 40000, Destination: net.ParseIP("8.8.8.8"), DestinationPort: 53},
+	}, conns)
+}
@@ -0,0 +1,405 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package abuse
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/eventbus"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+)
+
+// AppTopicAbuseDetected is a topic for publish events about abusive consumer sessions.
+const AppTopicAbuseDetected = "Consumer abuse detected"
+
+// Reason tells which heuristic detected the abuse.
+type Reason string
+
+const (
+	// ReasonSpam means consumer connects to mail servers.
+	ReasonSpam Reason = "spam"
+	// ReasonPortScan means consumer probes many ports of a single host.
+	ReasonPortScan Reason = "port-scan"
+	// ReasonConnectionRate means consumer opens new connections excessively fast.
+	ReasonConnectionRate Reason = "connection-rate"
+)
+
+// Action is taken against the session of abusive consumer.
+type Action string
+
+const (
+	// ActionThrottle limits speed of the session, session is terminated if abuse continues.
+	ActionThrottle Action = "throttle"
+	// ActionTerminate ends the session.
+	ActionTerminate Action = "terminate"
+)
+
+// NewAction parses action name.
+func NewAction(s string) (Action, error) {
+	switch a := Action(strings.ToLower(s)); a {
+	case ActionThrottle, ActionTerminate:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown action %q, expected %q or %q", s, ActionThrottle, ActionTerminate)
+}
+
+// AppEventAbuseDetected is published once action is taken against abusive consumer session.
+type AppEventAbuseDetected struct {
+	ServiceID  string
+	SessionID  string
+	ConsumerID string
+	Reason     Reason
+	Action     Action
+	// BannedUntil is set when consumer was banned from creating new sessions.
+	BannedUntil time.Time
+}
+
+// Rules are heuristics telling that consumer abuses provider connection, zero value disables the rule.
+type Rules struct {
+	// SpamPorts are destination ports of mail servers consumers are not allowed to connect to.
+	SpamPorts []int
+	// PortScanPorts is the number of distinct ports of a single host connected to at once which is considered a port scan.
+	PortScanPorts int
+	// MaxNewConnectionsPerMinute is the rate of new connections above which it is considered excessive.
+	MaxNewConnectionsPerMinute int
+}
+
+// IsZero checks whether all rules are disabled.
+func (r Rules) IsZero() bool {
+	return len(r.SpamPorts) == 0 && r.PortScanPorts <= 0 && r.MaxNewConnectionsPerMinute <= 0
+}
+
+// Config configures abuse detector.
+type Config struct {
+	Rules  Rules
+	Action Action
+	// ThrottleBandwidth is the speed abusive sessions are limited to.
+	ThrottleBandwidth datasize.BitSpeed
+	// BanDuration is the time consumers of terminated sessions are not allowed to create sessions, zero value disables bans.
+	BanDuration time.Duration
+	// Exempt are trusted consumer identities which are never penalized.
+	Exempt        []string
+	CheckInterval time.Duration
+}
+
+// Services terminates and throttles sessions of provided services.
+type Services interface {
+	TerminateSession(id service.ID, sessionID string) error
+	ThrottleSession(id service.ID, sessionID string, bandwidth datasize.BitSpeed) error
+}
+
+// Bans temporarily rejects consumers.
+type Bans interface {
+	Ban(consumerID string, until time.Time) error
+}
+
+// providedSession is a session tracked by the detector.
+type providedSession struct {
+	serviceID  string
+	consumerID string
+	network    *net.IPNet
+	throttled  bool
+}
+
+// sessionTraffic is the traffic of a single session seen during a check.
+type sessionTraffic struct {
+	newConnections int
+	spam           bool
+	hostPorts      map[string]map[int]struct{}
+	// keys are connections counted towards the violation.
+	keys []string
+}
+
+// Detector inspects connections of consumer sessions and penalizes abusive consumers:
+// their sessions are throttled or terminated and their identities are temporarily banned.
+type Detector struct {
+	config    Config
+	sampler   Sampler
+	services  Services
+	bans      Bans
+	publisher eventbus.Publisher
+	now       func() time.Time
+
+	lock     sync.Mutex
+	sessions map[string]*providedSession
+	seen     map[string]struct{}
+	// penalized are connections already counted towards a violation, they are not counted again.
+	penalized map[string]struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDetector creates consumer abuse detector.
+func NewDetector(config Config, sampler Sampler, services Services, bans Bans, publisher eventbus.Publisher) *Detector {
+	return &Detector{
+		config:    config,
+		sampler:   sampler,
+		services:  services,
+		bans:      bans,
+		publisher: publisher,
+		now:       time.Now,
+		sessions:  make(map[string]*providedSession),
+		penalized: make(map[string]struct{}),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to session events.
+func (d *Detector) Subscribe(bus eventbus.Subscriber) error {
+	if err := bus.Subscribe(sevent.AppTopicSession, d.consumeSessionEvent); err != nil {
+		return err
+	}
+	return bus.Subscribe(sevent.AppTopicSessionNetwork, d.consumeSessionNetworkEvent)
+}
+
+// Start starts inspecting connections.
+func (d *Detector) Start() {
+	go d.checkLoop()
+}
+
+// Stop stops inspecting connections.
+func (d *Detector) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+}
+
+func (d *Detector) checkLoop() {
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-time.After(d.config.CheckInterval):
+			if err := d.check(); err != nil {
+				log.Warn().Err(err).Msg("Could not list connections, abuse detector stopped")
+				return
+			}
+		}
+	}
+}
+
+func (d *Detector) consumeSessionEvent(e sevent.AppEventSession) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	switch e.Status {
+	case sevent.CreatedStatus:
+		s := d.sessionLocked(e.Session.ID)
+		s.serviceID = e.Service.ID
+		s.consumerID = e.Session.ConsumerID.Address
+	case sevent.RemovedStatus:
+		delete(d.sessions, e.Session.ID)
+	}
+}
+
+func (d *Detector) consumeSessionNetworkEvent(e sevent.AppEventSessionNetwork) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	network := e.Network
+	d.sessionLocked(e.ID).network = &network
+}
+
+// sessionLocked returns tracked session, session events may arrive in any order.
+func (d *Detector) sessionLocked(id string) *providedSession {
+	s, ok := d.sessions[id]
+	if !ok {
+		s = &providedSession{}
+		d.sessions[id] = s
+	}
+	return s
+}
+
+// check inspects connections and penalizes sessions violating the rules.
+func (d *Detector) check() error {
+	conns, err := d.sampler.Connections()
+	if err != nil {
+		return err
+	}
+
+	d.lock.Lock()
+	violations := d.inspectLocked(conns)
+	d.lock.Unlock()
+
+	for sessionID, reason := range violations {
+		d.penalize(sessionID, reason)
+	}
+	return nil
+}
+
+// inspectLocked attributes connections to sessions by their source address and returns sessions violating the rules.
+func (d *Detector) inspectLocked(conns []Connection) map[string]Reason {
+	// Connections tracked before the first check are not known to be new.
+	countNew := d.seen != nil
+	seen := make(map[string]struct{}, len(conns))
+	traffic := make(map[string]*sessionTraffic)
+	for _, conn := range conns {
+		key := conn.key()
+		seen[key] = struct{}{}
+
+		sessionID := d.sessionOfLocked(conn.Source)
+		if sessionID == "" {
+			continue
+		}
+		t, ok := traffic[sessionID]
+		if !ok {
+			t = &sessionTraffic{hostPorts: make(map[string]map[int]struct{})}
+			traffic[sessionID] = t
+		}
+
+		if _, known := d.seen[key]; countNew && !known {
+			t.newConnections++
+		}
+		if _, ok := d.penalized[key]; ok {
+			continue
+		}
+		t.keys = append(t.keys, key)
+		for _, port := range d.config.Rules.SpamPorts {
+			if conn.DestinationPort == port {
+				t.spam = true
+			}
+		}
+		host := conn.Destination.String()
+		if t.hostPorts[host] == nil {
+			t.hostPorts[host] = make(map[int]struct{})
+		}
+		t.hostPorts[host][conn.DestinationPort] = struct{}{}
+	}
+	d.seen = seen
+	for key := range d.penalized {
+		if _, ok := seen[key]; !ok {
+			delete(d.penalized, key)
+		}
+	}
+
+	maxNew := d.config.Rules.MaxNewConnectionsPerMinute * int(d.config.CheckInterval) / int(time.Minute)
+	violations := make(map[string]Reason)
+	for sessionID, t := range traffic {
+		switch {
+		case t.spam:
+			violations[sessionID] = ReasonSpam
+		case d.config.Rules.PortScanPorts > 0 && t.maxHostPorts() >= d.config.Rules.PortScanPorts:
+			violations[sessionID] = ReasonPortScan
+		case d.config.Rules.MaxNewConnectionsPerMinute > 0 && t.newConnections > maxNew:
+			violations[sessionID] = ReasonConnectionRate
+		}
+	}
+	for sessionID := range violations {
+		for _, key := range traffic[sessionID].keys {
+			d.penalized[key] = struct{}{}
+		}
+	}
+	return violations
+}
+
+// maxHostPorts returns the largest number of distinct ports connected to on a single host.
+func (t *sessionTraffic) maxHostPorts() int {
+	max := 0
+	for _, ports := range t.hostPorts {
+		if len(ports) > max {
+			max = len(ports)
+		}
+	}
+	return max
+}
+
+// sessionOfLocked returns ID of the session tunnel network source address belongs to.
+func (d *Detector) sessionOfLocked(source net.IP) string {
+	for id, s := range d.sessions {
+		if s.network != nil && s.network.Contains(source) {
+			return id
+		}
+	}
+	return ""
+}
+
+// penalize throttles or terminates the session, unless consumer is exempt. Consumers of terminated sessions are banned.
+func (d *Detector) penalize(sessionID string, reason Reason) {
+	d.lock.Lock()
+	s, ok := d.sessions[sessionID]
+	if !ok || s.consumerID == "" {
+		d.lock.Unlock()
+		return
+	}
+	if d.isExempt(s.consumerID) {
+		d.lock.Unlock()
+		log.Info().Msgf("Ignoring %s of exempt consumer %s in session %s", reason, s.consumerID, sessionID)
+		return
+	}
+
+	action := d.config.Action
+	if action == ActionThrottle && s.throttled {
+		// Abuse continued despite throttling.
+		action = ActionTerminate
+	}
+	if action == ActionThrottle {
+		s.throttled = true
+	}
+	event := AppEventAbuseDetected{
+		ServiceID:  s.serviceID,
+		SessionID:  sessionID,
+		ConsumerID: s.consumerID,
+		Reason:     reason,
+	}
+	d.lock.Unlock()
+
+	if action == ActionThrottle {
+		err := d.services.ThrottleSession(service.ID(event.ServiceID), sessionID, d.config.ThrottleBandwidth)
+		if errors.Is(err, service.ErrThrottlingNotSupported) {
+			action = ActionTerminate
+		} else if err != nil {
+			log.Error().Err(err).Msgf("Could not throttle session %s", sessionID)
+		}
+	}
+	if action == ActionTerminate {
+		if err := d.services.TerminateSession(service.ID(event.ServiceID), sessionID); err != nil {
+			log.Error().Err(err).Msgf("Could not terminate session %s", sessionID)
+		}
+	}
+	if action == ActionTerminate && d.config.BanDuration > 0 {
+		until := d.now().Add(d.config.BanDuration)
+		if err := d.bans.Ban(event.ConsumerID, until); err != nil {
+			log.Error().Err(err).Msgf("Could not ban consumer %s", event.ConsumerID)
+		} else {
+			event.BannedUntil = until
+		}
+	}
+
+	event.Action = action
+	log.Warn().Msgf("Detected %s by consumer %s in session %s, taking action: %s", reason, event.ConsumerID, sessionID, action)
+	d.publisher.Publish(AppTopicAbuseDetected, event)
+}
+
+func (d *Detector) isExempt(consumerID string) bool {
+	for _, exempt := range d.config.Exempt {
+		if strings.EqualFold(exempt, consumerID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package abuse
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/mocks"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+)
+
+var testRules = Rules{SpamPorts: []int{25}, PortScanPorts: 3, MaxNewConnectionsPerMinute: 2}
+
+func TestDetector_ThrottlesThenTerminatesAbusiveSession(t *testing.T) {
+	services := &mockServices{}
+	bans := &mockBans{}
+	sampler := &mockSampler{}
+	bus := mocks.NewEventBus()
+	d := NewDetector(Config{
+		Rules:             testRules,
+		Action:            ActionThrottle,
+		ThrottleBandwidth: datasize.BitSpeed(datasize.MiB),
+		BanDuration:       time.Hour,
+		CheckInterval:     time.Minute,
+	}, sampler, services, bans, bus)
+	startSession(d, "s1", "0x1", "10.182.0.0/24")
+	startSession(d, "s2", "0x2", "10.182.1.0/24")
+
+	sampler.conns = []Connection{
+		conn("10.182.0.2", 1000, "1.1.1.1", 443),
+		conn("10.182.1.2", 1000, "1.1.1.1", 443),
+		conn("10.182.1.2", 1001, "1.1.1.2", 443),
+		conn("10.182.1.2", 1002, "1.1.1.3", 443),
+	}
+	assert.NoError(t, d.check())
+	assert.Empty(t, services.throttled)
+
+	sampler.conns = append(sampler.conns, conn("10.182.0.2", 1001, "2.2.2.2", 25))
+	assert.NoError(t, d.check())
+	assert.Equal(t, []string{"s1"}, services.throttled)
+	assert.Empty(t, bans.banned)
+
+	events := bus.GetEventHistory()
+	assert.Len(t, events, 1)
+	assert.Equal(t, AppEventAbuseDetected{
+		ServiceID: "service", SessionID: "s1", ConsumerID: "0x1", Reason: ReasonSpam, Action: ActionThrottle,
+	}, events[0].Event)
+
+	// Already penalized connections are not counted again.
+	assert.NoError(t, d.check())
+	assert.Empty(t, services.terminated)
+
+	sampler.conns = append(sampler.conns, conn("10.182.0.2", 1002, "2.2.2.3", 25))
+	assert.NoError(t, d.check())
+	assert.Equal(t, []string{"s1"}, services.terminated)
+	assert.Equal(t, []string{"0x1"}, bans.banned)
+
+	events = bus.GetEventHistory()
+	assert.Len(t, events, 2)
+	e := events[1].Event.(AppEventAbuseDetected)
+	assert.Equal(t, ActionTerminate, e.Action)
+	assert.False(t, e.BannedUntil.IsZero())
+}
+
+func TestDetector_CountsPortScanOnlyOnNewConnections(t *testing.T) {
+	sampler := &mockSampler{conns: []Connection{
+		conn("10.182.0.2", 1000, "1.1.1.1", 21),
+		conn("10.182.0.2", 1001, "1.1.1.1", 22),
+		conn("10.182.0.2", 1002, "1.1.1.1", 23),
+	}}
+	d := NewDetector(Config{Rules: testRules, Action: ActionThrottle, CheckInterval: time.Minute}, sampler, &mockServices{}, &mockBans{}, mocks.NewEventBus())
+	startSession(d, "s1", "0x1", "10.182.0.0/24")
+
+	assert.Equal(t, map[string]Reason{"s1": ReasonPortScan}, d.inspectLocked(sampler.conns))
+	assert.Empty(t, d.inspectLocked(sampler.conns))
+
+	sampler.conns = append(sampler.conns, conn("10.182.0.2", 1003, "1.1.1.1", 80), conn("10.182.0.2", 1004, "1.1.1.1", 81))
+	assert.Empty(t, d.inspectLocked(sampler.conns))
+
+	sampler.conns = append(sampler.conns, conn("10.182.0.2", 1005, "1.1.1.1", 82))
+	assert.Equal(t, map[string]Reason{"s1": ReasonPortScan}, d.inspectLocked(sampler.conns))
+}
+
+func TestDetector_DetectsPortScanAndConnectionRate(t *testing.T) {
+	services := &mockServices{}
+	sampler := &mockSampler{}
+	d := NewDetector(Config{Rules: testRules, Action: ActionTerminate, CheckInterval: time.Minute}, sampler, services, &mockBans{}, mocks.NewEventBus())
+	startSession(d, "s1", "0x1", "10.182.0.0/24")
+	startSession(d, "s2", "0x2", "10.182.1.0/24")
+
+	assert.NoError(t, d.check())
+	sampler.conns = []Connection{
+		conn("10.182.0.2", 1000, "1.1.1.1", 21),
+		conn("10.182.0.2", 1001, "1.1.1.1", 22),
+		conn("10.182.0.2", 1002, "1.1.1.1", 23),
+		conn("10.182.1.2", 1000, "1.1.1.1", 443),
+		conn("10.182.1.2", 1001, "1.1.1.2", 443),
+		conn("10.182.1.2", 1002, "1.1.1.3", 443),
+	}
+	violations := d.inspectLocked(sampler.conns)
+
+	assert.Equal(t, map[string]Reason{"s1": ReasonPortScan, "s2": ReasonConnectionRate}, violations)
+}
+
+func TestDetector_IgnoresExemptConsumers(t *testing.T) {
+	services := &mockServices{}
+	bans := &mockBans{}
+	sampler := &mockSampler{conns: []Connection{conn("10.182.0.2", 1000, "2.2.2.2", 25)}}
+	d := NewDetector(Config{
+		Rules:         testRules,
+		Action:        ActionTerminate,
+		BanDuration:   time.Hour,
+		Exempt:        []string{"0xAB"},
+		CheckInterval: time.Minute,
+	}, sampler, services, bans, mocks.NewEventBus())
+	startSession(d, "s1", "0xab", "10.182.0.0/24")
+
+	assert.NoError(t, d.check())
+
+	assert.Empty(t, services.terminated)
+	assert.Empty(t, bans.banned)
+}
+
+func TestDetector_TerminatesSessionIfThrottlingIsNotSupported(t *testing.T) {
+	services := &mockServices{throttleErr: service.ErrThrottlingNotSupported}
+	sampler := &mockSampler{conns: []Connection{conn("10.182.0.2", 1000, "2.2.2.2", 25)}}
+	d := NewDetector(Config{Rules: testRules, Action: ActionThrottle, CheckInterval: time.Minute}, sampler, services, &mockBans{}, mocks.NewEventBus())
+	startSession(d, "s1", "0x1", "10.182.0.0/24")
+
+	assert.NoError(t, d.check())
+
+	assert.Equal(t, []string{"s1"}, services.terminated)
+}
+
+func TestDetector_ForgetsRemovedSessions(t *testing.T) {
+	services := &mockServices{}
+	sampler := &mockSampler{conns: []Connection{conn("10.182.0.2", 1000, "2.2.2.2", 25)}}
+	d := NewDetector(Config{Rules: testRules, Action: ActionTerminate, CheckInterval: time.Minute}, sampler, services, &mockBans{}, mocks.NewEventBus())
+	startSession(d, "s1", "0x1", "10.182.0.0/24")
+	d.consumeSessionEvent(sevent.AppEventSession{Status: sevent.RemovedStatus, Session: sevent.SessionContext{ID: "s1"}})
+
+	assert.NoError(t, d.check())
+
+	assert.Empty(t, services.terminated)
+}
+
+func TestDetector_ReturnsSamplerError(t *testing.T) {
+	d := NewDetector(Config{Rules: testRules}, &mockSampler{err: ErrNotSupported}, &mockServices{}, &mockBans{}, mocks.NewEventBus())
+
+	assert.True(t, errors.Is(d.check(), ErrNotSupported))
+}
+
+func TestNewAction(t *testing.T) {
+	action, err := NewAction("Terminate")
+	assert.NoError(t, err)
+	assert.Equal(t, ActionTerminate, action)
+
+	_, err = NewAction("kill")
+	assert.EqualError(t, err, `unknown action "kill", expected "throttle" or "terminate"`)
+}
+
+func startSession(d *Detector, sessionID, consumerID, network string) {
+	_, ipNet, _ := net.ParseCIDR(network)
+	d.consumeSessionNetworkEvent(sevent.AppEventSessionNetwork{ID: sessionID, Network: *ipNet})
+	d.consumeSessionEvent(sevent.AppEventSession{
+		Status:  sevent.CreatedStatus,
+		Service: sevent.ServiceContext{ID: "service"},
+		Session: sevent.SessionContext{ID: sessionID, ConsumerID: identity.FromAddress(consumerID)},
+	})
+}
+
+func conn(source string, sourcePort int, destination string, destinationPort int) Connection {
+	return Connection{
+		Protocol:        "tcp",
+		Source:          net.ParseIP(source),
+		SourcePort:      sourcePort,
+		Destination:     net.ParseIP(destination),
+		DestinationPort: destinationPort,
+	}
+}
+
+type mockSampler struct {
+	conns []Connection
+	err   error
+}
+
+func (m *mockSampler) Connections() ([]Connection, error) {
+	return m.conns, m.err
+}
+
+type mockServices struct {
+	throttleErr error
+	throttled   []string
+	terminated  []string
+}
+
+func (m *mockServices) TerminateSession(_ service.ID, sessionID string) error {
+	m.terminated = append(m.terminated, sessionID)
+	return nil
+}
+
+func (m *mockServices) ThrottleSession(_ service.ID, sessionID string, _ datasize.BitSpeed) error {
+	if m.throttleErr != nil {
+		return m.throttleErr
+	}
+	m.throttled = append(m.throttled, sessionID)
+	return nil
+}
+
+type mockBans struct {
+	banned []string
+}
+
+func (m *mockBans) Ban(consumerID string, _ time.Time) error {
+	m.banned = append(m.banned, consumerID)
+	return nil
+}
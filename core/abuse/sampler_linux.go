@@ -0,0 +1,43 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package abuse
+
+import (
+	"os"
+)
+
+// conntrackSampler lists connections from the netfilter connection tracking table.
+type conntrackSampler struct {
+	path string
+}
+
+// NewSampler returns connection sampler of the host.
+func NewSampler() Sampler {
+	return &conntrackSampler{path: "/proc/net/nf_conntrack"}
+}
+
+// Connections lists connections currently tracked by netfilter.
+func (s *conntrackSampler) Connections() ([]Connection, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseConntrack(f)
+}
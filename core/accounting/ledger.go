@@ -0,0 +1,209 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package accounting
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/asdine/storm/v3"
+	"github.com/asdine/storm/v3/q"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/eventbus"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+)
+
+const bucket = "provider-traffic-accounting"
+
+// Record is traffic of the provided session accounted since the previous record of the same session.
+type Record struct {
+	ID          int    `storm:"id,increment"`
+	SessionID   string `storm:"index"`
+	ConsumerID  string `storm:"index"`
+	ServiceType string
+	At          time.Time `storm:"index"`
+	// BytesSent is traffic sent to the consumer
+	BytesSent uint64
+	// BytesReceived is traffic received from the consumer
+	BytesReceived uint64
+}
+
+// Totals is traffic summed over accounting records.
+type Totals struct {
+	BytesSent     uint64
+	BytesReceived uint64
+}
+
+func (t *Totals) add(r Record) {
+	t.BytesSent += r.BytesSent
+	t.BytesReceived += r.BytesReceived
+}
+
+// Ledger persists traffic of provided sessions incrementally, so that accounting survives node restarts.
+type Ledger struct {
+	storage       *boltdb.Bolt
+	flushInterval time.Duration
+	now           func() time.Time
+
+	lock     sync.Mutex
+	sessions map[string]*account
+}
+
+// account is traffic of the active session, reported totals are flushed to records once per flush interval.
+type account struct {
+	consumerID      string
+	serviceType     string
+	sent            uint64
+	received        uint64
+	flushedSent     uint64
+	flushedReceived uint64
+	flushedAt       time.Time
+}
+
+// NewLedger creates ledger of provided sessions traffic.
+func NewLedger(storage *boltdb.Bolt, flushInterval time.Duration) *Ledger {
+	return &Ledger{
+		storage:       storage,
+		flushInterval: flushInterval,
+		now:           time.Now,
+		sessions:      make(map[string]*account),
+	}
+}
+
+// Subscribe subscribes to session and session traffic events.
+func (l *Ledger) Subscribe(bus eventbus.Subscriber) error {
+	if err := bus.Subscribe(sevent.AppTopicSession, l.consumeSessionEvent); err != nil {
+		return err
+	}
+	return bus.SubscribeAsync(sevent.AppTopicDataTransferred, l.consumeDataTransferredEvent)
+}
+
+// Stop persists traffic of active sessions which is not flushed yet.
+func (l *Ledger) Stop() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for id, acc := range l.sessions {
+		l.flushLocked(id, acc)
+	}
+}
+
+// SessionTotals returns accounted traffic of the session.
+func (l *Ledger) SessionTotals(sessionID string) (Totals, error) {
+	return l.totals(q.Eq("SessionID", sessionID))
+}
+
+// ConsumerTotals returns accounted traffic of all sessions of the consumer since given time.
+func (l *Ledger) ConsumerTotals(consumerID string, since time.Time) (Totals, error) {
+	return l.totals(q.Eq("ConsumerID", consumerID), q.Gte("At", since.UTC()))
+}
+
+// TotalsSince returns accounted traffic of all sessions since given time.
+func (l *Ledger) TotalsSince(since time.Time) (Totals, error) {
+	return l.totals(q.Gte("At", since.UTC()))
+}
+
+// Records returns accounting records of the session ordered by time.
+func (l *Ledger) Records(sessionID string) ([]Record, error) {
+	var records []Record
+	err := l.storage.DB().From(bucket).Select(q.Eq("SessionID", sessionID)).OrderBy("At").Find(&records)
+	if errors.Is(err, storm.ErrNotFound) {
+		return []Record{}, nil
+	}
+	return records, err
+}
+
+func (l *Ledger) totals(matchers ...q.Matcher) (Totals, error) {
+	var totals Totals
+	err := l.storage.DB().From(bucket).Select(matchers...).Each(new(Record), func(record interface{}) error {
+		totals.add(*record.(*Record))
+		return nil
+	})
+	return totals, err
+}
+
+func (l *Ledger) consumeSessionEvent(e sevent.AppEventSession) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	switch e.Status {
+	case sevent.CreatedStatus:
+		l.sessions[e.Session.ID] = &account{
+			consumerID:  e.Session.ConsumerID.Address,
+			serviceType: e.Session.Proposal.ServiceType,
+			flushedAt:   l.now(),
+		}
+	case sevent.RemovedStatus:
+		if acc, ok := l.sessions[e.Session.ID]; ok {
+			l.flushLocked(e.Session.ID, acc)
+			delete(l.sessions, e.Session.ID)
+		}
+	}
+}
+
+func (l *Ledger) consumeDataTransferredEvent(e sevent.AppEventDataTransferred) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	acc, ok := l.sessions[e.ID]
+	if !ok {
+		return
+	}
+	// Traffic is reported from the consumer point of view.
+	acc.sent = e.Down
+	acc.received = e.Up
+	if l.now().Sub(acc.flushedAt) >= l.flushInterval {
+		l.flushLocked(e.ID, acc)
+	}
+}
+
+// flushLocked stores traffic reported since the previous record of the session.
+func (l *Ledger) flushLocked(sessionID string, acc *account) {
+	now := l.now()
+	record := Record{
+		SessionID:   sessionID,
+		ConsumerID:  acc.consumerID,
+		ServiceType: acc.serviceType,
+		At:          now.UTC(),
+	}
+	// Service may restart its counters, then everything reported since is new traffic.
+	if acc.sent >= acc.flushedSent {
+		record.BytesSent = acc.sent - acc.flushedSent
+	} else {
+		record.BytesSent = acc.sent
+	}
+	if acc.received >= acc.flushedReceived {
+		record.BytesReceived = acc.received - acc.flushedReceived
+	} else {
+		record.BytesReceived = acc.received
+	}
+	acc.flushedAt = now
+	if record.BytesSent == 0 && record.BytesReceived == 0 {
+		return
+	}
+
+	if err := l.storage.Store(bucket, &record); err != nil {
+		log.Error().Err(err).Msgf("Could not store traffic accounting record of session %s", sessionID)
+		return
+	}
+	acc.flushedSent = acc.sent
+	acc.flushedReceived = acc.received
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package accounting
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+)
+
+var (
+	consumerID = identity.FromAddress("0x1")
+	started    = time.Date(2020, 10, 16, 12, 0, 0, 0, time.UTC)
+)
+
+func TestLedger_StoresIncrementalRecords(t *testing.T) {
+	l, cleanup := newTestLedger(t)
+	defer cleanup()
+	now := started
+	l.now = func() time.Time { return now }
+
+	l.consumeSessionEvent(sessionEvent(sevent.CreatedStatus, "s1"))
+	l.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "s1", Up: 10, Down: 100})
+
+	now = now.Add(time.Minute)
+	l.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "s1", Up: 20, Down: 300})
+	l.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "s1", Up: 25, Down: 400})
+
+	now = now.Add(30 * time.Second)
+	l.consumeSessionEvent(sessionEvent(sevent.RemovedStatus, "s1"))
+
+	records, err := l.Records("s1")
+	assert.NoError(t, err)
+	assert.Equal(t, []Record{
+		{ID: 1, SessionID: "s1", ConsumerID: consumerID.Address, ServiceType: "wireguard", At: started.Add(time.Minute), BytesSent: 300, BytesReceived: 20},
+		{ID: 2, SessionID: "s1", ConsumerID: consumerID.Address, ServiceType: "wireguard", At: started.Add(90 * time.Second), BytesSent: 100, BytesReceived: 5},
+	}, records)
+
+	totals, err := l.SessionTotals("s1")
+	assert.NoError(t, err)
+	assert.Equal(t, Totals{BytesSent: 400, BytesReceived: 25}, totals)
+}
+
+func TestLedger_SumsTotals(t *testing.T) {
+	l, cleanup := newTestLedger(t)
+	defer cleanup()
+	now := started
+	l.now = func() time.Time { return now }
+
+	l.consumeSessionEvent(sessionEvent(sevent.CreatedStatus, "s1"))
+	l.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "s1", Up: 10, Down: 100})
+	l.consumeSessionEvent(sessionEvent(sevent.RemovedStatus, "s1"))
+
+	now = now.Add(time.Hour)
+	l.consumeSessionEvent(sessionEvent(sevent.CreatedStatus, "s2"))
+	l.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "s2", Up: 1, Down: 2})
+	l.Stop()
+
+	totals, err := l.ConsumerTotals(consumerID.Address, started)
+	assert.NoError(t, err)
+	assert.Equal(t, Totals{BytesSent: 102, BytesReceived: 11}, totals)
+
+	totals, err = l.TotalsSince(started.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, Totals{BytesSent: 2, BytesReceived: 1}, totals)
+
+	totals, err = l.ConsumerTotals("0x2", started)
+	assert.NoError(t, err)
+	assert.Equal(t, Totals{}, totals)
+}
+
+func TestLedger_IgnoresUnknownSessions(t *testing.T) {
+	l, cleanup := newTestLedger(t)
+	defer cleanup()
+
+	l.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "unknown", Up: 10, Down: 100})
+	l.Stop()
+
+	records, err := l.Records("unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func sessionEvent(status sevent.Status, id string) sevent.AppEventSession {
+	return sevent.AppEventSession{
+		Status: status,
+		Session: sevent.SessionContext{
+			ID:         id,
+			ConsumerID: consumerID,
+			Proposal:   market.ServiceProposal{ServiceType: "wireguard"},
+		},
+	}
+}
+
+func newTestLedger(t *testing.T) (*Ledger, func()) {
+	dir, err := ioutil.TempDir("", "ledgerTest")
+	assert.NoError(t, err)
+	db, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+
+	return NewLedger(db, time.Minute), func() {
+		assert.NoError(t, db.Close())
+		assert.NoError(t, os.RemoveAll(dir))
+	}
+}
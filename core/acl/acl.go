@@ -0,0 +1,198 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package acl
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mysteriumnetwork/node/identity"
+)
+
+const aclBucket = "consumer-acl"
+
+// List is a list consumer identity belongs to.
+type List string
+
+const (
+	// ListAllow admits the consumer, once any consumer is allowed all the others are rejected.
+	ListAllow List = "allow"
+	// ListBlock rejects the consumer.
+	ListBlock List = "block"
+)
+
+// NewList parses consumer list name.
+func NewList(s string) (List, error) {
+	switch l := List(strings.ToLower(s)); l {
+	case ListAllow, ListBlock:
+		return l, nil
+	}
+	return "", fmt.Errorf("unknown list %q, expected %q or %q", s, ListAllow, ListBlock)
+}
+
+// Entry puts consumer identity into allow or block list.
+type Entry struct {
+	Identity string `storm:"id"`
+	List     List
+	// Expires is set for temporary entries, e.g. automatic bans, which are ignored once expired.
+	Expires time.Time
+}
+
+// Expired checks if temporary entry is no longer in effect.
+func (e Entry) Expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+// Validate checks if entry is complete.
+func (e Entry) Validate() error {
+	if e.Identity == "" {
+		return errors.New("consumer identity is required")
+	}
+	if _, err := NewList(string(e.List)); err != nil {
+		return err
+	}
+	return nil
+}
+
+type persistentStorage interface {
+	Store(bucket string, data interface{}) error
+	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
+	GetAllFrom(bucket string, data interface{}) error
+	Delete(bucket string, data interface{}) error
+}
+
+var errBoltNotFound = "not found"
+
+// ErrNotFound represents an error where consumer is in neither list.
+var ErrNotFound = errors.New("consumer is not in access control list")
+
+// Storage keeps provider access control lists of consumer identities.
+type Storage struct {
+	lock sync.Mutex
+	bolt persistentStorage
+}
+
+// NewStorage returns a new instance of the access control list storage.
+func NewStorage(bolt persistentStorage) *Storage {
+	return &Storage{
+		bolt: bolt,
+	}
+}
+
+// Save puts consumer into the list, moving it from the other list.
+func (s *Storage) Save(e Entry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	e.Identity = strings.ToLower(e.Identity)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return errors.Wrap(s.bolt.Store(aclBucket, &e), "could not store access control entry")
+}
+
+// Ban temporarily puts consumer into the block list. Consumers in the allow list are never banned,
+// so allow list works as an override list of trusted consumers.
+func (s *Storage) Ban(consumerID string, until time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e := Entry{}
+	err := s.bolt.GetOneByField(aclBucket, "Identity", strings.ToLower(consumerID), &e)
+	if err != nil && err.Error() != errBoltNotFound {
+		return errors.Wrap(err, "could not get access control entry")
+	}
+	if err == nil && e.List == ListAllow {
+		return nil
+	}
+	if err == nil && e.List == ListBlock && e.Expires.IsZero() {
+		return nil
+	}
+
+	e = Entry{Identity: strings.ToLower(consumerID), List: ListBlock, Expires: until}
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	return errors.Wrap(s.bolt.Store(aclBucket, &e), "could not store access control entry")
+}
+
+// List returns all entries of both lists.
+func (s *Storage) List() ([]Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.list()
+}
+
+func (s *Storage) list() ([]Entry, error) {
+	res := []Entry{}
+	err := s.bolt.GetAllFrom(aclBucket, &res)
+	if err != nil && err.Error() != errBoltNotFound {
+		return nil, errors.Wrap(err, "could not get access control entries")
+	}
+	return res, nil
+}
+
+// Delete removes consumer from the list it belongs to.
+func (s *Storage) Delete(consumerID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e := Entry{}
+	err := s.bolt.GetOneByField(aclBucket, "Identity", strings.ToLower(consumerID), &e)
+	if err != nil {
+		if err.Error() == errBoltNotFound {
+			return ErrNotFound
+		}
+		return errors.Wrap(err, "could not get access control entry")
+	}
+	return errors.Wrap(s.bolt.Delete(aclBucket, &e), "could not delete access control entry")
+}
+
+// IsAllowed checks if consumer may create sessions: blocked consumers are rejected,
+// and if allow list is not empty, only consumers in it are admitted.
+func (s *Storage) IsAllowed(consumerID identity.Identity) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entries, err := s.list()
+	if err != nil {
+		return false, err
+	}
+
+	address := strings.ToLower(consumerID.Address)
+	now := time.Now()
+	allowList := false
+	for _, e := range entries {
+		if e.Expired(now) {
+			continue
+		}
+		if e.Identity == address {
+			return e.List == ListAllow, nil
+		}
+		if e.List == ListAllow {
+			allowList = true
+		}
+	}
+	return !allowList, nil
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package acl
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/identity"
+)
+
+func TestStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aclStorageTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	defer bolt.Close()
+	storage := NewStorage(bolt)
+
+	entries, err := storage.List()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	assertAllowed(t, storage, "0x1", true)
+
+	assert.NoError(t, storage.Save(Entry{Identity: "0xAB", List: ListBlock}))
+	assertAllowed(t, storage, "0xab", false)
+	assertAllowed(t, storage, "0x1", true)
+
+	assert.NoError(t, storage.Save(Entry{Identity: "0x2", List: ListAllow}))
+	assertAllowed(t, storage, "0x2", true)
+	assertAllowed(t, storage, "0x1", false)
+
+	assert.NoError(t, storage.Save(Entry{Identity: "0xab", List: ListAllow}))
+	assertAllowed(t, storage, "0xab", true)
+
+	entries, err = storage.List()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Entry{{Identity: "0xab", List: ListAllow}, {Identity: "0x2", List: ListAllow}}, entries)
+
+	assert.NoError(t, storage.Delete("0xAB"))
+	assert.NoError(t, storage.Delete("0x2"))
+	assert.Equal(t, ErrNotFound, storage.Delete("0x2"))
+	assertAllowed(t, storage, "0x1", true)
+}
+
+func TestStorage_Ban(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aclStorageTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	defer bolt.Close()
+	storage := NewStorage(bolt)
+
+	assert.NoError(t, storage.Ban("0xAB", time.Now().Add(time.Hour)))
+	assertAllowed(t, storage, "0xab", false)
+
+	assert.NoError(t, storage.Ban("0xab", time.Now().Add(-time.Second)))
+	assertAllowed(t, storage, "0xab", true)
+
+	assert.NoError(t, storage.Save(Entry{Identity: "0x2", List: ListAllow}))
+	assert.NoError(t, storage.Ban("0x2", time.Now().Add(time.Hour)))
+	assertAllowed(t, storage, "0x2", true)
+
+	assert.NoError(t, storage.Save(Entry{Identity: "0x3", List: ListBlock}))
+	assert.NoError(t, storage.Ban("0x3", time.Now().Add(-time.Second)))
+	assertAllowed(t, storage, "0x3", false)
+}
+
+func TestEntryValidate(t *testing.T) {
+	assert.NoError(t, Entry{Identity: "0x1", List: ListAllow}.Validate())
+	assert.EqualError(t, Entry{List: ListBlock}.Validate(), "consumer identity is required")
+	assert.EqualError(t, Entry{Identity: "0x1", List: "deny"}.Validate(), `unknown list "deny", expected "allow" or "block"`)
+}
+
+func assertAllowed(t *testing.T, storage *Storage, consumerID string, expected bool) {
+	allowed, err := storage.IsAllowed(identity.FromAddress(consumerID))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, allowed, consumerID)
+}
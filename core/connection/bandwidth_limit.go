@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"sync"
+
+	"github.com/mysteriumnetwork/node/core/shaper"
+	"github.com/mysteriumnetwork/node/datasize"
+)
+
+// TunnelInterface is implemented by connections which know name of their tunnel network interface
+type TunnelInterface interface {
+	InterfaceName() string
+}
+
+// bandwidthLimit keeps consumer tunnel bandwidth under configured limit, zero limit means unlimited.
+type bandwidthLimit struct {
+	limiter shaper.Limiter
+
+	lock          sync.Mutex
+	limit         datasize.BitSpeed
+	interfaceName string
+}
+
+func newBandwidthLimit(limiter shaper.Limiter, limit datasize.BitSpeed) *bandwidthLimit {
+	return &bandwidthLimit{limiter: limiter, limit: limit}
+}
+
+// start applies the limit to tunnel interface.
+func (bl *bandwidthLimit) start(interfaceName string) error {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	bl.interfaceName = interfaceName
+	return bl.applyLocked()
+}
+
+// stop removes the limit from tunnel interface.
+func (bl *bandwidthLimit) stop() {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	if bl.interfaceName != "" && bl.limit > 0 {
+		bl.limiter.Clear(bl.interfaceName)
+	}
+	bl.interfaceName = ""
+}
+
+// set changes the limit, it is applied immediately when tunnel is up. Limit which can not be applied is not kept.
+func (bl *bandwidthLimit) set(limit datasize.BitSpeed) error {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	if bl.interfaceName != "" && bl.limit > 0 && limit == 0 {
+		bl.limiter.Clear(bl.interfaceName)
+	}
+	previous := bl.limit
+	bl.limit = limit
+	if err := bl.applyLocked(); err != nil {
+		bl.limit = previous
+		return err
+	}
+	return nil
+}
+
+func (bl *bandwidthLimit) applyLocked() error {
+	if bl.interfaceName == "" || bl.limit == 0 {
+		return nil
+	}
+
+	return bl.limiter.Limit(bl.interfaceName, shaper.Kbps(bl.limit))
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/shaper"
+)
+
+type limiterFake struct {
+	err     error
+	limits  map[string]int
+	cleared []string
+}
+
+func newLimiterFake() *limiterFake {
+	return &limiterFake{limits: make(map[string]int)}
+}
+
+func (l *limiterFake) Limit(interfaceName string, limitKbps int) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.limits[interfaceName] = limitKbps
+	return nil
+}
+
+func (l *limiterFake) Clear(interfaceName string) {
+	delete(l.limits, interfaceName)
+	l.cleared = append(l.cleared, interfaceName)
+}
+
+func TestBandwidthLimit_AppliesConfiguredLimitOnStart(t *testing.T) {
+	limiter := newLimiterFake()
+	limit := newBandwidthLimit(limiter, 10*1000*1000)
+
+	assert.NoError(t, limit.start("wg0"))
+	assert.Equal(t, map[string]int{"wg0": 10000}, limiter.limits)
+
+	limit.stop()
+	assert.Empty(t, limiter.limits)
+	assert.Equal(t, []string{"wg0"}, limiter.cleared)
+}
+
+func TestBandwidthLimit_UnlimitedByDefault(t *testing.T) {
+	limiter := newLimiterFake()
+	limit := newBandwidthLimit(limiter, 0)
+
+	assert.NoError(t, limit.start("wg0"))
+	limit.stop()
+	assert.Empty(t, limiter.limits)
+	assert.Empty(t, limiter.cleared)
+}
+
+func TestBandwidthLimit_ChangesLimitAtRuntime(t *testing.T) {
+	limiter := newLimiterFake()
+	limit := newBandwidthLimit(limiter, 0)
+
+	// Limit is kept until tunnel is up.
+	assert.NoError(t, limit.set(512*1000))
+	assert.Empty(t, limiter.limits)
+
+	assert.NoError(t, limit.start("wg0"))
+	assert.Equal(t, map[string]int{"wg0": 512}, limiter.limits)
+
+	assert.NoError(t, limit.set(2*1000*1000))
+	assert.Equal(t, map[string]int{"wg0": 2000}, limiter.limits)
+
+	assert.NoError(t, limit.set(0))
+	assert.Empty(t, limiter.limits)
+}
+
+func TestBandwidthLimit_FailsWhenLimitingIsUnsupported(t *testing.T) {
+	limiter := newLimiterFake()
+	limiter.err = shaper.ErrUnsupported
+	limit := newBandwidthLimit(limiter, 0)
+
+	assert.NoError(t, limit.start("wg0"))
+	assert.Equal(t, shaper.ErrUnsupported, limit.set(512*1000))
+	assert.NoError(t, limit.set(0))
+
+	limit.stop()
+	assert.Empty(t, limiter.cleared)
+}
@@ -18,6 +18,7 @@
 package connection
 
 import (
+	"math/big"
 	"net"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -31,8 +32,34 @@ import (
 type ConnectParams struct {
 	// kill switch option restricting communication only through VPN
 	DisableKillSwitch bool
+	// KillSwitch configures scope of the kill switch
+	KillSwitch KillSwitchOptions
 	// DNS servers to use
 	DNS DNSOption
+	// FallbackProposals are tried in order when connection to the primary proposal fails
+	FallbackProposals []market.ServiceProposal
+	// EntryProposal makes connection multi-hop, traffic enters through the entry provider and exits through the target provider
+	EntryProposal *market.ServiceProposal
+	// EntryInterface is the tunnel interface of the entry hop, tunnel traffic to the provider is routed through it
+	EntryInterface string
+	// SplitTunnel selects traffic bypassing or exclusively using the tunnel
+	SplitTunnel SplitTunnel
+	// DisconnectOnDNSLeak disconnects when DNS queries are found to bypass the tunnel
+	DisconnectOnDNSLeak bool
+	// DataCap disconnects when connection transfers more data than allowed
+	DataCap DataCap
+	// SpendCap disconnects once consumer pays provider at least the given amount during the session
+	SpendCap *big.Int
+	// Retry overrides retry policy of the connection manager
+	Retry *RetryPolicy
+	// ProbeCandidates overrides count of candidates probed before connecting, values below 2 disable probing
+	ProbeCandidates int
+	// KeepDefaultRoute leaves default route untouched, only traffic bound to the tunnel interface goes through the tunnel
+	KeepDefaultRoute bool
+	// PolicyRouting routes only marked traffic through the tunnel, using a dedicated routing table
+	PolicyRouting PolicyRouting
+	// MTU of the tunnel interface, zero value probes path to the provider and lowers default MTU when it does not fit
+	MTU int
 }
 
 // ConnectOptions represents the params we need to ensure a successful connection
@@ -46,4 +73,6 @@ type ConnectOptions struct {
 	ProviderNATConn *net.UDPConn
 	ChannelConn     *net.UDPConn
 	HermesID        common.Address
+	// Relayed is set when ProviderNATConn goes through p2p relay server instead of directly to the provider.
+	Relayed bool
 }
@@ -35,6 +35,12 @@ const (
 	AppTopicConnectionStatistics = "Statistics"
 	// AppTopicConnectionSession represents the session lifetime changes
 	AppTopicConnectionSession = "Session"
+	// AppTopicConnectionHealth represents the tunnel health topic
+	AppTopicConnectionHealth = "ConnectionHealth"
+	// AppTopicDataCap represents the data cap usage warnings topic
+	AppTopicDataCap = "DataCap"
+	// AppTopicDNSLeak represents the topic of DNS queries found bypassing the tunnel
+	AppTopicDNSLeak = "DNSLeak"
 )
 
 // AppEventConnectionState is the struct we'll emit on a AppEventConnectionState topic event
@@ -49,6 +55,8 @@ type State string
 const (
 	// NotConnected means no connection exists
 	NotConnected = State("NotConnected")
+	// Probing means that candidate providers are probed before connecting to the fastest one
+	Probing = State("Probing")
 	// Connecting means that connection is startCalled but not yet fully established
 	Connecting = State("Connecting")
 	// Connected means that fully established connection exists
@@ -76,6 +84,96 @@ type Status struct {
 	State            State
 	SessionID        session.ID
 	Proposal         market.ServiceProposal
+	// FailoverFrom is the provider which was substituted by the current one after it failed
+	FailoverFrom identity.Identity
+	// EntryProposal is the entry hop of multi-hop connection
+	EntryProposal *market.ServiceProposal
+	// DNSLeakDetected is set when DNS queries were found to bypass the tunnel
+	DNSLeakDetected bool
+	// DisconnectReason explains why the connection was closed, it is kept until the next connection
+	DisconnectReason DisconnectReason
+	// InterfaceName is the tunnel network interface of the connection, if known
+	InterfaceName string
+	// Paused is set while consumer traffic is routed around the tunnel of established connection
+	Paused bool
+	// MTU of the tunnel interface, if set explicitly or lowered after probing path to the provider
+	MTU int
+	// Probes holds results of probing candidate providers before connecting
+	Probes []ProbeResult
+	// Phase is the latest phase of establishing the connection it has reached
+	Phase Phase
+	// Phases lists phases the connection went through, in order
+	Phases []PhaseTiming
+	// Relayed is set when traffic to the provider goes through p2p relay server since direct NAT traversal failed
+	Relayed bool
+}
+
+// Phase represents a step of establishing the connection
+type Phase string
+
+const (
+	// PhaseResolvingProposal means that provider's proposal is being validated and its contacts resolved
+	PhaseResolvingProposal = Phase("ResolvingProposal")
+	// PhaseDialingP2P means that p2p channel with the provider is being established
+	PhaseDialingP2P = Phase("DialingP2P")
+	// PhaseNegotiatingSession means that session and its payments are being negotiated with the provider
+	PhaseNegotiatingSession = Phase("NegotiatingSession")
+	// PhaseConfiguringTunnel means that tunnel to the provider is being brought up
+	PhaseConfiguringTunnel = Phase("ConfiguringTunnel")
+	// PhaseConnected means that connection is established
+	PhaseConnected = Phase("Connected")
+)
+
+// PhaseTiming holds the time connection entered the phase at
+type PhaseTiming struct {
+	Phase     Phase
+	StartedAt time.Time
+}
+
+// ProbeResult holds outcome of probing a candidate provider before connecting
+type ProbeResult struct {
+	ProviderID string
+	// Latency is round trip time of the probe echo through p2p channel
+	Latency time.Duration
+	// Error is set when provider did not respond to the probe
+	Error string
+}
+
+// DisconnectReason explains why the connection was closed
+type DisconnectReason string
+
+const (
+	// DisconnectReasonUserRequested means that connection was closed on consumer's request
+	DisconnectReasonUserRequested = DisconnectReason("UserRequested")
+	// DisconnectReasonReconnect means that connection was closed to be established again, e.g. after host network changed
+	DisconnectReasonReconnect = DisconnectReason("Reconnect")
+	// DisconnectReasonPaymentFailed means that connection was closed after consumer failed to pay the provider
+	DisconnectReasonPaymentFailed = DisconnectReason("PaymentFailed")
+	// DisconnectReasonProviderGone means that connection was closed after provider stopped answering keep alive pings
+	DisconnectReasonProviderGone = DisconnectReason("ProviderGone")
+	// DisconnectReasonProviderShutdown means that connection was closed after provider announced it is stopping the service
+	DisconnectReasonProviderShutdown = DisconnectReason("ProviderShutdown")
+	// DisconnectReasonKillSwitch means that connection was lost and kill switch keeps non tunnel traffic blocked until the node is stopped
+	DisconnectReasonKillSwitch = DisconnectReason("KillSwitch")
+	// DisconnectReasonDNSLeak means that connection was closed after DNS leak was detected
+	DisconnectReasonDNSLeak = DisconnectReason("DNSLeakDetected")
+	// DisconnectReasonDataCap means that connection was closed after data cap was reached
+	DisconnectReasonDataCap = DisconnectReason("DataCapReached")
+	// DisconnectReasonIdle means that connection was closed after no traffic flowed through the tunnel for idle timeout
+	DisconnectReasonIdle = DisconnectReason("Idle")
+	// DisconnectReasonConnectionLost means that connection was closed after the tunnel went down unexpectedly
+	DisconnectReasonConnectionLost = DisconnectReason("ConnectionLost")
+	// DisconnectReasonSpendCap means that connection was closed after spend cap was reached
+	DisconnectReasonSpendCap = DisconnectReason("SpendCapReached")
+)
+
+// Unexpected checks if connection was lost rather than closed on purpose
+func (r DisconnectReason) Unexpected() bool {
+	switch r {
+	case DisconnectReasonConnectionLost, DisconnectReasonProviderGone, DisconnectReasonProviderShutdown, DisconnectReasonKillSwitch:
+		return true
+	}
+	return false
 }
 
 // Duration returns elapsed time from marked session start
@@ -99,8 +197,42 @@ type AppEventConnectionSession struct {
 	SessionInfo Status
 }
 
+// Health holds tunnel health measured by pinging the provider through the tunnel
+type Health struct {
+	// RTT is average round trip time of successful pings
+	RTT time.Duration
+	// PacketLoss is ratio of lost pings, from 0 to 1
+	PacketLoss float64
+	// Samples is count of pings the measurement is based on
+	Samples int
+}
+
+// AppEventConnectionHealth represents a tunnel health event
+type AppEventConnectionHealth struct {
+	Health      Health
+	SessionInfo Status
+}
+
 // AppEventConnectionStatistics represents a session statistics event
 type AppEventConnectionStatistics struct {
 	Stats       Statistics
 	SessionInfo Status
 }
+
+// AppEventDataCap is emitted when connection data usage crosses a warning threshold of the data cap
+type AppEventDataCap struct {
+	// Used is count of bytes counted towards the data cap
+	Used uint64
+	// Limit is the data cap in bytes
+	Limit uint64
+	// Percent is the crossed warning threshold
+	Percent     int
+	SessionInfo Status
+}
+
+// AppEventDNSLeak is emitted when DNS queries of established connection are found bypassing the tunnel
+type AppEventDNSLeak struct {
+	// Resolvers are addresses of DNS resolvers which queried the canary domain
+	Resolvers   []string
+	SessionInfo Status
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+)
+
+// DataCapPeriod defines which traffic counts towards the data cap
+type DataCapPeriod string
+
+const (
+	// DataCapPerConnection counts traffic of the current connection only
+	DataCapPerConnection = DataCapPeriod("connection")
+	// DataCapPerDay counts traffic of all connections started since local midnight
+	DataCapPerDay = DataCapPeriod("day")
+)
+
+// ErrDataCapReached indicates that daily data cap is already used up
+var ErrDataCapReached = errors.New("data cap reached")
+
+// dataCapWarnPercents are usage thresholds at which consumer is warned before the connection is closed
+var dataCapWarnPercents = []int{80, 95}
+
+// DataCap limits amount of data transferred through the tunnel, zero value disables it
+type DataCap struct {
+	// Bytes counts both sent and received data
+	Bytes  uint64
+	Period DataCapPeriod
+}
+
+// Validate checks if data cap is well formed
+func (dc DataCap) Validate() error {
+	switch dc.Period {
+	case "", DataCapPerConnection, DataCapPerDay:
+		return nil
+	}
+	return fmt.Errorf("unknown data cap period %q", dc.Period)
+}
+
+// DataUsageProvider returns data already consumed by the consumer, used to count daily data cap
+type DataUsageProvider interface {
+	DataConsumedSince(since time.Time) (uint64, error)
+}
+
+// dataCapTracker counts data usage of a connection against the data cap.
+type dataCapTracker struct {
+	dataCap    DataCap
+	timeGetter TimeGetter
+
+	// day, baseline and offset are used for daily data cap only
+	day      time.Time
+	baseline uint64
+	offset   uint64
+	warned   int
+}
+
+func (m *connectionManager) newDataCapTracker(dataCap DataCap) (*dataCapTracker, error) {
+	if err := dataCap.Validate(); err != nil {
+		return nil, err
+	}
+	if dataCap.Bytes == 0 {
+		return nil, nil
+	}
+
+	tracker := &dataCapTracker{dataCap: dataCap, timeGetter: m.timeGetter}
+	if dataCap.Period != DataCapPerDay {
+		return tracker, nil
+	}
+
+	tracker.day = startOfDay(m.timeGetter())
+	if m.dataUsage != nil {
+		consumed, err := m.dataUsage.DataConsumedSince(tracker.day)
+		if err != nil {
+			return nil, fmt.Errorf("could not get daily data usage: %w", err)
+		}
+		tracker.baseline = consumed
+	}
+	if tracker.baseline >= dataCap.Bytes {
+		return nil, ErrDataCapReached
+	}
+	return tracker, nil
+}
+
+// update counts connection statistics, returns used data, crossed warning threshold (if any) and whether the cap is reached.
+func (t *dataCapTracker) update(stats connectionstate.Statistics) (used uint64, warnPercent int, reached bool) {
+	used = stats.BytesSent + stats.BytesReceived
+	if t.dataCap.Period == DataCapPerDay {
+		if today := startOfDay(t.timeGetter()); today.After(t.day) {
+			t.day = today
+			t.baseline = 0
+			t.offset = used
+			t.warned = 0
+		}
+		used = t.baseline + used - t.offset
+	}
+
+	for _, percent := range dataCapWarnPercents {
+		if percent > t.warned && used*100 >= t.dataCap.Bytes*uint64(percent) {
+			warnPercent = percent
+		}
+	}
+	if warnPercent > 0 {
+		t.warned = warnPercent
+	}
+	return used, warnPercent, used >= t.dataCap.Bytes
+}
+
+// enforceDataCap warns consumer when connection approaches data cap and disconnects once it is reached.
+func (m *connectionManager) enforceDataCap(ctx context.Context, statsSupplier statsSupplier, tracker *dataCapTracker) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.statsReportInterval):
+		}
+
+		stats, err := statsSupplier.Statistics()
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not get connection statistics for data cap")
+			continue
+		}
+
+		used, warnPercent, reached := tracker.update(stats)
+		if warnPercent > 0 {
+			log.Warn().Msgf("Data cap usage reached %d%%: %d of %d bytes", warnPercent, used, tracker.dataCap.Bytes)
+			m.eventBus.Publish(connectionstate.AppTopicDataCap, connectionstate.AppEventDataCap{
+				Used:        used,
+				Limit:       tracker.dataCap.Bytes,
+				Percent:     warnPercent,
+				SessionInfo: m.Status(),
+			})
+		}
+		if reached {
+			log.Warn().Msg("Disconnecting due to reached data cap")
+			logDisconnectError(m.disconnectWithReason(connectionstate.DisconnectReasonDataCap))
+			return
+		}
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+)
+
+func TestDataCap_Validate(t *testing.T) {
+	assert.NoError(t, DataCap{}.Validate())
+	assert.NoError(t, DataCap{Bytes: 100, Period: DataCapPerDay}.Validate())
+	assert.Error(t, DataCap{Bytes: 100, Period: "week"}.Validate())
+}
+
+func TestDataCapTracker_WarnsOnceAtEachThreshold(t *testing.T) {
+	tracker := &dataCapTracker{dataCap: DataCap{Bytes: 100}, timeGetter: time.Now}
+
+	used, warnPercent, reached := tracker.update(connectionstate.Statistics{BytesSent: 10, BytesReceived: 60})
+	assert.Equal(t, uint64(70), used)
+	assert.Equal(t, 0, warnPercent)
+	assert.False(t, reached)
+
+	_, warnPercent, _ = tracker.update(connectionstate.Statistics{BytesSent: 10, BytesReceived: 70})
+	assert.Equal(t, 80, warnPercent)
+	_, warnPercent, _ = tracker.update(connectionstate.Statistics{BytesSent: 10, BytesReceived: 75})
+	assert.Equal(t, 0, warnPercent)
+	_, warnPercent, reached = tracker.update(connectionstate.Statistics{BytesSent: 10, BytesReceived: 85})
+	assert.Equal(t, 95, warnPercent)
+	assert.False(t, reached)
+
+	_, _, reached = tracker.update(connectionstate.Statistics{BytesSent: 10, BytesReceived: 90})
+	assert.True(t, reached)
+}
+
+func TestDataCapTracker_DailyCapCountsEarlierUsageAndResetsAtMidnight(t *testing.T) {
+	now := time.Date(2020, time.June, 1, 23, 0, 0, 0, time.UTC)
+	m := &connectionManager{
+		dataUsage:  &mockDataUsage{consumed: 50},
+		timeGetter: func() time.Time { return now },
+	}
+
+	tracker, err := m.newDataCapTracker(DataCap{Bytes: 100, Period: DataCapPerDay})
+	assert.NoError(t, err)
+
+	used, _, reached := tracker.update(connectionstate.Statistics{BytesReceived: 40})
+	assert.Equal(t, uint64(90), used)
+	assert.False(t, reached)
+
+	now = now.Add(2 * time.Hour)
+	used, _, reached = tracker.update(connectionstate.Statistics{BytesReceived: 45})
+	assert.Equal(t, uint64(0), used)
+	assert.False(t, reached)
+
+	used, _, _ = tracker.update(connectionstate.Statistics{BytesReceived: 60})
+	assert.Equal(t, uint64(15), used)
+}
+
+func TestDataCapTracker_DisabledWithoutLimit(t *testing.T) {
+	m := &connectionManager{timeGetter: time.Now}
+
+	tracker, err := m.newDataCapTracker(DataCap{Period: DataCapPerDay})
+	assert.NoError(t, err)
+	assert.Nil(t, tracker)
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+)
+
+// TunnelPeer is implemented by connections which know provider's address inside the tunnel
+type TunnelPeer interface {
+	TunnelPeerIP() net.IP
+}
+
+// HealthConfig contains tunnel health monitoring options.
+type HealthConfig struct {
+	// PingInterval zero value disables health monitoring
+	PingInterval time.Duration
+	PingTimeout  time.Duration
+	// WindowSize is count of latest pings health is calculated from
+	WindowSize int
+}
+
+// tunnelPingFunc pings provider through the tunnel, returns round trip time
+type tunnelPingFunc func(ctx context.Context, peerIP net.IP) (time.Duration, error)
+
+// dnsPing queries DNS server which provider runs inside the tunnel.
+// Any answer proves that the tunnel carries traffic both ways, no privileges are needed unlike ICMP.
+func dnsPing(ctx context.Context, peerIP net.IP) (time.Duration, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(".", dns.TypeNS)
+
+	client := dns.Client{Net: "udp"}
+	_, rtt, err := client.ExchangeContext(ctx, msg, net.JoinHostPort(peerIP.String(), strconv.Itoa(53)))
+	return rtt, err
+}
+
+type healthSample struct {
+	rtt  time.Duration
+	lost bool
+}
+
+// healthWindow keeps latest ping samples.
+type healthWindow struct {
+	size    int
+	samples []healthSample
+}
+
+func newHealthWindow(size int) *healthWindow {
+	if size < 1 {
+		size = 1
+	}
+	return &healthWindow{size: size}
+}
+
+func (w *healthWindow) add(rtt time.Duration, lost bool) {
+	w.samples = append(w.samples, healthSample{rtt: rtt, lost: lost})
+	if len(w.samples) > w.size {
+		w.samples = w.samples[len(w.samples)-w.size:]
+	}
+}
+
+func (w *healthWindow) health() connectionstate.Health {
+	var lost int
+	var rttSum time.Duration
+	for _, sample := range w.samples {
+		if sample.lost {
+			lost++
+			continue
+		}
+		rttSum += sample.rtt
+	}
+
+	health := connectionstate.Health{Samples: len(w.samples)}
+	if len(w.samples) > 0 {
+		health.PacketLoss = float64(lost) / float64(len(w.samples))
+	}
+	if received := len(w.samples) - lost; received > 0 {
+		health.RTT = rttSum / time.Duration(received)
+	}
+	return health
+}
+
+// monitorHealth pings provider through the tunnel until connection context is done and publishes tunnel health.
+func (m *connectionManager) monitorHealth(ctx context.Context, peerIP net.IP) {
+	window := newHealthWindow(m.config.Health.WindowSize)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.config.Health.PingInterval):
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, m.config.Health.PingTimeout)
+		rtt, err := m.tunnelPing(pingCtx, peerIP)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Debug().Err(err).Msgf("Tunnel ping to %s failed", peerIP)
+		}
+
+		window.add(rtt, err != nil)
+		m.eventBus.Publish(connectionstate.AppTopicConnectionHealth, connectionstate.AppEventConnectionHealth{
+			Health:      window.health(),
+			SessionInfo: m.Status(),
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthWindow_CalculatesLossAndAverageRTT(t *testing.T) {
+	window := newHealthWindow(3)
+	assert.Equal(t, connectionstate.Health{}, window.health())
+
+	window.add(10*time.Millisecond, false)
+	window.add(0, true)
+	window.add(30*time.Millisecond, false)
+	assert.Equal(t, connectionstate.Health{RTT: 20 * time.Millisecond, PacketLoss: 1.0 / 3, Samples: 3}, window.health())
+
+	// oldest sample is dropped
+	window.add(50*time.Millisecond, false)
+	assert.Equal(t, connectionstate.Health{RTT: 40 * time.Millisecond, PacketLoss: 1.0 / 3, Samples: 3}, window.health())
+}
+
+func TestHealthWindow_AllLost(t *testing.T) {
+	window := newHealthWindow(2)
+	window.add(0, true)
+	window.add(0, true)
+	assert.Equal(t, connectionstate.Health{PacketLoss: 1, Samples: 2}, window.health())
+}
+
+func TestMonitorHealth_PublishesHealthUntilContextIsDone(t *testing.T) {
+	bus := mocks.NewEventBus()
+	pings := 0
+	m := &connectionManager{
+		eventBus: bus,
+		config: Config{Health: HealthConfig{
+			PingInterval: time.Millisecond,
+			PingTimeout:  time.Second,
+			WindowSize:   10,
+		}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.tunnelPing = func(_ context.Context, peerIP net.IP) (time.Duration, error) {
+		assert.Equal(t, "10.182.0.1", peerIP.String())
+		pings++
+		if pings == 2 {
+			cancel()
+		}
+		if pings%2 == 0 {
+			return 0, errors.New("timeout")
+		}
+		return 5 * time.Millisecond, nil
+	}
+
+	m.monitorHealth(ctx, net.ParseIP("10.182.0.1"))
+
+	history := bus.GetEventHistory()
+	assert.Len(t, history, 1)
+	assert.Equal(t, connectionstate.AppTopicConnectionHealth, history[0].Topic)
+	evt := history[0].Event.(connectionstate.AppEventConnectionHealth)
+	assert.Equal(t, connectionstate.Health{RTT: 5 * time.Millisecond, Samples: 1}, evt.Health)
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+)
+
+const (
+	// idleWindow is the period traffic rate is measured over.
+	idleWindow = time.Minute
+	// idleTrafficRate is bytes per second considered as no traffic,
+	// tunnel keep alives and health pings flow even when consumer does not use the tunnel.
+	idleTrafficRate = 100
+)
+
+// idleTracker detects periods without consumer traffic in the tunnel.
+type idleTracker struct {
+	timeout     time.Duration
+	lastActive  time.Time
+	windowStart time.Time
+	windowBytes uint64
+}
+
+func newIdleTracker(timeout time.Duration, now time.Time) *idleTracker {
+	return &idleTracker{timeout: timeout, lastActive: now, windowStart: now}
+}
+
+// update counts connection statistics, returns true once there was no traffic for idle timeout.
+func (t *idleTracker) update(stats connectionstate.Statistics, now time.Time) bool {
+	total := stats.BytesSent + stats.BytesReceived
+	if elapsed := now.Sub(t.windowStart); elapsed >= idleWindow {
+		if total-t.windowBytes > uint64(elapsed.Seconds()*idleTrafficRate) {
+			t.lastActive = now
+		}
+		t.windowStart = now
+		t.windowBytes = total
+	}
+	return now.Sub(t.lastActive) >= t.timeout
+}
+
+// disconnectWhenIdle disconnects once there is no traffic in the tunnel for configured idle timeout.
+func (m *connectionManager) disconnectWhenIdle(ctx context.Context, statsSupplier statsSupplier) {
+	tracker := newIdleTracker(m.config.IdleTimeout, m.timeGetter())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.statsReportInterval):
+		}
+
+		// Paused connection carries no consumer traffic on purpose.
+		if m.Status().Paused {
+			tracker.lastActive = m.timeGetter()
+			continue
+		}
+
+		stats, err := statsSupplier.Statistics()
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not get connection statistics for idle check")
+			continue
+		}
+
+		if tracker.update(stats, m.timeGetter()) {
+			log.Info().Msgf("Disconnecting after %s without traffic", m.config.IdleTimeout)
+			logDisconnectError(m.disconnectWithReason(connectionstate.DisconnectReasonIdle))
+			return
+		}
+	}
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+)
+
+func TestIdleTracker_DetectsIdleTunnel(t *testing.T) {
+	start := time.Date(2020, time.June, 1, 10, 0, 0, 0, time.UTC)
+	tracker := newIdleTracker(3*time.Minute, start)
+
+	// Background traffic, like keep alives, does not count as activity.
+	assert.False(t, tracker.update(connectionstate.Statistics{BytesSent: 1000}, start.Add(time.Minute)))
+	assert.False(t, tracker.update(connectionstate.Statistics{BytesSent: 2000}, start.Add(2*time.Minute)))
+	assert.True(t, tracker.update(connectionstate.Statistics{BytesSent: 3000}, start.Add(3*time.Minute)))
+}
+
+func TestIdleTracker_TrafficResetsTimer(t *testing.T) {
+	start := time.Date(2020, time.June, 1, 10, 0, 0, 0, time.UTC)
+	tracker := newIdleTracker(3*time.Minute, start)
+
+	assert.False(t, tracker.update(connectionstate.Statistics{}, start.Add(2*time.Minute)))
+	assert.False(t, tracker.update(connectionstate.Statistics{BytesReceived: 1024 * 1024}, start.Add(3*time.Minute)))
+	// Not a full measurement window yet.
+	assert.False(t, tracker.update(connectionstate.Statistics{BytesReceived: 1024 * 1024}, start.Add(3*time.Minute+30*time.Second)))
+	assert.False(t, tracker.update(connectionstate.Statistics{BytesReceived: 1024 * 1024}, start.Add(5*time.Minute)))
+	assert.True(t, tracker.update(connectionstate.Statistics{BytesReceived: 1024 * 1024}, start.Add(6*time.Minute)))
+}
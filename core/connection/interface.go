@@ -22,6 +22,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 )
@@ -54,4 +55,14 @@ type Manager interface {
 	CheckChannel(context.Context) error
 	// Reconnect reconnects current session
 	Reconnect()
+	// Rebind moves p2p channel of current session to the network host is attached to, keeping the session
+	Rebind(context.Context) error
+	// UpdateSplitTunnel replaces split tunnel rules of established connection
+	UpdateSplitTunnel(splitTunnel SplitTunnel) error
+	// SetMaxBandwidth changes consumer tunnel speed limit, zero value removes the limit
+	SetMaxBandwidth(limit datasize.BitSpeed) error
+	// Pause routes consumer traffic around the tunnel and lifts the kill switch, keeping the session alive
+	Pause() error
+	// Resume routes consumer traffic back into the tunnel of the paused connection
+	Resume() error
 }
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/p2p"
+	"github.com/mysteriumnetwork/node/pb"
+	"github.com/mysteriumnetwork/node/session"
+)
+
+// KeyRotator is implemented by connections able to rotate tunnel keys without tearing down the session
+type KeyRotator interface {
+	// RotateKeys generates new tunnel keys, exchanges consumer config for the new provider config with renegotiate and applies them.
+	RotateKeys(renegotiate func(config ConsumerConfig) ([]byte, error)) error
+}
+
+// KeyRotationConfig contains tunnel key rotation options.
+type KeyRotationConfig struct {
+	// Interval zero value disables key rotation
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// rotateKeys renegotiates tunnel keys with the provider periodically until connection context is done.
+// Failed rotation keeps the current keys, so the session and its payments go on.
+func (m *connectionManager) rotateKeys(ctx context.Context, rotator KeyRotator, channel p2p.ChannelSender, consumerID identity.Identity, sessionID session.ID) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.config.KeyRotation.Interval):
+		}
+
+		err := rotator.RotateKeys(func(config ConsumerConfig) ([]byte, error) {
+			return m.renegotiateSession(ctx, channel, consumerID, sessionID, config)
+		})
+		if err != nil {
+			log.Warn().Err(err).Msgf("Could not rotate tunnel keys. SessionID=%s", sessionID)
+			continue
+		}
+		log.Info().Msgf("Rotated tunnel keys. SessionID=%s", sessionID)
+	}
+}
+
+// renegotiateSession sends new consumer config to the provider and returns the new provider config.
+func (m *connectionManager) renegotiateSession(ctx context.Context, channel p2p.ChannelSender, consumerID identity.Identity, sessionID session.ID, config ConsumerConfig) ([]byte, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal session config: %w", err)
+	}
+
+	msg := &pb.SessionRenegotiation{
+		ConsumerID: consumerID.Address,
+		SessionID:  string(sessionID),
+		Config:     data,
+	}
+	log.Debug().Msgf("Sending P2P message to %q: %s", p2p.TopicSessionRenegotiate, sessionID)
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.KeyRotation.Timeout)
+	defer cancel()
+	res, err := channel.Send(ctx, p2p.TopicSessionRenegotiate, p2p.ProtoMessage(msg))
+	if err != nil {
+		return nil, fmt.Errorf("could not send session renegotiation: %w", err)
+	}
+
+	var reply pb.SessionRenegotiation
+	if err := res.UnmarshalProto(&reply); err != nil {
+		return nil, fmt.Errorf("could not unmarshal session renegotiation reply: %w", err)
+	}
+	return reply.GetConfig(), nil
+}
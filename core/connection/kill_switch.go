@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// KillSwitchMode defines when non tunnel traffic is blocked
+type KillSwitchMode string
+
+const (
+	// KillSwitchModeOnDisconnect blocks non tunnel traffic while connection is established or being restored,
+	// block is lifted once consumer disconnects
+	KillSwitchModeOnDisconnect = KillSwitchMode("on-disconnect")
+	// KillSwitchModeAlways keeps non tunnel traffic blocked after disconnect, until the node is stopped.
+	// Exceptions of the connection, i.e. LAN and allowed ports, are lifted on disconnect.
+	KillSwitchModeAlways = KillSwitchMode("always")
+)
+
+// ErrKillSwitchPortsUnsupported indicates that kill switch can not allow traffic by destination port on this platform
+var ErrKillSwitchPortsUnsupported = errors.New("kill switch allowed ports are supported on Linux only")
+
+// lanNetworks are private, link local and multicast networks allowed through the kill switch for LAN access
+var lanNetworks = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"169.254.0.0/16",
+	"224.0.0.0/4",
+}
+
+// KillSwitchOptions configures scope of the kill switch
+type KillSwitchOptions struct {
+	// Mode defaults to KillSwitchModeOnDisconnect
+	Mode KillSwitchMode
+	// AllowLAN allows traffic to local networks
+	AllowLAN bool
+	// AllowedPorts are destination ports allowed outside of the tunnel, e.g. 22 for SSH, supported on Linux only
+	AllowedPorts []int
+}
+
+// Validate validates kill switch options
+func (o KillSwitchOptions) Validate() error {
+	switch o.Mode {
+	case "", KillSwitchModeOnDisconnect, KillSwitchModeAlways:
+	default:
+		return fmt.Errorf("unknown kill switch mode %q", o.Mode)
+	}
+
+	if len(o.AllowedPorts) > 0 && runtime.GOOS != "linux" {
+		return ErrKillSwitchPortsUnsupported
+	}
+	for _, port := range o.AllowedPorts {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid kill switch allowed port %d", port)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKillSwitchOptions_Validate(t *testing.T) {
+	assert.NoError(t, KillSwitchOptions{}.Validate())
+	assert.NoError(t, KillSwitchOptions{Mode: KillSwitchModeAlways, AllowLAN: true}.Validate())
+	assert.Error(t, KillSwitchOptions{Mode: "sometimes"}.Validate())
+
+	if runtime.GOOS != "linux" {
+		assert.Equal(t, ErrKillSwitchPortsUnsupported, KillSwitchOptions{AllowedPorts: []int{22}}.Validate())
+		return
+	}
+	assert.NoError(t, KillSwitchOptions{Mode: KillSwitchModeAlways, AllowLAN: true, AllowedPorts: []int{22}}.Validate())
+	assert.Error(t, KillSwitchOptions{AllowedPorts: []int{0}}.Validate())
+	assert.Error(t, KillSwitchOptions{AllowedPorts: []int{65536}}.Validate())
+}
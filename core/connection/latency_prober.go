@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/p2p"
+)
+
+// ErrNoUnlockedIdentity is returned when there is no unlocked identity to probe providers with.
+var ErrNoUnlockedIdentity = errors.New("no unlocked identity to probe providers with")
+
+type consumerIdentities interface {
+	GetIdentities() []identity.Identity
+	IsUnlocked(address string) bool
+}
+
+// LatencyProber measures round trip time to providers outside of connecting,
+// with the same probe which is sent to candidate providers before connecting.
+type LatencyProber struct {
+	dialer     p2p.Dialer
+	identities consumerIdentities
+	timeout    time.Duration
+}
+
+// NewLatencyProber creates prober dialing providers on behalf of the first unlocked identity.
+func NewLatencyProber(dialer p2p.Dialer, identities consumerIdentities, timeout time.Duration) *LatencyProber {
+	return &LatencyProber{
+		dialer:     dialer,
+		identities: identities,
+		timeout:    timeout,
+	}
+}
+
+// Probe measures round trip time to the provider of the proposal.
+func (p *LatencyProber) Probe(proposal market.ServiceProposal) (time.Duration, error) {
+	consumerID, ok := p.consumerID()
+	if !ok {
+		return 0, ErrNoUnlockedIdentity
+	}
+
+	ctx, cancel := withTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	return probeLatency(ctx, p.dialer, consumerID, proposal)
+}
+
+func (p *LatencyProber) consumerID() (identity.Identity, bool) {
+	for _, id := range p.identities.GetIdentities() {
+		if p.identities.IsUnlocked(id.Address) {
+			return id, true
+		}
+	}
+	return identity.Identity{}, false
+}
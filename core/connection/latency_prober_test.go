@@ -0,0 +1,56 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyProber_Probe(t *testing.T) {
+	dialer := &probeP2PDialer{
+		ch:      &mockP2PChannel{},
+		latency: map[string]time.Duration{activeProviderID.Address: 10 * time.Millisecond},
+	}
+	prober := NewLatencyProber(dialer, identity.NewIdentityManagerFake([]identity.Identity{consumerID}, consumerID), time.Second)
+
+	latency, err := prober.Probe(activeProposal)
+	assert.NoError(t, err)
+	assert.True(t, latency >= 10*time.Millisecond)
+}
+
+func TestLatencyProber_ProbeFailsWhenProviderIsUnreachable(t *testing.T) {
+	dialer := &probeP2PDialer{
+		ch:          &mockP2PChannel{},
+		unreachable: activeProviderID.Address,
+	}
+	prober := NewLatencyProber(dialer, identity.NewIdentityManagerFake([]identity.Identity{consumerID}, consumerID), time.Second)
+
+	_, err := prober.Probe(activeProposal)
+	assert.EqualError(t, err, "provider unreachable")
+}
+
+func TestLatencyProber_ProbeRequiresUnlockedIdentity(t *testing.T) {
+	prober := NewLatencyProber(&probeP2PDialer{ch: &mockP2PChannel{}}, identity.NewIdentityManagerFake(nil, consumerID), time.Second)
+
+	_, err := prober.Probe(activeProposal)
+	assert.Equal(t, ErrNoUnlockedIdentity, err)
+}
@@ -20,18 +20,22 @@ package connection
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 
 	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/core/dnsleak"
 	"github.com/mysteriumnetwork/node/core/location"
 
 	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/core/shaper"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
@@ -40,13 +44,10 @@ import (
 	"github.com/mysteriumnetwork/node/pb"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/connectivity"
+	pingpongEvent "github.com/mysteriumnetwork/node/session/pingpong/event"
 	"github.com/mysteriumnetwork/node/trace"
 )
 
-const (
-	p2pDialTimeout = 60 * time.Second
-)
-
 var (
 	// ErrNoConnection error indicates that action applied to manager expects active connection (i.e. disconnect)
 	ErrNoConnection = errors.New("no connection exists")
@@ -81,6 +82,18 @@ type KeepAliveConfig struct {
 type Config struct {
 	IPCheck   IPCheckConfig
 	KeepAlive KeepAliveConfig
+	Timeouts  TimeoutConfig
+	Health    HealthConfig
+	// KeyRotation describes periodic rotation of tunnel keys of the established session
+	KeyRotation KeyRotationConfig
+	// MaxBandwidth limits consumer tunnel speed, zero value means unlimited
+	MaxBandwidth datasize.BitSpeed
+	// IdleTimeout disconnects when no traffic flows through the tunnel for given time, zero value disables it
+	IdleTimeout time.Duration
+	// Retry describes how failed connect attempts are retried, connect params may override it
+	Retry RetryPolicy
+	// Probe describes probing of candidate providers before connecting, connect params may override count of candidates
+	Probe ProbeConfig
 }
 
 // DefaultConfig returns default params.
@@ -95,6 +108,18 @@ func DefaultConfig() Config {
 			SendTimeout:     5 * time.Second,
 			MaxSendErrCount: 5,
 		},
+		Timeouts: DefaultTimeoutConfig(),
+		Health: HealthConfig{
+			PingInterval: 10 * time.Second,
+			PingTimeout:  3 * time.Second,
+			WindowSize:   30,
+		},
+		Probe: ProbeConfig{
+			Timeout: 10 * time.Second,
+		},
+		KeyRotation: KeyRotationConfig{
+			Timeout: 10 * time.Second,
+		},
 	}
 }
 
@@ -129,11 +154,20 @@ type connectionManager struct {
 	statsReportInterval  time.Duration
 	validator            validator
 	p2pDialer            p2p.Dialer
+	dnsLeakDetector      dnsleak.Detector
+	dataUsage            DataUsageProvider
+	tunnelPing           tunnelPingFunc
 	timeGetter           TimeGetter
+	splitTunnel          *splitTunnelRoutes
+	bandwidthLimit       *bandwidthLimit
+	spendCap             spendCap
+	// invoicePaidHandler is subscribed to payment events, kept to unsubscribe the very same func value
+	invoicePaidHandler func(pingpongEvent.AppEventInvoicePaid)
 
 	// These are populated by Connect at runtime.
 	ctx                    context.Context
 	ctxLock                sync.RWMutex
+	generation             uint64
 	status                 connectionstate.Status
 	statusLock             sync.RWMutex
 	cleanupLock            sync.Mutex
@@ -146,7 +180,18 @@ type connectionManager struct {
 	channel                p2p.Channel
 
 	discoLock      sync.Mutex
+	failoverLock   sync.Mutex
+	retryLock      sync.Mutex
+	retryCancel    func()
 	connectOptions ConnectOptions
+
+	// probes are results of probing candidates before the current connect, guarded by status lock
+	probes []connectionstate.ProbeResult
+	// routeTaker is the established connection able to take over the default route, guarded by status lock
+	routeTaker DefaultRouteTaker
+	// pauseRules lift the kill switch while connection is paused
+	pauseLock  sync.Mutex
+	pauseRules []firewall.OutgoingRuleRemove
 }
 
 // NewManager creates connection manager with given dependencies
@@ -160,8 +205,10 @@ func NewManager(
 	statsReportInterval time.Duration,
 	validator validator,
 	p2pDialer p2p.Dialer,
+	dnsLeakDetector dnsleak.Detector,
+	dataUsage DataUsageProvider,
 ) *connectionManager {
-	return &connectionManager{
+	m := &connectionManager{
 		newConnection:        connectionCreator,
 		status:               connectionstate.Status{State: connectionstate.NotConnected},
 		eventBus:             eventBus,
@@ -174,11 +221,138 @@ func NewManager(
 		statsReportInterval:  statsReportInterval,
 		validator:            validator,
 		p2pDialer:            p2pDialer,
+		dnsLeakDetector:      dnsLeakDetector,
+		dataUsage:            dataUsage,
+		tunnelPing:           dnsPing,
 		timeGetter:           time.Now,
+		splitTunnel:          newSplitTunnelRoutes(net.LookupIP, excludeNetwork, includeNetwork),
+		bandwidthLimit:       newBandwidthLimit(shaper.NewLimiter(), config.MaxBandwidth),
+	}
+	m.invoicePaidHandler = m.consumeInvoicePaidEvent
+	return m
+}
+
+func (m *connectionManager) Connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams) error {
+	if err := params.SplitTunnel.Validate(); err != nil {
+		return err
+	}
+	if err := params.KillSwitch.Validate(); err != nil {
+		return err
+	}
+	if params.Retry != nil {
+		if err := params.Retry.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := params.PolicyRouting.Validate(); err != nil {
+		return err
+	}
+	if err := validateMTU(params.MTU); err != nil {
+		return err
+	}
+	if params.SplitTunnel.Exclusive() {
+		// Only matching traffic is routed through the tunnel, the rest of host traffic must not be blocked.
+		params.DisableKillSwitch = true
+		params.KeepDefaultRoute = true
+	}
+	if params.PolicyRouting.Enabled() {
+		// Only marked traffic goes through the tunnel, the rest of host traffic must be neither blocked nor resolved through it.
+		params.DisableKillSwitch = true
+		params.DNS = DNSOptionSystem
+		params.KeepDefaultRoute = true
 	}
+
+	proposal, params, err := m.probeCandidates(consumerID, hermesID, proposal, params)
+	if err != nil {
+		return err
+	}
+
+	return m.connectWithFailover(consumerID, hermesID, proposal, params, identity.Identity{})
 }
 
-func (m *connectionManager) Connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams) (err error) {
+// connectWithFailover connects to given proposal, falling back to the next fallback proposal on failure.
+func (m *connectionManager) connectWithFailover(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams, failoverFrom identity.Identity) error {
+	err := m.connectWithRetry(consumerID, hermesID, proposal, params, failoverFrom)
+	for err != nil && len(params.FallbackProposals) > 0 && isFailoverAllowed(err) {
+		failoverFrom = identity.FromAddress(proposal.ProviderID)
+		proposal, params = nextFallback(params)
+
+		log.Warn().Err(err).Msgf("Connection to provider %s failed, failing over to provider %s", failoverFrom.Address, proposal.ProviderID)
+		err = m.connectWithRetry(consumerID, hermesID, proposal, params, failoverFrom)
+	}
+	return err
+}
+
+// failoverOrDisconnect disconnects from a failed provider and connects to the next fallback proposal if there is one.
+// Connection which was already cancelled by disconnect is not failed over. Failure is handled only once for the connection
+// generation it was observed in, so that failures reported late or by several watchers do not tear down the next connection.
+func (m *connectionManager) failoverOrDisconnect(generation uint64, reason connectionstate.DisconnectReason) {
+	m.failoverLock.Lock()
+	if !m.claimGeneration(generation) {
+		m.failoverLock.Unlock()
+		log.Debug().Msgf("Connection failure %q is already handled, ignoring", reason)
+		return
+	}
+	if m.currentCtx().Err() != nil {
+		m.failoverLock.Unlock()
+		logDisconnectError(m.disconnectWithReason(reason))
+		return
+	}
+	options := m.connectOptions
+	if len(options.Params.FallbackProposals) == 0 {
+		if !options.Params.DisableKillSwitch && options.Params.KillSwitch.Mode == KillSwitchModeAlways {
+			reason = connectionstate.DisconnectReasonKillSwitch
+		}
+		logDisconnectError(m.disconnectWithReason(reason))
+		m.failoverLock.Unlock()
+		return
+	}
+
+	liftBlock := m.holdTrafficBlock(options.Params)
+	defer liftBlock()
+	logDisconnectError(m.disconnectWithReason(reason))
+	m.failoverLock.Unlock()
+
+	proposal, params := nextFallback(options.Params)
+	log.Warn().Msgf("Connection to provider %s lost, failing over to provider %s", options.ProviderID.Address, proposal.ProviderID)
+	err := m.connectWithFailover(options.ConsumerID, options.HermesID, proposal, params, options.ProviderID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fail over to fallback provider")
+	}
+}
+
+// claimGeneration checks if failure observed in the given connection generation is not handled yet
+// and moves to the next generation, so that the failure is not handled again.
+func (m *connectionManager) claimGeneration(generation uint64) bool {
+	m.ctxLock.Lock()
+	defer m.ctxLock.Unlock()
+
+	if m.generation != generation {
+		return false
+	}
+	m.generation++
+	return true
+}
+
+func nextFallback(params ConnectParams) (market.ServiceProposal, ConnectParams) {
+	next := params.FallbackProposals[0]
+	params.FallbackProposals = params.FallbackProposals[1:]
+	return next, params
+}
+
+func isFailoverAllowed(err error) bool {
+	switch {
+	case errors.Is(err, ErrAlreadyExists),
+		errors.Is(err, ErrConnectionCancelled),
+		errors.Is(err, ErrUnlockRequired),
+		errors.Is(err, ErrDataCapReached),
+		errors.Is(err, context.Canceled):
+		return false
+	}
+	return true
+}
+
+func (m *connectionManager) connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams, failoverFrom identity.Identity) (err error) {
 	var sessionID session.ID
 
 	tracer := trace.NewTracer("Consumer whole Connect")
@@ -194,10 +368,15 @@ func (m *connectionManager) Connect(consumerID identity.Identity, hermesID commo
 		return nil
 	})
 
-	if m.Status().State != connectionstate.NotConnected {
+	if state := m.Status().State; state != connectionstate.NotConnected && state != connectionstate.Probing {
 		return ErrAlreadyExists
 	}
 
+	dataCap, err := m.newDataCapTracker(params.DataCap)
+	if err != nil {
+		return err
+	}
+
 	err = m.validator.Validate(consumerID, proposal)
 	if err != nil {
 		return err
@@ -205,9 +384,10 @@ func (m *connectionManager) Connect(consumerID identity.Identity, hermesID commo
 
 	m.ctxLock.Lock()
 	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.generation++
 	m.ctxLock.Unlock()
 
-	m.statusConnecting(consumerID, hermesID, proposal)
+	m.statusConnecting(consumerID, hermesID, proposal, failoverFrom)
 	defer func() {
 		if err != nil {
 			log.Err(err).Msg("Connect failed, disconnecting")
@@ -215,13 +395,19 @@ func (m *connectionManager) Connect(consumerID identity.Identity, hermesID commo
 		}
 	}()
 
+	// Connect timeout limits all connect stages, established connection lives on with manager's context.
+	connectCtx, cancelConnect := withTimeout(m.currentCtx(), m.config.Timeouts.Connect)
+	defer cancelConnect()
+
 	providerID := identity.FromAddress(proposal.ProviderID)
 
-	err = m.createP2PChannel(m.currentCtx(), consumerID, providerID, proposal, tracer)
+	m.statusPhase(connectionstate.PhaseDialingP2P)
+	err = m.createP2PChannel(connectCtx, consumerID, providerID, proposal, tracer)
 	if err != nil {
 		return fmt.Errorf("could not create p2p channel during connect: %w", err)
 	}
 
+	m.statusPhase(connectionstate.PhaseNegotiatingSession)
 	connection, err := m.newConnection(proposal.ServiceType)
 	if err != nil {
 		return err
@@ -232,7 +418,7 @@ func (m *connectionManager) Connect(consumerID identity.Identity, hermesID commo
 		return err
 	}
 
-	sessionDTO, err := m.createP2PSession(m.currentCtx(), connection, m.channel, consumerID, hermesID, proposal, tracer)
+	sessionDTO, err := m.createP2PSession(connectCtx, connection, m.channel, consumerID, hermesID, proposal, tracer)
 	sessionID = session.ID(sessionDTO.GetID())
 	if err != nil {
 		m.sendSessionStatus(m.channel, consumerID, sessionID, connectivity.StatusSessionEstablishmentFailed, err)
@@ -240,12 +426,20 @@ func (m *connectionManager) Connect(consumerID identity.Identity, hermesID commo
 	}
 
 	traceStart := tracer.StartStage("Consumer session creation (start)")
-	go m.keepAliveLoop(m.channel, sessionID)
+	go m.keepAliveLoop(m.channel, sessionID, m.currentGeneration())
+	m.handleProviderShutdown(m.channel, sessionID, m.currentGeneration())
 	m.setStatus(func(status *connectionstate.Status) {
 		status.SessionID = sessionID
 	})
 	m.publishSessionCreate(sessionID)
 	paymentSession.SetSessionID(string(sessionID))
+	if params.SpendCap != nil {
+		m.spendCap.start(string(sessionID), params.SpendCap)
+		m.addCleanup(func() error {
+			m.spendCap.stop()
+			return nil
+		})
+	}
 	tracer.EndStage(traceStart)
 
 	// Try to establish connection with peer.
@@ -259,8 +453,10 @@ func (m *connectionManager) Connect(consumerID identity.Identity, hermesID commo
 		ProviderNATConn: m.channel.ServiceConn(),
 		ChannelConn:     m.channel.Conn(),
 		HermesID:        hermesID,
+		Relayed:         m.channel.Relayed(),
 	}
-	err = m.startConnection(m.currentCtx(), connection, m.connectOptions, tracer)
+	m.statusPhase(connectionstate.PhaseConfiguringTunnel)
+	err = m.startConnection(connectCtx, connection, m.connectOptions, tracer)
 	if err != nil {
 		if err == context.Canceled {
 			return ErrConnectionCancelled
@@ -275,6 +471,13 @@ func (m *connectionManager) Connect(consumerID identity.Identity, hermesID commo
 		return err
 	}
 
+	if dataCap != nil {
+		go m.enforceDataCap(m.currentCtx(), connection, dataCap)
+	}
+	if m.config.IdleTimeout > 0 {
+		go m.disconnectWhenIdle(m.currentCtx(), connection)
+	}
+
 	return nil
 }
 
@@ -310,6 +513,40 @@ func (m *connectionManager) checkSessionIP(channel p2p.Channel, consumerID ident
 	}
 }
 
+// checkDNSLeak verifies that DNS queries egress through the tunnel, not directly from consumer's network.
+func (m *connectionManager) checkDNSLeak(ctx context.Context, disconnectOnLeak bool) {
+	if m.dnsLeakDetector == nil {
+		return
+	}
+
+	resolvers, err := m.dnsLeakDetector.DetectResolvers(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not check DNS leak")
+		return
+	}
+
+	// Skip check if not connected. This may happen when context was canceled via Disconnect.
+	status := m.Status()
+	if status.State != connectionstate.Connected || !dnsleak.IsLeaked(resolvers, status.ConsumerLocation) {
+		return
+	}
+
+	log.Warn().Msgf("DNS leak detected, queries are resolved by %v", resolvers)
+	m.setStatus(func(status *connectionstate.Status) {
+		status.DNSLeakDetected = true
+	})
+	event := connectionstate.AppEventDNSLeak{SessionInfo: m.Status()}
+	for _, resolver := range resolvers {
+		event.Resolvers = append(event.Resolvers, resolver.IP)
+	}
+	m.eventBus.Publish(connectionstate.AppTopicDNSLeak, event)
+
+	if disconnectOnLeak {
+		log.Warn().Msg("Disconnecting due to DNS leak")
+		logDisconnectError(m.disconnectWithReason(connectionstate.DisconnectReasonDNSLeak))
+	}
+}
+
 // sendSessionStatus sends session connectivity status to other peer.
 func (m *connectionManager) sendSessionStatus(channel p2p.ChannelSender, consumerID identity.Identity, sessionID session.ID, code connectivity.StatusCode, errDetails error) error {
 	var errDetailsMsg string
@@ -361,7 +598,7 @@ func (m *connectionManager) paymentLoop(channel p2p.Channel, consumerID, provide
 		err := payments.Start()
 		if err != nil {
 			log.Error().Err(err).Msg("Payment error")
-			err = m.Disconnect()
+			err = m.disconnectWithReason(connectionstate.DisconnectReasonPaymentFailed)
 			if err != nil {
 				log.Error().Err(err).Msg("Could not disconnect gracefully")
 			}
@@ -407,13 +644,13 @@ func (m *connectionManager) createP2PChannel(ctx context.Context, consumerID, pr
 		return fmt.Errorf("provider does not support p2p communication: %w", err)
 	}
 
-	timeoutCtx, cancel := context.WithTimeout(ctx, p2pDialTimeout)
+	timeoutCtx, cancel := withTimeout(ctx, m.config.Timeouts.P2PDial)
 	defer cancel()
 
 	// TODO register all handlers before channel read/write loops
-	channel, err := m.p2pDialer.Dial(timeoutCtx, consumerID, providerID, proposal.ServiceType, contactDef, tracer)
+	channel, err := m.p2pDialer.Dial(timeoutCtx, consumerID, providerID, proposal.ServiceKey(), contactDef, tracer)
 	if err != nil {
-		return fmt.Errorf("p2p dialer failed: %w", err)
+		return fmt.Errorf("p2p dialer failed: %w", stageError(timeoutCtx, StageP2PDial, err))
 	}
 	m.addCleanupAfterDisconnect(func() error {
 		log.Trace().Msg("Cleaning: closing P2P communication channel")
@@ -423,6 +660,12 @@ func (m *connectionManager) createP2PChannel(ctx context.Context, consumerID, pr
 	})
 
 	m.channel = channel
+	if channel.Relayed() {
+		log.Warn().Msgf("Could not reach provider %s directly, connection is relayed", providerID.Address)
+		m.setStatus(func(status *connectionstate.Status) {
+			status.Relayed = true
+		})
+	}
 	return nil
 }
 
@@ -465,11 +708,11 @@ func (m *connectionManager) createP2PSession(ctx context.Context, c Connection,
 		Config:     config,
 	}
 	log.Debug().Msgf("Sending P2P message to %q: %s", p2p.TopicSessionCreate, sessionRequest.String())
-	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	ctx, cancel := withTimeout(ctx, m.config.Timeouts.SessionCreate)
 	defer cancel()
 	res, err := p2pChannel.Send(ctx, p2p.TopicSessionCreate, p2p.ProtoMessage(sessionRequest))
 	if err != nil {
-		return nil, fmt.Errorf("could not send p2p session create request: %w", err)
+		return nil, fmt.Errorf("could not send p2p session create request: %w", stageError(ctx, StageSessionCreate, err))
 	}
 
 	var sessionResponse pb.SessionResponse
@@ -538,8 +781,11 @@ func (m *connectionManager) startConnection(ctx context.Context, conn Connection
 
 	originalPublicIP := m.getPublicIP()
 
-	if err = conn.Start(ctx, connectOptions); err != nil {
-		return err
+	tunnelCtx, cancel := withTimeout(ctx, m.config.Timeouts.TunnelUp)
+	defer cancel()
+
+	if err = conn.Start(tunnelCtx, connectOptions); err != nil {
+		return stageError(tunnelCtx, StageTunnelUp, err)
 	}
 	m.addCleanup(func() error {
 		log.Trace().Msg("Cleaning: stopping connection")
@@ -548,16 +794,80 @@ func (m *connectionManager) startConnection(ctx context.Context, conn Connection
 		return nil
 	})
 
-	err = m.setupTrafficBlock(connectOptions.Params.DisableKillSwitch)
+	if entryInterface := connectOptions.Params.EntryInterface; entryInterface != "" {
+		if err = m.routeThroughEntry(conn, entryInterface); err != nil {
+			return err
+		}
+	}
+
+	err = m.setupTrafficBlock(connectOptions.Params)
 	if err != nil {
 		return err
 	}
 
-	err = m.waitForConnectedState(conn.State())
+	err = m.waitForConnectedState(tunnelCtx, conn.State())
 	if err != nil {
+		return stageError(tunnelCtx, StageTunnelUp, err)
+	}
+
+	m.addCleanup(func() error {
+		log.Trace().Msg("Cleaning: removing split tunnel routes")
+		defer log.Trace().Msg("Cleaning: removing split tunnel routes DONE")
+		m.splitTunnel.clear()
+		return nil
+	})
+	var tunnelInterface string
+	if tunnel, ok := conn.(TunnelInterface); ok {
+		tunnelInterface = tunnel.InterfaceName()
+	}
+	if err = m.splitTunnel.apply(connectOptions.Params.SplitTunnel, tunnelInterface); err != nil {
 		return err
 	}
 
+	if policy := connectOptions.Params.PolicyRouting; policy.Enabled() {
+		tunnel, ok := conn.(TunnelInterface)
+		if !ok {
+			return errors.New("connection does not support policy routing")
+		}
+		if err = addPolicyRoute(tunnel.InterfaceName(), policy.FwMark, policy.table()); err != nil {
+			return err
+		}
+		m.addCleanup(func() error {
+			log.Trace().Msg("Cleaning: removing policy routing rule")
+			defer log.Trace().Msg("Cleaning: removing policy routing rule DONE")
+			return deletePolicyRoute(policy.FwMark, policy.table())
+		})
+	}
+
+	if taker, ok := conn.(DefaultRouteTaker); ok {
+		m.setRouteTaker(taker)
+		m.addCleanup(func() error {
+			m.setRouteTaker(nil)
+			return nil
+		})
+	}
+
+	if tunnel, ok := conn.(TunnelInterface); ok {
+		m.setStatus(func(status *connectionstate.Status) {
+			status.InterfaceName = tunnel.InterfaceName()
+		})
+		m.addCleanup(func() error {
+			log.Trace().Msg("Cleaning: removing bandwidth limit")
+			defer log.Trace().Msg("Cleaning: removing bandwidth limit DONE")
+			m.bandwidthLimit.stop()
+			return nil
+		})
+		if err := m.bandwidthLimit.start(tunnel.InterfaceName()); err != nil {
+			log.Error().Err(err).Msg("Could not limit tunnel bandwidth")
+		}
+
+		if mtu := connectOptions.Params.MTU; mtu != 0 {
+			m.setTunnelMTU(tunnel.InterfaceName(), mtu)
+		} else if endpoint, ok := conn.(ProviderEndpoint); ok && endpoint.ProviderIP() != nil {
+			go m.adjustMTU(m.currentCtx(), tunnel.InterfaceName(), endpoint.ProviderIP())
+		}
+	}
+
 	statsPublisher := newStatsPublisher(m.eventBus, m.statsReportInterval)
 	go statsPublisher.start(m, conn)
 	m.addCleanup(func() error {
@@ -567,14 +877,89 @@ func (m *connectionManager) startConnection(ctx context.Context, conn Connection
 		return nil
 	})
 
-	go m.consumeConnectionStates(conn.State())
-	go m.connectionWaiter(conn)
+	if peer, ok := conn.(TunnelPeer); ok && peer.TunnelPeerIP() != nil && m.config.Health.PingInterval > 0 {
+		go m.monitorHealth(m.currentCtx(), peer.TunnelPeerIP())
+	}
+
+	if rotator, ok := conn.(KeyRotator); ok && m.config.KeyRotation.Interval > 0 {
+		if m.channel.Capabilities().Has(p2p.CapabilitySessionRenegotiate) {
+			go m.rotateKeys(m.currentCtx(), rotator, m.channel, connectOptions.ConsumerID, connectOptions.SessionID)
+		} else {
+			log.Info().Msgf("Provider does not support tunnel key rotation, keys are kept for the whole session. SessionID=%s", connectOptions.SessionID)
+		}
+	}
+
+	go m.consumeConnectionStates(conn.State(), m.currentGeneration())
+	go m.connectionWaiter(conn, m.currentGeneration())
 
 	// Clear IP cache so session IP check can report that IP has really changed.
 	m.clearIPCache()
 
-	go m.checkSessionIP(m.channel, connectOptions.ConsumerID, connectOptions.SessionID, originalPublicIP)
+	// Host traffic does not go through the tunnel which keeps default route intact, so there is nothing to check.
+	if !connectOptions.Params.KeepDefaultRoute {
+		go m.checkSessionIP(m.channel, connectOptions.ConsumerID, connectOptions.SessionID, originalPublicIP)
+		go m.checkDNSLeak(m.currentCtx(), connectOptions.Params.DisconnectOnDNSLeak)
+	}
+
+	return nil
+}
+
+// UpdateSplitTunnel replaces split tunnel rules of the established connection.
+func (m *connectionManager) UpdateSplitTunnel(splitTunnel SplitTunnel) error {
+	if err := splitTunnel.Validate(); err != nil {
+		return err
+	}
+	status := m.Status()
+	if status.State != connectionstate.Connected {
+		return ErrNoConnection
+	}
+	// Exclusive mode keeps default route outside of the tunnel, which is decided when connecting.
+	if splitTunnel.Exclusive() != m.connectOptions.Params.SplitTunnel.Exclusive() {
+		return ErrSplitTunnelModeChange
+	}
 
+	if err := m.splitTunnel.apply(splitTunnel, status.InterfaceName); err != nil {
+		return err
+	}
+	// Keep rules for reconnects and fail overs.
+	m.connectOptions.Params.SplitTunnel = splitTunnel
+	return nil
+}
+
+// SetMaxBandwidth changes consumer tunnel speed limit, zero value removes the limit.
+// New limit is applied immediately to established connection and kept for next connections.
+func (m *connectionManager) SetMaxBandwidth(limit datasize.BitSpeed) error {
+	return m.bandwidthLimit.set(limit)
+}
+
+func (m *connectionManager) setRouteTaker(taker DefaultRouteTaker) {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+
+	m.routeTaker = taker
+}
+
+func (m *connectionManager) canTakeDefaultRoute() bool {
+	m.statusLock.RLock()
+	defer m.statusLock.RUnlock()
+
+	return m.routeTaker != nil
+}
+
+// takeDefaultRoute routes all consumer traffic through the connection established with default route kept intact.
+func (m *connectionManager) takeDefaultRoute() error {
+	m.statusLock.RLock()
+	taker := m.routeTaker
+	m.statusLock.RUnlock()
+
+	if taker == nil {
+		return ErrSwitchNotSupported
+	}
+	if err := taker.TakeDefaultRoute(); err != nil {
+		return err
+	}
+	// Keep default route on reconnects and fail overs.
+	m.connectOptions.Params.KeepDefaultRoute = false
 	return nil
 }
 
@@ -600,22 +985,48 @@ func (m *connectionManager) setStatus(delta func(status *connectionstate.Status)
 	}
 }
 
-func (m *connectionManager) statusConnecting(consumerID identity.Identity, accountantID common.Address, proposal market.ServiceProposal) {
+func (m *connectionManager) statusConnecting(consumerID identity.Identity, accountantID common.Address, proposal market.ServiceProposal, failoverFrom identity.Identity) {
 	m.setStatus(func(status *connectionstate.Status) {
+		startedAt := m.timeGetter()
 		*status = connectionstate.Status{
-			StartedAt:        m.timeGetter(),
+			StartedAt:        startedAt,
 			ConsumerID:       consumerID,
 			ConsumerLocation: m.locationResolver.GetOrigin(),
 			HermesID:         accountantID,
 			Proposal:         proposal,
 			State:            connectionstate.Connecting,
+			FailoverFrom:     failoverFrom,
+			Probes:           m.probes,
+			Phase:            connectionstate.PhaseResolvingProposal,
+			Phases: []connectionstate.PhaseTiming{
+				{Phase: connectionstate.PhaseResolvingProposal, StartedAt: startedAt},
+			},
 		}
 	})
 }
 
+func (m *connectionManager) statusPhase(phase connectionstate.Phase) {
+	m.setStatus(func(status *connectionstate.Status) {
+		m.enterPhase(status, phase)
+	})
+}
+
+// enterPhase records the phase connection has reached, once. Phases are copied, since returned statuses share them.
+func (m *connectionManager) enterPhase(status *connectionstate.Status, phase connectionstate.Phase) {
+	if status.Phase == phase {
+		return
+	}
+
+	phases := make([]connectionstate.PhaseTiming, len(status.Phases), len(status.Phases)+1)
+	copy(phases, status.Phases)
+	status.Phase = phase
+	status.Phases = append(phases, connectionstate.PhaseTiming{Phase: phase, StartedAt: m.timeGetter()})
+}
+
 func (m *connectionManager) statusConnected() {
 	m.setStatus(func(status *connectionstate.Status) {
 		status.State = connectionstate.Connected
+		m.enterPhase(status, connectionstate.PhaseConnected)
 	})
 }
 
@@ -648,11 +1059,27 @@ func (m *connectionManager) Cancel() {
 	logDisconnectError(m.Disconnect())
 }
 
+// Disconnect closes the connection on consumer's request.
 func (m *connectionManager) Disconnect() error {
+	return m.disconnectWithReason(connectionstate.DisconnectReasonUserRequested)
+}
+
+// disconnectWithReason closes the connection, reason is kept in status and ended session event.
+// Connection which is already being closed keeps the reason it is closed for.
+func (m *connectionManager) disconnectWithReason(reason connectionstate.DisconnectReason) error {
+	if m.cancelRetry() {
+		return nil
+	}
+
 	if m.Status().State == connectionstate.NotConnected {
 		return ErrNoConnection
 	}
 
+	m.setStatus(func(status *connectionstate.Status) {
+		if status.DisconnectReason == "" {
+			status.DisconnectReason = reason
+		}
+	})
 	m.statusDisconnecting()
 	m.disconnect()
 
@@ -666,6 +1093,18 @@ func (m *connectionManager) CheckChannel(ctx context.Context) error {
 	return nil
 }
 
+// Rebind moves p2p channel to the network host is attached to. Tunnel follows on its own,
+// since its socket listens on all interfaces and provider learns its new address from tunnel traffic.
+func (m *connectionManager) Rebind(ctx context.Context) error {
+	if m.Status().State != connectionstate.Connected {
+		return ErrNoConnection
+	}
+	if err := m.channel.Rebind(ctx); err != nil {
+		return fmt.Errorf("could not rebind p2p channel: %w", err)
+	}
+	return nil
+}
+
 func (m *connectionManager) disconnect() {
 	m.discoLock.Lock()
 	defer m.discoLock.Unlock()
@@ -685,7 +1124,7 @@ func (m *connectionManager) disconnect() {
 	m.cleanAfterDisconnect()
 }
 
-func (m *connectionManager) connectionWaiter(connection Connection) {
+func (m *connectionManager) connectionWaiter(connection Connection, generation uint64) {
 	err := connection.Wait()
 	if err != nil {
 		log.Warn().Err(err).Msg("Connection exited with error")
@@ -693,10 +1132,10 @@ func (m *connectionManager) connectionWaiter(connection Connection) {
 		log.Info().Msg("Connection exited")
 	}
 
-	logDisconnectError(m.Disconnect())
+	m.failoverOrDisconnect(generation, connectionstate.DisconnectReasonConnectionLost)
 }
 
-func (m *connectionManager) waitForConnectedState(stateChannel <-chan connectionstate.State) error {
+func (m *connectionManager) waitForConnectedState(ctx context.Context, stateChannel <-chan connectionstate.State) error {
 	log.Debug().Msg("waiting for connected state")
 	for {
 		select {
@@ -716,19 +1155,19 @@ func (m *connectionManager) waitForConnectedState(stateChannel <-chan connection
 			default:
 				m.onStateChanged(state)
 			}
-		case <-m.currentCtx().Done():
-			return m.currentCtx().Err()
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
 
-func (m *connectionManager) consumeConnectionStates(stateChannel <-chan connectionstate.State) {
+func (m *connectionManager) consumeConnectionStates(stateChannel <-chan connectionstate.State, generation uint64) {
 	for state := range stateChannel {
 		m.onStateChanged(state)
 	}
 
 	log.Debug().Msg("State updater stopCalled")
-	logDisconnectError(m.Disconnect())
+	m.failoverOrDisconnect(generation, connectionstate.DisconnectReasonConnectionLost)
 }
 
 func (m *connectionManager) onStateChanged(state connectionstate.State) {
@@ -743,8 +1182,29 @@ func (m *connectionManager) onStateChanged(state connectionstate.State) {
 	}
 }
 
-func (m *connectionManager) setupTrafficBlock(disableKillSwitch bool) error {
-	if disableKillSwitch {
+// holdTrafficBlock keeps non tunnel traffic blocked while connection fails over to the fallback provider,
+// returned function lifts it once the fallback connection has set up its own block or failover has failed.
+func (m *connectionManager) holdTrafficBlock(params ConnectParams) func() {
+	// Block which outlives the session stays in place on its own.
+	if params.DisableKillSwitch || params.KillSwitch.Mode == KillSwitchModeAlways {
+		return func() {}
+	}
+
+	outboundIP, err := m.ipResolver.GetOutboundIP()
+	if err != nil {
+		log.Error().Err(err).Msg("Could not keep traffic blocked during failover")
+		return func() {}
+	}
+	removeRule, err := firewall.BlockNonTunnelTraffic(firewall.Session, outboundIP)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not keep traffic blocked during failover")
+		return func() {}
+	}
+	return func() { removeRule() }
+}
+
+func (m *connectionManager) setupTrafficBlock(params ConnectParams) error {
+	if params.DisableKillSwitch {
 		return nil
 	}
 
@@ -753,16 +1213,52 @@ func (m *connectionManager) setupTrafficBlock(disableKillSwitch bool) error {
 		return err
 	}
 
-	removeRule, err := firewall.BlockNonTunnelTraffic(firewall.Session, outboundIP)
+	// Traffic block which outlives the session is not removed on disconnect, its exceptions are.
+	scope := firewall.Session
+	if params.KillSwitch.Mode == KillSwitchModeAlways {
+		scope = firewall.Global
+	}
+
+	removeRule, err := firewall.BlockNonTunnelTraffic(scope, outboundIP)
 	if err != nil {
 		return err
 	}
+	if scope == firewall.Session {
+		m.addCleanup(func() error {
+			log.Trace().Msg("Cleaning: traffic block rule")
+			defer log.Trace().Msg("Cleaning: traffic block rule DONE")
+			removeRule()
+			return nil
+		})
+	}
+
+	var allowRules []firewall.OutgoingRuleRemove
 	m.addCleanup(func() error {
-		log.Trace().Msg("Cleaning: traffic block rule")
-		defer log.Trace().Msg("Cleaning: traffic block rule DONE")
-		removeRule()
+		log.Trace().Msg("Cleaning: traffic block exceptions")
+		defer log.Trace().Msg("Cleaning: traffic block exceptions DONE")
+		for _, removeRule := range allowRules {
+			removeRule()
+		}
 		return nil
 	})
+	if params.KillSwitch.AllowLAN {
+		for _, network := range lanNetworks {
+			removeRule, err := firewall.AllowIPAccess(network)
+			if err != nil {
+				return err
+			}
+			allowRules = append(allowRules, removeRule)
+		}
+	}
+	for _, port := range params.KillSwitch.AllowedPorts {
+		for _, protocol := range []string{"tcp", "udp"} {
+			removeRule, err := firewall.AllowPortAccess(protocol, port)
+			if err != nil {
+				return err
+			}
+			allowRules = append(allowRules, removeRule)
+		}
+	}
 	return nil
 }
 
@@ -773,7 +1269,7 @@ func (m *connectionManager) publishStateEvent(state connectionstate.State) {
 	})
 }
 
-func (m *connectionManager) keepAliveLoop(channel p2p.Channel, sessionID session.ID) {
+func (m *connectionManager) keepAliveLoop(channel p2p.Channel, sessionID session.ID, generation uint64) {
 	// TODO: Remove this check once all provider migrates to p2p.
 	if channel == nil {
 		return
@@ -791,6 +1287,8 @@ func (m *connectionManager) keepAliveLoop(channel p2p.Channel, sessionID session
 	})
 
 	// Send pings to provider.
+	status := m.Status()
+	metrics := p2p.NewMetricsReporter(m.eventBus, channel, p2p.DiagnosticsRoleConsumer, identity.FromAddress(status.Proposal.ProviderID), string(sessionID), status.Proposal.ServiceType)
 	var errCount int
 	for {
 		select {
@@ -804,18 +1302,49 @@ func (m *connectionManager) keepAliveLoop(channel p2p.Channel, sessionID session
 				errCount++
 				if errCount == m.config.KeepAlive.MaxSendErrCount {
 					log.Error().Msgf("Max p2p keepalive err count reached, disconnecting. SessionID=%s", sessionID)
-					m.Disconnect()
+					m.eventBus.Publish(p2p.AppTopicPeerUnreachable, p2p.AppEventPeerUnreachable{
+						Role:        p2p.DiagnosticsRoleConsumer,
+						PeerID:      identity.FromAddress(status.Proposal.ProviderID),
+						SessionID:   string(sessionID),
+						ServiceType: status.Proposal.ServiceType,
+						FailedPings: errCount,
+					})
+					go m.failoverOrDisconnect(generation, connectionstate.DisconnectReasonProviderGone)
 					cancel()
 					return
 				}
 			} else {
 				errCount = 0
 			}
+			metrics.Report()
 			cancel()
 		}
 	}
 }
 
+// handleProviderShutdown fails over to another provider once the provider announces it is stopping the service,
+// so that the session is closed cleanly while the provider is still draining it.
+func (m *connectionManager) handleProviderShutdown(channel p2p.Channel, sessionID session.ID, generation uint64) {
+	// TODO: Remove this check once all provider migrates to p2p.
+	if channel == nil {
+		return
+	}
+
+	channel.Handle(p2p.TopicSessionShutdown, func(c p2p.Context) error {
+		var si pb.SessionInfo
+		if err := c.Request().UnmarshalProto(&si); err != nil {
+			return err
+		}
+		if session.ID(si.GetSessionID()) != sessionID {
+			return c.OK()
+		}
+
+		log.Info().Msgf("Provider is shutting down the service, disconnecting. SessionID=%s", sessionID)
+		go m.failoverOrDisconnect(generation, connectionstate.DisconnectReasonProviderShutdown)
+		return c.OK()
+	})
+}
+
 func (m *connectionManager) sendKeepAlivePing(ctx context.Context, channel p2p.Channel, sessionID session.ID) error {
 	msg := &pb.P2PKeepAlivePing{
 		SessionID: string(sessionID),
@@ -831,8 +1360,15 @@ func (m *connectionManager) currentCtx() context.Context {
 	return m.ctx
 }
 
+func (m *connectionManager) currentGeneration() uint64 {
+	m.ctxLock.RLock()
+	defer m.ctxLock.RUnlock()
+
+	return m.generation
+}
+
 func (m *connectionManager) Reconnect() {
-	err := m.Disconnect()
+	err := m.disconnectWithReason(connectionstate.DisconnectReasonReconnect)
 	if err != nil {
 		log.Error().Err(err).Msgf("Failed to disconnect stale session")
 	}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"net"
 	"sync"
 	"testing"
@@ -28,9 +29,12 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/core/dnsleak"
 	"github.com/mysteriumnetwork/node/core/location"
 	"github.com/mysteriumnetwork/node/core/location/locationstate"
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/trace"
+	"github.com/mysteriumnetwork/node/utils/netutil"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -46,8 +50,10 @@ import (
 	"github.com/mysteriumnetwork/node/pb"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/connectivity"
+	pingpongEvent "github.com/mysteriumnetwork/node/session/pingpong/event"
 	"github.com/mysteriumnetwork/node/sleep"
 
+	"github.com/mysteriumnetwork/payments/crypto"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -69,7 +75,7 @@ type testContext struct {
 
 var (
 	consumerID            = identity.FromAddress("identity-1")
-	consumerLocation      = locationstate.Location{Country: "CH"}
+	consumerLocation      = locationstate.Location{Country: "CH", ASN: 3303}
 	activeProviderID      = identity.FromAddress("fake-node-1")
 	hermesID              = common.HexToAddress("hermes")
 	activeProviderContact = market.Contact{
@@ -152,12 +158,32 @@ func (tc *testContext) SetupTest() {
 		tc.statsReportInterval,
 		&mockValidator{},
 		tc.mockP2P,
+		nil,
+		nil,
 	)
 	tc.connManager.timeGetter = func() time.Time {
 		return tc.mockTime
 	}
 }
 
+// phasesUntil returns phases connection goes through until the given one, all entered at mock time
+func (tc *testContext) phasesUntil(last connectionstate.Phase) []connectionstate.PhaseTiming {
+	var phases []connectionstate.PhaseTiming
+	for _, phase := range []connectionstate.Phase{
+		connectionstate.PhaseResolvingProposal,
+		connectionstate.PhaseDialingP2P,
+		connectionstate.PhaseNegotiatingSession,
+		connectionstate.PhaseConfiguringTunnel,
+		connectionstate.PhaseConnected,
+	} {
+		phases = append(phases, connectionstate.PhaseTiming{Phase: phase, StartedAt: tc.mockTime})
+		if phase == last {
+			break
+		}
+	}
+	return phases
+}
+
 func (tc *testContext) TestWhenNoConnectionIsMadeStatusIsNotConnected() {
 	assert.Exactly(tc.T(), connectionstate.Status{State: connectionstate.NotConnected}, tc.connManager.Status())
 }
@@ -175,6 +201,8 @@ func (tc *testContext) TestOnConnectErrorStatusIsNotConnected() {
 			HermesID:         hermesID,
 			State:            connectionstate.NotConnected,
 			Proposal:         activeProposal,
+			Phase:            connectionstate.PhaseNegotiatingSession,
+			Phases:           tc.phasesUntil(connectionstate.PhaseNegotiatingSession),
 		},
 		tc.connManager.Status(),
 	)
@@ -193,6 +221,8 @@ func (tc *testContext) TestWhenManagerMadeConnectionStatusReturnsConnectedStateA
 			State:            connectionstate.Connected,
 			SessionID:        establishedSessionID,
 			Proposal:         activeProposal,
+			Phase:            connectionstate.PhaseConnected,
+			Phases:           tc.phasesUntil(connectionstate.PhaseConnected),
 		},
 		tc.connManager.Status(),
 	)
@@ -216,6 +246,8 @@ func (tc *testContext) TestSessionDoesFullReconnectOnWakeupEvent() {
 			State:            connectionstate.Connected,
 			SessionID:        establishedSessionID,
 			Proposal:         activeProposal,
+			Phase:            connectionstate.PhaseConnected,
+			Phases:           tc.phasesUntil(connectionstate.PhaseConnected),
 		},
 		tc.connManager.Status(),
 	)
@@ -260,6 +292,8 @@ func (tc *testContext) TestStatusReportsConnectingWhenConnectionIsInProgress() {
 			State:            connectionstate.Connecting,
 			SessionID:        establishedSessionID,
 			Proposal:         activeProposal,
+			Phase:            connectionstate.PhaseConfiguringTunnel,
+			Phases:           tc.phasesUntil(connectionstate.PhaseConfiguringTunnel),
 		},
 		tc.connManager.Status(),
 	)
@@ -292,6 +326,9 @@ func (tc *testContext) TestStatusReportsNotConnected() {
 			State:            connectionstate.Disconnecting,
 			SessionID:        establishedSessionID,
 			Proposal:         activeProposal,
+			DisconnectReason: connectionstate.DisconnectReasonUserRequested,
+			Phase:            connectionstate.PhaseConnected,
+			Phases:           tc.phasesUntil(connectionstate.PhaseConnected),
 		},
 		tc.connManager.Status(),
 	)
@@ -312,6 +349,9 @@ func (tc *testContext) TestStatusReportsNotConnected() {
 			State:            connectionstate.NotConnected,
 			SessionID:        establishedSessionID,
 			Proposal:         activeProposal,
+			DisconnectReason: connectionstate.DisconnectReasonUserRequested,
+			Phase:            connectionstate.PhaseConnected,
+			Phases:           tc.phasesUntil(connectionstate.PhaseConnected),
 		},
 		tc.connManager.Status(),
 	)
@@ -326,6 +366,17 @@ func (tc *testContext) TestDisconnectReturnsErrorWhenNoConnectionExists() {
 	assert.Equal(tc.T(), ErrNoConnection, tc.connManager.Disconnect())
 }
 
+func (tc *testContext) TestPauseReturnsErrorWhenNoConnectionExists() {
+	assert.Equal(tc.T(), ErrNoConnection, tc.connManager.Pause())
+	assert.Equal(tc.T(), ErrNoConnection, tc.connManager.Resume())
+}
+
+func (tc *testContext) TestPauseIsNotSupportedWhenConnectionCanNotReleaseDefaultRoute() {
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{}))
+	assert.Equal(tc.T(), ErrPauseNotSupported, tc.connManager.Pause())
+	assert.False(tc.T(), tc.connManager.Status().Paused)
+}
+
 func (tc *testContext) TestReconnectingStatusIsReportedWhenOpenVpnGoesIntoReconnectingState() {
 	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{}))
 	tc.fakeConnectionFactory.mockConnection.reportState(reconnectingState)
@@ -340,6 +391,8 @@ func (tc *testContext) TestReconnectingStatusIsReportedWhenOpenVpnGoesIntoReconn
 			State:            connectionstate.Reconnecting,
 			SessionID:        establishedSessionID,
 			Proposal:         activeProposal,
+			Phase:            connectionstate.PhaseConnected,
+			Phases:           tc.phasesUntil(connectionstate.PhaseConnected),
 		},
 		tc.connManager.Status(),
 	)
@@ -373,6 +426,419 @@ func (tc *testContext) TestConnectFailsIfConnectionFactoryReturnsError() {
 	assert.Error(tc.T(), tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{}))
 }
 
+func (tc *testContext) TestConnectFailsOverToFallbackProposal() {
+	fallbackProvider := identity.FromAddress("fake-node-2")
+	fallbackProposal := activeProposal
+	fallbackProposal.ProviderID = fallbackProvider.Address
+
+	newConnection := tc.connManager.newConnection
+	tc.connManager.newConnection = func(serviceType string) (Connection, error) {
+		if tc.connManager.Status().Proposal.ProviderID == activeProviderID.Address {
+			return nil, errors.New("primary provider failed")
+		}
+		return newConnection(serviceType)
+	}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{
+		FallbackProposals: []market.ServiceProposal{fallbackProposal},
+	})
+	assert.NoError(tc.T(), err)
+
+	status := tc.connManager.Status()
+	assert.Equal(tc.T(), connectionstate.Connected, status.State)
+	assert.Equal(tc.T(), fallbackProvider.Address, status.Proposal.ProviderID)
+	assert.Equal(tc.T(), activeProviderID, status.FailoverFrom)
+}
+
+func (tc *testContext) TestConnectFailsWhenAllFallbackProposalsFail() {
+	fallbackProposal := activeProposal
+	fallbackProposal.ProviderID = "fake-node-2"
+	tc.fakeConnectionFactory.mockError = errors.New("fatal connection error")
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{
+		FallbackProposals: []market.ServiceProposal{fallbackProposal},
+	})
+	assert.Error(tc.T(), err)
+	assert.Equal(tc.T(), connectionstate.NotConnected, tc.connManager.Status().State)
+	assert.Equal(tc.T(), "fake-node-2", tc.connManager.Status().Proposal.ProviderID)
+}
+
+// droppingConnection is lost on drop, which is observed both by waiting for it and by its state channel.
+type droppingConnection struct {
+	Connection
+	states chan connectionstate.State
+	exited chan struct{}
+}
+
+func newDroppingConnection(conn Connection) *droppingConnection {
+	return &droppingConnection{Connection: conn, states: make(chan connectionstate.State, 1), exited: make(chan struct{})}
+}
+
+func (c *droppingConnection) Start(ctx context.Context, options ConnectOptions) error {
+	if err := c.Connection.Start(ctx, options); err != nil {
+		return err
+	}
+	c.states <- connectionstate.Connected
+	return nil
+}
+
+func (c *droppingConnection) State() <-chan connectionstate.State {
+	return c.states
+}
+
+func (c *droppingConnection) Wait() error {
+	<-c.exited
+	return nil
+}
+
+func (c *droppingConnection) drop() {
+	close(c.states)
+	close(c.exited)
+}
+
+func (tc *testContext) TestConnectionLossIsFailedOverOnce() {
+	fallbackProposal := activeProposal
+	fallbackProposal.ProviderID = "fake-node-2"
+	lastProposal := activeProposal
+	lastProposal.ProviderID = "fake-node-3"
+	// Only the connection loss is failed over, unanswered keep alive pings must not interfere.
+	tc.connManager.config.KeepAlive.SendInterval = time.Hour
+
+	dropping := make(chan *droppingConnection, 1)
+	newConnection := tc.connManager.newConnection
+	tc.connManager.newConnection = func(serviceType string) (Connection, error) {
+		conn, err := newConnection(serviceType)
+		if err != nil || tc.connManager.Status().Proposal.ProviderID != activeProviderID.Address {
+			return conn, err
+		}
+		droppingConn := newDroppingConnection(conn)
+		dropping <- droppingConn
+		return droppingConn, nil
+	}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{
+		FallbackProposals: []market.ServiceProposal{fallbackProposal, lastProposal},
+	})
+	assert.NoError(tc.T(), err)
+
+	// Both connection waiter and state consumer observe the loss.
+	(<-dropping).drop()
+
+	assert.Eventually(tc.T(), func() bool {
+		status := tc.connManager.Status()
+		return status.State == connectionstate.Connected && status.Proposal.ProviderID == fallbackProposal.ProviderID
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Never(tc.T(), func() bool {
+		return tc.connManager.Status().Proposal.ProviderID != fallbackProposal.ProviderID
+	}, 300*time.Millisecond, 10*time.Millisecond)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+// recordingFirewall keeps track of outgoing traffic rules in effect
+type recordingFirewall struct {
+	firewall.OutgoingTrafficFirewall
+	lock   sync.Mutex
+	rules  map[string]int
+	lifted []string
+}
+
+func (f *recordingFirewall) add(rule string) (firewall.OutgoingRuleRemove, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.rules[rule]++
+	return func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		if f.rules[rule]--; f.rules[rule] == 0 {
+			delete(f.rules, rule)
+			f.lifted = append(f.lifted, rule)
+		}
+	}, nil
+}
+
+func (f *recordingFirewall) BlockOutgoingTraffic(scope firewall.Scope, outboundIP string) (firewall.OutgoingRuleRemove, error) {
+	return f.add("block:" + string(scope))
+}
+
+func (f *recordingFirewall) AllowIPAccess(ip string) (firewall.OutgoingRuleRemove, error) {
+	return f.add("allow:" + ip)
+}
+
+func (f *recordingFirewall) AllowPortAccess(protocol string, port int) (firewall.OutgoingRuleRemove, error) {
+	return f.add(fmt.Sprintf("allow-port:%s/%d", protocol, port))
+}
+
+func (f *recordingFirewall) liftedRules() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return append([]string(nil), f.lifted...)
+}
+
+func (f *recordingFirewall) inEffect() map[string]int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	rules := make(map[string]int, len(f.rules))
+	for rule, count := range f.rules {
+		rules[rule] = count
+	}
+	return rules
+}
+
+func (tc *testContext) TestAlwaysOnKillSwitchKeepsOnlyBlockAfterDisconnect() {
+	fw := &recordingFirewall{rules: make(map[string]int)}
+	defaultFirewall := firewall.DefaultOutgoingFirewall
+	firewall.DefaultOutgoingFirewall = fw
+	defer func() { firewall.DefaultOutgoingFirewall = defaultFirewall }()
+
+	killSwitch := KillSwitchOptions{Mode: KillSwitchModeAlways, AllowedPorts: []int{22}}
+	for i := 0; i < 2; i++ {
+		assert.NoError(tc.T(), tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{KillSwitch: killSwitch}))
+		assert.Equal(tc.T(), 1, fw.inEffect()["allow-port:tcp/22"])
+		assert.NoError(tc.T(), tc.connManager.Disconnect())
+		waitABit()
+	}
+
+	assert.Equal(tc.T(), map[string]int{"block:global": 2}, fw.inEffect())
+}
+
+func (tc *testContext) TestKillSwitchBlockIsKeptDuringFailover() {
+	fw := &recordingFirewall{rules: make(map[string]int)}
+	defaultFirewall := firewall.DefaultOutgoingFirewall
+	firewall.DefaultOutgoingFirewall = fw
+	defer func() { firewall.DefaultOutgoingFirewall = defaultFirewall }()
+
+	fallbackProposal := activeProposal
+	fallbackProposal.ProviderID = "fake-node-2"
+	tc.connManager.config.KeepAlive.SendInterval = time.Hour
+
+	dropping := make(chan *droppingConnection, 1)
+	newConnection := tc.connManager.newConnection
+	tc.connManager.newConnection = func(serviceType string) (Connection, error) {
+		conn, err := newConnection(serviceType)
+		if err != nil || tc.connManager.Status().Proposal.ProviderID != activeProviderID.Address {
+			return conn, err
+		}
+		droppingConn := newDroppingConnection(conn)
+		dropping <- droppingConn
+		return droppingConn, nil
+	}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{
+		FallbackProposals: []market.ServiceProposal{fallbackProposal},
+	})
+	assert.NoError(tc.T(), err)
+	(<-dropping).drop()
+
+	assert.Eventually(tc.T(), func() bool {
+		status := tc.connManager.Status()
+		return status.State == connectionstate.Connected && status.Proposal.ProviderID == fallbackProposal.ProviderID
+	}, 2*time.Second, 10*time.Millisecond)
+	waitABit()
+	assert.Equal(tc.T(), 1, fw.inEffect()["block:session"])
+	assert.NotContains(tc.T(), fw.liftedRules(), "block:session")
+
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+	waitABit()
+	assert.Empty(tc.T(), fw.inEffect())
+	assert.Contains(tc.T(), fw.liftedRules(), "block:session")
+}
+
+func (tc *testContext) TestConnectRetriesTransientFailures() {
+	attempts := 0
+	newConnection := tc.connManager.newConnection
+	tc.connManager.newConnection = func(serviceType string) (Connection, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("transient failure")
+		}
+		return newConnection(serviceType)
+	}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{
+		Retry: &RetryPolicy{Attempts: 3, Backoff: time.Millisecond},
+	})
+	assert.NoError(tc.T(), err)
+	assert.Equal(tc.T(), 3, attempts)
+	assert.Equal(tc.T(), connectionstate.Connected, tc.connManager.Status().State)
+}
+
+func (tc *testContext) TestConnectGivesUpAfterRetryAttempts() {
+	tc.connManager.config.Retry = RetryPolicy{Attempts: 2, Backoff: time.Millisecond, Jitter: 0.5}
+	attempts := 0
+	tc.connManager.newConnection = func(serviceType string) (Connection, error) {
+		attempts++
+		return nil, errors.New("transient failure")
+	}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{})
+	assert.EqualError(tc.T(), err, "transient failure")
+	assert.Equal(tc.T(), 2, attempts)
+	assert.Equal(tc.T(), connectionstate.NotConnected, tc.connManager.Status().State)
+}
+
+func (tc *testContext) TestConnectDoesNotRetryPermanentFailures() {
+	tc.connManager.config.Retry = RetryPolicy{Attempts: 3}
+	validator := &mockValidator{errorToReturn: ErrInsufficientBalance}
+	tc.connManager.validator = validator
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{})
+	assert.Equal(tc.T(), ErrInsufficientBalance, err)
+	assert.Equal(tc.T(), 1, validator.calls)
+}
+
+func (tc *testContext) TestConnectRetryCanBeCancelled() {
+	tc.connManager.newConnection = func(serviceType string) (Connection, error) {
+		return nil, errors.New("transient failure")
+	}
+
+	connectWaiter := &sync.WaitGroup{}
+	connectWaiter.Add(1)
+	var err error
+	go func() {
+		defer connectWaiter.Done()
+		err = tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{
+			Retry: &RetryPolicy{Attempts: 2, Backoff: time.Minute},
+		})
+	}()
+
+	waitABit()
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+
+	connectWaiter.Wait()
+	assert.Equal(tc.T(), ErrConnectionCancelled, err)
+}
+
+func (tc *testContext) TestConnectRejectsInvalidRetryPolicy() {
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{
+		Retry: &RetryPolicy{Attempts: 2, Jitter: 2},
+	})
+	assert.EqualError(tc.T(), err, "connect retry jitter must be between 0 and 1: 2")
+}
+
+type providerEndpointConnection struct {
+	Connection
+	providerIP net.IP
+}
+
+func (c *providerEndpointConnection) ProviderIP() net.IP {
+	return c.providerIP
+}
+
+func (tc *testContext) TestConnectRoutesProviderThroughEntryInterface() {
+	var lock sync.Mutex
+	var added, deleted []string
+	routeThroughInterface = func(ip net.IP, iface string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		added = append(added, ip.String()+" dev "+iface)
+		return nil
+	}
+	deleteRouteThroughInterface = func(ip net.IP, iface string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		deleted = append(deleted, ip.String()+" dev "+iface)
+		return nil
+	}
+	defer func() {
+		routeThroughInterface = netutil.RouteThroughInterface
+		deleteRouteThroughInterface = netutil.DeleteRouteThroughInterface
+	}()
+	tc.connManager.newConnection = func(serviceType string) (Connection, error) {
+		conn, err := tc.fakeConnectionFactory.CreateConnection(serviceType)
+		return &providerEndpointConnection{Connection: conn, providerIP: net.ParseIP("1.2.3.4")}, err
+	}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{EntryInterface: "wg0"})
+	assert.NoError(tc.T(), err)
+	lock.Lock()
+	assert.Equal(tc.T(), []string{"1.2.3.4 dev wg0"}, added)
+	lock.Unlock()
+
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+	assert.Eventually(tc.T(), func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(deleted) == 1 && deleted[0] == "1.2.3.4 dev wg0"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func (tc *testContext) TestConnectWithEntryInterfaceRequiresProviderEndpoint() {
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{EntryInterface: "wg0"})
+	assert.EqualError(tc.T(), err, "connection does not support multi-hop: provider address is unknown")
+}
+
+func (tc *testContext) TestConnectProbesCandidatesAndConnectsToFastest() {
+	fallbackProposal := activeProposal
+	fallbackProposal.ProviderID = "fake-node-2"
+	tc.connManager.p2pDialer = &probeP2PDialer{
+		ch:      tc.mockP2P.ch,
+		latency: map[string]time.Duration{activeProviderID.Address: 50 * time.Millisecond},
+	}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{
+		FallbackProposals: []market.ServiceProposal{fallbackProposal},
+		ProbeCandidates:   2,
+	})
+	assert.NoError(tc.T(), err)
+
+	status := tc.connManager.Status()
+	assert.Equal(tc.T(), connectionstate.Connected, status.State)
+	assert.Equal(tc.T(), "fake-node-2", status.Proposal.ProviderID)
+	assert.Len(tc.T(), status.Probes, 2)
+	assert.Equal(tc.T(), "fake-node-2", status.Probes[0].ProviderID)
+	assert.Empty(tc.T(), status.Probes[0].Error)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) TestConnectProbingPrefersRespondingCandidates() {
+	fallbackProposal := activeProposal
+	fallbackProposal.ProviderID = "fake-node-2"
+	tc.connManager.p2pDialer = &probeP2PDialer{
+		ch:          tc.mockP2P.ch,
+		unreachable: activeProviderID.Address,
+	}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{
+		FallbackProposals: []market.ServiceProposal{fallbackProposal},
+		ProbeCandidates:   2,
+	})
+	assert.NoError(tc.T(), err)
+	assert.Equal(tc.T(), "fake-node-2", tc.connManager.Status().Proposal.ProviderID)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) TestConnectProbingCanBeCancelled() {
+	fallbackProposal := activeProposal
+	fallbackProposal.ProviderID = "fake-node-2"
+	tc.connManager.config.Probe = ProbeConfig{Candidates: 2, Timeout: time.Minute}
+	tc.connManager.p2pDialer = &probeP2PDialer{
+		ch:      tc.mockP2P.ch,
+		latency: map[string]time.Duration{activeProviderID.Address: time.Minute, "fake-node-2": time.Minute},
+	}
+
+	connectWaiter := &sync.WaitGroup{}
+	connectWaiter.Add(1)
+	var err error
+	go func() {
+		defer connectWaiter.Done()
+		err = tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{
+			FallbackProposals: []market.ServiceProposal{fallbackProposal},
+		})
+	}()
+
+	waitABit()
+	assert.Equal(tc.T(), connectionstate.Probing, tc.connManager.Status().State)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+
+	connectWaiter.Wait()
+	assert.Equal(tc.T(), ErrConnectionCancelled, err)
+	assert.Equal(tc.T(), connectionstate.NotConnected, tc.connManager.Status().State)
+}
+
 func (tc *testContext) TestStatusIsConnectedWhenConnectCommandReturnsWithoutError() {
 	tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{})
 	assert.Equal(
@@ -385,6 +851,8 @@ func (tc *testContext) TestStatusIsConnectedWhenConnectCommandReturnsWithoutErro
 			State:            connectionstate.Connected,
 			SessionID:        establishedSessionID,
 			Proposal:         activeProposal,
+			Phase:            connectionstate.PhaseConnected,
+			Phases:           tc.phasesUntil(connectionstate.PhaseConnected),
 		},
 		tc.connManager.Status(),
 	)
@@ -567,6 +1035,213 @@ func (tc *testContext) Test_ManagerNotifiesAboutSessionIPNotChanged() {
 	)
 }
 
+func (tc *testContext) Test_ConnectFailsWithStageTimeoutWhenTunnelDoesNotComeUp() {
+	tc.fakeConnectionFactory.mockConnection.onStartReportStates = []fakeState{
+		processStarted,
+		connectingState,
+	}
+	tc.connManager.config.Timeouts.TunnelUp = 10 * time.Millisecond
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{})
+
+	assert.True(tc.T(), errors.Is(err, ErrConnectTimeout))
+	var stageErr *StageTimeoutError
+	assert.True(tc.T(), errors.As(err, &stageErr))
+	assert.Equal(tc.T(), StageTunnelUp, stageErr.Stage)
+	assert.Equal(tc.T(), connectionstate.NotConnected, tc.connManager.Status().State)
+}
+
+func (tc *testContext) Test_ManagerNotifiesAboutDNSLeak() {
+	tc.stubPublisher.Clear()
+	tc.connManager.dnsLeakDetector = &mockDNSLeakDetector{resolvers: []dnsleak.Resolver{{IP: "10.0.0.1", ASN: consumerLocation.ASN}}}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+
+	assert.Eventually(tc.T(), func() bool {
+		history := tc.stubPublisher.GetEventHistory()
+		for _, v := range history {
+			if v.Topic == connectionstate.AppTopicDNSLeak {
+				return assert.Equal(tc.T(), []string{"10.0.0.1"}, v.Event.(connectionstate.AppEventDNSLeak).Resolvers)
+			}
+		}
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.True(tc.T(), tc.connManager.Status().DNSLeakDetected)
+	assert.Equal(tc.T(), connectionstate.Connected, tc.connManager.Status().State)
+}
+
+func (tc *testContext) Test_ManagerDisconnectsOnDNSLeak() {
+	tc.connManager.dnsLeakDetector = &mockDNSLeakDetector{resolvers: []dnsleak.Resolver{{IP: "10.0.0.1", ASN: consumerLocation.ASN}}}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{DisconnectOnDNSLeak: true})
+	assert.NoError(tc.T(), err)
+
+	assert.Eventually(tc.T(), func() bool {
+		return tc.connManager.Status().State == connectionstate.NotConnected
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func (tc *testContext) Test_ManagerDoesNotReportDNSLeakForTunnelResolvers() {
+	tc.stubPublisher.Clear()
+	detector := &mockDNSLeakDetector{resolvers: []dnsleak.Resolver{{IP: "1.1.1.1", ASN: 13335}}}
+	tc.connManager.dnsLeakDetector = detector
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+
+	assert.Eventually(tc.T(), detector.wasCalled, 2*time.Second, 10*time.Millisecond)
+	waitABit()
+	assert.False(tc.T(), tc.connManager.Status().DNSLeakDetected)
+}
+
+func (tc *testContext) Test_ManagerDisconnectsWhenDataCapIsReached() {
+	tc.stubPublisher.Clear()
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{DataCap: DataCap{Bytes: 30}})
+	assert.NoError(tc.T(), err)
+
+	assert.Eventually(tc.T(), func() bool {
+		return tc.connManager.Status().State == connectionstate.NotConnected
+	}, 2*time.Second, 10*time.Millisecond)
+
+	var warning *connectionstate.AppEventDataCap
+	var ended *connectionstate.AppEventConnectionSession
+	for _, v := range tc.stubPublisher.GetEventHistory() {
+		switch e := v.Event.(type) {
+		case connectionstate.AppEventDataCap:
+			warning = &e
+		case connectionstate.AppEventConnectionSession:
+			if e.Status == connectionstate.SessionEndedStatus {
+				ended = &e
+			}
+		}
+	}
+	assert.NotNil(tc.T(), warning)
+	assert.Equal(tc.T(), 95, warning.Percent)
+	assert.NotNil(tc.T(), ended)
+	assert.Equal(tc.T(), connectionstate.DisconnectReasonDataCap, ended.SessionInfo.DisconnectReason)
+}
+
+func (tc *testContext) Test_ManagerDisconnectsWhenIdle() {
+	tc.stubPublisher.Clear()
+	tc.connManager.config.IdleTimeout = 2 * time.Minute
+	var lock sync.Mutex
+	tc.connManager.timeGetter = func() time.Time {
+		lock.Lock()
+		defer lock.Unlock()
+
+		tc.mockTime = tc.mockTime.Add(time.Minute)
+		return tc.mockTime
+	}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+
+	assert.Eventually(tc.T(), func() bool {
+		for _, v := range tc.stubPublisher.GetEventHistory() {
+			if e, ok := v.Event.(connectionstate.AppEventConnectionSession); ok && e.Status == connectionstate.SessionEndedStatus {
+				return e.SessionInfo.DisconnectReason == connectionstate.DisconnectReasonIdle
+			}
+		}
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func (tc *testContext) Test_ManagerReportsConnectionLost() {
+	tc.stubPublisher.Clear()
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = nil
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+
+	assert.Eventually(tc.T(), func() bool {
+		for _, v := range tc.stubPublisher.GetEventHistory() {
+			if e, ok := v.Event.(connectionstate.AppEventConnectionSession); ok && e.Status == connectionstate.SessionEndedStatus {
+				return e.SessionInfo.DisconnectReason == connectionstate.DisconnectReasonConnectionLost
+			}
+		}
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func (tc *testContext) Test_ManagerReportsProviderGone() {
+	tc.stubPublisher.Clear()
+
+	// Provider of the mock channel does not answer keep alive pings.
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+
+	assert.Eventually(tc.T(), func() bool {
+		for _, v := range tc.stubPublisher.GetEventHistory() {
+			if e, ok := v.Event.(connectionstate.AppEventConnectionSession); ok && e.Status == connectionstate.SessionEndedStatus {
+				return e.SessionInfo.DisconnectReason == connectionstate.DisconnectReasonProviderGone
+			}
+		}
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func (tc *testContext) Test_ManagerKeepsFirstDisconnectReason() {
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+
+	assert.NoError(tc.T(), tc.connManager.disconnectWithReason(connectionstate.DisconnectReasonDataCap))
+	assert.Equal(tc.T(), ErrNoConnection, tc.connManager.Disconnect())
+	assert.Equal(tc.T(), connectionstate.DisconnectReasonDataCap, tc.connManager.Status().DisconnectReason)
+}
+
+func (tc *testContext) Test_ManagerDisconnectsWhenSpendCapIsReached() {
+	tc.stubPublisher.Clear()
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{SpendCap: big.NewInt(100)})
+	assert.NoError(tc.T(), err)
+
+	invoicePaid := func(sessionID string, total int64) pingpongEvent.AppEventInvoicePaid {
+		return pingpongEvent.AppEventInvoicePaid{SessionID: sessionID, Invoice: crypto.Invoice{AgreementTotal: big.NewInt(total)}}
+	}
+	tc.connManager.consumeInvoicePaidEvent(invoicePaid(string(establishedSessionID), 50))
+	tc.connManager.consumeInvoicePaidEvent(invoicePaid("other-session", 200))
+	assert.Equal(tc.T(), connectionstate.Connected, tc.connManager.Status().State)
+
+	tc.connManager.consumeInvoicePaidEvent(invoicePaid(string(establishedSessionID), 100))
+	assert.Eventually(tc.T(), func() bool {
+		for _, v := range tc.stubPublisher.GetEventHistory() {
+			if e, ok := v.Event.(connectionstate.AppEventConnectionSession); ok && e.Status == connectionstate.SessionEndedStatus {
+				return e.SessionInfo.DisconnectReason == connectionstate.DisconnectReasonSpendCap
+			}
+		}
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func (tc *testContext) Test_ManagerIgnoresPaymentEventsAfterUnsubscribe() {
+	bus := eventbus.New()
+	assert.NoError(tc.T(), tc.connManager.Subscribe(bus))
+	assert.NoError(tc.T(), tc.connManager.Unsubscribe(bus))
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{SpendCap: big.NewInt(100)})
+	assert.NoError(tc.T(), err)
+
+	bus.Publish(pingpongEvent.AppTopicInvoicePaid, pingpongEvent.AppEventInvoicePaid{
+		SessionID: string(establishedSessionID),
+		Invoice:   crypto.Invoice{AgreementTotal: big.NewInt(100)},
+	})
+	assert.Never(tc.T(), func() bool {
+		return tc.connManager.Status().State != connectionstate.Connected
+	}, 100*time.Millisecond, 10*time.Millisecond)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) Test_ManagerRefusesToConnectWhenDailyDataCapIsUsedUp() {
+	tc.connManager.dataUsage = &mockDataUsage{consumed: 100}
+
+	err := tc.connManager.Connect(consumerID, hermesID, activeProposal, ConnectParams{DataCap: DataCap{Bytes: 100, Period: DataCapPerDay}})
+	assert.Equal(tc.T(), ErrDataCapReached, err)
+	assert.Equal(tc.T(), connectionstate.NotConnected, tc.connManager.Status().State)
+}
+
 func (tc *testContext) Test_ManagerNotifiesAboutSuccessfulConnection() {
 	tc.stubPublisher.Clear()
 
@@ -685,6 +1360,37 @@ func (m mockP2PDialer) Dial(ctx context.Context, consumerID identity.Identity, p
 	return m.ch, nil
 }
 
+// probeP2PDialer dials channels answering probes with per provider latency.
+type probeP2PDialer struct {
+	ch          *mockP2PChannel
+	latency     map[string]time.Duration
+	unreachable string
+}
+
+func (m *probeP2PDialer) Dial(ctx context.Context, consumerID identity.Identity, providerID identity.Identity, serviceType string, contactDef p2p.ContactDefinition, tracer *trace.Tracer) (p2p.Channel, error) {
+	if providerID.Address == m.unreachable {
+		return nil, errors.New("provider unreachable")
+	}
+	return &probeP2PChannel{mockP2PChannel: m.ch, latency: m.latency[providerID.Address]}, nil
+}
+
+type probeP2PChannel struct {
+	*mockP2PChannel
+	latency time.Duration
+}
+
+func (m *probeP2PChannel) Send(ctx context.Context, topic string, msg *p2p.Message) (*p2p.Message, error) {
+	if topic != p2p.TopicProbe {
+		return m.mockP2PChannel.Send(ctx, topic, msg)
+	}
+	select {
+	case <-time.After(m.latency):
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 type mockP2PChannel struct {
 	status proto.Message
 	lock   sync.Mutex
@@ -694,6 +1400,22 @@ func (m *mockP2PChannel) Conn() *net.UDPConn {
 	return &net.UDPConn{}
 }
 
+func (m *mockP2PChannel) Relayed() bool {
+	return false
+}
+
+func (m *mockP2PChannel) Capabilities() p2p.Capabilities {
+	return p2p.Capabilities{}
+}
+
+func (m *mockP2PChannel) Metrics() p2p.ChannelMetrics {
+	return p2p.ChannelMetrics{}
+}
+
+func (m *mockP2PChannel) Rebind(context.Context) error {
+	return nil
+}
+
 func (m *mockP2PChannel) getSentMsg() proto.Message {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -740,12 +1462,43 @@ func (m *mockP2PChannel) Close() error {
 
 type mockValidator struct {
 	errorToReturn error
+	calls         int
 }
 
 func (mv *mockValidator) Validate(consumerID identity.Identity, proposal market.ServiceProposal) error {
+	mv.calls++
 	return mv.errorToReturn
 }
 
+type mockDNSLeakDetector struct {
+	resolvers []dnsleak.Resolver
+	called    bool
+	sync.Mutex
+}
+
+func (d *mockDNSLeakDetector) DetectResolvers(context.Context) ([]dnsleak.Resolver, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.called = true
+	return d.resolvers, nil
+}
+
+func (d *mockDNSLeakDetector) wasCalled() bool {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.called
+}
+
+type mockDataUsage struct {
+	consumed uint64
+}
+
+func (du *mockDataUsage) DataConsumedSince(time.Time) (uint64, error) {
+	return du.consumed, nil
+}
+
 type mockLocationResolver struct{}
 
 func (mlr *mockLocationResolver) GetOrigin() locationstate.Location {
@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/utils/netutil"
+)
+
+// Tunnel MTU bounds. IPv6 carried by the tunnel requires at least 1280 bytes on every link.
+const (
+	MinMTU = 1280
+	MaxMTU = 1500
+)
+
+const (
+	// defaultTunnelMTU is the MTU WireGuard interfaces are created with, it fits the largest tunnel overhead into 1500 bytes path.
+	defaultTunnelMTU = 1420
+	// tunnelOverhead is the size of outer IPv6, UDP and WireGuard headers.
+	tunnelOverhead = 80
+	// mtuProbeTimeout is time to wait for a single probe reply.
+	mtuProbeTimeout = time.Second
+	// mtuProbeAttempts is count of probes sent before packet size is considered too large, tolerating occasional packet loss.
+	mtuProbeAttempts = 2
+)
+
+var (
+	setMTU         = netutil.SetMTU
+	pingNoFragment = netutil.PingNoFragment
+)
+
+// ProviderEndpoint is implemented by connections tunnelling traffic to a known provider address,
+// path to which can be probed for its MTU.
+type ProviderEndpoint interface {
+	ProviderIP() net.IP
+}
+
+// validateMTU validates tunnel MTU requested by connect params, zero value selects MTU automatically.
+func validateMTU(mtu int) error {
+	if mtu != 0 && (mtu < MinMTU || mtu > MaxMTU) {
+		return fmt.Errorf("invalid MTU %d, should be between %d and %d", mtu, MinMTU, MaxMTU)
+	}
+	return nil
+}
+
+// adjustMTU probes path MTU to the provider and lowers tunnel MTU, if the default one does not fit into the path.
+// Links with lower MTU, such as PPPoE and LTE, silently drop large tunnel packets otherwise and connections stall.
+func (m *connectionManager) adjustMTU(ctx context.Context, iface string, providerIP net.IP) {
+	pathMTU := probePathMTU(ctx, providerIP)
+	if pathMTU == 0 {
+		log.Debug().Msgf("Could not probe path MTU to %s, keeping default tunnel MTU", providerIP)
+		return
+	}
+
+	mtu := pathMTU - tunnelOverhead
+	if mtu >= defaultTunnelMTU {
+		log.Debug().Msgf("Path MTU to %s is %d, keeping default tunnel MTU", providerIP, pathMTU)
+		return
+	}
+
+	log.Info().Msgf("Path MTU to %s is %d, lowering tunnel MTU to %d", providerIP, pathMTU, mtu)
+	m.setTunnelMTU(iface, mtu)
+}
+
+func (m *connectionManager) setTunnelMTU(iface string, mtu int) {
+	if err := setMTU(iface, mtu); err != nil {
+		log.Error().Err(err).Msgf("Could not set MTU of %s", iface)
+		return
+	}
+	m.setStatus(func(status *connectionstate.Status) {
+		status.MTU = mtu
+	})
+}
+
+// probePathMTU finds the largest packet reaching the host unfragmented, zero if even the smallest one does not.
+func probePathMTU(ctx context.Context, ip net.IP) int {
+	low, high := MinMTU+tunnelOverhead, MaxMTU
+	if !probeMTU(ctx, ip, low) {
+		return 0
+	}
+
+	for low < high {
+		size := (low + high + 1) / 2
+		if probeMTU(ctx, ip, size) {
+			low = size
+		} else {
+			high = size - 1
+		}
+		if ctx.Err() != nil {
+			return 0
+		}
+	}
+	return low
+}
+
+func probeMTU(ctx context.Context, ip net.IP, size int) bool {
+	for i := 0; i < mtuProbeAttempts; i++ {
+		if ctx.Err() != nil {
+			return false
+		}
+		if err := pingNoFragment(ip, size, mtuProbeTimeout); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMTU(t *testing.T) {
+	assert.NoError(t, validateMTU(0))
+	assert.NoError(t, validateMTU(1400))
+	assert.EqualError(t, validateMTU(1000), "invalid MTU 1000, should be between 1280 and 1500")
+	assert.EqualError(t, validateMTU(9000), "invalid MTU 9000, should be between 1280 and 1500")
+}
+
+func TestProbePathMTU(t *testing.T) {
+	tests := []struct {
+		name     string
+		pathMTU  int
+		expected int
+	}{
+		{"full size path", 1500, 1500},
+		{"PPPoE link", 1492, 1492},
+		{"LTE link", 1428, 1428},
+		{"path below tunnel minimum", 1300, 0},
+		{"no replies", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer stubPing(tt.pathMTU)()
+			assert.Equal(t, tt.expected, probePathMTU(context.Background(), net.ParseIP("1.2.3.4")))
+		})
+	}
+}
+
+func TestProbePathMTU_ToleratesLostProbes(t *testing.T) {
+	lost := map[int]bool{}
+	original := pingNoFragment
+	pingNoFragment = func(ip net.IP, size int, timeout time.Duration) error {
+		if size > 1492 {
+			return errors.New("message too long")
+		}
+		// Every size is lost once, second attempt gets through.
+		if !lost[size] {
+			lost[size] = true
+			return errors.New("timeout")
+		}
+		return nil
+	}
+	defer func() { pingNoFragment = original }()
+
+	assert.Equal(t, 1492, probePathMTU(context.Background(), net.ParseIP("1.2.3.4")))
+}
+
+func TestProbePathMTU_StopsWhenCancelled(t *testing.T) {
+	defer stubPing(1500)()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Equal(t, 0, probePathMTU(ctx, net.ParseIP("1.2.3.4")))
+}
+
+func stubPing(pathMTU int) func() {
+	original := pingNoFragment
+	pingNoFragment = func(ip net.IP, size int, timeout time.Duration) error {
+		if size > pathMTU {
+			return errors.New("message too long")
+		}
+		return nil
+	}
+	return func() { pingNoFragment = original }
+}
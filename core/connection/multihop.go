@@ -0,0 +1,331 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/utils/netutil"
+)
+
+var (
+	routeThroughInterface       = netutil.RouteThroughInterface
+	deleteRouteThroughInterface = netutil.DeleteRouteThroughInterface
+)
+
+// EntryHopTopicPrefix prefixes topics of connection events published by the entry hop of multi-hop connection
+const EntryHopTopicPrefix = "EntryHop"
+
+// topicPrefixEventBus publishes connection events under prefixed topics,
+// so that they are not mistaken for the events of the main connection.
+type topicPrefixEventBus struct {
+	eventbus.EventBus
+	prefix string
+}
+
+// NewEntryHopEventBus returns event bus to be used by the entry hop connection manager
+func NewEntryHopEventBus(bus eventbus.EventBus) eventbus.EventBus {
+	return &topicPrefixEventBus{EventBus: bus, prefix: EntryHopTopicPrefix}
+}
+
+// Publish publishes connection events under prefixed topics
+func (b *topicPrefixEventBus) Publish(topic string, data interface{}) {
+	switch topic {
+	case connectionstate.AppTopicConnectionState, connectionstate.AppTopicConnectionStatistics, connectionstate.AppTopicConnectionSession,
+		connectionstate.AppTopicConnectionHealth:
+		topic = b.prefix + topic
+	}
+	b.EventBus.Publish(topic, data)
+}
+
+// multiHopManager chains two connections: consumer traffic enters the network through the entry provider
+// and leaves it through the exit provider. Each hop has its own session and payments.
+// Entry hop keeps the default route intact and carries only the exit hop tunnel, which routes consumer traffic.
+// Connections without entry proposal are single hop and handled by exit manager alone.
+type multiHopManager struct {
+	entry Manager
+	exit  Manager
+
+	lock          sync.Mutex
+	entryProposal *market.ServiceProposal
+	exitProposal  market.ServiceProposal
+	consumerID    identity.Identity
+	hermesID      common.Address
+	params        ConnectParams
+	connecting    bool
+	cancelled     bool
+}
+
+// NewMultiHopManager creates connection manager composing entry and exit hop managers
+func NewMultiHopManager(entry, exit Manager) *multiHopManager {
+	return &multiHopManager{
+		entry: entry,
+		exit:  exit,
+	}
+}
+
+// Connect connects to the entry hop first, exit hop tunnel is then routed through the entry hop tunnel.
+func (m *multiHopManager) Connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams) error {
+	if params.EntryProposal == nil {
+		return m.exit.Connect(consumerID, hermesID, proposal, params)
+	}
+
+	m.lock.Lock()
+	if m.entryProposal != nil {
+		m.lock.Unlock()
+		return ErrAlreadyExists
+	}
+	m.entryProposal = params.EntryProposal
+	m.exitProposal = proposal
+	m.consumerID = consumerID
+	m.hermesID = hermesID
+	m.params = params
+	m.connecting = true
+	m.cancelled = false
+	m.lock.Unlock()
+
+	err := m.connect(consumerID, hermesID, proposal, params)
+
+	m.lock.Lock()
+	m.connecting = false
+	if err != nil {
+		m.entryProposal = nil
+	}
+	m.lock.Unlock()
+
+	return err
+}
+
+func (m *multiHopManager) connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams) error {
+	// Exit hop takes care of default route, kill switch and DNS, entry hop only carries its traffic.
+	entryParams := ConnectParams{
+		DisableKillSwitch: true,
+		DNS:               DNSOptionSystem,
+		KeepDefaultRoute:  true,
+	}
+	if err := m.entry.Connect(consumerID, hermesID, *params.EntryProposal, entryParams); err != nil {
+		log.Error().Err(err).Msg("Could not connect to the entry hop")
+		return err
+	}
+
+	if m.isCancelled() {
+		logDisconnectError(m.entry.Disconnect())
+		return ErrConnectionCancelled
+	}
+
+	return m.connectExit(consumerID, hermesID, proposal, params)
+}
+
+// connectExit connects to the exit hop routing its tunnel through the interface of the connected entry hop.
+func (m *multiHopManager) connectExit(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams) error {
+	entryInterface := m.entry.Status().InterfaceName
+	if entryInterface == "" {
+		logDisconnectError(m.entry.Disconnect())
+		return errors.New("entry hop connection has no tunnel interface to route exit hop through")
+	}
+
+	exitParams := params
+	exitParams.EntryProposal = nil
+	exitParams.EntryInterface = entryInterface
+	if err := m.exit.Connect(consumerID, hermesID, proposal, exitParams); err != nil {
+		log.Error().Err(err).Msg("Could not connect to the exit hop")
+		logDisconnectError(m.entry.Disconnect())
+		return err
+	}
+	return nil
+}
+
+func (m *multiHopManager) isCancelled() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.cancelled
+}
+
+func (m *multiHopManager) isMultiHop() (*market.ServiceProposal, market.ServiceProposal, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.entryProposal, m.exitProposal, m.entryProposal != nil
+}
+
+// Status returns status of the exit hop, or status of the entry hop while it is being established.
+func (m *multiHopManager) Status() connectionstate.Status {
+	status := m.exit.Status()
+
+	entryProposal, exitProposal, ok := m.isMultiHop()
+	if !ok {
+		return status
+	}
+
+	if status.State == connectionstate.NotConnected {
+		status = m.entry.Status()
+		status.Proposal = exitProposal
+	}
+	status.EntryProposal = entryProposal
+	return status
+}
+
+// Disconnect disconnects both hops, exit hop first.
+func (m *multiHopManager) Disconnect() error {
+	m.lock.Lock()
+	multiHop := m.entryProposal != nil
+	if m.connecting {
+		m.cancelled = true
+	} else {
+		m.entryProposal = nil
+	}
+	m.lock.Unlock()
+
+	exitErr := m.exit.Disconnect()
+	if !multiHop {
+		return exitErr
+	}
+
+	entryErr := m.entry.Disconnect()
+	if exitErr == ErrNoConnection && entryErr == ErrNoConnection {
+		return ErrNoConnection
+	}
+	if exitErr != nil && exitErr != ErrNoConnection {
+		return exitErr
+	}
+	if entryErr != nil && entryErr != ErrNoConnection {
+		return entryErr
+	}
+	return nil
+}
+
+// CheckChannel checks channels of both hops.
+func (m *multiHopManager) CheckChannel(ctx context.Context) error {
+	if _, _, ok := m.isMultiHop(); ok {
+		if err := m.entry.CheckChannel(ctx); err != nil {
+			return err
+		}
+	}
+	return m.exit.CheckChannel(ctx)
+}
+
+// Reconnect reconnects both hops, entry hop first. Exit hop is connected again,
+// since the reconnected entry hop may come up with a different tunnel interface.
+func (m *multiHopManager) Reconnect() {
+	if _, _, ok := m.isMultiHop(); !ok {
+		m.exit.Reconnect()
+		return
+	}
+
+	m.setConnecting(true)
+	defer m.setConnecting(false)
+
+	logDisconnectError(m.exit.Disconnect())
+	m.entry.Reconnect()
+
+	m.lock.Lock()
+	consumerID, hermesID, proposal, params := m.consumerID, m.hermesID, m.exitProposal, m.params
+	m.lock.Unlock()
+	if err := m.connectExit(consumerID, hermesID, proposal, params); err != nil {
+		log.Error().Err(err).Msg("Failed to reconnect the exit hop")
+	}
+}
+
+// Rebind rebinds channel of the entry hop, exit hop tunnel is routed through the entry hop tunnel and is not affected.
+func (m *multiHopManager) Rebind(ctx context.Context) error {
+	if _, _, ok := m.isMultiHop(); ok {
+		return m.entry.Rebind(ctx)
+	}
+	return m.exit.Rebind(ctx)
+}
+
+// UpdateSplitTunnel updates split tunnel rules of the exit hop, which routes consumer traffic.
+func (m *multiHopManager) UpdateSplitTunnel(splitTunnel SplitTunnel) error {
+	return m.exit.UpdateSplitTunnel(splitTunnel)
+}
+
+// SetMaxBandwidth limits speed of the exit hop, which carries consumer traffic.
+func (m *multiHopManager) SetMaxBandwidth(limit datasize.BitSpeed) error {
+	return m.exit.SetMaxBandwidth(limit)
+}
+
+// Pause pauses the exit hop, which routes consumer traffic. Entry hop only carries traffic of the exit hop.
+func (m *multiHopManager) Pause() error {
+	return m.exit.Pause()
+}
+
+// Resume resumes the exit hop.
+func (m *multiHopManager) Resume() error {
+	return m.exit.Resume()
+}
+
+func (m *multiHopManager) setConnecting(connecting bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.connecting = connecting
+}
+
+// Subscribe subscribes to exit hop events, to tear down entry hop once exit hop is gone.
+func (m *multiHopManager) Subscribe(bus eventbus.Subscriber) error {
+	return bus.SubscribeAsync(connectionstate.AppTopicConnectionState, m.handleExitStateEvent)
+}
+
+func (m *multiHopManager) handleExitStateEvent(e connectionstate.AppEventConnectionState) {
+	if e.State != connectionstate.NotConnected {
+		return
+	}
+
+	m.lock.Lock()
+	orphaned := m.entryProposal != nil && !m.connecting && m.exit.Status().State == connectionstate.NotConnected
+	if orphaned {
+		m.entryProposal = nil
+	}
+	m.lock.Unlock()
+
+	if orphaned {
+		log.Info().Msg("Exit hop disconnected, disconnecting entry hop")
+		logDisconnectError(m.entry.Disconnect())
+	}
+}
+
+// routeThroughEntry routes tunnel traffic to the provider through the entry hop tunnel interface
+// instead of the physical gateway, so that it is carried by both hops.
+func (m *connectionManager) routeThroughEntry(conn Connection, entryInterface string) error {
+	endpoint, ok := conn.(ProviderEndpoint)
+	if !ok || endpoint.ProviderIP() == nil {
+		return errors.New("connection does not support multi-hop: provider address is unknown")
+	}
+
+	providerIP := endpoint.ProviderIP()
+	if err := routeThroughInterface(providerIP, entryInterface); err != nil {
+		return err
+	}
+	m.addCleanup(func() error {
+		log.Trace().Msg("Cleaning: removing route through entry hop")
+		defer log.Trace().Msg("Cleaning: removing route through entry hop DONE")
+		return deleteRouteThroughInterface(providerIP, entryInterface)
+	})
+	return nil
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/mocks"
+)
+
+type hopManagerFake struct {
+	connectErr error
+	iface      string
+
+	lock   sync.Mutex
+	status connectionstate.Status
+	params ConnectParams
+}
+
+func (h *hopManagerFake) Connect(consumerID identity.Identity, _ common.Address, proposal market.ServiceProposal, params ConnectParams) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.connectErr != nil {
+		return h.connectErr
+	}
+	h.params = params
+	h.status = connectionstate.Status{ConsumerID: consumerID, Proposal: proposal, State: connectionstate.Connected, InterfaceName: h.iface}
+	return nil
+}
+
+func (h *hopManagerFake) Status() connectionstate.Status {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.status
+}
+
+func (h *hopManagerFake) Disconnect() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.status.State == connectionstate.NotConnected {
+		return ErrNoConnection
+	}
+	h.status = connectionstate.Status{State: connectionstate.NotConnected}
+	return nil
+}
+
+func (h *hopManagerFake) CheckChannel(context.Context) error { return nil }
+
+func (h *hopManagerFake) Reconnect() {}
+
+func (h *hopManagerFake) Rebind(context.Context) error { return nil }
+
+func (h *hopManagerFake) UpdateSplitTunnel(SplitTunnel) error { return nil }
+
+func (h *hopManagerFake) SetMaxBandwidth(datasize.BitSpeed) error { return nil }
+
+func (h *hopManagerFake) Pause() error { return nil }
+
+func (h *hopManagerFake) Resume() error { return nil }
+
+func newHopManagerFake() *hopManagerFake {
+	return &hopManagerFake{iface: "wg0", status: connectionstate.Status{State: connectionstate.NotConnected}}
+}
+
+func TestMultiHopManager_ConnectsSingleHopWithoutEntryProposal(t *testing.T) {
+	entry, exit := newHopManagerFake(), newHopManagerFake()
+	manager := NewMultiHopManager(entry, exit)
+
+	assert.NoError(t, manager.Connect(consumerID, hermesID, activeProposal, ConnectParams{}))
+
+	assert.Equal(t, connectionstate.NotConnected, entry.Status().State)
+	assert.Equal(t, connectionstate.Connected, manager.Status().State)
+	assert.Nil(t, manager.Status().EntryProposal)
+}
+
+func TestMultiHopManager_ConnectsBothHops(t *testing.T) {
+	entry, exit := newHopManagerFake(), newHopManagerFake()
+	manager := NewMultiHopManager(entry, exit)
+	entryProposal := market.ServiceProposal{ProviderID: "entry-node", ServiceType: activeServiceType}
+
+	err := manager.Connect(consumerID, hermesID, activeProposal, ConnectParams{EntryProposal: &entryProposal, DNS: DNSOptionProvider})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "entry-node", entry.Status().Proposal.ProviderID)
+	assert.True(t, entry.params.DisableKillSwitch)
+	assert.True(t, entry.params.KeepDefaultRoute)
+	assert.Nil(t, exit.params.EntryProposal)
+	assert.Equal(t, "wg0", exit.params.EntryInterface)
+	assert.False(t, exit.params.KeepDefaultRoute)
+	assert.Equal(t, DNSOptionProvider, exit.params.DNS)
+
+	status := manager.Status()
+	assert.Equal(t, connectionstate.Connected, status.State)
+	assert.Equal(t, activeProposal.ProviderID, status.Proposal.ProviderID)
+	assert.Equal(t, &entryProposal, status.EntryProposal)
+
+	assert.NoError(t, manager.Disconnect())
+	assert.Equal(t, connectionstate.NotConnected, entry.Status().State)
+	assert.Equal(t, connectionstate.NotConnected, exit.Status().State)
+	assert.Equal(t, ErrNoConnection, manager.Disconnect())
+}
+
+func TestMultiHopManager_DisconnectsEntryHopWhenExitHopFails(t *testing.T) {
+	entry, exit := newHopManagerFake(), newHopManagerFake()
+	exit.connectErr = errors.New("exit failed")
+	manager := NewMultiHopManager(entry, exit)
+	entryProposal := market.ServiceProposal{ProviderID: "entry-node"}
+
+	err := manager.Connect(consumerID, hermesID, activeProposal, ConnectParams{EntryProposal: &entryProposal})
+	assert.EqualError(t, err, "exit failed")
+
+	assert.Equal(t, connectionstate.NotConnected, entry.Status().State)
+	assert.Equal(t, connectionstate.NotConnected, manager.Status().State)
+}
+
+func TestMultiHopManager_FailsWithoutEntryHopInterface(t *testing.T) {
+	entry, exit := newHopManagerFake(), newHopManagerFake()
+	entry.iface = ""
+	manager := NewMultiHopManager(entry, exit)
+	entryProposal := market.ServiceProposal{ProviderID: "entry-node"}
+
+	err := manager.Connect(consumerID, hermesID, activeProposal, ConnectParams{EntryProposal: &entryProposal})
+	assert.Error(t, err)
+
+	assert.Equal(t, connectionstate.NotConnected, entry.Status().State)
+	assert.Equal(t, connectionstate.NotConnected, exit.Status().State)
+}
+
+func TestMultiHopManager_DisconnectsEntryHopWhenExitHopIsGone(t *testing.T) {
+	entry, exit := newHopManagerFake(), newHopManagerFake()
+	manager := NewMultiHopManager(entry, exit)
+	entryProposal := market.ServiceProposal{ProviderID: "entry-node"}
+	assert.NoError(t, manager.Connect(consumerID, hermesID, activeProposal, ConnectParams{EntryProposal: &entryProposal}))
+
+	assert.NoError(t, exit.Disconnect())
+	manager.handleExitStateEvent(connectionstate.AppEventConnectionState{State: connectionstate.NotConnected})
+
+	assert.Equal(t, connectionstate.NotConnected, entry.Status().State)
+	assert.Nil(t, manager.Status().EntryProposal)
+}
+
+func TestEntryHopEventBus_PublishesConnectionEventsUnderEntryHopTopics(t *testing.T) {
+	bus := mocks.NewEventBus()
+	entryBus := NewEntryHopEventBus(bus)
+
+	entryBus.Publish(connectionstate.AppTopicConnectionState, connectionstate.AppEventConnectionState{State: connectionstate.Connected})
+	history := bus.GetEventHistory()
+	assert.Len(t, history, 1)
+	assert.Equal(t, EntryHopTopicPrefix+connectionstate.AppTopicConnectionState, history[0].Topic)
+}
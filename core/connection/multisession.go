@@ -0,0 +1,355 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	pingpongEvent "github.com/mysteriumnetwork/node/session/pingpong/event"
+	"github.com/mysteriumnetwork/payments/crypto"
+)
+
+// AdditionalSessionTopicPrefix prefixes topics of connection events published by additional consumer sessions
+const AdditionalSessionTopicPrefix = "AdditionalSession"
+
+var (
+	// ErrSessionNotFound error indicates that there is no additional session with the given name
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionNameRequired error indicates that additional session was requested without a name
+	ErrSessionNameRequired = errors.New("session name is required")
+)
+
+// ManagerFactory creates connection manager publishing connection events to the given event bus.
+// Managers of additional sessions subscribed to events of the multi-session manager bus
+// get unsubscribed from it once their session is disconnected.
+type ManagerFactory func(bus eventbus.EventBus) (Manager, error)
+
+// eventUnsubscriber is implemented by managers subscribed to events when created.
+type eventUnsubscriber interface {
+	Unsubscribe(bus eventbus.Subscriber) error
+}
+
+// SessionStatus holds status, latest statistics and payments of an additional session
+type SessionStatus struct {
+	Status     connectionstate.Status
+	Statistics connectionstate.Statistics
+	Invoice    crypto.Invoice
+}
+
+// MultiSessionManager keeps additional consumer sessions alive alongside the main connection.
+// Every additional session has its own connection manager, so it pays for itself and reports its own statistics.
+// Additional sessions leave default route, kill switch and DNS to the main connection:
+// their tunnels carry only traffic bound to their interfaces.
+// Main connection switched to the new provider is served by standby manager until it gets disconnected.
+type MultiSessionManager struct {
+	base       Manager
+	bus        eventbus.EventBus
+	newManager ManagerFactory
+	newStandby ManagerFactory
+
+	switchLock sync.Mutex
+
+	lock     sync.Mutex
+	sessions map[string]*additionalSession
+	switched *standbyManager
+	spares   []*standbyManager
+}
+
+type additionalSession struct {
+	manager Manager
+
+	lock    sync.Mutex
+	stats   connectionstate.Statistics
+	invoice crypto.Invoice
+}
+
+// NewMultiSessionManager creates connection manager which serves the main connection with the given manager
+// and creates a separate manager for every additional session and for connection switches.
+func NewMultiSessionManager(main Manager, bus eventbus.EventBus, newManager, newStandby ManagerFactory) *MultiSessionManager {
+	return &MultiSessionManager{
+		base:       main,
+		bus:        bus,
+		newManager: newManager,
+		newStandby: newStandby,
+		sessions:   make(map[string]*additionalSession),
+	}
+}
+
+// Connect connects the main connection, reports error if it is already connected.
+func (m *MultiSessionManager) Connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams) error {
+	m.lock.Lock()
+	// Switched connection is gone, so the main manager takes over the next connection again.
+	if m.switched != nil && m.switched.Status().State == connectionstate.NotConnected {
+		m.switched.bus.demote()
+		m.spares = append(m.spares, m.switched)
+		m.switched = nil
+	}
+	m.lock.Unlock()
+
+	return m.mainManager().Connect(consumerID, hermesID, proposal, params)
+}
+
+// Status returns status of the main connection.
+func (m *MultiSessionManager) Status() connectionstate.Status {
+	return m.mainManager().Status()
+}
+
+// Disconnect disconnects the main connection.
+func (m *MultiSessionManager) Disconnect() error {
+	return m.mainManager().Disconnect()
+}
+
+// CheckChannel checks channel of the main connection.
+func (m *MultiSessionManager) CheckChannel(ctx context.Context) error {
+	return m.mainManager().CheckChannel(ctx)
+}
+
+// Reconnect reconnects the main connection.
+func (m *MultiSessionManager) Reconnect() {
+	m.mainManager().Reconnect()
+}
+
+// Rebind rebinds channel of the main connection.
+func (m *MultiSessionManager) Rebind(ctx context.Context) error {
+	return m.mainManager().Rebind(ctx)
+}
+
+// UpdateSplitTunnel updates split tunnel rules of the main connection.
+func (m *MultiSessionManager) UpdateSplitTunnel(splitTunnel SplitTunnel) error {
+	return m.mainManager().UpdateSplitTunnel(splitTunnel)
+}
+
+// SetMaxBandwidth limits speed of the main connection.
+func (m *MultiSessionManager) SetMaxBandwidth(limit datasize.BitSpeed) error {
+	return m.mainManager().SetMaxBandwidth(limit)
+}
+
+// Pause pauses the main connection.
+func (m *MultiSessionManager) Pause() error {
+	return m.mainManager().Pause()
+}
+
+// Resume resumes the main connection.
+func (m *MultiSessionManager) Resume() error {
+	return m.mainManager().Resume()
+}
+
+func (m *MultiSessionManager) mainManager() Manager {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.switched != nil {
+		return m.switched
+	}
+	return m.base
+}
+
+// ConnectSession connects additional session with the given name, reports error if the session is already connected.
+func (m *MultiSessionManager) ConnectSession(name string, consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams) error {
+	if name == "" {
+		return ErrSessionNameRequired
+	}
+
+	session, err := m.session(name)
+	if err != nil {
+		return err
+	}
+
+	params.DisableKillSwitch = true
+	params.DNS = DNSOptionSystem
+	params.KeepDefaultRoute = true
+	params.SplitTunnel = SplitTunnel{}
+	params.EntryProposal = nil
+
+	session.reset()
+	return session.manager.Connect(consumerID, hermesID, proposal, params)
+}
+
+func (m *MultiSessionManager) session(name string) (*additionalSession, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if session, ok := m.sessions[name]; ok {
+		return session, nil
+	}
+
+	session := &additionalSession{}
+	manager, err := m.newManager(&sessionEventBus{
+		EventBus: &topicPrefixEventBus{EventBus: m.bus, prefix: AdditionalSessionTopicPrefix},
+		session:  session,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	session.manager = manager
+	m.sessions[name] = session
+	return session, nil
+}
+
+// DisconnectSession disconnects additional session with the given name and forgets it.
+func (m *MultiSessionManager) DisconnectSession(name string) error {
+	m.lock.Lock()
+	session, ok := m.sessions[name]
+	m.lock.Unlock()
+
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	err := session.manager.Disconnect()
+	if err != nil && err != ErrNoConnection {
+		return err
+	}
+	m.removeSession(name, session)
+	return err
+}
+
+// removeSession forgets disconnected session and releases its manager.
+func (m *MultiSessionManager) removeSession(name string, session *additionalSession) {
+	m.lock.Lock()
+	if m.sessions[name] == session {
+		delete(m.sessions, name)
+	}
+	m.lock.Unlock()
+
+	if unsubscriber, ok := session.manager.(eventUnsubscriber); ok {
+		if err := unsubscriber.Unsubscribe(m.bus); err != nil {
+			log.Warn().Err(err).Msgf("Could not unsubscribe manager of additional session %s", name)
+		}
+	}
+}
+
+// SessionStatus returns status of additional session with the given name.
+func (m *MultiSessionManager) SessionStatus(name string) (SessionStatus, error) {
+	m.lock.Lock()
+	session, ok := m.sessions[name]
+	m.lock.Unlock()
+
+	if !ok {
+		return SessionStatus{}, ErrSessionNotFound
+	}
+	return session.status(), nil
+}
+
+// Sessions returns statuses of all additional sessions by their names.
+func (m *MultiSessionManager) Sessions() map[string]SessionStatus {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	statuses := make(map[string]SessionStatus, len(m.sessions))
+	for name, session := range m.sessions {
+		statuses[name] = session.status()
+	}
+	return statuses
+}
+
+// DisconnectAll disconnects and forgets all additional sessions, main connection is left intact.
+func (m *MultiSessionManager) DisconnectAll() {
+	m.lock.Lock()
+	sessions := make(map[string]*additionalSession, len(m.sessions))
+	for name, session := range m.sessions {
+		sessions[name] = session
+	}
+	m.lock.Unlock()
+
+	for name, session := range sessions {
+		if err := session.manager.Disconnect(); err != nil && err != ErrNoConnection {
+			log.Error().Err(err).Msg("Could not disconnect additional session")
+			continue
+		}
+		m.removeSession(name, session)
+	}
+}
+
+// Subscribe subscribes to payment events to keep track of additional sessions payments.
+func (m *MultiSessionManager) Subscribe(bus eventbus.Subscriber) error {
+	return bus.SubscribeAsync(pingpongEvent.AppTopicInvoicePaid, m.consumeInvoicePaidEvent)
+}
+
+func (m *MultiSessionManager) consumeInvoicePaidEvent(e pingpongEvent.AppEventInvoicePaid) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, session := range m.sessions {
+		if string(session.manager.Status().SessionID) == e.SessionID {
+			session.setInvoice(e.Invoice)
+			return
+		}
+	}
+}
+
+func (s *additionalSession) status() SessionStatus {
+	status := s.manager.Status()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return SessionStatus{
+		Status:     status,
+		Statistics: s.stats,
+		Invoice:    s.invoice,
+	}
+}
+
+func (s *additionalSession) setStatistics(stats connectionstate.Statistics) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stats = stats
+}
+
+func (s *additionalSession) setInvoice(invoice crypto.Invoice) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.invoice = invoice
+}
+
+func (s *additionalSession) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stats = connectionstate.Statistics{}
+	s.invoice = crypto.Invoice{}
+}
+
+// sessionEventBus keeps the latest statistics of an additional session while publishing its events.
+type sessionEventBus struct {
+	eventbus.EventBus
+
+	session *additionalSession
+}
+
+// Publish records statistics events before publishing them
+func (b *sessionEventBus) Publish(topic string, data interface{}) {
+	if e, ok := data.(connectionstate.AppEventConnectionStatistics); ok {
+		b.session.setStatistics(e.Stats)
+	}
+	b.EventBus.Publish(topic, data)
+}
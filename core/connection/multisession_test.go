@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/mocks"
+	pingpongEvent "github.com/mysteriumnetwork/node/session/pingpong/event"
+	"github.com/mysteriumnetwork/payments/crypto"
+)
+
+type sessionManagerFake struct {
+	*hopManagerFake
+	unsubscribed bool
+}
+
+func (f *sessionManagerFake) Unsubscribe(bus eventbus.Subscriber) error {
+	f.unsubscribed = true
+	return nil
+}
+
+type sessionManagersFake struct {
+	managers []*sessionManagerFake
+	buses    []eventbus.EventBus
+}
+
+func (f *sessionManagersFake) newManager(bus eventbus.EventBus) (Manager, error) {
+	manager := &sessionManagerFake{hopManagerFake: newHopManagerFake()}
+	f.managers = append(f.managers, manager)
+	f.buses = append(f.buses, bus)
+	return manager, nil
+}
+
+func TestMultiSessionManager_ConnectsSessionsIndependently(t *testing.T) {
+	main := newHopManagerFake()
+	factory := &sessionManagersFake{}
+	manager := NewMultiSessionManager(main, mocks.NewEventBus(), factory.newManager, factory.newManager)
+	otherProposal := market.ServiceProposal{ProviderID: "other-node", ServiceType: activeServiceType}
+
+	assert.NoError(t, manager.Connect(consumerID, hermesID, activeProposal, ConnectParams{}))
+	assert.NoError(t, manager.ConnectSession("first", consumerID, hermesID, activeProposal, ConnectParams{DNS: DNSOptionProvider}))
+	assert.NoError(t, manager.ConnectSession("second", consumerID, hermesID, otherProposal, ConnectParams{}))
+
+	assert.Len(t, factory.managers, 2)
+	assert.Equal(t, connectionstate.Connected, main.Status().State)
+	for _, session := range factory.managers {
+		assert.True(t, session.params.DisableKillSwitch)
+		assert.True(t, session.params.KeepDefaultRoute)
+		assert.Equal(t, DNSOptionSystem, session.params.DNS)
+	}
+
+	sessions := manager.Sessions()
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, "other-node", sessions["second"].Status.Proposal.ProviderID)
+
+	assert.NoError(t, manager.DisconnectSession("first"))
+	assert.Equal(t, connectionstate.NotConnected, factory.managers[0].Status().State)
+	assert.Equal(t, connectionstate.Connected, factory.managers[1].Status().State)
+	assert.Equal(t, connectionstate.Connected, manager.Status().State)
+}
+
+func TestMultiSessionManager_ReusesManagerOfConnectedSession(t *testing.T) {
+	factory := &sessionManagersFake{}
+	manager := NewMultiSessionManager(newHopManagerFake(), mocks.NewEventBus(), factory.newManager, factory.newManager)
+
+	assert.NoError(t, manager.ConnectSession("first", consumerID, hermesID, activeProposal, ConnectParams{}))
+	assert.NoError(t, manager.ConnectSession("first", consumerID, hermesID, activeProposal, ConnectParams{}))
+	assert.Len(t, factory.managers, 1)
+}
+
+func TestMultiSessionManager_ForgetsDisconnectedSessions(t *testing.T) {
+	factory := &sessionManagersFake{}
+	manager := NewMultiSessionManager(newHopManagerFake(), mocks.NewEventBus(), factory.newManager, factory.newManager)
+
+	assert.NoError(t, manager.ConnectSession("first", consumerID, hermesID, activeProposal, ConnectParams{}))
+	assert.NoError(t, manager.DisconnectSession("first"))
+	assert.True(t, factory.managers[0].unsubscribed)
+	assert.Empty(t, manager.Sessions())
+	assert.Equal(t, ErrSessionNotFound, manager.DisconnectSession("first"))
+
+	assert.NoError(t, manager.ConnectSession("first", consumerID, hermesID, activeProposal, ConnectParams{}))
+	assert.NoError(t, manager.ConnectSession("second", consumerID, hermesID, activeProposal, ConnectParams{}))
+	manager.DisconnectAll()
+	assert.Len(t, factory.managers, 3)
+	for _, session := range factory.managers {
+		assert.True(t, session.unsubscribed)
+	}
+	assert.Empty(t, manager.Sessions())
+}
+
+func TestMultiSessionManager_RejectsUnknownSessions(t *testing.T) {
+	manager := NewMultiSessionManager(newHopManagerFake(), mocks.NewEventBus(), (&sessionManagersFake{}).newManager, (&sessionManagersFake{}).newManager)
+
+	assert.Equal(t, ErrSessionNameRequired, manager.ConnectSession("", consumerID, hermesID, activeProposal, ConnectParams{}))
+	assert.Equal(t, ErrSessionNotFound, manager.DisconnectSession("unknown"))
+	_, err := manager.SessionStatus("unknown")
+	assert.Equal(t, ErrSessionNotFound, err)
+}
+
+func TestMultiSessionManager_KeepsStatisticsOfSession(t *testing.T) {
+	bus := mocks.NewEventBus()
+	factory := &sessionManagersFake{}
+	manager := NewMultiSessionManager(newHopManagerFake(), bus, factory.newManager, factory.newManager)
+	assert.NoError(t, manager.ConnectSession("first", consumerID, hermesID, activeProposal, ConnectParams{}))
+
+	stats := connectionstate.Statistics{BytesSent: 10, BytesReceived: 20}
+	factory.buses[0].Publish(connectionstate.AppTopicConnectionStatistics, connectionstate.AppEventConnectionStatistics{Stats: stats})
+
+	status, err := manager.SessionStatus("first")
+	assert.NoError(t, err)
+	assert.Equal(t, stats, status.Statistics)
+
+	history := bus.GetEventHistory()
+	assert.Len(t, history, 1)
+	assert.Equal(t, AdditionalSessionTopicPrefix+connectionstate.AppTopicConnectionStatistics, history[0].Topic)
+}
+
+func TestMultiSessionManager_KeepsPaymentsOfSession(t *testing.T) {
+	factory := &sessionManagersFake{}
+	manager := NewMultiSessionManager(newHopManagerFake(), mocks.NewEventBus(), factory.newManager, factory.newManager)
+	assert.NoError(t, manager.ConnectSession("first", consumerID, hermesID, activeProposal, ConnectParams{}))
+	factory.managers[0].status.SessionID = "session-1"
+
+	manager.consumeInvoicePaidEvent(pingpongEvent.AppEventInvoicePaid{SessionID: "other-session", Invoice: crypto.Invoice{AgreementTotal: big.NewInt(5)}})
+	manager.consumeInvoicePaidEvent(pingpongEvent.AppEventInvoicePaid{SessionID: "session-1", Invoice: crypto.Invoice{AgreementTotal: big.NewInt(10)}})
+
+	status, err := manager.SessionStatus("first")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(10), status.Invoice.AgreementTotal)
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/firewall"
+)
+
+// ErrPauseNotSupported error indicates that connection can not route traffic around the tunnel while keeping it up
+var ErrPauseNotSupported = errors.New("connection does not support pause")
+
+// pausedNetworks are allowed through the kill switch while connection is paused
+var pausedNetworks = []string{"0.0.0.0/0", "::/0"}
+
+// DefaultRouteReleaser is implemented by connections able to route traffic around the tunnel while it stays up.
+// Traffic is routed back into the tunnel with DefaultRouteTaker.
+type DefaultRouteReleaser interface {
+	ReleaseDefaultRoute() error
+}
+
+// Pause routes consumer traffic around the tunnel and lifts the kill switch.
+// Tunnel, session and payments are kept alive, so that Resume brings traffic back into the tunnel without reconnecting.
+func (m *connectionManager) Pause() error {
+	m.pauseLock.Lock()
+	defer m.pauseLock.Unlock()
+
+	status := m.Status()
+	if status.State != connectionstate.Connected {
+		return ErrNoConnection
+	}
+	if status.Paused {
+		return nil
+	}
+
+	m.statusLock.RLock()
+	releaser, ok := m.routeTaker.(DefaultRouteReleaser)
+	m.statusLock.RUnlock()
+	// Connection which keeps default route intact carries no host traffic to pause.
+	if !ok || m.connectOptions.Params.KeepDefaultRoute {
+		return ErrPauseNotSupported
+	}
+
+	if !m.connectOptions.Params.DisableKillSwitch {
+		for _, network := range pausedNetworks {
+			removeRule, err := firewall.AllowIPAccess(network)
+			if err != nil {
+				m.removePauseRules()
+				return err
+			}
+			m.pauseRules = append(m.pauseRules, removeRule)
+		}
+	}
+	if err := releaser.ReleaseDefaultRoute(); err != nil {
+		m.removePauseRules()
+		return err
+	}
+	m.addCleanup(func() error {
+		log.Trace().Msg("Cleaning: pause traffic block exceptions")
+		defer log.Trace().Msg("Cleaning: pause traffic block exceptions DONE")
+		m.pauseLock.Lock()
+		defer m.pauseLock.Unlock()
+
+		m.removePauseRules()
+		m.setStatus(func(status *connectionstate.Status) {
+			status.Paused = false
+		})
+		return nil
+	})
+
+	log.Info().Msg("Connection paused, traffic is routed around the tunnel")
+	m.setStatus(func(status *connectionstate.Status) {
+		status.Paused = true
+	})
+	return nil
+}
+
+// Resume routes consumer traffic back into the tunnel of the paused connection and restores the kill switch.
+func (m *connectionManager) Resume() error {
+	m.pauseLock.Lock()
+	defer m.pauseLock.Unlock()
+
+	status := m.Status()
+	if status.State != connectionstate.Connected {
+		return ErrNoConnection
+	}
+	if !status.Paused {
+		return nil
+	}
+
+	m.statusLock.RLock()
+	taker := m.routeTaker
+	m.statusLock.RUnlock()
+	if taker == nil {
+		return ErrNoConnection
+	}
+
+	if err := taker.TakeDefaultRoute(); err != nil {
+		return err
+	}
+	m.removePauseRules()
+
+	log.Info().Msg("Connection resumed")
+	m.setStatus(func(status *connectionstate.Status) {
+		status.Paused = false
+	})
+	return nil
+}
+
+// removePauseRules restores the kill switch lifted by pause, must be called with pause lock held.
+func (m *connectionManager) removePauseRules() {
+	for _, removeRule := range m.pauseRules {
+		removeRule()
+	}
+	m.pauseRules = nil
+}
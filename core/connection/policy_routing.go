@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/mysteriumnetwork/node/utils/netutil"
+)
+
+// ErrPolicyRoutingUnsupported indicates that policy routing is not available on this platform
+var ErrPolicyRoutingUnsupported = errors.New("policy routing is supported on Linux only")
+
+// Routing tables reserved by the kernel: default, main and local.
+const (
+	reservedTableMin = 253
+	reservedTableMax = 255
+)
+
+var (
+	addPolicyRoute    = netutil.AddPolicyRoute
+	deletePolicyRoute = netutil.DeletePolicyRoute
+)
+
+// PolicyRouting installs the tunnel into a dedicated routing table, so that only traffic marked
+// with the firewall mark uses it. Default route, kill switch and DNS of the host are left untouched.
+type PolicyRouting struct {
+	// FwMark selects traffic routed through the tunnel, zero value disables policy routing
+	FwMark uint32
+	// Table holds the tunnel route, defaults to the value of firewall mark
+	Table int
+}
+
+// Enabled checks if tunnel should carry marked traffic only
+func (pr PolicyRouting) Enabled() bool {
+	return pr.FwMark != 0
+}
+
+// Validate validates policy routing options
+func (pr PolicyRouting) Validate() error {
+	if !pr.Enabled() {
+		if pr.Table != 0 {
+			return errors.New("policy routing table requires firewall mark")
+		}
+		return nil
+	}
+
+	if runtime.GOOS != "linux" {
+		return ErrPolicyRoutingUnsupported
+	}
+	if table := pr.table(); table <= 0 || (table >= reservedTableMin && table <= reservedTableMax) {
+		return fmt.Errorf("invalid policy routing table %d", table)
+	}
+	return nil
+}
+
+func (pr PolicyRouting) table() int {
+	if pr.Table != 0 {
+		return pr.Table
+	}
+	return int(pr.FwMark)
+}
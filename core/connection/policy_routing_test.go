@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyRouting_Validate(t *testing.T) {
+	assert.NoError(t, PolicyRouting{}.Validate())
+	assert.EqualError(t, PolicyRouting{Table: 100}.Validate(), "policy routing table requires firewall mark")
+
+	if runtime.GOOS != "linux" {
+		assert.Equal(t, ErrPolicyRoutingUnsupported, PolicyRouting{FwMark: 1}.Validate())
+		return
+	}
+	assert.NoError(t, PolicyRouting{FwMark: 51820}.Validate())
+	assert.NoError(t, PolicyRouting{FwMark: 51820, Table: 100}.Validate())
+	assert.EqualError(t, PolicyRouting{FwMark: 254}.Validate(), "invalid policy routing table 254")
+	assert.EqualError(t, PolicyRouting{FwMark: 1, Table: -1}.Validate(), "invalid policy routing table -1")
+}
+
+func TestPolicyRouting_TableDefaultsToMark(t *testing.T) {
+	assert.Equal(t, 51820, PolicyRouting{FwMark: 51820}.table())
+	assert.Equal(t, 100, PolicyRouting{FwMark: 51820, Table: 100}.table())
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/p2p"
+	"github.com/mysteriumnetwork/node/trace"
+)
+
+// ProbeConfig describes probing of candidate providers before connecting, zero value disables probing.
+type ProbeConfig struct {
+	// Candidates is count of candidates, the proposal and its fallbacks, to probe
+	Candidates int
+	// Timeout limits probing of all candidates
+	Timeout time.Duration
+}
+
+// probeCandidates probes up to the configured count of candidates and orders them by probe latency,
+// so that connection is attempted to the fastest responder first. Candidates which were not probed keep their order.
+func (m *connectionManager) probeCandidates(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams) (market.ServiceProposal, ConnectParams, error) {
+	count := m.config.Probe.Candidates
+	if params.ProbeCandidates != 0 {
+		count = params.ProbeCandidates
+	}
+	candidates := append([]market.ServiceProposal{proposal}, params.FallbackProposals...)
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+	if count < 2 {
+		m.setProbes(nil)
+		return proposal, params, nil
+	}
+
+	if !m.statusProbing(consumerID, hermesID, proposal) {
+		return proposal, params, ErrAlreadyExists
+	}
+
+	results := m.probe(consumerID, candidates[:count])
+	if results == nil {
+		m.statusNotConnected()
+		return proposal, params, ErrConnectionCancelled
+	}
+
+	probed := make([]int, count)
+	for i := range probed {
+		probed[i] = i
+	}
+	sort.SliceStable(probed, func(i, j int) bool {
+		a, b := results[probed[i]], results[probed[j]]
+		if (a.Error == "") != (b.Error == "") {
+			return a.Error == ""
+		}
+		return a.Error == "" && a.Latency < b.Latency
+	})
+
+	ordered := make([]market.ServiceProposal, 0, len(candidates))
+	for _, i := range probed {
+		ordered = append(ordered, candidates[i])
+	}
+	ordered = append(ordered, candidates[count:]...)
+	if ordered[0].ProviderID != proposal.ProviderID {
+		log.Info().Msgf("Provider %s responded to the probe first, connecting to it", ordered[0].ProviderID)
+	}
+
+	params.FallbackProposals = ordered[1:]
+	return ordered[0], params, nil
+}
+
+// probe probes given candidates concurrently and publishes results to the connection status as they come.
+// It returns nil if probing was cancelled by disconnect.
+func (m *connectionManager) probe(consumerID identity.Identity, candidates []market.ServiceProposal) []connectionstate.ProbeResult {
+	ctx, cancel := withTimeout(context.Background(), m.config.Probe.Timeout)
+	defer cancel()
+
+	cancelled := false
+	m.retryLock.Lock()
+	m.retryCancel = func() {
+		cancelled = true
+		cancel()
+	}
+	m.retryLock.Unlock()
+	defer func() {
+		m.retryLock.Lock()
+		m.retryCancel = nil
+		m.retryLock.Unlock()
+	}()
+
+	results := make([]connectionstate.ProbeResult, len(candidates))
+	var wg sync.WaitGroup
+	for i, candidate := range candidates {
+		wg.Add(1)
+		go func(i int, candidate market.ServiceProposal) {
+			defer wg.Done()
+			results[i] = m.probeProvider(ctx, consumerID, candidate)
+			m.addProbe(results[i])
+		}(i, candidate)
+	}
+	wg.Wait()
+
+	m.retryLock.Lock()
+	defer m.retryLock.Unlock()
+	if cancelled {
+		return nil
+	}
+	return results
+}
+
+// probeProvider dials provider through p2p and measures round trip time of the probe echo.
+func (m *connectionManager) probeProvider(ctx context.Context, consumerID identity.Identity, proposal market.ServiceProposal) connectionstate.ProbeResult {
+	result := connectionstate.ProbeResult{ProviderID: proposal.ProviderID}
+
+	latency, err := probeLatency(ctx, m.p2pDialer, consumerID, proposal)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Latency = latency
+	return result
+}
+
+func probeLatency(ctx context.Context, dialer p2p.Dialer, consumerID identity.Identity, proposal market.ServiceProposal) (time.Duration, error) {
+	contactDef, err := p2p.ParseContact(proposal.ProviderContacts)
+	if err != nil {
+		return 0, err
+	}
+
+	channel, err := dialer.Dial(ctx, consumerID, identity.FromAddress(proposal.ProviderID), proposal.ServiceKey(), contactDef, trace.NewTracer("Consumer probe"))
+	if err != nil {
+		return 0, err
+	}
+	defer channel.Close()
+
+	start := time.Now()
+	// Providers not knowing the probe still reply to it, which is good enough for measuring latency.
+	if _, err := channel.Send(ctx, p2p.TopicProbe, &p2p.Message{}); err != nil && !errors.Is(err, p2p.ErrHandlerNotFound) {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+func (m *connectionManager) statusProbing(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal) bool {
+	probing := false
+	m.setStatus(func(status *connectionstate.Status) {
+		if status.State != connectionstate.NotConnected {
+			return
+		}
+		probing = true
+		m.probes = []connectionstate.ProbeResult{}
+		*status = connectionstate.Status{
+			StartedAt:        m.timeGetter(),
+			ConsumerID:       consumerID,
+			ConsumerLocation: m.locationResolver.GetOrigin(),
+			HermesID:         hermesID,
+			Proposal:         proposal,
+			State:            connectionstate.Probing,
+			Probes:           m.probes,
+		}
+	})
+	return probing
+}
+
+func (m *connectionManager) addProbe(result connectionstate.ProbeResult) {
+	m.setStatus(func(status *connectionstate.Status) {
+		probes := make([]connectionstate.ProbeResult, len(m.probes), len(m.probes)+1)
+		copy(probes, m.probes)
+		m.probes = append(probes, result)
+		status.Probes = m.probes
+	})
+}
+
+func (m *connectionManager) setProbes(probes []connectionstate.ProbeResult) {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+
+	m.probes = probes
+}
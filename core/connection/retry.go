@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// RetryPolicy describes how failed connect attempts to the same provider are retried, zero value disables retries.
+type RetryPolicy struct {
+	// Attempts is count of connect attempts to each provider
+	Attempts int
+	// Backoff is delay before the second attempt, it is doubled before each next attempt
+	Backoff time.Duration
+	// Jitter randomizes delays by up to the given fraction of the delay, from 0 to 1
+	Jitter float64
+}
+
+// Validate checks if retry policy is well formed
+func (p RetryPolicy) Validate() error {
+	if p.Attempts < 0 {
+		return fmt.Errorf("connect attempts can not be negative: %d", p.Attempts)
+	}
+	if p.Backoff < 0 {
+		return fmt.Errorf("connect retry backoff can not be negative: %s", p.Backoff)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("connect retry jitter must be between 0 and 1: %v", p.Jitter)
+	}
+	return nil
+}
+
+// delay returns how long to wait after the given failed attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.Backoff << uint(attempt-1)
+	if p.Jitter > 0 {
+		delay += time.Duration((2*rand.Float64() - 1) * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// isRetryAllowed tells if connect error may be transient, so that connecting to the same provider again could succeed.
+func isRetryAllowed(err error) bool {
+	switch {
+	case !isFailoverAllowed(err),
+		errors.Is(err, ErrInsufficientBalance),
+		errors.Is(err, ErrUnsupportedServiceType):
+		return false
+	}
+	return true
+}
+
+// connectWithRetry connects to given proposal, retrying transient failures according to the retry policy.
+func (m *connectionManager) connectWithRetry(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams, failoverFrom identity.Identity) error {
+	policy := m.config.Retry
+	if params.Retry != nil {
+		policy = *params.Retry
+	}
+
+	err := m.connect(consumerID, hermesID, proposal, params, failoverFrom)
+	for attempt := 1; err != nil && attempt < policy.Attempts && isRetryAllowed(err); attempt++ {
+		delay := policy.delay(attempt)
+		log.Warn().Err(err).Msgf("Connection to provider %s failed, retrying in %s (attempt %d of %d)", proposal.ProviderID, delay, attempt+1, policy.Attempts)
+		if !m.waitRetry(delay) {
+			return ErrConnectionCancelled
+		}
+		err = m.connect(consumerID, hermesID, proposal, params, failoverFrom)
+	}
+	return err
+}
+
+// waitRetry waits before the next connect attempt, returns false if connect was cancelled meanwhile.
+func (m *connectionManager) waitRetry(delay time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), delay)
+	defer cancel()
+
+	m.retryLock.Lock()
+	m.retryCancel = cancel
+	m.retryLock.Unlock()
+	defer func() {
+		m.retryLock.Lock()
+		m.retryCancel = nil
+		m.retryLock.Unlock()
+	}()
+
+	<-ctx.Done()
+	return ctx.Err() == context.DeadlineExceeded
+}
+
+// cancelRetry stops waiting for the next connect attempt or probing of candidates, returns false if there was nothing to wait for.
+func (m *connectionManager) cancelRetry() bool {
+	m.retryLock.Lock()
+	defer m.retryLock.Unlock()
+
+	if m.retryCancel == nil {
+		return false
+	}
+	m.retryCancel()
+	m.retryCancel = nil
+	return true
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RetryPolicy_DelayDoublesWithEachAttempt(t *testing.T) {
+	policy := RetryPolicy{Attempts: 4, Backoff: time.Second}
+
+	assert.Equal(t, time.Second, policy.delay(1))
+	assert.Equal(t, 2*time.Second, policy.delay(2))
+	assert.Equal(t, 4*time.Second, policy.delay(3))
+}
+
+func Test_RetryPolicy_DelayIsJittered(t *testing.T) {
+	policy := RetryPolicy{Attempts: 2, Backoff: time.Second, Jitter: 0.2}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.delay(2)
+		assert.True(t, delay >= 1600*time.Millisecond && delay <= 2400*time.Millisecond, delay)
+	}
+}
+
+func Test_RetryPolicy_Validate(t *testing.T) {
+	assert.NoError(t, RetryPolicy{}.Validate())
+	assert.NoError(t, RetryPolicy{Attempts: 3, Backoff: time.Second, Jitter: 1}.Validate())
+	assert.EqualError(t, RetryPolicy{Attempts: -1}.Validate(), "connect attempts can not be negative: -1")
+	assert.EqualError(t, RetryPolicy{Backoff: -time.Second}.Validate(), "connect retry backoff can not be negative: -1s")
+	assert.EqualError(t, RetryPolicy{Jitter: -0.1}.Validate(), "connect retry jitter must be between 0 and 1: -0.1")
+}
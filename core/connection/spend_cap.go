@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/eventbus"
+	pingpongEvent "github.com/mysteriumnetwork/node/session/pingpong/event"
+)
+
+// spendCap tracks payments of the current session against the spend cap.
+type spendCap struct {
+	lock      sync.Mutex
+	sessionID string
+	limit     *big.Int
+}
+
+func (sc *spendCap) start(sessionID string, limit *big.Int) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	sc.sessionID = sessionID
+	sc.limit = limit
+}
+
+func (sc *spendCap) stop() {
+	sc.start("", nil)
+}
+
+// reached checks if the paid invoice exhausts spend cap of the current session, cap is reported only once.
+func (sc *spendCap) reached(e pingpongEvent.AppEventInvoicePaid) bool {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	if sc.limit == nil || sc.sessionID == "" || e.SessionID != sc.sessionID || e.Invoice.AgreementTotal == nil {
+		return false
+	}
+	if e.Invoice.AgreementTotal.Cmp(sc.limit) < 0 {
+		return false
+	}
+
+	sc.limit = nil
+	return true
+}
+
+// Subscribe subscribes to payment events to enforce spend cap.
+func (m *connectionManager) Subscribe(bus eventbus.Subscriber) error {
+	return bus.SubscribeAsync(pingpongEvent.AppTopicInvoicePaid, m.invoicePaidHandler)
+}
+
+// Unsubscribe unsubscribes from events the manager was subscribed to.
+func (m *connectionManager) Unsubscribe(bus eventbus.Subscriber) error {
+	return bus.Unsubscribe(pingpongEvent.AppTopicInvoicePaid, m.invoicePaidHandler)
+}
+
+func (m *connectionManager) consumeInvoicePaidEvent(e pingpongEvent.AppEventInvoicePaid) {
+	if !m.spendCap.reached(e) {
+		return
+	}
+
+	log.Warn().Msgf("Disconnecting due to reached spend cap: paid %s", e.Invoice.AgreementTotal)
+	logDisconnectError(m.disconnectWithReason(connectionstate.DisconnectReasonSpendCap))
+}
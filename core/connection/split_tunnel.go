@@ -0,0 +1,295 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/firewall"
+	"github.com/mysteriumnetwork/node/utils/netutil"
+)
+
+// SplitTunnelMode defines how split tunnel rules are applied
+type SplitTunnelMode string
+
+const (
+	// SplitTunnelBypass routes matching traffic outside of the tunnel
+	SplitTunnelBypass = SplitTunnelMode("bypass")
+	// SplitTunnelExclusive routes only matching traffic through the tunnel
+	SplitTunnelExclusive = SplitTunnelMode("exclusive")
+)
+
+// ErrSplitTunnelAppsUnsupported indicates that per application split tunneling is not available.
+// Routing traffic of a process needs policy routing by its cgroup or socket marks, which the node does not set up.
+var ErrSplitTunnelAppsUnsupported = errors.New("per application split tunneling is not supported")
+
+// ErrSplitTunnelModeChange indicates that split tunnel mode can not be switched without reconnecting
+var ErrSplitTunnelModeChange = errors.New("exclusive split tunnel mode can not be switched while connected")
+
+// minSplitTunnelPrefix is the shortest IPv4 prefix allowed in split tunnel rules.
+// Shorter networks overlap the /1 routes of the tunnel itself or the default route.
+const minSplitTunnelPrefix = 2
+
+// SplitTunnel holds rules selecting traffic which bypasses or exclusively uses the tunnel
+type SplitTunnel struct {
+	Mode SplitTunnelMode
+	// Networks in CIDR notation
+	Networks []string
+	// Domains are resolved to addresses when rules are applied
+	Domains []string
+	// Apps are process names, rejected with ErrSplitTunnelAppsUnsupported
+	Apps []string
+}
+
+// IsEmpty checks if there are no split tunnel rules
+func (st SplitTunnel) IsEmpty() bool {
+	return len(st.Networks) == 0 && len(st.Domains) == 0 && len(st.Apps) == 0
+}
+
+// Exclusive checks if only matching traffic goes through the tunnel, which then must keep the default route intact
+func (st SplitTunnel) Exclusive() bool {
+	return !st.IsEmpty() && st.Mode == SplitTunnelExclusive
+}
+
+// Validate validates split tunnel rules
+func (st SplitTunnel) Validate() error {
+	if st.IsEmpty() {
+		return nil
+	}
+
+	switch st.Mode {
+	case SplitTunnelBypass, SplitTunnelExclusive:
+	default:
+		return fmt.Errorf("unknown split tunnel mode %q", st.Mode)
+	}
+
+	for _, network := range st.Networks {
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return fmt.Errorf("invalid split tunnel network %q: %w", network, err)
+		}
+		if ones, _ := ipNet.Mask.Size(); ipNet.IP.To4() != nil && ones < minSplitTunnelPrefix {
+			return fmt.Errorf("split tunnel network %q overlaps tunnel routes, prefix must be at least /%d", network, minSplitTunnelPrefix)
+		}
+	}
+
+	if len(st.Apps) > 0 {
+		return ErrSplitTunnelAppsUnsupported
+	}
+	return nil
+}
+
+// lookupIPFunc resolves domain to its addresses
+type lookupIPFunc func(host string) ([]net.IP, error)
+
+// matchingNetworks returns IPv4 networks matching split tunnel rules.
+func (st SplitTunnel) matchingNetworks(lookupIP lookupIPFunc) ([]net.IPNet, error) {
+	if st.IsEmpty() {
+		return nil, nil
+	}
+
+	var matching []net.IPNet
+	for _, network := range st.Networks {
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid split tunnel network %q: %w", network, err)
+		}
+		if ipNet.IP.To4() != nil {
+			matching = append(matching, *ipNet)
+		}
+	}
+	for _, domain := range st.Domains {
+		ips, err := lookupIP(domain)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve split tunnel domain %q: %w", domain, err)
+		}
+		matching = append(matching, hostNetworks(ips)...)
+	}
+	return matching, nil
+}
+
+// hostNetworks converts IPv4 addresses to single host networks.
+func hostNetworks(ips []net.IP) []net.IPNet {
+	var networks []net.IPNet
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			networks = append(networks, net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)})
+		}
+	}
+	return networks
+}
+
+// diffNetworks returns networks present in a, but not in b.
+func diffNetworks(a, b []net.IPNet) []net.IPNet {
+	present := make(map[string]bool, len(b))
+	for _, network := range b {
+		present[network.String()] = true
+	}
+
+	var diff []net.IPNet
+	for _, network := range a {
+		if !present[network.String()] {
+			present[network.String()] = true
+			diff = append(diff, network)
+		}
+	}
+	return diff
+}
+
+// excludeNetworkFunc routes network outside of the tunnel, returns function restoring previous routing
+type excludeNetworkFunc func(network net.IPNet) (func(), error)
+
+// includeNetworkFunc routes network through the tunnel interface, returns function restoring previous routing
+type includeNetworkFunc func(network net.IPNet, iface string) (func(), error)
+
+// excludeNetwork routes network through the default gateway and allows it through the kill switch.
+func excludeNetwork(network net.IPNet) (func(), error) {
+	if err := netutil.ExcludeNetwork(network); err != nil {
+		return nil, err
+	}
+
+	removeRule, err := firewall.AllowIPAccess(network.String())
+	if err != nil {
+		if err := netutil.RemoveExcludedNetwork(network); err != nil {
+			log.Warn().Err(err).Msgf("Failed to remove split tunnel route %s", network.String())
+		}
+		return nil, err
+	}
+
+	return func() {
+		removeRule()
+		if err := netutil.RemoveExcludedNetwork(network); err != nil {
+			log.Warn().Err(err).Msgf("Failed to remove split tunnel route %s", network.String())
+		}
+	}, nil
+}
+
+// includeNetwork routes network through the tunnel interface, while default route stays outside of the tunnel.
+func includeNetwork(network net.IPNet, iface string) (func(), error) {
+	if iface == "" {
+		return nil, errors.New("tunnel interface is unknown")
+	}
+	if err := netutil.RouteNetworkThroughInterface(network, iface); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if err := netutil.DeleteRouteNetworkThroughInterface(network, iface); err != nil {
+			log.Warn().Err(err).Msgf("Failed to remove split tunnel route %s", network.String())
+		}
+	}, nil
+}
+
+// splitTunnelRoutes keeps track of networks routed by split tunnel rules for the current connection.
+// Matching networks are routed outside of the tunnel in bypass mode and through the tunnel in exclusive mode.
+type splitTunnelRoutes struct {
+	lookupIP       lookupIPFunc
+	excludeNetwork excludeNetworkFunc
+	includeNetwork includeNetworkFunc
+
+	lock      sync.Mutex
+	exclusive bool
+	networks  []net.IPNet
+	restore   map[string]func()
+}
+
+func newSplitTunnelRoutes(lookupIP lookupIPFunc, exclude excludeNetworkFunc, include includeNetworkFunc) *splitTunnelRoutes {
+	return &splitTunnelRoutes{
+		lookupIP:       lookupIP,
+		excludeNetwork: exclude,
+		includeNetwork: include,
+		restore:        make(map[string]func()),
+	}
+}
+
+// apply changes routing to match given split tunnel rules, only the difference to current routing is applied.
+func (r *splitTunnelRoutes) apply(splitTunnel SplitTunnel, iface string) error {
+	networks, err := splitTunnel.matchingNetworks(r.lookupIP)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.exclusive != splitTunnel.Exclusive() {
+		r.clearLocked()
+		r.exclusive = splitTunnel.Exclusive()
+	}
+
+	for _, network := range diffNetworks(r.networks, networks) {
+		r.restoreLocked(network)
+	}
+
+	applied := diffNetworks(r.networks, diffNetworks(r.networks, networks))
+	for _, network := range diffNetworks(networks, r.networks) {
+		restore, err := r.routeLocked(network, iface)
+		if err != nil {
+			r.networks = applied
+			return err
+		}
+		r.restore[network.String()] = restore
+		applied = append(applied, network)
+	}
+	r.networks = applied
+
+	return nil
+}
+
+// clear restores routing of all matching networks.
+func (r *splitTunnelRoutes) clear() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.clearLocked()
+}
+
+func (r *splitTunnelRoutes) routeLocked(network net.IPNet, iface string) (func(), error) {
+	if r.exclusive {
+		restore, err := r.includeNetwork(network, iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not route %s through the tunnel: %w", network.String(), err)
+		}
+		return restore, nil
+	}
+
+	restore, err := r.excludeNetwork(network)
+	if err != nil {
+		return nil, fmt.Errorf("could not route %s outside of the tunnel: %w", network.String(), err)
+	}
+	return restore, nil
+}
+
+func (r *splitTunnelRoutes) clearLocked() {
+	for _, network := range r.networks {
+		r.restoreLocked(network)
+	}
+	r.networks = nil
+}
+
+func (r *splitTunnelRoutes) restoreLocked(network net.IPNet) {
+	if restore, ok := r.restore[network.String()]; ok {
+		restore()
+		delete(r.restore, network.String())
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func networksToStrings(networks []net.IPNet) []string {
+	var res []string
+	for _, network := range networks {
+		res = append(res, network.String())
+	}
+	return res
+}
+
+func TestSplitTunnel_Validate(t *testing.T) {
+	assert.NoError(t, SplitTunnel{}.Validate())
+	assert.NoError(t, SplitTunnel{Mode: SplitTunnelBypass, Networks: []string{"10.0.0.0/8"}}.Validate())
+	assert.Error(t, SplitTunnel{Mode: "unknown", Networks: []string{"10.0.0.0/8"}}.Validate())
+	assert.Error(t, SplitTunnel{Mode: SplitTunnelBypass, Networks: []string{"10.0.0.0"}}.Validate())
+	assert.Error(t, SplitTunnel{Mode: SplitTunnelExclusive, Networks: []string{"0.0.0.0/0"}}.Validate())
+	assert.Error(t, SplitTunnel{Mode: SplitTunnelBypass, Networks: []string{"128.0.0.0/1"}}.Validate())
+	assert.NoError(t, SplitTunnel{Mode: SplitTunnelBypass, Networks: []string{"128.0.0.0/2"}}.Validate())
+	assert.Equal(t, ErrSplitTunnelAppsUnsupported, SplitTunnel{Mode: SplitTunnelBypass, Apps: []string{"firefox"}}.Validate())
+}
+
+func TestSplitTunnel_MatchingNetworks(t *testing.T) {
+	lookupIP := func(host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("1.2.3.4"), net.ParseIP("::1")}, nil
+	}
+	splitTunnel := SplitTunnel{
+		Mode:     SplitTunnelBypass,
+		Networks: []string{"192.168.0.0/16", "fd00::/8"},
+		Domains:  []string{"example.com"},
+	}
+
+	networks, err := splitTunnel.matchingNetworks(lookupIP)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.0.0/16", "1.2.3.4/32"}, networksToStrings(networks))
+}
+
+func TestSplitTunnel_MatchingNetworksFailsOnUnresolvableDomain(t *testing.T) {
+	lookupIP := func(host string) ([]net.IP, error) {
+		return nil, errors.New("no such host")
+	}
+
+	_, err := SplitTunnel{Mode: SplitTunnelBypass, Domains: []string{"example.com"}}.matchingNetworks(lookupIP)
+	assert.Error(t, err)
+}
+
+func TestSplitTunnelRoutes_AppliesOnlyDifference(t *testing.T) {
+	var excluded []string
+	routes := newSplitTunnelRoutes(nil, func(network net.IPNet) (func(), error) {
+		excluded = append(excluded, "+"+network.String())
+		return func() { excluded = append(excluded, "-"+network.String()) }, nil
+	}, nil)
+
+	assert.NoError(t, routes.apply(SplitTunnel{Mode: SplitTunnelBypass, Networks: []string{"10.0.0.0/8", "192.168.0.0/16"}}, "wg0"))
+	assert.Equal(t, []string{"+10.0.0.0/8", "+192.168.0.0/16"}, excluded)
+
+	excluded = nil
+	assert.NoError(t, routes.apply(SplitTunnel{Mode: SplitTunnelBypass, Networks: []string{"192.168.0.0/16", "172.16.0.0/12"}}, "wg0"))
+	assert.Equal(t, []string{"-10.0.0.0/8", "+172.16.0.0/12"}, excluded)
+
+	excluded = nil
+	routes.clear()
+	assert.Equal(t, []string{"-192.168.0.0/16", "-172.16.0.0/12"}, excluded)
+}
+
+func TestSplitTunnelRoutes_ExclusiveModeRoutesMatchingNetworksThroughTunnel(t *testing.T) {
+	var routed []string
+	routes := newSplitTunnelRoutes(nil, func(network net.IPNet) (func(), error) {
+		routed = append(routed, "+"+network.String()+" gw")
+		return func() { routed = append(routed, "-"+network.String()+" gw") }, nil
+	}, func(network net.IPNet, iface string) (func(), error) {
+		routed = append(routed, "+"+network.String()+" "+iface)
+		return func() { routed = append(routed, "-"+network.String()+" "+iface) }, nil
+	})
+
+	assert.NoError(t, routes.apply(SplitTunnel{Mode: SplitTunnelExclusive, Networks: []string{"64.0.0.0/2"}}, "wg0"))
+	assert.Equal(t, []string{"+64.0.0.0/2 wg0"}, routed)
+
+	routed = nil
+	assert.NoError(t, routes.apply(SplitTunnel{Mode: SplitTunnelBypass, Networks: []string{"64.0.0.0/2"}}, "wg0"))
+	assert.Equal(t, []string{"-64.0.0.0/2 wg0", "+64.0.0.0/2 gw"}, routed)
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// StandbyTopicPrefix prefixes topics of connection events published by the standby connection
+// while it is being established to replace the main one
+const StandbyTopicPrefix = "Standby"
+
+// ErrSwitchNotSupported error indicates that connection can not be switched without interrupting traffic
+var ErrSwitchNotSupported = errors.New("connection does not support seamless switch")
+
+// DefaultRouteTaker is implemented by connections established with default route kept intact,
+// which are able to route all consumer traffic through their tunnel afterwards.
+type DefaultRouteTaker interface {
+	TakeDefaultRoute() error
+}
+
+// defaultRouteTaker is implemented by managers of connections able to take over the default route.
+type defaultRouteTaker interface {
+	canTakeDefaultRoute() bool
+	takeDefaultRoute() error
+}
+
+// standbyManager establishes connection to the new provider alongside the main connection, then takes it over.
+type standbyManager struct {
+	Manager
+
+	bus *standbyEventBus
+}
+
+// standbyEventBus publishes connection events under prefixed topics until standby connection becomes the main one.
+type standbyEventBus struct {
+	eventbus.EventBus
+
+	standby eventbus.EventBus
+
+	lock sync.Mutex
+	main bool
+}
+
+func newStandbyEventBus(bus eventbus.EventBus) *standbyEventBus {
+	return &standbyEventBus{
+		EventBus: bus,
+		standby:  &topicPrefixEventBus{EventBus: bus, prefix: StandbyTopicPrefix},
+	}
+}
+
+// Publish publishes connection events under prefixed topics while connection is on standby
+func (b *standbyEventBus) Publish(topic string, data interface{}) {
+	b.lock.Lock()
+	main := b.main
+	b.lock.Unlock()
+
+	if main {
+		b.EventBus.Publish(topic, data)
+		return
+	}
+	b.standby.Publish(topic, data)
+}
+
+// promote publishes further events under main topics. Session and state of the connection are announced,
+// since their events were published under prefixed topics while it was being established.
+func (b *standbyEventBus) promote(status connectionstate.Status) {
+	b.lock.Lock()
+	b.main = true
+	b.lock.Unlock()
+
+	b.EventBus.Publish(connectionstate.AppTopicConnectionSession, connectionstate.AppEventConnectionSession{
+		Status:      connectionstate.SessionCreatedStatus,
+		SessionInfo: status,
+	})
+	b.EventBus.Publish(connectionstate.AppTopicConnectionState, connectionstate.AppEventConnectionState{
+		State:       status.State,
+		SessionInfo: status,
+	})
+}
+
+func (b *standbyEventBus) demote() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.main = false
+}
+
+// Switch switches established connection to the new provider with minimal traffic interruption:
+// connection to the new provider is established first, keeping the default route of the main connection,
+// then the main connection is torn down and the new one takes over the default route.
+// Multi-hop connections are not switched seamlessly.
+func (m *MultiSessionManager) Switch(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams) error {
+	m.switchLock.Lock()
+	defer m.switchLock.Unlock()
+
+	current := m.mainManager()
+	status := current.Status()
+	if status.State != connectionstate.Connected {
+		return ErrNoConnection
+	}
+	if status.EntryProposal != nil || params.EntryProposal != nil {
+		return ErrSwitchNotSupported
+	}
+
+	standby, err := m.standbyManager()
+	if err != nil {
+		return err
+	}
+
+	params.KeepDefaultRoute = true
+	if err := standby.Connect(consumerID, hermesID, proposal, params); err != nil {
+		log.Error().Err(err).Msg("Could not connect to the new provider, keeping the current connection")
+		return err
+	}
+
+	taker, ok := standby.Manager.(defaultRouteTaker)
+	if !ok || !taker.canTakeDefaultRoute() {
+		logDisconnectError(standby.Disconnect())
+		return ErrSwitchNotSupported
+	}
+
+	log.Info().Msgf("Switching connection from %s to %s", status.Proposal.ProviderID, proposal.ProviderID)
+	logDisconnectError(current.Disconnect())
+
+	m.lock.Lock()
+	if previous := m.switched; previous != nil {
+		previous.bus.demote()
+		m.spares = append(m.spares, previous)
+	}
+	m.switched = standby
+	m.lock.Unlock()
+
+	if err := taker.takeDefaultRoute(); err != nil {
+		log.Error().Err(err).Msg("New connection could not take over the default route, disconnecting")
+		logDisconnectError(standby.Disconnect())
+		return err
+	}
+
+	standby.bus.promote(standby.Status())
+	return nil
+}
+
+// standbyManager returns manager for the standby connection, creating one if there is no spare manager.
+func (m *MultiSessionManager) standbyManager() (*standbyManager, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if len(m.spares) > 0 {
+		standby := m.spares[len(m.spares)-1]
+		m.spares = m.spares[:len(m.spares)-1]
+		return standby, nil
+	}
+
+	bus := newStandbyEventBus(m.bus)
+	manager, err := m.newStandby(bus)
+	if err != nil {
+		return nil, err
+	}
+	return &standbyManager{Manager: manager, bus: bus}, nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/mocks"
+)
+
+type standbyManagerFake struct {
+	*hopManagerFake
+
+	main             Manager
+	mainStateOnStart connectionstate.State
+	routeErr         error
+	tookRoute        bool
+}
+
+func (f *standbyManagerFake) Connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params ConnectParams) error {
+	f.mainStateOnStart = f.main.Status().State
+	return f.hopManagerFake.Connect(consumerID, hermesID, proposal, params)
+}
+
+func (f *standbyManagerFake) canTakeDefaultRoute() bool { return true }
+
+func (f *standbyManagerFake) takeDefaultRoute() error {
+	f.tookRoute = true
+	return f.routeErr
+}
+
+type standbyManagersFake struct {
+	main       Manager
+	connectErr error
+	managers   []*standbyManagerFake
+	buses      []eventbus.EventBus
+}
+
+func (f *standbyManagersFake) newManager(bus eventbus.EventBus) (Manager, error) {
+	manager := &standbyManagerFake{hopManagerFake: newHopManagerFake(), main: f.main}
+	manager.connectErr = f.connectErr
+	f.managers = append(f.managers, manager)
+	f.buses = append(f.buses, bus)
+	return manager, nil
+}
+
+var switchProposal = market.ServiceProposal{ProviderID: "new-node", ServiceType: activeServiceType}
+
+func TestMultiSessionManager_SwitchConnectsNewProviderBeforeDisconnecting(t *testing.T) {
+	bus := mocks.NewEventBus()
+	main := newHopManagerFake()
+	standby := &standbyManagersFake{main: main}
+	manager := NewMultiSessionManager(main, bus, (&sessionManagersFake{}).newManager, standby.newManager)
+	assert.NoError(t, manager.Connect(consumerID, hermesID, activeProposal, ConnectParams{}))
+
+	assert.NoError(t, manager.Switch(consumerID, hermesID, switchProposal, ConnectParams{DNS: DNSOptionProvider}))
+
+	assert.Len(t, standby.managers, 1)
+	assert.Equal(t, connectionstate.Connected, standby.managers[0].mainStateOnStart)
+	assert.True(t, standby.managers[0].params.KeepDefaultRoute)
+	assert.Equal(t, DNSOptionProvider, standby.managers[0].params.DNS)
+	assert.True(t, standby.managers[0].tookRoute)
+	assert.Equal(t, connectionstate.NotConnected, main.Status().State)
+
+	status := manager.Status()
+	assert.Equal(t, connectionstate.Connected, status.State)
+	assert.Equal(t, "new-node", status.Proposal.ProviderID)
+
+	history := bus.GetEventHistory()
+	assert.Len(t, history, 2)
+	assert.Equal(t, connectionstate.AppTopicConnectionSession, history[0].Topic)
+	assert.Equal(t, connectionstate.SessionCreatedStatus, history[0].Event.(connectionstate.AppEventConnectionSession).Status)
+	assert.Equal(t, connectionstate.AppTopicConnectionState, history[1].Topic)
+	assert.Equal(t, connectionstate.Connected, history[1].Event.(connectionstate.AppEventConnectionState).State)
+}
+
+func TestMultiSessionManager_SwitchPublishesStandbyEventsUnderPrefixedTopics(t *testing.T) {
+	bus := mocks.NewEventBus()
+	main := newHopManagerFake()
+	standby := &standbyManagersFake{main: main}
+	manager := NewMultiSessionManager(main, bus, (&sessionManagersFake{}).newManager, standby.newManager)
+	_, err := manager.standbyManager()
+	assert.NoError(t, err)
+
+	standby.buses[0].Publish(connectionstate.AppTopicConnectionState, connectionstate.AppEventConnectionState{State: connectionstate.Connecting})
+
+	history := bus.GetEventHistory()
+	assert.Len(t, history, 1)
+	assert.Equal(t, StandbyTopicPrefix+connectionstate.AppTopicConnectionState, history[0].Topic)
+}
+
+func TestMultiSessionManager_SwitchRequiresConnection(t *testing.T) {
+	main := newHopManagerFake()
+	standby := &standbyManagersFake{main: main}
+	manager := NewMultiSessionManager(main, mocks.NewEventBus(), (&sessionManagersFake{}).newManager, standby.newManager)
+
+	assert.Equal(t, ErrNoConnection, manager.Switch(consumerID, hermesID, switchProposal, ConnectParams{}))
+	assert.Empty(t, standby.managers)
+}
+
+func TestMultiSessionManager_SwitchKeepsConnectionWhenNewProviderFails(t *testing.T) {
+	main := newHopManagerFake()
+	standby := &standbyManagersFake{main: main, connectErr: errors.New("provider unreachable")}
+	manager := NewMultiSessionManager(main, mocks.NewEventBus(), (&sessionManagersFake{}).newManager, standby.newManager)
+	assert.NoError(t, manager.Connect(consumerID, hermesID, activeProposal, ConnectParams{}))
+
+	assert.EqualError(t, manager.Switch(consumerID, hermesID, switchProposal, ConnectParams{}), "provider unreachable")
+
+	assert.Equal(t, connectionstate.Connected, main.Status().State)
+	assert.Equal(t, activeProposal.ProviderID, manager.Status().Proposal.ProviderID)
+}
+
+func TestMultiSessionManager_SwitchRequiresRouteTakeOver(t *testing.T) {
+	main := newHopManagerFake()
+	standby := &sessionManagersFake{}
+	manager := NewMultiSessionManager(main, mocks.NewEventBus(), (&sessionManagersFake{}).newManager, standby.newManager)
+	assert.NoError(t, manager.Connect(consumerID, hermesID, activeProposal, ConnectParams{}))
+
+	assert.Equal(t, ErrSwitchNotSupported, manager.Switch(consumerID, hermesID, switchProposal, ConnectParams{}))
+
+	assert.Equal(t, connectionstate.Connected, main.Status().State)
+	assert.Equal(t, connectionstate.NotConnected, standby.managers[0].Status().State)
+}
+
+func TestMultiSessionManager_ConnectsWithMainManagerAfterSwitchedConnectionIsGone(t *testing.T) {
+	main := newHopManagerFake()
+	standby := &standbyManagersFake{main: main}
+	manager := NewMultiSessionManager(main, mocks.NewEventBus(), (&sessionManagersFake{}).newManager, standby.newManager)
+	assert.NoError(t, manager.Connect(consumerID, hermesID, activeProposal, ConnectParams{}))
+	assert.NoError(t, manager.Switch(consumerID, hermesID, switchProposal, ConnectParams{}))
+	assert.NoError(t, manager.Switch(consumerID, hermesID, activeProposal, ConnectParams{}))
+	assert.Len(t, standby.managers, 2)
+
+	assert.NoError(t, manager.Disconnect())
+	assert.NoError(t, manager.Connect(consumerID, hermesID, switchProposal, ConnectParams{}))
+
+	assert.Equal(t, "new-node", main.Status().Proposal.ProviderID)
+	assert.Len(t, manager.spares, 2)
+}
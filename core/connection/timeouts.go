@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ConnectStage identifies a stage of the connect flow
+type ConnectStage string
+
+const (
+	// StageProposalFetch is fetching of the provider's proposal
+	StageProposalFetch = ConnectStage("proposal-fetch")
+	// StageP2PDial is establishing p2p channel with the provider
+	StageP2PDial = ConnectStage("p2p-dial")
+	// StageSessionCreate is creating session with the provider
+	StageSessionCreate = ConnectStage("session-create")
+	// StageTunnelUp is waiting for the tunnel to come up
+	StageTunnelUp = ConnectStage("tunnel-up")
+)
+
+// ErrConnectTimeout indicates that connect did not finish in time
+var ErrConnectTimeout = errors.New("connect timed out")
+
+// StageTimeoutError indicates that connect timed out during the given stage
+type StageTimeoutError struct {
+	Stage ConnectStage
+}
+
+// Error returns error message
+func (e *StageTimeoutError) Error() string {
+	return fmt.Sprintf("connect timed out during %s stage", e.Stage)
+}
+
+// Is makes stage timeout errors match ErrConnectTimeout
+func (e *StageTimeoutError) Is(target error) bool {
+	return target == ErrConnectTimeout
+}
+
+// TimeoutConfig contains timeouts of the connect flow, zero value disables the timeout.
+type TimeoutConfig struct {
+	// Connect limits the whole connect flow, stages included
+	Connect       time.Duration
+	ProposalFetch time.Duration
+	P2PDial       time.Duration
+	SessionCreate time.Duration
+	TunnelUp      time.Duration
+}
+
+// DefaultTimeoutConfig returns default connect timeouts.
+func DefaultTimeoutConfig() TimeoutConfig {
+	return TimeoutConfig{
+		Connect:       2 * time.Minute,
+		ProposalFetch: 20 * time.Second,
+		P2PDial:       60 * time.Second,
+		SessionCreate: 20 * time.Second,
+		TunnelUp:      60 * time.Second,
+	}
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// stageError reports stage timeout if the stage failed because its context deadline was exceeded.
+func stageError(ctx context.Context, stage ConnectStage, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &StageTimeoutError{Stage: stage}
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apidiscovery
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/market/mysterium"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Endpoint is a discovery API reachable at the given address.
+type Endpoint struct {
+	Address string
+	API     ProposalsAPI
+}
+
+// EndpointStatus describes health of a single discovery API endpoint.
+type EndpointStatus struct {
+	Address string
+	Healthy bool
+}
+
+// FailoverStatus describes discovery API endpoints in priority order and the one currently in use.
+type FailoverStatus struct {
+	Active    string
+	Endpoints []EndpointStatus
+}
+
+// FailoverAPI queries proposals from a prioritized list of discovery API endpoints.
+// Failed queries are retried on the following endpoints, while periodic health checks
+// switch back to the endpoint of the highest priority once it recovers.
+type FailoverAPI struct {
+	endpoints           []Endpoint
+	healthCheckInterval time.Duration
+
+	mu      sync.RWMutex
+	active  int
+	healthy []bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewFailoverAPI creates discovery API client failing over between the given endpoints, the first one has the highest priority.
+func NewFailoverAPI(endpoints []Endpoint, healthCheckInterval time.Duration) *FailoverAPI {
+	healthy := make([]bool, len(endpoints))
+	for i := range healthy {
+		healthy[i] = true
+	}
+
+	return &FailoverAPI{
+		endpoints:           endpoints,
+		healthCheckInterval: healthCheckInterval,
+		healthy:             healthy,
+		stop:                make(chan struct{}),
+	}
+}
+
+// QueryProposals queries proposals from the active endpoint, failing over to the next ones on errors.
+func (f *FailoverAPI) QueryProposals(query mysterium.ProposalsQuery) ([]market.ServiceProposal, error) {
+	if len(f.endpoints) == 0 {
+		return nil, errors.New("no discovery API endpoints configured")
+	}
+
+	f.mu.RLock()
+	active := f.active
+	f.mu.RUnlock()
+
+	var lastErr error
+	for n := 0; n < len(f.endpoints); n++ {
+		i := (active + n) % len(f.endpoints)
+		proposals, err := f.endpoints[i].API.QueryProposals(query)
+		f.setHealthy(i, err == nil)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Discovery API endpoint %s failed", f.endpoints[i].Address)
+			lastErr = err
+			continue
+		}
+
+		if i != active {
+			f.activate(i)
+		}
+		return proposals, nil
+	}
+
+	return nil, lastErr
+}
+
+// Status returns health of the endpoints and the one currently in use.
+func (f *FailoverAPI) Status() FailoverStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	status := FailoverStatus{Endpoints: make([]EndpointStatus, len(f.endpoints))}
+	for i, endpoint := range f.endpoints {
+		status.Endpoints[i] = EndpointStatus{Address: endpoint.Address, Healthy: f.healthy[i]}
+	}
+	if len(f.endpoints) > 0 {
+		status.Active = f.endpoints[f.active].Address
+	}
+	return status
+}
+
+// Start begins periodic health checks of the endpoints.
+func (f *FailoverAPI) Start() {
+	go func() {
+		for {
+			select {
+			case <-f.stop:
+				return
+			case <-time.After(f.healthCheckInterval):
+				f.healthCheck()
+			}
+		}
+	}()
+}
+
+// Stop stops periodic health checks.
+func (f *FailoverAPI) Stop() {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+	})
+}
+
+// healthCheck probes endpoints in priority order and activates the first healthy one.
+func (f *FailoverAPI) healthCheck() {
+	for i, endpoint := range f.endpoints {
+		_, err := endpoint.API.QueryProposals(mysterium.ProposalsQuery{})
+		f.setHealthy(i, err == nil)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Discovery API endpoint %s health check failed", endpoint.Address)
+			continue
+		}
+
+		f.mu.RLock()
+		active := f.active
+		f.mu.RUnlock()
+		if i != active {
+			f.activate(i)
+		}
+		return
+	}
+}
+
+func (f *FailoverAPI) activate(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	log.Info().Msgf("Switching discovery API endpoint from %s to %s", f.endpoints[f.active].Address, f.endpoints[i].Address)
+	f.active = i
+}
+
+func (f *FailoverAPI) setHealthy(i int, healthy bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.healthy[i] = healthy
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apidiscovery
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/market/mysterium"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProposalsAPI struct {
+	mu        sync.Mutex
+	proposals []market.ServiceProposal
+	err       error
+	calls     int
+}
+
+func (m *mockProposalsAPI) QueryProposals(_ mysterium.ProposalsQuery) ([]market.ServiceProposal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	return m.proposals, m.err
+}
+
+func (m *mockProposalsAPI) setErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.err = err
+}
+
+func Test_FailoverAPI_QueryProposals_FailsOverToNextEndpoint(t *testing.T) {
+	// given
+	primary := &mockProposalsAPI{err: errors.New("unreachable")}
+	fallback := &mockProposalsAPI{proposals: []market.ServiceProposal{{ProviderID: "0x1"}}}
+	api := NewFailoverAPI([]Endpoint{
+		{Address: "http://primary", API: primary},
+		{Address: "http://fallback", API: fallback},
+	}, 0)
+
+	// when
+	proposals, err := api.QueryProposals(mysterium.ProposalsQuery{})
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 1)
+	assert.Equal(t, FailoverStatus{
+		Active: "http://fallback",
+		Endpoints: []EndpointStatus{
+			{Address: "http://primary", Healthy: false},
+			{Address: "http://fallback", Healthy: true},
+		},
+	}, api.Status())
+
+	// when
+	_, err = api.QueryProposals(mysterium.ProposalsQuery{})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, 2, fallback.calls)
+}
+
+func Test_FailoverAPI_QueryProposals_ReturnsErrorWhenAllEndpointsFail(t *testing.T) {
+	// given
+	api := NewFailoverAPI([]Endpoint{
+		{Address: "http://primary", API: &mockProposalsAPI{err: errors.New("unreachable")}},
+		{Address: "http://fallback", API: &mockProposalsAPI{err: errors.New("timeout")}},
+	}, 0)
+
+	// when
+	_, err := api.QueryProposals(mysterium.ProposalsQuery{})
+
+	// then
+	assert.EqualError(t, err, "timeout")
+	assert.Equal(t, "http://primary", api.Status().Active)
+}
+
+func Test_FailoverAPI_HealthCheck_SwitchesBackToRecoveredEndpoint(t *testing.T) {
+	// given
+	primary := &mockProposalsAPI{err: errors.New("unreachable")}
+	fallback := &mockProposalsAPI{}
+	api := NewFailoverAPI([]Endpoint{
+		{Address: "http://primary", API: primary},
+		{Address: "http://fallback", API: fallback},
+	}, 0)
+	api.healthCheck()
+	assert.Equal(t, "http://fallback", api.Status().Active)
+
+	// when
+	primary.setErr(nil)
+	api.healthCheck()
+
+	// then
+	assert.Equal(t, FailoverStatus{
+		Active: "http://primary",
+		Endpoints: []EndpointStatus{
+			{Address: "http://primary", Healthy: true},
+			{Address: "http://fallback", Healthy: true},
+		},
+	}, api.Status())
+}
@@ -26,13 +26,20 @@ import (
 	"github.com/pkg/errors"
 )
 
+// ProposalsAPI is a discovery API client able to query proposals.
+type ProposalsAPI interface {
+	QueryProposals(query mysterium.ProposalsQuery) ([]market.ServiceProposal, error)
+}
+
 type apiRepository struct {
-	discoveryAPI *mysterium.MysteriumAPI
+	discoveryAPI ProposalsAPI
+	verifier     *proposal.SignatureVerifier
 }
 
 // NewRepository constructs a new proposal repository (backed by API).
-func NewRepository(api *mysterium.MysteriumAPI) *apiRepository {
-	return &apiRepository{discoveryAPI: api}
+// Fetched proposals are checked by the signature verifier, which is optional.
+func NewRepository(api ProposalsAPI, verifier *proposal.SignatureVerifier) *apiRepository {
+	return &apiRepository{discoveryAPI: api, verifier: verifier}
 }
 
 // Proposal returns proposal by ID.
@@ -46,10 +53,12 @@ func (a *apiRepository) Proposal(id market.ProposalID) (*market.ServiceProposal,
 	if err != nil {
 		return nil, err
 	}
-	if len(proposals) != 1 {
-		return nil, fmt.Errorf("proposal does not exist: %+v", id)
+	for i := range proposals {
+		if proposals[i].UniqueID() == id && a.verifier.Verify(&proposals[i]) {
+			return &proposals[i], nil
+		}
 	}
-	return &proposals[0], nil
+	return nil, fmt.Errorf("proposal does not exist: %+v", id)
 }
 
 // Proposals returns proposals matching filter.
@@ -60,7 +69,7 @@ func (a *apiRepository) Proposals(filter *proposal.Filter) ([]market.ServiceProp
 	}
 
 	res := make([]market.ServiceProposal, 0)
-	for _, p := range proposals {
+	for _, p := range a.verifier.Filter(proposals) {
 		if filter.Matches(p) {
 			res = append(res, p)
 		}
@@ -25,6 +25,7 @@ import (
 	"github.com/mysteriumnetwork/node/communication/nats"
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/rs/zerolog/log"
 )
 
 // Repository provides proposals from the broker.
@@ -32,6 +33,8 @@ type Repository struct {
 	storage         *ProposalStorage
 	receiver        communication.Receiver
 	timeoutInterval time.Duration
+	seed            proposal.Repository
+	verifier        *proposal.SignatureVerifier
 
 	stopOnce sync.Once
 	stopChan chan struct{}
@@ -42,16 +45,23 @@ type Repository struct {
 }
 
 // NewRepository constructs a new proposal repository (backed by the broker).
+// Seed repository is optional, it fills the storage on start and resolves proposals not announced yet,
+// so the broker subscription alone can be used instead of polling.
+// Announced proposals are checked by the signature verifier before they are stored, the verifier is optional.
 func NewRepository(
 	connection nats.Connection,
 	storage *ProposalStorage,
 	proposalTimeoutInterval time.Duration,
 	proposalCheckInterval time.Duration,
+	seed proposal.Repository,
+	verifier *proposal.SignatureVerifier,
 ) *Repository {
 	return &Repository{
 		storage:         storage,
 		receiver:        nats.NewReceiver(connection, communication.NewCodecJSON(), "*"),
 		timeoutInterval: proposalTimeoutInterval,
+		seed:            seed,
+		verifier:        verifier,
 
 		stopChan:          make(chan struct{}),
 		timeoutCheckStep:  proposalCheckInterval,
@@ -61,7 +71,17 @@ func NewRepository(
 
 // Proposal returns a single proposal by its ID.
 func (r *Repository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
-	return r.storage.GetProposal(id)
+	p, err := r.storage.GetProposal(id)
+	if err == nil || r.seed == nil {
+		return p, err
+	}
+
+	p, err = r.seed.Proposal(id)
+	if err != nil {
+		return nil, err
+	}
+	r.addSeeded(*p)
+	return p, nil
 }
 
 // Proposals returns proposals matching the filter.
@@ -87,6 +107,9 @@ func (r *Repository) Start() error {
 	}
 
 	go r.timeoutCheckLoop()
+	if r.seed != nil {
+		go r.seedProposals()
+	}
 
 	return nil
 }
@@ -103,7 +126,7 @@ func (r *Repository) Stop() {
 }
 
 func (r *Repository) proposalRegisterMessage(message registerMessage) error {
-	if !message.Proposal.IsSupported() {
+	if !message.Proposal.IsSupported() || !r.verifier.Verify(&message.Proposal) {
 		return nil
 	}
 
@@ -117,6 +140,10 @@ func (r *Repository) proposalRegisterMessage(message registerMessage) error {
 }
 
 func (r *Repository) proposalUnregisterMessage(message unregisterMessage) error {
+	if !r.verifier.Verify(&message.Proposal) {
+		return nil
+	}
+
 	r.storage.RemoveProposal(message.Proposal.UniqueID())
 
 	r.watchdogLock.Lock()
@@ -127,7 +154,7 @@ func (r *Repository) proposalUnregisterMessage(message unregisterMessage) error
 }
 
 func (r *Repository) proposalPingMessage(message pingMessage) error {
-	if !message.Proposal.IsSupported() {
+	if !message.Proposal.IsSupported() || !r.verifier.Verify(&message.Proposal) {
 		return nil
 	}
 
@@ -140,6 +167,32 @@ func (r *Repository) proposalPingMessage(message pingMessage) error {
 	return nil
 }
 
+func (r *Repository) seedProposals() {
+	proposals, err := r.seed.Proposals(&proposal.Filter{})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to seed broker proposals")
+	}
+
+	for _, p := range proposals {
+		if !r.storage.HasProposal(p.UniqueID()) {
+			r.addSeeded(p)
+		}
+	}
+	log.Debug().Msgf("Seeded %d broker proposals", len(proposals))
+}
+
+func (r *Repository) addSeeded(p market.ServiceProposal) {
+	if !p.IsSupported() || !r.verifier.Verify(&p) {
+		return
+	}
+
+	r.storage.AddProposal(p)
+
+	r.watchdogLock.Lock()
+	defer r.watchdogLock.Unlock()
+	r.timeoutCheckSeens[p.UniqueID()] = time.Now()
+}
+
 func (r *Repository) timeoutCheckLoop() {
 	for {
 		select {
@@ -19,10 +19,12 @@ package brokerdiscovery
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/mysteriumnetwork/node/communication/nats"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
@@ -76,7 +78,7 @@ func Test_Subscriber_StartSyncsNewProposals(t *testing.T) {
 	connection := nats.StartConnectionMock()
 	defer connection.Close()
 
-	repo := NewRepository(connection, NewStorage(eventbus.New()), 10*time.Millisecond, 10*time.Millisecond)
+	repo := NewRepository(connection, NewStorage(eventbus.New()), 10*time.Millisecond, 10*time.Millisecond, nil, nil)
 	err := repo.Start()
 	defer repo.Stop()
 	assert.NoError(t, err)
@@ -93,7 +95,7 @@ func Test_Subscriber_SkipUnsupportedProposal(t *testing.T) {
 	connection := nats.StartConnectionMock()
 	defer connection.Close()
 
-	repo := NewRepository(connection, NewStorage(eventbus.New()), 10*time.Millisecond, 10*time.Millisecond)
+	repo := NewRepository(connection, NewStorage(eventbus.New()), 10*time.Millisecond, 10*time.Millisecond, nil, nil)
 	err := repo.Start()
 	defer repo.Stop()
 	assert.NoError(t, err)
@@ -107,11 +109,38 @@ func Test_Subscriber_SkipUnsupportedProposal(t *testing.T) {
 	assert.Exactly(t, []market.ServiceProposal{}, repo.storage.Proposals())
 }
 
+func Test_Subscriber_DropsProposalsWithInvalidSignatureOnlyWhenRequired(t *testing.T) {
+	forged := `{
+		"proposal": {"provider_id": "0x1", "service_type": "mock_service", "payment_method_type": "mock_payment", "provider_contacts": [{"type":"mock_contact"}], "signature": "Zm9yZ2Vk", "signature_version": 1}
+	}`
+
+	connection := nats.StartConnectionMock()
+	defer connection.Close()
+	repo := NewRepository(connection, NewStorage(eventbus.New()), time.Minute, 10*time.Millisecond, nil, proposal.NewSignatureVerifier(false))
+	assert.NoError(t, repo.Start())
+	defer repo.Stop()
+
+	proposalRegister(connection, forged)
+	assert.Eventually(t, proposalCountEquals(repo, 1), 2*time.Second, 10*time.Millisecond)
+	assert.False(t, repo.storage.Proposals()[0].SignatureVerified)
+
+	requiredConnection := nats.StartConnectionMock()
+	defer requiredConnection.Close()
+	requiredRepo := NewRepository(requiredConnection, NewStorage(eventbus.New()), time.Minute, 10*time.Millisecond, nil, proposal.NewSignatureVerifier(true))
+	assert.NoError(t, requiredRepo.Start())
+	defer requiredRepo.Stop()
+
+	proposalRegister(requiredConnection, forged)
+	assert.Never(t, func() bool {
+		return len(requiredRepo.storage.Proposals()) > 0
+	}, 100*time.Millisecond, 10*time.Millisecond)
+}
+
 func Test_Subscriber_StartSyncsIdleProposals(t *testing.T) {
 	connection := nats.StartConnectionMock()
 	defer connection.Close()
 
-	repo := NewRepository(connection, NewStorage(eventbus.New()), 10*time.Millisecond, 10*time.Millisecond)
+	repo := NewRepository(connection, NewStorage(eventbus.New()), 10*time.Millisecond, 10*time.Millisecond, nil, nil)
 	err := repo.Start()
 	defer repo.Stop()
 	assert.NoError(t, err)
@@ -126,7 +155,7 @@ func Test_Subscriber_StartSyncsHealthyProposals(t *testing.T) {
 	connection := nats.StartConnectionMock()
 	defer connection.Close()
 
-	repo := NewRepository(connection, NewStorage(eventbus.New()), 10*time.Millisecond, 10*time.Millisecond)
+	repo := NewRepository(connection, NewStorage(eventbus.New()), 10*time.Millisecond, 10*time.Millisecond, nil, nil)
 	err := repo.Start()
 	defer repo.Stop()
 	assert.NoError(t, err)
@@ -149,7 +178,7 @@ func Test_Subscriber_StartSyncsStoppedProposals(t *testing.T) {
 	connection := nats.StartConnectionMock()
 	defer connection.Close()
 
-	repo := NewRepository(connection, NewStorage(eventbus.New()), 10*time.Millisecond, 10*time.Millisecond)
+	repo := NewRepository(connection, NewStorage(eventbus.New()), 10*time.Millisecond, 10*time.Millisecond, nil, nil)
 	repo.storage.AddProposal(proposalFirst(), proposalSecond())
 	err := repo.Start()
 	defer repo.Stop()
@@ -163,6 +192,48 @@ func Test_Subscriber_StartSyncsStoppedProposals(t *testing.T) {
 	assert.Exactly(t, []market.ServiceProposal{proposalSecond()}, repo.storage.Proposals())
 }
 
+type mockSeedRepository struct {
+	proposals []market.ServiceProposal
+}
+
+func (m *mockSeedRepository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	for i := range m.proposals {
+		if m.proposals[i].UniqueID() == id {
+			return &m.proposals[i], nil
+		}
+	}
+	return nil, errors.New("proposal not found")
+}
+
+func (m *mockSeedRepository) Proposals(_ *proposal.Filter) ([]market.ServiceProposal, error) {
+	return m.proposals, nil
+}
+
+func Test_Subscriber_StartSeedsProposals(t *testing.T) {
+	connection := nats.StartConnectionMock()
+	defer connection.Close()
+
+	seed := &mockSeedRepository{proposals: []market.ServiceProposal{proposalFirst()}}
+	repo := NewRepository(connection, NewStorage(eventbus.New()), time.Minute, 10*time.Millisecond, seed, nil)
+	err := repo.Start()
+	defer repo.Stop()
+	assert.NoError(t, err)
+
+	assert.Eventually(t, proposalCountEquals(repo, 1), 2*time.Second, 10*time.Millisecond)
+	assert.Exactly(t, []market.ServiceProposal{proposalFirst()}, repo.storage.Proposals())
+
+	// proposal not announced yet is resolved by seed
+	second := proposalSecond()
+	seed.proposals = append(seed.proposals, second)
+	p, err := repo.Proposal(second.UniqueID())
+	assert.NoError(t, err)
+	assert.Exactly(t, second, *p)
+	assert.True(t, repo.storage.HasProposal(second.UniqueID()))
+
+	_, err = repo.Proposal(market.ProposalID{ProviderID: "0x3", ServiceType: "mock_service"})
+	assert.Error(t, err)
+}
+
 func proposalRegister(connection nats.Connection, payload string) {
 	err := connection.Publish("*.proposal-register", []byte(payload))
 	if err != nil {
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dhtdiscovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+const (
+	// announceProtocol is used to push proposal announcements to peers.
+	announceProtocol = protocol.ID("/mysterium/discovery/announce/1.0.0")
+	// queryProtocol is used to fetch all proposal announcements known by peer.
+	queryProtocol = protocol.ID("/mysterium/discovery/query/1.0.0")
+
+	// maxMessageSize limits size of messages read from peers.
+	maxMessageSize = 10 << 20
+)
+
+// announcement is a proposal state announced by its provider.
+type announcement struct {
+	Proposal   market.ServiceProposal `json:"proposal"`
+	Unregister bool                   `json:"unregister,omitempty"`
+	Timestamp  int64                  `json:"timestamp"`
+}
+
+// signedAnnouncement is an announcement signed by provider identity, so it can be relayed by any peer.
+type signedAnnouncement struct {
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+func signAnnouncement(proposal market.ServiceProposal, unregister bool, signer identity.Signer) (signedAnnouncement, error) {
+	payload, err := json.Marshal(announcement{
+		Proposal:   proposal,
+		Unregister: unregister,
+		Timestamp:  time.Now().UnixNano(),
+	})
+	if err != nil {
+		return signedAnnouncement{}, fmt.Errorf("could not serialize announcement: %w", err)
+	}
+
+	signature, err := signer.Sign(payload)
+	if err != nil {
+		return signedAnnouncement{}, fmt.Errorf("could not sign announcement: %w", err)
+	}
+
+	return signedAnnouncement{Payload: payload, Signature: signature.Bytes()}, nil
+}
+
+// verify checks that announcement is signed by the provider of the announced proposal.
+func (sa signedAnnouncement) verify() (announcement, error) {
+	var a announcement
+	if err := json.Unmarshal(sa.Payload, &a); err != nil {
+		return announcement{}, fmt.Errorf("could not parse announcement: %w", err)
+	}
+
+	provider := identity.FromAddress(a.Proposal.ProviderID)
+	if !identity.NewVerifierIdentity(provider).Verify(sa.Payload, identity.SignatureBytes(sa.Signature)) {
+		return announcement{}, errors.New("announcement is not signed by the provider")
+	}
+	return a, nil
+}
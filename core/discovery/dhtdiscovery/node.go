@@ -19,15 +19,25 @@ package dhtdiscovery
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
 	"github.com/multiformats/go-multiaddr"
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/rs/zerolog/log"
 )
 
+// defaultProposalTimeout is used when proposal timeout is not configured, e.g. for consumer-only nodes.
+const defaultProposalTimeout = 10 * time.Minute
+
 // Node represents DHT server-client in P2P network.
 type Node struct {
 	libP2PConfig     libp2p.Config
@@ -36,12 +46,17 @@ type Node struct {
 	libP2PNodeCancel context.CancelFunc
 
 	bootstrapPeers []*peer.AddrInfo
+	store          *announcementStore
 }
 
-// NewNode create an instance of DHT node.
-func NewNode(listenAddress string, bootstrapPeerAddresses []string) (*Node, error) {
+// NewNode create an instance of DHT node, keeping announced proposals for the given time.
+func NewNode(listenAddress string, bootstrapPeerAddresses []string, proposalTimeout time.Duration) (*Node, error) {
+	if proposalTimeout <= 0 {
+		proposalTimeout = defaultProposalTimeout
+	}
 	node := &Node{
 		bootstrapPeers: make([]*peer.AddrInfo, len(bootstrapPeerAddresses)),
+		store:          newAnnouncementStore(proposalTimeout),
 	}
 
 	// Parse and validate configuration
@@ -85,6 +100,9 @@ func (n *Node) Start() (err error) {
 
 	log.Info().Msgf("DHT node started on %s with ID=%s", n.libP2PNode.Addrs(), n.libP2PNode.ID())
 
+	n.libP2PNode.SetStreamHandler(announceProtocol, n.handleAnnounce)
+	n.libP2PNode.SetStreamHandler(queryProtocol, n.handleQuery)
+
 	// Start connecting to the bootstrap peer nodes early. They will tell us about the other nodes in the network.
 	for _, peerInfo := range n.bootstrapPeers {
 		go n.connectToPeer(*peerInfo)
@@ -96,6 +114,135 @@ func (n *Node) Start() (err error) {
 // Stop stops DHT node.
 func (n *Node) Stop() {
 	n.libP2PNodeCancel()
+	if n.libP2PNode != nil {
+		if err := n.libP2PNode.Close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close DHT node")
+		}
+	}
+}
+
+// Announce stores signed proposal announcement and pushes it to the connected peers.
+func (n *Node) Announce(sa signedAnnouncement) error {
+	stored, err := n.store.put(sa)
+	if err != nil {
+		return err
+	}
+	if stored {
+		n.broadcast(sa, "")
+	}
+	return nil
+}
+
+// Query collects proposal announcements from the connected peers and returns the known proposals.
+func (n *Node) Query(ctx context.Context) []market.ServiceProposal {
+	if n.libP2PNode == nil {
+		return n.store.proposals()
+	}
+
+	var wg sync.WaitGroup
+	for _, peerID := range n.libP2PNode.Network().Peers() {
+		wg.Add(1)
+		go func(peerID peer.ID) {
+			defer wg.Done()
+			n.queryPeer(ctx, peerID)
+		}(peerID)
+	}
+	wg.Wait()
+
+	return n.store.proposals()
+}
+
+func (n *Node) queryPeer(ctx context.Context, peerID peer.ID) {
+	var announcements []signedAnnouncement
+	if err := n.request(ctx, peerID, queryProtocol, nil, &announcements); err != nil {
+		log.Debug().Err(err).Msgf("Failed to query DHT peer %s", peerID)
+		return
+	}
+
+	for _, sa := range announcements {
+		if _, err := n.store.put(sa); err != nil {
+			log.Debug().Err(err).Msgf("Skipping invalid announcement from DHT peer %s", peerID)
+		}
+	}
+}
+
+func (n *Node) broadcast(sa signedAnnouncement, except peer.ID) {
+	if n.libP2PNode == nil {
+		return
+	}
+
+	for _, peerID := range n.libP2PNode.Network().Peers() {
+		if peerID == except {
+			continue
+		}
+		go func(peerID peer.ID) {
+			ctx, cancel := context.WithTimeout(n.libP2PNodeCtx, 10*time.Second)
+			defer cancel()
+			if err := n.request(ctx, peerID, announceProtocol, sa, nil); err != nil {
+				log.Debug().Err(err).Msgf("Failed to announce proposal to DHT peer %s", peerID)
+			}
+		}(peerID)
+	}
+}
+
+// request sends a message over a new stream and reads the reply, if reply is expected.
+func (n *Node) request(ctx context.Context, peerID peer.ID, protocolID protocol.ID, message interface{}, reply interface{}) error {
+	stream, err := n.libP2PNode.NewStream(ctx, peerID, protocolID)
+	if err != nil {
+		return fmt.Errorf("failed to open stream: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = stream.SetDeadline(deadline)
+	}
+	if message != nil {
+		if err := json.NewEncoder(stream).Encode(message); err != nil {
+			stream.Reset()
+			return fmt.Errorf("failed to send message: %w", err)
+		}
+	}
+	if err := stream.Close(); err != nil {
+		stream.Reset()
+		return fmt.Errorf("failed to close stream: %w", err)
+	}
+	if reply == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(io.LimitReader(stream, maxMessageSize)).Decode(reply); err != nil {
+		stream.Reset()
+		return fmt.Errorf("failed to read reply: %w", err)
+	}
+	return nil
+}
+
+func (n *Node) handleAnnounce(stream network.Stream) {
+	defer stream.Close()
+
+	var sa signedAnnouncement
+	if err := json.NewDecoder(io.LimitReader(stream, maxMessageSize)).Decode(&sa); err != nil {
+		log.Debug().Err(err).Msg("Failed to read DHT announcement")
+		stream.Reset()
+		return
+	}
+
+	stored, err := n.store.put(sa)
+	if err != nil {
+		log.Debug().Err(err).Msg("Skipping invalid DHT announcement")
+		return
+	}
+	if stored {
+		n.broadcast(sa, stream.Conn().RemotePeer())
+	}
+}
+
+func (n *Node) handleQuery(stream network.Stream) {
+	defer stream.Close()
+
+	if err := json.NewEncoder(stream).Encode(n.store.all()); err != nil {
+		log.Debug().Err(err).Msg("Failed to reply to DHT query")
+		stream.Reset()
+	}
 }
 
 func (n *Node) connectToPeer(peerInfo peer.AddrInfo) {
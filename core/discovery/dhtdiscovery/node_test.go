@@ -0,0 +1,175 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dhtdiscovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	market.RegisterServiceDefinitionUnserializer(
+		"mock_service",
+		func(rawDefinition *json.RawMessage) (market.ServiceDefinition, error) {
+			return mockServiceDefinition{}, nil
+		},
+	)
+	market.RegisterPaymentMethodUnserializer(
+		"mock_payment",
+		func(rawDefinition *json.RawMessage) (market.PaymentMethod, error) {
+			return mockPaymentMethod{}, nil
+		},
+	)
+	market.RegisterContactUnserializer("mock_contact",
+		func(rawMessage *json.RawMessage) (market.ContactDefinition, error) {
+			return mockContact{}, nil
+		},
+	)
+}
+
+func newTestSigner(t *testing.T) (identity.Signer, identity.Identity) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+
+	ks := identity.NewKeystoreMemory()
+	account, err := ks.ImportECDSA(key, "")
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Unlock(account, ""))
+
+	id := identity.FromAddress(account.Address.Hex())
+	return identity.NewSigner(ks, id), id
+}
+
+func newTestProposal(providerID identity.Identity) market.ServiceProposal {
+	return market.ServiceProposal{
+		ProviderID:        providerID.Address,
+		ServiceType:       "mock_service",
+		ServiceDefinition: mockServiceDefinition{},
+		PaymentMethodType: "mock_payment",
+		PaymentMethod:     mockPaymentMethod{},
+		ProviderContacts:  []market.Contact{{Type: "mock_contact", Definition: mockContact{}}},
+	}
+}
+
+func Test_AnnouncementStore(t *testing.T) {
+	signer, providerID := newTestSigner(t)
+	otherSigner, _ := newTestSigner(t)
+	p := newTestProposal(providerID)
+	store := newAnnouncementStore(time.Minute)
+
+	forged, err := signAnnouncement(p, false, otherSigner)
+	assert.NoError(t, err)
+	_, err = store.put(forged)
+	assert.Error(t, err)
+	assert.Len(t, store.proposals(), 0)
+
+	registered, err := signAnnouncement(p, false, signer)
+	assert.NoError(t, err)
+	stored, err := store.put(registered)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+	assert.Len(t, store.proposals(), 1)
+
+	stored, err = store.put(registered)
+	assert.NoError(t, err)
+	assert.False(t, stored, "same announcement should not be stored twice")
+
+	unregistered, err := signAnnouncement(p, true, signer)
+	assert.NoError(t, err)
+	stored, err = store.put(unregistered)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+	assert.Len(t, store.proposals(), 0)
+	assert.Len(t, store.all(), 1)
+
+	stored, err = store.put(registered)
+	assert.NoError(t, err)
+	assert.False(t, stored, "older announcement should be ignored")
+
+	store.timeNow = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	assert.Len(t, store.all(), 0)
+}
+
+func Test_NodeQueriesPeerAnnouncements(t *testing.T) {
+	providerNode, err := NewNode("/ip4/127.0.0.1/tcp/0", nil, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, providerNode.Start())
+	defer providerNode.Stop()
+
+	providerAddress := fmt.Sprintf("%s/p2p/%s", providerNode.libP2PNode.Addrs()[0], providerNode.libP2PNode.ID())
+	consumerNode, err := NewNode("/ip4/127.0.0.1/tcp/0", []string{providerAddress}, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, consumerNode.Start())
+	defer consumerNode.Stop()
+
+	assert.Eventually(t, func() bool {
+		return len(consumerNode.libP2PNode.Network().Peers()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	signer, providerID := newTestSigner(t)
+	p := newTestProposal(providerID)
+	assert.NoError(t, NewRegistry(providerNode).RegisterProposal(p, signer))
+
+	repository := NewRepository(consumerNode, 5*time.Second)
+	proposals, err := repository.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 1)
+
+	found, err := repository.Proposal(p.UniqueID())
+	assert.NoError(t, err)
+	assert.Equal(t, p.ProviderID, found.ProviderID)
+
+	assert.NoError(t, NewRegistry(providerNode).UnregisterProposal(p, signer))
+	assert.Eventually(t, func() bool {
+		proposals, _ := repository.Proposals(&proposal.Filter{})
+		return len(proposals) == 0
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+type mockServiceDefinition struct{}
+
+func (service mockServiceDefinition) GetLocation() market.Location {
+	return market.Location{}
+}
+
+type mockPaymentMethod struct{}
+
+func (method mockPaymentMethod) GetPrice() money.Money {
+	return money.Money{}
+}
+
+func (method mockPaymentMethod) GetType() string {
+	return "mock"
+}
+
+func (method mockPaymentMethod) GetRate() market.PaymentRate {
+	return market.PaymentRate{
+		PerTime: time.Minute,
+	}
+}
+
+type mockContact struct{}
@@ -23,24 +23,33 @@ import (
 )
 
 type registryDHT struct {
+	node *Node
 }
 
-// NewRegistry create an instance of Broker registryBroker.
-func NewRegistry() *registryDHT {
-	return &registryDHT{}
+// NewRegistry create an instance of DHT registryDHT.
+func NewRegistry(node *Node) *registryDHT {
+	return &registryDHT{node: node}
 }
 
-// RegisterProposal registers service proposal to discovery service.
+// RegisterProposal announces signed service proposal to DHT peers.
 func (rd *registryDHT) RegisterProposal(proposal market.ServiceProposal, signer identity.Signer) error {
-	return nil
+	return rd.announce(proposal, false, signer)
 }
 
-// UnregisterProposal unregisters a service proposal when client disconnects.
+// UnregisterProposal announces to DHT peers that service proposal is not available anymore.
 func (rd *registryDHT) UnregisterProposal(proposal market.ServiceProposal, signer identity.Signer) error {
-	return nil
+	return rd.announce(proposal, true, signer)
 }
 
-// PingProposal pings service proposal as being alive.
+// PingProposal renews signed service proposal announcement in DHT peers.
 func (rd *registryDHT) PingProposal(proposal market.ServiceProposal, signer identity.Signer) error {
-	return nil
+	return rd.announce(proposal, false, signer)
+}
+
+func (rd *registryDHT) announce(proposal market.ServiceProposal, unregister bool, signer identity.Signer) error {
+	sa, err := signAnnouncement(proposal, unregister, signer)
+	if err != nil {
+		return err
+	}
+	return rd.node.Announce(sa)
 }
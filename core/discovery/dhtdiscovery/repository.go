@@ -18,7 +18,9 @@
 package dhtdiscovery
 
 import (
-	"sync"
+	"context"
+	"fmt"
+	"time"
 
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
 	"github.com/mysteriumnetwork/node/market"
@@ -26,35 +28,42 @@ import (
 
 // Repository provides proposals from the DHT.
 type Repository struct {
-	stopOnce sync.Once
-	stopChan chan struct{}
+	node         *Node
+	queryTimeout time.Duration
 }
 
 // NewRepository constructs a new proposal repository (backed by the DHT).
-func NewRepository() *Repository {
+func NewRepository(node *Node, queryTimeout time.Duration) *Repository {
 	return &Repository{
-		stopChan: make(chan struct{}),
+		node:         node,
+		queryTimeout: queryTimeout,
 	}
 }
 
 // Proposal returns a single proposal by its ID.
 func (r *Repository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
-	return nil, nil
+	proposals, err := r.Proposals(&proposal.Filter{ProviderID: id.ProviderID, ServiceType: id.ServiceType})
+	if err != nil {
+		return nil, err
+	}
+	for i := range proposals {
+		if proposals[i].UniqueID() == id {
+			return &proposals[i], nil
+		}
+	}
+	return nil, fmt.Errorf("proposal does not exist: %+v", id)
 }
 
 // Proposals returns proposals matching the filter.
 func (r *Repository) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
-	return []market.ServiceProposal{}, nil
-}
+	ctx, cancel := context.WithTimeout(context.Background(), r.queryTimeout)
+	defer cancel()
 
-// Start begins proposals synchronization to storage.
-func (r *Repository) Start() error {
-	return nil
-}
-
-// Stop ends proposals synchronization to storage.
-func (r *Repository) Stop() {
-	r.stopOnce.Do(func() {
-		close(r.stopChan)
-	})
+	res := make([]market.ServiceProposal, 0)
+	for _, p := range r.node.Query(ctx) {
+		if p.IsSupported() && filter.Matches(p) {
+			res = append(res, p)
+		}
+	}
+	return res, nil
 }
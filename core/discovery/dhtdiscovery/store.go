@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dhtdiscovery
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/market"
+)
+
+type storedAnnouncement struct {
+	signed       signedAnnouncement
+	announcement announcement
+}
+
+// announcementStore keeps the latest verified announcement of each proposal until it expires.
+type announcementStore struct {
+	ttl     time.Duration
+	timeNow func() time.Time
+
+	mu    sync.Mutex
+	items map[market.ProposalID]storedAnnouncement
+}
+
+func newAnnouncementStore(ttl time.Duration) *announcementStore {
+	return &announcementStore{
+		ttl:     ttl,
+		timeNow: time.Now,
+		items:   make(map[market.ProposalID]storedAnnouncement),
+	}
+}
+
+// put stores announcement if it is valid and newer than the known one, reporting whether it was stored.
+func (s *announcementStore) put(sa signedAnnouncement) (bool, error) {
+	a, err := sa.verify()
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.expired(a) {
+		return false, nil
+	}
+	id := a.Proposal.UniqueID()
+	if known, ok := s.items[id]; ok && known.announcement.Timestamp >= a.Timestamp {
+		return false, nil
+	}
+	// unregistered proposals are kept until expiry, so older announcements relayed later are ignored
+	s.items[id] = storedAnnouncement{signed: sa, announcement: a}
+	return true, nil
+}
+
+// all returns signed announcements which have not expired yet, including unregistrations.
+func (s *announcementStore) all() []signedAnnouncement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]signedAnnouncement, 0, len(s.items))
+	for id, item := range s.items {
+		if s.expired(item.announcement) {
+			delete(s.items, id)
+			continue
+		}
+		result = append(result, item.signed)
+	}
+	return result
+}
+
+// proposals returns proposals which are announced and not expired.
+func (s *announcementStore) proposals() []market.ServiceProposal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]market.ServiceProposal, 0, len(s.items))
+	for id, item := range s.items {
+		if s.expired(item.announcement) {
+			delete(s.items, id)
+			continue
+		}
+		if !item.announcement.Unregister {
+			// announcements are stored only when signed by the provider of the proposal
+			p := item.announcement.Proposal
+			p.SignatureVerified = true
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func (s *announcementStore) expired(a announcement) bool {
+	return s.timeNow().Sub(time.Unix(0, a.Timestamp)) > s.ttl
+}
@@ -51,6 +51,7 @@ type Discovery struct {
 	ownIdentity      identity.Identity
 	proposalRegistry ProposalRegistry
 	proposalPingTTL  time.Duration
+	keepalive        *keepalive
 	signerCreate     identity.SignerFactory
 	signer           identity.Signer
 	proposal         market.ServiceProposal
@@ -77,6 +78,7 @@ func NewService(
 		identityRegistry:            identityRegistry,
 		proposalRegistry:            proposalRegistry,
 		proposalPingTTL:             proposalPingTTL,
+		keepalive:                   newKeepalive(proposalPingTTL),
 		eventBus:                    eventBus,
 		signerCreate:                signerCreate,
 		statusChan:                  make(chan Status),
@@ -94,6 +96,9 @@ func (d *Discovery) Start(ownIdentity identity.Identity, proposal market.Service
 
 	d.ownIdentity = ownIdentity
 	d.signer = d.signerCreate(ownIdentity)
+	if err := proposal.Sign(d.signer); err != nil {
+		log.Warn().Err(err).Msg("Failed to sign proposal, announcing it unsigned")
+	}
 	d.proposal = proposal
 
 	d.proposalAnnouncementStopped.Add(1)
@@ -189,6 +194,7 @@ func (d *Discovery) registerProposal() {
 		d.changeStatus(RegisterProposal)
 		return
 	}
+	d.keepalive.announced()
 	d.eventBus.Publish(AppTopicProposalAnnounce, d.proposal)
 	d.changeStatus(PingProposal)
 }
@@ -197,13 +203,30 @@ func (d *Discovery) pingProposal() {
 	select {
 	case <-d.stop:
 		return
-	case <-time.After(d.proposalPingTTL):
+	case <-time.After(d.keepalive.interval()):
 		err := d.proposalRegistry.PingProposal(d.proposal, d.signer)
 		if err != nil {
-			log.Error().Err(err).Msg("Failed to ping proposal")
+			log.Error().Err(err).Msg("Failed to ping proposal, re-registering it")
+			err = d.proposalRegistry.RegisterProposal(d.proposal, d.signer)
 		}
 
-		d.eventBus.Publish(AppTopicProposalAnnounce, d.proposal)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to re-register proposal")
+			if d.keepalive.failed() {
+				log.Warn().Msgf("Proposal %s expired in discovery, last announced at %v", d.proposal.ServiceType, d.keepalive.lastAnnouncement())
+				d.eventBus.Publish(AppTopicProposalExpired, AppEventProposalExpired{
+					ProviderID:    d.proposal.ProviderID,
+					ServiceType:   d.proposal.ServiceType,
+					LastAnnounced: d.keepalive.lastAnnouncement(),
+					Error:         err.Error(),
+				})
+			}
+		} else {
+			if d.keepalive.announced() {
+				log.Info().Msgf("Proposal %s is announced in discovery again", d.proposal.ServiceType)
+			}
+			d.eventBus.Publish(AppTopicProposalAnnounce, d.proposal)
+		}
 		d.changeStatus(PingProposal)
 	}
 }
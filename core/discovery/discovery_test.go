@@ -45,6 +45,7 @@ func discoveryWithMockedDependencies() *Discovery {
 		},
 		proposalRegistry: &mockedProposalRegistry{},
 		proposalPingTTL:  1 * time.Minute,
+		keepalive:        newKeepalive(1 * time.Minute),
 		eventBus:         eventbus.New(),
 		stop:             make(chan struct{}),
 	}
@@ -17,6 +17,8 @@
 
 package discovery
 
+import "time"
+
 // Topic represents the different topics a consumer can subscribe to
 const (
 	// AppTopicProposalAdded represents newly announced proposal
@@ -27,4 +29,15 @@ const (
 	AppTopicProposalRemoved = "ProposalRemoved"
 	// AppTopicProposalAnnounce represent proposal events topic.
 	AppTopicProposalAnnounce = "proposalEvent"
+	// AppTopicProposalExpired represents own proposal which could not be re-announced before its discovery TTL expired
+	AppTopicProposalExpired = "ProposalExpired"
 )
+
+// AppEventProposalExpired is published when provider proposal vanishes from discovery,
+// because neither pinging nor re-registering it succeeded within its TTL.
+type AppEventProposalExpired struct {
+	ProviderID    string
+	ServiceType   string
+	LastAnnounced time.Time
+	Error         string
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+import (
+	"sync"
+	"time"
+)
+
+// keepalive tracks announcements of the provider proposal, so that it is re-announced
+// before its discovery TTL expires and expiry is detected when announcements keep failing.
+type keepalive struct {
+	ttl     time.Duration
+	timeNow func() time.Time
+
+	mu            sync.Mutex
+	lastAnnounced time.Time
+	failing       bool
+	expired       bool
+}
+
+func newKeepalive(ttl time.Duration) *keepalive {
+	return &keepalive{
+		ttl:     ttl,
+		timeNow: time.Now,
+	}
+}
+
+// interval returns how long to wait before the next announcement.
+// Proposal is re-announced when a quarter of its TTL is left, failed announcements are retried sooner.
+func (k *keepalive) interval() time.Duration {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.failing {
+		return k.ttl / 10
+	}
+	return k.ttl - k.ttl/4
+}
+
+// announced records successful announcement, reporting whether it restored the expired proposal.
+func (k *keepalive) announced() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	restored := k.expired
+	k.lastAnnounced = k.timeNow()
+	k.failing = false
+	k.expired = false
+	return restored
+}
+
+// failed records failed announcement, reporting whether the proposal has just expired in discovery.
+func (k *keepalive) failed() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.failing = true
+	if k.expired || k.lastAnnounced.IsZero() || k.timeNow().Sub(k.lastAnnounced) < k.ttl {
+		return false
+	}
+	k.expired = true
+	return true
+}
+
+// lastAnnouncement returns time of the last successful announcement.
+func (k *keepalive) lastAnnouncement() time.Time {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.lastAnnounced
+}
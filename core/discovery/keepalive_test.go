@@ -0,0 +1,114 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	identityregistry "github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepalive(t *testing.T) {
+	now := time.Date(2020, 6, 17, 10, 0, 0, 0, time.UTC)
+	k := newKeepalive(time.Minute)
+	k.timeNow = func() time.Time { return now }
+
+	assert.Equal(t, 45*time.Second, k.interval())
+	assert.False(t, k.failed(), "proposal which was never announced can not expire")
+
+	assert.False(t, k.announced())
+	assert.Equal(t, 45*time.Second, k.interval())
+
+	now = now.Add(45 * time.Second)
+	assert.False(t, k.failed())
+	assert.Equal(t, 6*time.Second, k.interval())
+
+	now = now.Add(15 * time.Second)
+	assert.True(t, k.failed())
+	assert.False(t, k.failed(), "expiry is reported once")
+
+	assert.True(t, k.announced())
+	assert.Equal(t, now, k.lastAnnouncement())
+	assert.Equal(t, 45*time.Second, k.interval())
+}
+
+type failingProposalRegistry struct {
+	mu            sync.Mutex
+	registrations int
+	pings         int
+}
+
+func (r *failingProposalRegistry) RegisterProposal(proposal market.ServiceProposal, signer identity.Signer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.registrations++
+	if r.registrations > 1 {
+		return errors.New("discovery is down")
+	}
+	return nil
+}
+
+func (r *failingProposalRegistry) PingProposal(proposal market.ServiceProposal, signer identity.Signer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pings++
+	return errors.New("discovery is down")
+}
+
+func (r *failingProposalRegistry) UnregisterProposal(proposal market.ServiceProposal, signer identity.Signer) error {
+	return nil
+}
+
+func TestPingFailuresPublishExpiredEvent(t *testing.T) {
+	d := discoveryWithMockedDependencies()
+	d.identityRegistry = &identityregistry.FakeRegistry{RegistrationStatus: identityregistry.Registered}
+	registry := &failingProposalRegistry{}
+	d.proposalRegistry = registry
+	d.keepalive = newKeepalive(100 * time.Millisecond)
+
+	expired := make(chan AppEventProposalExpired, 1)
+	err := d.eventBus.Subscribe(AppTopicProposalExpired, func(e AppEventProposalExpired) {
+		expired <- e
+	})
+	assert.NoError(t, err)
+
+	d.Start(providerID, serviceProposal)
+	defer d.Stop()
+
+	select {
+	case e := <-expired:
+		assert.Equal(t, providerID.Address, e.ProviderID)
+		assert.Equal(t, "discovery is down", e.Error)
+		assert.False(t, e.LastAnnounced.IsZero())
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected proposal expired event")
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	assert.True(t, registry.pings > 1)
+	assert.True(t, registry.registrations >= registry.pings, "every failed ping is followed by re-registration")
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/asdine/storm/v3"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	cacheBucket         = "proposal_cache"
+	cacheMetaBucket     = "proposal_cache_meta"
+	cacheLastRefreshKey = "last_refresh"
+)
+
+// CachedProposal is a proposal stored in cache together with the time it was last seen in discovery.
+type CachedProposal struct {
+	ID          string `storm:"id"`
+	Proposal    market.ServiceProposal
+	RefreshedAt time.Time
+}
+
+type cacheStorage interface {
+	Store(bucket string, data interface{}) error
+	GetAllFrom(bucket string, data interface{}) error
+	Delete(bucket string, data interface{}) error
+	SetValue(bucket string, key interface{}, to interface{}) error
+	GetValue(bucket string, key interface{}, to interface{}) error
+}
+
+// Cache is a repository persisting proposals of the underlying repository,
+// so they can be served while discovery is unreachable.
+type Cache struct {
+	delegate Repository
+	storage  cacheStorage
+	ttl      time.Duration
+	timeNow  func() time.Time
+
+	lock sync.Mutex
+}
+
+// NewCache returns a repository caching proposals of the given one for the given time.
+func NewCache(delegate Repository, storage cacheStorage, ttl time.Duration) *Cache {
+	return &Cache{
+		delegate: delegate,
+		storage:  storage,
+		ttl:      ttl,
+		timeNow:  time.Now,
+	}
+}
+
+// Proposal returns a single proposal by its ID, falling back to the cached one if discovery fails.
+func (c *Cache) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	proposal, err := c.delegate.Proposal(id)
+	if err == nil {
+		c.store([]market.ServiceProposal{*proposal}, false)
+		return proposal, nil
+	}
+
+	for _, cached := range c.cached() {
+		if cached.Proposal.UniqueID() == id {
+			log.Warn().Err(err).Msgf("Serving cached proposal %v refreshed at %s", id, cached.RefreshedAt)
+			p := cached.Proposal
+			return &p, nil
+		}
+	}
+	return nil, err
+}
+
+// Proposals returns proposals matching the filter. If discovery fails, proposals
+// received so far are merged with the cached ones.
+func (c *Cache) Proposals(filter *Filter) ([]market.ServiceProposal, error) {
+	proposals, err := c.delegate.Proposals(filter)
+	c.store(proposals, err == nil)
+	if err == nil {
+		return proposals, nil
+	}
+
+	cached := c.cached()
+	if len(cached) == 0 {
+		return proposals, err
+	}
+
+	unique := make(map[market.ProposalID]market.ServiceProposal)
+	for _, cp := range cached {
+		if filter.Matches(cp.Proposal) {
+			unique[cp.Proposal.UniqueID()] = cp.Proposal
+		}
+	}
+	for _, p := range proposals {
+		unique[p.UniqueID()] = p
+	}
+
+	result := make([]market.ServiceProposal, 0, len(unique))
+	for _, p := range unique {
+		result = append(result, p)
+	}
+
+	log.Warn().Err(err).Msgf("Discovery failed, serving %d proposals including cached ones", len(result))
+	return result, nil
+}
+
+// LastRefresh returns the time of the last successful proposal query of discovery.
+func (c *Cache) LastRefresh() (time.Time, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var lastRefresh time.Time
+	err := c.storage.GetValue(cacheMetaBucket, cacheLastRefreshKey, &lastRefresh)
+	if err != nil && !errors.Is(err, storm.ErrNotFound) {
+		return time.Time{}, fmt.Errorf("could not get last proposal refresh: %w", err)
+	}
+	return lastRefresh, nil
+}
+
+// cached returns stored proposals which have not expired yet.
+func (c *Cache) cached() []CachedProposal {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var all []CachedProposal
+	if err := c.storage.GetAllFrom(cacheBucket, &all); err != nil {
+		log.Error().Err(err).Msg("Could not get cached proposals")
+		return nil
+	}
+
+	var result []CachedProposal
+	for _, cp := range all {
+		if c.timeNow().Sub(cp.RefreshedAt) <= c.ttl {
+			result = append(result, cp)
+		}
+	}
+	return result
+}
+
+func (c *Cache) store(proposals []market.ServiceProposal, refreshed bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.timeNow().UTC()
+	for _, p := range proposals {
+		cp := CachedProposal{
+			ID:          p.UniqueID().ProviderID + "/" + p.UniqueID().ServiceType,
+			Proposal:    p,
+			RefreshedAt: now,
+		}
+		if err := c.storage.Store(cacheBucket, &cp); err != nil {
+			log.Error().Err(err).Msg("Could not cache proposal")
+		}
+	}
+	if refreshed {
+		if err := c.storage.SetValue(cacheMetaBucket, cacheLastRefreshKey, now); err != nil {
+			log.Error().Err(err).Msg("Could not store last proposal refresh")
+		}
+	}
+	c.purgeExpired()
+}
+
+func (c *Cache) purgeExpired() {
+	var all []CachedProposal
+	if err := c.storage.GetAllFrom(cacheBucket, &all); err != nil {
+		log.Error().Err(err).Msg("Could not get cached proposals")
+		return
+	}
+
+	for i := range all {
+		if c.timeNow().Sub(all[i].RefreshedAt) > c.ttl {
+			if err := c.storage.Delete(cacheBucket, &all[i]); err != nil {
+				log.Error().Err(err).Msg("Could not delete expired cached proposal")
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepository struct {
+	proposals []market.ServiceProposal
+	err       error
+}
+
+func (m *mockRepository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for i := range m.proposals {
+		if m.proposals[i].UniqueID() == id {
+			return &m.proposals[i], nil
+		}
+	}
+	return nil, errors.New("proposal not found")
+}
+
+func (m *mockRepository) Proposals(filter *Filter) ([]market.ServiceProposal, error) {
+	return m.proposals, m.err
+}
+
+func proposalIDs(proposals []market.ServiceProposal) []market.ProposalID {
+	ids := make([]market.ProposalID, len(proposals))
+	for i := range proposals {
+		ids[i] = proposals[i].UniqueID()
+	}
+	return ids
+}
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proposalCacheTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	defer bolt.Close()
+
+	proposal1 := market.ServiceProposal{ProviderID: "0x1", ServiceType: "wireguard"}
+	proposal2 := market.ServiceProposal{ProviderID: "0x2", ServiceType: "openvpn"}
+	delegate := &mockRepository{proposals: []market.ServiceProposal{proposal1, proposal2}}
+
+	now := time.Date(2020, 6, 17, 10, 0, 0, 0, time.UTC)
+	cache := NewCache(delegate, bolt, time.Hour)
+	cache.timeNow = func() time.Time { return now }
+
+	lastRefresh, err := cache.LastRefresh()
+	assert.NoError(t, err)
+	assert.True(t, lastRefresh.IsZero())
+
+	proposals, err := cache.Proposals(&Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 2)
+
+	lastRefresh, err = cache.LastRefresh()
+	assert.NoError(t, err)
+	assert.Equal(t, now, lastRefresh)
+
+	// discovery is unreachable
+	delegate.proposals, delegate.err = nil, errors.New("discovery unreachable")
+	now = now.Add(30 * time.Minute)
+
+	proposals, err = cache.Proposals(&Filter{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []market.ProposalID{proposal1.UniqueID(), proposal2.UniqueID()}, proposalIDs(proposals))
+
+	proposals, err = cache.Proposals(&Filter{ServiceType: "openvpn"})
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ProposalID{proposal2.UniqueID()}, proposalIDs(proposals))
+
+	p, err := cache.Proposal(proposal1.UniqueID())
+	assert.NoError(t, err)
+	assert.Equal(t, proposal1.UniqueID(), p.UniqueID())
+
+	lastRefresh, err = cache.LastRefresh()
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-30*time.Minute), lastRefresh)
+
+	// cached proposals expired
+	now = now.Add(time.Hour)
+
+	_, err = cache.Proposals(&Filter{})
+	assert.EqualError(t, err, "discovery unreachable")
+
+	_, err = cache.Proposal(proposal1.UniqueID())
+	assert.EqualError(t, err, "discovery unreachable")
+}
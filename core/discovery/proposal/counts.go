@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"sort"
+
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// Count is a number of proposals of the service type offered by providers in the country.
+type Count struct {
+	Country     string
+	ServiceType string
+	Count       int
+}
+
+// Counts returns numbers of proposals matching the filter, grouped by provider country and service type.
+// Counts are sorted by country and service type, proposals without location are counted under empty country.
+func Counts(repository Repository, filter *Filter) ([]Count, error) {
+	proposals, err := repository.Proposals(filter)
+	if err != nil {
+		return nil, err
+	}
+	return countProposals(proposals), nil
+}
+
+func countProposals(proposals []market.ServiceProposal) []Count {
+	type key struct {
+		country     string
+		serviceType string
+	}
+
+	groups := make(map[key]int)
+	for _, p := range proposals {
+		var country string
+		if p.ServiceDefinition != nil {
+			country = p.ServiceDefinition.GetLocation().Country
+		}
+		groups[key{country: country, serviceType: p.ServiceType}]++
+	}
+
+	counts := make([]Count, 0, len(groups))
+	for k, count := range groups {
+		counts = append(counts, Count{Country: k.country, ServiceType: k.serviceType, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Country != counts[j].Country {
+			return counts[i].Country < counts[j].Country
+		}
+		return counts[i].ServiceType < counts[j].ServiceType
+	})
+	return counts
+}
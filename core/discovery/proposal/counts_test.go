@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounts(t *testing.T) {
+	repository := &mockRepository{proposals: []market.ServiceProposal{
+		proposalProvider1Streaming,
+		proposalProvider2Streaming,
+		proposalProvider1Noop,
+		{ProviderID: provider2, ServiceType: serviceTypeNoop},
+		{ProviderID: "0x3", ServiceType: serviceTypeStreaming, ServiceDefinition: mockService{Location: locationDatacenter}},
+	}}
+
+	counts, err := Counts(repository, &Filter{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Count{
+		{Country: "", ServiceType: serviceTypeNoop, Count: 2},
+		{Country: "DE", ServiceType: serviceTypeStreaming, Count: 2},
+		{Country: "LT", ServiceType: serviceTypeStreaming, Count: 1},
+	}, counts)
+
+	_, err = Counts(&mockRepository{err: errors.New("discovery is down")}, &Filter{})
+	assert.EqualError(t, err, "discovery is down")
+}
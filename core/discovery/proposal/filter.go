@@ -30,6 +30,8 @@ type Filter struct {
 	ProviderID          string
 	ServiceType         string
 	LocationType        string
+	LocationCountry     string
+	LocationCountries   []string
 	AccessPolicyID      string
 	AccessPolicySource  string
 	UpperTimePriceBound *big.Int
@@ -38,6 +40,8 @@ type Filter struct {
 	LowerGBPriceBound   *big.Int
 	ExcludeUnsupported  bool
 	IncludeFailed       bool
+	// QualityMin is the minimal quality rating from 0 to 1, applied by repositories aware of proposal quality
+	QualityMin float64
 }
 
 // Matches return flag if filter matches given proposal
@@ -57,6 +61,12 @@ func (filter *Filter) Matches(proposal market.ServiceProposal) bool {
 	if filter.LocationType != "" {
 		conditions = append(conditions, reducer.Equal(reducer.LocationType, filter.LocationType))
 	}
+	if filter.LocationCountry != "" {
+		conditions = append(conditions, reducer.Equal(reducer.LocationCountry, filter.LocationCountry))
+	}
+	if len(filter.LocationCountries) > 0 {
+		conditions = append(conditions, reducer.InString(reducer.LocationCountry, filter.LocationCountries...))
+	}
 	if filter.AccessPolicyID != "" || filter.AccessPolicySource != "" {
 		conditions = append(conditions, reducer.AccessPolicy(filter.AccessPolicyID, filter.AccessPolicySource))
 	}
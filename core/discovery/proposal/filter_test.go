@@ -194,6 +194,16 @@ func Test_ProposalFilter_FiltersByLocationType(t *testing.T) {
 	assert.True(t, filter.Matches(proposalProvider2Streaming))
 }
 
+func Test_ProposalFilter_FiltersByLocationCountry(t *testing.T) {
+	filter := &Filter{
+		LocationCountry: "DE",
+	}
+	assert.False(t, filter.Matches(proposalEmpty))
+	assert.True(t, filter.Matches(proposalProvider1Streaming))
+	assert.False(t, filter.Matches(proposalProvider1Noop))
+	assert.False(t, filter.Matches(proposalProvider2Streaming))
+}
+
 func Test_ProposalFilter_FiltersByAccessID(t *testing.T) {
 	filter := &Filter{
 		AccessPolicyID: "whitelist",
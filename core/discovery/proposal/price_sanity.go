@@ -0,0 +1,168 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// priceMedianTTL is how long network median prices are reused before they are calculated again.
+const priceMedianTTL = 10 * time.Minute
+
+// PriceSanity flags proposals priced far above the network median, optionally hiding them from listings.
+type PriceSanity struct {
+	Repository
+	maxRatio float64
+	hide     bool
+	timeNow  func() time.Time
+
+	lock         sync.Mutex
+	medianGiB    float64
+	medianMinute float64
+	calculatedAt time.Time
+}
+
+// NewPriceSanity wraps the repository to flag proposals which price per GiB or per minute
+// exceeds the network median more than maxRatio times. Flagged proposals are dropped from
+// listings when hide is set, single proposal lookups always return them flagged.
+func NewPriceSanity(repository Repository, maxRatio float64, hide bool) *PriceSanity {
+	return &PriceSanity{
+		Repository: repository,
+		maxRatio:   maxRatio,
+		hide:       hide,
+		timeNow:    time.Now,
+	}
+}
+
+// Proposal returns the proposal flagged when it is a price outlier.
+func (ps *PriceSanity) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	p, err := ps.Repository.Proposal(id)
+	if err != nil || p == nil {
+		return p, err
+	}
+
+	medianGiB, medianMinute := ps.medians()
+	checked := *p
+	checked.PriceOutlier = ps.isOutlier(checked, medianGiB, medianMinute)
+	return &checked, nil
+}
+
+// Proposals returns proposals matching the filter with price outliers flagged or hidden.
+func (ps *PriceSanity) Proposals(filter *Filter) ([]market.ServiceProposal, error) {
+	proposals, err := ps.Repository.Proposals(filter)
+	if len(proposals) == 0 {
+		return proposals, err
+	}
+
+	medianGiB, medianMinute := ps.medians()
+	checked := make([]market.ServiceProposal, 0, len(proposals))
+	for _, p := range proposals {
+		p.PriceOutlier = ps.isOutlier(p, medianGiB, medianMinute)
+		if p.PriceOutlier && ps.hide {
+			continue
+		}
+		checked = append(checked, p)
+	}
+	return checked, err
+}
+
+func (ps *PriceSanity) isOutlier(p market.ServiceProposal, medianGiB, medianMinute float64) bool {
+	if price, ok := pricePerGiB(p); ok && medianGiB > 0 && price > ps.maxRatio*medianGiB {
+		return true
+	}
+	if price, ok := pricePerMinute(p); ok && medianMinute > 0 && price > ps.maxRatio*medianMinute {
+		return true
+	}
+	return false
+}
+
+// medians returns network median prices per GiB and per minute, calculating them over all proposals when they are stale.
+func (ps *PriceSanity) medians() (float64, float64) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	now := ps.timeNow()
+	if !ps.calculatedAt.IsZero() && now.Sub(ps.calculatedAt) < priceMedianTTL {
+		return ps.medianGiB, ps.medianMinute
+	}
+
+	proposals, _ := ps.Repository.Proposals(&Filter{ExcludeUnsupported: true})
+	if len(proposals) == 0 {
+		return ps.medianGiB, ps.medianMinute
+	}
+
+	var gibPrices, minutePrices []float64
+	for _, p := range proposals {
+		if price, ok := pricePerGiB(p); ok {
+			gibPrices = append(gibPrices, price)
+		}
+		if price, ok := pricePerMinute(p); ok {
+			minutePrices = append(minutePrices, price)
+		}
+	}
+	ps.medianGiB = median(gibPrices)
+	ps.medianMinute = median(minutePrices)
+	ps.calculatedAt = now
+	return ps.medianGiB, ps.medianMinute
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[middle-1] + values[middle]) / 2
+	}
+	return values[middle]
+}
+
+func pricePerGiB(p market.ServiceProposal) (float64, bool) {
+	amount, ok := priceAmount(p)
+	if !ok || p.PaymentMethod.GetRate().PerByte == 0 {
+		return 0, false
+	}
+	return amount * float64(datasize.GiB.Bytes()) / float64(p.PaymentMethod.GetRate().PerByte), true
+}
+
+func pricePerMinute(p market.ServiceProposal) (float64, bool) {
+	amount, ok := priceAmount(p)
+	if !ok || p.PaymentMethod.GetRate().PerTime == 0 {
+		return 0, false
+	}
+	return amount * float64(time.Minute) / float64(p.PaymentMethod.GetRate().PerTime), true
+}
+
+func priceAmount(p market.ServiceProposal) (float64, bool) {
+	if _, unsupported := p.PaymentMethod.(market.UnsupportedPaymentMethod); unsupported || p.PaymentMethod == nil {
+		return 0, false
+	}
+	price := p.PaymentMethod.GetPrice()
+	if price.Amount == nil {
+		return 0, false
+	}
+	amount, _ := new(big.Float).SetInt(price.Amount).Float64()
+	return amount, true
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func pricedProposal(providerID string, pricePerMinute int64) market.ServiceProposal {
+	return market.ServiceProposal{
+		ID:          1,
+		ProviderID:  providerID,
+		ServiceType: "wireguard",
+		PaymentMethod: &mockPaymentMethod{
+			price: money.NewMoney(big.NewInt(pricePerMinute), money.CurrencyMyst),
+			rate:  market.PaymentRate{PerTime: time.Minute},
+		},
+	}
+}
+
+func TestPriceSanity_FlagsOutliers(t *testing.T) {
+	delegate := &mockRepository{proposals: []market.ServiceProposal{
+		pricedProposal("0x1", 100),
+		pricedProposal("0x2", 120),
+		pricedProposal("0x3", 150),
+		pricedProposal("0x4", 100000),
+	}}
+	repository := NewPriceSanity(delegate, 10, false)
+
+	proposals, err := repository.Proposals(&Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 4)
+	assert.False(t, proposals[0].PriceOutlier)
+	assert.False(t, proposals[2].PriceOutlier)
+	assert.True(t, proposals[3].PriceOutlier)
+	assert.False(t, delegate.proposals[3].PriceOutlier, "proposals of the delegate must not be modified")
+
+	p, err := repository.Proposal(market.NewProposalID("0x4", "wireguard", 1))
+	assert.NoError(t, err)
+	assert.True(t, p.PriceOutlier)
+}
+
+func TestPriceSanity_HidesOutliers(t *testing.T) {
+	delegate := &mockRepository{proposals: []market.ServiceProposal{
+		pricedProposal("0x1", 100),
+		pricedProposal("0x2", 100000),
+		pricedProposal("0x3", 150),
+	}}
+	repository := NewPriceSanity(delegate, 10, true)
+
+	proposals, err := repository.Proposals(&Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ProposalID{
+		market.NewProposalID("0x1", "wireguard", 1),
+		market.NewProposalID("0x3", "wireguard", 1),
+	}, proposalIDs(proposals))
+}
+
+func TestPriceSanity_ReusesMedians(t *testing.T) {
+	delegate := &mockRepository{proposals: []market.ServiceProposal{pricedProposal("0x1", 100)}}
+	now := time.Date(2020, 6, 17, 10, 0, 0, 0, time.UTC)
+	repository := NewPriceSanity(delegate, 10, true)
+	repository.timeNow = func() time.Time { return now }
+
+	proposals, err := repository.Proposals(&Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 1)
+
+	delegate.proposals = []market.ServiceProposal{pricedProposal("0x2", 100000)}
+	proposals, err = repository.Proposals(&Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 0, "median of the previous calculation is reused")
+
+	now = now.Add(priceMedianTTL)
+	proposals, err = repository.Proposals(&Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 1)
+}
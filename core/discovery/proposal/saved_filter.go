@@ -0,0 +1,176 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/asdine/storm/v3"
+)
+
+const savedFiltersBucket = "proposal_saved_filters"
+
+// ErrSavedFilterNotFound is returned when there is no saved filter with the given name.
+var ErrSavedFilterNotFound = errors.New("saved filter not found")
+
+// SavedFilter is a named set of proposal conditions persisted by the node.
+type SavedFilter struct {
+	Name string `storm:"id"`
+	// Countries lists accepted provider countries, any country is accepted if empty
+	Countries []string
+	// IPType is the accepted provider IP type, e.g. "residential"
+	IPType string
+	// UpperTimePrice is the price ceiling per minute
+	UpperTimePrice *big.Int
+	// UpperGBPrice is the price ceiling per GiB
+	UpperGBPrice *big.Int
+	// QualityMin is the minimal quality rating from 0 to 1
+	QualityMin float64
+}
+
+// Validate checks that saved filter conditions are sane.
+func (sf SavedFilter) Validate() error {
+	if strings.TrimSpace(sf.Name) == "" {
+		return errors.New("filter name is required")
+	}
+	if sf.QualityMin < 0 || sf.QualityMin > 1 {
+		return fmt.Errorf("quality floor must be between 0 and 1, got %v", sf.QualityMin)
+	}
+	if sf.UpperTimePrice != nil && sf.UpperTimePrice.Sign() < 0 {
+		return errors.New("price ceiling per minute can not be negative")
+	}
+	if sf.UpperGBPrice != nil && sf.UpperGBPrice.Sign() < 0 {
+		return errors.New("price ceiling per GiB can not be negative")
+	}
+	return nil
+}
+
+// ApplyTo narrows the given filter with conditions of the saved filter.
+func (sf SavedFilter) ApplyTo(filter *Filter) {
+	if len(sf.Countries) > 0 {
+		filter.LocationCountries = sf.Countries
+	}
+	if sf.IPType != "" {
+		filter.LocationType = sf.IPType
+	}
+	if sf.UpperTimePrice != nil {
+		filter.UpperTimePriceBound = minBound(filter.UpperTimePriceBound, sf.UpperTimePrice)
+		if filter.LowerTimePriceBound == nil {
+			filter.LowerTimePriceBound = new(big.Int)
+		}
+	}
+	if sf.UpperGBPrice != nil {
+		filter.UpperGBPriceBound = minBound(filter.UpperGBPriceBound, sf.UpperGBPrice)
+		if filter.LowerGBPriceBound == nil {
+			filter.LowerGBPriceBound = new(big.Int)
+		}
+	}
+	if sf.QualityMin > filter.QualityMin {
+		filter.QualityMin = sf.QualityMin
+	}
+}
+
+func minBound(current, ceiling *big.Int) *big.Int {
+	if current == nil || ceiling.Cmp(current) < 0 {
+		return new(big.Int).Set(ceiling)
+	}
+	return current
+}
+
+type savedFilterBolt interface {
+	Store(bucket string, data interface{}) error
+	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
+	GetAllFrom(bucket string, data interface{}) error
+	Delete(bucket string, data interface{}) error
+}
+
+// SavedFilterStorage persists named proposal filters.
+type SavedFilterStorage struct {
+	lock sync.Mutex
+	bolt savedFilterBolt
+}
+
+// NewSavedFilterStorage returns a new instance of the saved filter storage.
+func NewSavedFilterStorage(bolt savedFilterBolt) *SavedFilterStorage {
+	return &SavedFilterStorage{bolt: bolt}
+}
+
+// Store validates and stores the filter, overriding the one with the same name.
+func (s *SavedFilterStorage) Store(filter SavedFilter) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	for i := range filter.Countries {
+		filter.Countries[i] = strings.ToUpper(strings.TrimSpace(filter.Countries[i]))
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.bolt.Store(savedFiltersBucket, &filter); err != nil {
+		return fmt.Errorf("could not store saved filter: %w", err)
+	}
+	return nil
+}
+
+// Get returns the saved filter with the given name.
+func (s *SavedFilterStorage) Get(name string) (SavedFilter, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var filter SavedFilter
+	err := s.bolt.GetOneByField(savedFiltersBucket, "Name", name, &filter)
+	if errors.Is(err, storm.ErrNotFound) {
+		return SavedFilter{}, ErrSavedFilterNotFound
+	}
+	if err != nil {
+		return SavedFilter{}, fmt.Errorf("could not get saved filter: %w", err)
+	}
+	return filter, nil
+}
+
+// List returns all saved filters.
+func (s *SavedFilterStorage) List() ([]SavedFilter, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	filters := []SavedFilter{}
+	if err := s.bolt.GetAllFrom(savedFiltersBucket, &filters); err != nil {
+		return nil, fmt.Errorf("could not list saved filters: %w", err)
+	}
+	return filters, nil
+}
+
+// Delete removes the saved filter with the given name.
+func (s *SavedFilterStorage) Delete(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := s.bolt.Delete(savedFiltersBucket, &SavedFilter{Name: name})
+	if errors.Is(err, storm.ErrNotFound) {
+		return ErrSavedFilterNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("could not delete saved filter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedFilter_ApplyTo(t *testing.T) {
+	savedFilter := SavedFilter{
+		Name:           "streaming",
+		Countries:      []string{"DE"},
+		IPType:         "residential",
+		UpperTimePrice: big.NewInt(100),
+		QualityMin:     0.5,
+	}
+
+	filter := &Filter{ServiceType: serviceTypeStreaming, UpperTimePriceBound: big.NewInt(50)}
+	savedFilter.ApplyTo(filter)
+	assert.Equal(t, &Filter{
+		ServiceType:         serviceTypeStreaming,
+		LocationCountries:   []string{"DE"},
+		LocationType:        "residential",
+		LowerTimePriceBound: big.NewInt(0),
+		UpperTimePriceBound: big.NewInt(50),
+		QualityMin:          0.5,
+	}, filter)
+
+	filter = &Filter{UpperTimePriceBound: big.NewInt(500), QualityMin: 0.9}
+	savedFilter.ApplyTo(filter)
+	assert.Equal(t, big.NewInt(100), filter.UpperTimePriceBound)
+	assert.Equal(t, 0.9, filter.QualityMin)
+}
+
+func TestSavedFilter_MatchesCountries(t *testing.T) {
+	filter := &Filter{}
+	SavedFilter{Countries: []string{"DE", "NL"}}.ApplyTo(filter)
+
+	assert.True(t, filter.Matches(proposalProvider1Streaming))
+	assert.False(t, filter.Matches(proposalProvider2Streaming))
+}
+
+func TestSavedFilterStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "savedFilterTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	defer bolt.Close()
+
+	storage := NewSavedFilterStorage(bolt)
+
+	_, err = storage.Get("streaming")
+	assert.Equal(t, ErrSavedFilterNotFound, err)
+
+	err = storage.Store(SavedFilter{Name: "streaming", QualityMin: 2})
+	assert.Error(t, err)
+
+	err = storage.Store(SavedFilter{Name: "streaming", Countries: []string{" de"}, UpperGBPrice: big.NewInt(10)})
+	assert.NoError(t, err)
+
+	filter, err := storage.Get("streaming")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"DE"}, filter.Countries)
+	assert.Equal(t, big.NewInt(10), filter.UpperGBPrice)
+
+	filters, err := storage.List()
+	assert.NoError(t, err)
+	assert.Len(t, filters, 1)
+
+	assert.NoError(t, storage.Delete("streaming"))
+	assert.Equal(t, ErrSavedFilterNotFound, storage.Delete("streaming"))
+
+	filters, err = storage.List()
+	assert.NoError(t, err)
+	assert.Len(t, filters, 0)
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import "github.com/mysteriumnetwork/node/market"
+
+// Target selects proposals of a single provider or of any provider located in the given country
+type Target struct {
+	// ProviderID selects a single provider, takes precedence over Country
+	ProviderID string
+	// Country selects any provider located in the given country
+	Country     string
+	ServiceType string
+}
+
+// Find returns at most limit proposals matching the target, best one first.
+func (t Target) Find(repository Repository, limit int) ([]market.ServiceProposal, error) {
+	if t.ProviderID != "" {
+		p, err := repository.Proposal(market.ProposalID{
+			ProviderID:  t.ProviderID,
+			ServiceType: t.ServiceType,
+		})
+		if err != nil || p == nil {
+			return nil, err
+		}
+		return []market.ServiceProposal{*p}, nil
+	}
+
+	proposals, err := repository.Proposals(&Filter{
+		ServiceType:        t.ServiceType,
+		LocationCountry:    t.Country,
+		ExcludeUnsupported: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(proposals) > limit {
+		proposals = proposals[:limit]
+	}
+	return proposals, nil
+}
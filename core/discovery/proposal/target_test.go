@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/market"
+)
+
+func Test_Target_FindsProvider(t *testing.T) {
+	repository := &repositoryStub{proposals: []market.ServiceProposal{proposalProvider1Streaming, proposalProvider2Streaming}}
+
+	proposals, err := Target{ProviderID: provider2, ServiceType: serviceTypeStreaming}.Find(repository, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{proposalProvider2Streaming}, proposals)
+
+	proposals, err = Target{ProviderID: provider2, ServiceType: serviceTypeNoop}.Find(repository, 5)
+	assert.NoError(t, err)
+	assert.Empty(t, proposals)
+}
+
+func Test_Target_FindsCountry(t *testing.T) {
+	repository := &repositoryStub{proposals: []market.ServiceProposal{proposalProvider1Streaming, proposalProvider1Noop, proposalProvider2Streaming}}
+
+	proposals, err := Target{Country: "DE"}.Find(repository, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{proposalProvider1Streaming}, proposals)
+	assert.True(t, repository.filter.ExcludeUnsupported)
+
+	proposals, err = Target{ServiceType: serviceTypeStreaming}.Find(repository, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{proposalProvider1Streaming}, proposals)
+}
+
+type repositoryStub struct {
+	proposals []market.ServiceProposal
+	filter    *Filter
+}
+
+func (r *repositoryStub) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	for _, p := range r.proposals {
+		if p.ProviderID == id.ProviderID && p.ServiceType == id.ServiceType {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *repositoryStub) Proposals(filter *Filter) ([]market.ServiceProposal, error) {
+	r.filter = filter
+
+	// test proposals carry no contacts, so none of them is supported
+	matcher := *filter
+	matcher.ExcludeUnsupported = false
+
+	var proposals []market.ServiceProposal
+	for _, p := range r.proposals {
+		if matcher.Matches(p) {
+			proposals = append(proposals, p)
+		}
+	}
+	return proposals, nil
+}
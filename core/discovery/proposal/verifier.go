@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"errors"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/rs/zerolog/log"
+)
+
+// SignatureVerifier checks provider signatures of proposals ingested from discovery.
+type SignatureVerifier struct {
+	requireSignature bool
+}
+
+// NewSignatureVerifier returns a new instance of the verifier.
+// Proposals without a valid signature are accepted as unverified unless signature is required,
+// as older providers do not sign proposals and newer ones may sign them over fields unknown to this node.
+func NewSignatureVerifier(requireSignature bool) *SignatureVerifier {
+	return &SignatureVerifier{requireSignature: requireSignature}
+}
+
+// Verify flags the proposal with a valid provider signature as verified,
+// and reports whether the proposal should be accepted.
+// Nil verifier accepts all proposals without verifying them.
+func (v *SignatureVerifier) Verify(p *market.ServiceProposal) bool {
+	if v == nil {
+		return true
+	}
+
+	err := p.VerifySignature()
+	switch {
+	case err == nil:
+		p.SignatureVerified = true
+		return true
+	case !v.requireSignature:
+		return true
+	case errors.Is(err, market.ErrProposalUnsigned):
+		return false
+	default:
+		log.Warn().Err(err).Msgf("Dropping proposal %v with invalid signature", p.UniqueID())
+		return false
+	}
+}
+
+// Filter returns accepted proposals, flagging verified ones.
+func (v *SignatureVerifier) Filter(proposals []market.ServiceProposal) []market.ServiceProposal {
+	if v == nil {
+		return proposals
+	}
+
+	res := make([]market.ServiceProposal, 0, len(proposals))
+	for _, p := range proposals {
+		if v.Verify(&p) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
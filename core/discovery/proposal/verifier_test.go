@@ -0,0 +1,66 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedProposal(t *testing.T) market.ServiceProposal {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	ks := identity.NewKeystoreMemory()
+	account, err := ks.ImportECDSA(key, "")
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Unlock(account, ""))
+	provider := identity.FromAddress(account.Address.Hex())
+
+	p := market.ServiceProposal{ID: 1, ProviderID: provider.Address, ServiceType: serviceTypeStreaming}
+	assert.NoError(t, p.Sign(identity.NewSigner(ks, provider)))
+	return p
+}
+
+func TestSignatureVerifier_Filter(t *testing.T) {
+	signed := signedProposal(t)
+	unsigned := market.ServiceProposal{ID: 1, ProviderID: provider2, ServiceType: serviceTypeStreaming}
+	forged := signedProposal(t)
+	forged.ProviderID = signed.ProviderID
+	forged.ID = 2
+	newer := signedProposal(t)
+	newer.SignatureVersion++
+
+	proposals := NewSignatureVerifier(false).Filter([]market.ServiceProposal{signed, unsigned, forged, newer})
+	assert.Len(t, proposals, 4)
+	assert.Equal(t, signed.UniqueID(), proposals[0].UniqueID())
+	assert.True(t, proposals[0].SignatureVerified)
+	for _, p := range proposals[1:] {
+		assert.False(t, p.SignatureVerified)
+	}
+
+	proposals = NewSignatureVerifier(true).Filter([]market.ServiceProposal{signed, unsigned, forged, newer})
+	assert.Len(t, proposals, 1)
+	assert.Equal(t, signed.UniqueID(), proposals[0].UniqueID())
+
+	var verifier *SignatureVerifier
+	assert.Len(t, verifier.Filter([]market.ServiceProposal{signed, unsigned, forged, newer}), 4)
+}
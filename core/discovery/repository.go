@@ -29,6 +29,7 @@ import (
 // repository provides proposals from multiple other repositories.
 type repository struct {
 	delegates []proposal.Repository
+	fallbacks []proposal.Repository
 }
 
 // NewRepository constructs a new composite repository.
@@ -41,11 +42,17 @@ func (c *repository) Add(repository proposal.Repository) {
 	c.delegates = append(c.delegates, repository)
 }
 
+// AddFallback adds a repository which is used only when delegate repositories fail.
+func (c *repository) AddFallback(repository proposal.Repository) {
+	c.fallbacks = append(c.fallbacks, repository)
+}
+
 // Proposal returns a single proposal by its ID.
 func (c *repository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
 	allErrors := utils.ErrorCollection{}
 
-	for _, delegate := range c.delegates {
+	delegates := append(append([]proposal.Repository{}, c.delegates...), c.fallbacks...)
+	for _, delegate := range delegates {
 		serviceProposal, err := delegate.Proposal(id)
 		if err == nil {
 			return serviceProposal, nil
@@ -58,12 +65,29 @@ func (c *repository) Proposal(id market.ProposalID) (*market.ServiceProposal, er
 
 // Proposals returns proposals matching the filter.
 func (c *repository) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
-	log.Debug().Msgf("Retrieving proposals from %d repositories", len(c.delegates))
-	proposals := make([][]market.ServiceProposal, len(c.delegates))
-	errors := make([]error, len(c.delegates))
+	result, err := c.proposals(c.delegates, filter)
+	if (err == nil && len(c.delegates) > 0) || len(c.fallbacks) == 0 {
+		return result, err
+	}
+
+	log.Warn().Err(err).Msgf("Retrieving proposals from %d fallback repositories", len(c.fallbacks))
+	fallbackResult, fallbackErr := c.proposals(c.fallbacks, filter)
+	if fallbackErr != nil && len(fallbackResult) == 0 {
+		if err == nil {
+			err = fallbackErr
+		}
+		return result, err
+	}
+	return mergeProposals(result, fallbackResult), nil
+}
+
+func (c *repository) proposals(delegates []proposal.Repository, filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	log.Debug().Msgf("Retrieving proposals from %d repositories", len(delegates))
+	proposals := make([][]market.ServiceProposal, len(delegates))
+	errors := make([]error, len(delegates))
 
 	var wg sync.WaitGroup
-	for i, delegate := range c.delegates {
+	for i, delegate := range delegates {
 		wg.Add(1)
 		go func(idx int, repo proposal.Repository) {
 			defer wg.Done()
@@ -72,10 +96,23 @@ func (c *repository) Proposals(filter *proposal.Filter) ([]market.ServiceProposa
 	}
 	wg.Wait()
 
-	uniqueProposals := make(map[market.ProposalID]market.ServiceProposal)
 	for i, repoProposals := range proposals {
 		log.Trace().Msgf("Retrieved %d proposals from repository %d", len(repoProposals), i)
-		for _, p := range repoProposals {
+	}
+	result := mergeProposals(proposals...)
+
+	allErrors := utils.ErrorCollection{}
+	allErrors.Add(errors...)
+
+	log.Err(allErrors.Error()).Msgf("Returning %d unique proposals", len(result))
+	return result, allErrors.Error()
+}
+
+// mergeProposals returns unique proposals of the given lists.
+func mergeProposals(lists ...[]market.ServiceProposal) []market.ServiceProposal {
+	uniqueProposals := make(map[market.ProposalID]market.ServiceProposal)
+	for _, list := range lists {
+		for _, p := range list {
 			uniqueProposals[p.UniqueID()] = p
 		}
 	}
@@ -84,10 +121,5 @@ func (c *repository) Proposals(filter *proposal.Filter) ([]market.ServiceProposa
 	for _, val := range uniqueProposals {
 		result = append(result, val)
 	}
-
-	allErrors := utils.ErrorCollection{}
-	allErrors.Add(errors...)
-
-	log.Err(allErrors.Error()).Msgf("Returning %d unique proposals", len(result))
-	return result, allErrors.Error()
+	return result
 }
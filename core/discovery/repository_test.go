@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+type repositoryStub struct {
+	proposals []market.ServiceProposal
+	err       error
+	queried   bool
+}
+
+func (r *repositoryStub) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	r.queried = true
+	if r.err != nil {
+		return nil, r.err
+	}
+	for i := range r.proposals {
+		if r.proposals[i].UniqueID() == id {
+			return &r.proposals[i], nil
+		}
+	}
+	return nil, errors.New("proposal not found")
+}
+
+func (r *repositoryStub) Proposals(_ *proposal.Filter) ([]market.ServiceProposal, error) {
+	r.queried = true
+	return r.proposals, r.err
+}
+
+func TestRepository_FallbackIsUsedWhenDelegatesFail(t *testing.T) {
+	primaryProposal := market.ServiceProposal{ProviderID: "0x1", ServiceType: "wireguard"}
+	fallbackProposal := market.ServiceProposal{ProviderID: "0x2", ServiceType: "wireguard"}
+	primary := &repositoryStub{proposals: []market.ServiceProposal{primaryProposal}}
+	fallback := &repositoryStub{proposals: []market.ServiceProposal{fallbackProposal}}
+
+	repo := NewRepository()
+	repo.Add(primary)
+	repo.AddFallback(fallback)
+
+	proposals, err := repo.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{primaryProposal}, proposals)
+	assert.False(t, fallback.queried)
+
+	primary.proposals, primary.err = nil, errors.New("discovery API is down")
+	proposals, err = repo.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{fallbackProposal}, proposals)
+
+	p, err := repo.Proposal(fallbackProposal.UniqueID())
+	assert.NoError(t, err)
+	assert.Equal(t, fallbackProposal, *p)
+
+	fallback.err = errors.New("no DHT peers")
+	fallback.proposals = nil
+	_, err = repo.Proposals(&proposal.Filter{})
+	assert.EqualError(t, err, "ErrorCollection: discovery API is down")
+}
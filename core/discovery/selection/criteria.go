@@ -0,0 +1,305 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package selection
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/consumer/session"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/core/quality"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// unknownRating is given to candidates there is no data about
+const unknownRating = 0.5
+
+// successRating returns success ratio, smoothed towards unknown rating when there are few attempts.
+func successRating(success, total int) float64 {
+	return float64(success+1) / float64(total+2)
+}
+
+// PriceCriterion prefers cheaper proposals.
+type PriceCriterion struct{}
+
+// Rate rates candidates by their price relative to the cheapest one.
+func (PriceCriterion) Rate(candidates []market.ServiceProposal, _ Policy) ([]float64, error) {
+	costs := make([]float64, len(candidates))
+	cheapest := -1.0
+	for i, p := range candidates {
+		costs[i] = proposalCost(p)
+		if cheapest < 0 || costs[i] < cheapest {
+			cheapest = costs[i]
+		}
+	}
+
+	ratings := make([]float64, len(candidates))
+	for i, cost := range costs {
+		if cost == 0 {
+			ratings[i] = 1
+		} else {
+			ratings[i] = cheapest / cost
+		}
+	}
+	return ratings, nil
+}
+
+// proposalCost estimates price of an hour long connection transferring a gibibyte of data.
+func proposalCost(p market.ServiceProposal) float64 {
+	if _, unsupported := p.PaymentMethod.(market.UnsupportedPaymentMethod); unsupported || p.PaymentMethod == nil {
+		return 0
+	}
+	price := p.PaymentMethod.GetPrice()
+	if price.Amount == nil {
+		return 0
+	}
+	amount, _ := new(big.Float).SetInt(price.Amount).Float64()
+
+	var cost float64
+	rate := p.PaymentMethod.GetRate()
+	if rate.PerByte > 0 {
+		cost += amount * float64(datasize.GiB.Bytes()) / float64(rate.PerByte)
+	}
+	if rate.PerTime > 0 {
+		cost += amount * float64(time.Hour) / float64(rate.PerTime)
+	}
+	return cost
+}
+
+type qualityProvider interface {
+	ProposalsMetrics() []quality.ConnectMetric
+}
+
+// QualityCriterion prefers proposals which other consumers connect to successfully, according to Quality Oracle.
+type QualityCriterion struct {
+	qualityProvider qualityProvider
+}
+
+// NewQualityCriterion returns criterion rating proposals by Quality Oracle metrics.
+func NewQualityCriterion(qualityProvider qualityProvider) *QualityCriterion {
+	return &QualityCriterion{qualityProvider: qualityProvider}
+}
+
+// Rate rates candidates by their connect success ratio.
+// Quality the candidates are enriched with is preferred, Quality Oracle metrics are fetched only for the rest.
+func (c *QualityCriterion) Rate(candidates []market.ServiceProposal, _ Policy) ([]float64, error) {
+	var metrics map[quality.ProposalID]quality.ConnectMetric
+	lookup := func(p market.ServiceProposal) *market.ProposalQuality {
+		if p.Quality != nil {
+			return p.Quality
+		}
+		if metrics == nil {
+			metrics = make(map[quality.ProposalID]quality.ConnectMetric)
+			for _, m := range c.qualityProvider.ProposalsMetrics() {
+				metrics[m.ProposalID] = m
+			}
+		}
+		m, ok := metrics[quality.ProposalID{ProviderID: p.ProviderID, ServiceType: p.ServiceType}]
+		if !ok {
+			return nil
+		}
+		return &market.ProposalQuality{
+			ConnectSuccess:   m.ConnectCount.Success,
+			ConnectFail:      m.ConnectCount.Fail,
+			ConnectTimeout:   m.ConnectCount.Timeout,
+			MonitoringFailed: m.MonitoringFailed,
+		}
+	}
+
+	ratings := make([]float64, len(candidates))
+	for i, p := range candidates {
+		q := lookup(p)
+		switch {
+		case q == nil:
+			ratings[i] = unknownRating
+		case q.MonitoringFailed:
+			ratings[i] = 0
+		default:
+			ratings[i] = successRating(q.ConnectSuccess, q.ConnectSuccess+q.ConnectFail+q.ConnectTimeout)
+		}
+	}
+	return ratings, nil
+}
+
+// LatencyCriterion prefers providers with lower round trip time measured during previous connections of this node run,
+// latency probed to the providers is used for the ones not connected to yet.
+type LatencyCriterion struct {
+	lock sync.Mutex
+	rtt  map[string]time.Duration
+}
+
+// NewLatencyCriterion returns criterion rating proposals by the measured tunnel latency.
+func NewLatencyCriterion() *LatencyCriterion {
+	return &LatencyCriterion{
+		rtt: make(map[string]time.Duration),
+	}
+}
+
+// Subscribe subscribes to tunnel health events.
+func (c *LatencyCriterion) Subscribe(bus eventbus.Subscriber) error {
+	return bus.SubscribeAsync(connectionstate.AppTopicConnectionHealth, c.consumeHealthEvent)
+}
+
+func (c *LatencyCriterion) consumeHealthEvent(e connectionstate.AppEventConnectionHealth) {
+	if e.Health.RTT <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.rtt[e.SessionInfo.Proposal.ProviderID] = e.Health.RTT
+}
+
+// Rate rates candidates by their latency relative to the fastest one.
+func (c *LatencyCriterion) Rate(candidates []market.ServiceProposal, _ Policy) ([]float64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var fastest time.Duration
+	for _, p := range candidates {
+		if rtt, ok := c.latency(p); ok && (fastest == 0 || rtt < fastest) {
+			fastest = rtt
+		}
+	}
+
+	ratings := make([]float64, len(candidates))
+	for i, p := range candidates {
+		if rtt, ok := c.latency(p); ok {
+			ratings[i] = float64(fastest) / float64(rtt)
+		} else {
+			ratings[i] = unknownRating
+		}
+	}
+	return ratings, nil
+}
+
+func (c *LatencyCriterion) latency(p market.ServiceProposal) (time.Duration, bool) {
+	if rtt, ok := c.rtt[p.ProviderID]; ok {
+		return rtt, true
+	}
+	return p.Latency, p.Latency > 0
+}
+
+// CountryCriterion prefers providers located in the countries of selection policy.
+type CountryCriterion struct{}
+
+// Rate rates candidates by position of their country in the preferred countries, other countries are rated 0.
+func (CountryCriterion) Rate(candidates []market.ServiceProposal, policy Policy) ([]float64, error) {
+	ratings := make([]float64, len(candidates))
+	for i, p := range candidates {
+		if _, unsupported := p.ServiceDefinition.(market.UnsupportedServiceDefinition); unsupported || p.ServiceDefinition == nil {
+			continue
+		}
+		country := p.ServiceDefinition.GetLocation().Country
+		for pos, preferred := range policy.Countries {
+			if strings.EqualFold(country, preferred) {
+				ratings[i] = 1 - float64(pos)/float64(len(policy.Countries))
+				break
+			}
+		}
+	}
+	return ratings, nil
+}
+
+type sessionHistory interface {
+	List(filter *session.Filter) ([]session.History, error)
+}
+
+// HistoryCriterion prefers providers which did not drop previous connections of this consumer.
+type HistoryCriterion struct {
+	sessions sessionHistory
+}
+
+// NewHistoryCriterion returns criterion rating proposals by local session history.
+func NewHistoryCriterion(sessions sessionHistory) *HistoryCriterion {
+	return &HistoryCriterion{sessions: sessions}
+}
+
+// Rate rates candidates by ratio of their sessions which were not closed because of a failure.
+func (c *HistoryCriterion) Rate(candidates []market.ServiceProposal, _ Policy) ([]float64, error) {
+	sessions, err := c.sessions.List(session.NewFilter().SetDirection(session.DirectionConsumed).SetStatus(session.StatusCompleted))
+	if err != nil {
+		return nil, err
+	}
+
+	success := make(map[string]int)
+	total := make(map[string]int)
+	for _, s := range sessions {
+		providerID := s.ProviderID.Address
+		total[providerID]++
+		reason := connectionstate.DisconnectReason(s.DisconnectReason)
+		if !reason.Unexpected() && reason != connectionstate.DisconnectReasonDNSLeak {
+			success[providerID]++
+		}
+	}
+
+	ratings := make([]float64, len(candidates))
+	for i, p := range candidates {
+		ratings[i] = successRating(success[p.ProviderID], total[p.ProviderID])
+	}
+	return ratings, nil
+}
+
+type natTypeDetector interface {
+	NATType() string
+}
+
+// NATCriterion prefers providers whose NAT is likely to be traversed by hole punching from behind the consumer's NAT.
+type NATCriterion struct {
+	detector natTypeDetector
+}
+
+// NewNATCriterion returns criterion rating proposals by NAT types of the consumer and the provider.
+func NewNATCriterion(detector natTypeDetector) *NATCriterion {
+	return &NATCriterion{detector: detector}
+}
+
+// Rate rates candidates by chances to punch a hole between the consumer's and the provider's NAT.
+func (c *NATCriterion) Rate(candidates []market.ServiceProposal, _ Policy) ([]float64, error) {
+	consumerNAT := c.detector.NATType()
+
+	ratings := make([]float64, len(candidates))
+	for i, p := range candidates {
+		ratings[i] = natPairRating(consumerNAT, p.NATType)
+	}
+	return ratings, nil
+}
+
+// natPairRating rates chances to punch a hole between the given NAT types, empty type is unknown.
+func natPairRating(consumerNAT, providerNAT string) float64 {
+	switch {
+	case consumerNAT == market.NATTypePublic || providerNAT == market.NATTypePublic:
+		return 1
+	case providerNAT == "":
+		return unknownRating
+	case consumerNAT == "" && providerNAT == market.NATTypeSymmetric:
+		return 0.25
+	case consumerNAT == "":
+		return 0.75
+	case consumerNAT == market.NATTypeSymmetric || providerNAT == market.NATTypeSymmetric:
+		return 0
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package selection
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/consumer/session"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/core/quality"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/mocks"
+	"github.com/mysteriumnetwork/node/money"
+)
+
+type mockService struct {
+	country string
+}
+
+func (s mockService) GetLocation() market.Location {
+	return market.Location{Country: s.country}
+}
+
+func pricedProposal(providerID string, amount int64, rate market.PaymentRate) market.ServiceProposal {
+	return market.ServiceProposal{
+		ProviderID: providerID,
+		PaymentMethod: &mocks.PaymentMethod{
+			Rate:  rate,
+			Price: money.Money{Amount: big.NewInt(amount), Currency: money.CurrencyMyst},
+		},
+	}
+}
+
+func Test_PriceCriterion_PrefersCheaper(t *testing.T) {
+	candidates := []market.ServiceProposal{
+		pricedProposal("0x1", 100, market.PaymentRate{PerTime: time.Hour}),
+		pricedProposal("0x2", 100, market.PaymentRate{PerTime: 30 * time.Minute}),
+		pricedProposal("0x3", 0, market.PaymentRate{PerTime: time.Hour}),
+		pricedProposal("0x4", 50, market.PaymentRate{PerTime: time.Hour, PerByte: 1024 * 1024 * 1024}),
+	}
+
+	ratings, err := PriceCriterion{}.Rate(candidates, Policy{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0, 0, 1, 0}, ratings)
+
+	ratings, err = PriceCriterion{}.Rate(candidates[:2], Policy{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 0.5}, ratings)
+
+	ratings, err = PriceCriterion{}.Rate([]market.ServiceProposal{candidates[1], candidates[3]}, Policy{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 1}, ratings)
+}
+
+type qualityProviderStub struct {
+	metrics []quality.ConnectMetric
+}
+
+func (q qualityProviderStub) ProposalsMetrics() []quality.ConnectMetric {
+	return q.metrics
+}
+
+func Test_QualityCriterion_PrefersSuccessfulConnects(t *testing.T) {
+	criterion := NewQualityCriterion(qualityProviderStub{metrics: []quality.ConnectMetric{
+		{ProposalID: quality.ProposalID{ProviderID: "0x1", ServiceType: "wireguard"}, ConnectCount: quality.ConnectCount{Success: 8}},
+		{ProposalID: quality.ProposalID{ProviderID: "0x2", ServiceType: "wireguard"}, ConnectCount: quality.ConnectCount{Success: 3, Fail: 2, Timeout: 1}},
+		{ProposalID: quality.ProposalID{ProviderID: "0x3", ServiceType: "wireguard"}, ConnectCount: quality.ConnectCount{Success: 8}, MonitoringFailed: true},
+	}})
+
+	ratings, err := criterion.Rate([]market.ServiceProposal{
+		{ProviderID: "0x1", ServiceType: "wireguard"},
+		{ProviderID: "0x2", ServiceType: "wireguard"},
+		{ProviderID: "0x3", ServiceType: "wireguard"},
+		{ProviderID: "0x1", ServiceType: "openvpn"},
+	}, Policy{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.9, 0.5, 0, 0.5}, ratings)
+}
+
+func Test_QualityCriterion_PrefersEnrichedQuality(t *testing.T) {
+	criterion := NewQualityCriterion(qualityProviderStub{metrics: []quality.ConnectMetric{
+		{ProposalID: quality.ProposalID{ProviderID: "0x1", ServiceType: "wireguard"}, ConnectCount: quality.ConnectCount{Fail: 8}},
+	}})
+
+	ratings, err := criterion.Rate([]market.ServiceProposal{
+		{ProviderID: "0x1", ServiceType: "wireguard", Quality: &market.ProposalQuality{ConnectSuccess: 8}},
+		{ProviderID: "0x1", ServiceType: "wireguard"},
+	}, Policy{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.9, 0.1}, ratings)
+}
+
+func Test_LatencyCriterion_PrefersFaster(t *testing.T) {
+	criterion := NewLatencyCriterion()
+	for providerID, rtt := range map[string]time.Duration{"0x1": 40 * time.Millisecond, "0x2": 20 * time.Millisecond, "0x3": 0} {
+		criterion.consumeHealthEvent(connectionstate.AppEventConnectionHealth{
+			Health:      connectionstate.Health{RTT: rtt},
+			SessionInfo: connectionstate.Status{Proposal: market.ServiceProposal{ProviderID: providerID}},
+		})
+	}
+
+	ratings, err := criterion.Rate([]market.ServiceProposal{proposal1, proposal2, proposal3}, Policy{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 1, 0.5}, ratings)
+}
+
+func Test_LatencyCriterion_UsesProbedLatencyOfProvidersNotConnectedTo(t *testing.T) {
+	criterion := NewLatencyCriterion()
+	criterion.consumeHealthEvent(connectionstate.AppEventConnectionHealth{
+		Health:      connectionstate.Health{RTT: 40 * time.Millisecond},
+		SessionInfo: connectionstate.Status{Proposal: market.ServiceProposal{ProviderID: "0x1"}},
+	})
+
+	ratings, err := criterion.Rate([]market.ServiceProposal{
+		{ProviderID: "0x1", Latency: 10 * time.Millisecond},
+		{ProviderID: "0x2", Latency: 20 * time.Millisecond},
+		{ProviderID: "0x3"},
+	}, Policy{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 1, 0.5}, ratings)
+}
+
+func Test_CountryCriterion_PrefersCountriesInOrder(t *testing.T) {
+	candidates := []market.ServiceProposal{
+		{ProviderID: "0x1", ServiceDefinition: mockService{country: "LT"}},
+		{ProviderID: "0x2", ServiceDefinition: mockService{country: "DE"}},
+		{ProviderID: "0x3", ServiceDefinition: mockService{country: "US"}},
+	}
+
+	ratings, err := CountryCriterion{}.Rate(candidates, Policy{Countries: []string{"de", "LT"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 1, 0}, ratings)
+}
+
+type sessionHistoryStub struct {
+	sessions []session.History
+}
+
+func (s sessionHistoryStub) List(_ *session.Filter) ([]session.History, error) {
+	return s.sessions, nil
+}
+
+func Test_HistoryCriterion_PrefersProvidersWhichDidNotFail(t *testing.T) {
+	criterion := NewHistoryCriterion(sessionHistoryStub{sessions: []session.History{
+		{ProviderID: identity.FromAddress("0x1")},
+		{ProviderID: identity.FromAddress("0x1"), DisconnectReason: string(connectionstate.DisconnectReasonIdle)},
+		{ProviderID: identity.FromAddress("0x2"), DisconnectReason: string(connectionstate.DisconnectReasonConnectionLost)},
+		{ProviderID: identity.FromAddress("0x2"), DisconnectReason: string(connectionstate.DisconnectReasonDNSLeak)},
+	}})
+
+	ratings, err := criterion.Rate([]market.ServiceProposal{proposal1, proposal2, proposal3}, Policy{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.75, 0.25, 0.5}, ratings)
+}
+
+type natTypeDetectorStub string
+
+func (s natTypeDetectorStub) NATType() string {
+	return string(s)
+}
+
+func Test_NATCriterion_PrefersTraversableProviders(t *testing.T) {
+	candidates := []market.ServiceProposal{
+		{ProviderID: "0x1", NATType: market.NATTypePublic},
+		{ProviderID: "0x2", NATType: market.NATTypePortRestricted},
+		{ProviderID: "0x3", NATType: market.NATTypeSymmetric},
+		{ProviderID: "0x4"},
+	}
+
+	ratings, err := NewNATCriterion(natTypeDetectorStub(market.NATTypePortRestricted)).Rate(candidates, Policy{})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 1, 0, 0.5}, ratings)
+
+	ratings, err = NewNATCriterion(natTypeDetectorStub(market.NATTypeSymmetric)).Rate(candidates, Policy{})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 0, 0, 0.5}, ratings)
+
+	ratings, err = NewNATCriterion(natTypeDetectorStub("")).Rate(candidates, Policy{})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 0.75, 0.25, 0.5}, ratings)
+}
@@ -0,0 +1,220 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package selection
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// Names of the built-in rating criteria
+const (
+	CriterionPrice   = "price"
+	CriterionQuality = "quality"
+	CriterionLatency = "latency"
+	CriterionCountry = "country"
+	CriterionHistory = "history"
+	CriterionNAT     = "nat"
+)
+
+// Policy tunes proposal selection.
+type Policy struct {
+	// Weights of rating criteria by criterion name, criteria without weight are not used
+	Weights map[string]float64
+	// Countries lists preferred provider countries, most preferred first
+	Countries []string
+	// Filter is the name of saved proposal filter candidates must match, candidates are not filtered if empty
+	Filter string
+}
+
+func (p Policy) copy() Policy {
+	res := Policy{
+		Weights:   make(map[string]float64, len(p.Weights)),
+		Countries: append([]string(nil), p.Countries...),
+		Filter:    p.Filter,
+	}
+	for name, weight := range p.Weights {
+		res.Weights[name] = weight
+	}
+	return res
+}
+
+// Criterion rates candidate proposals.
+type Criterion interface {
+	// Rate returns rating of each candidate from 0 (worst) to 1 (best), in the order of candidates
+	Rate(candidates []market.ServiceProposal, policy Policy) ([]float64, error)
+}
+
+// Engine ranks proposals by weighted ratings of registered criteria.
+type Engine struct {
+	lock     sync.RWMutex
+	criteria map[string]Criterion
+	policy   Policy
+}
+
+// NewEngine returns selection engine without criteria, all proposals are ranked equally until criteria are registered.
+func NewEngine() *Engine {
+	return &Engine{
+		criteria: make(map[string]Criterion),
+	}
+}
+
+// Register adds rating criterion under the given name.
+func (e *Engine) Register(name string, criterion Criterion) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.criteria[name] = criterion
+}
+
+// Policy returns current selection policy.
+func (e *Engine) Policy() Policy {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	return e.policy.copy()
+}
+
+// SetPolicy replaces selection policy, weights must refer registered criteria.
+func (e *Engine) SetPolicy(policy Policy) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for name, weight := range policy.Weights {
+		if _, ok := e.criteria[name]; !ok {
+			return fmt.Errorf("unknown selection criterion %q", name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("weight of selection criterion %q can not be negative", name)
+		}
+	}
+	e.policy = policy.copy()
+	return nil
+}
+
+// Rank returns candidates ordered by score, best one first. Candidates with equal score keep their order.
+func (e *Engine) Rank(candidates []market.ServiceProposal) []market.ServiceProposal {
+	scores := e.Scores(candidates)
+
+	ranked := make([]int, len(candidates))
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i]] > scores[ranked[j]]
+	})
+
+	res := make([]market.ServiceProposal, len(candidates))
+	for i, idx := range ranked {
+		res[i] = candidates[idx]
+	}
+	return res
+}
+
+// Scores returns weighted score of each candidate from 0 (worst) to 1 (best), in the order of candidates.
+func (e *Engine) Scores(candidates []market.ServiceProposal) []float64 {
+	e.lock.RLock()
+	policy := e.policy.copy()
+	criteria := make(map[string]Criterion, len(e.criteria))
+	for name, criterion := range e.criteria {
+		criteria[name] = criterion
+	}
+	e.lock.RUnlock()
+
+	scores := make([]float64, len(candidates))
+	if len(candidates) < 2 {
+		return scores
+	}
+
+	var total float64
+	for name, weight := range policy.Weights {
+		if weight == 0 {
+			continue
+		}
+		ratings, err := criteria[name].Rate(candidates, policy)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Could not rate proposals by %s, skipping", name)
+			continue
+		}
+		for i, rating := range ratings {
+			scores[i] += weight * rating
+		}
+		total += weight
+	}
+	if total > 0 {
+		for i := range scores {
+			scores[i] /= total
+		}
+	}
+	return scores
+}
+
+type savedFilters interface {
+	Get(name string) (proposal.SavedFilter, error)
+}
+
+// Repository returns proposals ranked by selection engine, best one first.
+type Repository struct {
+	proposal.Repository
+	engine  *Engine
+	filters savedFilters
+}
+
+// NewRepository wraps proposal repository to rank proposals it returns.
+// Saved filters are optional, they resolve the filter named in selection policy.
+func NewRepository(repository proposal.Repository, engine *Engine, filters savedFilters) *Repository {
+	return &Repository{
+		Repository: repository,
+		engine:     engine,
+		filters:    filters,
+	}
+}
+
+// Proposals returns proposals matching the filter and saved filter of the policy, best one first.
+// Proposals flagged as price outliers are never offered for selection.
+func (r *Repository) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	if name := r.engine.Policy().Filter; name != "" && r.filters != nil {
+		saved, err := r.filters.Get(name)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Could not apply saved filter %q to provider selection", name)
+		} else {
+			narrowed := *filter
+			saved.ApplyTo(&narrowed)
+			filter = &narrowed
+		}
+	}
+
+	proposals, err := r.Repository.Proposals(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]market.ServiceProposal, 0, len(proposals))
+	for _, p := range proposals {
+		if !p.PriceOutlier {
+			candidates = append(candidates, p)
+		}
+	}
+	return r.engine.Rank(candidates), nil
+}
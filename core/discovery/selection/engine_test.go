@@ -0,0 +1,164 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package selection
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+var (
+	proposal1 = market.ServiceProposal{ProviderID: "0x1", ServiceType: "wireguard"}
+	proposal2 = market.ServiceProposal{ProviderID: "0x2", ServiceType: "wireguard"}
+	proposal3 = market.ServiceProposal{ProviderID: "0x3", ServiceType: "wireguard"}
+)
+
+type criterionStub struct {
+	ratings map[string]float64
+	err     error
+}
+
+func (c criterionStub) Rate(candidates []market.ServiceProposal, _ Policy) ([]float64, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	ratings := make([]float64, len(candidates))
+	for i, p := range candidates {
+		ratings[i] = c.ratings[p.ProviderID]
+	}
+	return ratings, nil
+}
+
+func newEngineStub() *Engine {
+	engine := NewEngine()
+	engine.Register("cheap", criterionStub{ratings: map[string]float64{"0x1": 0.2, "0x2": 1, "0x3": 0.6}})
+	engine.Register("fast", criterionStub{ratings: map[string]float64{"0x1": 1, "0x2": 0, "0x3": 0.6}})
+	engine.Register("broken", criterionStub{err: errors.New("boom")})
+	return engine
+}
+
+func Test_Engine_RanksByWeightedScore(t *testing.T) {
+	engine := newEngineStub()
+	candidates := []market.ServiceProposal{proposal1, proposal2, proposal3}
+
+	assert.NoError(t, engine.SetPolicy(Policy{Weights: map[string]float64{"cheap": 1}}))
+	assert.Equal(t, []market.ServiceProposal{proposal2, proposal3, proposal1}, engine.Rank(candidates))
+
+	assert.NoError(t, engine.SetPolicy(Policy{Weights: map[string]float64{"cheap": 1, "fast": 3}}))
+	assert.Equal(t, []market.ServiceProposal{proposal1, proposal3, proposal2}, engine.Rank(candidates))
+	assert.InDeltaSlice(t, []float64{0.8, 0.25, 0.6}, engine.Scores(candidates), 0.0001)
+}
+
+func Test_Engine_KeepsOrderWithoutWeights(t *testing.T) {
+	engine := newEngineStub()
+	candidates := []market.ServiceProposal{proposal1, proposal2, proposal3}
+
+	assert.Equal(t, candidates, engine.Rank(candidates))
+
+	assert.NoError(t, engine.SetPolicy(Policy{Weights: map[string]float64{"cheap": 0}}))
+	assert.Equal(t, candidates, engine.Rank(candidates))
+}
+
+func Test_Engine_SkipsFailingCriterion(t *testing.T) {
+	engine := newEngineStub()
+	assert.NoError(t, engine.SetPolicy(Policy{Weights: map[string]float64{"cheap": 1, "broken": 10}}))
+
+	assert.Equal(t,
+		[]market.ServiceProposal{proposal2, proposal3, proposal1},
+		engine.Rank([]market.ServiceProposal{proposal1, proposal2, proposal3}),
+	)
+}
+
+func Test_Engine_SetPolicyValidatesWeights(t *testing.T) {
+	engine := newEngineStub()
+
+	assert.EqualError(t, engine.SetPolicy(Policy{Weights: map[string]float64{"unknown": 1}}), `unknown selection criterion "unknown"`)
+	assert.EqualError(t, engine.SetPolicy(Policy{Weights: map[string]float64{"cheap": -1}}), `weight of selection criterion "cheap" can not be negative`)
+
+	policy := Policy{Weights: map[string]float64{"cheap": 1}, Countries: []string{"DE"}}
+	assert.NoError(t, engine.SetPolicy(policy))
+	policy.Weights["cheap"] = 2
+	assert.Equal(t, Policy{Weights: map[string]float64{"cheap": 1}, Countries: []string{"DE"}}, engine.Policy())
+}
+
+type repositoryStub struct {
+	proposals      []market.ServiceProposal
+	recordedFilter *proposal.Filter
+}
+
+func (r *repositoryStub) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	return nil, nil
+}
+
+func (r *repositoryStub) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	r.recordedFilter = filter
+	return r.proposals, nil
+}
+
+type savedFiltersStub map[string]proposal.SavedFilter
+
+func (s savedFiltersStub) Get(name string) (proposal.SavedFilter, error) {
+	filter, ok := s[name]
+	if !ok {
+		return proposal.SavedFilter{}, proposal.ErrSavedFilterNotFound
+	}
+	return filter, nil
+}
+
+func Test_Repository_RanksProposals(t *testing.T) {
+	engine := newEngineStub()
+	assert.NoError(t, engine.SetPolicy(Policy{Weights: map[string]float64{"fast": 1}}))
+	repository := NewRepository(&repositoryStub{proposals: []market.ServiceProposal{proposal2, proposal3, proposal1}}, engine, nil)
+
+	proposals, err := repository.Proposals(&proposal.Filter{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{proposal1, proposal3, proposal2}, proposals)
+}
+
+func Test_Repository_AppliesPolicyFilter(t *testing.T) {
+	engine := newEngineStub()
+	assert.NoError(t, engine.SetPolicy(Policy{Filter: "streaming"}))
+	delegate := &repositoryStub{}
+	filters := savedFiltersStub{"streaming": {Name: "streaming", Countries: []string{"DE"}, QualityMin: 0.5}}
+	repository := NewRepository(delegate, engine, filters)
+
+	filter := &proposal.Filter{ServiceType: "wireguard"}
+	_, err := repository.Proposals(filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &proposal.Filter{ServiceType: "wireguard", LocationCountries: []string{"DE"}, QualityMin: 0.5}, delegate.recordedFilter)
+	assert.Equal(t, &proposal.Filter{ServiceType: "wireguard"}, filter)
+}
+
+func Test_Repository_SkipsPriceOutliers(t *testing.T) {
+	engine := newEngineStub()
+	outlier := proposal2
+	outlier.PriceOutlier = true
+	repository := NewRepository(&repositoryStub{proposals: []market.ServiceProposal{proposal1, outlier, proposal3}}, engine, nil)
+
+	proposals, err := repository.Proposals(&proposal.Filter{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{proposal1, proposal3}, proposals)
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package selection
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/rs/zerolog/log"
+)
+
+type latencyProber interface {
+	Probe(proposal market.ServiceProposal) (time.Duration, error)
+}
+
+type probedLatency struct {
+	latency  time.Duration
+	probedAt time.Time
+}
+
+// LatencyRepository annotates proposals with latency probed to their providers.
+// Only the top ranked providers are probed, in the background, so their proposals
+// are annotated once providers respond.
+type LatencyRepository struct {
+	proposal.Repository
+	engine  *Engine
+	prober  latencyProber
+	count   int
+	ttl     time.Duration
+	timeNow func() time.Time
+
+	lock    sync.Mutex
+	probed  map[string]probedLatency
+	probing map[string]bool
+}
+
+// NewLatencyRepository wraps proposal repository to annotate proposals with latency,
+// probing up to count top ranked providers which were not probed during the last ttl.
+func NewLatencyRepository(repository proposal.Repository, engine *Engine, prober latencyProber, count int, ttl time.Duration) *LatencyRepository {
+	return &LatencyRepository{
+		Repository: repository,
+		engine:     engine,
+		prober:     prober,
+		count:      count,
+		ttl:        ttl,
+		timeNow:    time.Now,
+		probed:     make(map[string]probedLatency),
+		probing:    make(map[string]bool),
+	}
+}
+
+// Proposal returns the proposal annotated with latency when it is known.
+func (r *LatencyRepository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	p, err := r.Repository.Proposal(id)
+	if err != nil || p == nil {
+		return p, err
+	}
+
+	annotated := *p
+	annotated.Latency = r.latency(p.ProviderID)
+	return &annotated, nil
+}
+
+// Proposals returns proposals matching the filter annotated with known latency,
+// and starts probing the top ranked providers without fresh latency.
+func (r *LatencyRepository) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	proposals, err := r.Repository.Proposals(filter)
+	if len(proposals) == 0 {
+		return proposals, err
+	}
+
+	annotated := make([]market.ServiceProposal, len(proposals))
+	for i, p := range proposals {
+		p.Latency = r.latency(p.ProviderID)
+		annotated[i] = p
+	}
+	r.probeTop(annotated)
+	return annotated, err
+}
+
+func (r *LatencyRepository) latency(providerID string) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	probed, ok := r.probed[providerID]
+	if !ok || r.timeNow().Sub(probed.probedAt) >= r.ttl {
+		return 0
+	}
+	return probed.latency
+}
+
+func (r *LatencyRepository) probeTop(proposals []market.ServiceProposal) {
+	ranked := r.engine.Rank(proposals)
+	if len(ranked) > r.count {
+		ranked = ranked[:r.count]
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.timeNow()
+	for _, p := range ranked {
+		if probed, ok := r.probed[p.ProviderID]; ok && now.Sub(probed.probedAt) < r.ttl {
+			continue
+		}
+		if r.probing[p.ProviderID] {
+			continue
+		}
+		r.probing[p.ProviderID] = true
+		go r.probe(p)
+	}
+}
+
+func (r *LatencyRepository) probe(p market.ServiceProposal) {
+	latency, err := r.prober.Probe(p)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Could not probe latency of provider %s", p.ProviderID)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// failed probes are remembered as unknown latency, so unreachable providers are not probed again until ttl passes
+	r.probed[p.ProviderID] = probedLatency{latency: latency, probedAt: r.timeNow()}
+	delete(r.probing, p.ProviderID)
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package selection
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+type latencyProberStub struct {
+	lock    sync.Mutex
+	latency map[string]time.Duration
+	probed  []string
+}
+
+func (p *latencyProberStub) Probe(proposal market.ServiceProposal) (time.Duration, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.probed = append(p.probed, proposal.ProviderID)
+	latency, ok := p.latency[proposal.ProviderID]
+	if !ok {
+		return 0, errors.New("provider unreachable")
+	}
+	return latency, nil
+}
+
+func (p *latencyProberStub) probedProviders() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return append([]string(nil), p.probed...)
+}
+
+func Test_LatencyRepository_ProbesTopRankedProviders(t *testing.T) {
+	// given
+	engine := newEngineStub()
+	assert.NoError(t, engine.SetPolicy(Policy{Weights: map[string]float64{"cheap": 1}}))
+	prober := &latencyProberStub{latency: map[string]time.Duration{"0x2": 20 * time.Millisecond, "0x3": 30 * time.Millisecond}}
+	repository := NewLatencyRepository(&repositoryStub{proposals: []market.ServiceProposal{proposal1, proposal2, proposal3}}, engine, prober, 2, time.Minute)
+
+	// when
+	proposals, err := repository.Proposals(&proposal.Filter{})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{proposal1, proposal2, proposal3}, proposals)
+	assert.Eventually(t, func() bool {
+		return len(prober.probedProviders()) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"0x2", "0x3"}, prober.probedProviders())
+
+	// when
+	assert.Eventually(t, func() bool {
+		proposals, _ = repository.Proposals(&proposal.Filter{})
+		return proposals[1].Latency > 0 && proposals[2].Latency > 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// then
+	assert.Equal(t, time.Duration(0), proposals[0].Latency)
+	assert.Equal(t, 20*time.Millisecond, proposals[1].Latency)
+	assert.Equal(t, 30*time.Millisecond, proposals[2].Latency)
+	assert.Len(t, prober.probedProviders(), 2)
+}
+
+func Test_LatencyRepository_ProbesAgainAfterTTL(t *testing.T) {
+	// given
+	engine := newEngineStub()
+	prober := &latencyProberStub{}
+	repository := NewLatencyRepository(&repositoryStub{proposals: []market.ServiceProposal{proposal1}}, engine, prober, 1, time.Minute)
+	now := time.Now()
+	repository.timeNow = func() time.Time { return now }
+
+	_, err := repository.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		repository.lock.Lock()
+		defer repository.lock.Unlock()
+		return len(repository.probed) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// when
+	_, err = repository.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+
+	// then
+	assert.Len(t, prober.probedProviders(), 1)
+
+	// when
+	now = now.Add(time.Minute)
+	_, err = repository.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+
+	// then
+	assert.Eventually(t, func() bool {
+		return len(prober.probedProviders()) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package selection
+
+import (
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// QualityFilter drops proposals rated by quality criterion below the quality floor of the filter.
+type QualityFilter struct {
+	proposal.Repository
+	criterion *QualityCriterion
+}
+
+// NewQualityFilter wraps proposal repository to apply quality floor of filters.
+func NewQualityFilter(repository proposal.Repository, qualityProvider qualityProvider) *QualityFilter {
+	return &QualityFilter{
+		Repository: repository,
+		criterion:  NewQualityCriterion(qualityProvider),
+	}
+}
+
+// Proposals returns proposals matching the filter, including its quality floor.
+func (r *QualityFilter) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	proposals, err := r.Repository.Proposals(filter)
+	if err != nil || filter.QualityMin <= 0 || len(proposals) == 0 {
+		return proposals, err
+	}
+
+	ratings, err := r.criterion.Rate(proposals, Policy{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]market.ServiceProposal, 0, len(proposals))
+	for i, p := range proposals {
+		if ratings[i] >= filter.QualityMin {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package selection
+
+import (
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/quality"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_QualityFilter_DropsProposalsBelowFloor(t *testing.T) {
+	good := market.ServiceProposal{ProviderID: "0x1", ServiceType: "wireguard"}
+	bad := market.ServiceProposal{ProviderID: "0x2", ServiceType: "wireguard"}
+	unknown := market.ServiceProposal{ProviderID: "0x3", ServiceType: "wireguard"}
+	repository := NewQualityFilter(
+		&repositoryStub{proposals: []market.ServiceProposal{good, bad, unknown}},
+		qualityProviderStub{metrics: []quality.ConnectMetric{
+			{ProposalID: quality.ProposalID{ProviderID: "0x1", ServiceType: "wireguard"}, ConnectCount: quality.ConnectCount{Success: 8}},
+			{ProposalID: quality.ProposalID{ProviderID: "0x2", ServiceType: "wireguard"}, ConnectCount: quality.ConnectCount{Fail: 8}},
+		}},
+	)
+
+	proposals, err := repository.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{good, bad, unknown}, proposals)
+
+	proposals, err = repository.Proposals(&proposal.Filter{QualityMin: 0.5})
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{good, unknown}, proposals)
+}
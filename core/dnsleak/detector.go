@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dnsleak
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/location/locationstate"
+	"github.com/mysteriumnetwork/node/requests"
+)
+
+// Resolver describes DNS resolver which queried the canary domain
+type Resolver struct {
+	IP      string `json:"ip"`
+	ASN     int    `json:"asn"`
+	ISP     string `json:"isp"`
+	Country string `json:"country"`
+}
+
+// Detector detects DNS resolvers used by the system
+type Detector interface {
+	DetectResolvers(ctx context.Context) ([]Resolver, error)
+}
+
+type resolversResponse struct {
+	Resolvers []Resolver `json:"resolvers"`
+}
+
+type lookupHostFunc func(ctx context.Context, host string) ([]string, error)
+
+// detector resolves unique subdomain of canary domain and asks the detection service
+// which resolvers have queried its authoritative name server for that subdomain.
+type detector struct {
+	httpClient   *requests.HTTPClient
+	canaryDomain string
+	url          string
+	lookupHost   lookupHostFunc
+}
+
+// NewDetector creates DNS leak detector using given canary domain and detection service URL
+func NewDetector(httpClient *requests.HTTPClient, canaryDomain, url string) *detector {
+	return &detector{
+		httpClient:   httpClient,
+		canaryDomain: canaryDomain,
+		url:          url,
+		lookupHost:   net.DefaultResolver.LookupHost,
+	}
+}
+
+// DetectResolvers returns resolvers which were used to resolve the canary domain
+func (d *detector) DetectResolvers(ctx context.Context) ([]Resolver, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Canary subdomains do not resolve to any address, it is the query reaching the name server that matters.
+	host := token + "." + d.canaryDomain
+	if _, err := d.lookupHost(ctx, host); err != nil {
+		log.Debug().Err(err).Msgf("Canary domain %s lookup finished", host)
+	}
+
+	request, err := requests.NewGetRequest(d.url, token, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	var response resolversResponse
+	if err := d.httpClient.DoRequestAndParseResponse(request.WithContext(ctx), &response); err != nil {
+		return nil, fmt.Errorf("could not fetch resolvers of canary domain: %w", err)
+	}
+	return response.Resolvers, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate canary token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// IsLeaked checks if any of the resolvers belongs to the network of consumer's origin location,
+// which means DNS queries left the consumer bypassing the tunnel.
+func IsLeaked(resolvers []Resolver, origin locationstate.Location) bool {
+	for _, resolver := range resolvers {
+		if origin.IP != "" && resolver.IP == origin.IP {
+			return true
+		}
+		if origin.ASN != 0 && resolver.ASN == origin.ASN {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dnsleak
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/location/locationstate"
+	"github.com/mysteriumnetwork/node/requests"
+)
+
+func TestDetector_DetectResolvers(t *testing.T) {
+	var lookedUp string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(lookedUp, strings.TrimPrefix(r.URL.Path, "/")+"."))
+		w.Write([]byte(`{"resolvers": [{"ip": "1.1.1.1", "asn": 13335, "isp": "Cloudflare", "country": "US"}]}`))
+	}))
+	defer server.Close()
+
+	detector := NewDetector(requests.NewHTTPClient("0.0.0.0", time.Second), "canary.example.com", server.URL)
+	detector.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		lookedUp = host
+		return nil, nil
+	}
+
+	resolvers, err := detector.DetectResolvers(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(lookedUp, ".canary.example.com"))
+	assert.Equal(t, []Resolver{{IP: "1.1.1.1", ASN: 13335, ISP: "Cloudflare", Country: "US"}}, resolvers)
+}
+
+func TestIsLeaked(t *testing.T) {
+	origin := locationstate.Location{IP: "2.2.2.2", ASN: 8764}
+
+	assert.False(t, IsLeaked(nil, origin))
+	assert.False(t, IsLeaked([]Resolver{{IP: "1.1.1.1", ASN: 13335}}, origin))
+	assert.True(t, IsLeaked([]Resolver{{IP: "1.1.1.1", ASN: 13335}, {IP: "3.3.3.3", ASN: 8764}}, origin))
+	assert.True(t, IsLeaked([]Resolver{{IP: "2.2.2.2"}}, origin))
+	assert.False(t, IsLeaked([]Resolver{{IP: "3.3.3.3"}}, locationstate.Location{}))
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package hours
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/eventbus"
+)
+
+// Services pauses and resumes provided services.
+type Services interface {
+	Pause()
+	Resume()
+}
+
+// Sessions lists sessions served by the provider.
+type Sessions interface {
+	GetAll() []*service.Session
+}
+
+// OperatingHours provides services only during configured windows. Once window ends, services
+// stop accepting new sessions and established sessions are given a drain period to finish.
+type OperatingHours struct {
+	windows       []Window
+	drainPeriod   time.Duration
+	checkInterval time.Duration
+	services      Services
+	sessions      Sessions
+	now           func() time.Time
+
+	lock    sync.Mutex
+	checked bool
+	open    bool
+	drain   *time.Timer
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewOperatingHours creates operating hours of provided services.
+func NewOperatingHours(windows []Window, drainPeriod, checkInterval time.Duration, services Services, sessions Sessions) *OperatingHours {
+	return &OperatingHours{
+		windows:       windows,
+		drainPeriod:   drainPeriod,
+		checkInterval: checkInterval,
+		services:      services,
+		sessions:      sessions,
+		now:           time.Now,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to service status events.
+func (h *OperatingHours) Subscribe(bus eventbus.Subscriber) error {
+	return bus.SubscribeAsync(servicestate.AppTopicServiceStatus, h.consumeServiceStatusEvent)
+}
+
+// Start pauses services if they are out of operating hours and starts watching for windows to open and close.
+func (h *OperatingHours) Start() {
+	h.check()
+	go h.checkLoop()
+}
+
+// Stop stops watching operating hours, services are left as they are.
+func (h *OperatingHours) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		h.cancelDrainLocked()
+	})
+}
+
+// IsOpen checks whether services are provided at the moment.
+func (h *OperatingHours) IsOpen() bool {
+	return h.isOpenAt(h.now())
+}
+
+func (h *OperatingHours) isOpenAt(now time.Time) bool {
+	if len(h.windows) == 0 {
+		return true
+	}
+	for _, w := range h.windows {
+		if w.isOpenAt(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *OperatingHours) checkLoop() {
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-time.After(h.checkInterval):
+			h.check()
+		}
+	}
+}
+
+// consumeServiceStatusEvent pauses services started out of operating hours.
+func (h *OperatingHours) consumeServiceStatusEvent(e servicestate.AppEventServiceStatus) {
+	if e.Status != string(servicestate.Running) {
+		return
+	}
+
+	h.lock.Lock()
+	closed := h.checked && !h.open
+	h.lock.Unlock()
+
+	if closed {
+		h.services.Pause()
+	}
+}
+
+// check pauses or resumes services once operating window closes or opens.
+func (h *OperatingHours) check() {
+	open := h.IsOpen()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.checked && h.open == open {
+		return
+	}
+	h.checked = true
+	h.open = open
+
+	if open {
+		log.Info().Msg("Operating hours started, resuming services")
+		h.cancelDrainLocked()
+		h.services.Resume()
+		return
+	}
+
+	log.Info().Msgf("Operating hours ended, draining sessions for %s", h.drainPeriod)
+	h.services.Pause()
+	h.drain = time.AfterFunc(h.drainPeriod, h.closeSessions)
+}
+
+func (h *OperatingHours) cancelDrainLocked() {
+	if h.drain != nil {
+		h.drain.Stop()
+		h.drain = nil
+	}
+}
+
+// closeSessions closes sessions which did not finish during the drain period.
+func (h *OperatingHours) closeSessions() {
+	h.lock.Lock()
+	open := h.open
+	h.lock.Unlock()
+	if open {
+		return
+	}
+
+	for _, session := range h.sessions.GetAll() {
+		log.Info().Msgf("Closing session %s out of operating hours", session.ID)
+		go session.Close()
+	}
+}
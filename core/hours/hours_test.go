@@ -0,0 +1,162 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package hours
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/consumer/schedule"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/pb"
+	"github.com/mysteriumnetwork/node/trace"
+)
+
+func TestParseWindows(t *testing.T) {
+	windows, err := ParseWindows("mon-fri 18:00-08:00; sat,sun 00:00-00:00")
+	assert.NoError(t, err)
+	assert.Equal(t, []Window{
+		{
+			Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Start: schedule.TimeOfDay{Hour: 18},
+			End:   schedule.TimeOfDay{Hour: 8},
+		},
+		{
+			Days:  []time.Weekday{time.Saturday, time.Sunday},
+			Start: schedule.TimeOfDay{},
+			End:   schedule.TimeOfDay{},
+		},
+	}, windows)
+	assert.Equal(t, "mon,tue,wed,thu,fri 18:00-08:00", windows[0].String())
+
+	windows, err = ParseWindows("fri-mon 22:30-23:00")
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}, windows[0].Days)
+
+	windows, err = ParseWindows("")
+	assert.NoError(t, err)
+	assert.Empty(t, windows)
+
+	for _, s := range []string{"18:00", "mon 18:00-25:00", "someday 18:00-20:00", "mon fri 18:00-20:00"} {
+		_, err := ParseWindows(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestWindow_IsOpenAt(t *testing.T) {
+	// 2020-10-16 is Friday.
+	friday := func(hour int) time.Time { return time.Date(2020, 10, 16, hour, 0, 0, 0, time.UTC) }
+
+	overnight := Window{Days: []time.Weekday{time.Friday}, Start: schedule.TimeOfDay{Hour: 22}, End: schedule.TimeOfDay{Hour: 7}}
+	assert.False(t, overnight.isOpenAt(friday(21)))
+	assert.True(t, overnight.isOpenAt(friday(22)))
+	assert.True(t, overnight.isOpenAt(friday(30)))
+	assert.False(t, overnight.isOpenAt(friday(31)))
+	assert.False(t, overnight.isOpenAt(friday(6)))
+
+	wholeDay := Window{Start: schedule.TimeOfDay{Hour: 9}, End: schedule.TimeOfDay{Hour: 9}}
+	assert.True(t, wholeDay.isOpenAt(friday(8)))
+	assert.True(t, wholeDay.isOpenAt(friday(9)))
+}
+
+func TestOperatingHours_PausesAndResumesServices(t *testing.T) {
+	services := &mockServices{}
+	windows, _ := ParseWindows("08:00-20:00")
+	h := NewOperatingHours(windows, time.Hour, time.Hour, services, &mockSessions{})
+	now := time.Date(2020, 10, 16, 21, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	h.check()
+	assert.False(t, h.IsOpen())
+	assert.Equal(t, 1, services.paused)
+
+	h.check()
+	assert.Equal(t, 1, services.paused)
+
+	h.consumeServiceStatusEvent(servicestate.AppEventServiceStatus{Status: string(servicestate.Running)})
+	assert.Equal(t, 2, services.paused)
+
+	now = now.Add(12 * time.Hour)
+	h.check()
+	assert.Equal(t, 1, services.resumed)
+
+	h.consumeServiceStatusEvent(servicestate.AppEventServiceStatus{Status: string(servicestate.Running)})
+	assert.Equal(t, 2, services.paused)
+	h.Stop()
+}
+
+func TestOperatingHours_ClosesSessionsAfterDrainPeriod(t *testing.T) {
+	session, _ := service.NewSession(&service.Instance{}, &pb.SessionRequest{}, trace.NewTracer(""))
+	windows, _ := ParseWindows("08:00-20:00")
+	h := NewOperatingHours(windows, 10*time.Millisecond, time.Hour, &mockServices{}, &mockSessions{sessions: []*service.Session{session}})
+	h.now = func() time.Time { return time.Date(2020, 10, 16, 21, 0, 0, 0, time.UTC) }
+
+	h.check()
+	select {
+	case <-session.Done():
+	case <-time.After(time.Second):
+		t.Fatal("session was not closed after drain period")
+	}
+}
+
+func TestOperatingHours_OpenWindowCancelsDrain(t *testing.T) {
+	session, _ := service.NewSession(&service.Instance{}, &pb.SessionRequest{}, trace.NewTracer(""))
+	windows, _ := ParseWindows("08:00-20:00")
+	h := NewOperatingHours(windows, 50*time.Millisecond, time.Hour, &mockServices{}, &mockSessions{sessions: []*service.Session{session}})
+	now := time.Date(2020, 10, 16, 21, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	h.check()
+	now = now.Add(12 * time.Hour)
+	h.check()
+
+	select {
+	case <-session.Done():
+		t.Fatal("session was closed during operating hours")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+type mockServices struct {
+	lock            sync.Mutex
+	paused, resumed int
+}
+
+func (m *mockServices) Pause() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.paused++
+}
+
+func (m *mockServices) Resume() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.resumed++
+}
+
+type mockSessions struct {
+	sessions []*service.Session
+}
+
+func (m *mockSessions) GetAll() []*service.Session {
+	return m.sessions
+}
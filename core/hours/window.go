@@ -0,0 +1,156 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package hours
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mysteriumnetwork/node/consumer/schedule"
+)
+
+// Window is a daily period of time during which services are provided.
+// Window ending earlier than it starts lasts past midnight, e.g. from 22:00 to 07:00.
+type Window struct {
+	// Days limits the window to the given week days it starts on, empty value means every day
+	Days  []time.Weekday
+	Start schedule.TimeOfDay
+	End   schedule.TimeOfDay
+}
+
+// ParseWindows parses windows separated by ";", e.g. "mon-fri 18:00-08:00; sat,sun 00:00-00:00".
+// Window starting and ending at the same time lasts whole day.
+func ParseWindows(s string) ([]Window, error) {
+	var windows []Window
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		window, err := parseWindow(part)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+func parseWindow(s string) (window Window, err error) {
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+	case 2:
+		if window.Days, err = parseDays(fields[0]); err != nil {
+			return window, err
+		}
+	default:
+		return window, fmt.Errorf("invalid operating hours %q, expected [days] HH:MM-HH:MM", s)
+	}
+
+	times := strings.Split(fields[len(fields)-1], "-")
+	if len(times) != 2 {
+		return window, fmt.Errorf("invalid operating hours %q, expected [days] HH:MM-HH:MM", s)
+	}
+	if window.Start, err = schedule.ParseTimeOfDay(times[0]); err != nil {
+		return window, err
+	}
+	if window.End, err = schedule.ParseTimeOfDay(times[1]); err != nil {
+		return window, err
+	}
+	return window, nil
+}
+
+// parseDays parses comma separated week days or their ranges, e.g. "mon-fri,sun".
+func parseDays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.Split(part, "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("invalid week days %q", part)
+		}
+		from, err := schedule.ParseWeekday(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = schedule.ParseWeekday(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+		// Ranges may wrap around the week end, e.g. "fri-mon".
+		for day := from; ; day = (day + 1) % 7 {
+			days = append(days, day)
+			if day == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// String returns window in the format it is parsed from.
+func (w Window) String() string {
+	var days []string
+	for _, day := range w.Days {
+		days = append(days, strings.ToLower(day.String()[:3]))
+	}
+	times := w.Start.String() + "-" + w.End.String()
+	if len(days) == 0 {
+		return times
+	}
+	return strings.Join(days, ",") + " " + times
+}
+
+func (w Window) isScheduledOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// isOpenAt checks whether the given time falls into the window started today or yesterday.
+func (w Window) isOpenAt(now time.Time) bool {
+	for days := 0; days <= 1; days++ {
+		day := now.AddDate(0, 0, -days)
+		if !w.isScheduledOn(day.Weekday()) {
+			continue
+		}
+		start := at(day, w.Start)
+		end := at(day, w.End)
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !now.Before(start) && now.Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+func at(day time.Time, t schedule.TimeOfDay) time.Time {
+	year, month, date := day.Date()
+	return time.Date(year, month, date, t.Hour, t.Minute, 0, 0, day.Location())
+}
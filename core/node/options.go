@@ -19,14 +19,18 @@ package node
 
 import (
 	"path"
+	"strings"
+	"time"
 
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/logconfig"
 	openvpn_core "github.com/mysteriumnetwork/node/services/openvpn/core"
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
+	"github.com/mysteriumnetwork/payments/crypto"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
 )
 
 // Openvpn interface is abstraction over real openvpn options to unblock mobile development
@@ -62,6 +66,9 @@ type Options struct {
 	UI               OptionsUI
 	FeedbackURL      string
 
+	// UpstreamProxy is the proxy outbound control traffic is sent through, empty value connects directly
+	UpstreamProxy string
+
 	Keystore OptionsKeystore
 
 	logconfig.LogOptions
@@ -75,23 +82,61 @@ type Options struct {
 	Openvpn  Openvpn
 	Firewall OptionsFirewall
 
+	ConnectTimeouts OptionsConnectTimeouts
+	ConnectRetry    OptionsConnectRetry
+	ConnectProbe    OptionsConnectProbe
+	// ConnectionMaxBandwidth limits consumer tunnel speed, e.g. "10mbps", empty value means unlimited
+	ConnectionMaxBandwidth string
+	// ConnectionIdleTimeout disconnects consumer when no traffic flows through the tunnel for given time, zero value disables it
+	ConnectionIdleTimeout time.Duration
+	// ConnectionKeyRotationInterval is how often tunnel keys of the established session are renegotiated, zero value disables it
+	ConnectionKeyRotationInterval time.Duration
+	AutoConnect                   OptionsAutoConnect
+	AutoSwitch                    OptionsAutoSwitch
+	// ConnectionNetworkCheckInterval is how often host network is checked for changes requiring reconnect, zero value disables it
+	ConnectionNetworkCheckInterval time.Duration
+
+	// SessionBandwidth limits speed of each provided session, e.g. "10mbps", empty value means unlimited
+	SessionBandwidth string
+	// SessionBandwidthAdvertise advertises provided session speed limit in service proposals
+	SessionBandwidthAdvertise bool
+	// TrafficQuotaGiB limits monthly traffic of all provided services, zero value means unlimited
+	TrafficQuotaGiB uint64
+	// OperatingHours limits time when services are provided, e.g. "mon-fri 18:00-08:00", empty value means always
+	OperatingHours string
+	// OperatingHoursDrain is the time given to sessions to finish once operating hours end
+	OperatingHoursDrain time.Duration
+	DynamicPricing      OptionsDynamicPricing
+	Throttle            OptionsThrottle
+	Webhook             OptionsWebhook
+	SpeedTest           OptionsSpeedTest
+	AbuseDetection      OptionsAbuseDetection
+
 	Payments OptionsPayments
 
 	Consumer bool
 
-	P2PPorts *port.Range
+	P2PPorts     *port.Range
+	P2PPortRange *port.Range
+	P2PRelay     OptionsP2PRelay
+	P2PKeepAlive OptionsP2PKeepAlive
+	// P2PKeyRotationInterval is how often p2p channel keys are rotated, zero disables the rotation.
+	P2PKeyRotationInterval time.Duration
+	// P2PObfuscation disguises p2p channel traffic on the wire.
+	P2PObfuscation bool
 }
 
 // GetOptions retrieves node options from the app configuration.
 func GetOptions() *Options {
 	network := OptionsNetwork{
-		Testnet:               config.GetBool(config.FlagTestnet),
-		Localnet:              config.GetBool(config.FlagLocalnet),
-		Betanet:               config.GetBool(config.FlagBetanet),
-		ExperimentNATPunching: config.GetBool(config.FlagNATPunching),
-		MysteriumAPIAddress:   config.GetString(config.FlagAPIAddress),
-		BrokerAddress:         config.GetString(config.FlagBrokerAddress),
-		EtherClientRPC:        config.GetString(config.FlagEtherRPC),
+		Testnet:                 config.GetBool(config.FlagTestnet),
+		Localnet:                config.GetBool(config.FlagLocalnet),
+		Betanet:                 config.GetBool(config.FlagBetanet),
+		ExperimentNATPunching:   config.GetBool(config.FlagNATPunching),
+		MysteriumAPIAddress:     config.GetString(config.FlagAPIAddress),
+		BrokerAddress:           config.GetString(config.FlagBrokerAddress),
+		BrokerFallbackAddresses: config.GetStringSlice(config.FlagBrokerFallbackAddresses),
+		EtherClientRPC:          config.GetString(config.FlagEtherRPC),
 	}
 	return &Options{
 		Directories:      *GetOptionsDirectory(&network),
@@ -99,6 +144,7 @@ func GetOptions() *Options {
 		TequilapiPort:    config.GetInt(config.FlagTequilapiPort),
 		TequilapiEnabled: true,
 		BindAddress:      config.GetString(config.FlagBindAddress),
+		UpstreamProxy:    config.GetString(config.FlagUpstreamProxy),
 		UI: OptionsUI{
 			UIEnabled:     config.GetBool(config.FlagUIEnable),
 			UIBindAddress: config.GetString(config.FlagUIAddress),
@@ -106,22 +152,110 @@ func GetOptions() *Options {
 		},
 		FeedbackURL: config.GetString(config.FlagFeedbackURL),
 		Keystore: OptionsKeystore{
+			Provider:       config.GetString(config.FlagKeystoreProvider),
 			UseLightweight: config.GetBool(config.FlagKeystoreLightweight),
+			ScryptN:        config.GetInt(config.FlagKeystoreScryptN),
+			ScryptP:        config.GetInt(config.FlagKeystoreScryptP),
+			RemoteSigner: OptionsRemoteSigner{
+				URL:      config.GetString(config.FlagKeystoreRemoteSigner),
+				Identity: config.GetString(config.FlagKeystoreRemoteSignerIdentity),
+				CertFile: config.GetString(config.FlagKeystoreRemoteSignerCert),
+				KeyFile:  config.GetString(config.FlagKeystoreRemoteSignerKey),
+				CAFile:   config.GetString(config.FlagKeystoreRemoteSignerCA),
+			},
+			PKCS11: OptionsPKCS11{
+				Module: config.GetString(config.FlagKeystorePKCS11Module),
+				Token:  config.GetString(config.FlagKeystorePKCS11Token),
+				PIN:    config.GetString(config.FlagKeystorePKCS11PIN),
+			},
 		},
 		LogOptions:     *GetLogOptions(),
 		OptionsNetwork: network,
 		Discovery:      *GetDiscoveryOptions(),
 		Quality: OptionsQuality{
-			Type:    QualityType(config.GetString(config.FlagQualityType)),
-			Address: config.GetString(config.FlagQualityAddress),
+			Type:       QualityType(config.GetString(config.FlagQualityType)),
+			Address:    config.GetString(config.FlagQualityAddress),
+			MetricsTTL: config.GetDuration(config.FlagQualityMetricsTTL),
 		},
 		Location: OptionsLocation{
-			IPDetectorURL: config.GetString(config.FlagIPDetectorURL),
-			Type:          LocationType(config.GetString(config.FlagLocationType)),
-			Address:       config.GetString(config.FlagLocationAddress),
-			Country:       config.GetString(config.FlagLocationCountry),
-			City:          config.GetString(config.FlagLocationCity),
-			NodeType:      config.GetString(config.FlagLocationNodeType),
+			IPDetectorURL:       config.GetString(config.FlagIPDetectorURL),
+			DNSLeakDetectorURL:  config.GetString(config.FlagDNSLeakDetectorURL),
+			DNSLeakCanaryDomain: config.GetString(config.FlagDNSLeakCanaryDomain),
+			Type:                LocationType(config.GetString(config.FlagLocationType)),
+			Address:             config.GetString(config.FlagLocationAddress),
+			Country:             config.GetString(config.FlagLocationCountry),
+			City:                config.GetString(config.FlagLocationCity),
+			NodeType:            config.GetString(config.FlagLocationNodeType),
+		},
+		ConnectTimeouts: OptionsConnectTimeouts{
+			Connect:       config.GetDuration(config.FlagConnectTimeout),
+			ProposalFetch: config.GetDuration(config.FlagConnectTimeoutProposalFetch),
+			P2PDial:       config.GetDuration(config.FlagConnectTimeoutP2PDial),
+			SessionCreate: config.GetDuration(config.FlagConnectTimeoutSessionCreate),
+			TunnelUp:      config.GetDuration(config.FlagConnectTimeoutTunnelUp),
+		},
+		ConnectRetry: OptionsConnectRetry{
+			Attempts: config.GetInt(config.FlagConnectRetryAttempts),
+			Backoff:  config.GetDuration(config.FlagConnectRetryBackoff),
+			Jitter:   config.GetFloat64(config.FlagConnectRetryJitter),
+		},
+		ConnectProbe: OptionsConnectProbe{
+			Candidates: config.GetInt(config.FlagConnectProbeCandidates),
+			Timeout:    config.GetDuration(config.FlagConnectProbeTimeout),
+		},
+		ConnectionMaxBandwidth:        config.GetString(config.FlagConnectionMaxBandwidth),
+		ConnectionIdleTimeout:         config.GetDuration(config.FlagConnectionIdleTimeout),
+		ConnectionKeyRotationInterval: config.GetDuration(config.FlagConnectionKeyRotationInterval),
+		AutoConnect: OptionsAutoConnect{
+			Enabled:     config.GetBool(config.FlagConnectionAutoConnect),
+			ProviderID:  config.GetString(config.FlagConnectionAutoConnectProvider),
+			Country:     config.GetString(config.FlagConnectionAutoConnectCountry),
+			ServiceType: config.GetString(config.FlagConnectionAutoConnectServiceType),
+		},
+		AutoSwitch: OptionsAutoSwitch{
+			Enabled:       config.GetBool(config.FlagConnectionAutoSwitch),
+			MaxPacketLoss: config.GetFloat64(config.FlagConnectionAutoSwitchMaxPacketLoss),
+			MaxLatency:    config.GetDuration(config.FlagConnectionAutoSwitchMaxLatency),
+			Period:        config.GetDuration(config.FlagConnectionAutoSwitchPeriod),
+		},
+		ConnectionNetworkCheckInterval: config.GetDuration(config.FlagConnectionNetworkCheckInterval),
+		SessionBandwidth:               config.GetString(config.FlagServiceSessionBandwidth),
+		SessionBandwidthAdvertise:      config.GetBool(config.FlagServiceSessionBandwidthAdvertise),
+		TrafficQuotaGiB:                config.GetUInt64(config.FlagServiceTrafficQuota),
+		OperatingHours:                 config.GetString(config.FlagServiceOperatingHours),
+		OperatingHoursDrain:            config.GetDuration(config.FlagServiceOperatingHoursDrain),
+		Webhook: OptionsWebhook{
+			URL:    config.GetString(config.FlagServiceWebhookURL),
+			Secret: config.GetString(config.FlagServiceWebhookSecret),
+		},
+		SpeedTest: OptionsSpeedTest{
+			Targets:  config.GetStringSlice(config.FlagServiceSpeedTestTargets),
+			Interval: config.GetDuration(config.FlagServiceSpeedTestInterval),
+			Duration: config.GetDuration(config.FlagServiceSpeedTestDuration),
+		},
+		AbuseDetection: OptionsAbuseDetection{
+			Enabled:                    config.GetBool(config.FlagServiceAbuseDetection),
+			SpamPorts:                  config.GetStringSlice(config.FlagServiceAbuseDetectionSpamPorts),
+			PortScanPorts:              config.GetInt(config.FlagServiceAbuseDetectionPortScanPorts),
+			MaxNewConnectionsPerMinute: config.GetInt(config.FlagServiceAbuseDetectionMaxNewConnections),
+			Action:                     config.GetString(config.FlagServiceAbuseDetectionAction),
+			ThrottleBandwidth:          config.GetString(config.FlagServiceAbuseDetectionThrottleBandwidth),
+			BanDuration:                config.GetDuration(config.FlagServiceAbuseDetectionBan),
+			Exempt:                     config.GetStringSlice(config.FlagServiceAbuseDetectionExempt),
+		},
+		Throttle: OptionsThrottle{
+			CPUPercent:             config.GetFloat64(config.FlagServiceThrottleCPU),
+			MemoryPercent:          config.GetFloat64(config.FlagServiceThrottleMemory),
+			FileDescriptorsPercent: config.GetFloat64(config.FlagServiceThrottleFileDescriptors),
+		},
+		DynamicPricing: OptionsDynamicPricing{
+			Enabled:           config.GetBool(config.FlagPaymentDynamicPricing),
+			PricePerGBMin:     crypto.FloatToBigMyst(config.GetFloat64(config.FlagPaymentDynamicPricingPricePerGBMin)),
+			PricePerGBMax:     crypto.FloatToBigMyst(config.GetFloat64(config.FlagPaymentDynamicPricingPricePerGBMax)),
+			PricePerMinuteMin: crypto.FloatToBigMyst(config.GetFloat64(config.FlagPaymentDynamicPricingPricePerMinuteMin)),
+			PricePerMinuteMax: crypto.FloatToBigMyst(config.GetFloat64(config.FlagPaymentDynamicPricingPricePerMinuteMax)),
+			MaxSessions:       config.GetInt(config.FlagPaymentDynamicPricingSessions),
+			MaxBandwidth:      config.GetString(config.FlagPaymentDynamicPricingBandwidth),
 		},
 		Transactor: OptionsTransactor{
 			TransactorEndpointAddress:       config.GetString(config.FlagTransactorAddress),
@@ -130,6 +264,10 @@ func GetOptions() *Options {
 			ProviderMaxRegistrationAttempts: config.GetInt(config.FlagTransactorProviderMaxRegistrationAttempts),
 			ProviderRegistrationRetryDelay:  config.GetDuration(config.FlagTransactorProviderRegistrationRetryDelay),
 			ProviderRegistrationStake:       config.GetBigInt(config.FlagTransactorProviderRegistrationStake),
+			RegistrationRetryMaxAttempts:    config.GetInt(config.FlagTransactorRegistrationRetryMaxAttempts),
+			RegistrationRetryDelay:          config.GetDuration(config.FlagTransactorRegistrationRetryDelay),
+			RegistrationRetryMaxDelay:       config.GetDuration(config.FlagTransactorRegistrationRetryMaxDelay),
+			RegistrationRetryStuckTimeout:   config.GetDuration(config.FlagTransactorRegistrationRetryStuckTimeout),
 		},
 		Payments: OptionsPayments{
 			MaxAllowedPaymentPercentile:    config.GetInt(config.FlagPaymentsMaxHermesFee),
@@ -146,6 +284,7 @@ func GetOptions() *Options {
 			ConsumerDataLeewayMegabytes:    config.GetUInt64(config.FlagPaymentsConsumerDataLeewayMegabytes),
 			ProviderInvoiceFrequency:       config.GetDuration(config.FlagPaymentsProviderInvoiceFrequency),
 			MaxUnpaidInvoiceValue:          config.GetBigInt(config.FlagPaymentsMaxUnpaidInvoiceValue),
+			ProviderSessionKeyTTL:          config.GetDuration(config.FlagPaymentsProviderSessionKeyTTL),
 		},
 		Hermes: OptionsHermes{
 			HermesID: config.GetString(config.FlagHermesID),
@@ -156,8 +295,20 @@ func GetOptions() *Options {
 		Firewall: OptionsFirewall{
 			BlockAlways: config.GetBool(config.FlagFirewallKillSwitch),
 		},
-		P2PPorts: getP2PListenPorts(),
-		Consumer: config.GetBool(config.FlagConsumer),
+		P2PPorts:     getP2PPortRange(config.FlagP2PListenPorts),
+		P2PPortRange: getP2PPortRange(config.FlagP2PPorts),
+		P2PRelay: OptionsP2PRelay{
+			Address:        config.GetString(config.FlagP2PRelayAddress),
+			ServiceTraffic: config.GetBool(config.FlagP2PRelayServiceTraffic),
+		},
+		P2PKeepAlive: OptionsP2PKeepAlive{
+			Interval:    config.GetDuration(config.FlagP2PKeepAliveInterval),
+			Timeout:     config.GetDuration(config.FlagP2PKeepAliveTimeout),
+			MaxFailures: config.GetInt(config.FlagP2PKeepAliveMaxFailures),
+		},
+		P2PKeyRotationInterval: config.GetDuration(config.FlagP2PKeyRotationInterval),
+		P2PObfuscation:         config.GetBool(config.FlagP2PObfuscation),
+		Consumer:               config.GetBool(config.FlagConsumer),
 	}
 }
 
@@ -188,11 +339,47 @@ func GetDiscoveryOptions() *OptionsDiscovery {
 	}
 
 	return &OptionsDiscovery{
-		Types:         types,
-		PingInterval:  config.GetDuration(config.FlagDiscoveryPingInterval),
-		FetchEnabled:  true,
-		FetchInterval: config.GetDuration(config.FlagDiscoveryFetchInterval),
-		DHT:           *GetDHTOptions(),
+		Types:                  types,
+		PingInterval:           config.GetDuration(config.FlagDiscoveryPingInterval),
+		FetchEnabled:           true,
+		FetchInterval:          config.GetDuration(config.FlagDiscoveryFetchInterval),
+		CacheTTL:               config.GetDuration(config.FlagDiscoveryCacheTTL),
+		BrokerPush:             config.GetBool(config.FlagDiscoveryBrokerPush),
+		APIv3Address:           config.GetString(config.FlagDiscoveryAPIv3Address),
+		APIv3PageSize:          config.GetInt(config.FlagDiscoveryAPIv3PageSize),
+		APIFallbackAddresses:   config.GetStringSlice(config.FlagDiscoveryAPIFallbackAddresses),
+		APIHealthCheckInterval: config.GetDuration(config.FlagDiscoveryAPIHealthCheckInterval),
+		RequireSignature:       config.GetBool(config.FlagDiscoveryRequireSignature),
+		PriceMaxRatio:          config.GetFloat64(config.FlagDiscoveryPriceMaxRatio),
+		PriceHideOutliers:      config.GetBool(config.FlagDiscoveryPriceHideOutliers),
+		DHT:                    *GetDHTOptions(),
+		Selection:              *GetSelectionOptions(),
+	}
+}
+
+// GetSelectionOptions retrieves provider selection options from the app configuration.
+func GetSelectionOptions() *OptionsSelection {
+	var countries []string
+	for _, country := range strings.Split(config.GetString(config.FlagSelectionCountries), ",") {
+		if country = strings.TrimSpace(country); country != "" {
+			countries = append(countries, country)
+		}
+	}
+
+	return &OptionsSelection{
+		PriceWeight:   config.GetFloat64(config.FlagSelectionWeightPrice),
+		QualityWeight: config.GetFloat64(config.FlagSelectionWeightQuality),
+		LatencyWeight: config.GetFloat64(config.FlagSelectionWeightLatency),
+		CountryWeight: config.GetFloat64(config.FlagSelectionWeightCountry),
+		HistoryWeight: config.GetFloat64(config.FlagSelectionWeightHistory),
+		NATWeight:     config.GetFloat64(config.FlagSelectionWeightNAT),
+		Countries:     countries,
+		Filter:        config.GetString(config.FlagSelectionFilter),
+		LatencyProbe: OptionsLatencyProbe{
+			Count:   config.GetInt(config.FlagSelectionLatencyProbeCount),
+			Timeout: config.GetDuration(config.FlagSelectionLatencyProbeTimeout),
+			TTL:     config.GetDuration(config.FlagSelectionLatencyProbeTTL),
+		},
 	}
 }
 
@@ -208,18 +395,56 @@ func GetDHTOptions() *OptionsDHT {
 
 // OptionsKeystore stores the keystore configuration
 type OptionsKeystore struct {
+	// Provider selects where keys are kept, filesystem keystore is used if empty.
+	Provider       string
 	UseLightweight bool
+	// ScryptN and ScryptP override scrypt parameters of the lightweight or standard preset if not zero.
+	ScryptN int
+	ScryptP int
+	// RemoteSigner keeps identity key in the remote service if its URL is set.
+	RemoteSigner OptionsRemoteSigner
+	// PKCS11 describes HSM keeping the keys when pkcs11 provider is selected.
+	PKCS11 OptionsPKCS11
+}
+
+// OptionsPKCS11 describes HSM token accessed through PKCS#11 module.
+type OptionsPKCS11 struct {
+	Module string
+	Token  string
+	PIN    string
+}
+
+// OptionsRemoteSigner describes remote service signing on behalf of the identity.
+type OptionsRemoteSigner struct {
+	URL      string
+	Identity string
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// OptionsP2PRelay describes relay server p2p traffic falls back to when NAT traversal fails.
+type OptionsP2PRelay struct {
+	Address        string
+	ServiceTraffic bool
+}
+
+// OptionsP2PKeepAlive describes keepalive pings detecting unreachable p2p peer, zero interval keeps the default one.
+type OptionsP2PKeepAlive struct {
+	Interval    time.Duration
+	Timeout     time.Duration
+	MaxFailures int
 }
 
-func getP2PListenPorts() *port.Range {
-	p2pPortRange, err := port.ParseRange(config.GetString(config.FlagP2PListenPorts))
+func getP2PPortRange(flag cli.StringFlag) *port.Range {
+	p2pPortRange, err := port.ParseRange(config.GetString(flag))
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to parse p2p listen port range, using default value")
+		log.Warn().Err(err).Msgf("Failed to parse %s port range, using default value", flag.Name)
 		p2pPortRange = port.UnspecifiedRange()
 	}
 	if p2pPortRange.Capacity() > resources.MaxConnections {
-		log.Warn().Msgf("Specified p2p port range exceeds maximum number of connections allowed for the platform (%d), "+
-			"using default value", resources.MaxConnections)
+		log.Warn().Msgf("Specified %s port range exceeds maximum number of connections allowed for the platform (%d), "+
+			"using default value", flag.Name, resources.MaxConnections)
 		p2pPortRange = port.UnspecifiedRange()
 	}
 	return p2pPortRange
@@ -0,0 +1,39 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node
+
+import "time"
+
+// OptionsAbuseDetection controls penalizing of consumers abusing provider connection
+type OptionsAbuseDetection struct {
+	Enabled bool
+	// SpamPorts are destination ports of mail servers consumers are not allowed to connect to
+	SpamPorts []string
+	// PortScanPorts is the number of ports of a single host which is considered a port scan, zero value disables the rule
+	PortScanPorts int
+	// MaxNewConnectionsPerMinute is the new connection rate of a session considered excessive, zero value disables the rule
+	MaxNewConnectionsPerMinute int
+	// Action is taken against abusive sessions, either "throttle" or "terminate"
+	Action string
+	// ThrottleBandwidth is the speed abusive sessions are limited to, e.g. "1mbps"
+	ThrottleBandwidth string
+	// BanDuration is the time abusive consumers are not allowed to create sessions, zero value disables bans
+	BanDuration time.Duration
+	// Exempt are identities of trusted consumers which are never penalized
+	Exempt []string
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node
+
+import "time"
+
+// OptionsConnectTimeouts describes timeouts of consumer connect flow, zero value disables the timeout
+type OptionsConnectTimeouts struct {
+	Connect       time.Duration
+	ProposalFetch time.Duration
+	P2PDial       time.Duration
+	SessionCreate time.Duration
+	TunnelUp      time.Duration
+}
+
+// OptionsConnectRetry describes how failed connect attempts are retried
+type OptionsConnectRetry struct {
+	Attempts int
+	Backoff  time.Duration
+	Jitter   float64
+}
+
+// OptionsConnectProbe describes probing of candidate providers before connecting
+type OptionsConnectProbe struct {
+	Candidates int
+	Timeout    time.Duration
+}
+
+// OptionsAutoConnect describes target which consumer is kept connected to since node start
+type OptionsAutoConnect struct {
+	Enabled     bool
+	ProviderID  string
+	Country     string
+	ServiceType string
+}
+
+// OptionsAutoSwitch describes when consumer connection is switched to another provider because of degraded tunnel health
+type OptionsAutoSwitch struct {
+	Enabled       bool
+	MaxPacketLoss float64
+	MaxLatency    time.Duration
+	Period        time.Duration
+}
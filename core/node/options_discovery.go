@@ -38,7 +38,47 @@ type OptionsDiscovery struct {
 	PingInterval  time.Duration
 	FetchEnabled  bool
 	FetchInterval time.Duration
-	DHT           OptionsDHT
+	CacheTTL      time.Duration
+	BrokerPush    bool
+	// APIv3Address is the address of discovery API v3, proposals are fetched from Mysterium API when empty
+	APIv3Address string
+	// APIv3PageSize is the number of proposals fetched from discovery API v3 in a single request
+	APIv3PageSize int
+	// APIFallbackAddresses are discovery APIs in priority order used while the main one is unreachable
+	APIFallbackAddresses []string
+	// APIHealthCheckInterval is the interval of discovery API health checks when fallback addresses are configured
+	APIHealthCheckInterval time.Duration
+	// RequireSignature drops proposals without a valid provider signature
+	RequireSignature bool
+	// PriceMaxRatio flags proposals priced above the network median more than the given times, 0 disables the check
+	PriceMaxRatio float64
+	// PriceHideOutliers hides flagged proposals from listings
+	PriceHideOutliers bool
+	DHT               OptionsDHT
+	Selection         OptionsSelection
+}
+
+// OptionsSelection describes weights of provider selection criteria, zero weight disables the criterion.
+type OptionsSelection struct {
+	PriceWeight   float64
+	QualityWeight float64
+	LatencyWeight float64
+	CountryWeight float64
+	HistoryWeight float64
+	NATWeight     float64
+	// Countries lists preferred provider countries, most preferred first
+	Countries []string
+	// Filter is the name of saved proposal filter providers must match
+	Filter string
+	// LatencyProbe describes probing latency of top ranked providers
+	LatencyProbe OptionsLatencyProbe
+}
+
+// OptionsLatencyProbe describes probing latency of top ranked providers, zero count disables probing.
+type OptionsLatencyProbe struct {
+	Count   int
+	Timeout time.Duration
+	TTL     time.Duration
 }
 
 // OptionsDHT describes possible parameters of DHT configuration.
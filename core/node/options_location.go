@@ -35,6 +35,9 @@ const (
 type OptionsLocation struct {
 	IPDetectorURL string
 
+	DNSLeakDetectorURL  string
+	DNSLeakCanaryDomain string
+
 	Type     LocationType
 	Address  string
 	Country  string
@@ -27,6 +27,8 @@ type OptionsNetwork struct {
 
 	MysteriumAPIAddress string
 	BrokerAddress       string
+	// BrokerFallbackAddresses are brokers used while the main one is unreachable.
+	BrokerFallbackAddresses []string
 
 	EtherClientRPC string
 }
@@ -0,0 +1,33 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node
+
+import "math/big"
+
+// OptionsDynamicPricing controls demand based pricing of provided services
+type OptionsDynamicPricing struct {
+	Enabled           bool
+	PricePerGBMin     *big.Int
+	PricePerGBMax     *big.Int
+	PricePerMinuteMin *big.Int
+	PricePerMinuteMax *big.Int
+	// MaxSessions is the number of active sessions at which provider is considered fully utilized
+	MaxSessions int
+	// MaxBandwidth is the traffic speed at which provider is considered fully utilized, e.g. "100mbps", empty value ignores traffic
+	MaxBandwidth string
+}
@@ -17,6 +17,8 @@
 
 package node
 
+import "time"
+
 // QualityType identifies Quality Oracle provider
 type QualityType string
 
@@ -33,4 +35,6 @@ const (
 type OptionsQuality struct {
 	Type    QualityType
 	Address string
+	// MetricsTTL is how long proposal metrics fetched from Quality Oracle are reused
+	MetricsTTL time.Duration
 }
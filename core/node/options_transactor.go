@@ -30,4 +30,9 @@ type OptionsTransactor struct {
 	ProviderMaxRegistrationAttempts int
 	ProviderRegistrationRetryDelay  time.Duration
 	ProviderRegistrationStake       *big.Int
+
+	RegistrationRetryMaxAttempts  int
+	RegistrationRetryDelay        time.Duration
+	RegistrationRetryMaxDelay     time.Duration
+	RegistrationRetryStuckTimeout time.Duration
 }
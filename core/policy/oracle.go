@@ -31,13 +31,30 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+const cacheBucket = "access-policy-cache"
+
+// Storage persists cached policy rules, so that they survive node restarts.
+type Storage interface {
+	GetValue(bucket string, key interface{}, to interface{}) error
+	SetValue(bucket string, key interface{}, to interface{}) error
+}
+
+// cachedRules are policy rules last fetched from TrustOracle.
+type cachedRules struct {
+	Rules     market.AccessPolicyRuleSet
+	ETag      string
+	FetchedAt time.Time
+}
+
 type policySubscription struct {
 	policy      market.AccessPolicy
 	eTag        string
 	subscribers []*Repository
 }
 
-// Oracle represents async policy fetcher from TrustOracle
+// Oracle represents async policy fetcher from TrustOracle.
+// Fetched rules are cached, so that policies are served stale while TrustOracle is unreachable and revalidated
+// on each fetch, until cached rules get older than max stale period.
 type Oracle struct {
 	client             *requests.HTTPClient
 	fetchURL           string
@@ -45,17 +62,27 @@ type Oracle struct {
 	fetchLock          sync.RWMutex
 	fetchSubscriptions []policySubscription
 
+	storage  Storage
+	maxStale time.Duration
+	cache    map[string]cachedRules
+	now      func() time.Time
+
 	fetchShutdown     chan struct{}
 	fetchShutdownOnce sync.Once
 }
 
-// NewOracle create instance of policy fetcher
-func NewOracle(client *requests.HTTPClient, policyURL string, interval time.Duration) *Oracle {
+// NewOracle create instance of policy fetcher, cached rules are persisted to the given storage when it is not nil.
+// Zero max stale period disables serving of cached rules.
+func NewOracle(client *requests.HTTPClient, policyURL string, interval time.Duration, storage Storage, maxStale time.Duration) *Oracle {
 	return &Oracle{
 		client:             client,
 		fetchURL:           policyURL,
 		fetchInterval:      interval,
 		fetchSubscriptions: make([]policySubscription, 0),
+		storage:            storage,
+		maxStale:           maxStale,
+		cache:              make(map[string]cachedRules),
+		now:                time.Now,
 		fetchShutdown:      make(chan struct{}),
 	}
 }
@@ -73,7 +100,7 @@ func (pr *Oracle) Start() {
 			copy(subscriptionsActive, pr.fetchSubscriptions)
 
 			for index := range subscriptionsActive {
-				if err := pr.fetchPolicyRules(&subscriptionsActive[index]); err != nil {
+				if err := pr.syncPolicyRules(&subscriptionsActive[index]); err != nil {
 					log.Warn().Err(err).Msg("synchronise fetch failed")
 				}
 			}
@@ -127,7 +154,7 @@ func (pr *Oracle) SubscribePolicies(policies []market.AccessPolicy, repository *
 			subscribers: []*Repository{repository},
 		})
 
-		if err := pr.fetchPolicyRules(&subscriptionsNew[index]); err != nil {
+		if err := pr.syncPolicyRules(&subscriptionsNew[index]); err != nil {
 			return errors.Wrap(err, "initial fetch failed")
 		}
 	}
@@ -136,6 +163,74 @@ func (pr *Oracle) SubscribePolicies(policies []market.AccessPolicy, repository *
 	return nil
 }
 
+// UnsubscribePolicies stops syncing policies to the given repository, e.g. once access policies of the service change.
+func (pr *Oracle) UnsubscribePolicies(repository *Repository) {
+	pr.fetchLock.Lock()
+	defer pr.fetchLock.Unlock()
+
+	subscriptionsNew := make([]policySubscription, 0, len(pr.fetchSubscriptions))
+	for _, subscription := range pr.fetchSubscriptions {
+		subscribers := make([]*Repository, 0, len(subscription.subscribers))
+		for _, subscriber := range subscription.subscribers {
+			if subscriber != repository {
+				subscribers = append(subscribers, subscriber)
+			}
+		}
+		if len(subscribers) == 0 {
+			continue
+		}
+		subscription.subscribers = subscribers
+		subscriptionsNew = append(subscriptionsNew, subscription)
+	}
+
+	pr.fetchSubscriptions = subscriptionsNew
+}
+
+// syncPolicyRules fetches policy rules to subscribers, falling back to cached rules while TrustOracle is unreachable.
+func (pr *Oracle) syncPolicyRules(subscription *policySubscription) error {
+	err := pr.fetchPolicyRules(subscription)
+	if err == nil {
+		return nil
+	}
+
+	cached, ok := pr.cachedRules(subscription.policy)
+	if !ok || pr.maxStale <= 0 || pr.now().Sub(cached.FetchedAt) > pr.maxStale {
+		return err
+	}
+	log.Warn().Err(err).Msgf("Using access policy %s cached at %s", subscription.policy.ID, cached.FetchedAt.Format(time.RFC3339))
+	subscription.eTag = cached.ETag
+	for _, subscriber := range subscription.subscribers {
+		subscriber.SetPolicyRules(subscription.policy, cached.Rules)
+	}
+	return nil
+}
+
+func (pr *Oracle) cachedRules(policy market.AccessPolicy) (cachedRules, bool) {
+	if cached, ok := pr.cache[policy.Source]; ok {
+		return cached, true
+	}
+	if pr.storage == nil {
+		return cachedRules{}, false
+	}
+
+	var cached cachedRules
+	if err := pr.storage.GetValue(cacheBucket, policy.Source, &cached); err != nil {
+		return cachedRules{}, false
+	}
+	pr.cache[policy.Source] = cached
+	return cached, true
+}
+
+func (pr *Oracle) cacheRules(policy market.AccessPolicy, cached cachedRules) {
+	pr.cache[policy.Source] = cached
+	if pr.storage == nil {
+		return
+	}
+	if err := pr.storage.SetValue(cacheBucket, policy.Source, cached); err != nil {
+		log.Warn().Err(err).Msgf("Could not cache access policy %s", policy.ID)
+	}
+}
+
 func (pr *Oracle) fetchPolicyRules(subscription *policySubscription) error {
 	req, err := requests.NewGetRequest(subscription.policy.Source, "", nil)
 	if err != nil {
@@ -152,6 +247,10 @@ func (pr *Oracle) fetchPolicyRules(subscription *policySubscription) error {
 	httptrace.TraceRequestResponse(req, res)
 
 	if res.StatusCode == http.StatusNotModified {
+		if cached, ok := pr.cachedRules(subscription.policy); ok && cached.ETag == subscription.eTag {
+			cached.FetchedAt = pr.now()
+			pr.cacheRules(subscription.policy, cached)
+		}
 		return nil
 	}
 	if err := requests.ParseResponseError(res); err != nil {
@@ -164,6 +263,7 @@ func (pr *Oracle) fetchPolicyRules(subscription *policySubscription) error {
 		return errors.Wrapf(err, "failed to parse policy rule %s", subscription.policy)
 	}
 	subscription.eTag = res.Header.Get("ETag")
+	pr.cacheRules(subscription.policy, cachedRules{Rules: rules, ETag: subscription.eTag, FetchedAt: pr.now()})
 
 	for _, subscriber := range subscription.subscribers {
 		subscriber.SetPolicyRules(subscription.policy, rules)
@@ -18,6 +18,7 @@
 package policy
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -161,6 +162,23 @@ func Test_Oracle_SubscribePolicies_MultipleSubscribers(t *testing.T) {
 	assert.Equal(t, []market.AccessPolicyRuleSet{policyOneRulesUpdated}, repo2.Rules())
 }
 
+func Test_Oracle_UnsubscribePolicies(t *testing.T) {
+	server := mockPolicyServer()
+	defer server.Close()
+
+	oracle := createEmptyOracle(server.URL)
+
+	repo1 := NewRepository()
+	assert.NoError(t, oracle.SubscribePolicies(oracle.Policies([]string{"1", "3"}), repo1))
+	repo2 := NewRepository()
+	assert.NoError(t, oracle.SubscribePolicies(oracle.Policies([]string{"1"}), repo2))
+	assert.Len(t, oracle.fetchSubscriptions, 3)
+
+	oracle.UnsubscribePolicies(repo1)
+	assert.Len(t, oracle.fetchSubscriptions, 1)
+	assert.Equal(t, []*Repository{repo2}, oracle.fetchSubscriptions[0].subscribers)
+}
+
 func Test_Oracle_StartSyncsPolicies(t *testing.T) {
 	repo := NewRepository()
 	server := mockPolicyServer()
@@ -179,7 +197,7 @@ func Test_Oracle_StartSyncsPolicies(t *testing.T) {
 }
 
 func Test_PolicyRepository_StartMultipleTimes(t *testing.T) {
-	oracle := NewOracle(requests.NewHTTPClient("0.0.0.0", time.Second), "http://policy.localhost", time.Minute)
+	oracle := NewOracle(requests.NewHTTPClient("0.0.0.0", time.Second), "http://policy.localhost", time.Minute, nil, 0)
 	go oracle.Start()
 	oracle.Stop()
 
@@ -187,11 +205,72 @@ func Test_PolicyRepository_StartMultipleTimes(t *testing.T) {
 	oracle.Stop()
 }
 
+func Test_Oracle_SubscribePolicies_ServesStaleRulesWhileEndpointFails(t *testing.T) {
+	var failing bool
+	var lock sync.Mutex
+	policyServer := mockPolicyServer()
+	defer policyServer.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		policyServer.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	storage := &mockStorage{values: make(map[interface{}]cachedRules)}
+	now := time.Date(2020, 10, 16, 12, 0, 0, 0, time.UTC)
+	oracle := NewOracle(requests.NewHTTPClient("0.0.0.0", time.Second), server.URL+"/", time.Minute, storage, time.Hour)
+	oracle.now = func() time.Time { return now }
+	assert.NoError(t, oracle.SubscribePolicies(oracle.Policies([]string{"1"}), NewRepository()))
+
+	lock.Lock()
+	failing = true
+	lock.Unlock()
+
+	// Cached rules survive node restart.
+	restarted := NewOracle(requests.NewHTTPClient("0.0.0.0", time.Second), server.URL+"/", time.Minute, storage, time.Hour)
+	restarted.now = func() time.Time { return now.Add(30 * time.Minute) }
+	repo := NewRepository()
+	assert.NoError(t, restarted.SubscribePolicies(restarted.Policies([]string{"1"}), repo))
+	assert.Equal(t, []market.AccessPolicyRuleSet{policyOneRulesUpdated}, repo.Rules())
+
+	restarted.now = func() time.Time { return now.Add(2 * time.Hour) }
+	err := restarted.SubscribePolicies(restarted.Policies([]string{"1"}), NewRepository())
+	assert.Error(t, err)
+
+	err = restarted.SubscribePolicies(restarted.Policies([]string{"2"}), NewRepository())
+	assert.Error(t, err)
+}
+
+type mockStorage struct {
+	values map[interface{}]cachedRules
+}
+
+func (m *mockStorage) GetValue(_ string, key interface{}, to interface{}) error {
+	value, ok := m.values[key]
+	if !ok {
+		return errors.New("not found")
+	}
+	*to.(*cachedRules) = value
+	return nil
+}
+
+func (m *mockStorage) SetValue(_ string, key interface{}, value interface{}) error {
+	m.values[key] = value.(cachedRules)
+	return nil
+}
+
 func createEmptyOracle(mockServerURL string) *Oracle {
 	return NewOracle(
 		requests.NewHTTPClient("0.0.0.0", 100*time.Millisecond),
 		mockServerURL+"/",
 		time.Minute,
+		nil,
+		0,
 	)
 }
 
@@ -200,6 +279,8 @@ func createFilledOracle(mockServerURL string, interval time.Duration, repo *Repo
 		requests.NewHTTPClient("0.0.0.0", time.Second),
 		mockServerURL+"/",
 		interval,
+		nil,
+		0,
 	)
 	oracle.SubscribePolicies(
 		[]market.AccessPolicy{oracle.Policy("1"), oracle.Policy("2")},
@@ -0,0 +1,252 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pricing
+
+import (
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/market"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+)
+
+// Services lists provided services and updates their prices.
+type Services interface {
+	List() map[service.ID]*service.Instance
+	UpdatePaymentMethod(id service.ID, pm market.PaymentMethod) error
+}
+
+// Sessions lists sessions served by the provider.
+type Sessions interface {
+	GetAll() []*service.Session
+}
+
+// Bounds limits prices advertised by dynamic pricing.
+type Bounds struct {
+	PricePerGBMin, PricePerGBMax         *big.Int
+	PricePerMinuteMin, PricePerMinuteMax *big.Int
+}
+
+// Config describes how dynamic pricing measures provider utilization.
+type Config struct {
+	Bounds Bounds
+	// MaxSessions is the number of active sessions at which provider is fully utilized.
+	MaxSessions int
+	// MaxBandwidth is the traffic speed at which provider is fully utilized, zero value ignores traffic.
+	MaxBandwidth datasize.BitSpeed
+	// Steps is the number of price levels above the minimum, proposals are re-registered only when level changes.
+	Steps int
+	// CheckInterval is the period of utilization measurement.
+	CheckInterval time.Duration
+}
+
+// DynamicPricing raises prices of provided services as provider gets busier and lowers them when demand drops.
+type DynamicPricing struct {
+	config   Config
+	services Services
+	sessions Sessions
+	now      func() time.Time
+
+	lock         sync.Mutex
+	sessionBytes map[string]uint64
+	bytes        uint64
+	measuredAt   time.Time
+	level        int
+	applied      map[service.ID]int
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDynamicPricing creates dynamic pricing of provided services.
+func NewDynamicPricing(config Config, services Services, sessions Sessions) *DynamicPricing {
+	if config.Steps <= 0 {
+		config.Steps = 1
+	}
+	return &DynamicPricing{
+		config:       config,
+		services:     services,
+		sessions:     sessions,
+		now:          time.Now,
+		sessionBytes: make(map[string]uint64),
+		applied:      make(map[service.ID]int),
+		stop:         make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to session traffic and service status events.
+func (p *DynamicPricing) Subscribe(bus eventbus.Subscriber) error {
+	if err := bus.SubscribeAsync(sevent.AppTopicDataTransferred, p.consumeDataTransferredEvent); err != nil {
+		return err
+	}
+	if err := bus.SubscribeAsync(sevent.AppTopicSession, p.consumeSessionEvent); err != nil {
+		return err
+	}
+	return bus.SubscribeAsync(servicestate.AppTopicServiceStatus, p.consumeServiceStatusEvent)
+}
+
+// Start starts measuring provider utilization.
+func (p *DynamicPricing) Start() {
+	p.lock.Lock()
+	p.measuredAt = p.now()
+	p.lock.Unlock()
+
+	p.apply()
+	go p.checkLoop()
+}
+
+// Stop stops measuring provider utilization, prices are left as they are.
+func (p *DynamicPricing) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+}
+
+func (p *DynamicPricing) checkLoop() {
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(p.config.CheckInterval):
+			p.check()
+		}
+	}
+}
+
+func (p *DynamicPricing) consumeDataTransferredEvent(e sevent.AppEventDataTransferred) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// Session reports totals, only the growth since the previous report is new traffic.
+	total := e.Up + e.Down
+	delta := total
+	if last, ok := p.sessionBytes[e.ID]; ok && total >= last {
+		delta = total - last
+	}
+	p.sessionBytes[e.ID] = total
+	p.bytes += delta
+}
+
+func (p *DynamicPricing) consumeSessionEvent(e sevent.AppEventSession) {
+	if e.Status != sevent.RemovedStatus {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.sessionBytes, e.Session.ID)
+}
+
+// consumeServiceStatusEvent prices newly started services without waiting for the next check.
+func (p *DynamicPricing) consumeServiceStatusEvent(e servicestate.AppEventServiceStatus) {
+	if e.Status != string(servicestate.Running) {
+		return
+	}
+	p.apply()
+}
+
+// check measures provider utilization and updates prices once price level changes.
+func (p *DynamicPricing) check() {
+	p.lock.Lock()
+	now := p.now()
+	var speed datasize.BitSpeed
+	if elapsed := now.Sub(p.measuredAt).Seconds(); elapsed > 0 {
+		speed = datasize.BitSpeed(float64(p.bytes) * 8 / elapsed)
+	}
+	p.bytes = 0
+	p.measuredAt = now
+
+	level := int(math.Round(p.utilization(len(p.sessions.GetAll()), speed) * float64(p.config.Steps)))
+	if level != p.level {
+		log.Info().Msgf("Provider utilization changed, updating prices to level %d of %d", level, p.config.Steps)
+	}
+	p.level = level
+	p.lock.Unlock()
+
+	p.apply()
+}
+
+// utilization returns load of the provider from 0 to 1, the busiest resource is taken into account.
+func (p *DynamicPricing) utilization(sessions int, speed datasize.BitSpeed) float64 {
+	var utilization float64
+	if p.config.MaxSessions > 0 {
+		utilization = float64(sessions) / float64(p.config.MaxSessions)
+	}
+	if p.config.MaxBandwidth > 0 {
+		utilization = math.Max(utilization, float64(speed)/float64(p.config.MaxBandwidth))
+	}
+	return math.Min(utilization, 1)
+}
+
+// apply updates prices of services which are not priced at the current level yet.
+func (p *DynamicPricing) apply() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	instances := p.services.List()
+	for id := range p.applied {
+		if _, ok := instances[id]; !ok {
+			delete(p.applied, id)
+		}
+	}
+
+	pm := p.paymentMethod(p.level)
+	for id := range instances {
+		if level, ok := p.applied[id]; ok && level == p.level {
+			continue
+		}
+		if err := p.services.UpdatePaymentMethod(id, pm); err != nil {
+			log.Warn().Err(err).Msgf("Could not update prices of service %s", id)
+			continue
+		}
+		p.applied[id] = p.level
+	}
+}
+
+func (p *DynamicPricing) paymentMethod(level int) market.PaymentMethod {
+	bounds := p.config.Bounds
+	return pingpong.NewPaymentMethod(
+		priceAt(bounds.PricePerGBMin, bounds.PricePerGBMax, level, p.config.Steps),
+		priceAt(bounds.PricePerMinuteMin, bounds.PricePerMinuteMax, level, p.config.Steps),
+	)
+}
+
+// priceAt returns price of the given level, prices of levels are evenly spread between min and max.
+func priceAt(min, max *big.Int, level, steps int) *big.Int {
+	if min == nil {
+		min = new(big.Int)
+	}
+	if max == nil || max.Cmp(min) <= 0 {
+		return min
+	}
+
+	diff := new(big.Int).Sub(max, min)
+	diff.Mul(diff, big.NewInt(int64(level)))
+	diff.Div(diff, big.NewInt(int64(steps)))
+	return diff.Add(diff, min)
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pricing
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/market"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+)
+
+var testBounds = Bounds{
+	PricePerGBMin:     big.NewInt(100),
+	PricePerGBMax:     big.NewInt(300),
+	PricePerMinuteMin: big.NewInt(10),
+	PricePerMinuteMax: big.NewInt(30),
+}
+
+func TestDynamicPricing_FollowsSessionCount(t *testing.T) {
+	services := &mockServices{instances: map[service.ID]*service.Instance{"1": {}}}
+	sessions := &mockSessions{}
+	p := newTestPricing(Config{Bounds: testBounds, MaxSessions: 4, Steps: 2}, services, sessions)
+
+	p.Start()
+	defer p.Stop()
+	assert.Equal(t, []market.PaymentMethod{pingpong.NewPaymentMethod(big.NewInt(100), big.NewInt(10))}, services.updates)
+
+	sessions.sessions = []*service.Session{{}, {}}
+	p.check()
+	assert.Len(t, services.updates, 2)
+	assert.Equal(t, pingpong.NewPaymentMethod(big.NewInt(200), big.NewInt(20)), services.updates[1])
+
+	sessions.sessions = []*service.Session{{}, {}, {}, {}, {}}
+	p.check()
+	assert.Len(t, services.updates, 3)
+	assert.Equal(t, pingpong.NewPaymentMethod(big.NewInt(300), big.NewInt(30)), services.updates[2])
+}
+
+func TestDynamicPricing_KeepsProposalWhenLevelDoesNotChange(t *testing.T) {
+	services := &mockServices{instances: map[service.ID]*service.Instance{"1": {}}}
+	sessions := &mockSessions{}
+	p := newTestPricing(Config{Bounds: testBounds, MaxSessions: 10, Steps: 2}, services, sessions)
+
+	p.Start()
+	defer p.Stop()
+
+	sessions.sessions = []*service.Session{{}}
+	p.check()
+	p.consumeServiceStatusEvent(runningEvent)
+	assert.Len(t, services.updates, 1)
+}
+
+func TestDynamicPricing_FollowsBandwidth(t *testing.T) {
+	services := &mockServices{instances: map[service.ID]*service.Instance{"1": {}}}
+	p := newTestPricing(Config{Bounds: testBounds, MaxSessions: 10, MaxBandwidth: datasize.BitSpeed(datasize.KiB), Steps: 2}, services, &mockSessions{})
+
+	p.Start()
+	defer p.Stop()
+
+	now := p.now()
+	p.now = func() time.Time { return now.Add(time.Second) }
+	p.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "1", Up: 100, Down: 100})
+	p.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "1", Up: 1024, Down: 0})
+	p.check()
+
+	assert.Len(t, services.updates, 2)
+	assert.Equal(t, pingpong.NewPaymentMethod(big.NewInt(300), big.NewInt(30)), services.updates[1])
+}
+
+func TestDynamicPricing_PricesStartedServices(t *testing.T) {
+	services := &mockServices{instances: map[service.ID]*service.Instance{"1": {}}}
+	p := newTestPricing(Config{Bounds: testBounds, MaxSessions: 10}, services, &mockSessions{})
+
+	p.Start()
+	defer p.Stop()
+
+	services.instances["2"] = &service.Instance{}
+	p.consumeServiceStatusEvent(runningEvent)
+	assert.Equal(t, []service.ID{"1", "2"}, services.updated)
+}
+
+func TestPriceAt(t *testing.T) {
+	assert.Equal(t, big.NewInt(100), priceAt(big.NewInt(100), big.NewInt(200), 0, 4))
+	assert.Equal(t, big.NewInt(150), priceAt(big.NewInt(100), big.NewInt(200), 2, 4))
+	assert.Equal(t, big.NewInt(200), priceAt(big.NewInt(100), big.NewInt(200), 4, 4))
+	assert.Equal(t, big.NewInt(100), priceAt(big.NewInt(100), big.NewInt(50), 4, 4))
+	assert.Equal(t, big.NewInt(0), priceAt(nil, nil, 4, 4))
+}
+
+func newTestPricing(config Config, services Services, sessions Sessions) *DynamicPricing {
+	config.CheckInterval = time.Hour
+	return NewDynamicPricing(config, services, sessions)
+}
+
+var runningEvent = servicestate.AppEventServiceStatus{Status: string(servicestate.Running)}
+
+type mockServices struct {
+	instances map[service.ID]*service.Instance
+	updated   []service.ID
+	updates   []market.PaymentMethod
+}
+
+func (m *mockServices) List() map[service.ID]*service.Instance {
+	return m.instances
+}
+
+func (m *mockServices) UpdatePaymentMethod(id service.ID, pm market.PaymentMethod) error {
+	m.updated = append(m.updated, id)
+	m.updates = append(m.updates, pm)
+	return nil
+}
+
+type mockSessions struct {
+	sessions []*service.Session
+}
+
+func (m *mockSessions) GetAll() []*service.Session {
+	return m.sessions
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package quality
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+type metricsFinder interface {
+	ProposalsMetrics() []ConnectMetric
+}
+
+// MetricsCache fetches proposals metrics from Quality Oracle in bulk and reuses them until they expire,
+// so that every consumer of the metrics does not issue its own request.
+type MetricsCache struct {
+	finder  metricsFinder
+	ttl     time.Duration
+	timeNow func() time.Time
+
+	lock      sync.Mutex
+	metrics   []ConnectMetric
+	index     map[ProposalID]ConnectMetric
+	fetchedAt time.Time
+}
+
+// NewMetricsCache returns a new instance of the metrics cache.
+func NewMetricsCache(finder metricsFinder, ttl time.Duration) *MetricsCache {
+	return &MetricsCache{
+		finder:  finder,
+		ttl:     ttl,
+		timeNow: time.Now,
+	}
+}
+
+// ProposalsMetrics returns a list of proposals connection metrics.
+func (c *MetricsCache) ProposalsMetrics() []ConnectMetric {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.refresh()
+	return c.metrics
+}
+
+// Quality returns the quality of the given proposal, nil when Quality Oracle does not know it.
+func (c *MetricsCache) Quality(p market.ServiceProposal) *market.ProposalQuality {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.refresh()
+	return c.quality(p)
+}
+
+func (c *MetricsCache) quality(p market.ServiceProposal) *market.ProposalQuality {
+	m, ok := c.index[ProposalID{ProviderID: p.ProviderID, ServiceType: p.ServiceType}]
+	if !ok {
+		return nil
+	}
+	return &market.ProposalQuality{
+		ConnectSuccess:   m.ConnectCount.Success,
+		ConnectFail:      m.ConnectCount.Fail,
+		ConnectTimeout:   m.ConnectCount.Timeout,
+		MonitoringFailed: m.MonitoringFailed,
+	}
+}
+
+func (c *MetricsCache) refresh() {
+	now := c.timeNow()
+	if !c.fetchedAt.IsZero() && now.Sub(c.fetchedAt) < c.ttl {
+		return
+	}
+	c.fetchedAt = now
+
+	metrics := c.finder.ProposalsMetrics()
+	if metrics == nil && c.metrics != nil {
+		// Quality Oracle is unreachable, keep serving the last known metrics.
+		return
+	}
+
+	c.metrics = metrics
+	c.index = make(map[ProposalID]ConnectMetric, len(metrics))
+	for _, m := range metrics {
+		c.index[m.ProposalID] = m
+	}
+}
+
+// ProposalRepository enriches proposals of the wrapped repository with Quality Oracle metrics.
+type ProposalRepository struct {
+	proposal.Repository
+	metrics *MetricsCache
+}
+
+// NewProposalRepository returns proposal repository filling in proposal quality.
+func NewProposalRepository(repository proposal.Repository, metrics *MetricsCache) *ProposalRepository {
+	return &ProposalRepository{
+		Repository: repository,
+		metrics:    metrics,
+	}
+}
+
+// Proposal returns the proposal with its quality.
+func (r *ProposalRepository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	p, err := r.Repository.Proposal(id)
+	if err != nil || p == nil {
+		return p, err
+	}
+
+	enriched := *p
+	enriched.Quality = r.metrics.Quality(enriched)
+	return &enriched, nil
+}
+
+// Proposals returns proposals matching the filter with their quality, partial results are enriched as well.
+func (r *ProposalRepository) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	proposals, err := r.Repository.Proposals(filter)
+	if len(proposals) == 0 {
+		return proposals, err
+	}
+
+	r.metrics.lock.Lock()
+	defer r.metrics.lock.Unlock()
+
+	r.metrics.refresh()
+	enriched := make([]market.ServiceProposal, len(proposals))
+	for i, p := range proposals {
+		p.Quality = r.metrics.quality(p)
+		enriched[i] = p
+	}
+	return enriched, err
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package quality
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMetricsFinder struct {
+	metrics []ConnectMetric
+	calls   int
+}
+
+func (f *mockMetricsFinder) ProposalsMetrics() []ConnectMetric {
+	f.calls++
+	return f.metrics
+}
+
+type mockProposalRepository struct {
+	proposals []market.ServiceProposal
+}
+
+func (r *mockProposalRepository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	for _, p := range r.proposals {
+		if p.UniqueID() == id {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *mockProposalRepository) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	return r.proposals, nil
+}
+
+func TestMetricsCache_ReusesMetricsUntilExpired(t *testing.T) {
+	finder := &mockMetricsFinder{metrics: []ConnectMetric{
+		{ProposalID: ProposalID{ProviderID: "0x1", ServiceType: "wireguard"}, ConnectCount: ConnectCount{Success: 3}},
+	}}
+	now := time.Date(2020, 6, 17, 10, 0, 0, 0, time.UTC)
+	cache := NewMetricsCache(finder, time.Minute)
+	cache.timeNow = func() time.Time { return now }
+
+	assert.Len(t, cache.ProposalsMetrics(), 1)
+	assert.Len(t, cache.ProposalsMetrics(), 1)
+	assert.Equal(t, 1, finder.calls)
+
+	now = now.Add(time.Minute)
+	finder.metrics = nil
+	assert.Len(t, cache.ProposalsMetrics(), 1, "last known metrics are kept while oracle is unreachable")
+	assert.Equal(t, 2, finder.calls)
+}
+
+func TestProposalRepository_EnrichesProposals(t *testing.T) {
+	known := market.ServiceProposal{ID: 1, ProviderID: "0x1", ServiceType: "wireguard"}
+	unknown := market.ServiceProposal{ID: 1, ProviderID: "0x2", ServiceType: "wireguard"}
+	delegate := &mockProposalRepository{proposals: []market.ServiceProposal{known, unknown}}
+	finder := &mockMetricsFinder{metrics: []ConnectMetric{
+		{ProposalID: ProposalID{ProviderID: "0x1", ServiceType: "wireguard"}, ConnectCount: ConnectCount{Success: 3, Fail: 2, Timeout: 1}},
+	}}
+	repository := NewProposalRepository(delegate, NewMetricsCache(finder, time.Minute))
+
+	proposals, err := repository.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 2)
+	assert.Equal(t, &market.ProposalQuality{ConnectSuccess: 3, ConnectFail: 2, ConnectTimeout: 1}, proposals[0].Quality)
+	assert.Nil(t, proposals[1].Quality)
+	assert.Nil(t, delegate.proposals[0].Quality, "proposals of the delegate must not be modified")
+
+	p, err := repository.Proposal(known.UniqueID())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, p.Quality.ConnectSuccess)
+	assert.Equal(t, 1, finder.calls)
+}
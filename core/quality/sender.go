@@ -32,6 +32,7 @@ import (
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/p2p"
 	sessionEvent "github.com/mysteriumnetwork/node/session/event"
 	pingpongEvent "github.com/mysteriumnetwork/node/session/pingpong/event"
 	"github.com/mysteriumnetwork/node/trace"
@@ -48,6 +49,7 @@ const (
 	unlockEventName     = "unlock"
 	proposalEventName   = "proposal_event"
 	natMappingEventName = "nat_mapping"
+	traversalEventName  = "p2p_traversal"
 )
 
 // Transport allows sending events
@@ -97,6 +99,17 @@ type natMappingContext struct {
 	Gateways     []map[string]string `json:"gateways,omitempty"`
 }
 
+type traversalContext struct {
+	Role         string `json:"role"`
+	ServiceType  string `json:"service_type"`
+	Method       string `json:"method"`
+	Outcome      string `json:"outcome"`
+	NATType      string `json:"nat_type"`
+	PeerNATType  string `json:"peer_nat_type"`
+	DurationMs   int64  `json:"duration_ms"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
 type sessionEventContext struct {
 	Event string
 	sessionContext
@@ -160,6 +173,9 @@ func (sender *Sender) Subscribe(bus eventbus.Subscriber) error {
 	if err := bus.SubscribeAsync(registry.AppTopicIdentityRegistration, sender.sendRegistrationEvent); err != nil {
 		return err
 	}
+	if err := bus.SubscribeAsync(p2p.AppTopicTraversal, sender.sendTraversalEvent); err != nil {
+		return err
+	}
 	if err := bus.SubscribeAsync(location.LocUpdateEvent, sender.cacheLocationData); err != nil {
 		return err
 	}
@@ -298,6 +314,20 @@ func (sender *Sender) sendTraceEvent(stage trace.Event) {
 	})
 }
 
+// sendTraversalEvent sends outcome of p2p connection attempt together with NAT types of both peers.
+func (sender *Sender) sendTraversalEvent(e p2p.AppEventTraversal) {
+	sender.sendEvent(traversalEventName, traversalContext{
+		Role:         e.Role,
+		ServiceType:  e.ServiceType,
+		Method:       e.Method,
+		Outcome:      e.Outcome,
+		NATType:      e.NATType,
+		PeerNATType:  e.PeerNATType,
+		DurationMs:   e.Duration.Milliseconds(),
+		ErrorMessage: e.Error,
+	})
+}
+
 // SendNATMappingSuccessEvent sends event about successful NAT mapping
 func (sender *Sender) SendNATMappingSuccessEvent(stage string, gateways []map[string]string) {
 	sender.sendEvent(natMappingEventName, natMappingContext{
@@ -21,6 +21,9 @@ import (
 	"errors"
 	"runtime"
 	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/p2p"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -85,3 +88,30 @@ func TestSender_SendNATMappingFailEvent_SendsToTransport(t *testing.T) {
 	assert.Equal(t, "hole_punching", c.Stage)
 	assert.Equal(t, mockGateways, c.Gateways)
 }
+
+func TestSender_SendTraversalEvent_SendsToTransport(t *testing.T) {
+	mockTransport := buildMockEventsTransport(nil)
+	sender := &Sender{Transport: mockTransport, AppVersion: "test version"}
+
+	sender.sendTraversalEvent(p2p.AppEventTraversal{
+		Role:        p2p.DiagnosticsRoleConsumer,
+		ServiceType: "wireguard",
+		Method:      p2p.TraversalMethodPinger,
+		Outcome:     p2p.TraversalOutcomePunched,
+		NATType:     "symmetric",
+		PeerNATType: "port_restricted",
+		Duration:    1500 * time.Millisecond,
+	})
+
+	sentEvent := mockTransport.sentEvent
+	assert.Equal(t, "p2p_traversal", sentEvent.EventName)
+	assert.Equal(t, traversalContext{
+		Role:        "consumer",
+		ServiceType: "wireguard",
+		Method:      "pinger",
+		Outcome:     "punched",
+		NATType:     "symmetric",
+		PeerNATType: "port_restricted",
+		DurationMs:  1500,
+	}, sentEvent.Context)
+}
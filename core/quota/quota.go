@@ -0,0 +1,243 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package quota
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/eventbus"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+)
+
+const (
+	storageBucket = "provider-traffic-quota"
+	storageKey    = "usage"
+
+	// periodLayout formats period key, quota period is a calendar month in UTC.
+	periodLayout = "2006-01"
+)
+
+// Storage persists traffic usage, so that it survives node restarts.
+type Storage interface {
+	GetValue(bucket string, key interface{}, to interface{}) error
+	SetValue(bucket string, key interface{}, to interface{}) error
+}
+
+// Services pauses and resumes provided services.
+type Services interface {
+	Pause()
+	Resume()
+}
+
+// Sessions lists sessions served by the provider.
+type Sessions interface {
+	GetAll() []*service.Session
+}
+
+// Usage is traffic used by provider during a quota period.
+type Usage struct {
+	Period string
+	Bytes  uint64
+}
+
+// TrafficQuota accounts traffic of all provider sessions and pauses services once monthly quota is used up.
+// Services are resumed when the next period starts.
+type TrafficQuota struct {
+	limit         uint64
+	checkInterval time.Duration
+	storage       Storage
+	services      Services
+	sessions      Sessions
+	now           func() time.Time
+
+	lock         sync.Mutex
+	usage        Usage
+	sessionBytes map[string]uint64
+	exhausted    bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewTrafficQuota creates traffic quota of given size per month.
+func NewTrafficQuota(limit datasize.BitSize, checkInterval time.Duration, storage Storage, services Services, sessions Sessions) *TrafficQuota {
+	return &TrafficQuota{
+		limit:         limit.Bytes(),
+		checkInterval: checkInterval,
+		storage:       storage,
+		services:      services,
+		sessions:      sessions,
+		now:           time.Now,
+		sessionBytes:  make(map[string]uint64),
+		stop:          make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to session traffic and service status events.
+func (q *TrafficQuota) Subscribe(bus eventbus.Subscriber) error {
+	if err := bus.SubscribeAsync(sevent.AppTopicDataTransferred, q.consumeDataTransferredEvent); err != nil {
+		return err
+	}
+	if err := bus.SubscribeAsync(sevent.AppTopicSession, q.consumeSessionEvent); err != nil {
+		return err
+	}
+	return bus.SubscribeAsync(servicestate.AppTopicServiceStatus, q.consumeServiceStatusEvent)
+}
+
+// Start restores usage of the current period and starts watching for the period end, usage is persisted on each check.
+func (q *TrafficQuota) Start() {
+	q.lock.Lock()
+	var usage Usage
+	err := q.storage.GetValue(storageBucket, storageKey, &usage)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Warn().Err(err).Msg("Could not restore traffic quota usage")
+	}
+	q.usage = usage
+	q.lock.Unlock()
+
+	q.check()
+	go q.periodLoop()
+}
+
+// Stop stops watching for the period end and persists the usage.
+func (q *TrafficQuota) Stop() {
+	q.stopOnce.Do(func() {
+		close(q.stop)
+
+		q.lock.Lock()
+		defer q.lock.Unlock()
+		q.persistLocked()
+	})
+}
+
+// Usage returns traffic used during the current period.
+func (q *TrafficQuota) Usage() Usage {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.usage
+}
+
+func (q *TrafficQuota) periodLoop() {
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-time.After(q.checkInterval):
+			q.check()
+
+			q.lock.Lock()
+			q.persistLocked()
+			q.lock.Unlock()
+		}
+	}
+}
+
+func (q *TrafficQuota) consumeDataTransferredEvent(e sevent.AppEventDataTransferred) {
+	q.lock.Lock()
+	// Session reports totals, only the growth since the previous report is new traffic.
+	total := e.Up + e.Down
+	delta := total
+	if last, ok := q.sessionBytes[e.ID]; ok && total >= last {
+		delta = total - last
+	}
+	q.sessionBytes[e.ID] = total
+	q.rollPeriodLocked()
+	q.usage.Bytes += delta
+	q.lock.Unlock()
+
+	q.check()
+}
+
+func (q *TrafficQuota) consumeSessionEvent(e sevent.AppEventSession) {
+	if e.Status != sevent.RemovedStatus {
+		return
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	delete(q.sessionBytes, e.Session.ID)
+}
+
+// consumeServiceStatusEvent pauses services started while quota is used up.
+func (q *TrafficQuota) consumeServiceStatusEvent(e servicestate.AppEventServiceStatus) {
+	if e.Status != string(servicestate.Running) {
+		return
+	}
+
+	q.lock.Lock()
+	exhausted := q.exhausted
+	q.lock.Unlock()
+
+	if exhausted {
+		q.services.Pause()
+	}
+}
+
+// check starts a new period when current one ends and pauses or resumes services accordingly.
+func (q *TrafficQuota) check() {
+	q.lock.Lock()
+	q.rollPeriodLocked()
+
+	wasExhausted := q.exhausted
+	q.exhausted = q.usage.Bytes >= q.limit
+	exhausted := q.exhausted
+	if exhausted && !wasExhausted {
+		q.persistLocked()
+	}
+	q.lock.Unlock()
+
+	switch {
+	case exhausted && !wasExhausted:
+		log.Warn().Msgf("Monthly traffic quota of %s is used up, pausing services", datasize.FromBytes(q.limit))
+		q.services.Pause()
+		for _, session := range q.sessions.GetAll() {
+			go session.Close()
+		}
+	case !exhausted && wasExhausted:
+		log.Info().Msg("Traffic quota period started, resuming services")
+		q.services.Resume()
+	}
+}
+
+// rollPeriodLocked starts a new period with zero usage once current one ends.
+func (q *TrafficQuota) rollPeriodLocked() {
+	period := q.now().UTC().Format(periodLayout)
+	if q.usage.Period == period {
+		return
+	}
+
+	log.Info().Msgf("Starting traffic quota period %s", period)
+	q.usage = Usage{Period: period}
+	q.persistLocked()
+}
+
+func (q *TrafficQuota) persistLocked() {
+	if err := q.storage.SetValue(storageBucket, storageKey, q.usage); err != nil {
+		log.Warn().Err(err).Msg("Could not persist traffic quota usage")
+	}
+}
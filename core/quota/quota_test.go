@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package quota
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/datasize"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+)
+
+func TestTrafficQuota_PausesServicesWhenUsedUp(t *testing.T) {
+	services := &mockServices{}
+	q := newTestQuota(1000, &mockStorage{}, services)
+
+	q.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "1", Up: 100, Down: 200})
+	q.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "1", Up: 300, Down: 400})
+	assert.Equal(t, Usage{Period: "2020-10", Bytes: 700}, q.Usage())
+	assert.Equal(t, 0, services.paused)
+
+	q.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "2", Up: 200, Down: 100})
+	assert.Equal(t, uint64(1000), q.Usage().Bytes)
+	assert.Equal(t, 1, services.paused)
+
+	q.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "2", Up: 300, Down: 100})
+	assert.Equal(t, 1, services.paused)
+}
+
+func TestTrafficQuota_CountsRestartedSessionCounters(t *testing.T) {
+	q := newTestQuota(1000, &mockStorage{}, &mockServices{})
+
+	q.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "1", Up: 100, Down: 100})
+	q.consumeSessionEvent(sevent.AppEventSession{Status: sevent.RemovedStatus, Session: sevent.SessionContext{ID: "1"}})
+	q.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "1", Up: 50, Down: 0})
+
+	assert.Equal(t, uint64(250), q.Usage().Bytes)
+}
+
+func TestTrafficQuota_ResumesServicesInNextPeriod(t *testing.T) {
+	services := &mockServices{}
+	q := newTestQuota(1000, &mockStorage{}, services)
+
+	q.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "1", Up: 1000})
+	assert.Equal(t, 1, services.paused)
+
+	q.now = func() time.Time { return time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC) }
+	q.check()
+
+	assert.Equal(t, Usage{Period: "2020-11"}, q.Usage())
+	assert.Equal(t, 1, services.resumed)
+}
+
+func TestTrafficQuota_RestoresUsageOfCurrentPeriod(t *testing.T) {
+	services := &mockServices{}
+	st := &mockStorage{value: &Usage{Period: "2020-10", Bytes: 2000}}
+	q := newTestQuota(1000, st, services)
+
+	q.Start()
+	defer q.Stop()
+
+	assert.Equal(t, Usage{Period: "2020-10", Bytes: 2000}, q.Usage())
+	assert.Equal(t, 1, services.paused)
+
+	q.consumeServiceStatusEvent(servicestate.AppEventServiceStatus{Status: string(servicestate.Running)})
+	assert.Equal(t, 2, services.paused)
+}
+
+func TestTrafficQuota_DropsUsageOfPreviousPeriod(t *testing.T) {
+	services := &mockServices{}
+	st := &mockStorage{value: &Usage{Period: "2020-09", Bytes: 2000}}
+	q := newTestQuota(1000, st, services)
+
+	q.Start()
+	defer q.Stop()
+
+	assert.Equal(t, Usage{Period: "2020-10"}, q.Usage())
+	assert.Equal(t, 0, services.paused)
+	assert.Equal(t, &Usage{Period: "2020-10"}, st.value)
+}
+
+func newTestQuota(limitBytes uint64, st Storage, services Services) *TrafficQuota {
+	q := NewTrafficQuota(datasize.FromBytes(limitBytes), time.Hour, st, services, &mockSessions{})
+	q.now = func() time.Time { return time.Date(2020, 10, 16, 12, 0, 0, 0, time.UTC) }
+	return q
+}
+
+type mockStorage struct {
+	value *Usage
+}
+
+func (m *mockStorage) GetValue(_ string, _ interface{}, to interface{}) error {
+	if m.value == nil {
+		return storage.ErrNotFound
+	}
+	*to.(*Usage) = *m.value
+	return nil
+}
+
+func (m *mockStorage) SetValue(_ string, _ interface{}, value interface{}) error {
+	usage := value.(Usage)
+	m.value = &usage
+	return nil
+}
+
+type mockServices struct {
+	lock    sync.Mutex
+	paused  int
+	resumed int
+}
+
+func (m *mockServices) Pause() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.paused++
+}
+
+func (m *mockServices) Resume() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.resumed++
+}
+
+type mockSessions struct{}
+
+func (m *mockSessions) GetAll() []*service.Session {
+	return nil
+}
@@ -19,10 +19,14 @@ package service
 
 import (
 	"fmt"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/p2p"
@@ -39,6 +43,10 @@ var (
 	ErrUnsupportedServiceType = errors.New("unsupported service type")
 	// ErrUnsupportedAccessPolicy indicates that manager tried to create service with unsupported access policy
 	ErrUnsupportedAccessPolicy = errors.New("unsupported access policy")
+	// ErrRestartRequired indicates that changed options can not be applied to the running service, it has to be restarted
+	ErrRestartRequired = errors.New("service options can not be changed while service is running")
+	// ErrThrottlingNotSupported indicates that service is not able to limit speed of a single session
+	ErrThrottlingNotSupported = errors.New("session throttling is not supported by the service")
 )
 
 // Service interface represents pluggable Mysterium service
@@ -48,6 +56,22 @@ type Service interface {
 	ConfigProvider
 }
 
+// BackendReporter is implemented by services which run on one of several implementations selected on start.
+type BackendReporter interface {
+	Backend() string
+}
+
+// OptionsReloader is implemented by services able to apply changed options while running, e.g. port range
+// or shaping limits used by new sessions. ErrRestartRequired is returned for options requiring service restart.
+type OptionsReloader interface {
+	ReloadOptions(options Options) error
+}
+
+// SessionThrottler is implemented by services able to limit speed of a single session.
+type SessionThrottler interface {
+	ThrottleSession(sessionID string, bandwidth datasize.BitSpeed) error
+}
+
 // DiscoveryFactory initiates instance which is able announce service discoverability
 type DiscoveryFactory func() Discovery
 
@@ -58,6 +82,16 @@ type Discovery interface {
 	Wait()
 }
 
+// ConsumerACL decides whether consumer is allowed to create sessions.
+type ConsumerACL interface {
+	IsAllowed(consumerID identity.Identity) (bool, error)
+}
+
+// NATTypeDetector tells type of NAT the provider is behind, empty type is returned when it is unknown.
+type NATTypeDetector interface {
+	NATType() string
+}
+
 // WaitForNATHole blocks until NAT hole is punched towards consumer through local NAT or until hole punching failed
 type WaitForNATHole func() error
 
@@ -70,6 +104,9 @@ func NewManager(
 	p2pListener p2p.Listener,
 	sessionManager func(service *Instance, channel p2p.Channel) *SessionManager,
 	statusStorage connectivity.StatusStorage,
+	consumerACL ConsumerACL,
+	drainPeriod time.Duration,
+	natTypeDetector NATTypeDetector,
 ) *Manager {
 	return &Manager{
 		serviceRegistry:  serviceRegistry,
@@ -80,6 +117,10 @@ func NewManager(
 		p2pListener:      p2pListener,
 		sessionManager:   sessionManager,
 		statusStorage:    statusStorage,
+		consumerACL:      consumerACL,
+		drainPeriod:      drainPeriod,
+		natTypeDetector:  natTypeDetector,
+		supervisor:       newSupervisor(),
 	}
 }
 
@@ -95,12 +136,22 @@ type Manager struct {
 	p2pListener    p2p.Listener
 	sessionManager func(service *Instance, channel p2p.Channel) *SessionManager
 	statusStorage  connectivity.StatusStorage
+	consumerACL    ConsumerACL
+	// drainPeriod is how long consumers are given to close their sessions before the service is stopped.
+	drainPeriod     time.Duration
+	natTypeDetector NATTypeDetector
+	supervisor      supervisor
+	// startLock serializes service starts, so that services of the same type get distinct proposal IDs.
+	startLock sync.Mutex
 }
 
 // Start starts an instance of the given service type if knows one in service registry.
 // It passes the options to the start method of the service.
 // If an error occurs in the underlying service, the error is then returned.
 func (manager *Manager) Start(providerID identity.Identity, serviceType string, policyIDs []string, options Options, pm market.PaymentMethod) (id ID, err error) {
+	manager.startLock.Lock()
+	defer manager.startLock.Unlock()
+
 	service, proposal, err := manager.serviceRegistry.Create(serviceType, options)
 	if err != nil {
 		return id, err
@@ -119,6 +170,10 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 	}
 
 	proposal.SetProviderContacts(providerID, market.ContactList{manager.p2pListener.GetContact()})
+	proposal.ID = manager.freeProposalID(providerID, serviceType)
+	if manager.natTypeDetector != nil {
+		proposal.NATType = manager.natTypeDetector.NATType()
+	}
 
 	id, err = generateID()
 	if err != nil {
@@ -144,13 +199,15 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 	channelHandlers := func(ch p2p.Channel) {
 		instance.addP2PChannel(ch)
 		mng := manager.sessionManager(instance, ch)
-		subscribeSessionCreate(mng, ch)
+		subscribeSessionCreate(mng, ch, manager.consumerACL)
 		subscribeSessionStatus(ch, manager.statusStorage)
 		subscribeSessionAcknowledge(mng, ch)
 		subscribeSessionDestroy(mng, ch)
+		subscribeSessionRenegotiate(mng, ch)
 		subscribeSessionPayments(mng, ch)
+		subscribeProbe(ch, func() { instance.closeP2PChannel(ch) })
 	}
-	stopP2PListener, err := manager.p2pListener.Listen(providerID, serviceType, channelHandlers)
+	stopP2PListener, err := manager.p2pListener.Listen(providerID, proposal.ServiceKey(), channelHandlers)
 	if err != nil {
 		return id, fmt.Errorf("could not subscribe to p2p channels: %w", err)
 	}
@@ -160,10 +217,10 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 	go func() {
 		instance.setState(servicestate.Running)
 
-		serveErr := service.Serve(instance)
-		if serveErr != nil {
-			log.Error().Err(serveErr).Msg("Service serve failed")
-		}
+		manager.supervisor.supervise(instance, func() (Service, error) {
+			service, _, err := manager.serviceRegistry.Create(serviceType, instance.CopyOptions())
+			return service, err
+		})
 
 		stopP2PListener()
 
@@ -180,6 +237,22 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 	return id, nil
 }
 
+// freeProposalID returns the lowest proposal ID not used by running services of the provider of the same type.
+func (manager *Manager) freeProposalID(providerID identity.Identity, serviceType string) int {
+	used := make(map[int]bool)
+	for _, instance := range manager.servicePool.List() {
+		if instance.ProviderID == providerID && instance.Type == serviceType {
+			used[instance.CopyProposal().ID] = true
+		}
+	}
+
+	id := market.FirstProposalID
+	for used[id] {
+		id++
+	}
+	return id
+}
+
 func generateID() (ID, error) {
 	uid, err := uuid.NewV4()
 	if err != nil {
@@ -195,11 +268,27 @@ func (manager *Manager) List() map[ID]*Instance {
 
 // Kill stops all services.
 func (manager *Manager) Kill() error {
+	var wg sync.WaitGroup
+	for _, instance := range manager.servicePool.List() {
+		wg.Add(1)
+		go func(instance *Instance) {
+			defer wg.Done()
+			instance.drain(manager.drainPeriod)
+		}(instance)
+	}
+	wg.Wait()
+
 	return manager.servicePool.StopAll()
 }
 
-// Stop stops the service.
+// Stop stops the service once its sessions are drained.
 func (manager *Manager) Stop(id ID) error {
+	instance := manager.servicePool.Instance(id)
+	if instance == nil {
+		return ErrNoSuchInstance
+	}
+	instance.drain(manager.drainPeriod)
+
 	err := manager.servicePool.Stop(id)
 	if err != nil {
 		return err
@@ -208,6 +297,104 @@ func (manager *Manager) Stop(id ID) error {
 	return nil
 }
 
+// Pause stops announcing proposals of running services, paused services reject new sessions.
+func (manager *Manager) Pause() {
+	for _, instance := range manager.servicePool.List() {
+		instance.pause()
+	}
+}
+
+// Resume announces proposals of paused services again.
+func (manager *Manager) Resume() {
+	for _, instance := range manager.servicePool.List() {
+		instance.resume(manager.discoveryFactory)
+	}
+}
+
+// UpdatePaymentMethod changes prices of the running service without restarting it.
+func (manager *Manager) UpdatePaymentMethod(id ID, pm market.PaymentMethod) error {
+	instance := manager.servicePool.Instance(id)
+	if instance == nil {
+		return ErrNoSuchInstance
+	}
+
+	instance.updatePaymentMethod(pm, manager.discoveryFactory)
+	return nil
+}
+
+// Reload changes options and access policies of the running service without restarting it.
+// Options are applied live by services supporting it, proposal is re-registered with the new access policies.
+func (manager *Manager) Reload(id ID, policyIDs []string, options Options) error {
+	instance := manager.servicePool.Instance(id)
+	if instance == nil {
+		return ErrNoSuchInstance
+	}
+
+	policyRules := policy.NewRepository()
+	var accessPolicies *[]market.AccessPolicy
+	if len(policyIDs) > 0 {
+		policies := manager.policyOracle.Policies(policyIDs)
+		if err := manager.policyOracle.SubscribePolicies(policies, policyRules); err != nil {
+			log.Warn().Err(err).Msg("Can't find given access policies")
+			return ErrUnsupportedAccessPolicy
+		}
+		accessPolicies = &policies
+	}
+
+	if !reflect.DeepEqual(instance.CopyOptions(), options) {
+		reloader, ok := instance.Service().(OptionsReloader)
+		if !ok {
+			manager.policyOracle.UnsubscribePolicies(policyRules)
+			return ErrRestartRequired
+		}
+		if err := reloader.ReloadOptions(options); err != nil {
+			manager.policyOracle.UnsubscribePolicies(policyRules)
+			return err
+		}
+	}
+
+	replaced := instance.reload(options, policyRules, accessPolicies, manager.discoveryFactory)
+	manager.policyOracle.UnsubscribePolicies(replaced)
+	return nil
+}
+
+// UpdateMeasuredBandwidth announces bandwidth of the provider measured by speed test in the service proposal.
+func (manager *Manager) UpdateMeasuredBandwidth(id ID, bandwidth datasize.BitSpeed) error {
+	instance := manager.servicePool.Instance(id)
+	if instance == nil {
+		return ErrNoSuchInstance
+	}
+
+	instance.updateProposal(func(proposal *market.ServiceProposal) {
+		proposal.MeasuredBandwidth = bandwidth
+	}, manager.discoveryFactory)
+	return nil
+}
+
+// TerminateSession ends a single session of the running service without restarting it.
+func (manager *Manager) TerminateSession(id ID, sessionID string) error {
+	instance := manager.servicePool.Instance(id)
+	if instance == nil {
+		return ErrNoSuchInstance
+	}
+
+	return instance.closeSession(sessionID)
+}
+
+// ThrottleSession limits speed of a single session of the running service.
+func (manager *Manager) ThrottleSession(id ID, sessionID string, bandwidth datasize.BitSpeed) error {
+	instance := manager.servicePool.Instance(id)
+	if instance == nil {
+		return ErrNoSuchInstance
+	}
+
+	throttler, ok := instance.Service().(SessionThrottler)
+	if !ok {
+		return ErrThrottlingNotSupported
+	}
+	return throttler.ThrottleSession(sessionID, bandwidth)
+}
+
 // Service returns a service instance by requested id.
 func (manager *Manager) Service(id ID) *Instance {
 	return manager.servicePool.Instance(id)
@@ -24,6 +24,7 @@ import (
 
 	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/mocks"
@@ -35,7 +36,7 @@ import (
 
 var (
 	serviceType      = "the-very-awesome-test-service-type"
-	mockPolicyOracle = policy.NewOracle(requests.NewHTTPClient("0.0.0.0", requests.DefaultTimeout), "http://policy.localhost/", 1*time.Minute)
+	mockPolicyOracle = policy.NewOracle(requests.NewHTTPClient("0.0.0.0", requests.DefaultTimeout), "http://policy.localhost/", 1*time.Minute, nil, 0)
 )
 
 func init() {
@@ -57,8 +58,9 @@ func TestManager_StartRemovesServiceFromPoolIfServiceCrashes(t *testing.T) {
 		discoveryFactory,
 		mocks.NewEventBus(),
 		mockPolicyOracle,
-		&mockP2PListener{}, nil, nil,
+		&mockP2PListener{}, nil, nil, nil, 0, nil,
 	)
+	manager.supervisor = supervisor{initialBackoff: time.Millisecond, maxBackoff: time.Millisecond, maxRestarts: 2, healthyAfter: time.Minute}
 	_, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
 	assert.Nil(t, err)
 
@@ -66,6 +68,51 @@ func TestManager_StartRemovesServiceFromPoolIfServiceCrashes(t *testing.T) {
 	assert.Len(t, manager.servicePool.List(), 0)
 }
 
+func TestManager_StartRestartsCrashedService(t *testing.T) {
+	registry := NewRegistry()
+	var created int
+	registry.Register(serviceType, func(options Options) (Service, market.ServiceProposal, error) {
+		created++
+		mockCopy := *serviceMock
+		if created == 1 {
+			mockCopy.onStartReturnError = errors.New("some error")
+		} else {
+			mockCopy.mockProcess = make(chan struct{})
+		}
+		return &mockCopy, proposalMock, nil
+	})
+
+	discovery := mockDiscovery{}
+	eventBus := mocks.NewEventBus()
+	manager := NewManager(
+		registry,
+		MockDiscoveryFactoryFunc(&discovery),
+		eventBus,
+		mockPolicyOracle,
+		&mockP2PListener{}, nil, nil, nil, 0, nil,
+	)
+	manager.supervisor = supervisor{initialBackoff: time.Millisecond, maxBackoff: time.Millisecond, maxRestarts: 2, healthyAfter: time.Minute}
+	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
+	assert.NoError(t, err)
+
+	instance := manager.Service(id)
+	assert.Eventually(t, func() bool {
+		return instance.State() == servicestate.Running && instance.Service().(*serviceFake).mockProcess != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Len(t, manager.servicePool.List(), 1)
+
+	var statuses []string
+	for _, e := range eventBus.GetEventHistory() {
+		if status, ok := e.Event.(servicestate.AppEventServiceStatus); ok {
+			statuses = append(statuses, status.Status)
+		}
+	}
+	assert.Equal(t, []string{"Running", "Starting", "Running"}, statuses)
+
+	assert.NoError(t, manager.Stop(id))
+	discovery.Wait()
+}
+
 func TestManager_StartDoesNotCrashIfStoppedByUser(t *testing.T) {
 	registry := NewRegistry()
 	mockCopy := *serviceMock
@@ -81,7 +128,7 @@ func TestManager_StartDoesNotCrashIfStoppedByUser(t *testing.T) {
 		discoveryFactory,
 		mocks.NewEventBus(),
 		mockPolicyOracle,
-		&mockP2PListener{}, nil, nil,
+		&mockP2PListener{}, nil, nil, nil, 0, nil,
 	)
 	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
 	assert.Nil(t, err)
@@ -107,7 +154,7 @@ func TestManager_StopSendsEvent_SucceedsAndPublishesEvent(t *testing.T) {
 		discoveryFactory,
 		eventBus,
 		mockPolicyOracle,
-		&mockP2PListener{}, nil, nil,
+		&mockP2PListener{}, nil, nil, nil, 0, nil,
 	)
 
 	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
@@ -145,6 +192,150 @@ func TestManager_StopSendsEvent_SucceedsAndPublishesEvent(t *testing.T) {
 	assert.True(t, matchFound)
 }
 
+func TestManager_PauseAndResume(t *testing.T) {
+	registry := NewRegistry()
+	mockCopy := *serviceMock
+	mockCopy.mockProcess = make(chan struct{})
+	registry.Register(serviceType, func(options Options) (Service, market.ServiceProposal, error) {
+		return &mockCopy, proposalMock, nil
+	})
+
+	discovery := mockDiscovery{}
+	manager := NewManager(
+		registry,
+		MockDiscoveryFactoryFunc(&discovery),
+		mocks.NewEventBus(),
+		mockPolicyOracle,
+		&mockP2PListener{}, nil, nil, nil, 0, nil,
+	)
+	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
+	assert.NoError(t, err)
+	instance := manager.Service(id)
+	assert.Eventually(t, func() bool {
+		return instance.State() == servicestate.Running
+	}, 2*time.Second, 10*time.Millisecond)
+
+	manager.Pause()
+	assert.Equal(t, servicestate.Paused, instance.State())
+
+	manager.Resume()
+	assert.Equal(t, servicestate.Running, instance.State())
+
+	assert.NoError(t, manager.Stop(id))
+	discovery.Wait()
+}
+
+func TestManager_UpdatePaymentMethod(t *testing.T) {
+	registry := NewRegistry()
+	mockCopy := *serviceMock
+	mockCopy.mockProcess = make(chan struct{})
+	registry.Register(serviceType, func(options Options) (Service, market.ServiceProposal, error) {
+		return &mockCopy, proposalMock, nil
+	})
+
+	discovery := mockDiscovery{}
+	manager := NewManager(
+		registry,
+		MockDiscoveryFactoryFunc(&discovery),
+		mocks.NewEventBus(),
+		mockPolicyOracle,
+		&mockP2PListener{}, nil, nil, nil, 0, nil,
+	)
+	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
+	assert.NoError(t, err)
+	instance := manager.Service(id)
+	assert.Eventually(t, func() bool {
+		return instance.State() == servicestate.Running
+	}, 2*time.Second, 10*time.Millisecond)
+
+	pm := mocks.DefaultPaymentMethod()
+	assert.NoError(t, manager.UpdatePaymentMethod(id, pm))
+	assert.Equal(t, pm, instance.CopyProposal().PaymentMethod)
+	assert.Equal(t, servicestate.Running, instance.State())
+
+	assert.Equal(t, ErrNoSuchInstance, manager.UpdatePaymentMethod("unknown", pm))
+	assert.Equal(t, ErrNoSuchInstance, manager.TerminateSession("unknown", "session"))
+	assert.Equal(t, ErrorSessionNotExists, manager.TerminateSession(id, "session"))
+	assert.Equal(t, ErrNoSuchInstance, manager.ThrottleSession("unknown", "session", datasize.BitSpeed(datasize.MiB)))
+	assert.Equal(t, ErrThrottlingNotSupported, manager.ThrottleSession(id, "session", datasize.BitSpeed(datasize.MiB)))
+
+	assert.NoError(t, manager.Stop(id))
+	discovery.Wait()
+}
+
+func TestManager_Reload(t *testing.T) {
+	registry := NewRegistry()
+	mockCopy := *serviceMock
+	mockCopy.mockProcess = make(chan struct{})
+	registry.Register(serviceType, func(options Options) (Service, market.ServiceProposal, error) {
+		return &mockCopy, proposalMock, nil
+	})
+
+	discovery := mockDiscovery{}
+	manager := NewManager(
+		registry,
+		MockDiscoveryFactoryFunc(&discovery),
+		mocks.NewEventBus(),
+		mockPolicyOracle,
+		&mockP2PListener{}, nil, nil, nil, 0, nil,
+	)
+	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
+	assert.NoError(t, err)
+	instance := manager.Service(id)
+	assert.Eventually(t, func() bool {
+		return instance.State() == servicestate.Running
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, manager.Reload(id, nil, struct{}{}))
+	assert.Nil(t, instance.CopyProposal().AccessPolicies)
+	assert.Equal(t, servicestate.Running, instance.State())
+
+	assert.Equal(t, ErrRestartRequired, manager.Reload(id, nil, "changed"))
+	assert.Equal(t, struct{}{}, instance.CopyOptions())
+	assert.Equal(t, ErrNoSuchInstance, manager.Reload("unknown", nil, struct{}{}))
+
+	assert.NoError(t, manager.Stop(id))
+	discovery.Wait()
+}
+
+func TestManager_StartAssignsDistinctProposalIDsToServicesOfTheSameType(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(serviceType, func(options Options) (Service, market.ServiceProposal, error) {
+		mockCopy := *serviceMock
+		mockCopy.mockProcess = make(chan struct{})
+		return &mockCopy, market.ServiceProposal{ServiceType: serviceType}, nil
+	})
+
+	discovery := mockDiscovery{}
+	manager := NewManager(
+		registry,
+		MockDiscoveryFactoryFunc(&discovery),
+		mocks.NewEventBus(),
+		mockPolicyOracle,
+		&mockP2PListener{}, nil, nil, nil, 0, nil,
+	)
+	providerID := identity.FromAddress(proposalMock.ProviderID)
+	id1, err := manager.Start(providerID, serviceType, nil, struct{}{}, nil)
+	assert.NoError(t, err)
+	id2, err := manager.Start(providerID, serviceType, nil, struct{}{}, nil)
+	assert.NoError(t, err)
+
+	proposal1 := manager.Service(id1).CopyProposal()
+	proposal2 := manager.Service(id2).CopyProposal()
+	assert.Equal(t, 1, proposal1.ID)
+	assert.Equal(t, 2, proposal2.ID)
+	assert.Equal(t, serviceType, proposal1.ServiceKey())
+	assert.Equal(t, serviceType+"-2", proposal2.ServiceKey())
+
+	assert.NoError(t, manager.Stop(id1))
+	id3, err := manager.Start(providerID, serviceType, nil, struct{}{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, manager.Service(id3).CopyProposal().ID)
+
+	assert.NoError(t, manager.Stop(id2))
+	assert.NoError(t, manager.Stop(id3))
+}
+
 type mockP2PListener struct {
 }
 
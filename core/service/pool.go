@@ -18,15 +18,20 @@
 package service
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/service/servicestate"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/logconfig"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/p2p"
+	"github.com/mysteriumnetwork/node/pb"
 	"github.com/mysteriumnetwork/node/utils"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -155,18 +160,40 @@ type Instance struct {
 	eventPublisher  Publisher
 	p2pChannelsLock sync.Mutex
 	p2pChannels     []p2p.Channel
+	sessionsLock    sync.Mutex
+	sessions        map[*Session]sessionChannel
+	stopped         chan struct{}
 }
 
 // Service returns the running service implementation.
 func (i *Instance) Service() Service {
+	i.stateLock.RLock()
+	defer i.stateLock.RUnlock()
 	return i.service
 }
 
+// Backend returns implementation the running service uses, empty for services having a single implementation.
+func (i *Instance) Backend() string {
+	if reporter, ok := i.Service().(BackendReporter); ok {
+		return reporter.Backend()
+	}
+	return ""
+}
+
 // Policies returns service policies of the running service instance.
 func (i *Instance) Policies() *policy.Repository {
+	i.stateLock.RLock()
+	defer i.stateLock.RUnlock()
 	return i.policies
 }
 
+// CopyOptions returns options the service instance currently runs with.
+func (i *Instance) CopyOptions() Options {
+	i.stateLock.RLock()
+	defer i.stateLock.RUnlock()
+	return i.Options
+}
+
 // State returns the service instance state.
 func (i *Instance) State() servicestate.State {
 	i.stateLock.RLock()
@@ -174,6 +201,18 @@ func (i *Instance) State() servicestate.State {
 	return i.state
 }
 
+// CopyProposal returns the proposal currently announced by the service instance.
+func (i *Instance) CopyProposal() market.ServiceProposal {
+	i.stateLock.RLock()
+	defer i.stateLock.RUnlock()
+	return i.Proposal
+}
+
+// logger returns logger tagging records with the service instance ID.
+func (i *Instance) logger() *zerolog.Logger {
+	return logconfig.ServiceLogger(string(i.ID))
+}
+
 func (i *Instance) setState(newState servicestate.State) {
 	i.stateLock.Lock()
 	defer i.stateLock.Unlock()
@@ -182,6 +221,75 @@ func (i *Instance) setState(newState servicestate.State) {
 	i.eventPublisher.Publish(servicestate.AppTopicServiceStatus, i.toEvent())
 }
 
+// pause stops announcing the service proposal, established sessions are kept.
+func (i *Instance) pause() {
+	i.stateLock.Lock()
+	defer i.stateLock.Unlock()
+
+	if i.state != servicestate.Running {
+		return
+	}
+	if i.discovery != nil {
+		i.discovery.Stop()
+	}
+	i.state = servicestate.Paused
+	i.eventPublisher.Publish(servicestate.AppTopicServiceStatus, i.toEvent())
+}
+
+// resume announces the service proposal again using a new discovery, since stopped one can not be restarted.
+func (i *Instance) resume(discoveryFactory DiscoveryFactory) {
+	i.stateLock.Lock()
+	defer i.stateLock.Unlock()
+
+	if i.state != servicestate.Paused {
+		return
+	}
+	i.discovery = discoveryFactory()
+	i.discovery.Start(i.ProviderID, i.Proposal)
+	i.state = servicestate.Running
+	i.eventPublisher.Publish(servicestate.AppTopicServiceStatus, i.toEvent())
+}
+
+// updatePaymentMethod changes payment terms of the proposal and re-registers it using a new discovery.
+// Established sessions keep payment terms they were started with.
+func (i *Instance) updatePaymentMethod(pm market.PaymentMethod, discoveryFactory DiscoveryFactory) {
+	i.updateProposal(func(proposal *market.ServiceProposal) {
+		proposal.SetPaymentMethod(pm)
+	}, discoveryFactory)
+}
+
+// reload replaces options and access policies of the running service and re-registers its proposal.
+// Replaced policy repository is returned, so that it can be unsubscribed from policy updates.
+func (i *Instance) reload(options Options, policies *policy.Repository, accessPolicies *[]market.AccessPolicy, discoveryFactory DiscoveryFactory) *policy.Repository {
+	i.stateLock.Lock()
+	replaced := i.policies
+	i.Options = options
+	i.policies = policies
+	i.stateLock.Unlock()
+
+	i.updateProposal(func(proposal *market.ServiceProposal) {
+		proposal.SetAccessPolicies(accessPolicies)
+	}, discoveryFactory)
+	return replaced
+}
+
+// updateProposal changes the proposal and re-registers it using a new discovery.
+func (i *Instance) updateProposal(update func(proposal *market.ServiceProposal), discoveryFactory DiscoveryFactory) {
+	i.stateLock.Lock()
+	defer i.stateLock.Unlock()
+
+	update(&i.Proposal)
+	// Paused service announces updated proposal once resumed.
+	if i.discovery == nil || i.state == servicestate.Paused || i.state == servicestate.NotRunning {
+		return
+	}
+	// Old proposal has to be unregistered before the new one is announced.
+	i.discovery.Stop()
+	i.discovery.Wait()
+	i.discovery = discoveryFactory()
+	i.discovery.Start(i.ProviderID, i.Proposal)
+}
+
 func (i *Instance) addP2PChannel(ch p2p.Channel) {
 	i.p2pChannelsLock.Lock()
 	defer i.p2pChannelsLock.Unlock()
@@ -205,13 +313,174 @@ func (i *Instance) closeP2PChannel(ch p2p.Channel) {
 	}
 }
 
+// sessionChannel is the p2p channel consumer of the session is reachable through.
+type sessionChannel interface {
+	p2p.ChannelSender
+	Capabilities() p2p.Capabilities
+}
+
+// addSession tracks established session together with the channel its consumer is reachable through.
+func (i *Instance) addSession(session *Session, ch sessionChannel) {
+	i.sessionsLock.Lock()
+	defer i.sessionsLock.Unlock()
+
+	if i.sessions == nil {
+		i.sessions = make(map[*Session]sessionChannel)
+	}
+	i.sessions[session] = ch
+}
+
+func (i *Instance) removeSession(session *Session) {
+	i.sessionsLock.Lock()
+	defer i.sessionsLock.Unlock()
+
+	delete(i.sessions, session)
+}
+
+// closeSession ends the established session with the given ID, other sessions of the service are kept.
+func (i *Instance) closeSession(sessionID string) error {
+	var found *Session
+	i.sessionsLock.Lock()
+	for session := range i.sessions {
+		if string(session.ID) == sessionID {
+			found = session
+			break
+		}
+	}
+	i.sessionsLock.Unlock()
+	if found == nil {
+		return ErrorSessionNotExists
+	}
+
+	i.logger().Info().Msgf("Terminating session %s of service %s", sessionID, i.ID)
+	found.Close()
+	return nil
+}
+
+// drain stops accepting new sessions, notifies consumers of established sessions that the service is stopping
+// and waits for them to close their sessions, settling final payments, until drain period expires.
+func (i *Instance) drain(period time.Duration) {
+	if period <= 0 {
+		return
+	}
+	i.pause()
+
+	i.sessionsLock.Lock()
+	sessions := make(map[*Session]sessionChannel, len(i.sessions))
+	for session, ch := range i.sessions {
+		sessions[session] = ch
+	}
+	i.sessionsLock.Unlock()
+	if len(sessions) == 0 {
+		return
+	}
+
+	i.logger().Info().Msgf("Draining %d sessions of service %s", len(sessions), i.ID)
+	ctx, cancel := context.WithTimeout(context.Background(), period)
+	defer cancel()
+	for session, ch := range sessions {
+		go notifyShutdown(ctx, ch, session)
+	}
+	for session := range sessions {
+		select {
+		case <-session.Done():
+		case <-ctx.Done():
+			i.logger().Warn().Msgf("Drain period of service %s expired, stopping remaining sessions", i.ID)
+			return
+		}
+	}
+}
+
+func notifyShutdown(ctx context.Context, ch sessionChannel, session *Session) {
+	if !ch.Capabilities().Has(p2p.CapabilitySessionShutdown) {
+		session.logger().Debug().Msgf("Consumer does not support service shutdown notice. SessionID=%s", session.ID)
+		return
+	}
+
+	msg := &pb.SessionInfo{
+		ConsumerID: session.ConsumerID.Address,
+		SessionID:  string(session.ID),
+	}
+	log.Debug().Msgf("Sending P2P message to %q: %s", p2p.TopicSessionShutdown, msg.String())
+	if _, err := ch.Send(ctx, p2p.TopicSessionShutdown, p2p.ProtoMessage(msg)); err != nil {
+		session.logger().Warn().Err(err).Msgf("Could not notify consumer about service shutdown. SessionID=%s", session.ID)
+	}
+}
+
+// waitRestart marks failed service as starting unless it is paused and waits for the given delay.
+// Returns false if the instance was stopped meanwhile.
+func (i *Instance) waitRestart(delay time.Duration) bool {
+	i.stateLock.Lock()
+	if i.state != servicestate.Paused {
+		i.state = servicestate.Starting
+		i.eventPublisher.Publish(servicestate.AppTopicServiceStatus, i.toEvent())
+	}
+	stopped := i.stopChannel()
+	i.stateLock.Unlock()
+
+	select {
+	case <-stopped:
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+// replaceService swaps failed service with the restarted one, unless the instance was stopped meanwhile.
+func (i *Instance) replaceService(service Service) bool {
+	i.stateLock.Lock()
+	defer i.stateLock.Unlock()
+
+	if i.isStoppedLocked() {
+		return false
+	}
+	i.service = service
+	if i.state != servicestate.Paused {
+		i.state = servicestate.Running
+		i.eventPublisher.Publish(servicestate.AppTopicServiceStatus, i.toEvent())
+	}
+	return true
+}
+
+func (i *Instance) isStopped() bool {
+	i.stateLock.Lock()
+	defer i.stateLock.Unlock()
+	return i.isStoppedLocked()
+}
+
+func (i *Instance) isStoppedLocked() bool {
+	select {
+	case <-i.stopChannel():
+		return true
+	default:
+		return false
+	}
+}
+
+// stopChannel returns channel closed once the instance is stopped, state lock has to be held.
+func (i *Instance) stopChannel() chan struct{} {
+	if i.stopped == nil {
+		i.stopped = make(chan struct{})
+	}
+	return i.stopped
+}
+
 func (i *Instance) stop() error {
 	errStop := utils.ErrorCollection{}
-	if i.discovery != nil {
-		i.discovery.Stop()
+	i.stateLock.Lock()
+	if !i.isStoppedLocked() {
+		close(i.stopChannel())
+	}
+	paused := i.state == servicestate.Paused
+	discovery := i.discovery
+	service := i.service
+	i.stateLock.Unlock()
+	// Discovery of paused service is already stopped.
+	if discovery != nil && !paused {
+		discovery.Stop()
 	}
-	if i.service != nil {
-		errStop.Add(i.service.Stop())
+	if service != nil {
+		errStop.Add(service.Stop())
 	}
 
 	i.p2pChannelsLock.Lock()
@@ -18,13 +18,19 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
 	"github.com/mysteriumnetwork/node/mocks"
+	"github.com/mysteriumnetwork/node/p2p"
+	"github.com/mysteriumnetwork/node/pb"
+	"github.com/mysteriumnetwork/node/trace"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -111,3 +117,109 @@ func Test_Pool_StopAllDoesNotStopOneInstance(t *testing.T) {
 	err := pool.StopAll()
 	assert.EqualError(t, err, "Some instances did not stop: ErrorCollection(I dont want to stop)")
 }
+
+type mockShutdownSender struct {
+	lock         sync.Mutex
+	topics       []string
+	onSend       func()
+	capabilities []string
+}
+
+func newMockShutdownSender() *mockShutdownSender {
+	return &mockShutdownSender{capabilities: []string{p2p.CapabilitySessionShutdown}}
+}
+
+func (m *mockShutdownSender) Capabilities() p2p.Capabilities {
+	return p2p.Capabilities{Version: p2p.ProtocolVersion, Names: m.capabilities}
+}
+
+func (m *mockShutdownSender) Send(_ context.Context, topic string, _ *p2p.Message) (*p2p.Message, error) {
+	m.lock.Lock()
+	m.topics = append(m.topics, topic)
+	m.lock.Unlock()
+	if m.onSend != nil {
+		m.onSend()
+	}
+	return nil, nil
+}
+
+func Test_Instance_DrainWaitsForNotifiedSessionsToClose(t *testing.T) {
+	instance := &Instance{ID: "test id", state: servicestate.Running, eventPublisher: mocks.NewEventBus()}
+	session, _ := NewSession(instance, &pb.SessionRequest{}, trace.NewTracer(""))
+	sender := newMockShutdownSender()
+	sender.onSend = session.Close
+	instance.addSession(session, sender)
+
+	start := time.Now()
+	instance.drain(time.Minute)
+
+	assert.True(t, time.Since(start) < time.Minute)
+	assert.Equal(t, servicestate.Paused, instance.State())
+	assert.Equal(t, []string{p2p.TopicSessionShutdown}, sender.topics)
+}
+
+func Test_Instance_DrainSkipsNoticeToConsumerWithoutCapability(t *testing.T) {
+	instance := &Instance{ID: "test id", state: servicestate.Running, eventPublisher: mocks.NewEventBus()}
+	session, _ := NewSession(instance, &pb.SessionRequest{}, trace.NewTracer(""))
+	sender := &mockShutdownSender{}
+	instance.addSession(session, sender)
+
+	instance.drain(50 * time.Millisecond)
+
+	assert.Empty(t, sender.topics)
+}
+
+func Test_Instance_DrainGivesUpAfterDrainPeriod(t *testing.T) {
+	instance := &Instance{ID: "test id", state: servicestate.Running, eventPublisher: mocks.NewEventBus()}
+	session, _ := NewSession(instance, &pb.SessionRequest{}, trace.NewTracer(""))
+	instance.addSession(session, newMockShutdownSender())
+
+	start := time.Now()
+	instance.drain(50 * time.Millisecond)
+
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	select {
+	case <-session.Done():
+		assert.Fail(t, "session should not be closed by drain")
+	default:
+	}
+}
+
+func Test_Instance_CloseSession(t *testing.T) {
+	instance := &Instance{ID: "test id", state: servicestate.Running, eventPublisher: mocks.NewEventBus()}
+	kicked, _ := NewSession(instance, &pb.SessionRequest{}, trace.NewTracer(""))
+	kept, _ := NewSession(instance, &pb.SessionRequest{}, trace.NewTracer(""))
+	instance.addSession(kicked, newMockShutdownSender())
+	instance.addSession(kept, newMockShutdownSender())
+
+	assert.Equal(t, ErrorSessionNotExists, instance.closeSession("unknown"))
+	assert.NoError(t, instance.closeSession(string(kicked.ID)))
+
+	select {
+	case <-kicked.Done():
+	default:
+		assert.Fail(t, "terminated session should be closed")
+	}
+	select {
+	case <-kept.Done():
+		assert.Fail(t, "other sessions should be kept")
+	default:
+	}
+}
+
+type mockBackendService struct {
+	mockService
+	backend string
+}
+
+func (mbs *mockBackendService) Backend() string {
+	return mbs.backend
+}
+
+func Test_Instance_Backend(t *testing.T) {
+	instance := &Instance{service: &mockService{}}
+	assert.Equal(t, "", instance.Backend())
+
+	instance = &Instance{service: &mockBackendService{backend: "kernel"}}
+	assert.Equal(t, "kernel", instance.Backend())
+}
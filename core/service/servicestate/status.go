@@ -40,4 +40,6 @@ const (
 	Starting = State("Starting")
 	// Running means that fully established service exists
 	Running = State("Running")
+	// Paused means that service is not announced and does not accept new sessions
+	Paused = State("Paused")
 )
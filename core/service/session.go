@@ -24,12 +24,13 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gofrs/uuid"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/logconfig"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/pb"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/event"
 	"github.com/mysteriumnetwork/node/trace"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 )
 
 // Session structure holds all required information about current session between service consumer and provider.
@@ -56,10 +57,10 @@ func (s *Session) Close() {
 	defer s.cleanupLock.Unlock()
 
 	for i := len(s.cleanup) - 1; i >= 0; i-- {
-		log.Trace().Msgf("Session cleaning up: (%v/%v)", i+1, len(s.cleanup))
+		s.logger().Trace().Msgf("Session cleaning up: (%v/%v)", i+1, len(s.cleanup))
 		err := s.cleanup[i]()
 		if err != nil {
-			log.Warn().Err(err).Msg("Cleanup error")
+			s.logger().Warn().Err(err).Msg("Cleanup error")
 		}
 	}
 	s.cleanup = nil
@@ -70,6 +71,11 @@ func (s *Session) Done() <-chan struct{} {
 	return s.done
 }
 
+// logger returns logger tagging records with the service instance and session IDs.
+func (s *Session) logger() *zerolog.Logger {
+	return logconfig.SessionLogger(s.ServiceID, string(s.ID))
+}
+
 func (s *Session) addCleanup(fn func() error) {
 	s.cleanupLock.Lock()
 	defer s.cleanupLock.Unlock()
@@ -111,7 +117,7 @@ func NewSession(service *Instance, request *pb.SessionRequest, tracer *trace.Tra
 		ConsumerID:       identity.FromAddress(request.GetConsumer().GetId()),
 		ConsumerLocation: consumerLocation,
 		HermesID:         common.HexToAddress(request.GetConsumer().GetHermesID()),
-		Proposal:         service.Proposal,
+		Proposal:         service.CopyProposal(),
 		ServiceID:        string(service.ID),
 		CreatedAt:        time.Now().UTC(),
 		request:          request,
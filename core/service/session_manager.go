@@ -25,7 +25,9 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/logconfig"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/nat/event"
 	"github.com/mysteriumnetwork/node/p2p"
@@ -34,7 +36,7 @@ import (
 	sevent "github.com/mysteriumnetwork/node/session/event"
 	"github.com/mysteriumnetwork/payments/crypto"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 )
 
 var (
@@ -44,6 +46,14 @@ var (
 	ErrorSessionNotExists = errors.New("session does not exists")
 	// ErrorWrongSessionOwner returned when consumer tries to destroy session that does not belongs to him
 	ErrorWrongSessionOwner = errors.New("wrong session owner")
+	// ErrorMaxSessionsReached returned when service already serves maximum number of concurrent sessions
+	ErrorMaxSessionsReached = errors.New("maximum number of sessions reached")
+	// ErrorMaxConsumerSessionsReached returned when consumer already has maximum number of concurrent sessions with the provider
+	ErrorMaxConsumerSessionsReached = errors.New("maximum number of consumer sessions reached")
+	// ErrorServicePaused returned when paused service is asked for a new session
+	ErrorServicePaused = errors.New("service is paused")
+	// ErrorRenegotiationNotSupported returned when consumer asks to rotate keys of the service which can not do it
+	ErrorRenegotiationNotSupported = errors.New("session renegotiation is not supported by the service")
 )
 
 // IDGenerator defines method for session id generation
@@ -73,6 +83,10 @@ type KeepAliveConfig struct {
 // Config contains common configuration options for session manager.
 type Config struct {
 	KeepAlive KeepAliveConfig
+	// MaxSessions limits concurrent sessions of the service, 0 means unlimited.
+	MaxSessions int
+	// MaxConsumerSessions limits concurrent sessions of a single consumer across all services, 0 means unlimited.
+	MaxConsumerSessions int
 }
 
 // DefaultConfig returns default params.
@@ -83,6 +97,7 @@ func DefaultConfig() Config {
 			SendTimeout:     5 * time.Second,
 			MaxSendErrCount: 5,
 		},
+		MaxConsumerSessions: 2,
 	}
 }
 
@@ -91,6 +106,12 @@ type ConfigProvider interface {
 	ProvideConfig(sessionID string, sessionConfig json.RawMessage, conn *net.UDPConn) (*ConfigParams, error)
 }
 
+// ConfigRenegotiator is implemented by services able to rotate tunnel keys of the running session,
+// new consumer config is exchanged for the new service config without tearing down the session.
+type ConfigRenegotiator interface {
+	RenegotiateConfig(sessionID string, sessionConfig json.RawMessage) (ServiceConfiguration, error)
+}
+
 // DestroyCallback cleanups session
 type DestroyCallback func()
 
@@ -160,7 +181,7 @@ func (manager *SessionManager) Start(request *pb.SessionRequest) (_ pb.SessionRe
 	}
 	defer func() {
 		if err != nil {
-			log.Err(err).Msg("Session failed, disconnecting")
+			session.logger().Err(err).Msg("Session failed, disconnecting")
 			session.Close()
 		}
 	}()
@@ -169,7 +190,7 @@ func (manager *SessionManager) Start(request *pb.SessionRequest) (_ pb.SessionRe
 	defer func() {
 		session.tracer.EndStage(trace)
 		traceResult := session.tracer.Finish(manager.publisher, string(session.ID))
-		log.Debug().Msgf("Provider connection trace: %s", traceResult)
+		session.logger().Debug().Msgf("Provider connection trace: %s", traceResult)
 	}()
 
 	if err = manager.startSession(session); err != nil {
@@ -203,11 +224,19 @@ func (manager *SessionManager) startSession(session *Session) error {
 	if err := manager.validateSession(session); err != nil {
 		return err
 	}
+	if err := manager.checkCapacity(session); err != nil {
+		return err
+	}
+	if err := manager.checkConsumerSessions(session); err != nil {
+		return err
+	}
 
-	manager.clearStaleSession(session.ConsumerID, manager.service.Type)
+	manager.clearStaleSession(session.ConsumerID, manager.service.Proposal.ServiceKey())
 
 	manager.sessionStorage.Add(session)
+	manager.service.addSession(session, manager.channel)
 	session.addCleanup(func() error {
+		manager.service.removeSession(session)
 		manager.sessionStorage.Remove(session.ID)
 		return nil
 	})
@@ -222,6 +251,10 @@ func (manager *SessionManager) validateSession(session *Session) error {
 		return ErrorInvalidProposal
 	}
 
+	if manager.service.State() == servicestate.Paused {
+		return ErrorServicePaused
+	}
+
 	if !manager.service.Policies().IsIdentityAllowed(session.ConsumerID) {
 		return fmt.Errorf("consumer identity is not allowed: %s", session.ConsumerID.Address)
 	}
@@ -229,17 +262,62 @@ func (manager *SessionManager) validateSession(session *Session) error {
 	return nil
 }
 
-func (manager *SessionManager) clearStaleSession(consumerID identity.Identity, serviceType string) {
+// checkCapacity rejects the session if service is full. Stale sessions of the same consumer
+// are not counted, since they are replaced by the new one.
+func (manager *SessionManager) checkCapacity(session *Session) error {
+	if manager.config.MaxSessions <= 0 {
+		return nil
+	}
+
+	active := 0
+	for _, s := range manager.sessionStorage.GetAll() {
+		if s.ServiceID != session.ServiceID || s.ConsumerID == session.ConsumerID {
+			continue
+		}
+		active++
+	}
+	if active >= manager.config.MaxSessions {
+		return ErrorMaxSessionsReached
+	}
+
+	return nil
+}
+
+// checkConsumerSessions rejects the session if consumer already has too many sessions with the provider,
+// so that a single consumer does not take up the whole capacity. Stale sessions of the same service type
+// are not counted, since they are replaced by the new one.
+func (manager *SessionManager) checkConsumerSessions(session *Session) error {
+	if manager.config.MaxConsumerSessions <= 0 {
+		return nil
+	}
+
+	active := 0
+	for _, s := range manager.sessionStorage.GetAll() {
+		if s.ConsumerID != session.ConsumerID || s.Proposal.ServiceType == session.Proposal.ServiceType {
+			continue
+		}
+		active++
+	}
+	if active >= manager.config.MaxConsumerSessions {
+		return ErrorMaxConsumerSessionsReached
+	}
+
+	return nil
+}
+
+// clearStaleSession closes previous sessions of the consumer with the same service,
+// sessions with other services of the same type are kept.
+func (manager *SessionManager) clearStaleSession(consumerID identity.Identity, serviceKey string) {
 	// Reading stale session before starting the clean up in goroutine.
 	// This is required to make sure we are not cleaning the newly created session.
 	for _, session := range manager.sessionStorage.GetAll() {
 		if consumerID != session.ConsumerID {
 			continue
 		}
-		if serviceType != session.Proposal.ServiceType {
+		if serviceKey != session.Proposal.ServiceKey() {
 			continue
 		}
-		log.Info().Msgf("Cleaning stale session %s for %s consumer", session.ID, consumerID.Address)
+		session.logger().Info().Msgf("Cleaning stale session %s for %s consumer", session.ID, consumerID.Address)
 		go session.Close()
 	}
 }
@@ -258,11 +336,42 @@ func (manager *SessionManager) Destroy(consumerID identity.Identity, sessionID s
 	return nil
 }
 
+// Renegotiate rotates tunnel keys of the running session, payments of the session are not interrupted.
+func (manager *SessionManager) Renegotiate(consumerID identity.Identity, sessionID string, sessionConfig json.RawMessage) ([]byte, error) {
+	session, found := manager.sessionStorage.Find(session.ID(sessionID))
+	if !found {
+		return nil, ErrorSessionNotExists
+	}
+	if session.ConsumerID != consumerID {
+		return nil, ErrorWrongSessionOwner
+	}
+
+	renegotiator, ok := manager.service.Service().(ConfigRenegotiator)
+	if !ok {
+		return nil, ErrorRenegotiationNotSupported
+	}
+	config, err := renegotiator.RenegotiateConfig(sessionID, sessionConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot renegotiate config of session %s: %w", sessionID, err)
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot pack session %s service config: %w", sessionID, err)
+	}
+	return data, nil
+}
+
+// sessionLogger returns logger tagging records with the service instance and the given session IDs.
+func (manager *SessionManager) sessionLogger(sessionID string) *zerolog.Logger {
+	return logconfig.SessionLogger(string(manager.service.ID), sessionID)
+}
+
 func (manager *SessionManager) paymentLoop(session *Session) error {
 	trace := session.tracer.StartStage("Provider session create (payment)")
 	defer session.tracer.EndStage(trace)
 
-	log.Info().Msg("Using new payments")
+	session.logger().Info().Msg("Using new payments")
 	engine, err := manager.paymentEngineFactory(manager.service.ProviderID, session.ConsumerID, session.HermesID, string(session.ID), manager.paymentEngineChan)
 	if err != nil {
 		return err
@@ -277,12 +386,12 @@ func (manager *SessionManager) paymentLoop(session *Session) error {
 	go func() {
 		err := engine.Start()
 		if err != nil {
-			log.Error().Err(err).Msg("Payment engine error")
+			session.logger().Error().Err(err).Msg("Payment engine error")
 			session.Close()
 		}
 	}()
 
-	log.Info().Msg("Waiting for a first invoice to be paid")
+	session.logger().Info().Msg("Waiting for a first invoice to be paid")
 	if err := engine.WaitFirstInvoice(30 * time.Second); err != nil {
 		return fmt.Errorf("first invoice was not paid: %w", err)
 	}
@@ -326,11 +435,12 @@ func (manager *SessionManager) keepAliveLoop(sess *Session, channel p2p.Channel)
 			return err
 		}
 
-		log.Debug().Msgf("Received p2p keepalive ping with SessionID=%s", ping.SessionID)
+		sess.logger().Debug().Msgf("Received p2p keepalive ping with SessionID=%s", ping.SessionID)
 		return c.OK()
 	})
 
 	// Send pings to consumer.
+	metrics := p2p.NewMetricsReporter(manager.publisher, channel, p2p.DiagnosticsRoleProvider, sess.ConsumerID, string(sess.ID), sess.Proposal.ServiceType)
 	var errCount int
 	for {
 		select {
@@ -341,16 +451,26 @@ func (manager *SessionManager) keepAliveLoop(sess *Session, channel p2p.Channel)
 			return
 		case <-time.After(manager.config.KeepAlive.SendInterval):
 			if err := manager.sendKeepAlivePing(channel, sess.ID); err != nil {
-				log.Err(err).Msgf("Failed to send p2p keepalive ping. SessionID=%s", sess.ID)
+				sess.logger().Err(err).Msgf("Failed to send p2p keepalive ping. SessionID=%s", sess.ID)
 				errCount++
 				if errCount == manager.config.KeepAlive.MaxSendErrCount {
-					log.Error().Msgf("Max p2p keepalive err count reached, closing p2p channel. SessionID=%s", sess.ID)
+					sess.logger().Error().Msgf("Max p2p keepalive err count reached, closing session and p2p channel. SessionID=%s", sess.ID)
+					manager.publisher.Publish(p2p.AppTopicPeerUnreachable, p2p.AppEventPeerUnreachable{
+						Role:        p2p.DiagnosticsRoleProvider,
+						PeerID:      sess.ConsumerID,
+						SessionID:   string(sess.ID),
+						ServiceType: sess.Proposal.ServiceType,
+						FailedPings: errCount,
+					})
+					// Consumer is gone, session and its payments are not waited for to time out.
+					sess.Close()
 					channel.Close()
 					return
 				}
 			} else {
 				errCount = 0
 			}
+			metrics.Report()
 		}
 	}
 }
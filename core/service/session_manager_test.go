@@ -19,6 +19,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net"
 	"testing"
@@ -77,11 +78,12 @@ func (m mockBalanceTracker) WaitFirstInvoice(time.Duration) error {
 }
 
 type mockP2PChannel struct {
-	tracer *trace.Tracer
+	tracer  *trace.Tracer
+	sendErr error
 }
 
 func (m *mockP2PChannel) Send(_ context.Context, _ string, _ *p2p.Message) (*p2p.Message, error) {
-	return nil, nil
+	return nil, m.sendErr
 }
 
 func (m *mockP2PChannel) Handle(topic string, handler p2p.HandlerFunc) {
@@ -95,6 +97,16 @@ func (m *mockP2PChannel) ServiceConn() *net.UDPConn { return nil }
 
 func (m *mockP2PChannel) Conn() *net.UDPConn { return nil }
 
+func (m *mockP2PChannel) Relayed() bool { return false }
+
+func (m *mockP2PChannel) Capabilities() p2p.Capabilities {
+	return p2p.Capabilities{Version: p2p.ProtocolVersion, Names: []string{p2p.CapabilitySessionShutdown}}
+}
+
+func (m *mockP2PChannel) Metrics() p2p.ChannelMetrics { return p2p.ChannelMetrics{} }
+
+func (m *mockP2PChannel) Rebind(context.Context) error { return nil }
+
 func (m *mockP2PChannel) Close() error { return nil }
 
 func TestManager_Start_StoresSession(t *testing.T) {
@@ -272,6 +284,109 @@ func TestManager_Start_RejectsUnknownProposal(t *testing.T) {
 	}, 2*time.Second, 10*time.Millisecond)
 }
 
+func TestManager_Start_RejectsWhenMaxSessionsReached(t *testing.T) {
+	publisher := mocks.NewEventBus()
+	sessionStore := NewSessionPool(publisher)
+	manager := newManager(currentService, sessionStore, publisher, &mockBalanceTracker{})
+	manager.config.MaxSessions = 1
+
+	request := func(consumer identity.Identity) *pb.SessionRequest {
+		return &pb.SessionRequest{
+			Consumer: &pb.ConsumerInfo{
+				Id:       consumer.Address,
+				HermesID: hermesID.String(),
+			},
+			ProposalID: int64(currentProposalID),
+		}
+	}
+
+	_, err := manager.Start(request(consumerID))
+	assert.NoError(t, err)
+
+	_, err = manager.Start(request(identity.FromAddress("0x2")))
+	assert.Exactly(t, ErrorMaxSessionsReached, err)
+	assert.Len(t, sessionStore.GetAll(), 1)
+
+	// Same consumer replaces its stale session.
+	_, err = manager.Start(request(consumerID))
+	assert.NoError(t, err)
+}
+
+func TestManager_Start_RejectsWhenMaxConsumerSessionsReached(t *testing.T) {
+	publisher := mocks.NewEventBus()
+	sessionStore := NewSessionPool(publisher)
+	serviceOfType := func(serviceType string) *Instance {
+		proposal := currentProposal
+		proposal.ServiceType = serviceType
+		return NewInstance(
+			identity.FromAddress(proposal.ProviderID),
+			serviceType,
+			struct{}{},
+			proposal,
+			servicestate.Running,
+			&mockService{},
+			policy.NewRepository(),
+			&mockDiscovery{},
+		)
+	}
+	request := func(consumer identity.Identity) *pb.SessionRequest {
+		return &pb.SessionRequest{
+			Consumer: &pb.ConsumerInfo{
+				Id:       consumer.Address,
+				HermesID: hermesID.String(),
+			},
+			ProposalID: int64(currentProposalID),
+		}
+	}
+
+	for _, serviceType := range []string{"first", "second"} {
+		manager := newManager(serviceOfType(serviceType), sessionStore, publisher, &mockBalanceTracker{})
+		_, err := manager.Start(request(consumerID))
+		assert.NoError(t, err)
+	}
+
+	manager := newManager(serviceOfType("third"), sessionStore, publisher, &mockBalanceTracker{})
+	_, err := manager.Start(request(consumerID))
+	assert.Exactly(t, ErrorMaxConsumerSessionsReached, err)
+	assert.Len(t, sessionStore.GetAll(), 2)
+
+	// Other consumers are not limited.
+	_, err = manager.Start(request(identity.FromAddress("0x2")))
+	assert.NoError(t, err)
+
+	// Same service type replaces stale session of the consumer.
+	manager = newManager(serviceOfType("first"), sessionStore, publisher, &mockBalanceTracker{})
+	_, err = manager.Start(request(consumerID))
+	assert.NoError(t, err)
+}
+
+func TestManager_Start_RejectsWhenServicePaused(t *testing.T) {
+	publisher := mocks.NewEventBus()
+	sessionStore := NewSessionPool(publisher)
+	pausedService := NewInstance(
+		identity.FromAddress(currentProposal.ProviderID),
+		currentProposal.ServiceType,
+		struct{}{},
+		currentProposal,
+		servicestate.Paused,
+		&mockService{},
+		policy.NewRepository(),
+		&mockDiscovery{},
+	)
+	manager := newManager(pausedService, sessionStore, publisher, &mockBalanceTracker{})
+
+	_, err := manager.Start(&pb.SessionRequest{
+		Consumer: &pb.ConsumerInfo{
+			Id:       consumerID.Address,
+			HermesID: hermesID.String(),
+		},
+		ProposalID: int64(currentProposalID),
+	})
+
+	assert.Exactly(t, ErrorServicePaused, err)
+	assert.Len(t, sessionStore.GetAll(), 0)
+}
+
 type MockNatEventTracker struct {
 }
 
@@ -333,6 +448,102 @@ func TestManager_AcknowledgeSession_PublishesEvent(t *testing.T) {
 	}, 2*time.Second, 10*time.Millisecond)
 }
 
+type mockRenegotiatingService struct {
+	mockService
+}
+
+func (mr *mockRenegotiatingService) RenegotiateConfig(sessionID string, _ json.RawMessage) (ServiceConfiguration, error) {
+	return map[string]string{"session": sessionID}, nil
+}
+
+func TestManager_Renegotiate(t *testing.T) {
+	publisher := mocks.NewEventBus()
+	renegotiatingService := NewInstance(
+		identity.FromAddress(currentProposal.ProviderID),
+		currentProposal.ServiceType,
+		struct{}{},
+		currentProposal,
+		servicestate.Running,
+		&mockRenegotiatingService{},
+		policy.NewRepository(),
+		&mockDiscovery{},
+	)
+
+	sessionStore := NewSessionPool(publisher)
+	session, _ := NewSession(
+		renegotiatingService,
+		&pb.SessionRequest{Consumer: &pb.ConsumerInfo{Id: consumerID.Address}},
+		trace.NewTracer(""),
+	)
+	sessionStore.Add(session)
+
+	manager := newManager(renegotiatingService, sessionStore, publisher, &mockBalanceTracker{})
+
+	_, err := manager.Renegotiate(consumerID, "unknown", nil)
+	assert.Exactly(t, ErrorSessionNotExists, err)
+
+	_, err = manager.Renegotiate(identity.FromAddress("0x2"), string(session.ID), nil)
+	assert.Exactly(t, ErrorWrongSessionOwner, err)
+
+	config, err := manager.Renegotiate(consumerID, string(session.ID), nil)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"session":"`+string(session.ID)+`"}`, string(config))
+
+	manager = newManager(currentService, sessionStore, publisher, &mockBalanceTracker{})
+	_, err = manager.Renegotiate(consumerID, string(session.ID), nil)
+	assert.Exactly(t, ErrorRenegotiationNotSupported, err)
+}
+
+func TestManager_KeepAlive_ClosesSessionOfUnreachablePeer(t *testing.T) {
+	publisher := mocks.NewEventBus()
+	sessionStore := NewSessionPool(publisher)
+	config := DefaultConfig()
+	config.KeepAlive = KeepAliveConfig{
+		SendInterval:    time.Millisecond,
+		SendTimeout:     time.Millisecond,
+		MaxSendErrCount: 3,
+	}
+	manager := NewSessionManager(
+		currentService,
+		sessionStore,
+		func(_, _ identity.Identity, _ common.Address, _ string, _ chan crypto.ExchangeMessage) (PaymentEngine, error) {
+			return &mockBalanceTracker{}, nil
+		},
+		&MockNatEventTracker{},
+		publisher,
+		&mockP2PChannel{tracer: trace.NewTracer("Provider connect"), sendErr: errors.New("timeout")},
+		config,
+	)
+
+	_, err := manager.Start(&pb.SessionRequest{
+		Consumer: &pb.ConsumerInfo{
+			Id:       consumerID.Address,
+			HermesID: hermesID.String(),
+		},
+		ProposalID: int64(currentProposalID),
+	})
+	assert.NoError(t, err)
+	session := sessionStore.GetAll()[0]
+
+	assert.Eventually(t, func() bool {
+		return len(sessionStore.GetAll()) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	var unreachable []p2p.AppEventPeerUnreachable
+	for _, e := range publisher.GetEventHistory() {
+		if e.Topic == p2p.AppTopicPeerUnreachable {
+			unreachable = append(unreachable, e.Event.(p2p.AppEventPeerUnreachable))
+		}
+	}
+	assert.Equal(t, []p2p.AppEventPeerUnreachable{{
+		Role:        p2p.DiagnosticsRoleProvider,
+		PeerID:      consumerID,
+		SessionID:   string(session.ID),
+		ServiceType: currentProposal.ServiceType,
+		FailedPings: 3,
+	}}, unreachable)
+}
+
 func newManager(service *Instance, sessions *SessionPool, publisher publisher, paymentEngine PaymentEngine) *SessionManager {
 	return NewSessionManager(
 		service,
@@ -18,6 +18,7 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
@@ -30,15 +31,33 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func subscribeSessionCreate(mng *SessionManager, ch p2p.Channel) {
+// ErrorConsumerNotAllowed is returned to consumer rejected by provider access control lists.
+var ErrorConsumerNotAllowed = errors.New("consumer is not allowed by provider")
+
+func subscribeSessionCreate(mng *SessionManager, ch p2p.Channel, acl ConsumerACL) {
 	ch.Handle(p2p.TopicSessionCreate, func(c p2p.Context) error {
 		var request pb.SessionRequest
 		if err := c.Request().UnmarshalProto(&request); err != nil {
 			return err
 		}
-		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicSessionCreate, request.String())
+		mng.service.logger().Debug().Msgf("Received P2P message for %q: %s", p2p.TopicSessionCreate, request.String())
+
+		if acl != nil {
+			consumerID := identity.FromAddress(request.GetConsumer().GetId())
+			allowed, err := acl.IsAllowed(consumerID)
+			if err != nil {
+				return fmt.Errorf("could not check consumer access: %w", err)
+			}
+			if !allowed {
+				mng.service.logger().Info().Msgf("Rejecting session of consumer %s by access control list", consumerID.Address)
+				return c.Error(ErrorConsumerNotAllowed)
+			}
+		}
 
 		response, err := mng.Start(&request)
+		if errors.Is(err, ErrorMaxSessionsReached) || errors.Is(err, ErrorMaxConsumerSessionsReached) || errors.Is(err, ErrorServicePaused) {
+			return fmt.Errorf("cannot start session: %v: %w", err, p2p.ErrPeerAtCapacity)
+		}
 		if err != nil {
 			return fmt.Errorf("cannot start session: %s: %w", response.ID, err)
 		}
@@ -47,6 +66,20 @@ func subscribeSessionCreate(mng *SessionManager, ch p2p.Channel) {
 	})
 }
 
+// probeChannelLinger is how long channel used only for probing is kept open after echoing the probe.
+const probeChannelLinger = 10 * time.Second
+
+// subscribeProbe echoes consumer latency probes, consumer connects through a new channel afterwards,
+// so the probed channel is closed once the echo is delivered.
+func subscribeProbe(ch p2p.ChannelHandler, closeChannel func()) {
+	ch.Handle(p2p.TopicProbe, func(c p2p.Context) error {
+		log.Debug().Msgf("Received P2P message for %q", p2p.TopicProbe)
+
+		time.AfterFunc(probeChannelLinger, closeChannel)
+		return c.OK()
+	})
+}
+
 func subscribeSessionStatus(ch p2p.ChannelHandler, statusStorage connectivity.StatusStorage) {
 	ch.Handle(p2p.TopicSessionStatus, func(c p2p.Context) error {
 		var ss pb.SessionStatus
@@ -74,7 +107,7 @@ func subscribeSessionDestroy(mng *SessionManager, ch p2p.ChannelHandler) {
 		if err := c.Request().UnmarshalProto(&si); err != nil {
 			return err
 		}
-		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicSessionDestroy, si.String())
+		mng.sessionLogger(si.GetSessionID()).Debug().Msgf("Received P2P message for %q: %s", p2p.TopicSessionDestroy, si.String())
 
 		go func() {
 			consumerID := identity.FromAddress(si.GetConsumerID())
@@ -82,7 +115,7 @@ func subscribeSessionDestroy(mng *SessionManager, ch p2p.ChannelHandler) {
 
 			err := mng.Destroy(consumerID, sessionID)
 			if err != nil {
-				log.Err(err).Msgf("Could not destroy session %s: %v", sessionID, err)
+				mng.sessionLogger(sessionID).Err(err).Msgf("Could not destroy session %s: %v", sessionID, err)
 			}
 		}()
 
@@ -96,7 +129,7 @@ func subscribeSessionAcknowledge(mng *SessionManager, ch p2p.ChannelHandler) {
 		if err := c.Request().UnmarshalProto(&si); err != nil {
 			return err
 		}
-		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicSessionAcknowledge, si.String())
+		mng.sessionLogger(si.GetSessionID()).Debug().Msgf("Received P2P message for %q: %s", p2p.TopicSessionAcknowledge, si.String())
 		consumerID := identity.FromAddress(si.GetConsumerID())
 		sessionID := si.GetSessionID()
 
@@ -109,6 +142,29 @@ func subscribeSessionAcknowledge(mng *SessionManager, ch p2p.ChannelHandler) {
 	})
 }
 
+// subscribeSessionRenegotiate rotates tunnel keys of the session on consumer request.
+func subscribeSessionRenegotiate(mng *SessionManager, ch p2p.ChannelHandler) {
+	ch.Handle(p2p.TopicSessionRenegotiate, func(c p2p.Context) error {
+		var sr pb.SessionRenegotiation
+		if err := c.Request().UnmarshalProto(&sr); err != nil {
+			return err
+		}
+		mng.sessionLogger(sr.GetSessionID()).Debug().Msgf("Received P2P message for %q: %s", p2p.TopicSessionRenegotiate, sr.GetSessionID())
+
+		consumerID := identity.FromAddress(sr.GetConsumerID())
+		config, err := mng.Renegotiate(consumerID, sr.GetSessionID(), sr.GetConfig())
+		if err != nil {
+			return fmt.Errorf("cannot renegotiate session %s: %w", sr.GetSessionID(), err)
+		}
+
+		return c.OkWithReply(p2p.ProtoMessage(&pb.SessionRenegotiation{
+			ConsumerID: sr.GetConsumerID(),
+			SessionID:  sr.GetSessionID(),
+			Config:     config,
+		}))
+	})
+}
+
 const bigIntBase int = 10
 
 func subscribeSessionPayments(mng *SessionManager, ch p2p.ChannelHandler) {
@@ -117,7 +173,7 @@ func subscribeSessionPayments(mng *SessionManager, ch p2p.ChannelHandler) {
 		if err := c.Request().UnmarshalProto(&msg); err != nil {
 			return fmt.Errorf("could not unmarshal exchange message proto: %w", err)
 		}
-		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicPaymentMessage, msg.String())
+		mng.service.logger().Debug().Msgf("Received P2P message for %q: %s", p2p.TopicPaymentMessage, msg.String())
 
 		amount, ok := new(big.Int).SetString(msg.GetPromise().GetAmount(), bigIntBase)
 		if !ok {
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"time"
+)
+
+// supervisor keeps service instance serving, restarting it after it fails.
+// Consecutive restarts are delayed exponentially, so that crash looping service does not hog the host.
+type supervisor struct {
+	// initialBackoff is the delay before the first restart, it doubles after every consecutive failure up to maxBackoff.
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	// maxRestarts limits consecutive restarts, service failing more times is stopped for good.
+	maxRestarts int
+	// healthyAfter is the time after which serving service is considered recovered and failures are counted anew.
+	healthyAfter time.Duration
+}
+
+func newSupervisor() supervisor {
+	return supervisor{
+		initialBackoff: time.Second,
+		maxBackoff:     time.Minute,
+		maxRestarts:    5,
+		healthyAfter:   5 * time.Minute,
+	}
+}
+
+// supervise serves the instance, replacing failed service with a new one created by the given function.
+// It returns once the instance is stopped, its service ends without an error or restarts are exhausted.
+func (s supervisor) supervise(instance *Instance, create func() (Service, error)) {
+	backoff := s.initialBackoff
+	var failures int
+	service := instance.Service()
+	for {
+		started := time.Now()
+		err := service.Serve(instance)
+		if err == nil || instance.isStopped() {
+			return
+		}
+		if time.Since(started) >= s.healthyAfter {
+			failures, backoff = 0, s.initialBackoff
+		}
+
+		for service = nil; service == nil; {
+			failures++
+			if failures > s.maxRestarts {
+				instance.logger().Error().Err(err).Msgf("Service %s failed %d times in a row, giving up", instance.ID, failures)
+				return
+			}
+			instance.logger().Error().Err(err).Msgf("Service %s failed, restarting in %s", instance.ID, backoff)
+			if !instance.waitRestart(backoff) {
+				return
+			}
+			backoff = s.nextBackoff(backoff)
+			service, err = create()
+		}
+		if !instance.replaceService(service) {
+			return
+		}
+		instance.logger().Info().Msgf("Service %s restarted", instance.ID)
+	}
+}
+
+func (s supervisor) nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > s.maxBackoff {
+		return s.maxBackoff
+	}
+	return backoff
+}
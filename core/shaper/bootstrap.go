@@ -17,6 +17,15 @@
 
 package shaper
 
+import (
+	"errors"
+
+	"github.com/mysteriumnetwork/node/datasize"
+)
+
+// ErrUnsupported indicates that bandwidth can not be limited on this platform.
+var ErrUnsupported = errors.New("bandwidth limiting is supported on Linux only")
+
 // Shaper shapes traffic on a network interface.
 type Shaper interface {
 	// Start applies shaping configuration on the specified interface and then continuously ensures it.
@@ -33,3 +42,46 @@ type eventListener interface {
 func New(listener eventListener) (shaper Shaper) {
 	return create(listener)
 }
+
+// Limiter limits bandwidth of a network interface to a given rate.
+type Limiter interface {
+	// Limit limits both upload and download speed of the interface.
+	Limit(interfaceName string, limitKbps int) error
+	// Clear removes the limits.
+	Clear(interfaceName string)
+}
+
+// NewLimiter creates a bandwidth limiter (linux) or no-op.
+func NewLimiter() Limiter {
+	return createLimiter()
+}
+
+// Kbps converts speed to the limiter rate, rounding non-zero speeds up to 1 kbps.
+func Kbps(limit datasize.BitSpeed) int {
+	limitKbps := int(limit / 1000)
+	if limitKbps < 1 {
+		limitKbps = 1
+	}
+	return limitKbps
+}
+
+// limitShaper shapes traffic to a fixed rate.
+type limitShaper struct {
+	limiter Limiter
+	limit   datasize.BitSpeed
+}
+
+// NewLimitShaper creates a traffic shaper limiting interface to the given rate (linux) or no-op.
+func NewLimitShaper(limit datasize.BitSpeed) Shaper {
+	return &limitShaper{limiter: createLimiter(), limit: limit}
+}
+
+// Start limits the interface speed.
+func (s *limitShaper) Start(interfaceName string) error {
+	return s.limiter.Limit(interfaceName, Kbps(s.limit))
+}
+
+// Clear removes the limit.
+func (s *limitShaper) Clear(interfaceName string) {
+	s.limiter.Clear(interfaceName)
+}
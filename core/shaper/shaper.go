@@ -43,3 +43,20 @@ func (noopShaper) Start(_ string) error {
 // Clear noop
 func (noopShaper) Clear(_ string) {
 }
+
+// noopLimiter does not limit bandwidth, limiting fails with ErrUnsupported
+type noopLimiter struct {
+}
+
+func createLimiter() *noopLimiter {
+	return &noopLimiter{}
+}
+
+// Limit fails, since bandwidth limiting is not implemented
+func (noopLimiter) Limit(_ string, _ int) error {
+	return ErrUnsupported
+}
+
+// Clear noop
+func (noopLimiter) Clear(_ string) {
+}
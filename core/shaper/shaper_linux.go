@@ -75,3 +75,32 @@ func (s *linuxShaper) Start(interfaceName string) error {
 func (s *linuxShaper) Clear(interfaceName string) {
 	s.ws.Clear(interfaceName)
 }
+
+type linuxLimiter struct {
+	ws *wondershaper.Shaper
+}
+
+func createLimiter() *linuxLimiter {
+	ws := wondershaper.New()
+	ws.Stdout = log.Logger
+	ws.Stderr = log.Logger
+	return &linuxLimiter{ws: ws}
+}
+
+// Limit limits both upload and download speed of the interface.
+func (l *linuxLimiter) Limit(interfaceName string, limitKbps int) error {
+	l.ws.Clear(interfaceName)
+
+	if err := l.ws.LimitDownlink(interfaceName, limitKbps); err != nil {
+		return errors.Wrap(err, "could not limit download speed")
+	}
+	if err := l.ws.LimitUplink(interfaceName, limitKbps); err != nil {
+		return errors.Wrap(err, "could not limit upload speed")
+	}
+	return nil
+}
+
+// Clear removes the limits.
+func (l *linuxLimiter) Clear(interfaceName string) {
+	l.ws.Clear(interfaceName)
+}
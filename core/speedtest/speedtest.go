@@ -0,0 +1,212 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package speedtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/eventbus"
+)
+
+// Services lists provided services and announces measured bandwidth in their proposals.
+type Services interface {
+	List() map[service.ID]*service.Instance
+	UpdateMeasuredBandwidth(id service.ID, bandwidth datasize.BitSpeed) error
+}
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Config describes how provider measures its bandwidth.
+type Config struct {
+	// Targets are URLs of large files downloaded to measure bandwidth, the fastest one is taken into account.
+	Targets []string
+	// Interval is the period of measurement.
+	Interval time.Duration
+	// Duration limits download from a single target.
+	Duration time.Duration
+}
+
+// SpeedTest periodically measures download bandwidth of the provider and announces it in proposals of provided services.
+type SpeedTest struct {
+	config   Config
+	services Services
+	client   httpClient
+
+	lock      sync.Mutex
+	bandwidth datasize.BitSpeed
+	applied   map[service.ID]datasize.BitSpeed
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSpeedTest creates speed test of the provider.
+func NewSpeedTest(config Config, services Services, client httpClient) *SpeedTest {
+	return &SpeedTest{
+		config:   config,
+		services: services,
+		client:   client,
+		applied:  make(map[service.ID]datasize.BitSpeed),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to service status events.
+func (t *SpeedTest) Subscribe(bus eventbus.Subscriber) error {
+	return bus.SubscribeAsync(servicestate.AppTopicServiceStatus, t.consumeServiceStatusEvent)
+}
+
+// Start starts periodic measurement, the first one is done right away.
+func (t *SpeedTest) Start() {
+	go t.measureLoop()
+}
+
+// Stop stops periodic measurement, announced bandwidth is left as it is.
+func (t *SpeedTest) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stop)
+	})
+}
+
+// Bandwidth returns the last measured bandwidth, zero value means it was not measured yet.
+func (t *SpeedTest) Bandwidth() datasize.BitSpeed {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.bandwidth
+}
+
+func (t *SpeedTest) measureLoop() {
+	for {
+		t.check()
+
+		select {
+		case <-t.stop:
+			return
+		case <-time.After(t.config.Interval):
+		}
+	}
+}
+
+// consumeServiceStatusEvent announces bandwidth of newly started services without waiting for the next measurement.
+func (t *SpeedTest) consumeServiceStatusEvent(e servicestate.AppEventServiceStatus) {
+	if e.Status != string(servicestate.Running) {
+		return
+	}
+	t.apply()
+}
+
+// check measures bandwidth against all targets and announces the best result.
+func (t *SpeedTest) check() {
+	var best datasize.BitSpeed
+	for _, target := range t.config.Targets {
+		bandwidth, err := t.measure(target)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Speed test against %s failed", target)
+			continue
+		}
+		log.Debug().Msgf("Speed test against %s: %s", target, bandwidth)
+		if bandwidth > best {
+			best = bandwidth
+		}
+	}
+	if best == 0 {
+		log.Warn().Msg("Speed test failed against all targets, keeping the previous result")
+		return
+	}
+
+	log.Info().Msgf("Measured provider bandwidth: %s", best)
+	t.lock.Lock()
+	t.bandwidth = best
+	t.lock.Unlock()
+
+	t.apply()
+}
+
+// measure downloads the target until it ends or measurement duration passes and returns the download speed.
+func (t *SpeedTest) measure(target string) (datasize.BitSpeed, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.config.Duration)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	bytes, err := io.Copy(ioutil.Discard, resp.Body)
+	if err != nil && ctx.Err() == nil {
+		return 0, err
+	}
+
+	elapsed := time.Since(start).Seconds()
+	if bytes == 0 || elapsed <= 0 {
+		return 0, fmt.Errorf("nothing downloaded")
+	}
+	return datasize.BitSpeed(float64(bytes) * 8 / elapsed), nil
+}
+
+// apply announces measured bandwidth in proposals of services which do not announce it yet.
+func (t *SpeedTest) apply() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.bandwidth == 0 {
+		return
+	}
+
+	instances := t.services.List()
+	for id := range t.applied {
+		if _, ok := instances[id]; !ok {
+			delete(t.applied, id)
+		}
+	}
+
+	for id := range instances {
+		if bandwidth, ok := t.applied[id]; ok && bandwidth == t.bandwidth {
+			continue
+		}
+		if err := t.services.UpdateMeasuredBandwidth(id, t.bandwidth); err != nil {
+			log.Warn().Err(err).Msgf("Could not announce measured bandwidth of service %s", id)
+			continue
+		}
+		t.applied[id] = t.bandwidth
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package speedtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/datasize"
+)
+
+type mockServices struct {
+	lock      sync.Mutex
+	instances map[service.ID]*service.Instance
+	updates   map[service.ID][]datasize.BitSpeed
+}
+
+func (s *mockServices) List() map[service.ID]*service.Instance {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.instances
+}
+
+func (s *mockServices) UpdateMeasuredBandwidth(id service.ID, bandwidth datasize.BitSpeed) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.updates[id] = append(s.updates[id], bandwidth)
+	return nil
+}
+
+func newMockServices(ids ...service.ID) *mockServices {
+	services := &mockServices{
+		instances: make(map[service.ID]*service.Instance),
+		updates:   make(map[service.ID][]datasize.BitSpeed),
+	}
+	for _, id := range ids {
+		services.instances[id] = &service.Instance{}
+	}
+	return services
+}
+
+func newTestTarget(size int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(strings.Repeat("x", size)))
+	}))
+}
+
+func TestSpeedTest_AnnouncesMeasuredBandwidth(t *testing.T) {
+	target := newTestTarget(1024 * 1024)
+	defer target.Close()
+	services := newMockServices("1")
+	test := NewSpeedTest(Config{Targets: []string{target.URL}, Duration: time.Second}, services, http.DefaultClient)
+
+	test.check()
+
+	assert.True(t, test.Bandwidth() > 0)
+	assert.Equal(t, []datasize.BitSpeed{test.Bandwidth()}, services.updates["1"])
+
+	services.instances["2"] = &service.Instance{}
+	test.consumeServiceStatusEvent(servicestate.AppEventServiceStatus{ID: "2", Status: string(servicestate.Running)})
+	assert.Len(t, services.updates["1"], 1)
+	assert.Equal(t, []datasize.BitSpeed{test.Bandwidth()}, services.updates["2"])
+}
+
+func TestSpeedTest_KeepsPreviousResultWhenTargetsFail(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+	services := newMockServices("1")
+	test := NewSpeedTest(Config{Targets: []string{failing.URL}, Duration: time.Second}, services, http.DefaultClient)
+	test.bandwidth = datasize.BitSpeed(datasize.MiB)
+
+	test.check()
+
+	assert.Equal(t, datasize.BitSpeed(datasize.MiB), test.Bandwidth())
+	assert.Empty(t, services.updates["1"])
+}
@@ -62,6 +62,7 @@ type Connection struct {
 	Statistics connectionstate.Statistics
 	Throughput bandwidth.Throughput
 	Invoice    crypto.Invoice
+	Health     connectionstate.Health
 }
 
 func (c Connection) String() string {
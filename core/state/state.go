@@ -92,6 +92,7 @@ type Keeper struct {
 	consumeConnectionStatisticsEvent func(interface{})
 	consumeConnectionThroughputEvent func(interface{})
 	consumeConnectionSpendingEvent   func(interface{})
+	consumeConnectionHealthEvent     func(interface{})
 
 	announceStateChanges func(e interface{})
 }
@@ -137,6 +138,7 @@ func NewKeeper(deps KeeperDeps, debounceDuration time.Duration) *Keeper {
 	k.consumeConnectionStatisticsEvent = debounce(k.updateConnectionStats, debounceDuration)
 	k.consumeConnectionThroughputEvent = debounce(k.updateConnectionThroughput, debounceDuration)
 	k.consumeConnectionSpendingEvent = debounce(k.updateConnectionSpending, debounceDuration)
+	k.consumeConnectionHealthEvent = debounce(k.updateConnectionHealth, debounceDuration)
 	k.announceStateChanges = debounce(k.announceState, debounceDuration)
 
 	return k
@@ -197,6 +199,9 @@ func (k *Keeper) Subscribe(bus eventbus.Subscriber) error {
 	if err := bus.SubscribeAsync(bandwidth.AppTopicConnectionThroughput, k.consumeConnectionThroughputEvent); err != nil {
 		return err
 	}
+	if err := bus.SubscribeAsync(connectionstate.AppTopicConnectionHealth, k.consumeConnectionHealthEvent); err != nil {
+		return err
+	}
 	if err := bus.SubscribeAsync(pingpongEvent.AppTopicInvoicePaid, k.consumeConnectionSpendingEvent); err != nil {
 		return err
 	}
@@ -243,6 +248,7 @@ func (k *Keeper) updateServices() {
 			Type:                 v.Type,
 			Options:              v.Options,
 			Status:               string(v.State()),
+			Backend:              v.Backend(),
 			Proposal:             contract.NewProposalDTO(v.Proposal),
 			ConnectionStatistics: match.ConnectionStatistics,
 		}
@@ -433,6 +439,20 @@ func (k *Keeper) updateConnectionThroughput(e interface{}) {
 	go k.announceStateChanges(nil)
 }
 
+func (k *Keeper) updateConnectionHealth(e interface{}) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	evt, ok := e.(connectionstate.AppEventConnectionHealth)
+	if !ok {
+		log.Warn().Msg("Received a wrong kind of event for connection health update")
+		return
+	}
+
+	k.state.Connection.Health = evt.Health
+
+	go k.announceStateChanges(nil)
+}
+
 func (k *Keeper) updateConnectionSpending(e interface{}) {
 	k.lock.Lock()
 	defer k.lock.Unlock()
@@ -0,0 +1,250 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/asdine/storm/v3"
+	"github.com/asdine/storm/v3/q"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/eventbus"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+)
+
+const bucket = "provider-service-stats"
+
+// Hour is statistics of the provided service aggregated over a clock hour.
+type Hour struct {
+	ID          string `storm:"id"`
+	ServiceID   string `storm:"index"`
+	ServiceType string
+	At          time.Time `storm:"index"`
+	// Sessions is the number of sessions started during the hour
+	Sessions int
+	// Consumers are unique consumers served during the hour
+	Consumers []string
+	// BytesSent is traffic sent to consumers
+	BytesSent uint64
+	// BytesReceived is traffic received from consumers
+	BytesReceived uint64
+	// Tokens is the amount earned during the hour in the smallest MYST units
+	Tokens *big.Int
+}
+
+func (h *Hour) addConsumer(consumerID string) {
+	for _, c := range h.Consumers {
+		if c == consumerID {
+			return
+		}
+	}
+	h.Consumers = append(h.Consumers, consumerID)
+}
+
+// Collector aggregates sessions, unique consumers, traffic and earnings of provided services per hour.
+// Statistics of the current hours are kept in memory and persisted once per flush interval.
+type Collector struct {
+	storage       *boltdb.Bolt
+	flushInterval time.Duration
+	now           func() time.Time
+
+	lock     sync.Mutex
+	sessions map[string]*counters
+	hours    map[string]*Hour
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// counters are totals of the active session reported so far, used to account increments.
+type counters struct {
+	serviceID   string
+	serviceType string
+	consumerID  string
+	sent        uint64
+	received    uint64
+	tokens      *big.Int
+}
+
+// NewCollector creates statistics collector of provided services.
+func NewCollector(storage *boltdb.Bolt, flushInterval time.Duration) *Collector {
+	return &Collector{
+		storage:       storage,
+		flushInterval: flushInterval,
+		now:           time.Now,
+		sessions:      make(map[string]*counters),
+		hours:         make(map[string]*Hour),
+		stop:          make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to session, session traffic and earnings events.
+func (c *Collector) Subscribe(bus eventbus.Subscriber) error {
+	if err := bus.Subscribe(sevent.AppTopicSession, c.consumeSessionEvent); err != nil {
+		return err
+	}
+	if err := bus.SubscribeAsync(sevent.AppTopicDataTransferred, c.consumeDataTransferredEvent); err != nil {
+		return err
+	}
+	return bus.SubscribeAsync(sevent.AppTopicTokensEarned, c.consumeTokensEarnedEvent)
+}
+
+// Start starts persisting collected statistics periodically.
+func (c *Collector) Start() {
+	go func() {
+		ticker := time.NewTicker(c.flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.flush()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop persists collected statistics and stops the collector.
+func (c *Collector) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	c.flush()
+}
+
+// Hours returns hourly statistics of the service within the given period ordered by time.
+func (c *Collector) Hours(serviceID string, from, to time.Time) ([]Hour, error) {
+	c.flush()
+
+	var hours []Hour
+	err := c.storage.DB().From(bucket).Select(
+		q.Eq("ServiceID", serviceID),
+		q.Gte("At", from.UTC().Truncate(time.Hour)),
+		q.Lte("At", to.UTC()),
+	).OrderBy("At").Find(&hours)
+	if errors.Is(err, storm.ErrNotFound) {
+		return []Hour{}, nil
+	}
+	return hours, err
+}
+
+func (c *Collector) consumeSessionEvent(e sevent.AppEventSession) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch e.Status {
+	case sevent.CreatedStatus:
+		s := &counters{
+			serviceID:   e.Service.ID,
+			serviceType: e.Session.Proposal.ServiceType,
+			consumerID:  e.Session.ConsumerID.Address,
+			tokens:      new(big.Int),
+		}
+		c.sessions[e.Session.ID] = s
+		h := c.hourLocked(s)
+		h.Sessions++
+		h.addConsumer(s.consumerID)
+	case sevent.RemovedStatus:
+		delete(c.sessions, e.Session.ID)
+	}
+}
+
+func (c *Collector) consumeDataTransferredEvent(e sevent.AppEventDataTransferred) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	s, ok := c.sessions[e.ID]
+	if !ok {
+		return
+	}
+	// Traffic is reported from the consumer point of view.
+	h := c.hourLocked(s)
+	h.BytesSent += increment(s.sent, e.Down)
+	h.BytesReceived += increment(s.received, e.Up)
+	h.addConsumer(s.consumerID)
+	s.sent = e.Down
+	s.received = e.Up
+}
+
+func (c *Collector) consumeTokensEarnedEvent(e sevent.AppEventTokensEarned) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	s, ok := c.sessions[e.SessionID]
+	if !ok || e.Total == nil || e.Total.Cmp(s.tokens) <= 0 {
+		return
+	}
+	h := c.hourLocked(s)
+	h.Tokens.Add(h.Tokens, new(big.Int).Sub(e.Total, s.tokens))
+	s.tokens = new(big.Int).Set(e.Total)
+}
+
+// increment returns traffic reported since the previous report, service may restart its counters
+// and then everything reported since is new traffic.
+func increment(previous, current uint64) uint64 {
+	if current >= previous {
+		return current - previous
+	}
+	return current
+}
+
+// hourLocked returns statistics of the current hour of the session's service, restoring persisted ones.
+func (c *Collector) hourLocked(s *counters) *Hour {
+	at := c.now().UTC().Truncate(time.Hour)
+	id := fmt.Sprintf("%s/%d", s.serviceID, at.Unix())
+	if h, ok := c.hours[id]; ok {
+		return h
+	}
+
+	h := &Hour{}
+	if err := c.storage.DB().From(bucket).One("ID", id, h); err != nil {
+		if !errors.Is(err, storm.ErrNotFound) {
+			log.Warn().Err(err).Msgf("Could not restore statistics of service %s", s.serviceID)
+		}
+		h = &Hour{ID: id, ServiceID: s.serviceID, ServiceType: s.serviceType, At: at}
+	}
+	if h.Tokens == nil {
+		h.Tokens = new(big.Int)
+	}
+	c.hours[id] = h
+	return h
+}
+
+// flush persists collected statistics, statistics of past hours are released from memory.
+func (c *Collector) flush() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	current := c.now().UTC().Truncate(time.Hour)
+	for id, h := range c.hours {
+		if err := c.storage.Store(bucket, h); err != nil {
+			log.Error().Err(err).Msgf("Could not store statistics of service %s", h.ServiceID)
+			continue
+		}
+		if h.At.Before(current) {
+			delete(c.hours, id)
+		}
+	}
+}
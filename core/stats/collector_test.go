@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package stats
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+)
+
+var started = time.Date(2020, 10, 16, 12, 30, 0, 0, time.UTC)
+
+func TestCollector_AggregatesPerHour(t *testing.T) {
+	c, cleanup := newTestCollector(t)
+	defer cleanup()
+	now := started
+	c.now = func() time.Time { return now }
+
+	c.consumeSessionEvent(sessionEvent(sevent.CreatedStatus, "s1", "0x1"))
+	c.consumeSessionEvent(sessionEvent(sevent.CreatedStatus, "s2", "0x1"))
+	c.consumeSessionEvent(sessionEvent(sevent.CreatedStatus, "s3", "0x2"))
+	c.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "s1", Up: 10, Down: 100})
+	c.consumeTokensEarnedEvent(sevent.AppEventTokensEarned{SessionID: "s1", Total: big.NewInt(5)})
+
+	now = now.Add(time.Hour)
+	c.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "s1", Up: 15, Down: 300})
+	c.consumeTokensEarnedEvent(sevent.AppEventTokensEarned{SessionID: "s1", Total: big.NewInt(12)})
+	c.consumeSessionEvent(sessionEvent(sevent.RemovedStatus, "s1", "0x1"))
+	c.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "s1", Up: 20, Down: 400})
+	c.Stop()
+
+	hours, err := c.Hours("service1", started, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []Hour{
+		{
+			ID:            "service1/1602849600",
+			ServiceID:     "service1",
+			ServiceType:   "wireguard",
+			At:            started.Truncate(time.Hour),
+			Sessions:      3,
+			Consumers:     []string{"0x1", "0x2"},
+			BytesSent:     100,
+			BytesReceived: 10,
+			Tokens:        big.NewInt(5),
+		},
+		{
+			ID:            "service1/1602853200",
+			ServiceID:     "service1",
+			ServiceType:   "wireguard",
+			At:            started.Add(time.Hour).Truncate(time.Hour),
+			Consumers:     []string{"0x1"},
+			BytesSent:     200,
+			BytesReceived: 5,
+			Tokens:        big.NewInt(7),
+		},
+	}, hours)
+}
+
+func TestCollector_RestoresStoredHour(t *testing.T) {
+	c, cleanup := newTestCollector(t)
+	defer cleanup()
+	c.now = func() time.Time { return started }
+
+	c.consumeSessionEvent(sessionEvent(sevent.CreatedStatus, "s1", "0x1"))
+	c.Stop()
+
+	restarted := NewCollector(c.storage, time.Minute)
+	restarted.now = c.now
+	restarted.consumeSessionEvent(sessionEvent(sevent.CreatedStatus, "s2", "0x2"))
+
+	hours, err := restarted.Hours("service1", started, started)
+	assert.NoError(t, err)
+	assert.Len(t, hours, 1)
+	assert.Equal(t, 2, hours[0].Sessions)
+	assert.Equal(t, []string{"0x1", "0x2"}, hours[0].Consumers)
+
+	hours, err = restarted.Hours("unknown", started, started)
+	assert.NoError(t, err)
+	assert.Empty(t, hours)
+}
+
+func sessionEvent(status sevent.Status, id, consumerID string) sevent.AppEventSession {
+	return sevent.AppEventSession{
+		Status:  status,
+		Service: sevent.ServiceContext{ID: "service1"},
+		Session: sevent.SessionContext{
+			ID:         id,
+			ConsumerID: identity.FromAddress(consumerID),
+			Proposal:   market.ServiceProposal{ServiceType: "wireguard"},
+		},
+	}
+}
+
+func newTestCollector(t *testing.T) (*Collector, func()) {
+	dir, err := ioutil.TempDir("", "statsTest")
+	assert.NoError(t, err)
+	db, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+
+	return NewCollector(db, time.Minute), func() {
+		assert.NoError(t, db.Close())
+		assert.NoError(t, os.RemoveAll(dir))
+	}
+}
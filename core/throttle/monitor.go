@@ -0,0 +1,176 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package throttle
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/eventbus"
+)
+
+// resumeRatio is the share of a limit usage has to drop below before services are resumed,
+// so that services do not flap while usage hovers around the limit.
+const resumeRatio = 0.9
+
+// Services pauses and resumes provided services.
+type Services interface {
+	Pause()
+	Resume()
+}
+
+// Usage is the share of host resources in use, from 0 to 1.
+type Usage struct {
+	CPU             float64
+	Memory          float64
+	FileDescriptors float64
+}
+
+// Limits are resource usage shares above which services are paused, zero value disables the limit.
+type Limits struct {
+	CPU             float64
+	Memory          float64
+	FileDescriptors float64
+}
+
+// IsZero checks whether all limits are disabled.
+func (l Limits) IsZero() bool {
+	return l.CPU <= 0 && l.Memory <= 0 && l.FileDescriptors <= 0
+}
+
+// exceeded checks whether usage is above any of the limits scaled by the given ratio.
+func (l Limits) exceeded(u Usage, ratio float64) bool {
+	above := func(usage, limit float64) bool {
+		return limit > 0 && usage > limit*ratio
+	}
+	return above(u.CPU, l.CPU) || above(u.Memory, l.Memory) || above(u.FileDescriptors, l.FileDescriptors)
+}
+
+// Sampler measures resource usage of the host.
+type Sampler interface {
+	Sample() (Usage, error)
+}
+
+// Monitor pauses services while host resources are overused, e.g. on routers and other constrained hardware,
+// and resumes them once usage drops.
+type Monitor struct {
+	limits        Limits
+	checkInterval time.Duration
+	sampler       Sampler
+	services      Services
+
+	lock       sync.Mutex
+	usage      Usage
+	overloaded bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewMonitor creates resource monitor of the host.
+func NewMonitor(limits Limits, checkInterval time.Duration, sampler Sampler, services Services) *Monitor {
+	return &Monitor{
+		limits:        limits,
+		checkInterval: checkInterval,
+		sampler:       sampler,
+		services:      services,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to service status events.
+func (m *Monitor) Subscribe(bus eventbus.Subscriber) error {
+	return bus.SubscribeAsync(servicestate.AppTopicServiceStatus, m.consumeServiceStatusEvent)
+}
+
+// Start starts measuring resource usage.
+func (m *Monitor) Start() {
+	go m.checkLoop()
+}
+
+// Stop stops measuring resource usage, services are left as they are.
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+}
+
+// Usage returns the last measured resource usage.
+func (m *Monitor) Usage() Usage {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.usage
+}
+
+func (m *Monitor) checkLoop() {
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-time.After(m.checkInterval):
+			if err := m.check(); err != nil {
+				log.Warn().Err(err).Msg("Could not measure resource usage, resource monitor stopped")
+				return
+			}
+		}
+	}
+}
+
+// consumeServiceStatusEvent pauses services started while resources are overused.
+func (m *Monitor) consumeServiceStatusEvent(e servicestate.AppEventServiceStatus) {
+	if e.Status != string(servicestate.Running) {
+		return
+	}
+
+	m.lock.Lock()
+	overloaded := m.overloaded
+	m.lock.Unlock()
+
+	if overloaded {
+		m.services.Pause()
+	}
+}
+
+// check pauses services once any resource is overused and resumes them once usage of all resources drops.
+func (m *Monitor) check() error {
+	usage, err := m.sampler.Sample()
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.usage = usage
+	switch {
+	case !m.overloaded && m.limits.exceeded(usage, 1):
+		log.Warn().Msgf("Resource usage is above limits (CPU %.0f%%, memory %.0f%%, file descriptors %.0f%%), pausing services",
+			usage.CPU*100, usage.Memory*100, usage.FileDescriptors*100)
+		m.overloaded = true
+		m.services.Pause()
+	case m.overloaded && !m.limits.exceeded(usage, resumeRatio):
+		log.Info().Msg("Resource usage dropped, resuming services")
+		m.overloaded = false
+		m.services.Resume()
+	}
+	return nil
+}
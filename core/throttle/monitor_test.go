@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package throttle
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+)
+
+func TestMonitor_PausesAndResumesServices(t *testing.T) {
+	services := &mockServices{}
+	sampler := &mockSampler{}
+	m := NewMonitor(Limits{CPU: 0.8, FileDescriptors: 0.5}, time.Hour, sampler, services)
+
+	sampler.usage = Usage{CPU: 0.5, Memory: 0.99, FileDescriptors: 0.1}
+	assert.NoError(t, m.check())
+	assert.Equal(t, 0, services.paused)
+
+	sampler.usage = Usage{CPU: 0.5, FileDescriptors: 0.6}
+	assert.NoError(t, m.check())
+	assert.Equal(t, 1, services.paused)
+
+	m.consumeServiceStatusEvent(servicestate.AppEventServiceStatus{Status: string(servicestate.Running)})
+	assert.Equal(t, 2, services.paused)
+
+	// Usage has to drop clearly below the limit.
+	sampler.usage = Usage{CPU: 0.75, FileDescriptors: 0.4}
+	assert.NoError(t, m.check())
+	assert.Equal(t, 0, services.resumed)
+
+	sampler.usage = Usage{CPU: 0.7, FileDescriptors: 0.4}
+	assert.NoError(t, m.check())
+	assert.Equal(t, 1, services.resumed)
+	assert.Equal(t, sampler.usage, m.Usage())
+
+	m.consumeServiceStatusEvent(servicestate.AppEventServiceStatus{Status: string(servicestate.Running)})
+	assert.Equal(t, 2, services.paused)
+}
+
+func TestMonitor_ReturnsSamplerError(t *testing.T) {
+	services := &mockServices{}
+	m := NewMonitor(Limits{CPU: 0.8}, time.Hour, &mockSampler{err: ErrNotSupported}, services)
+
+	assert.True(t, errors.Is(m.check(), ErrNotSupported))
+	assert.Equal(t, 0, services.paused)
+}
+
+func TestLimits_IsZero(t *testing.T) {
+	assert.True(t, Limits{}.IsZero())
+	assert.False(t, Limits{Memory: 0.9}.IsZero())
+}
+
+type mockSampler struct {
+	usage Usage
+	err   error
+}
+
+func (m *mockSampler) Sample() (Usage, error) {
+	return m.usage, m.err
+}
+
+type mockServices struct {
+	paused, resumed int
+}
+
+func (m *mockServices) Pause() {
+	m.paused++
+}
+
+func (m *mockServices) Resume() {
+	m.resumed++
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package throttle
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNotSupported indicates that resource usage can not be measured on the host OS.
+var ErrNotSupported = errors.New("resource usage measurement is not supported")
+
+// cpuTimes are cumulative CPU times of all cores in clock ticks.
+type cpuTimes struct {
+	idle  uint64
+	total uint64
+}
+
+// usageSince returns the share of time CPU was busy since the previous times.
+func (t cpuTimes) usageSince(prev cpuTimes) float64 {
+	if t.total <= prev.total {
+		return 0
+	}
+	idle := float64(t.idle - prev.idle)
+	return 1 - idle/float64(t.total-prev.total)
+}
+
+// parseCPUTimes parses aggregated CPU line of /proc/stat.
+func parseCPUTimes(stat string) (cpuTimes, error) {
+	scanner := bufio.NewScanner(strings.NewReader(stat))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+
+		var times cpuTimes
+		// user nice system idle iowait irq softirq steal, guest times are already included in user times.
+		for i, field := range fields[1:] {
+			if i >= 8 {
+				break
+			}
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return cpuTimes{}, fmt.Errorf("invalid CPU time %q: %w", field, err)
+			}
+			times.total += value
+			if i == 3 || i == 4 {
+				times.idle += value
+			}
+		}
+		return times, nil
+	}
+	return cpuTimes{}, errors.New("CPU times not found")
+}
+
+// parseMemoryUsage parses /proc/meminfo and returns the share of memory not available for new processes.
+func parseMemoryUsage(meminfo string) (float64, error) {
+	var total, available uint64
+	var foundTotal, foundAvailable bool
+
+	scanner := bufio.NewScanner(strings.NewReader(meminfo))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "MemTotal:":
+			total, err = strconv.ParseUint(fields[1], 10, 64)
+			foundTotal = true
+		case "MemAvailable:":
+			available, err = strconv.ParseUint(fields[1], 10, 64)
+			foundAvailable = true
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid memory info %q: %w", scanner.Text(), err)
+		}
+	}
+	if !foundTotal || !foundAvailable || total == 0 {
+		return 0, errors.New("memory info not found")
+	}
+	if available > total {
+		return 0, nil
+	}
+	return 1 - float64(available)/float64(total), nil
+}
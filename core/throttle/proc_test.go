@@ -0,0 +1,49 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package throttle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCPUTimes(t *testing.T) {
+	prev, err := parseCPUTimes("cpu  100 0 100 700 100 0 0 0 0 0\ncpu0 50 0 50 350 50 0 0 0 0 0\n")
+	assert.NoError(t, err)
+	assert.Equal(t, cpuTimes{idle: 800, total: 1000}, prev)
+
+	next, err := parseCPUTimes("cpu  400 0 400 1100 100 0 0 0 0 0\n")
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.6, next.usageSince(prev), 0.0001)
+	assert.Equal(t, float64(0), prev.usageSince(prev))
+
+	_, err = parseCPUTimes("cpu0 50 0 50 350 50 0 0 0 0 0\n")
+	assert.Error(t, err)
+	_, err = parseCPUTimes("cpu  a 0 50 350 50 0 0 0 0 0\n")
+	assert.Error(t, err)
+}
+
+func TestParseMemoryUsage(t *testing.T) {
+	usage, err := parseMemoryUsage("MemTotal:       1000 kB\nMemFree:         100 kB\nMemAvailable:    250 kB\n")
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.75, usage, 0.0001)
+
+	_, err = parseMemoryUsage("MemTotal:       1000 kB\nMemFree:         100 kB\n")
+	assert.Error(t, err)
+}
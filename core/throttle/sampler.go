@@ -0,0 +1,32 @@
+// +build !linux
+
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package throttle
+
+type unsupportedSampler struct{}
+
+// NewSampler returns resource usage sampler of the host.
+func NewSampler() Sampler {
+	return &unsupportedSampler{}
+}
+
+// Sample fails, since resource usage is only measured on Linux.
+func (s *unsupportedSampler) Sample() (Usage, error) {
+	return Usage{}, ErrNotSupported
+}
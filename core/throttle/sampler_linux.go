@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package throttle
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+)
+
+// procSampler measures resource usage of the host from /proc.
+type procSampler struct {
+	prevCPU *cpuTimes
+}
+
+// NewSampler returns resource usage sampler of the host.
+func NewSampler() Sampler {
+	return &procSampler{}
+}
+
+// Sample measures resource usage, CPU usage is measured since the previous sample.
+func (s *procSampler) Sample() (usage Usage, err error) {
+	stat, err := ioutil.ReadFile("/proc/stat")
+	if err != nil {
+		return usage, err
+	}
+	cpu, err := parseCPUTimes(string(stat))
+	if err != nil {
+		return usage, err
+	}
+	if s.prevCPU != nil {
+		usage.CPU = cpu.usageSince(*s.prevCPU)
+	}
+	s.prevCPU = &cpu
+
+	meminfo, err := ioutil.ReadFile("/proc/meminfo")
+	if err != nil {
+		return usage, err
+	}
+	if usage.Memory, err = parseMemoryUsage(string(meminfo)); err != nil {
+		return usage, err
+	}
+
+	usage.FileDescriptors, err = fileDescriptorUsage()
+	return usage, err
+}
+
+// fileDescriptorUsage returns the share of the open files limit used by the node process.
+func fileDescriptorUsage() (float64, error) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0, err
+	}
+	if limit.Cur == 0 {
+		return 0, nil
+	}
+
+	dir, err := os.Open("/proc/self/fd")
+	if err != nil {
+		return 0, err
+	}
+	defer dir.Close()
+
+	fds, err := dir.Readdirnames(-1)
+	if err != nil {
+		return 0, err
+	}
+	return float64(len(fds)) / float64(limit.Cur), nil
+}
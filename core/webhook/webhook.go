@@ -0,0 +1,254 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/requests"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+)
+
+const (
+	// EventSessionStarted is delivered once provider session is created.
+	EventSessionStarted = "session_started"
+	// EventSessionEnded is delivered once provider session is removed, it carries session totals.
+	EventSessionEnded = "session_ended"
+
+	// SignatureHeader carries hex encoded HMAC-SHA256 of the request body keyed by the webhook secret.
+	SignatureHeader = "X-Mysterium-Signature"
+
+	queueSize     = 100
+	deliveryTries = 3
+)
+
+// Payload is delivered to the webhook endpoint on provider session lifecycle events.
+type Payload struct {
+	Event           string    `json:"event"`
+	At              time.Time `json:"at"`
+	SessionID       string    `json:"session_id"`
+	ServiceID       string    `json:"service_id"`
+	ServiceType     string    `json:"service_type"`
+	ProviderID      string    `json:"provider_id"`
+	ConsumerID      string    `json:"consumer_id"`
+	ConsumerCountry string    `json:"consumer_country"`
+	StartedAt       time.Time `json:"started_at"`
+	// DurationSeconds is session duration, set for ended sessions only.
+	DurationSeconds uint64 `json:"duration_seconds,omitempty"`
+	// BytesSent is traffic sent to the consumer.
+	BytesSent uint64 `json:"bytes_sent"`
+	// BytesReceived is traffic received from the consumer.
+	BytesReceived uint64 `json:"bytes_received"`
+	// Tokens is the amount earned during the session in the smallest MYST units.
+	Tokens *big.Int `json:"tokens"`
+}
+
+// HTTPClient sends webhook requests.
+type HTTPClient interface {
+	DoRequest(req *http.Request) error
+}
+
+// Webhook posts provider session lifecycle events to the configured URL, so that providers
+// running fleets can feed them to their own billing and monitoring systems.
+// Deliveries are queued, so that event handlers are not blocked by a slow endpoint.
+type Webhook struct {
+	url        string
+	secret     string
+	client     HTTPClient
+	retryDelay time.Duration
+	now        func() time.Time
+
+	lock     sync.Mutex
+	sessions map[string]*Payload
+
+	queue    chan Payload
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewWebhook creates webhook delivering events to the given URL, requests are signed when secret is not empty.
+func NewWebhook(url, secret string, client HTTPClient) *Webhook {
+	return &Webhook{
+		url:        url,
+		secret:     secret,
+		client:     client,
+		retryDelay: 5 * time.Second,
+		now:        time.Now,
+		sessions:   make(map[string]*Payload),
+		queue:      make(chan Payload, queueSize),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to session, session traffic and earnings events.
+func (w *Webhook) Subscribe(bus eventbus.Subscriber) error {
+	if err := bus.SubscribeAsync(sevent.AppTopicSession, w.consumeSessionEvent); err != nil {
+		return err
+	}
+	if err := bus.SubscribeAsync(sevent.AppTopicDataTransferred, w.consumeDataTransferredEvent); err != nil {
+		return err
+	}
+	return bus.SubscribeAsync(sevent.AppTopicTokensEarned, w.consumeTokensEarnedEvent)
+}
+
+// Start starts delivering queued events.
+func (w *Webhook) Start() {
+	go w.deliverLoop()
+}
+
+// Stop delivers events which are already queued without retrying and stops the webhook.
+func (w *Webhook) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+func (w *Webhook) consumeSessionEvent(e sevent.AppEventSession) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	switch e.Status {
+	case sevent.CreatedStatus:
+		p := &Payload{
+			SessionID:       e.Session.ID,
+			ServiceID:       e.Service.ID,
+			ServiceType:     e.Session.Proposal.ServiceType,
+			ProviderID:      e.Session.Proposal.ProviderID,
+			ConsumerID:      e.Session.ConsumerID.Address,
+			ConsumerCountry: e.Session.ConsumerLocation.Country,
+			StartedAt:       e.Session.StartedAt.UTC(),
+			Tokens:          new(big.Int),
+		}
+		w.sessions[e.Session.ID] = p
+		w.enqueue(EventSessionStarted, *p)
+	case sevent.RemovedStatus:
+		p, ok := w.sessions[e.Session.ID]
+		if !ok {
+			return
+		}
+		delete(w.sessions, e.Session.ID)
+		if duration := w.now().Sub(p.StartedAt); duration > 0 {
+			p.DurationSeconds = uint64(duration.Seconds())
+		}
+		w.enqueue(EventSessionEnded, *p)
+	}
+}
+
+func (w *Webhook) consumeDataTransferredEvent(e sevent.AppEventDataTransferred) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if p, ok := w.sessions[e.ID]; ok {
+		// Traffic is reported from the consumer point of view.
+		p.BytesSent = e.Down
+		p.BytesReceived = e.Up
+	}
+}
+
+func (w *Webhook) consumeTokensEarnedEvent(e sevent.AppEventTokensEarned) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if p, ok := w.sessions[e.SessionID]; ok && e.Total != nil {
+		p.Tokens = new(big.Int).Set(e.Total)
+	}
+}
+
+func (w *Webhook) enqueue(event string, p Payload) {
+	p.Event = event
+	p.At = w.now().UTC()
+	select {
+	case w.queue <- p:
+	default:
+		log.Warn().Msgf("Webhook queue is full, dropping %s event of session %s", event, p.SessionID)
+	}
+}
+
+func (w *Webhook) deliverLoop() {
+	defer close(w.done)
+
+	for {
+		select {
+		case p := <-w.queue:
+			w.deliver(p, deliveryTries)
+		case <-w.stop:
+			for {
+				select {
+				case p := <-w.queue:
+					w.deliver(p, 1)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *Webhook) deliver(p Payload, tries int) {
+	for try := 1; ; try++ {
+		err := w.send(p)
+		if err == nil {
+			return
+		}
+		if try >= tries {
+			log.Error().Err(err).Msgf("Could not deliver webhook %s event of session %s", p.Event, p.SessionID)
+			return
+		}
+		log.Warn().Err(err).Msgf("Could not deliver webhook %s event of session %s, retrying", p.Event, p.SessionID)
+		select {
+		case <-time.After(w.retryDelay):
+		case <-w.stop:
+			tries = try + 1
+		}
+	}
+}
+
+func (w *Webhook) send(p Payload) error {
+	req, err := requests.NewPostRequest(w.url, "", p)
+	if err != nil {
+		return err
+	}
+	if w.secret != "" {
+		body, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(SignatureHeader, Sign(w.secret, body))
+	}
+	return w.client.DoRequest(req)
+}
+
+// Sign returns hex encoded HMAC-SHA256 of the webhook request body, endpoints should compare it with SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
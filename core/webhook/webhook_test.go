@@ -0,0 +1,155 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	sevent "github.com/mysteriumnetwork/node/session/event"
+)
+
+var (
+	startedAt = time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	session   = sevent.AppEventSession{
+		Service: sevent.ServiceContext{ID: "service1"},
+		Session: sevent.SessionContext{
+			ID:               "session1",
+			StartedAt:        startedAt,
+			ConsumerID:       identity.FromAddress("0xconsumer"),
+			ConsumerLocation: market.Location{Country: "LT"},
+			Proposal:         market.ServiceProposal{ProviderID: "0xprovider", ServiceType: "wireguard"},
+		},
+	}
+)
+
+type mockClient struct {
+	lock      sync.Mutex
+	fails     int
+	payloads  []Payload
+	signature string
+	body      []byte
+}
+
+func (m *mockClient) DoRequest(req *http.Request) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.fails > 0 {
+		m.fails--
+		return errors.New("endpoint unavailable")
+	}
+	m.body, _ = ioutil.ReadAll(req.Body)
+	m.signature = req.Header.Get(SignatureHeader)
+	var p Payload
+	if err := json.Unmarshal(m.body, &p); err != nil {
+		return err
+	}
+	m.payloads = append(m.payloads, p)
+	return nil
+}
+
+func (m *mockClient) Payloads() []Payload {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.payloads
+}
+
+func newTestWebhook(secret string, client HTTPClient) *Webhook {
+	w := NewWebhook("http://localhost/hook", secret, client)
+	w.retryDelay = time.Millisecond
+	w.now = func() time.Time { return startedAt.Add(90 * time.Second) }
+	return w
+}
+
+func TestWebhook_DeliversSessionLifecycle(t *testing.T) {
+	client := &mockClient{}
+	w := newTestWebhook("", client)
+	w.Start()
+
+	created := session
+	created.Status = sevent.CreatedStatus
+	w.consumeSessionEvent(created)
+	w.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "session1", Up: 100, Down: 2000})
+	w.consumeTokensEarnedEvent(sevent.AppEventTokensEarned{SessionID: "session1", Total: big.NewInt(500)})
+	removed := session
+	removed.Status = sevent.RemovedStatus
+	w.consumeSessionEvent(removed)
+	w.Stop()
+
+	payloads := client.Payloads()
+	assert.Len(t, payloads, 2)
+	assert.Equal(t, Payload{
+		Event:           EventSessionStarted,
+		At:              startedAt.Add(90 * time.Second),
+		SessionID:       "session1",
+		ServiceID:       "service1",
+		ServiceType:     "wireguard",
+		ProviderID:      "0xprovider",
+		ConsumerID:      "0xconsumer",
+		ConsumerCountry: "LT",
+		StartedAt:       startedAt,
+		Tokens:          big.NewInt(0),
+	}, payloads[0])
+	assert.Equal(t, EventSessionEnded, payloads[1].Event)
+	assert.Equal(t, uint64(90), payloads[1].DurationSeconds)
+	assert.Equal(t, uint64(2000), payloads[1].BytesSent)
+	assert.Equal(t, uint64(100), payloads[1].BytesReceived)
+	assert.Equal(t, big.NewInt(500), payloads[1].Tokens)
+	assert.Empty(t, client.signature)
+}
+
+func TestWebhook_IgnoresUnknownSessions(t *testing.T) {
+	client := &mockClient{}
+	w := newTestWebhook("", client)
+	w.Start()
+
+	removed := session
+	removed.Status = sevent.RemovedStatus
+	w.consumeDataTransferredEvent(sevent.AppEventDataTransferred{ID: "session1", Up: 100})
+	w.consumeSessionEvent(removed)
+	w.Stop()
+
+	assert.Empty(t, client.Payloads())
+}
+
+func TestWebhook_RetriesAndSignsDeliveries(t *testing.T) {
+	client := &mockClient{fails: 2}
+	w := newTestWebhook("secret", client)
+	w.Start()
+
+	created := session
+	created.Status = sevent.CreatedStatus
+	w.consumeSessionEvent(created)
+	assert.Eventually(t, func() bool {
+		return len(client.Payloads()) == 1
+	}, time.Second, time.Millisecond)
+	w.Stop()
+
+	assert.Equal(t, Sign("secret", client.body), client.signature)
+}
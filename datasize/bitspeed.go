@@ -17,6 +17,13 @@
 
 package datasize
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
 // BitSpeed represents the data speed in bits per second.
 type BitSpeed BitSize
 
@@ -24,3 +31,32 @@ type BitSpeed BitSize
 func (t BitSpeed) String() string {
 	return BitSize(t).String() + "s"
 }
+
+// bitSpeedUnits maps network speed units to their value, network speeds use decimal multiples.
+var bitSpeedUnits = map[string]BitSpeed{
+	"bps":  1,
+	"kbps": 1000,
+	"mbps": 1000 * 1000,
+	"gbps": 1000 * 1000 * 1000,
+}
+
+// ParseBitSpeed parses human readable speed, e.g. "10mbps" or "512kbps".
+func ParseBitSpeed(s string) (BitSpeed, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	if i <= 0 {
+		return 0, fmt.Errorf("invalid speed %q, expected value with unit, e.g. 10mbps", s)
+	}
+
+	unit, ok := bitSpeedUnits[strings.TrimSpace(s[i:])]
+	if !ok {
+		return 0, fmt.Errorf("invalid speed unit in %q, expected one of bps, kbps, mbps, gbps", s)
+	}
+	value, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid speed %q: %w", s, err)
+	}
+	return BitSpeed(value) * unit, nil
+}
@@ -29,11 +29,24 @@ import (
 // Exec executes given args
 var Exec = defaultExec
 
+// Exec6 executes given args for IPv6 packet filter
+var Exec6 = defaultExec6
+
 func defaultExec(args ...string) ([]string, error) {
-	args = append([]string{"sudo", "/usr/sbin/iptables"}, args...)
+	lines, err := execBinary("/usr/sbin/iptables", args...)
+	return lines, errors.Wrap(err, "iptables cmd error")
+}
+
+func defaultExec6(args ...string) ([]string, error) {
+	lines, err := execBinary("/usr/sbin/ip6tables", args...)
+	return lines, errors.Wrap(err, "ip6tables cmd error")
+}
+
+func execBinary(binary string, args ...string) ([]string, error) {
+	args = append([]string{"sudo", binary}, args...)
 	output, err := cmdutil.ExecOutput(args...)
 	if err != nil {
-		return nil, errors.Wrap(err, "iptables cmd error")
+		return nil, err
 	}
 
 	outputScanner := bufio.NewScanner(bytes.NewBufferString(output))
@@ -46,11 +59,20 @@ func defaultExec(args ...string) ([]string, error) {
 
 // AddRuleWithRemoval activates given rule
 func AddRuleWithRemoval(rule Rule) (func(), error) {
-	if _, err := Exec(rule.ApplyArgs()...); err != nil {
+	return addRuleWithRemoval(Exec, rule)
+}
+
+// AddRule6WithRemoval activates given rule for IPv6 packets
+func AddRule6WithRemoval(rule Rule) (func(), error) {
+	return addRuleWithRemoval(Exec6, rule)
+}
+
+func addRuleWithRemoval(exec func(args ...string) ([]string, error), rule Rule) (func(), error) {
+	if _, err := exec(rule.ApplyArgs()...); err != nil {
 		return nil, err
 	}
 	return func() {
-		_, err := Exec(rule.RemoveArgs()...)
+		_, err := exec(rule.RemoveArgs()...)
 		if err != nil {
 			log.Warn().Err(err).Msgf("Error executing rule: %v you might wanna do it yourself", rule.RemoveArgs())
 		}
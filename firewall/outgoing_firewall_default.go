@@ -38,6 +38,7 @@ type OutgoingTrafficFirewall interface {
 	BlockOutgoingTraffic(scope Scope, outboundIP string) (OutgoingRuleRemove, error)
 	AllowIPAccess(ip string) (OutgoingRuleRemove, error)
 	AllowURLAccess(rawURLs ...string) (OutgoingRuleRemove, error)
+	AllowPortAccess(protocol string, port int) (OutgoingRuleRemove, error)
 }
 
 // Scope type represents scope of blocking consumer traffic.
@@ -61,6 +62,11 @@ func AllowIPAccess(ip string) (OutgoingRuleRemove, error) {
 	return DefaultOutgoingFirewall.AllowIPAccess(ip)
 }
 
+// AllowPortAccess adds destination port based exception.
+func AllowPortAccess(protocol string, port int) (OutgoingRuleRemove, error) {
+	return DefaultOutgoingFirewall.AllowPortAccess(protocol, port)
+}
+
 // Reset firewall state - usually called when cleanup is needed (during shutdown).
 func Reset() {
 	DefaultOutgoingFirewall.Teardown()
@@ -18,7 +18,10 @@
 package firewall
 
 import (
+	"fmt"
+	"net"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -33,10 +36,17 @@ type refCount struct {
 	f     func()
 }
 
+type execFunc func(args ...string) ([]string, error)
+
+// outboundInterface returns name of the network interface having given IP address
+var outboundInterface = interfaceByIP
+
 type outgoingFirewallIptables struct {
 	lock             sync.Mutex
 	trafficLockScope Scope
 	referenceTracker map[string]refCount
+	// ipv6 tells if IPv6 traffic is blocked as well, host might have no IPv6 packet filter
+	ipv6 bool
 }
 
 // Setup tries to setup all changes made by setup and leave system in the state before setup.
@@ -44,17 +54,35 @@ func (obi *outgoingFirewallIptables) Setup() error {
 	if err := obi.checkIptablesVersion(); err != nil {
 		return err
 	}
-	if err := obi.cleanupStaleRules(); err != nil {
+	if err := obi.cleanupStaleRules(iptables.Exec); err != nil {
+		return err
+	}
+	if err := obi.setupKillSwitchChain(iptables.Exec); err != nil {
 		return err
 	}
-	return obi.setupKillSwitchChain()
+
+	if err := obi.cleanupStaleRules(iptables.Exec6); err != nil {
+		log.Warn().Err(err).Msg("Could not setup IPv6 kill switch, IPv6 traffic will not be blocked")
+		return nil
+	}
+	if err := obi.setupKillSwitchChain(iptables.Exec6); err != nil {
+		log.Warn().Err(err).Msg("Could not setup IPv6 kill switch, IPv6 traffic will not be blocked")
+		return nil
+	}
+	obi.ipv6 = true
+	return nil
 }
 
 // Teardown tries to cleanup all changes made by setup and leave system in the state before setup.
 func (obi *outgoingFirewallIptables) Teardown() {
-	if err := obi.cleanupStaleRules(); err != nil {
+	if err := obi.cleanupStaleRules(iptables.Exec); err != nil {
 		log.Warn().Err(err).Msg("Error cleaning up iptables rules, you might want to do it yourself")
 	}
+	if obi.ipv6 {
+		if err := obi.cleanupStaleRules(iptables.Exec6); err != nil {
+			log.Warn().Err(err).Msg("Error cleaning up ip6tables rules, you might want to do it yourself")
+		}
+	}
 }
 
 // BlockOutgoingTraffic effectively disallows any outgoing traffic from consumer node with specified scope.
@@ -66,18 +94,65 @@ func (obi *outgoingFirewallIptables) BlockOutgoingTraffic(scope Scope, outboundI
 	obi.trafficLockScope = scope
 	return obi.trackingReferenceCall("block-traffic", func() (OutgoingRuleRemove, error) {
 		// Take custom chain into effect for packets in OUTPUT
-		return iptables.AddRuleWithRemoval(
+		removeRule, err := iptables.AddRuleWithRemoval(
 			iptables.AppendTo("OUTPUT").RuleSpec("-s", outboundIP, "-j", killswitchChain),
 		)
+		if err != nil || !obi.ipv6 {
+			return removeRule, err
+		}
+
+		// IPv6 packets are matched by interface, since outbound IP is IPv4 address of the same interface.
+		iface, err := outboundInterface(outboundIP)
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not block outgoing IPv6 traffic")
+			return removeRule, nil
+		}
+		removeRule6, err := iptables.AddRule6WithRemoval(
+			iptables.AppendTo("OUTPUT").RuleSpec("-o", iface, "-j", killswitchChain),
+		)
+		if err != nil {
+			removeRule()
+			return nil, err
+		}
+		return func() {
+			removeRule()
+			removeRule6()
+		}, nil
 	})
 }
 
 // AllowIPAccess adds exception to blocked traffic for specified URL (host part is usually taken).
 func (obi *outgoingFirewallIptables) AllowIPAccess(ip string) (OutgoingRuleRemove, error) {
-	return obi.trackingReferenceCall("allow:"+ip, func() (rule OutgoingRuleRemove, e error) {
-		return iptables.AddRuleWithRemoval(
-			iptables.InsertAt(killswitchChain, 1).RuleSpec("-d", ip, "-j", "ACCEPT"),
-		)
+	return obi.trackingReferenceCall("allow:"+ip, func() (OutgoingRuleRemove, error) {
+		rule := iptables.InsertAt(killswitchChain, 1).RuleSpec("-d", ip, "-j", "ACCEPT")
+		if isIPv6(ip) {
+			if !obi.ipv6 {
+				return func() {}, nil
+			}
+			return iptables.AddRule6WithRemoval(rule)
+		}
+		return iptables.AddRuleWithRemoval(rule)
+	})
+}
+
+// AllowPortAccess adds exception to blocked traffic for specified destination port.
+func (obi *outgoingFirewallIptables) AllowPortAccess(protocol string, port int) (OutgoingRuleRemove, error) {
+	return obi.trackingReferenceCall(fmt.Sprintf("allow-port:%s/%d", protocol, port), func() (OutgoingRuleRemove, error) {
+		rule := iptables.InsertAt(killswitchChain, 1).RuleSpec("-p", protocol, "--dport", strconv.Itoa(port), "-j", "ACCEPT")
+		removeRule, err := iptables.AddRuleWithRemoval(rule)
+		if err != nil || !obi.ipv6 {
+			return removeRule, err
+		}
+
+		removeRule6, err := iptables.AddRule6WithRemoval(rule)
+		if err != nil {
+			removeRule()
+			return nil, err
+		}
+		return func() {
+			removeRule()
+			removeRule6()
+		}, nil
 	})
 }
 
@@ -117,31 +192,31 @@ func (obi *outgoingFirewallIptables) checkIptablesVersion() error {
 	return nil
 }
 
-func (obi *outgoingFirewallIptables) setupKillSwitchChain() error {
+func (obi *outgoingFirewallIptables) setupKillSwitchChain(exec execFunc) error {
 	// Add chain
-	if _, err := iptables.Exec("-N", killswitchChain); err != nil {
+	if _, err := exec("-N", killswitchChain); err != nil {
 		return err
 	}
 	// Append rule - by default all packets going to kill switch chain are rejected
-	if _, err := iptables.Exec("-A", killswitchChain, "-m", "conntrack", "--ctstate", "NEW", "-j", "REJECT"); err != nil {
+	if _, err := exec("-A", killswitchChain, "-m", "conntrack", "--ctstate", "NEW", "-j", "REJECT"); err != nil {
 		return err
 	}
 
 	// Insert rule - TODO for now always allow outgoing DNS traffic, BUT it should be exposed as separate firewall call
-	if _, err := iptables.Exec("-I", killswitchChain, "1", "-p", "udp", "--dport", "53", "-j", "ACCEPT"); err != nil {
+	if _, err := exec("-I", killswitchChain, "1", "-p", "udp", "--dport", "53", "-j", "ACCEPT"); err != nil {
 		return err
 	}
 	// Insert rule - TCP DNS is not so popular - but for the sake of humanity, lets allow it too
-	if _, err := iptables.Exec("-I", killswitchChain, "1", "-p", "tcp", "--dport", "53", "-j", "ACCEPT"); err != nil {
+	if _, err := exec("-I", killswitchChain, "1", "-p", "tcp", "--dport", "53", "-j", "ACCEPT"); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (obi *outgoingFirewallIptables) cleanupStaleRules() error {
+func (obi *outgoingFirewallIptables) cleanupStaleRules(exec execFunc) error {
 	// List rules
-	rules, err := iptables.Exec("-S", "OUTPUT")
+	rules, err := exec("-S", "OUTPUT")
 	if err != nil {
 		return err
 	}
@@ -150,29 +225,48 @@ func (obi *outgoingFirewallIptables) cleanupStaleRules() error {
 		if strings.HasSuffix(rule, killswitchChain) {
 			deleteRule := strings.Replace(rule, "-A", "-D", 1)
 			deleteRuleArgs := strings.Split(deleteRule, " ")
-			if _, err := iptables.Exec(deleteRuleArgs...); err != nil {
+			if _, err := exec(deleteRuleArgs...); err != nil {
 				return err
 			}
 		}
 	}
 
 	// List chain rules
-	if _, err := iptables.Exec("-L", killswitchChain); err != nil {
+	if _, err := exec("-L", killswitchChain); err != nil {
 		// error means no such chain - log error just in case and bail out
 		log.Info().Err(err).Msg("[setup] Got error while listing kill switch chain rules. Probably nothing to worry about")
 		return nil
 	}
 
 	// Remove chain rules
-	if _, err := iptables.Exec("-F", killswitchChain); err != nil {
+	if _, err := exec("-F", killswitchChain); err != nil {
 		return err
 	}
 
 	// Remove chain
-	_, err = iptables.Exec("-X", killswitchChain)
+	_, err = exec("-X", killswitchChain)
 	return err
 }
 
+func interfaceByIP(ip string) (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.String() == ip {
+				return iface.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no interface with IP %s", ip)
+}
+
 func (obi *outgoingFirewallIptables) trackingReferenceCall(ref string, actualCall func() (OutgoingRuleRemove, error)) (OutgoingRuleRemove, error) {
 	obi.lock.Lock()
 	defer obi.lock.Unlock()
@@ -208,3 +302,12 @@ func (obi *outgoingFirewallIptables) decreaseRefCall(ref string) OutgoingRuleRem
 }
 
 var _ OutgoingTrafficFirewall = &outgoingFirewallIptables{}
+
+// isIPv6 checks if given address or network is IPv6 one.
+func isIPv6(address string) bool {
+	ip := net.ParseIP(address)
+	if _, network, err := net.ParseCIDR(address); err == nil {
+		ip = network.IP
+	}
+	return ip != nil && ip.To4() == nil
+}
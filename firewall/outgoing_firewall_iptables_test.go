@@ -18,6 +18,7 @@
 package firewall
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/mysteriumnetwork/node/firewall/iptables"
@@ -123,6 +124,7 @@ func Test_outgoingFirewallIptables_SetupIsSuccessful(t *testing.T) {
 		},
 	}
 	iptables.Exec = mockedExec.Exec
+	iptables.Exec6 = mockedExec.Exec
 
 	fw := &outgoingFirewallIptables{
 		referenceTracker: make(map[string]refCount),
@@ -156,6 +158,7 @@ func Test_outgoingFirewallIptables_SetupIsSucessfulIfPreviousCleanupFailed(t *te
 		},
 	}
 	iptables.Exec = mockedExec.Exec
+	iptables.Exec6 = mockedExec.Exec
 
 	fw := &outgoingFirewallIptables{
 		referenceTracker: make(map[string]refCount),
@@ -220,3 +223,132 @@ func Test_outgoingFirewallIptables_AddsAllowedIP(t *testing.T) {
 	assert.True(t, mockedExec.VerifyCalledWithArgs("-D", killswitchChain, "-d", "2.2.2.2", "-j", "ACCEPT"))
 
 }
+
+func Test_outgoingFirewallIptables_AddsAllowedPort(t *testing.T) {
+	mockedExec := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	iptables.Exec = mockedExec.Exec
+
+	fw := &outgoingFirewallIptables{
+		referenceTracker: make(map[string]refCount),
+	}
+
+	removeRuleFunc, err := fw.AllowPortAccess("tcp", 22)
+	assert.NoError(t, err)
+	assert.True(t, mockedExec.VerifyCalledWithArgs("-I", killswitchChain, "1", "-p", "tcp", "--dport", "22", "-j", "ACCEPT"))
+
+	removeRuleFunc()
+	assert.True(t, mockedExec.VerifyCalledWithArgs("-D", killswitchChain, "-p", "tcp", "--dport", "22", "-j", "ACCEPT"))
+}
+
+func Test_outgoingFirewallIptables_SetupBlocksIPv6Traffic(t *testing.T) {
+	mockedExec := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	mockedExec6 := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	iptables.Exec = mockedExec.Exec
+	iptables.Exec6 = mockedExec6.Exec
+
+	fw := &outgoingFirewallIptables{
+		referenceTracker: make(map[string]refCount),
+	}
+	assert.NoError(t, fw.Setup())
+	assert.True(t, fw.ipv6)
+	assert.True(t, mockedExec6.VerifyCalledWithArgs("-N", killswitchChain))
+	assert.True(t, mockedExec6.VerifyCalledWithArgs("-A", killswitchChain, "-m", "conntrack", "--ctstate", "NEW", "-j", "REJECT"))
+}
+
+func Test_outgoingFirewallIptables_SetupIsSuccessfulWithoutIPv6(t *testing.T) {
+	mockedExec := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	mockedExec6 := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{
+			"-S OUTPUT": {err: errors.New("ip6tables: command not found")},
+		},
+	}
+	iptables.Exec = mockedExec.Exec
+	iptables.Exec6 = mockedExec6.Exec
+
+	fw := &outgoingFirewallIptables{
+		referenceTracker: make(map[string]refCount),
+	}
+	assert.NoError(t, fw.Setup())
+	assert.False(t, fw.ipv6)
+	assert.True(t, mockedExec.VerifyCalledWithArgs("-N", killswitchChain))
+	assert.False(t, mockedExec6.VerifyCalledWithArgs("-N", killswitchChain))
+}
+
+func Test_outgoingFirewallIptables_BlocksOutgoingIPv6Traffic(t *testing.T) {
+	mockedExec := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	mockedExec6 := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	iptables.Exec = mockedExec.Exec
+	iptables.Exec6 = mockedExec6.Exec
+	outboundInterface = func(ip string) (string, error) {
+		return "eth0", nil
+	}
+	defer func() { outboundInterface = interfaceByIP }()
+
+	fw := &outgoingFirewallIptables{
+		referenceTracker: make(map[string]refCount),
+		ipv6:             true,
+	}
+
+	removeRuleFunc, err := fw.BlockOutgoingTraffic(Session, "1.1.1.1")
+	assert.NoError(t, err)
+	assert.True(t, mockedExec.VerifyCalledWithArgs("-A", "OUTPUT", "-s", "1.1.1.1", "-j", killswitchChain))
+	assert.True(t, mockedExec6.VerifyCalledWithArgs("-A", "OUTPUT", "-o", "eth0", "-j", killswitchChain))
+
+	removeRuleFunc()
+	assert.True(t, mockedExec.VerifyCalledWithArgs("-D", "OUTPUT", "-s", "1.1.1.1", "-j", killswitchChain))
+	assert.True(t, mockedExec6.VerifyCalledWithArgs("-D", "OUTPUT", "-o", "eth0", "-j", killswitchChain))
+}
+
+func Test_outgoingFirewallIptables_AddsAllowedIPv6(t *testing.T) {
+	mockedExec := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	mockedExec6 := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	iptables.Exec = mockedExec.Exec
+	iptables.Exec6 = mockedExec6.Exec
+
+	fw := &outgoingFirewallIptables{
+		referenceTracker: make(map[string]refCount),
+		ipv6:             true,
+	}
+
+	_, err := fw.AllowIPAccess("2001:db8::1")
+	assert.NoError(t, err)
+	assert.True(t, mockedExec6.VerifyCalledWithArgs("-I", killswitchChain, "1", "-d", "2001:db8::1", "-j", "ACCEPT"))
+	assert.False(t, mockedExec.VerifyCalledWithArgs("-I", killswitchChain, "1", "-d", "2001:db8::1", "-j", "ACCEPT"))
+}
+
+func Test_outgoingFirewallIptables_AddsAllowedIPv6Network(t *testing.T) {
+	mockedExec := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	mockedExec6 := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	iptables.Exec = mockedExec.Exec
+	iptables.Exec6 = mockedExec6.Exec
+
+	fw := &outgoingFirewallIptables{
+		referenceTracker: make(map[string]refCount),
+		ipv6:             true,
+	}
+
+	_, err := fw.AllowIPAccess("::/0")
+	assert.NoError(t, err)
+	assert.True(t, mockedExec6.VerifyCalledWithArgs("-I", killswitchChain, "1", "-d", "::/0", "-j", "ACCEPT"))
+	assert.False(t, mockedExec.VerifyCalledWithArgs("-I", killswitchChain, "1", "-d", "::/0", "-j", "ACCEPT"))
+}
@@ -63,4 +63,12 @@ func (ofn *outgoingFirewallNoop) AllowURLAccess(rawURLs ...string) (OutgoingRule
 	}, nil
 }
 
+// AllowPortAccess logs port for which access was requested.
+func (ofn *outgoingFirewallNoop) AllowPortAccess(protocol string, port int) (OutgoingRuleRemove, error) {
+	log.Info().Msgf("Allow port %s/%d access", protocol, port)
+	return func() {
+		log.Info().Msgf("Rule for port: %s/%d removed", protocol, port)
+	}, nil
+}
+
 var _ OutgoingTrafficFirewall = &outgoingFirewallNoop{}
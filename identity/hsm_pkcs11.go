@@ -0,0 +1,306 @@
+// +build cgo,!android,!ios
+
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miekg/pkcs11"
+)
+
+// pkcs11Application marks HSM data objects created by the node.
+const pkcs11Application = "mysterium node"
+
+// pkcs11Token is a logged in session with the HSM token. PKCS#11 sessions can not be used concurrently.
+type pkcs11Token struct {
+	mu       sync.Mutex
+	ctx      *pkcs11.Ctx
+	session  pkcs11.SessionHandle
+	ecParams []byte
+}
+
+func openHSMToken(module, label, pin string) (hsmToken, error) {
+	ecParams, err := asn1.Marshal(secp256k1OID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		return nil, fmt.Errorf("could not load PKCS#11 module %s", module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("could not initialize PKCS#11 module: %w", err)
+	}
+
+	session, err := openTokenSession(ctx, label, pin)
+	if err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+
+	return &pkcs11Token{
+		ctx:      ctx,
+		session:  session,
+		ecParams: ecParams,
+	}, nil
+}
+
+func openTokenSession(ctx *pkcs11.Ctx, label, pin string) (pkcs11.SessionHandle, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("could not list HSM slots: %w", err)
+	}
+
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("could not get HSM token info: %w", err)
+		}
+		if label != "" && strings.TrimSpace(info.Label) != label {
+			continue
+		}
+
+		session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		if err != nil {
+			return 0, fmt.Errorf("could not open HSM session: %w", err)
+		}
+		if err := ctx.Login(session, pkcs11.CKU_USER, pin); err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+			ctx.CloseSession(session)
+			return 0, fmt.Errorf("could not log into HSM token: %w", err)
+		}
+		return session, nil
+	}
+	return 0, fmt.Errorf("HSM token %q not found", label)
+}
+
+func (t *pkcs11Token) PublicKeys() ([]*ecdsa.PublicKey, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	objects, err := t.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, t.ecParams),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*ecdsa.PublicKey, 0, len(objects))
+	for _, object := range objects {
+		pub, err := t.publicKey(object)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, pub)
+	}
+	return keys, nil
+}
+
+func (t *pkcs11Token) GenerateKey() (*ecdsa.PublicKey, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pubObject, privObject, err := t.ctx.GenerateKeyPair(
+		t.session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, t.ecParams),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := t.publicKey(pubObject)
+	if err != nil {
+		return nil, err
+	}
+
+	// Address is known only once the key is generated, it links both halves of the key pair.
+	attributes := t.keyAttributes(pub)
+	for _, object := range []pkcs11.ObjectHandle{pubObject, privObject} {
+		if err := t.ctx.SetAttributeValue(t.session, object, attributes); err != nil {
+			return nil, err
+		}
+	}
+	return pub, nil
+}
+
+func (t *pkcs11Token) ImportKey(priv *ecdsa.PrivateKey) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	point, err := marshalECPoint(&priv.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	privAttributes := append([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, t.ecParams),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, math.PaddedBigBytes(priv.D, 32)),
+	}, t.keyAttributes(&priv.PublicKey)...)
+	if _, err := t.ctx.CreateObject(t.session, privAttributes); err != nil {
+		return err
+	}
+
+	pubAttributes := append([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, t.ecParams),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, point),
+	}, t.keyAttributes(&priv.PublicKey)...)
+	_, err = t.ctx.CreateObject(t.session, pubAttributes)
+	return err
+}
+
+func (t *pkcs11Token) Sign(pub *ecdsa.PublicKey, hash []byte) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	objects, err := t.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, crypto.PubkeyToAddress(*pub).Bytes()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("private key of %s not found", crypto.PubkeyToAddress(*pub).Hex())
+	}
+
+	if err := t.ctx.SignInit(t.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, objects[0]); err != nil {
+		return nil, err
+	}
+	return t.ctx.Sign(t.session, hash)
+}
+
+func (t *pkcs11Token) Secret(pub *ecdsa.PublicKey) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, pkcs11Application),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, "encryption secret "+crypto.PubkeyToAddress(*pub).Hex()),
+	}
+	objects, err := t.findObjects(template)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) > 0 {
+		attributes, err := t.ctx.GetAttributeValue(t.session, objects[0], []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil)})
+		if err != nil {
+			return nil, err
+		}
+		return attributes[0].Value, nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	template = append(template,
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, secret),
+	)
+	if _, err := t.ctx.CreateObject(t.session, template); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (t *pkcs11Token) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	defer t.ctx.Destroy()
+	defer t.ctx.Finalize()
+
+	if err := t.ctx.Logout(t.session); err != nil {
+		return err
+	}
+	return t.ctx.CloseSession(t.session)
+}
+
+// keyAttributes identify both halves of the key pair by the address.
+func (t *pkcs11Token) keyAttributes(pub *ecdsa.PublicKey) []*pkcs11.Attribute {
+	addr := crypto.PubkeyToAddress(*pub)
+	return []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_ID, addr.Bytes()),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, "mysterium identity "+addr.Hex()),
+	}
+}
+
+func (t *pkcs11Token) publicKey(object pkcs11.ObjectHandle) (*ecdsa.PublicKey, error) {
+	attributes, err := t.ctx.GetAttributeValue(t.session, object, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil)})
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalECPoint(attributes[0].Value)
+}
+
+func (t *pkcs11Token) findObjects(template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	if err := t.ctx.FindObjectsInit(t.session, template); err != nil {
+		return nil, err
+	}
+	defer t.ctx.FindObjectsFinal(t.session)
+
+	var found []pkcs11.ObjectHandle
+	for {
+		objects, _, err := t.ctx.FindObjects(t.session, 100)
+		if err != nil {
+			return nil, err
+		}
+		if len(objects) == 0 {
+			return found, nil
+		}
+		found = append(found, objects...)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"io"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Keystore providers which can be selected in the node configuration.
+const (
+	// KeystoreProviderFilesystem keeps keys encrypted with passphrase in keystore directory.
+	KeystoreProviderFilesystem = "filesystem"
+	// KeystoreProviderMemory keeps keys in memory only, they are lost once node stops. Meant for tests.
+	KeystoreProviderMemory = "memory"
+	// KeystoreProviderPKCS11 keeps keys in HSM accessed through PKCS#11 module, keys never leave the HSM.
+	KeystoreProviderPKCS11 = "pkcs11"
+)
+
+// KeystoreProvider keeps identity keys, signs with them and encrypts data owned by identities.
+type KeystoreProvider interface {
+	Accounts() []accounts.Account
+	NewAccount(passphrase string) (accounts.Account, error)
+	ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (accounts.Account, error)
+	Archive(a accounts.Account) error
+	Find(a accounts.Account) (accounts.Account, error)
+	Unlock(a accounts.Account, passphrase string) error
+	// Update changes passphrase protecting the key of the account.
+	Update(a accounts.Account, passphrase, newPassphrase string) error
+	Lock(addr common.Address) error
+	// SignHash produces signature in the [R || S || V] format where V is 0 or 1.
+	SignHash(a accounts.Account, hash []byte) ([]byte, error)
+	Encrypt(addr common.Address, plaintext []byte) ([]byte, error)
+	Decrypt(addr common.Address, encrypted []byte) ([]byte, error)
+}
+
+func deriveKey(secret []byte) ([]byte, error) {
+	hashFunc := sha512.New
+	hkdfDerived := hkdf.New(hashFunc, secret, nil, nil)
+	key := make([]byte, 32)
+	_, err := io.ReadFull(hkdfDerived, key)
+	return key, err
+}
+
+func encryptWithKey(key, plaintext []byte) ([]byte, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decryptWithKey(key, encrypted []byte) ([]byte, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(encrypted) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, encrypted := encrypted[:nonceSize], encrypted[nonceSize:]
+	return gcm.Open(nil, nonce, encrypted, nil)
+}
@@ -18,15 +18,12 @@
 package identity
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/ecdsa"
-	"crypto/rand"
-	"crypto/sha512"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -34,12 +31,39 @@ import (
 	ethKs "github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
-	"golang.org/x/crypto/hkdf"
 )
 
+// keystoreArchiveDir is the subdirectory of keystore where key files of deleted identities are moved to.
+const keystoreArchiveDir = "archive"
+
+// ScryptParams returns scrypt N and p parameters used to encrypt new keys. Lightweight preset uses 4MB of memory
+// instead of 256MB, custom non zero n or p override the preset, e.g. to make unlock bearable on ARM routers.
+func ScryptParams(lightweight bool, n, p int) (int, int, error) {
+	presetN, presetP := ethKs.StandardScryptN, ethKs.StandardScryptP
+	if lightweight {
+		presetN, presetP = ethKs.LightScryptN, ethKs.LightScryptP
+	}
+	if n == 0 {
+		n = presetN
+	}
+	if p == 0 {
+		p = presetP
+	}
+
+	if n < 2 || n&(n-1) != 0 {
+		return 0, 0, fmt.Errorf("scrypt N must be a power of two greater than 1, got %d", n)
+	}
+	if p < 1 {
+		return 0, 0, fmt.Errorf("scrypt p must be positive, got %d", p)
+	}
+	return n, p, nil
+}
+
 type ethKeystore interface {
 	Accounts() []accounts.Account
 	NewAccount(passphrase string) (accounts.Account, error)
+	ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (accounts.Account, error)
+	Update(a accounts.Account, passphrase, newPassphrase string) error
 	Find(a accounts.Account) (accounts.Account, error)
 }
 
@@ -47,18 +71,172 @@ type ethKeystore interface {
 func NewKeystoreFilesystem(directory string, ks ethKeystore) *Keystore {
 	return &Keystore{
 		ethKeystore: ks,
+		directory:   directory,
 		loadKey:     loadStoredKey,
 		unlocked:    make(map[common.Address]*unlocked),
+		archived:    make(map[common.Address]struct{}),
 	}
 }
 
 // Keystore handles everything that's related to eth accounts.
 type Keystore struct {
 	ethKeystore
-	loadKey func(addr common.Address, filename, auth string) (*ethKs.Key, error)
+	directory string
+	loadKey   func(addr common.Address, filename, auth string) (*ethKs.Key, error)
 
 	unlocked map[common.Address]*unlocked // Currently unlocked account (decrypted private keys)
+	archived map[common.Address]struct{}  // Accounts moved to archive, hidden until keystore notices the key file is gone
 	mu       sync.RWMutex
+
+	external    ExternalSigner // Account which key is kept outside of the keystore
+	externalKey []byte         // Encryption key of the external account, derived once it is unlocked
+}
+
+// ExternalSigner signs with the key kept outside of the keystore, e.g. in a remote vault.
+type ExternalSigner interface {
+	Account() accounts.Account
+	// SignHash produces signature in the [R || S || V] format where V is 0 or 1.
+	SignHash(hash []byte) ([]byte, error)
+}
+
+// externalKeyMessage is signed by the external signer to derive encryption key of its account.
+// Signers have to sign deterministically (RFC 6979), so the same key is derived every time.
+var externalKeyMessage = crypto.Keccak256([]byte("mysterium node encryption key"))
+
+// UseExternalSigner makes account of the external signer available along with accounts kept in the filesystem.
+// Signing with this account is routed to the signer.
+func (ks *Keystore) UseExternalSigner(signer ExternalSigner) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.external = signer
+	ks.externalKey = nil
+}
+
+// Accounts returns accounts kept in the filesystem and account of the external signer if any.
+func (ks *Keystore) Accounts() []accounts.Account {
+	var list []accounts.Account
+	for _, a := range ks.ethKeystore.Accounts() {
+		if !ks.isArchived(a.Address) {
+			list = append(list, a)
+		}
+	}
+	if signer := ks.externalSigner(); signer != nil {
+		list = append(list, signer.Account())
+	}
+	return list
+}
+
+// Find resolves the given account into a unique entry in the keystore.
+func (ks *Keystore) Find(a accounts.Account) (accounts.Account, error) {
+	if ks.isExternalAccount(a.Address) {
+		return ks.externalSigner().Account(), nil
+	}
+	if ks.isArchived(a.Address) {
+		return accounts.Account{}, ethKs.ErrNoMatch
+	}
+	return ks.ethKeystore.Find(a)
+}
+
+// ImportECDSA stores the given key into the keystore encrypting it with the passphrase.
+func (ks *Keystore) ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (accounts.Account, error) {
+	ks.mu.Lock()
+	delete(ks.archived, crypto.PubkeyToAddress(priv.PublicKey))
+	ks.mu.Unlock()
+
+	return ks.ethKeystore.ImportECDSA(priv, passphrase)
+}
+
+// Update re-encrypts key file of the account with the new passphrase.
+// The new key file is written aside and renamed over the old one, so the key is never lost half-written.
+func (ks *Keystore) Update(a accounts.Account, passphrase, newPassphrase string) error {
+	if ks.isExternalAccount(a.Address) {
+		return errors.New("passphrase of identity kept outside of the keystore can not be changed")
+	}
+	if ks.isArchived(a.Address) {
+		return ethKs.ErrNoMatch
+	}
+	return ks.ethKeystore.Update(a, passphrase, newPassphrase)
+}
+
+// Archive locks the account and moves its key file into archive directory of the keystore,
+// so that the key can still be restored manually.
+func (ks *Keystore) Archive(a accounts.Account) error {
+	if ks.isExternalAccount(a.Address) {
+		return errors.New("identity kept outside of the keystore can not be archived")
+	}
+
+	a, err := ks.Find(a)
+	if err != nil {
+		return err
+	}
+
+	archive := filepath.Join(ks.directory, keystoreArchiveDir)
+	if err := os.MkdirAll(archive, 0700); err != nil {
+		return fmt.Errorf("could not create keystore archive: %w", err)
+	}
+	if err := os.Rename(a.URL.Path, filepath.Join(archive, filepath.Base(a.URL.Path))); err != nil {
+		return fmt.Errorf("could not archive key file: %w", err)
+	}
+
+	if err := ks.Lock(a.Address); err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.archived[a.Address] = struct{}{}
+	return nil
+}
+
+func (ks *Keystore) isArchived(addr common.Address) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	_, found := ks.archived[addr]
+	return found
+}
+
+func (ks *Keystore) externalSigner() ExternalSigner {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return ks.external
+}
+
+func (ks *Keystore) isExternalAccount(addr common.Address) bool {
+	signer := ks.externalSigner()
+	return signer != nil && signer.Account().Address == addr
+}
+
+// unlockExternal derives encryption key of the external account, the signer authorizes access to the key by itself.
+func (ks *Keystore) unlockExternal() error {
+	signer := ks.externalSigner()
+
+	ks.mu.RLock()
+	unlocked := ks.externalKey != nil
+	ks.mu.RUnlock()
+	if unlocked {
+		return nil
+	}
+
+	signature, err := signer.SignHash(externalKeyMessage)
+	if err != nil {
+		return fmt.Errorf("could not unlock external signer: %w", err)
+	}
+	key, err := deriveKey(signature)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.external == signer {
+		ks.externalKey = key
+	}
+	return nil
 }
 
 // Unlock unlocks the given account indefinitely.
@@ -86,6 +264,10 @@ func (ks *Keystore) Lock(addr common.Address) error {
 // shortens the active unlock timeout. If the address was previously unlocked
 // indefinitely the timeout is not altered.
 func (ks *Keystore) TimedUnlock(a accounts.Account, passphrase string, timeout time.Duration) error {
+	if ks.isExternalAccount(a.Address) {
+		return ks.unlockExternal()
+	}
+
 	a, key, err := ks.getDecryptedKey(a, passphrase)
 	if err != nil {
 		return err
@@ -145,74 +327,48 @@ func (ks *Keystore) expire(addr common.Address, u *unlocked, timeout time.Durati
 
 // Encrypt takes a derived key for the given address and encrypts the plaintext.
 func (ks *Keystore) Encrypt(addr common.Address, plaintext []byte) ([]byte, error) {
-	ks.mu.RLock()
-	defer ks.mu.RUnlock()
-
-	key, found := ks.unlocked[addr]
-	if !found {
-		return nil, ethKs.ErrLocked
-	}
-
-	keyDerived, err := key.deriveKey()
-	if err != nil {
-		return nil, err
-	}
-
-	c, err := aes.NewCipher(keyDerived)
+	keyDerived, err := ks.encryptionKey(addr)
 	if err != nil {
 		return nil, err
 	}
+	return encryptWithKey(keyDerived, plaintext)
+}
 
-	gcm, err := cipher.NewGCM(c)
+// Decrypt takes a derived key for the given address and decrypts the encrypted message.
+func (ks *Keystore) Decrypt(addr common.Address, encrypted []byte) ([]byte, error) {
+	keyDerived, err := ks.encryptionKey(addr)
 	if err != nil {
 		return nil, err
 	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
-	}
-
-	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+	return decryptWithKey(keyDerived, encrypted)
 }
 
-// Decrypt takes a derived key for the given address and decrypts the encrypted message.
-func (ks *Keystore) Decrypt(addr common.Address, encrypted []byte) ([]byte, error) {
+// encryptionKey returns key derived for encryption of data owned by the given unlocked account.
+func (ks *Keystore) encryptionKey(addr common.Address) ([]byte, error) {
 	ks.mu.RLock()
 	defer ks.mu.RUnlock()
 
+	if ks.external != nil && ks.external.Account().Address == addr {
+		if ks.externalKey == nil {
+			return nil, ethKs.ErrLocked
+		}
+		return ks.externalKey, nil
+	}
+
 	key, found := ks.unlocked[addr]
 	if !found {
 		return nil, ethKs.ErrLocked
 	}
-
-	keyDerived, err := key.deriveKey()
-	if err != nil {
-		return nil, err
-	}
-
-	c, err := aes.NewCipher(keyDerived)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(c)
-	if err != nil {
-		return nil, err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(encrypted) < nonceSize {
-		return nil, errors.New("ciphertext too short")
-	}
-
-	nonce, encrypted := encrypted[:nonceSize], encrypted[nonceSize:]
-	return gcm.Open(nil, nonce, encrypted, nil)
+	return key.deriveKey()
 }
 
 // SignHash calculates a ECDSA signature for the given hash. The produced
 // signature is in the [R || S || V] format where V is 0 or 1.
 func (ks *Keystore) SignHash(a accounts.Account, hash []byte) ([]byte, error) {
+	if ks.isExternalAccount(a.Address) {
+		return ks.externalSigner().SignHash(hash)
+	}
+
 	// Look up the key to sign with and abort if it cannot be found
 	ks.mu.RLock()
 	defer ks.mu.RUnlock()
@@ -239,11 +395,7 @@ type unlocked struct {
 }
 
 func (u *unlocked) deriveKey() ([]byte, error) {
-	hashFunc := sha512.New
-	hkdfDerived := hkdf.New(hashFunc, u.Key.PrivateKey.D.Bytes(), nil, nil)
-	key := make([]byte, 32)
-	_, err := io.ReadFull(hkdfDerived, key)
-	return key, err
+	return deriveKey(u.Key.PrivateKey.D.Bytes())
 }
 
 func loadStoredKey(addr common.Address, filename, auth string) (*ethKs.Key, error) {
@@ -18,6 +18,10 @@
 package identity
 
 import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/accounts"
@@ -75,6 +79,50 @@ func Test_DerivedEncryption(t *testing.T) {
 	})
 }
 
+func Test_Keystore_Archive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystoreArchiveTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := NewKeystoreFilesystem(dir, ethKs.NewKeyStore(dir, ethKs.LightScryptN, ethKs.LightScryptP))
+	account, err := ks.ImportECDSA(encryptionKey, "")
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Unlock(account, ""))
+
+	err = ks.Archive(account)
+	assert.NoError(t, err)
+
+	assert.Empty(t, ks.Accounts())
+	_, err = ks.Find(account)
+	assert.Equal(t, ethKs.ErrNoMatch, err)
+	_, err = ks.SignHash(account, crypto.Keccak256([]byte(secretMessage)))
+	assert.Equal(t, ethKs.ErrLocked, err)
+
+	archived, err := ioutil.ReadDir(filepath.Join(dir, keystoreArchiveDir))
+	assert.NoError(t, err)
+	assert.Len(t, archived, 1)
+}
+
+func Test_ScryptParams(t *testing.T) {
+	n, p, err := ScryptParams(true, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{ethKs.LightScryptN, ethKs.LightScryptP}, []int{n, p})
+
+	n, p, err = ScryptParams(false, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{ethKs.StandardScryptN, ethKs.StandardScryptP}, []int{n, p})
+
+	n, p, err = ScryptParams(false, 1<<14, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1 << 14, ethKs.StandardScryptP}, []int{n, p})
+
+	_, _, err = ScryptParams(true, 1000, 1)
+	assert.Error(t, err)
+
+	_, _, err = ScryptParams(true, 1<<10, -1)
+	assert.Error(t, err)
+}
+
 var result []byte
 
 func Benchmark_DerivedEncryption(b *testing.B) {
@@ -125,3 +173,12 @@ func (ekm *ethKeystoreMock) Find(a accounts.Account) (accounts.Account, error) {
 func (ekm *ethKeystoreMock) NewAccount(passphrase string) (accounts.Account, error) {
 	return accounts.Account{}, errors.New("not implemented yet")
 }
+
+func (ekm *ethKeystoreMock) Update(a accounts.Account, passphrase, newPassphrase string) error {
+	return nil
+}
+
+func (ekm *ethKeystoreMock) ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (accounts.Account, error) {
+	ekm.account = accounts.Account{Address: crypto.PubkeyToAddress(priv.PublicKey)}
+	return ekm.account, nil
+}
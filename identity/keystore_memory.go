@@ -0,0 +1,218 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/subtle"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	ethKs "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// keystoreMemoryScheme is the URL scheme of accounts kept in memory.
+const keystoreMemoryScheme = "memory"
+
+// KeystoreMemory keeps keys in memory only, e.g. for tests or short lived nodes.
+// Keys are still guarded by passphrases, so it behaves the same way as the filesystem keystore.
+type KeystoreMemory struct {
+	mu       sync.RWMutex
+	keys     map[common.Address]memoryKey
+	unlocked map[common.Address]*ecdsa.PrivateKey
+}
+
+type memoryKey struct {
+	key        *ecdsa.PrivateKey
+	passphrase string
+}
+
+// NewKeystoreMemory creates empty keystore keeping keys in memory.
+func NewKeystoreMemory() *KeystoreMemory {
+	return &KeystoreMemory{
+		keys:     make(map[common.Address]memoryKey),
+		unlocked: make(map[common.Address]*ecdsa.PrivateKey),
+	}
+}
+
+// Accounts returns accounts of all kept keys sorted by address.
+func (ks *KeystoreMemory) Accounts() []accounts.Account {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	list := make([]accounts.Account, 0, len(ks.keys))
+	for addr := range ks.keys {
+		list = append(list, memoryAccount(addr))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].URL.Cmp(list[j].URL) < 0
+	})
+	return list
+}
+
+// NewAccount generates a new key and keeps it protected by the passphrase.
+func (ks *KeystoreMemory) NewAccount(passphrase string) (accounts.Account, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	return ks.ImportECDSA(key, passphrase)
+}
+
+// ImportECDSA keeps a copy of the given key protected by the passphrase.
+func (ks *KeystoreMemory) ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (accounts.Account, error) {
+	key, err := crypto.ToECDSA(crypto.FromECDSA(priv))
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if _, found := ks.keys[addr]; found {
+		return memoryAccount(addr), ethKs.ErrAccountAlreadyExists
+	}
+	ks.keys[addr] = memoryKey{key: key, passphrase: passphrase}
+	return memoryAccount(addr), nil
+}
+
+// Archive forgets the key, there is nowhere to archive it to.
+func (ks *KeystoreMemory) Archive(a accounts.Account) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	kept, found := ks.keys[a.Address]
+	if !found {
+		return ethKs.ErrNoMatch
+	}
+	delete(ks.unlocked, a.Address)
+	delete(ks.keys, a.Address)
+	zeroKey(kept.key)
+	return nil
+}
+
+// Find resolves the given account into the kept one.
+func (ks *KeystoreMemory) Find(a accounts.Account) (accounts.Account, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if _, found := ks.keys[a.Address]; !found {
+		return accounts.Account{}, ethKs.ErrNoMatch
+	}
+	return memoryAccount(a.Address), nil
+}
+
+// Unlock unlocks the given account until it is locked again.
+func (ks *KeystoreMemory) Unlock(a accounts.Account, passphrase string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	kept, found := ks.keys[a.Address]
+	if !found {
+		return ethKs.ErrNoMatch
+	}
+	if subtle.ConstantTimeCompare([]byte(kept.passphrase), []byte(passphrase)) != 1 {
+		return ethKs.ErrDecrypt
+	}
+	ks.unlocked[a.Address] = kept.key
+	return nil
+}
+
+// Update changes passphrase protecting the key of the account.
+func (ks *KeystoreMemory) Update(a accounts.Account, passphrase, newPassphrase string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	kept, found := ks.keys[a.Address]
+	if !found {
+		return ethKs.ErrNoMatch
+	}
+	if subtle.ConstantTimeCompare([]byte(kept.passphrase), []byte(passphrase)) != 1 {
+		return ethKs.ErrDecrypt
+	}
+	kept.passphrase = newPassphrase
+	ks.keys[a.Address] = kept
+	return nil
+}
+
+// Lock locks the given account.
+func (ks *KeystoreMemory) Lock(addr common.Address) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	delete(ks.unlocked, addr)
+	return nil
+}
+
+// SignHash calculates a ECDSA signature for the given hash. The produced
+// signature is in the [R || S || V] format where V is 0 or 1.
+func (ks *KeystoreMemory) SignHash(a accounts.Account, hash []byte) ([]byte, error) {
+	key, err := ks.unlockedKey(a.Address)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sign(hash, key)
+}
+
+// Encrypt takes a derived key for the given address and encrypts the plaintext.
+func (ks *KeystoreMemory) Encrypt(addr common.Address, plaintext []byte) ([]byte, error) {
+	key, err := ks.unlockedKey(addr)
+	if err != nil {
+		return nil, err
+	}
+	derived, err := deriveKey(key.D.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return encryptWithKey(derived, plaintext)
+}
+
+// Decrypt takes a derived key for the given address and decrypts the encrypted message.
+func (ks *KeystoreMemory) Decrypt(addr common.Address, encrypted []byte) ([]byte, error) {
+	key, err := ks.unlockedKey(addr)
+	if err != nil {
+		return nil, err
+	}
+	derived, err := deriveKey(key.D.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return decryptWithKey(derived, encrypted)
+}
+
+func (ks *KeystoreMemory) unlockedKey(addr common.Address) (*ecdsa.PrivateKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, found := ks.unlocked[addr]
+	if !found {
+		return nil, ethKs.ErrLocked
+	}
+	return key, nil
+}
+
+func memoryAccount(addr common.Address) accounts.Account {
+	return accounts.Account{
+		Address: addr,
+		URL:     accounts.URL{Scheme: keystoreMemoryScheme, Path: addr.Hex()},
+	}
+}
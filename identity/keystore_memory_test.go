@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"testing"
+
+	ethKs "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KeystoreMemory(t *testing.T) {
+	ks := NewKeystoreMemory()
+
+	account, err := ks.ImportECDSA(encryptionKey, "pass")
+	assert.NoError(t, err)
+	assert.Equal(t, encryptionAddress, account.Address)
+
+	_, err = ks.ImportECDSA(encryptionKey, "pass")
+	assert.Equal(t, ethKs.ErrAccountAlreadyExists, err)
+
+	created, err := ks.NewAccount("")
+	assert.NoError(t, err)
+	assert.Len(t, ks.Accounts(), 2)
+
+	t.Run("Requires unlock with the right passphrase", func(t *testing.T) {
+		_, err := ks.SignHash(account, crypto.Keccak256([]byte(secretMessage)))
+		assert.Equal(t, ethKs.ErrLocked, err)
+
+		assert.Equal(t, ethKs.ErrDecrypt, ks.Unlock(account, "wrong"))
+		assert.NoError(t, ks.Unlock(account, "pass"))
+	})
+
+	t.Run("Signs with the kept key", func(t *testing.T) {
+		hash := crypto.Keccak256([]byte(secretMessage))
+		signature, err := ks.SignHash(account, hash)
+		assert.NoError(t, err)
+
+		expected, err := crypto.Sign(hash, encryptionKey)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, signature)
+	})
+
+	t.Run("Encrypts compatibly with the filesystem keystore", func(t *testing.T) {
+		fsKeystore := NewKeystoreFilesystem("", &ethKeystoreMock{account: encryptionAccount})
+		fsKeystore.loadKey = func(addr common.Address, filename, auth string) (*ethKs.Key, error) {
+			return &ethKs.Key{Address: addr, PrivateKey: encryptionKey}, nil
+		}
+		assert.NoError(t, fsKeystore.Unlock(encryptionAccount, ""))
+
+		encrypted, err := ks.Encrypt(encryptionAddress, []byte(secretMessage))
+		assert.NoError(t, err)
+
+		decrypted, err := fsKeystore.Decrypt(encryptionAddress, encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, secretMessage, string(decrypted))
+	})
+
+	t.Run("Forgets archived key", func(t *testing.T) {
+		assert.NoError(t, ks.Archive(created))
+
+		_, err := ks.Find(created)
+		assert.Equal(t, ethKs.ErrNoMatch, err)
+		assert.Len(t, ks.Accounts(), 1)
+	})
+
+	t.Run("Locks account", func(t *testing.T) {
+		assert.NoError(t, ks.Lock(encryptionAddress))
+
+		_, err := ks.Encrypt(encryptionAddress, []byte(secretMessage))
+		assert.Equal(t, ethKs.ErrLocked, err)
+	})
+}
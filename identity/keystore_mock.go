@@ -124,6 +124,34 @@ func (mk *mockKeystore) NewAccount(passphrase string) (accounts.Account, error)
 	}, nil
 }
 
+func (mk *mockKeystore) ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (accounts.Account, error) {
+	mk.lock.Lock()
+	defer mk.lock.Unlock()
+
+	address := crypto.PubkeyToAddress(priv.PublicKey)
+	if _, ok := mk.keys[address]; ok {
+		return accounts.Account{}, ethKs.ErrAccountAlreadyExists
+	}
+	mk.keys[address] = MockKey{
+		Pass:  passphrase,
+		PkHex: hex.EncodeToString(crypto.FromECDSA(priv)),
+	}
+	return accounts.Account{
+		Address: address,
+	}, nil
+}
+
+func (mk *mockKeystore) Archive(a accounts.Account) error {
+	mk.lock.Lock()
+	defer mk.lock.Unlock()
+
+	if _, ok := mk.keys[a.Address]; !ok {
+		return ethKs.ErrNoMatch
+	}
+	delete(mk.keys, a.Address)
+	return nil
+}
+
 func (mk *mockKeystore) Unlock(a accounts.Account, passphrase string) error {
 	mk.lock.Lock()
 	defer mk.lock.Unlock()
@@ -151,6 +179,22 @@ func (mk *mockKeystore) Unlock(a accounts.Account, passphrase string) error {
 	return ethKs.ErrNoMatch
 }
 
+func (mk *mockKeystore) Update(a accounts.Account, passphrase, newPassphrase string) error {
+	mk.lock.Lock()
+	defer mk.lock.Unlock()
+
+	v, ok := mk.keys[a.Address]
+	if !ok {
+		return ethKs.ErrNoMatch
+	}
+	if v.Pass != passphrase {
+		return ethKs.ErrDecrypt
+	}
+	v.Pass = newPassphrase
+	mk.keys[a.Address] = v
+	return nil
+}
+
 func (mk *mockKeystore) Lock(addr common.Address) error {
 	mk.lock.Lock()
 	defer mk.lock.Unlock()
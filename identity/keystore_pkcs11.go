@@ -0,0 +1,289 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	ethKs "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rs/zerolog/log"
+)
+
+// keystorePKCS11Scheme is the URL scheme of accounts kept in HSM.
+const keystorePKCS11Scheme = "pkcs11"
+
+var (
+	// secp256k1OID identifies the curve of Ethereum keys, its DER encoding is used as CKA_EC_PARAMS.
+	secp256k1OID = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// hsmToken is the subset of HSM operations needed by the keystore. Keys are referred to by their public part.
+type hsmToken interface {
+	PublicKeys() ([]*ecdsa.PublicKey, error)
+	GenerateKey() (*ecdsa.PublicKey, error)
+	ImportKey(priv *ecdsa.PrivateKey) error
+	// Sign signs the hash with the private key of the given public one, producing raw [R || S] signature.
+	Sign(pub *ecdsa.PublicKey, hash []byte) ([]byte, error)
+	// Secret returns secret kept in HSM along with the key, used to derive encryption key of the account.
+	// The secret is created on first use.
+	Secret(pub *ecdsa.PublicKey) ([]byte, error)
+	Close() error
+}
+
+// KeystorePKCS11 keeps keys in HSM accessed through PKCS#11 module. Keys are generated inside the HSM and never leave it.
+// Access to them is authorized by the token PIN, so account passphrases are ignored.
+type KeystorePKCS11 struct {
+	token hsmToken
+
+	mu       sync.RWMutex
+	unlocked map[common.Address][]byte // Encryption keys of unlocked accounts
+}
+
+// OpenKeystorePKCS11 loads PKCS#11 module of the HSM and logs into the token with the given label,
+// the first token found is used if label is empty.
+func OpenKeystorePKCS11(module, tokenLabel, pin string) (*KeystorePKCS11, error) {
+	token, err := openHSMToken(module, tokenLabel, pin)
+	if err != nil {
+		return nil, err
+	}
+	return newKeystorePKCS11(token), nil
+}
+
+func newKeystorePKCS11(token hsmToken) *KeystorePKCS11 {
+	return &KeystorePKCS11{
+		token:    token,
+		unlocked: make(map[common.Address][]byte),
+	}
+}
+
+// Close logs out of the HSM token.
+func (ks *KeystorePKCS11) Close() error {
+	return ks.token.Close()
+}
+
+// Accounts returns accounts of secp256k1 keys kept in the HSM.
+func (ks *KeystorePKCS11) Accounts() []accounts.Account {
+	keys, err := ks.token.PublicKeys()
+	if err != nil {
+		log.Error().Err(err).Msg("Could not list keys kept in HSM")
+		return nil
+	}
+
+	list := make([]accounts.Account, len(keys))
+	for i, pub := range keys {
+		list[i] = pkcs11Account(crypto.PubkeyToAddress(*pub))
+	}
+	return list
+}
+
+// NewAccount generates a new key inside the HSM.
+func (ks *KeystorePKCS11) NewAccount(_ string) (accounts.Account, error) {
+	pub, err := ks.token.GenerateKey()
+	if err != nil {
+		return accounts.Account{}, fmt.Errorf("could not generate key in HSM: %w", err)
+	}
+	return pkcs11Account(crypto.PubkeyToAddress(*pub)), nil
+}
+
+// ImportECDSA stores the given key into the HSM, it can not be extracted afterwards.
+func (ks *KeystorePKCS11) ImportECDSA(priv *ecdsa.PrivateKey, _ string) (accounts.Account, error) {
+	addr := crypto.PubkeyToAddress(priv.PublicKey)
+	if _, err := ks.publicKey(addr); err == nil {
+		return pkcs11Account(addr), ethKs.ErrAccountAlreadyExists
+	}
+
+	if err := ks.token.ImportKey(priv); err != nil {
+		return accounts.Account{}, fmt.Errorf("could not import key into HSM: %w", err)
+	}
+	return pkcs11Account(addr), nil
+}
+
+// Archive is not supported, keys have to be removed from the HSM with its own tools.
+func (ks *KeystorePKCS11) Archive(_ accounts.Account) error {
+	return errors.New("identity kept in HSM can not be archived, remove its key with HSM tools")
+}
+
+// Find resolves the given account into the one kept in the HSM.
+func (ks *KeystorePKCS11) Find(a accounts.Account) (accounts.Account, error) {
+	if _, err := ks.publicKey(a.Address); err != nil {
+		return accounts.Account{}, err
+	}
+	return pkcs11Account(a.Address), nil
+}
+
+// Unlock makes the account usable for signing and encryption, passphrase is ignored as HSM is unlocked with PIN.
+func (ks *KeystorePKCS11) Unlock(a accounts.Account, _ string) error {
+	pub, err := ks.publicKey(a.Address)
+	if err != nil {
+		return err
+	}
+
+	secret, err := ks.token.Secret(pub)
+	if err != nil {
+		return fmt.Errorf("could not get encryption secret from HSM: %w", err)
+	}
+	key, err := deriveKey(secret)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.unlocked[a.Address] = key
+	return nil
+}
+
+// Update is not supported, keys in the HSM are protected by the token PIN instead of passphrases.
+func (ks *KeystorePKCS11) Update(_ accounts.Account, _, _ string) error {
+	return errors.New("identity kept in HSM has no passphrase, change PIN of the HSM token instead")
+}
+
+// Lock locks the given account.
+func (ks *KeystorePKCS11) Lock(addr common.Address) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	delete(ks.unlocked, addr)
+	return nil
+}
+
+// SignHash calculates a ECDSA signature for the given hash in the HSM. The produced
+// signature is in the [R || S || V] format where V is 0 or 1.
+func (ks *KeystorePKCS11) SignHash(a accounts.Account, hash []byte) ([]byte, error) {
+	if _, err := ks.encryptionKey(a.Address); err != nil {
+		return nil, err
+	}
+
+	pub, err := ks.publicKey(a.Address)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := ks.token.Sign(pub, hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign in HSM: %w", err)
+	}
+	return toEthSignature(hash, signature, pub)
+}
+
+// Encrypt takes a derived key for the given address and encrypts the plaintext.
+func (ks *KeystorePKCS11) Encrypt(addr common.Address, plaintext []byte) ([]byte, error) {
+	key, err := ks.encryptionKey(addr)
+	if err != nil {
+		return nil, err
+	}
+	return encryptWithKey(key, plaintext)
+}
+
+// Decrypt takes a derived key for the given address and decrypts the encrypted message.
+func (ks *KeystorePKCS11) Decrypt(addr common.Address, encrypted []byte) ([]byte, error) {
+	key, err := ks.encryptionKey(addr)
+	if err != nil {
+		return nil, err
+	}
+	return decryptWithKey(key, encrypted)
+}
+
+func (ks *KeystorePKCS11) encryptionKey(addr common.Address) ([]byte, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, found := ks.unlocked[addr]
+	if !found {
+		return nil, ethKs.ErrLocked
+	}
+	return key, nil
+}
+
+func (ks *KeystorePKCS11) publicKey(addr common.Address) (*ecdsa.PublicKey, error) {
+	keys, err := ks.token.PublicKeys()
+	if err != nil {
+		return nil, fmt.Errorf("could not list keys kept in HSM: %w", err)
+	}
+	for _, pub := range keys {
+		if crypto.PubkeyToAddress(*pub) == addr {
+			return pub, nil
+		}
+	}
+	return nil, ethKs.ErrNoMatch
+}
+
+func pkcs11Account(addr common.Address) accounts.Account {
+	return accounts.Account{
+		Address: addr,
+		URL:     accounts.URL{Scheme: keystorePKCS11Scheme, Path: addr.Hex()},
+	}
+}
+
+// marshalECPoint encodes public key as CKA_EC_POINT value, DER octet string of the uncompressed point.
+func marshalECPoint(pub *ecdsa.PublicKey) ([]byte, error) {
+	return asn1.Marshal(crypto.FromECDSAPub(pub))
+}
+
+// unmarshalECPoint decodes CKA_EC_POINT value, some modules return the raw point instead of DER octet string.
+func unmarshalECPoint(point []byte) (*ecdsa.PublicKey, error) {
+	if len(point) != 65 {
+		var raw []byte
+		if _, err := asn1.Unmarshal(point, &raw); err != nil {
+			return nil, fmt.Errorf("invalid EC point: %w", err)
+		}
+		point = raw
+	}
+	return crypto.UnmarshalPubkey(point)
+}
+
+// toEthSignature converts raw [R || S] signature into [R || S || V] one accepted by Ethereum:
+// S is moved to the lower half of the curve order and V is found by recovering the public key.
+func toEthSignature(hash, signature []byte, pub *ecdsa.PublicKey) ([]byte, error) {
+	if len(signature) != 64 {
+		return nil, fmt.Errorf("unexpected HSM signature length %d", len(signature))
+	}
+
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s.Sub(secp256k1N, s)
+	}
+
+	sig := make([]byte, 65)
+	copy(sig[:32], math.PaddedBigBytes(r, 32))
+	copy(sig[32:64], math.PaddedBigBytes(s, 32))
+
+	expected := crypto.FromECDSAPub(pub)
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		recovered, err := crypto.Ecrecover(hash, sig)
+		if err == nil && bytes.Equal(recovered, expected) {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("HSM signature does not match the key")
+}
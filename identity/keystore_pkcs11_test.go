@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	ethKs "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KeystorePKCS11(t *testing.T) {
+	token := &fakeHSMToken{secrets: make(map[*ecdsa.PrivateKey][]byte)}
+	ks := newKeystorePKCS11(token)
+
+	account, err := ks.ImportECDSA(encryptionKey, "ignored")
+	assert.NoError(t, err)
+	assert.Equal(t, encryptionAddress, account.Address)
+
+	_, err = ks.ImportECDSA(encryptionKey, "ignored")
+	assert.Equal(t, ethKs.ErrAccountAlreadyExists, err)
+
+	created, err := ks.NewAccount("")
+	assert.NoError(t, err)
+	assert.Len(t, ks.Accounts(), 2)
+
+	_, err = ks.SignHash(account, crypto.Keccak256([]byte(secretMessage)))
+	assert.Equal(t, ethKs.ErrLocked, err)
+	assert.NoError(t, ks.Unlock(account, ""))
+
+	t.Run("Signs in Ethereum format", func(t *testing.T) {
+		// HSM signatures are not deterministic, so S ends up in the upper half of the curve order every now and then.
+		for i := 0; i < 20; i++ {
+			hash := crypto.Keccak256([]byte(secretMessage), []byte{byte(i)})
+			signature, err := ks.SignHash(account, hash)
+			assert.NoError(t, err)
+
+			pub, err := crypto.SigToPub(hash, signature)
+			assert.NoError(t, err)
+			assert.Equal(t, encryptionAddress, crypto.PubkeyToAddress(*pub))
+			r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:64])
+			assert.True(t, crypto.ValidateSignatureValues(signature[64], r, s, true), "signature S has to be in the lower half")
+		}
+	})
+
+	t.Run("Encrypts with key derived from HSM secret", func(t *testing.T) {
+		encrypted, err := ks.Encrypt(encryptionAddress, []byte(secretMessage))
+		assert.NoError(t, err)
+
+		assert.NoError(t, ks.Lock(encryptionAddress))
+		_, err = ks.Decrypt(encryptionAddress, encrypted)
+		assert.Equal(t, ethKs.ErrLocked, err)
+
+		assert.NoError(t, ks.Unlock(account, ""))
+		decrypted, err := ks.Decrypt(encryptionAddress, encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, secretMessage, string(decrypted))
+	})
+
+	t.Run("Refuses to archive", func(t *testing.T) {
+		assert.Error(t, ks.Archive(created))
+		assert.Len(t, ks.Accounts(), 2)
+	})
+}
+
+func Test_UnmarshalECPoint(t *testing.T) {
+	point, err := marshalECPoint(&encryptionKey.PublicKey)
+	assert.NoError(t, err)
+
+	pub, err := unmarshalECPoint(point)
+	assert.NoError(t, err)
+	assert.Equal(t, encryptionAddress, crypto.PubkeyToAddress(*pub))
+
+	pub, err = unmarshalECPoint(crypto.FromECDSAPub(&encryptionKey.PublicKey))
+	assert.NoError(t, err)
+	assert.Equal(t, encryptionAddress, crypto.PubkeyToAddress(*pub))
+}
+
+type fakeHSMToken struct {
+	keys    []*ecdsa.PrivateKey
+	secrets map[*ecdsa.PrivateKey][]byte
+}
+
+func (f *fakeHSMToken) PublicKeys() ([]*ecdsa.PublicKey, error) {
+	keys := make([]*ecdsa.PublicKey, len(f.keys))
+	for i, key := range f.keys {
+		keys[i] = &key.PublicKey
+	}
+	return keys, nil
+}
+
+func (f *fakeHSMToken) GenerateKey() (*ecdsa.PublicKey, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	f.keys = append(f.keys, key)
+	return &key.PublicKey, nil
+}
+
+func (f *fakeHSMToken) ImportKey(priv *ecdsa.PrivateKey) error {
+	f.keys = append(f.keys, priv)
+	return nil
+}
+
+func (f *fakeHSMToken) Sign(pub *ecdsa.PublicKey, hash []byte) ([]byte, error) {
+	key := f.find(pub)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash)
+	if err != nil {
+		return nil, err
+	}
+	return append(math.PaddedBigBytes(r, 32), math.PaddedBigBytes(s, 32)...), nil
+}
+
+func (f *fakeHSMToken) Secret(pub *ecdsa.PublicKey) ([]byte, error) {
+	key := f.find(pub)
+	if _, found := f.secrets[key]; !found {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		f.secrets[key] = secret
+	}
+	return f.secrets[key], nil
+}
+
+func (f *fakeHSMToken) Close() error {
+	return nil
+}
+
+func (f *fakeHSMToken) find(pub *ecdsa.PublicKey) *ecdsa.PrivateKey {
+	for _, key := range f.keys {
+		if key.PublicKey.X.Cmp(pub.X) == 0 && key.PublicKey.Y.Cmp(pub.Y) == 0 {
+			return key
+		}
+	}
+	return nil
+}
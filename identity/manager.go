@@ -21,6 +21,7 @@
 package identity
 
 import (
+	"crypto/ecdsa"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts"
@@ -35,6 +36,7 @@ import (
 const (
 	AppTopicIdentityUnlock  = "identity-unlocked"
 	AppTopicIdentityCreated = "identity-created"
+	AppTopicIdentityDeleted = "identity-deleted"
 )
 
 type identityManager struct {
@@ -48,8 +50,11 @@ type identityManager struct {
 type keystore interface {
 	Accounts() []accounts.Account
 	NewAccount(passphrase string) (accounts.Account, error)
+	ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (accounts.Account, error)
+	Archive(a accounts.Account) error
 	Find(a accounts.Account) (accounts.Account, error)
 	Unlock(a accounts.Account, passphrase string) error
+	Update(a accounts.Account, passphrase, newPassphrase string) error
 	SignHash(a accounts.Account, hash []byte) ([]byte, error)
 }
 
@@ -96,6 +101,39 @@ func (idm *identityManager) CreateNewIdentity(passphrase string) (identity Ident
 	return identity, nil
 }
 
+// CreateNewIdentityWithMnemonic creates identity with the key derived from a new BIP39 seed phrase.
+// The phrase is not stored anywhere, it is returned to be shown to the user once.
+func (idm *identityManager) CreateNewIdentityWithMnemonic(passphrase string) (identity Identity, mnemonic string, err error) {
+	mnemonic, err = NewMnemonic()
+	if err != nil {
+		return identity, "", err
+	}
+
+	identity, err = idm.ImportIdentityFromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return identity, "", err
+	}
+	return identity, mnemonic, nil
+}
+
+// ImportIdentityFromMnemonic restores identity from its BIP39 seed phrase and stores the key protected with the passphrase.
+func (idm *identityManager) ImportIdentityFromMnemonic(mnemonic, passphrase string) (identity Identity, err error) {
+	key, err := KeyFromMnemonic(mnemonic)
+	if err != nil {
+		return identity, err
+	}
+	defer zeroKey(key)
+
+	account, err := idm.keystoreManager.ImportECDSA(key, passphrase)
+	if err != nil {
+		return identity, err
+	}
+
+	identity = accountToIdentity(account)
+	idm.eventBus.Publish(AppTopicIdentityCreated, identity.Address)
+	return identity, nil
+}
+
 func (idm *identityManager) GetIdentities() []Identity {
 	accountList := idm.keystoreManager.Accounts()
 
@@ -121,6 +159,25 @@ func (idm *identityManager) HasIdentity(address string) bool {
 	return err == nil
 }
 
+// DeleteIdentity locks the identity and archives its key file, so that it is not listed anymore.
+func (idm *identityManager) DeleteIdentity(address string) error {
+	account, err := idm.findAccount(address)
+	if err != nil {
+		return err
+	}
+
+	idm.unlockedMu.Lock()
+	defer idm.unlockedMu.Unlock()
+
+	if err := idm.keystoreManager.Archive(account); err != nil {
+		return errors.Wrapf(err, "keystore failed to archive identity: %s", address)
+	}
+	delete(idm.unlocked, address)
+
+	idm.eventBus.Publish(AppTopicIdentityDeleted, address)
+	return nil
+}
+
 func (idm *identityManager) Unlock(address string, passphrase string) error {
 	idm.unlockedMu.Lock()
 	defer idm.unlockedMu.Unlock()
@@ -147,6 +204,20 @@ func (idm *identityManager) Unlock(address string, passphrase string) error {
 	return nil
 }
 
+// ChangePassphrase re-encrypts key of the identity with the new passphrase.
+func (idm *identityManager) ChangePassphrase(address, passphrase, newPassphrase string) error {
+	account, err := idm.findAccount(address)
+	if err != nil {
+		return err
+	}
+
+	if err := idm.keystoreManager.Update(account, passphrase, newPassphrase); err != nil {
+		return errors.Wrapf(err, "keystore failed to change passphrase of identity: %s", address)
+	}
+	log.Info().Msgf("Passphrase of identity %s changed", address)
+	return nil
+}
+
 func (idm *identityManager) findAccount(address string) (accounts.Account, error) {
 	account, err := idm.keystoreManager.Find(addressToAccount(address))
 	if err != nil {
@@ -17,7 +17,10 @@
 
 package identity
 
-import "github.com/pkg/errors"
+import (
+	ethKs "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/pkg/errors"
+)
 
 type idmFake struct {
 	LastUnlockAddress    string
@@ -45,6 +48,28 @@ func (fakeIdm *idmFake) MarkUnlockToFail() {
 func (fakeIdm *idmFake) CreateNewIdentity(_ string) (Identity, error) {
 	return fakeIdm.newIdentity, nil
 }
+func (fakeIdm *idmFake) CreateNewIdentityWithMnemonic(_ string) (Identity, string, error) {
+	return fakeIdm.newIdentity, "", nil
+}
+func (fakeIdm *idmFake) ImportIdentityFromMnemonic(mnemonic, _ string) (Identity, error) {
+	if _, err := KeyFromMnemonic(mnemonic); err != nil {
+		return Identity{}, err
+	}
+	return fakeIdm.newIdentity, nil
+}
+func (fakeIdm *idmFake) DeleteIdentity(address string) error {
+	var remaining []Identity
+	for _, fakeIdentity := range fakeIdm.existingIdentities {
+		if address != fakeIdentity.Address {
+			remaining = append(remaining, fakeIdentity)
+		}
+	}
+	if len(remaining) == len(fakeIdm.existingIdentities) {
+		return errors.New("Identity not found")
+	}
+	fakeIdm.existingIdentities = remaining
+	return nil
+}
 func (fakeIdm *idmFake) GetIdentities() []Identity {
 	return fakeIdm.existingIdentities
 }
@@ -60,6 +85,15 @@ func (fakeIdm *idmFake) HasIdentity(_ string) bool {
 	return true
 }
 
+func (fakeIdm *idmFake) ChangePassphrase(address, passphrase, _ string) error {
+	fakeIdm.LastUnlockAddress = address
+	fakeIdm.LastUnlockPassphrase = passphrase
+	if fakeIdm.unlockFails {
+		return errors.Wrap(ethKs.ErrDecrypt, "Passphrase change failed")
+	}
+	return nil
+}
+
 func (fakeIdm *idmFake) Unlock(address string, passphrase string) error {
 	fakeIdm.LastUnlockAddress = address
 	fakeIdm.LastUnlockPassphrase = passphrase
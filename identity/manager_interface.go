@@ -21,9 +21,13 @@ package identity
 // TODO this interface must decay into caller specific smaller interfaces
 type Manager interface {
 	CreateNewIdentity(passphrase string) (Identity, error)
+	CreateNewIdentityWithMnemonic(passphrase string) (Identity, string, error)
+	ImportIdentityFromMnemonic(mnemonic, passphrase string) (Identity, error)
+	DeleteIdentity(address string) error
 	GetIdentities() []Identity
 	GetIdentity(address string) (Identity, error)
 	HasIdentity(address string) bool
 	Unlock(address string, passphrase string) error
+	ChangePassphrase(address, passphrase, newPassphrase string) error
 	IsUnlocked(address string) bool
 }
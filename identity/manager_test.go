@@ -20,7 +20,9 @@ package identity
 import (
 	"testing"
 
+	ethKs "github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/mysteriumnetwork/node/eventbus"
@@ -68,4 +70,12 @@ func Test_IdentityManager(t *testing.T) {
 		assert.True(t, idm.HasIdentity(newID.Address))
 		assert.False(t, idm.HasIdentity("0x000000000000000000000000000000000000000B"))
 	})
+	t.Run("changes passphrase", func(t *testing.T) {
+		err := idm.ChangePassphrase(newID.Address, "wrong", "new")
+		assert.Equal(t, ethKs.ErrDecrypt, errors.Cause(err))
+
+		assert.NoError(t, idm.ChangePassphrase(newID.Address, "", "new"))
+		assert.Equal(t, ethKs.ErrDecrypt, errors.Cause(idm.Unlock(newID.Address, "")))
+		assert.NoError(t, idm.Unlock(newID.Address, "new"))
+	})
 }
@@ -0,0 +1,64 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KeyFromMnemonic(t *testing.T) {
+	// Address derived by Ethereum wallets from the BIP39 test mnemonic.
+	key, err := KeyFromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), crypto.PubkeyToAddress(key.PublicKey))
+
+	_, err = KeyFromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon")
+	assert.Equal(t, ErrInvalidMnemonic, err)
+}
+
+func Test_NewMnemonic(t *testing.T) {
+	mnemonic, err := NewMnemonic()
+	assert.NoError(t, err)
+	assert.Len(t, strings.Fields(mnemonic), 12)
+
+	_, err = KeyFromMnemonic(mnemonic)
+	assert.NoError(t, err)
+}
+
+func Test_IdentityManager_ImportsIdentityFromMnemonic(t *testing.T) {
+	manager := NewIdentityManager(NewMockKeystore(), eventbus.New())
+
+	id, mnemonic, err := manager.CreateNewIdentityWithMnemonic("pass")
+	assert.NoError(t, err)
+	assert.True(t, manager.HasIdentity(id.Address))
+
+	restored := NewIdentityManager(NewMockKeystore(), eventbus.New())
+	imported, err := restored.ImportIdentityFromMnemonic(mnemonic, "other")
+	assert.NoError(t, err)
+	assert.Equal(t, id, imported)
+	assert.NoError(t, restored.Unlock(imported.Address, "other"))
+
+	_, err = restored.ImportIdentityFromMnemonic(mnemonic, "other")
+	assert.Error(t, err)
+}
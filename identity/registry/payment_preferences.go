@@ -0,0 +1,154 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package registry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const paymentPreferencesBucket = "identity_payment_preferences"
+
+// PaymentPreferences holds hermes the identity pays and settles with by default.
+// Zero values mean node-wide defaults are used.
+type PaymentPreferences struct {
+	Identity  identity.Identity `storm:"id"`
+	HermesID  common.Address
+	UpdatedAt time.Time
+}
+
+// PaymentPreferencesStorage allows for storing of identity payment preferences.
+type PaymentPreferencesStorage struct {
+	lock sync.Mutex
+	bolt persistentStorage
+}
+
+// NewPaymentPreferencesStorage returns a new instance of the payment preferences storage.
+func NewPaymentPreferencesStorage(bolt persistentStorage) *PaymentPreferencesStorage {
+	return &PaymentPreferencesStorage{
+		bolt: bolt,
+	}
+}
+
+// Store stores payment preferences of the identity, overriding previous ones.
+func (pps *PaymentPreferencesStorage) Store(preferences PaymentPreferences) error {
+	pps.lock.Lock()
+	defer pps.lock.Unlock()
+
+	preferences.UpdatedAt = time.Now().UTC()
+	return errors.Wrap(pps.bolt.Store(paymentPreferencesBucket, &preferences), "could not store payment preferences")
+}
+
+// Get fetches payment preferences of the given identity.
+func (pps *PaymentPreferencesStorage) Get(identity identity.Identity) (PaymentPreferences, error) {
+	pps.lock.Lock()
+	defer pps.lock.Unlock()
+
+	result := &PaymentPreferences{}
+	err := pps.bolt.GetOneByField(paymentPreferencesBucket, "Identity", identity, result)
+	if err != nil {
+		if err.Error() == errBoltNotFound {
+			err = ErrNotFound
+		} else {
+			err = errors.Wrap(err, "could not get payment preferences")
+		}
+	}
+	return *result, err
+}
+
+// Delete removes payment preferences of the given identity.
+func (pps *PaymentPreferencesStorage) Delete(identity identity.Identity) error {
+	pps.lock.Lock()
+	defer pps.lock.Unlock()
+
+	err := pps.bolt.Delete(paymentPreferencesBucket, &PaymentPreferences{Identity: identity})
+	if err != nil && err.Error() != errBoltNotFound {
+		return errors.Wrap(err, "could not delete payment preferences")
+	}
+	return nil
+}
+
+type paymentPreferencesStorage interface {
+	Store(preferences PaymentPreferences) error
+	Get(identity identity.Identity) (PaymentPreferences, error)
+	Delete(identity identity.Identity) error
+}
+
+// PaymentDefaults resolves hermes of identities used for consumer payments, provider invoices and settlement,
+// preferring the one stored for identity over node-wide default.
+type PaymentDefaults struct {
+	storage  paymentPreferencesStorage
+	hermesID common.Address
+}
+
+// NewPaymentDefaults returns payment defaults resolver falling back to given hermes.
+func NewPaymentDefaults(storage paymentPreferencesStorage, hermesID common.Address) *PaymentDefaults {
+	return &PaymentDefaults{
+		storage:  storage,
+		hermesID: hermesID,
+	}
+}
+
+// Subscribe purges preferences of deleted identities.
+func (pd *PaymentDefaults) Subscribe(eb eventbus.Subscriber) error {
+	return eb.SubscribeAsync(identity.AppTopicIdentityDeleted, pd.handleIdentityDeleted)
+}
+
+func (pd *PaymentDefaults) handleIdentityDeleted(address string) {
+	if err := pd.Reset(identity.FromAddress(address)); err != nil {
+		log.Error().Err(err).Msgf("Could not purge payment preferences of deleted identity %s", address)
+	}
+}
+
+// Get returns effective payment preferences of the identity.
+func (pd *PaymentDefaults) Get(id identity.Identity) PaymentPreferences {
+	preferences, err := pd.storage.Get(id)
+	if err != nil && err != ErrNotFound {
+		log.Warn().Err(err).Msgf("Could not get payment preferences of %s, using defaults", id.Address)
+	}
+
+	preferences.Identity = id
+	if preferences.HermesID == (common.Address{}) {
+		preferences.HermesID = pd.hermesID
+	}
+	return preferences
+}
+
+// HermesID returns hermes the identity pays and settles with.
+func (pd *PaymentDefaults) HermesID(id identity.Identity) common.Address {
+	return pd.Get(id).HermesID
+}
+
+// Set stores preferred hermes of the identity, zero value resets it to node-wide default.
+func (pd *PaymentDefaults) Set(id identity.Identity, hermesID common.Address) error {
+	return pd.storage.Store(PaymentPreferences{
+		Identity: id,
+		HermesID: hermesID,
+	})
+}
+
+// Reset removes stored preferences of the identity, so node-wide defaults are used.
+func (pd *PaymentDefaults) Reset(id identity.Identity) error {
+	return pd.storage.Delete(id)
+}
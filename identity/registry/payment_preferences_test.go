@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package registry
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentDefaults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paymentPreferencesTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	defer bolt.Close()
+	storage := NewPaymentPreferencesStorage(bolt)
+
+	defaultHermes := common.HexToAddress("0x1")
+	customHermes := common.HexToAddress("0x2")
+	id := identity.FromAddress("0x001")
+	defaults := NewPaymentDefaults(storage, defaultHermes)
+
+	_, err = storage.Get(id)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, defaultHermes, defaults.HermesID(id))
+
+	assert.NoError(t, defaults.Set(id, customHermes))
+	assert.Equal(t, customHermes, defaults.HermesID(id))
+
+	other := identity.FromAddress("0x002")
+	assert.Equal(t, defaultHermes, defaults.HermesID(other))
+
+	assert.NoError(t, defaults.Set(id, common.Address{}))
+	assert.Equal(t, defaultHermes, defaults.HermesID(id))
+
+	assert.NoError(t, defaults.Set(id, customHermes))
+	assert.NoError(t, defaults.Reset(id))
+	_, err = storage.Get(id)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, defaultHermes, defaults.HermesID(id))
+	assert.NoError(t, defaults.Reset(id))
+}
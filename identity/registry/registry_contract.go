@@ -38,6 +38,7 @@ type registryStorage interface {
 	Store(status StoredRegistrationStatus) error
 	Get(identity identity.Identity) (StoredRegistrationStatus, error)
 	GetAll() ([]StoredRegistrationStatus, error)
+	Delete(identity identity.Identity) error
 }
 
 type contractRegistry struct {
@@ -74,9 +75,22 @@ func (registry *contractRegistry) Subscribe(eb eventbus.Subscriber) error {
 	if err != nil {
 		return err
 	}
+	err = eb.SubscribeAsync(identity.AppTopicIdentityDeleted, registry.handleIdentityDeleted)
+	if err != nil {
+		return err
+	}
 	return eb.Subscribe(AppTopicTransactorRegistration, registry.handleRegistrationEvent)
 }
 
+func (registry *contractRegistry) handleIdentityDeleted(address string) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	if err := registry.storage.Delete(identity.FromAddress(address)); err != nil {
+		log.Error().Err(err).Msgf("Could not purge registration status of deleted identity %s", address)
+	}
+}
+
 // GetRegistrationStatus returns the registration status of the provided identity
 func (registry *contractRegistry) GetRegistrationStatus(id identity.Identity) (RegistrationStatus, error) {
 	status, err := registry.storage.Get(id)
@@ -0,0 +1,260 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package registry
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/node/event"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// AppTopicRegistrationRetry represents the registration retry event topic.
+const AppTopicRegistrationRetry = "registration_retry_topic"
+
+// AppEventRegistrationRetry is published after each registration re-submission attempt.
+type AppEventRegistrationRetry struct {
+	ID          identity.Identity
+	Attempt     int
+	MaxAttempts int
+	// Error is set if re-submission failed.
+	Error string
+	// NextRetryIn is the delay until the next attempt after failed re-submission, zero if there will be no more attempts.
+	NextRetryIn time.Duration
+}
+
+type registrationSubmitter interface {
+	RegisterIdentity(id string, stake, fee *big.Int, beneficiary string, referralToken *string) error
+	RegisterIdentitySponsored(id, beneficiary, sponsor string) error
+}
+
+type retrierStorage interface {
+	Get(identity identity.Identity) (StoredRegistrationStatus, error)
+	GetAll() ([]StoredRegistrationStatus, error)
+}
+
+// RegistrationRetrierConfig configures backoff of registration re-submissions.
+type RegistrationRetrierConfig struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	// StuckTimeout is how long registration may stay in progress before it is submitted again.
+	StuckTimeout time.Duration
+}
+
+// RegistrationRetrier re-submits identity registrations which failed or got stuck in progress,
+// waiting exponentially longer between attempts.
+type RegistrationRetrier struct {
+	submitter registrationSubmitter
+	checker   registrationStatusChecker
+	storage   retrierStorage
+	publisher eventbus.Publisher
+	cfg       RegistrationRetrierConfig
+
+	mu       sync.Mutex
+	retries  map[identity.Identity]*registrationRetry
+	stopped  bool
+	stopOnce sync.Once
+}
+
+type registrationRetry struct {
+	attempts int
+	timer    *time.Timer
+}
+
+// NewRegistrationRetrier creates a new registration retrier.
+func NewRegistrationRetrier(submitter registrationSubmitter, checker registrationStatusChecker, storage retrierStorage, publisher eventbus.Publisher, cfg RegistrationRetrierConfig) *RegistrationRetrier {
+	return &RegistrationRetrier{
+		submitter: submitter,
+		checker:   checker,
+		storage:   storage,
+		publisher: publisher,
+		cfg:       cfg,
+		retries:   make(map[identity.Identity]*registrationRetry),
+	}
+}
+
+// Subscribe subscribes the retrier to node and registration status events.
+func (r *RegistrationRetrier) Subscribe(eb eventbus.Subscriber) error {
+	if err := eb.SubscribeAsync(event.AppTopicNode, r.handleNodeEvent); err != nil {
+		return errors.Wrap(err, "could not subscribe to node events")
+	}
+	return eb.SubscribeAsync(AppTopicIdentityRegistration, r.handleRegistrationEvent)
+}
+
+func (r *RegistrationRetrier) handleNodeEvent(ev event.Payload) {
+	switch ev.Status {
+	case event.StatusStarted:
+		r.resumePending()
+	case event.StatusStopped:
+		r.stop()
+	}
+}
+
+// resumePending picks up registrations left failed or in progress by the previous run.
+func (r *RegistrationRetrier) resumePending() {
+	entries, err := r.storage.GetAll()
+	if err != nil && err != ErrNotFound {
+		log.Error().Err(err).Msg("Could not load pending registrations")
+		return
+	}
+	for _, entry := range entries {
+		r.handleRegistrationEvent(AppEventIdentityRegistration{ID: entry.Identity, Status: entry.RegistrationStatus})
+	}
+}
+
+func (r *RegistrationRetrier) handleRegistrationEvent(ev AppEventIdentityRegistration) {
+	if r.cfg.MaxAttempts <= 0 {
+		return
+	}
+
+	switch ev.Status {
+	case Registered:
+		r.forget(ev.ID)
+	case RegistrationError:
+		r.schedule(ev.ID, r.backoff(r.attempts(ev.ID)))
+	case InProgress:
+		r.schedule(ev.ID, r.cfg.StuckTimeout)
+	}
+}
+
+func (r *RegistrationRetrier) attempts(id identity.Identity) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if retry, ok := r.retries[id]; ok {
+		return retry.attempts
+	}
+	return 0
+}
+
+// backoff doubles the delay with every attempt up to the maximum one.
+func (r *RegistrationRetrier) backoff(attempts int) time.Duration {
+	delay := r.cfg.InitialDelay
+	for i := 0; i < attempts && delay < r.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.cfg.MaxDelay {
+		delay = r.cfg.MaxDelay
+	}
+	return delay
+}
+
+func (r *RegistrationRetrier) schedule(id identity.Identity, delay time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return
+	}
+
+	retry, ok := r.retries[id]
+	if !ok {
+		retry = &registrationRetry{}
+		r.retries[id] = retry
+	}
+	if retry.timer != nil {
+		retry.timer.Stop()
+	}
+	retry.timer = time.AfterFunc(delay, func() { r.retry(id) })
+}
+
+func (r *RegistrationRetrier) forget(id identity.Identity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if retry, ok := r.retries[id]; ok {
+		if retry.timer != nil {
+			retry.timer.Stop()
+		}
+		delete(r.retries, id)
+	}
+}
+
+func (r *RegistrationRetrier) retry(id identity.Identity) {
+	status, err := r.checker.GetRegistrationStatus(id)
+	if err == nil && (status == Registered || status == Unregistered) {
+		r.forget(id)
+		return
+	}
+
+	r.mu.Lock()
+	retry, ok := r.retries[id]
+	if !ok || r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	retry.attempts++
+	attempt := retry.attempts
+	r.mu.Unlock()
+
+	if attempt > r.cfg.MaxAttempts {
+		log.Warn().Msgf("Giving up registration of %s after %d attempts", id.Address, r.cfg.MaxAttempts)
+		r.forget(id)
+		return
+	}
+
+	log.Info().Msgf("Re-submitting registration of %s, attempt %d of %d", id.Address, attempt, r.cfg.MaxAttempts)
+	progress := AppEventRegistrationRetry{ID: id, Attempt: attempt, MaxAttempts: r.cfg.MaxAttempts}
+	if err := r.resubmit(id); err != nil {
+		log.Error().Err(err).Msgf("Registration re-submission of %s failed", id.Address)
+		progress.Error = err.Error()
+		if attempt < r.cfg.MaxAttempts {
+			progress.NextRetryIn = r.backoff(attempt)
+			r.schedule(id, progress.NextRetryIn)
+		} else {
+			r.forget(id)
+		}
+	}
+	r.publisher.Publish(AppTopicRegistrationRetry, progress)
+}
+
+// resubmit registers identity again with the stake and beneficiary of the original request, fee is quoted anew.
+// Sponsored registrations are submitted to the same sponsor again.
+func (r *RegistrationRetrier) resubmit(id identity.Identity) error {
+	var stake *big.Int
+	var beneficiary string
+	if stored, err := r.storage.Get(id); err == nil {
+		if stored.RegistrationRequest.Sponsored {
+			return r.submitter.RegisterIdentitySponsored(id.Address, stored.RegistrationRequest.Beneficiary, stored.RegistrationRequest.Sponsor)
+		}
+		stake = stored.RegistrationRequest.Stake
+		beneficiary = stored.RegistrationRequest.Beneficiary
+	}
+	return r.submitter.RegisterIdentity(id.Address, stake, nil, beneficiary, nil)
+}
+
+func (r *RegistrationRetrier) stop() {
+	r.stopOnce.Do(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.stopped = true
+		for id, retry := range r.retries {
+			if retry.timer != nil {
+				retry.timer.Stop()
+			}
+			delete(r.retries, id)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package registry
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+type submitterFake struct {
+	mu          sync.Mutex
+	submissions []string
+	stakes      []*big.Int
+	sponsors    []string
+	err         error
+}
+
+func (s *submitterFake) RegisterIdentity(id string, stake, fee *big.Int, beneficiary string, referralToken *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.submissions = append(s.submissions, id)
+	s.stakes = append(s.stakes, stake)
+	return s.err
+}
+
+func (s *submitterFake) RegisterIdentitySponsored(id, beneficiary, sponsor string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.submissions = append(s.submissions, id)
+	s.sponsors = append(s.sponsors, sponsor)
+	return s.err
+}
+
+func (s *submitterFake) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.submissions)
+}
+
+type retrierStorageFake struct {
+	entries []StoredRegistrationStatus
+}
+
+func (s *retrierStorageFake) Get(id identity.Identity) (StoredRegistrationStatus, error) {
+	for _, entry := range s.entries {
+		if entry.Identity == id {
+			return entry, nil
+		}
+	}
+	return StoredRegistrationStatus{}, ErrNotFound
+}
+
+func (s *retrierStorageFake) GetAll() ([]StoredRegistrationStatus, error) {
+	return s.entries, nil
+}
+
+type retryPublisherFake struct {
+	mu     sync.Mutex
+	events []AppEventRegistrationRetry
+}
+
+func (p *retryPublisherFake) Publish(topic string, ev interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e, ok := ev.(AppEventRegistrationRetry); ok {
+		p.events = append(p.events, e)
+	}
+}
+
+func (p *retryPublisherFake) last() AppEventRegistrationRetry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.events[len(p.events)-1]
+}
+
+var retrierTestConfig = RegistrationRetrierConfig{
+	MaxAttempts:  3,
+	InitialDelay: time.Millisecond,
+	MaxDelay:     4 * time.Millisecond,
+	StuckTimeout: 5 * time.Millisecond,
+}
+
+func Test_RegistrationRetrier_Backoff(t *testing.T) {
+	r := NewRegistrationRetrier(nil, nil, nil, nil, RegistrationRetrierConfig{InitialDelay: time.Minute, MaxDelay: 10 * time.Minute})
+
+	assert.Equal(t, time.Minute, r.backoff(0))
+	assert.Equal(t, 2*time.Minute, r.backoff(1))
+	assert.Equal(t, 8*time.Minute, r.backoff(3))
+	assert.Equal(t, 10*time.Minute, r.backoff(4))
+	assert.Equal(t, 10*time.Minute, r.backoff(100))
+}
+
+func Test_RegistrationRetrier_ResubmitsFailedRegistration(t *testing.T) {
+	id := identity.FromAddress("0x1")
+	stake := big.NewInt(10)
+	storage := &retrierStorageFake{entries: []StoredRegistrationStatus{
+		{Identity: id, RegistrationStatus: RegistrationError, RegistrationRequest: IdentityRegistrationRequest{Stake: stake}},
+	}}
+	submitter := &submitterFake{err: errors.New("transactor is down")}
+	publisher := &retryPublisherFake{}
+	r := NewRegistrationRetrier(submitter, &mockRegistrationStatusProvider{status: RegistrationError}, storage, publisher, retrierTestConfig)
+	defer r.stop()
+
+	r.resumePending()
+
+	assert.Eventually(t, func() bool { return submitter.count() == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, stake, submitter.stakes[0])
+	assert.Equal(t, AppEventRegistrationRetry{ID: id, Attempt: 3, MaxAttempts: 3, Error: "transactor is down"}, publisher.last())
+
+	// Gives up after max attempts.
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 3, submitter.count())
+}
+
+func Test_RegistrationRetrier_ResubmitsSponsoredRegistration(t *testing.T) {
+	id := identity.FromAddress("0x1")
+	storage := &retrierStorageFake{entries: []StoredRegistrationStatus{
+		{Identity: id, RegistrationStatus: RegistrationError, RegistrationRequest: IdentityRegistrationRequest{Sponsored: true, Sponsor: "sponsor"}},
+	}}
+	submitter := &submitterFake{}
+	r := NewRegistrationRetrier(submitter, &mockRegistrationStatusProvider{status: RegistrationError}, storage, &retryPublisherFake{}, retrierTestConfig)
+	defer r.stop()
+
+	r.resumePending()
+
+	assert.Eventually(t, func() bool { return submitter.count() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"sponsor"}, submitter.sponsors)
+	assert.Empty(t, submitter.stakes)
+}
+
+func Test_RegistrationRetrier_ResubmitsStuckRegistration(t *testing.T) {
+	id := identity.FromAddress("0x1")
+	submitter := &submitterFake{}
+	checker := &mockRegistrationStatusProvider{status: InProgress}
+	r := NewRegistrationRetrier(submitter, checker, &retrierStorageFake{}, &retryPublisherFake{}, retrierTestConfig)
+	defer r.stop()
+
+	r.handleRegistrationEvent(AppEventIdentityRegistration{ID: id, Status: InProgress})
+	assert.Eventually(t, func() bool { return submitter.count() == 1 }, time.Second, time.Millisecond)
+
+	// Registration completes before the next stuck check.
+	r.handleRegistrationEvent(AppEventIdentityRegistration{ID: id, Status: InProgress})
+	r.handleRegistrationEvent(AppEventIdentityRegistration{ID: id, Status: Registered})
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, submitter.count())
+}
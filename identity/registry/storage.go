@@ -32,6 +32,7 @@ type persistentStorage interface {
 	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
 	GetAllFrom(bucket string, data interface{}) error
 	Update(bucket string, object interface{}) error
+	Delete(bucket string, data interface{}) error
 }
 
 var errBoltNotFound = "not found"
@@ -100,6 +101,18 @@ func (rss *RegistrationStatusStorage) Get(identity identity.Identity) (StoredReg
 	return rss.get(identity)
 }
 
+// Delete removes the registration status of the given identity.
+func (rss *RegistrationStatusStorage) Delete(identity identity.Identity) error {
+	rss.lock.Lock()
+	defer rss.lock.Unlock()
+
+	err := rss.bolt.Delete(registrationStatusBucket, &StoredRegistrationStatus{Identity: identity})
+	if err != nil && err.Error() != errBoltNotFound {
+		return errors.Wrap(err, "could not delete registration status")
+	}
+	return nil
+}
+
 // GetAll fetches all the registration statuses
 func (rss *RegistrationStatusStorage) GetAll() ([]StoredRegistrationStatus, error) {
 	rss.lock.Lock()
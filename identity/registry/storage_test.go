@@ -95,6 +95,16 @@ func TestRegistrationStatusStorage(t *testing.T) {
 	res, err = consumerTotalsStorage.Get(mockStatus2.Identity)
 	assert.Nil(t, err)
 	assert.Equal(t, Registered, res.RegistrationStatus)
+
+	// should forget deleted identity
+	err = consumerTotalsStorage.Delete(mockStatus2.Identity)
+	assert.Nil(t, err)
+
+	_, err = consumerTotalsStorage.Get(mockStatus2.Identity)
+	assert.Equal(t, ErrNotFound, err)
+
+	err = consumerTotalsStorage.Delete(mockStatus2.Identity)
+	assert.Nil(t, err)
 }
 
 func statusesEqual(t *testing.T, a, b StoredRegistrationStatus) {
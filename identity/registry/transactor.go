@@ -95,6 +95,10 @@ type IdentityRegistrationRequest struct {
 	// Signature from fields above
 	Signature string `json:"signature"`
 	Identity  string `json:"identity"`
+	// Sponsored is set when registration is paid by a third-party on behalf of the identity.
+	Sponsored bool `json:"sponsored,omitempty"`
+	// Sponsor identifies the third-party paying for the registration, default sponsor of transactor is used if empty.
+	Sponsor string `json:"sponsor,omitempty"`
 }
 
 // PromiseSettlementRequest represents the settlement request body
@@ -147,6 +151,59 @@ func (t *Transactor) FetchStakeDecreaseFee() (FeesResponse, error) {
 	return f, err
 }
 
+// RegistrationEstimateResponse represents how long Transactor expects registration to take until it is confirmed.
+type RegistrationEstimateResponse struct {
+	Seconds int64 `json:"seconds"`
+}
+
+// FetchRegistrationEstimate fetches the current estimate of registration confirmation time.
+func (t *Transactor) FetchRegistrationEstimate() (time.Duration, error) {
+	f := RegistrationEstimateResponse{}
+
+	req, err := requests.NewGetRequest(t.endpointAddress, "identity/register/estimate", nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to fetch transactor registration estimate")
+	}
+
+	err = t.httpClient.DoRequestAndParseResponse(req, &f)
+	return time.Duration(f.Seconds) * time.Second, err
+}
+
+// RegistrationQuote describes what registering an identity will cost right now.
+type RegistrationQuote struct {
+	Fee            *big.Int
+	ValidUntil     time.Time
+	BountyEligible bool
+	// EstimatedConfirmation is zero when Transactor could not provide an estimate.
+	EstimatedConfirmation time.Duration
+}
+
+// QuoteRegistration quotes registration fee, bounty eligibility and estimated confirmation time for the given identity.
+func (t *Transactor) QuoteRegistration(id identity.Identity) (RegistrationQuote, error) {
+	fees, err := t.FetchRegistrationFees()
+	if err != nil {
+		return RegistrationQuote{}, errors.Wrap(err, "could not fetch registration fees")
+	}
+
+	eligible, err := t.CheckIfRegistrationBountyEligible(id)
+	if err != nil {
+		return RegistrationQuote{}, errors.Wrap(err, "could not check registration bounty eligibility")
+	}
+
+	estimate, err := t.FetchRegistrationEstimate()
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not fetch registration confirmation estimate")
+		estimate = 0
+	}
+
+	return RegistrationQuote{
+		Fee:                   fees.Fee,
+		ValidUntil:            fees.ValidUntil,
+		BountyEligible:        eligible,
+		EstimatedConfirmation: estimate,
+	}, nil
+}
+
 // SettleAndRebalance requests the transactor to settle and rebalance the given channel
 func (t *Transactor) SettleAndRebalance(hermesID, providerID string, promise pc.Promise) error {
 	payload := PromiseSettlementRequest{
@@ -249,6 +306,48 @@ func (t *Transactor) GetTokenReward(token string) (TokenRewardResponse, error) {
 	return f, err
 }
 
+// RegistrationAuthorization returns registration request of the identity signed with zero stake and fee.
+// Third-party payer may submit it to the registry on behalf of the identity, which needs no funds for that.
+func (t *Transactor) RegistrationAuthorization(id, beneficiary string) (IdentityRegistrationRequest, error) {
+	regReq, err := t.fillIdentityRegistrationRequest(id, new(big.Int), new(big.Int), beneficiary)
+	if err != nil {
+		return IdentityRegistrationRequest{}, errors.Wrap(err, "failed to fill in identity request")
+	}
+
+	err = t.validateRegisterIdentityRequest(regReq)
+	if err != nil {
+		return IdentityRegistrationRequest{}, errors.Wrap(err, "identity request validation failed")
+	}
+	return regReq, nil
+}
+
+// RegisterIdentitySponsored instructs Transactor to register identity with the transaction paid by the sponsor,
+// identity only authorizes the registration.
+func (t *Transactor) RegisterIdentitySponsored(id, beneficiary, sponsor string) error {
+	regReq, err := t.RegistrationAuthorization(id, beneficiary)
+	if err != nil {
+		return err
+	}
+	regReq.Sponsored = true
+	regReq.Sponsor = sponsor
+
+	req, err := requests.NewPostRequest(t.endpointAddress, "identity/register/sponsored", regReq)
+	if err != nil {
+		return errors.Wrap(err, "failed to create sponsored RegisterIdentity request")
+	}
+
+	err = t.httpClient.DoRequest(req)
+	if err != nil {
+		return err
+	}
+
+	// This is left as a synchronous call on purpose.
+	// We need to notify registry before returning.
+	t.publisher.Publish(AppTopicTransactorRegistration, regReq)
+
+	return nil
+}
+
 // RegisterIdentity instructs Transactor to register identity on behalf of a client identified by 'id'
 func (t *Transactor) RegisterIdentity(id string, stake, fee *big.Int, beneficiary string, referralToken *string) error {
 	if referralToken == nil {
@@ -0,0 +1,163 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const remoteSignerTimeout = 10 * time.Second
+
+// RemoteSignerTLS holds PEM files used for mutual TLS authentication with the remote signer.
+type RemoteSignerTLS struct {
+	CertFile string
+	KeyFile  string
+	// CAFile verifies the signer certificate, system roots are used if it is empty.
+	CAFile string
+}
+
+// RemoteSigner delegates signing to an external service, so that fleet operators can keep keys in a vault
+// instead of distributing keystores to every node. The service is called as:
+//
+//	POST <url>/sign {"address": "0x...", "hash": "0x..."}
+//	200 {"signature": "0x..."}
+//
+// where the signature is in the [R || S || V] format, V being 0, 1, 27 or 28.
+type RemoteSigner struct {
+	url     string
+	account accounts.Account
+	client  *http.Client
+}
+
+type remoteSignRequest struct {
+	Address common.Address `json:"address"`
+	Hash    hexutil.Bytes  `json:"hash"`
+}
+
+type remoteSignResponse struct {
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// NewRemoteSigner creates signer of the given identity backed by the remote service authenticated with mutual TLS.
+// The service has to be reached over HTTPS and the node has to present its client certificate.
+func NewRemoteSigner(signerURL string, address common.Address, tlsOptions RemoteSignerTLS) (*RemoteSigner, error) {
+	parsed, err := url.Parse(signerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote signer URL: %w", err)
+	}
+	if parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, fmt.Errorf("remote signer URL has to be https, got %q", signerURL)
+	}
+	if tlsOptions.CertFile == "" || tlsOptions.KeyFile == "" {
+		return nil, errors.New("remote signer requires client certificate and key for mutual TLS")
+	}
+
+	cert, err := tls.LoadX509KeyPair(tlsOptions.CertFile, tlsOptions.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load remote signer client certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if tlsOptions.CAFile != "" {
+		caPEM, err := ioutil.ReadFile(tlsOptions.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load remote signer CA: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no certificates found in remote signer CA file")
+		}
+	}
+
+	return newRemoteSigner(signerURL, address, &http.Client{
+		Timeout:   remoteSignerTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}), nil
+}
+
+func newRemoteSigner(url string, address common.Address, client *http.Client) *RemoteSigner {
+	return &RemoteSigner{
+		url:     strings.TrimSuffix(url, "/"),
+		account: accounts.Account{Address: address},
+		client:  client,
+	}
+}
+
+// Account returns account of the key kept by the remote signer.
+func (s *RemoteSigner) Account() accounts.Account {
+	return s.account
+}
+
+// SignHash asks the remote service to sign the hash. The produced signature is in the [R || S || V] format where V is 0 or 1.
+func (s *RemoteSigner) SignHash(hash []byte) ([]byte, error) {
+	body, err := json.Marshal(remoteSignRequest{Address: s.account.Address, Hash: hash})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Post(s.url+"/sign", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not reach remote signer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("remote signer responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var reply remoteSignResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("could not parse remote signer response: %w", err)
+	}
+
+	signature := []byte(reply.Signature)
+	if len(signature) != crypto.SignatureLength {
+		return nil, errors.New("malformed signature received from remote signer")
+	}
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+
+	// Make sure the service signed with the expected key.
+	key, err := crypto.SigToPub(hash, signature)
+	if err != nil {
+		return nil, err
+	}
+	if signer := crypto.PubkeyToAddress(*key); signer != s.account.Address {
+		return nil, fmt.Errorf("signer mismatch: expected %s, got %s", s.account.Address.Hex(), signer.Hex())
+	}
+	return signature, nil
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRemoteSignerServer(t *testing.T, key *ecdsa.PrivateKey) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sign", r.URL.Path)
+
+		var req remoteSignRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Address != crypto.PubkeyToAddress(key.PublicKey) {
+			http.Error(w, "unknown address", http.StatusNotFound)
+			return
+		}
+
+		signature, err := crypto.Sign(req.Hash, key)
+		assert.NoError(t, err)
+		signature[crypto.RecoveryIDOffset] += 27
+		assert.NoError(t, json.NewEncoder(w).Encode(remoteSignResponse{Signature: hexutil.Bytes(signature)}))
+	}))
+}
+
+func Test_RemoteSigner_SignHash(t *testing.T) {
+	server := newRemoteSignerServer(t, encryptionKey)
+	defer server.Close()
+
+	hash := crypto.Keccak256([]byte(secretMessage))
+
+	signer := newRemoteSigner(server.URL+"/", encryptionAddress, server.Client())
+	signature, err := signer.SignHash(hash)
+	assert.NoError(t, err)
+
+	expected, err := crypto.Sign(hash, encryptionKey)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, signature)
+
+	ks := NewKeystoreFilesystem("", &ethKeystoreMock{account: encryptionAccount})
+	ks.UseExternalSigner(signer)
+	signed, err := NewSigner(ks, FromAddress(encryptionAddress.Hex())).Sign([]byte(secretMessage))
+	assert.NoError(t, err)
+	assert.True(t, NewVerifierIdentity(FromAddress(encryptionAddress.Hex())).Verify([]byte(secretMessage), signed))
+}
+
+func Test_RemoteSigner_RejectsWrongSigner(t *testing.T) {
+	otherKey, _ := crypto.GenerateKey()
+	server := newRemoteSignerServer(t, otherKey)
+	defer server.Close()
+
+	hash := crypto.Keccak256([]byte(secretMessage))
+
+	_, err := newRemoteSigner(server.URL, encryptionAddress, server.Client()).SignHash(hash)
+	assert.EqualError(t, err, "remote signer responded with 404: unknown address")
+
+	// Service signing with another key than the identity one.
+	impostor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature, _ := crypto.Sign(hash, otherKey)
+		_ = json.NewEncoder(w).Encode(remoteSignResponse{Signature: hexutil.Bytes(signature)})
+	}))
+	defer impostor.Close()
+
+	_, err = newRemoteSigner(impostor.URL, encryptionAddress, impostor.Client()).SignHash(hash)
+	assert.Error(t, err)
+}
+
+func writeClientCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "node"},
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile = filepath.Join(dir, "client.crt")
+	keyFile = filepath.Join(dir, "client.key")
+	assert.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func Test_NewRemoteSigner_RequiresMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote-signer")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeClientCertificate(t, dir)
+
+	_, err = NewRemoteSigner("http://vault:8443", encryptionAddress, RemoteSignerTLS{CertFile: certFile, KeyFile: keyFile})
+	assert.Error(t, err)
+
+	_, err = NewRemoteSigner("https://vault:8443", encryptionAddress, RemoteSignerTLS{})
+	assert.Error(t, err)
+
+	_, err = NewRemoteSigner("https://vault:8443", encryptionAddress, RemoteSignerTLS{CertFile: certFile})
+	assert.Error(t, err)
+
+	signer, err := NewRemoteSigner("https://vault:8443", encryptionAddress, RemoteSignerTLS{CertFile: certFile, KeyFile: keyFile})
+	assert.NoError(t, err)
+	assert.Equal(t, encryptionAddress, signer.Account().Address)
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/pkg/errors"
+)
+
+// SessionKeyAuthorization is a statement signed by identity, authorizing the session key to sign on its behalf until expiry.
+type SessionKeyAuthorization struct {
+	Identity   Identity
+	SessionKey common.Address
+	ExpiresAt  time.Time
+	Signature  Signature
+}
+
+func (a SessionKeyAuthorization) message() []byte {
+	return []byte(fmt.Sprintf("Authorize session key %s for %s until %d", strings.ToLower(a.SessionKey.Hex()), a.Identity.Address, a.ExpiresAt.Unix()))
+}
+
+// Verify checks that the authorization is signed by identity and is not expired at the given time.
+func (a SessionKeyAuthorization) Verify(now time.Time) error {
+	if !now.Before(a.ExpiresAt) {
+		return errors.New("session key authorization expired")
+	}
+	if !NewVerifierIdentity(a.Identity).Verify(a.message(), a.Signature) {
+		return errors.New("session key authorization is not signed by identity")
+	}
+	return nil
+}
+
+// VerifySessionSignature checks that the message is signed by the session key authorized in the given authorization.
+func VerifySessionSignature(auth SessionKeyAuthorization, message []byte, signature Signature, now time.Time) error {
+	if err := auth.Verify(now); err != nil {
+		return err
+	}
+	if !NewVerifierIdentity(FromAddress(auth.SessionKey.Hex())).Verify(message, signature) {
+		return errors.New("message is not signed by session key")
+	}
+	return nil
+}
+
+// SessionKey is a short-lived key signing provider invoices on behalf of identity,
+// so peers can authenticate invoices without the identity key signing each of them.
+type SessionKey struct {
+	key           *ecdsa.PrivateKey
+	authorization SessionKeyAuthorization
+}
+
+// NewSessionKey generates a new session key and authorizes it by the identity signer for the given time.
+func NewSessionKey(signer Signer, id Identity, ttl time.Duration) (*SessionKey, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate session key")
+	}
+
+	auth := SessionKeyAuthorization{
+		Identity:   id,
+		SessionKey: crypto.PubkeyToAddress(key.PublicKey),
+		ExpiresAt:  time.Now().Add(ttl).Truncate(time.Second),
+	}
+	auth.Signature, err = signer.Sign(auth.message())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not authorize session key")
+	}
+
+	return &SessionKey{key: key, authorization: auth}, nil
+}
+
+// Authorization returns the identity authorization of the session key, which has to accompany its signatures.
+func (sk *SessionKey) Authorization() SessionKeyAuthorization {
+	return sk.authorization
+}
+
+// Sign signs given message with the session key.
+func (sk *SessionKey) Sign(message []byte) (Signature, error) {
+	signature, err := crypto.Sign(messageHash(message), sk.key)
+	if err != nil {
+		return Signature{}, err
+	}
+	return SignatureBytes(signature), nil
+}
+
+// SessionKeys keeps session keys of identities, renewing them before they expire.
+// Session keys sign provider invoices only. Promises, settlements and registration are verified
+// on chain and by hermes, which know identities only, so the identity key still has to be unlocked to sign them.
+type SessionKeys struct {
+	signerFactory SignerFactory
+	ttl           time.Duration
+
+	mu   sync.Mutex
+	keys map[Identity]*SessionKey
+}
+
+// NewSessionKeys returns session keys authorized by signers of the given factory for the given time.
+func NewSessionKeys(signerFactory SignerFactory, ttl time.Duration) *SessionKeys {
+	return &SessionKeys{
+		signerFactory: signerFactory,
+		ttl:           ttl,
+		keys:          make(map[Identity]*SessionKey),
+	}
+}
+
+// Get returns valid session key of the identity. New key is authorized when there is none
+// or the current one is past half of its lifetime, so authorizations seen by peers do not expire mid-use.
+func (sks *SessionKeys) Get(id Identity) (*SessionKey, error) {
+	sks.mu.Lock()
+	defer sks.mu.Unlock()
+
+	if key, ok := sks.keys[id]; ok && time.Now().Add(sks.ttl/2).Before(key.authorization.ExpiresAt) {
+		return key, nil
+	}
+
+	key, err := NewSessionKey(sks.signerFactory(id), id, sks.ttl)
+	if err != nil {
+		return nil, err
+	}
+	sks.keys[id] = key
+	return key, nil
+}
+
+// Subscribe revokes session keys of deleted identities.
+func (sks *SessionKeys) Subscribe(eb eventbus.Subscriber) error {
+	return eb.SubscribeAsync(AppTopicIdentityDeleted, func(address string) {
+		sks.Revoke(FromAddress(address))
+	})
+}
+
+// Revoke forgets session key of the identity.
+func (sks *SessionKeys) Revoke(id Identity) {
+	sks.mu.Lock()
+	defer sks.mu.Unlock()
+
+	delete(sks.keys, id)
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SessionKey(t *testing.T) {
+	ks := NewKeystoreMemory()
+	account, err := ks.ImportECDSA(signerKey, "")
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Unlock(account, ""))
+	id := FromAddress(account.Address.Hex())
+
+	key, err := NewSessionKey(NewSigner(ks, id), id, time.Minute)
+	assert.NoError(t, err)
+	auth := key.Authorization()
+	assert.Equal(t, id, auth.Identity)
+	assert.NoError(t, auth.Verify(time.Now()))
+
+	message := []byte("invoice")
+	signature, err := key.Sign(message)
+	assert.NoError(t, err)
+
+	// Identity key is not needed for signing once session key is authorized.
+	assert.NoError(t, ks.Lock(account.Address))
+	_, err = key.Sign(message)
+	assert.NoError(t, err)
+
+	t.Run("Verifies session signature", func(t *testing.T) {
+		assert.NoError(t, VerifySessionSignature(auth, message, signature, time.Now()))
+	})
+
+	t.Run("Rejects tampered message", func(t *testing.T) {
+		assert.Error(t, VerifySessionSignature(auth, []byte("other invoice"), signature, time.Now()))
+	})
+
+	t.Run("Rejects expired authorization", func(t *testing.T) {
+		assert.Error(t, VerifySessionSignature(auth, message, signature, time.Now().Add(time.Hour)))
+	})
+
+	t.Run("Rejects authorization of other identity", func(t *testing.T) {
+		forged := auth
+		forged.Identity = FromAddress("0x000000000000000000000000000000000000000a")
+		assert.Error(t, VerifySessionSignature(forged, message, signature, time.Now()))
+	})
+
+	t.Run("Rejects signature of unauthorized key", func(t *testing.T) {
+		forged := auth
+		forged.SessionKey = common.HexToAddress("0x000000000000000000000000000000000000000b")
+		assert.Error(t, VerifySessionSignature(forged, message, signature, time.Now()))
+	})
+}
+
+func Test_SessionKeys(t *testing.T) {
+	ks := NewKeystoreMemory()
+	account, err := ks.ImportECDSA(signerKey, "")
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Unlock(account, ""))
+	id := FromAddress(account.Address.Hex())
+
+	keys := NewSessionKeys(func(id Identity) Signer { return NewSigner(ks, id) }, time.Hour)
+	first, err := keys.Get(id)
+	assert.NoError(t, err)
+
+	// Reuses authorized key while identity is locked.
+	assert.NoError(t, ks.Lock(account.Address))
+	second, err := keys.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	keys.Revoke(id)
+	_, err = keys.Get(id)
+	assert.Error(t, err)
+}
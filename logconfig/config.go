@@ -99,7 +99,7 @@ func zeroLogger(out io.Writer) io.Writer {
 }
 
 func makeLogger(w io.Writer) zerolog.Logger {
-	return log.Output(w).
+	return log.Output(io.MultiWriter(w, LogStreams)).
 		Level(zerolog.DebugLevel).
 		With().
 		Caller().
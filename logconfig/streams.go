@@ -0,0 +1,150 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Log fields tagging records of a single service instance and its sessions.
+const (
+	FieldServiceID = "service_id"
+	FieldSessionID = "session_id"
+)
+
+const (
+	streamLines = 1000
+	maxStreams  = 200
+)
+
+// LogStreams keeps recent log records of each service instance and session of the node.
+var LogStreams = NewStreams(streamLines, maxStreams)
+
+// ServiceLogger returns logger tagging records with the service instance ID.
+func ServiceLogger(serviceID string) *zerolog.Logger {
+	logger := log.With().Str(FieldServiceID, serviceID).Logger()
+	return &logger
+}
+
+// SessionLogger returns logger tagging records with the service instance and session IDs.
+func SessionLogger(serviceID, sessionID string) *zerolog.Logger {
+	logger := log.With().Str(FieldServiceID, serviceID).Str(FieldSessionID, sessionID).Logger()
+	return &logger
+}
+
+// Streams splits tagged log records into streams of each service instance and session,
+// so that logs of a single consumer can be retrieved without searching the whole node log.
+// Only the latest records of the latest streams are kept.
+type Streams struct {
+	lock       sync.Mutex
+	lines      int
+	maxStreams int
+	streams    map[string][]string
+	order      []string
+}
+
+// NewStreams creates log streams keeping given number of lines of each stream.
+func NewStreams(lines, maxStreams int) *Streams {
+	return &Streams{
+		lines:      lines,
+		maxStreams: maxStreams,
+		streams:    make(map[string][]string),
+	}
+}
+
+// Write appends JSON log record to streams it is tagged with, untagged records are skipped.
+func (s *Streams) Write(p []byte) (int, error) {
+	if !bytes.Contains(p, []byte(FieldServiceID)) && !bytes.Contains(p, []byte(FieldSessionID)) {
+		return len(p), nil
+	}
+
+	var record struct {
+		ServiceID string `json:"service_id"`
+		SessionID string `json:"session_id"`
+	}
+	if err := json.Unmarshal(p, &record); err != nil {
+		return len(p), nil
+	}
+
+	var line bytes.Buffer
+	writer := zerolog.ConsoleWriter{Out: &line, NoColor: true, TimeFormat: timestampFmt}
+	if _, err := writer.Write(p); err != nil {
+		return len(p), nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if record.ServiceID != "" {
+		s.append(serviceStream(record.ServiceID), line.String())
+	}
+	if record.SessionID != "" {
+		s.append(sessionStream(record.SessionID), line.String())
+	}
+	return len(p), nil
+}
+
+// Service returns recent log lines of the service instance.
+func (s *Streams) Service(serviceID string) []string {
+	return s.stream(serviceStream(serviceID))
+}
+
+// Session returns recent log lines of the session.
+func (s *Streams) Session(sessionID string) []string {
+	return s.stream(sessionStream(sessionID))
+}
+
+func (s *Streams) stream(key string) []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	lines := make([]string, len(s.streams[key]))
+	copy(lines, s.streams[key])
+	return lines
+}
+
+func (s *Streams) append(key, line string) {
+	lines, ok := s.streams[key]
+	if !ok {
+		if len(s.order) >= s.maxStreams {
+			delete(s.streams, s.order[0])
+			s.order = s.order[1:]
+		}
+		s.order = append(s.order, key)
+	}
+
+	lines = append(lines, strings.TrimSuffix(line, "\n"))
+	if len(lines) > s.lines {
+		lines = lines[len(lines)-s.lines:]
+	}
+	s.streams[key] = lines
+}
+
+func serviceStream(serviceID string) string {
+	return "service:" + serviceID
+}
+
+func sessionStream(sessionID string) string {
+	return "session:" + sessionID
+}
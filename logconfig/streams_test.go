@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logconfig
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreams_SplitsRecordsByServiceAndSession(t *testing.T) {
+	streams := NewStreams(2, 10)
+	logger := zerolog.New(streams)
+
+	logger.Info().Msg("untagged")
+	logger.Info().Str(FieldServiceID, "service1").Msg("service started")
+	logger.Info().Str(FieldServiceID, "service1").Str(FieldSessionID, "session1").Msg("session started")
+	logger.Info().Str(FieldServiceID, "service1").Str(FieldSessionID, "session2").Msg("session started")
+
+	assert.Len(t, streams.Service("service1"), 2)
+	assert.Contains(t, streams.Service("service1")[0], "session started")
+	assert.Contains(t, streams.Service("service1")[0], "session_id=session1")
+	assert.Len(t, streams.Session("session1"), 1)
+	assert.Len(t, streams.Session("session2"), 1)
+	assert.Empty(t, streams.Session("unknown"))
+}
+
+func TestStreams_DropsOldestStreams(t *testing.T) {
+	streams := NewStreams(10, 2)
+	logger := zerolog.New(streams)
+
+	logger.Info().Str(FieldSessionID, "session1").Msg("first")
+	logger.Info().Str(FieldSessionID, "session2").Msg("second")
+	logger.Info().Str(FieldSessionID, "session3").Msg("third")
+
+	assert.Empty(t, streams.Session("session1"))
+	assert.Len(t, streams.Session("session2"), 1)
+	assert.Len(t, streams.Session("session3"), 1)
+}
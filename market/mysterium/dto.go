@@ -18,6 +18,8 @@
 package mysterium
 
 import (
+	"net/url"
+
 	"github.com/mysteriumnetwork/node/market"
 )
 
@@ -78,11 +80,45 @@ type ProposalsQuery struct {
 	IncludeFailed      bool
 }
 
+// toValues encodes query to URL parameters of proposals listing.
+func (query ProposalsQuery) toValues() url.Values {
+	values := url.Values{}
+	if query.NodeKey != "" {
+		values.Set("node_key", query.NodeKey)
+	}
+	if query.ServiceType != "" {
+		values.Set("service_type", query.ServiceType)
+	}
+	if query.AccessPolicyAll {
+		values.Set("access_policy", "*")
+	}
+	if query.AccessPolicyID != "" {
+		values.Set("access_policy[id]", query.AccessPolicyID)
+	}
+	if query.AccessPolicySource != "" {
+		values.Set("access_policy[source]", query.AccessPolicySource)
+	}
+	if query.NodeType != "" {
+		values.Set("node_type", query.NodeType)
+	}
+	if query.IncludeFailed {
+		values.Set("include_failed", "true")
+	}
+	return values
+}
+
 // ProposalsResponse represents JSON response for the list of proposals
 type ProposalsResponse struct {
 	Proposals []market.ServiceProposal `json:"proposals"`
 }
 
+// ProposalsPageResponse represents JSON response for a page of proposals of discovery API v3
+type ProposalsPageResponse struct {
+	Proposals  []market.ServiceProposal `json:"proposals"`
+	Page       int                      `json:"page"`
+	TotalPages int                      `json:"total_pages"`
+}
+
 // SessionStats mapped to json structure
 type SessionStats struct {
 	BytesSent       uint64 `json:"bytes_sent"`
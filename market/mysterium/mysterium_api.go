@@ -20,7 +20,6 @@ package mysterium
 import (
 	"fmt"
 	"net/http"
-	"net/url"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -215,29 +214,7 @@ func (mApi *MysteriumAPI) Proposals() ([]market.ServiceProposal, error) {
 
 // QueryProposals fetches currently active service proposals from discovery - by given query filter
 func (mApi *MysteriumAPI) QueryProposals(query ProposalsQuery) ([]market.ServiceProposal, error) {
-	values := url.Values{}
-	if query.NodeKey != "" {
-		values.Set("node_key", query.NodeKey)
-	}
-	if query.ServiceType != "" {
-		values.Set("service_type", query.ServiceType)
-	}
-	if query.AccessPolicyAll {
-		values.Set("access_policy", "*")
-	}
-	if query.AccessPolicyID != "" {
-		values.Set("access_policy[id]", query.AccessPolicyID)
-	}
-	if query.AccessPolicySource != "" {
-		values.Set("access_policy[source]", query.AccessPolicySource)
-	}
-	if query.NodeType != "" {
-		values.Set("node_type", query.NodeType)
-	}
-	if query.IncludeFailed {
-		values.Set("include_failed", "true")
-	}
-
+	values := query.toValues()
 	req, err := requests.NewGetRequest(mApi.discoveryAPIAddress, "proposals", values)
 	if err != nil {
 		return nil, err
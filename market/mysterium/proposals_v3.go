@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/requests"
+)
+
+// ProposalsClientV3 fetches proposals from discovery API v3.
+// Proposals are fetched page by page with gzip compression, and pages which did not change
+// since the previous fetch are served from memory using conditional requests.
+type ProposalsClientV3 struct {
+	httpClient *requests.HTTPClient
+	address    string
+	pageSize   int
+
+	mu    sync.Mutex
+	pages map[string]proposalsPage
+}
+
+type proposalsPage struct {
+	etag       string
+	proposals  []market.ServiceProposal
+	totalPages int
+}
+
+// NewProposalsClientV3 creates discovery API v3 client fetching pages of the given size.
+func NewProposalsClientV3(httpClient *requests.HTTPClient, address string, pageSize int) *ProposalsClientV3 {
+	return &ProposalsClientV3{
+		httpClient: httpClient,
+		address:    address,
+		pageSize:   pageSize,
+		pages:      make(map[string]proposalsPage),
+	}
+}
+
+// QueryProposals fetches currently active service proposals from discovery - by given query filter
+func (c *ProposalsClientV3) QueryProposals(query ProposalsQuery) ([]market.ServiceProposal, error) {
+	proposals := []market.ServiceProposal{}
+	for page := 1; ; page++ {
+		p, err := c.fetchPage(query, page)
+		if err != nil {
+			return nil, err
+		}
+
+		proposals = append(proposals, p.proposals...)
+		if page >= p.totalPages {
+			break
+		}
+	}
+	return proposals, nil
+}
+
+func (c *ProposalsClientV3) fetchPage(query ProposalsQuery, page int) (proposalsPage, error) {
+	values := query.toValues()
+	values.Set("page", strconv.Itoa(page))
+	values.Set("page_size", strconv.Itoa(c.pageSize))
+	key := values.Encode()
+
+	req, err := requests.NewGetRequest(c.address, "proposals", values)
+	if err != nil {
+		return proposalsPage{}, err
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	cached, ok := c.cachedPage(key)
+	if ok {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return proposalsPage{}, errors.Wrap(err, "cannot fetch proposals")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified && ok {
+		return cached, nil
+	}
+	if err := requests.ParseResponseError(res); err != nil {
+		return proposalsPage{}, err
+	}
+
+	var body io.Reader = res.Body
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return proposalsPage{}, errors.Wrap(err, "cannot decompress proposals response")
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	}
+
+	// decode the stream directly, so that the whole response body is never held in memory
+	var pageResponse ProposalsPageResponse
+	if err := json.NewDecoder(body).Decode(&pageResponse); err != nil {
+		return proposalsPage{}, errors.Wrap(err, "cannot parse proposals response")
+	}
+
+	p := proposalsPage{
+		etag:       res.Header.Get("ETag"),
+		proposals:  supportedProposalsOnly(pageResponse.Proposals),
+		totalPages: pageResponse.TotalPages,
+	}
+	log.Debug().Msgf("Proposals page %d/%d total: %d supported: %d", page, p.totalPages, len(pageResponse.Proposals), len(p.proposals))
+	c.storePage(key, p)
+	return p, nil
+}
+
+func (c *ProposalsClientV3) cachedPage(key string) (proposalsPage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pages[key]
+	return p, ok
+}
+
+func (c *ProposalsClientV3) storePage(key string, p proposalsPage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p.etag == "" {
+		delete(c.pages, key)
+		return
+	}
+	c.pages[key] = p
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/node/requests"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	market.RegisterServiceDefinitionUnserializer("v3_service", func(*json.RawMessage) (market.ServiceDefinition, error) {
+		return v3ServiceDefinition{}, nil
+	})
+	market.RegisterPaymentMethodUnserializer("v3_payment", func(*json.RawMessage) (market.PaymentMethod, error) {
+		return v3PaymentMethod{}, nil
+	})
+	market.RegisterContactUnserializer("v3_contact", func(*json.RawMessage) (market.ContactDefinition, error) {
+		return v3Contact{}, nil
+	})
+}
+
+func TestProposalsClientV3_FetchesCompressedPages(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Query().Get("page")+":"+r.Header.Get("If-None-Match"))
+		mu.Unlock()
+
+		assert.Equal(t, "/proposals", r.URL.Path)
+		assert.Equal(t, "wireguard", r.URL.Query().Get("service_type"))
+		assert.Equal(t, "2", r.URL.Query().Get("page_size"))
+		assert.Equal(t, "gzip", r.Header.Get("Accept-Encoding"))
+
+		page := r.URL.Query().Get("page")
+		etag := "etag-" + page
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		fmt.Fprintf(gz, `{"proposals": [%s, {"provider_id": "unsupported"}], "page": %s, "total_pages": 2}`, v3Proposal("0x"+page), page)
+	}))
+	defer s.Close()
+
+	client := NewProposalsClientV3(requests.NewHTTPClient("0.0.0.0", requests.DefaultTimeout), s.URL, 2)
+
+	proposals, err := client.QueryProposals(ProposalsQuery{ServiceType: "wireguard"})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 2)
+	assert.Equal(t, "0x1", proposals[0].ProviderID)
+	assert.Equal(t, "0x2", proposals[1].ProviderID)
+
+	proposals, err = client.QueryProposals(ProposalsQuery{ServiceType: "wireguard"})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 2)
+
+	assert.Equal(t, []string{"1:", "2:", "1:etag-1", "2:etag-2"}, requested)
+}
+
+func TestProposalsClientV3_ReturnsErrors(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	client := NewProposalsClientV3(requests.NewHTTPClient("0.0.0.0", requests.DefaultTimeout), s.URL, 2)
+
+	_, err := client.QueryProposals(ProposalsQuery{})
+	assert.Error(t, err)
+}
+
+func v3Proposal(providerID string) string {
+	return fmt.Sprintf(
+		`{"provider_id": %q, "service_type": "v3_service", "payment_method_type": "v3_payment", "provider_contacts": [{"type": "v3_contact"}]}`,
+		providerID,
+	)
+}
+
+type v3ServiceDefinition struct{}
+
+func (v3ServiceDefinition) GetLocation() market.Location {
+	return market.Location{}
+}
+
+type v3PaymentMethod struct{}
+
+func (v3PaymentMethod) GetPrice() money.Money {
+	return money.Money{}
+}
+
+func (v3PaymentMethod) GetType() string {
+	return "v3_payment"
+}
+
+func (v3PaymentMethod) GetRate() market.PaymentRate {
+	return market.PaymentRate{}
+}
+
+type v3Contact struct{}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package market
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mysteriumnetwork/node/identity"
+)
+
+// ErrProposalUnsigned is returned when verifying the proposal which has no provider signature.
+var ErrProposalUnsigned = errors.New("proposal is not signed")
+
+// ErrProposalSignatureVersion is returned when verifying the proposal signed over the unknown set of fields,
+// e.g. by a newer provider.
+var ErrProposalSignatureVersion = errors.New("unknown proposal signature version")
+
+// proposalSignatureVersion identifies fields covered by the signature, it has to be increased
+// whenever announced proposal fields are added to proposalTermsV1 or changed.
+const proposalSignatureVersion = 1
+
+// proposalTermsV1 lists every announced proposal field covered by the signature of version 1.
+// Fields are listed explicitly, so that consumers verify the signature over the same bytes regardless of
+// the fields newer providers announce, and regardless of how the proposal got re-serialized on its way.
+type proposalTermsV1 struct {
+	Version           int              `json:"version"`
+	ID                int              `json:"id"`
+	Format            string           `json:"format"`
+	ServiceType       string           `json:"service_type"`
+	ProviderID        string           `json:"provider_id"`
+	PaymentMethodType string           `json:"payment_method_type"`
+	Price             string           `json:"price"`
+	Currency          string           `json:"currency"`
+	PerTime           int64            `json:"per_time"`
+	PerByte           uint64           `json:"per_byte"`
+	Country           string           `json:"country"`
+	City              string           `json:"city"`
+	ASN               int              `json:"asn"`
+	NodeType          string           `json:"node_type"`
+	AccessPolicies    []AccessPolicy   `json:"access_policies"`
+	Contacts          []contactTermsV1 `json:"contacts"`
+	NATType           string           `json:"nat_type"`
+	MeasuredBandwidth uint64           `json:"measured_bandwidth"`
+}
+
+type contactTermsV1 struct {
+	Type       string          `json:"type"`
+	Definition json.RawMessage `json:"definition"`
+}
+
+func (proposal *ServiceProposal) signaturePayload() ([]byte, error) {
+	terms := proposalTermsV1{
+		Version:           proposalSignatureVersion,
+		ID:                proposal.ID,
+		Format:            proposal.Format,
+		ServiceType:       proposal.ServiceType,
+		ProviderID:        proposal.ProviderID,
+		PaymentMethodType: proposal.PaymentMethodType,
+		NATType:           proposal.NATType,
+		MeasuredBandwidth: uint64(proposal.MeasuredBandwidth),
+	}
+	if proposal.PaymentMethod != nil {
+		price := proposal.PaymentMethod.GetPrice()
+		if price.Amount != nil {
+			terms.Price = price.Amount.String()
+		}
+		terms.Currency = string(price.Currency)
+		terms.PerTime = int64(proposal.PaymentMethod.GetRate().PerTime)
+		terms.PerByte = proposal.PaymentMethod.GetRate().PerByte
+	}
+	if proposal.ServiceDefinition != nil {
+		location := proposal.ServiceDefinition.GetLocation()
+		terms.Country = location.Country
+		terms.City = location.City
+		terms.ASN = location.ASN
+		terms.NodeType = location.NodeType
+	}
+	if proposal.AccessPolicies != nil {
+		terms.AccessPolicies = *proposal.AccessPolicies
+	}
+	for _, contact := range proposal.ProviderContacts {
+		definition, err := canonicalJSON(contact.Definition)
+		if err != nil {
+			return nil, err
+		}
+		terms.Contacts = append(terms.Contacts, contactTermsV1{Type: contact.Type, Definition: definition})
+	}
+	return json.Marshal(terms)
+}
+
+// canonicalJSON serializes the value with object keys sorted, keeping numbers intact.
+func canonicalJSON(value interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var canonical interface{}
+	if err := decoder.Decode(&canonical); err != nil {
+		return nil, err
+	}
+	return json.Marshal(canonical)
+}
+
+// Sign signs the proposal fields of the current signature version with the provider identity.
+func (proposal *ServiceProposal) Sign(signer identity.Signer) error {
+	payload, err := proposal.signaturePayload()
+	if err != nil {
+		return fmt.Errorf("could not serialize proposal: %w", err)
+	}
+
+	signature, err := signer.Sign(payload)
+	if err != nil {
+		return fmt.Errorf("could not sign proposal: %w", err)
+	}
+	proposal.Signature = signature.Base64()
+	proposal.SignatureVersion = proposalSignatureVersion
+	return nil
+}
+
+// VerifySignature checks that the proposal is signed by the provider of the proposal.
+func (proposal *ServiceProposal) VerifySignature() error {
+	if proposal.Signature == "" {
+		return ErrProposalUnsigned
+	}
+	if proposal.SignatureVersion != proposalSignatureVersion {
+		return ErrProposalSignatureVersion
+	}
+
+	payload, err := proposal.signaturePayload()
+	if err != nil {
+		return fmt.Errorf("could not serialize proposal: %w", err)
+	}
+
+	provider := identity.FromAddress(proposal.ProviderID)
+	if !identity.NewVerifierIdentity(provider).Verify(payload, identity.SignatureBase64(proposal.Signature)) {
+		return errors.New("proposal is not signed by the provider")
+	}
+	return nil
+}
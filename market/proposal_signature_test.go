@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package market
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSigner(t *testing.T) (identity.Signer, identity.Identity) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+
+	ks := identity.NewKeystoreMemory()
+	account, err := ks.ImportECDSA(key, "")
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Unlock(account, ""))
+
+	id := identity.FromAddress(account.Address.Hex())
+	return identity.NewSigner(ks, id), id
+}
+
+func Test_ServiceProposal_SignatureVerification(t *testing.T) {
+	signer, provider := newTestSigner(t)
+	proposal := ServiceProposal{
+		ID:                1,
+		ServiceType:       "mock",
+		ProviderID:        provider.Address,
+		ServiceDefinition: serviceDefinition,
+		PaymentMethod:     paymentMethod,
+		ProviderContacts:  ContactList{providerContact},
+	}
+	assert.Equal(t, ErrProposalUnsigned, proposal.VerifySignature())
+
+	assert.NoError(t, proposal.Sign(signer))
+	assert.NotEmpty(t, proposal.Signature)
+	assert.NoError(t, proposal.VerifySignature())
+
+	tampered := proposal
+	tampered.ServiceType = "other"
+	assert.Error(t, tampered.VerifySignature())
+
+	otherSigner, other := newTestSigner(t)
+	forged := proposal
+	forged.ProviderID = other.Address
+	assert.Error(t, forged.VerifySignature())
+	assert.NoError(t, forged.Sign(otherSigner))
+	assert.NoError(t, forged.VerifySignature())
+
+	spoofed := forged
+	spoofed.ProviderID = provider.Address
+	assert.Error(t, spoofed.VerifySignature())
+}
+
+func Test_ServiceProposal_SignatureCoversAnnouncedFields(t *testing.T) {
+	signer, provider := newTestSigner(t)
+	proposal := ServiceProposal{
+		ID:                1,
+		ServiceType:       "mock",
+		ProviderID:        provider.Address,
+		ServiceDefinition: serviceDefinition,
+		PaymentMethod:     paymentMethod,
+		ProviderContacts:  ContactList{providerContact},
+		NATType:           "none",
+	}
+	assert.NoError(t, proposal.Sign(signer))
+
+	tampered := proposal
+	tampered.NATType = "symmetric"
+	assert.Error(t, tampered.VerifySignature())
+
+	tampered = proposal
+	tampered.MeasuredBandwidth = 100
+	assert.Error(t, tampered.VerifySignature())
+
+	tampered = proposal
+	tampered.ProviderContacts = ContactList{{Type: "type1", Definition: mockContact{}}}
+	assert.Error(t, tampered.VerifySignature())
+
+	local := proposal
+	local.Quality = &ProposalQuality{ConnectSuccess: 1}
+	local.PriceOutlier = true
+	local.Latency = time.Second
+	assert.NoError(t, local.VerifySignature())
+
+	newer := proposal
+	newer.SignatureVersion++
+	assert.Equal(t, ErrProposalSignatureVersion, newer.VerifySignature())
+}
+
+func Test_ServiceProposal_SignatureSurvivesSerialization(t *testing.T) {
+	signer, provider := newTestSigner(t)
+	proposal := ServiceProposal{
+		ID:                1,
+		Format:            "format/X",
+		ServiceType:       "mock_service",
+		ServiceDefinition: serviceDefinition,
+		PaymentMethodType: "mock_payment",
+		PaymentMethod:     paymentMethod,
+		ProviderID:        provider.Address,
+		ProviderContacts:  ContactList{{Type: "mock_contact", Definition: mockContact{}}},
+		NATType:           "none",
+	}
+	assert.NoError(t, proposal.Sign(signer))
+
+	data, err := json.Marshal(proposal)
+	assert.NoError(t, err)
+
+	var received ServiceProposal
+	assert.NoError(t, json.Unmarshal(data, &received))
+	assert.NoError(t, received.VerifySignature())
+
+	// Fields announced by newer providers are not covered by the known signature version.
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	fields["new_field"] = "value"
+	data, err = json.Marshal(fields)
+	assert.NoError(t, err)
+
+	received = ServiceProposal{}
+	assert.NoError(t, json.Unmarshal(data, &received))
+	assert.NoError(t, received.VerifySignature())
+}
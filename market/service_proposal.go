@@ -19,7 +19,10 @@ package market
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
 
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 )
 
@@ -27,11 +30,20 @@ const (
 	proposalFormat = "service-proposal/v1"
 )
 
+// NAT types of the provider announced in the proposal.
+const (
+	// NATTypePublic means the provider is reachable on its public IP directly.
+	NATTypePublic = "public"
+	// NATTypePortRestricted means the provider is behind NAT which keeps the same public port for all destinations.
+	NATTypePortRestricted = "port_restricted"
+	// NATTypeSymmetric means the provider is behind NAT which picks a new public port for every destination.
+	NATTypeSymmetric = "symmetric"
+)
+
 // ServiceProposal is top level structure which is presented to marketplace by service provider, and looked up by service consumer
 // service proposal can be marked as unsupported by deserializer, because of unknown service, payment method, or contact type
 type ServiceProposal struct {
-	// Per provider unique serial number of service description provided
-	// TODO Not supported yet
+	// Serial number of the provider's service of the same type, starting from one
 	ID int `json:"id"`
 
 	// A version number is included in the proposal to allow extensions to the proposal format
@@ -57,28 +69,71 @@ type ServiceProposal struct {
 
 	// AccessPolicies represents the access controls for proposal
 	AccessPolicies *[]AccessPolicy `json:"access_policies,omitempty"`
+
+	// Type of NAT the provider is behind, empty when unknown
+	NATType string `json:"nat_type,omitempty"`
+
+	// Bandwidth of the provider measured by speed test, zero value means it was not measured
+	MeasuredBandwidth datasize.BitSpeed `json:"measured_bandwidth,omitempty"`
+
+	// Provider signature of the proposal, empty for proposals of older providers
+	Signature string `json:"signature,omitempty"`
+
+	// Version of the signature, identifying proposal fields covered by it
+	SignatureVersion int `json:"signature_version,omitempty"`
+
+	// Quality of the service reported by Quality Oracle, nil when unknown.
+	// It is filled in by the consumer node and never announced by the provider.
+	Quality *ProposalQuality `json:"-"`
+
+	// SignatureVerified is set by the consumer node when the provider signature is valid.
+	SignatureVerified bool `json:"-"`
+
+	// PriceOutlier is set by the consumer node when the price is far above the network median.
+	PriceOutlier bool `json:"-"`
+
+	// Latency is round trip time to the provider probed by the consumer node, zero when unknown.
+	Latency time.Duration `json:"-"`
+}
+
+// ProposalQuality holds Quality Oracle metrics of the proposal.
+type ProposalQuality struct {
+	ConnectSuccess   int
+	ConnectFail      int
+	ConnectTimeout   int
+	MonitoringFailed bool
 }
 
 // UniqueID returns unique proposal composite ID
 func (proposal *ServiceProposal) UniqueID() ProposalID {
-	return ProposalID{
-		ProviderID:  proposal.ProviderID,
-		ServiceType: proposal.ServiceType,
+	return NewProposalID(proposal.ProviderID, proposal.ServiceType, proposal.ID)
+}
+
+// ServiceKey identifies the provider's service offering the proposal among services of the same type.
+// It is the service type for the first service of the type, so that it stays compatible with older consumers.
+func (proposal *ServiceProposal) ServiceKey() string {
+	if proposal.ID <= FirstProposalID {
+		return proposal.ServiceType
 	}
+	return fmt.Sprintf("%s-%d", proposal.ServiceType, proposal.ID)
 }
 
 // UnmarshalJSON is custom json unmarshaler to dynamically fill in ServiceProposal values
 func (proposal *ServiceProposal) UnmarshalJSON(data []byte) error {
 	var jsonData struct {
-		ID                int              `json:"id"`
-		Format            string           `json:"format"`
-		ServiceType       string           `json:"service_type"`
-		ProviderID        string           `json:"provider_id"`
-		PaymentMethodType string           `json:"payment_method_type"`
-		ServiceDefinition *json.RawMessage `json:"service_definition"`
-		PaymentMethod     *json.RawMessage `json:"payment_method"`
-		ProviderContacts  *json.RawMessage `json:"provider_contacts"`
-		AccessPolicies    *[]AccessPolicy  `json:"access_policies,omitempty"`
+		ID                int               `json:"id"`
+		Format            string            `json:"format"`
+		ServiceType       string            `json:"service_type"`
+		ProviderID        string            `json:"provider_id"`
+		PaymentMethodType string            `json:"payment_method_type"`
+		ServiceDefinition *json.RawMessage  `json:"service_definition"`
+		PaymentMethod     *json.RawMessage  `json:"payment_method"`
+		ProviderContacts  *json.RawMessage  `json:"provider_contacts"`
+		AccessPolicies    *[]AccessPolicy   `json:"access_policies,omitempty"`
+		NATType           string            `json:"nat_type,omitempty"`
+		MeasuredBandwidth datasize.BitSpeed `json:"measured_bandwidth,omitempty"`
+		Signature         string            `json:"signature,omitempty"`
+		SignatureVersion  int               `json:"signature_version,omitempty"`
 	}
 	if err := json.Unmarshal(data, &jsonData); err != nil {
 		return err
@@ -106,6 +161,10 @@ func (proposal *ServiceProposal) UnmarshalJSON(data []byte) error {
 	proposal.ProviderContacts = unserializeContacts(jsonData.ProviderContacts)
 
 	proposal.AccessPolicies = jsonData.AccessPolicies
+	proposal.NATType = jsonData.NATType
+	proposal.MeasuredBandwidth = jsonData.MeasuredBandwidth
+	proposal.Signature = jsonData.Signature
+	proposal.SignatureVersion = jsonData.SignatureVersion
 	return nil
 }
 
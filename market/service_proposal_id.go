@@ -25,7 +25,21 @@ type ProposalID struct {
 	// Unique identifier of a provider
 	ProviderID string
 
-	// Per provider unique serial number of service description provided
-	// TODO Not supported yet
+	// Serial number of the provider's service of the same type, zero for the first one
 	ID int
 }
+
+// FirstProposalID is the serial number of the provider's first service of a type.
+const FirstProposalID = 1
+
+// NewProposalID returns composite ID of the provider's service proposal with the given serial number.
+func NewProposalID(providerID, serviceType string, serial int) ProposalID {
+	id := ProposalID{
+		ProviderID:  providerID,
+		ServiceType: serviceType,
+	}
+	if serial > FirstProposalID {
+		id.ID = serial
+	}
+	return id
+}
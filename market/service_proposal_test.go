@@ -257,3 +257,13 @@ func Test_ServiceProposal_UnserializeAccessPolicy(t *testing.T) {
 	assert.Equal(t, expected, actual)
 	assert.True(t, actual.IsSupported())
 }
+
+func Test_ServiceProposal_ServiceKey(t *testing.T) {
+	proposal := ServiceProposal{ID: 1, ServiceType: "wireguard", ProviderID: "0x1"}
+	assert.Equal(t, "wireguard", proposal.ServiceKey())
+	assert.Equal(t, ProposalID{ServiceType: "wireguard", ProviderID: "0x1"}, proposal.UniqueID())
+
+	other := ServiceProposal{ID: 2, ServiceType: "wireguard", ProviderID: "0x1"}
+	assert.Equal(t, "wireguard-2", other.ServiceKey())
+	assert.Equal(t, ProposalID{ServiceType: "wireguard", ProviderID: "0x1", ID: 2}, other.UniqueID())
+}
@@ -161,13 +161,17 @@ func NewNode(appPath string, options *MobileNodeOptions) (*MobileNode, error) {
 		FeedbackURL:    options.FeedbackURL,
 		OptionsNetwork: network,
 		Quality: node.OptionsQuality{
-			Type:    node.QualityTypeMORQA,
-			Address: options.QualityOracleURL,
+			Type:       node.QualityTypeMORQA,
+			Address:    options.QualityOracleURL,
+			MetricsTTL: time.Minute,
 		},
 		Discovery: node.OptionsDiscovery{
-			Types:        []node.DiscoveryType{node.DiscoveryTypeAPI, node.DiscoveryTypeBroker, node.DiscoveryTypeDHT},
-			Address:      network.MysteriumAPIAddress,
-			FetchEnabled: false,
+			Types:             []node.DiscoveryType{node.DiscoveryTypeAPI, node.DiscoveryTypeBroker, node.DiscoveryTypeDHT},
+			Address:           network.MysteriumAPIAddress,
+			FetchEnabled:      false,
+			CacheTTL:          24 * time.Hour,
+			PriceMaxRatio:     10,
+			PriceHideOutliers: true,
 			DHT: node.OptionsDHT{
 				Address:        "0.0.0.0",
 				Port:           0,
@@ -175,6 +179,13 @@ func NewNode(appPath string, options *MobileNodeOptions) (*MobileNode, error) {
 				BootstrapPeers: []string{},
 			},
 		},
+		ConnectTimeouts: node.OptionsConnectTimeouts{
+			Connect:       connection.DefaultTimeoutConfig().Connect,
+			ProposalFetch: connection.DefaultTimeoutConfig().ProposalFetch,
+			P2PDial:       connection.DefaultTimeoutConfig().P2PDial,
+			SessionCreate: connection.DefaultTimeoutConfig().SessionCreate,
+			TunnelUp:      connection.DefaultTimeoutConfig().TunnelUp,
+		},
 		Location: node.OptionsLocation{
 			IPDetectorURL: options.IPDetectorURL,
 			Type:          node.LocationTypeOracle,
@@ -187,6 +198,10 @@ func NewNode(appPath string, options *MobileNodeOptions) (*MobileNode, error) {
 			ProviderMaxRegistrationAttempts: 10,
 			ProviderRegistrationRetryDelay:  time.Minute * 3,
 			ProviderRegistrationStake:       big.NewInt(6200000000),
+			RegistrationRetryMaxAttempts:    10,
+			RegistrationRetryDelay:          time.Minute,
+			RegistrationRetryMaxDelay:       time.Hour,
+			RegistrationRetryStuckTimeout:   time.Minute * 30,
 		},
 		Hermes: node.OptionsHermes{
 			HermesID: options.HermesID,
@@ -198,8 +213,10 @@ func NewNode(appPath string, options *MobileNodeOptions) (*MobileNode, error) {
 			SettlementTimeout:              time.Hour * 2,
 			MystSCAddress:                  options.MystSCAddress,
 		},
-		Consumer: true,
-		P2PPorts: port.UnspecifiedRange(),
+		Consumer:               true,
+		P2PPorts:               port.UnspecifiedRange(),
+		P2PPortRange:           port.UnspecifiedRange(),
+		P2PKeyRotationInterval: time.Hour,
 	}
 
 	err := di.Bootstrap(nodeOptions)
@@ -229,7 +246,7 @@ func NewNode(appPath string, options *MobileNodeOptions) (*MobileNode, error) {
 		proposalsManager: newProposalsManager(
 			di.ProposalRepository,
 			di.MysteriumAPI,
-			di.QualityClient,
+			di.QualityMetrics,
 		),
 		startTime: time.Now(),
 	}
@@ -481,10 +498,22 @@ func (mb *MobileNode) GetIdentityRegistrationFees() (*GetIdentityRegistrationFee
 type RegisterIdentityRequest struct {
 	IdentityAddress string
 	Token           string
+	// Sponsored registration is paid by a third-party, so identity without funds can be registered.
+	Sponsored bool
+	// Sponsor identifies the third-party, default sponsor of transactor is used if empty.
+	Sponsor string
 }
 
 // RegisterIdentity starts identity registration in background.
 func (mb *MobileNode) RegisterIdentity(req *RegisterIdentityRequest) error {
+	if req.Sponsored {
+		err := mb.transactor.RegisterIdentitySponsored(req.IdentityAddress, "", req.Sponsor)
+		if err != nil {
+			return errors.Wrap(err, "could not register identity")
+		}
+		return nil
+	}
+
 	fees, err := mb.transactor.FetchRegistrationFees()
 	if err != nil {
 		return errors.Wrap(err, "could not get registration fees")
@@ -277,6 +277,10 @@ func (w *wireguardDeviceImpl) newTunnDevice(wgTunnSetup WireguardTunnelSetup, co
 	wgTunnSetup.NewTunnel()
 	wgTunnSetup.SetSessionName("wg-tun-session")
 	wgTunnSetup.AddTunnelAddress(consumerIP.IP.String(), prefixLen)
+	if consumerIPv6 := config.Consumer.IPv6Address; consumerIPv6.IP != nil {
+		prefixLen, _ := consumerIPv6.Mask.Size()
+		wgTunnSetup.AddTunnelAddress(consumerIPv6.IP.String(), prefixLen)
+	}
 	wgTunnSetup.SetMTU(androidTunMtu)
 	wgTunnSetup.SetBlocking(true)
 
@@ -289,9 +293,11 @@ func (w *wireguardDeviceImpl) newTunnDevice(wgTunnSetup WireguardTunnelSetup, co
 		wgTunnSetup.AddDNS(dnsIP)
 	}
 
-	// Route all traffic through tunnel
+	// Route all traffic through tunnel, IPv6 traffic is captured even if tunnel does not carry it, so it does not leak.
 	wgTunnSetup.AddRoute("0.0.0.0", 1)
 	wgTunnSetup.AddRoute("128.0.0.0", 1)
+	wgTunnSetup.AddRoute("::", 1)
+	wgTunnSetup.AddRoute("8000::", 1)
 
 	fd, err := wgTunnSetup.Establish()
 	if err != nil {
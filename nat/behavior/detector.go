@@ -0,0 +1,134 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package behavior
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/mysteriumnetwork/node/market"
+)
+
+const stunTimeout = 3 * time.Second
+
+// Detector detects type of NAT the node is behind by comparing its addresses seen by several STUN servers.
+type Detector struct {
+	servers  []string
+	ttl      time.Duration
+	timeout  time.Duration
+	localIPs func() ([]net.IP, error)
+
+	lock       sync.Mutex
+	natType    string
+	detectedAt time.Time
+}
+
+// NewDetector returns NAT type detector using the given STUN servers, detected type is kept for ttl.
+func NewDetector(servers []string, ttl time.Duration) *Detector {
+	return &Detector{
+		servers:  servers,
+		ttl:      ttl,
+		timeout:  stunTimeout,
+		localIPs: interfaceIPs,
+	}
+}
+
+// NATType returns detected NAT type, detecting it again when the previous result is older than ttl.
+// Empty type is returned when it could not be detected.
+func (d *Detector) NATType() string {
+	if len(d.servers) == 0 {
+		return ""
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if !d.detectedAt.IsZero() && time.Since(d.detectedAt) < d.ttl {
+		return d.natType
+	}
+
+	natType, err := d.detect()
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not detect NAT type")
+	} else {
+		log.Info().Msgf("Detected NAT type: %s", natType)
+	}
+	d.natType = natType
+	d.detectedAt = time.Now()
+	return d.natType
+}
+
+func (d *Detector) detect() (string, error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var mapped []*net.UDPAddr
+	for _, server := range d.servers {
+		addr, err := mappedAddress(conn, server, d.timeout)
+		if err != nil {
+			log.Debug().Err(err).Msg("STUN request failed")
+			continue
+		}
+		mapped = append(mapped, addr)
+	}
+	if len(mapped) == 0 {
+		return "", errors.New("none of STUN servers responded")
+	}
+
+	localIPs, err := d.localIPs()
+	if err != nil {
+		return "", err
+	}
+	for _, ip := range localIPs {
+		if ip.Equal(mapped[0].IP) {
+			return market.NATTypePublic, nil
+		}
+	}
+
+	if len(mapped) < 2 {
+		return "", errors.New("at least two STUN servers have to respond to tell NAT type")
+	}
+	for _, addr := range mapped[1:] {
+		if !addr.IP.Equal(mapped[0].IP) || addr.Port != mapped[0].Port {
+			return market.NATTypeSymmetric, nil
+		}
+	}
+	return market.NATTypePortRestricted, nil
+}
+
+func interfaceIPs() ([]net.IP, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	return ips, nil
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package behavior
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// startSTUNServer starts STUN server on localhost which reports the given address, or the request source when it is nil.
+func startSTUNServer(t *testing.T, reported *net.UDPAddr) (string, func()) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, stunMaxResponseBytes)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < stunHeaderSize {
+				continue
+			}
+			addr := reported
+			if addr == nil {
+				addr = from.(*net.UDPAddr)
+			}
+			conn.WriteTo(bindingResponse(buf[8:20], addr), from)
+		}
+	}()
+	return conn.LocalAddr().String(), func() { conn.Close() }
+}
+
+func bindingResponse(transactionID []byte, addr *net.UDPAddr) []byte {
+	response := make([]byte, stunHeaderSize+12)
+	binary.BigEndian.PutUint16(response[0:2], stunBindingResponse)
+	binary.BigEndian.PutUint16(response[2:4], 12)
+	binary.BigEndian.PutUint32(response[4:8], stunMagicCookie)
+	copy(response[8:20], transactionID)
+
+	attr := response[stunHeaderSize:]
+	binary.BigEndian.PutUint16(attr[0:2], stunAttrXORMapped)
+	binary.BigEndian.PutUint16(attr[2:4], 8)
+	attr[5] = stunFamilyIPv4
+	binary.BigEndian.PutUint16(attr[6:8], uint16(addr.Port)^(stunMagicCookie>>16))
+	binary.BigEndian.PutUint32(attr[8:12], binary.BigEndian.Uint32(addr.IP.To4())^stunMagicCookie)
+	return response
+}
+
+func newTestDetector(servers ...string) *Detector {
+	detector := NewDetector(servers, time.Hour)
+	detector.timeout = time.Second
+	return detector
+}
+
+func TestDetector_NATType(t *testing.T) {
+	tests := map[string]struct {
+		reported []*net.UDPAddr
+		want     string
+	}{
+		"public": {
+			reported: []*net.UDPAddr{nil, nil},
+			want:     market.NATTypePublic,
+		},
+		"port restricted": {
+			reported: []*net.UDPAddr{
+				{IP: net.ParseIP("203.0.113.1"), Port: 40000},
+				{IP: net.ParseIP("203.0.113.1"), Port: 40000},
+			},
+			want: market.NATTypePortRestricted,
+		},
+		"symmetric": {
+			reported: []*net.UDPAddr{
+				{IP: net.ParseIP("203.0.113.1"), Port: 40000},
+				{IP: net.ParseIP("203.0.113.1"), Port: 40001},
+			},
+			want: market.NATTypeSymmetric,
+		},
+		"single server": {
+			reported: []*net.UDPAddr{
+				{IP: net.ParseIP("203.0.113.1"), Port: 40000},
+			},
+			want: "",
+		},
+		"no servers": {
+			want: "",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var servers []string
+			for _, reported := range tt.reported {
+				server, stop := startSTUNServer(t, reported)
+				defer stop()
+				servers = append(servers, server)
+			}
+
+			assert.Equal(t, tt.want, newTestDetector(servers...).NATType())
+		})
+	}
+}
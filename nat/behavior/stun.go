@@ -0,0 +1,156 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package behavior
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// STUN binding request and response as described in RFC 5389, only the parts required to learn mapped address.
+const (
+	stunHeaderSize       = 20
+	stunMagicCookie      = 0x2112A442
+	stunBindingRequest   = 0x0001
+	stunBindingResponse  = 0x0101
+	stunAttrMapped       = 0x0001
+	stunAttrXORMapped    = 0x0020
+	stunFamilyIPv4       = 0x01
+	stunMaxResponseBytes = 1024
+)
+
+var errNoMappedAddress = errors.New("no mapped address in STUN response")
+
+func newBindingRequest() (transactionID [12]byte, request []byte, err error) {
+	if _, err := rand.Read(transactionID[:]); err != nil {
+		return transactionID, nil, err
+	}
+
+	request = make([]byte, stunHeaderSize)
+	binary.BigEndian.PutUint16(request[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint16(request[2:4], 0)
+	binary.BigEndian.PutUint32(request[4:8], stunMagicCookie)
+	copy(request[8:20], transactionID[:])
+	return transactionID, request, nil
+}
+
+// parseBindingResponse returns address of the request as seen by STUN server.
+func parseBindingResponse(transactionID [12]byte, response []byte) (*net.UDPAddr, error) {
+	if len(response) < stunHeaderSize {
+		return nil, fmt.Errorf("STUN response too short: %d bytes", len(response))
+	}
+	if msgType := binary.BigEndian.Uint16(response[0:2]); msgType != stunBindingResponse {
+		return nil, fmt.Errorf("unexpected STUN message type: %#x", msgType)
+	}
+	if string(response[8:20]) != string(transactionID[:]) {
+		return nil, errors.New("STUN transaction ID mismatch")
+	}
+
+	length := int(binary.BigEndian.Uint16(response[2:4]))
+	if stunHeaderSize+length > len(response) {
+		return nil, errors.New("STUN response truncated")
+	}
+
+	var mapped *net.UDPAddr
+	attrs := response[stunHeaderSize : stunHeaderSize+length]
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if 4+attrLen > len(attrs) {
+			break
+		}
+		value := attrs[4 : 4+attrLen]
+
+		switch attrType {
+		case stunAttrXORMapped:
+			if addr, ok := parseAddress(value, true); ok {
+				return addr, nil
+			}
+		case stunAttrMapped:
+			if addr, ok := parseAddress(value, false); ok {
+				mapped = addr
+			}
+		}
+
+		// Attributes are padded to the multiple of 4 bytes.
+		next := 4 + (attrLen+3)/4*4
+		if next > len(attrs) {
+			break
+		}
+		attrs = attrs[next:]
+	}
+
+	if mapped == nil {
+		return nil, errNoMappedAddress
+	}
+	return mapped, nil
+}
+
+func parseAddress(value []byte, xored bool) (*net.UDPAddr, bool) {
+	if len(value) < 8 || value[1] != stunFamilyIPv4 {
+		return nil, false
+	}
+
+	port := binary.BigEndian.Uint16(value[2:4])
+	ip := make(net.IP, net.IPv4len)
+	copy(ip, value[4:8])
+	if xored {
+		port ^= stunMagicCookie >> 16
+		var cookie [4]byte
+		binary.BigEndian.PutUint32(cookie[:], stunMagicCookie)
+		for i := range ip {
+			ip[i] ^= cookie[i]
+		}
+	}
+	return &net.UDPAddr{IP: ip, Port: int(port)}, true
+}
+
+// mappedAddress sends binding request to STUN server from the given connection and returns address the server sees.
+func mappedAddress(conn net.PacketConn, server string, timeout time.Duration) (*net.UDPAddr, error) {
+	serverAddr, err := net.ResolveUDPAddr("udp4", server)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve STUN server %s: %w", server, err)
+	}
+
+	transactionID, request, err := newBindingRequest()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteTo(request, serverAddr); err != nil {
+		return nil, fmt.Errorf("could not send STUN request to %s: %w", server, err)
+	}
+
+	buf := make([]byte, stunMaxResponseBytes)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil, fmt.Errorf("no STUN response from %s: %w", server, err)
+		}
+		if from.String() != serverAddr.String() {
+			continue
+		}
+		return parseBindingResponse(transactionID, buf[:n])
+	}
+}
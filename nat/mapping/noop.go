@@ -44,3 +44,7 @@ func (p *noopPortMapper) Map(protocol string, port int, name string) (release fu
 		log.Debug().Msgf("Noop port mapping released: %d", port)
 	}, false
 }
+
+func (p *noopPortMapper) Leases() []Lease {
+	return nil
+}
@@ -20,6 +20,8 @@ package mapping
 import (
 	"errors"
 	"net"
+	"sort"
+	"sync"
 	"time"
 
 	portmap "github.com/ethereum/go-ethereum/p2p/nat"
@@ -53,6 +55,25 @@ type PortMapper interface {
 	// must be called when port no longer needed and ok which is true if
 	// port mapping was successful.
 	Map(protocol string, port int, name string) (release func(), ok bool)
+
+	// Leases returns port mappings currently held on the router.
+	Leases() []Lease
+}
+
+// Lease represents port mapping held on the router.
+type Lease struct {
+	Protocol string
+	Port     int
+	Name     string
+	// Method is the router protocol used for mapping, e.g. UPnP IGD or NAT-PMP.
+	Method string
+	// Permanent is set when router supports permanent leases only, those are not renewed.
+	Permanent bool
+	RenewedAt time.Time
+	// ExpiresAt is zero for permanent leases.
+	ExpiresAt time.Time
+	// Error is the error of the last lease renewal, if it failed.
+	Error error
 }
 
 // NewPortMapper returns port mapper instance.
@@ -60,12 +81,21 @@ func NewPortMapper(config *Config, publisher eventbus.Publisher) PortMapper {
 	return &portMapper{
 		config:    config,
 		publisher: publisher,
+		leases:    make(map[leaseKey]*Lease),
 	}
 }
 
+type leaseKey struct {
+	protocol string
+	port     int
+}
+
 type portMapper struct {
 	config    *Config
 	publisher eventbus.Publisher
+
+	mu     sync.Mutex
+	leases map[leaseKey]*Lease
 }
 
 func (p *portMapper) Map(protocol string, port int, name string) (release func(), ok bool) {
@@ -85,9 +115,14 @@ func (p *portMapper) Map(protocol string, port int, name string) (release func()
 		return nil, false
 	}
 
+	p.storeLease(protocol, port, name, permanent)
+
 	// If only permanent lease is supported we don't need to update it in intervals.
 	if permanent {
-		return func() { p.deleteMapping(protocol, port, port) }, true
+		return func() {
+			p.deleteLease(protocol, port)
+			p.deleteMapping(protocol, port, port)
+		}, true
 	}
 
 	stopUpdate := make(chan struct{})
@@ -99,16 +134,76 @@ func (p *portMapper) Map(protocol string, port int, name string) (release func()
 			case <-time.After(p.config.MapUpdateInterval):
 				_, err := p.addMapping(protocol, port, port, name)
 				p.notify(err)
+				p.renewLease(protocol, port, err)
 			}
 		}
 	}()
 
 	return func() {
+		p.deleteLease(protocol, port)
 		p.deleteMapping(protocol, port, port)
 		close(stopUpdate)
 	}, true
 }
 
+// Leases returns port mappings currently held on the router ordered by port.
+func (p *portMapper) Leases() []Lease {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	leases := make([]Lease, 0, len(p.leases))
+	for _, l := range p.leases {
+		leases = append(leases, *l)
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		if leases[i].Port == leases[j].Port {
+			return leases[i].Protocol < leases[j].Protocol
+		}
+		return leases[i].Port < leases[j].Port
+	})
+	return leases
+}
+
+func (p *portMapper) storeLease(protocol string, port int, name string, permanent bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lease := &Lease{
+		Protocol:  protocol,
+		Port:      port,
+		Name:      name,
+		Method:    p.config.MapInterface.String(),
+		Permanent: permanent,
+		RenewedAt: time.Now(),
+	}
+	if !permanent {
+		lease.ExpiresAt = lease.RenewedAt.Add(p.config.MapLifetime)
+	}
+	p.leases[leaseKey{protocol: protocol, port: port}] = lease
+}
+
+func (p *portMapper) renewLease(protocol string, port int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lease, ok := p.leases[leaseKey{protocol: protocol, port: port}]
+	if !ok {
+		return
+	}
+	lease.Error = err
+	if err == nil {
+		lease.RenewedAt = time.Now()
+		lease.ExpiresAt = lease.RenewedAt.Add(p.config.MapLifetime)
+	}
+}
+
+func (p *portMapper) deleteLease(protocol string, port int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.leases, leaseKey{protocol: protocol, port: port})
+}
+
 func (p *portMapper) routerIPPublic() bool {
 	ip, err := p.config.MapInterface.ExternalIP()
 	if err != nil {
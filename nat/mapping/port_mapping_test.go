@@ -74,6 +74,41 @@ func TestMap_uPnP_Enabled_With_Permanent_Lease(t *testing.T) {
 	}, router.addedMapping())
 }
 
+func TestMap_Leases(t *testing.T) {
+	router := &mockRouter{uPnPEnabled: true}
+	config := &Config{
+		MapInterface:      router,
+		MapUpdateInterval: 5 * time.Millisecond,
+		MapLifetime:       time.Minute,
+	}
+	portMapper := NewPortMapper(config, mocks.NewEventBus())
+
+	release1, ok := portMapper.Map("UDP", 51335, "Test 1")
+	assert.True(t, ok)
+	release2, ok := portMapper.Map("UDP", 51334, "Test 2")
+	assert.True(t, ok)
+
+	leases := portMapper.Leases()
+	assert.Len(t, leases, 2)
+	assert.Equal(t, 51334, leases[0].Port)
+	assert.Equal(t, "Test 2", leases[0].Name)
+	assert.Equal(t, "UDP", leases[0].Protocol)
+	assert.False(t, leases[0].Permanent)
+	assert.Equal(t, leases[0].RenewedAt.Add(config.MapLifetime), leases[0].ExpiresAt)
+	assert.Equal(t, 51335, leases[1].Port)
+
+	time.Sleep(config.MapUpdateInterval * 3)
+	assert.True(t, portMapper.Leases()[0].RenewedAt.After(leases[0].RenewedAt))
+
+	release2()
+	leases = portMapper.Leases()
+	assert.Len(t, leases, 1)
+	assert.Equal(t, 51335, leases[0].Port)
+
+	release1()
+	assert.Empty(t, portMapper.Leases())
+}
+
 func TestMap_uPnP_Disabled(t *testing.T) {
 	router := &mockRouter{uPnPEnabled: false}
 	config := &Config{
@@ -25,15 +25,84 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func protectedNetworks() (nets []*net.IPNet) {
-	cfg := config.GetString(config.FlagFirewallProtectedNetworks)
+// privateRanges are the ranges networks of provider's interfaces are protected in, even if they are not configured.
+var privateRanges = parseNetworks("10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,100.64.0.0/10,169.254.0.0/16")
+
+// protectedNetworks returns configured protected networks together with private networks provider's interfaces are in.
+func protectedNetworks() []*net.IPNet {
+	nets := parseNetworks(config.GetString(config.FlagFirewallProtectedNetworks))
+	for _, ipNet := range localNetworks() {
+		if !containsNetwork(nets, ipNet) {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// allowedNetworks returns networks consumers are allowed to reach, even though they are protected.
+func allowedNetworks() []*net.IPNet {
+	return parseNetworks(config.GetString(config.FlagFirewallAllowedNetworks))
+}
+
+// managementPorts returns TCP ports node is managed on, which consumers are never allowed to reach.
+func managementPorts() []int {
+	ports := []int{config.GetInt(config.FlagTequilapiPort)}
+	if config.GetBool(config.FlagUIEnable) {
+		ports = append(ports, config.GetInt(config.FlagUIPort))
+	}
+
+	var result []int
+	for _, port := range ports {
+		if port > 0 {
+			result = append(result, port)
+		}
+	}
+	return result
+}
+
+// localNetworks returns private IPv4 networks provider's interfaces are in.
+func localNetworks() (nets []*net.IPNet) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not list interface addresses to protect their networks")
+		return nil
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() == nil {
+			continue
+		}
+		network := &net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask).To4(), Mask: ipNet.Mask[len(ipNet.Mask)-net.IPv4len:]}
+		for _, private := range privateRanges {
+			if private.Contains(network.IP) {
+				nets = append(nets, network)
+				break
+			}
+		}
+	}
+	return nets
+}
+
+func containsNetwork(nets []*net.IPNet, network *net.IPNet) bool {
+	ones, _ := network.Mask.Size()
+	for _, n := range nets {
+		nOnes, _ := n.Mask.Size()
+		if n.Contains(network.IP) && nOnes <= ones {
+			return true
+		}
+	}
+	return false
+}
+
+func parseNetworks(cfg string) (nets []*net.IPNet) {
 	if cfg == "" {
 		return nil
 	}
 	for _, s := range strings.Split(cfg, ",") {
 		_, ipNet, err := net.ParseCIDR(s)
 		if err != nil {
-			log.Error().Err(err).Msg("Could not parse protected network string")
+			log.Error().Err(err).Msg("Could not parse network string")
 			continue
 		}
 		nets = append(nets, ipNet)
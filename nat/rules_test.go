@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package nat
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// setFirewallConfig sets firewall configuration, returned function restores it.
+func setFirewallConfig(protected, allowed string) func() {
+	config.Current.SetCLI(config.FlagFirewallProtectedNetworks.Name, protected)
+	config.Current.SetCLI(config.FlagFirewallAllowedNetworks.Name, allowed)
+	config.Current.SetCLI(config.FlagTequilapiPort.Name, 4050)
+	config.Current.SetCLI(config.FlagUIEnable.Name, true)
+	config.Current.SetCLI(config.FlagUIPort.Name, 4449)
+	return func() {
+		for _, flag := range []string{
+			config.FlagFirewallProtectedNetworks.Name,
+			config.FlagFirewallAllowedNetworks.Name,
+			config.FlagTequilapiPort.Name,
+			config.FlagUIEnable.Name,
+			config.FlagUIPort.Name,
+		} {
+			config.Current.RemoveCLI(flag)
+		}
+	}
+}
+
+func Test_protectedNetworks_IncludesConfiguredNetworks(t *testing.T) {
+	defer setFirewallConfig("10.0.0.0/8,invalid,192.168.0.0/16", "")()
+
+	networks := protectedNetworks()
+	assert.True(t, len(networks) >= 2)
+	assert.Equal(t, "10.0.0.0/8", networks[0].String())
+	assert.Equal(t, "192.168.0.0/16", networks[1].String())
+}
+
+func Test_containsNetwork(t *testing.T) {
+	nets := parseNetworks("10.0.0.0/8")
+
+	_, inside, _ := net.ParseCIDR("10.1.0.0/16")
+	_, wider, _ := net.ParseCIDR("10.0.0.0/7")
+	_, outside, _ := net.ParseCIDR("192.168.1.0/24")
+	assert.True(t, containsNetwork(nets, inside))
+	assert.False(t, containsNetwork(nets, wider))
+	assert.False(t, containsNetwork(nets, outside))
+}
+
+func Test_managementPorts(t *testing.T) {
+	defer setFirewallConfig("", "")()
+	assert.Equal(t, []int{4050, 4449}, managementPorts())
+
+	config.Current.SetCLI(config.FlagUIEnable.Name, false)
+	assert.Equal(t, []int{4050}, managementPorts())
+}
+
+func Test_makeIPTablesRules_AllowedNetworksPrecedeProtectedNetworks(t *testing.T) {
+	defer setFirewallConfig("10.0.0.0/8", "10.1.2.0/24")()
+	_, vpnNetwork, _ := net.ParseCIDR("10.8.0.0/24")
+
+	var rules []string
+	for _, rule := range makeIPTablesRules(Options{
+		VPNNetwork:        *vpnNetwork,
+		ProviderExtIP:     net.ParseIP("1.2.3.4"),
+		EnableDNSRedirect: true,
+		DNSIP:             net.ParseIP("10.8.0.1"),
+		DNSPort:           11153,
+	}) {
+		rules = append(rules, strings.Join(rule.ApplyArgs(), " "))
+	}
+
+	index := func(rule string) int {
+		for i := range rules {
+			if rules[i] == rule {
+				return i
+			}
+		}
+		assert.Fail(t, "rule not found", rule)
+		return -1
+	}
+	managementDrop := index("-A INPUT --source 10.8.0.0/24 --protocol tcp --dport 4050 --jump DROP")
+	dnsAccept := index("-A INPUT --source 10.8.0.0/24 --protocol udp --dport 11153 --jump ACCEPT")
+	allowedForward := index("-A FORWARD --source 10.8.0.0/24 --destination 10.1.2.0/24 --jump ACCEPT")
+	allowedInput := index("-A INPUT --source 10.8.0.0/24 --destination 10.1.2.0/24 --jump ACCEPT")
+	protectedForward := index("-A FORWARD --source 10.8.0.0/24 --destination 10.0.0.0/8 --jump DROP")
+	protectedInput := index("-A INPUT --source 10.8.0.0/24 --destination 10.0.0.0/8 --jump DROP")
+
+	assert.True(t, managementDrop < allowedInput)
+	assert.True(t, dnsAccept < protectedInput)
+	assert.True(t, allowedInput < protectedInput)
+	assert.True(t, allowedForward < protectedForward)
+}
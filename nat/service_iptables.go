@@ -35,6 +35,7 @@ type serviceIPTables struct {
 }
 
 const (
+	chainInput       = "INPUT"
 	chainForward     = "FORWARD"
 	chainPreRouting  = "PREROUTING"
 	chainPostRouting = "POSTROUTING"
@@ -147,14 +148,44 @@ func makeIPTablesRules(opts Options) (rules []iptables.Rule) {
 		rules = append(rules, rule)
 	}
 
-	// Protect private networks rule
-	for _, ipNet := range protectedNetworks() {
-		rule := iptables.AppendTo(chainForward).RuleSpec(
-			"--source", vpnNetwork, "--destination", ipNet.String(),
+	// Protect node management ports, consumers may reach them on any of provider's addresses
+	for _, port := range managementPorts() {
+		rule := iptables.AppendTo(chainInput).RuleSpec(
+			"--source", vpnNetwork, "--protocol", "tcp", "--dport", strconv.Itoa(port),
 			"--jump", "DROP")
 		rules = append(rules, rule)
 	}
 
+	// Redirected DNS queries are delivered to the provider's address inside protected network
+	if opts.EnableDNSRedirect {
+		for _, protocol := range []string{"udp", "tcp"} {
+			rule := iptables.AppendTo(chainInput).RuleSpec(
+				"--source", vpnNetwork, "--protocol", protocol, "--dport", strconv.Itoa(opts.DNSPort),
+				"--jump", "ACCEPT")
+			rules = append(rules, rule)
+		}
+	}
+
+	// Allowed networks override protection of private networks
+	for _, ipNet := range allowedNetworks() {
+		for _, chain := range []string{chainInput, chainForward} {
+			rule := iptables.AppendTo(chain).RuleSpec(
+				"--source", vpnNetwork, "--destination", ipNet.String(),
+				"--jump", "ACCEPT")
+			rules = append(rules, rule)
+		}
+	}
+
+	// Protect private networks rule, both the ones behind the provider and provider's own addresses in them
+	for _, ipNet := range protectedNetworks() {
+		for _, chain := range []string{chainInput, chainForward} {
+			rule := iptables.AppendTo(chain).RuleSpec(
+				"--source", vpnNetwork, "--destination", ipNet.String(),
+				"--jump", "DROP")
+			rules = append(rules, rule)
+		}
+	}
+
 	// NAT forwarding rule
 	rule := iptables.AppendTo(chainPostRouting).RuleSpec("--source", vpnNetwork, "!", "--destination", vpnNetwork,
 		"--jump", "SNAT", "--to", opts.ProviderExtIP.String(),
@@ -20,6 +20,7 @@ package nat
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -104,6 +105,21 @@ func makePfctlRules(opts Options) (rules []string, err error) {
 		rules = append(rules, rule)
 	}
 
+	// Allowed networks override protection of private networks
+	if allowed := allowedNetworks(); len(allowed) > 0 {
+		var targets []string
+		for _, network := range allowed {
+			targets = append(targets, network.String())
+		}
+		rule := fmt.Sprintf("nat on %s inet from %s to { %s } -> %s",
+			externalIface,
+			opts.VPNNetwork.String(),
+			strings.Join(targets, ", "),
+			opts.ProviderExtIP,
+		)
+		rules = append(rules, rule)
+	}
+
 	// Protect private networks rule
 	networks := protectedNetworks()
 	if len(networks) > 0 {
@@ -127,6 +143,19 @@ func makePfctlRules(opts Options) (rules []string, err error) {
 	)
 	rules = append(rules, rule)
 
+	// Protect node management ports, filtering rules have to follow translation rules
+	if ports := managementPorts(); len(ports) > 0 {
+		var targets []string
+		for _, port := range ports {
+			targets = append(targets, strconv.Itoa(port))
+		}
+		rule := fmt.Sprintf("block drop in quick inet proto tcp from %s to any port { %s }",
+			opts.VPNNetwork.String(),
+			strings.Join(targets, ", "),
+		)
+		rules = append(rules, rule)
+	}
+
 	return rules, nil
 }
 
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"sort"
+
+	"github.com/mysteriumnetwork/node/pb"
+)
+
+// ProtocolVersion is the version of p2p protocol spoken by this node.
+const ProtocolVersion = 1
+
+const (
+	// CapabilityCompression allows sending large messages compressed.
+	CapabilityCompression = "compression"
+	// CapabilitySessionRenegotiate allows rotating tunnel keys of the session via TopicSessionRenegotiate.
+	CapabilitySessionRenegotiate = "session-renegotiate"
+	// CapabilitySessionShutdown allows notifying consumer about service shutdown via TopicSessionShutdown.
+	CapabilitySessionShutdown = "session-shutdown"
+	// CapabilityChannelRekey allows rotating channel keys via TopicChannelRekey.
+	CapabilityChannelRekey = "channel-rekey"
+	// CapabilityChannelRebind allows moving channel to another peer address via TopicChannelRebind.
+	CapabilityChannelRebind = "channel-rebind"
+	// CapabilityObfuscation allows disguising channel packets as DTLS records.
+	CapabilityObfuscation = "obfuscation"
+	// CapabilitySignedInvoices announces that provider signs every invoice with a session key authorized by its identity.
+	CapabilitySignedInvoices = "signed-invoices"
+)
+
+// localCapabilities are optional p2p protocol features supported by this node.
+var localCapabilities = []string{
+	CapabilityCompression,
+	CapabilitySessionRenegotiate,
+	CapabilitySessionShutdown,
+	CapabilityChannelRekey,
+	CapabilityChannelRebind,
+	CapabilityObfuscation,
+	CapabilitySignedInvoices,
+}
+
+// Capabilities describe p2p protocol features both peers of the channel support.
+// New topics and message formats should be used only if peer supports them.
+type Capabilities struct {
+	// Version is p2p protocol version both peers speak, peers predating negotiation speak version 0.
+	Version int
+	// Names are capabilities supported by both peers, sorted.
+	Names []string
+}
+
+// Has reports whether both peers support given capability.
+func (c Capabilities) Has(capability string) bool {
+	i := sort.SearchStrings(c.Names, capability)
+	return i < len(c.Names) && c.Names[i] == capability
+}
+
+// negotiateCapabilities returns capabilities supported by this node and its peer.
+func negotiateCapabilities(peerConfig *pb.P2PConnectConfig) Capabilities {
+	peer := make(map[string]struct{})
+	for _, name := range peerConfig.GetCapabilities() {
+		peer[name] = struct{}{}
+	}
+	// Peers predating negotiation announce compression support only.
+	if peerConfig.GetCompression() {
+		peer[CapabilityCompression] = struct{}{}
+	}
+
+	caps := Capabilities{Version: int(peerConfig.GetProtocolVersion())}
+	if caps.Version > ProtocolVersion {
+		caps.Version = ProtocolVersion
+	}
+	for _, name := range localCapabilities {
+		if _, ok := peer[name]; ok {
+			caps.Names = append(caps.Names, name)
+		}
+	}
+	sort.Strings(caps.Names)
+	return caps
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"testing"
+
+	"github.com/mysteriumnetwork/node/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		peer     *pb.P2PConnectConfig
+		expected Capabilities
+	}{
+		{
+			name:     "Peer predating negotiation",
+			peer:     &pb.P2PConnectConfig{},
+			expected: Capabilities{},
+		},
+		{
+			name:     "Peer predating negotiation with compression",
+			peer:     &pb.P2PConnectConfig{Compression: true},
+			expected: Capabilities{Names: []string{CapabilityCompression}},
+		},
+		{
+			name: "Peer with the same version",
+			peer: &pb.P2PConnectConfig{
+				ProtocolVersion: ProtocolVersion,
+				Capabilities:    localCapabilities,
+			},
+			expected: Capabilities{
+				Version: ProtocolVersion,
+				Names:   []string{CapabilityChannelRebind, CapabilityChannelRekey, CapabilityCompression, CapabilityObfuscation, CapabilitySessionRenegotiate, CapabilitySessionShutdown, CapabilitySignedInvoices},
+			},
+		},
+		{
+			name: "Newer peer",
+			peer: &pb.P2PConnectConfig{
+				ProtocolVersion: ProtocolVersion + 1,
+				Capabilities:    []string{"unknown", CapabilitySessionShutdown},
+			},
+			expected: Capabilities{
+				Version: ProtocolVersion,
+				Names:   []string{CapabilitySessionShutdown},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, negotiateCapabilities(tt.peer))
+		})
+	}
+}
+
+func TestCapabilities_Has(t *testing.T) {
+	caps := Capabilities{Names: []string{CapabilityCompression, CapabilitySessionShutdown}}
+
+	assert.True(t, caps.Has(CapabilityCompression))
+	assert.True(t, caps.Has(CapabilitySessionShutdown))
+	assert.False(t, caps.Has(CapabilitySessionRenegotiate))
+	assert.False(t, Capabilities{}.Has(CapabilityCompression))
+}
@@ -44,6 +44,9 @@ var (
 
 	// ErrHandlerNotFound indicates that peer is not registered handler yet.
 	ErrHandlerNotFound = errors.New("p2p peer handler not found")
+
+	// ErrPeerAtCapacity indicates that peer can not serve any more requests, e.g. it already serves maximum number of sessions.
+	ErrPeerAtCapacity = errors.New("p2p peer is at capacity")
 )
 
 const (
@@ -78,6 +81,18 @@ type Channel interface {
 	// Conn returns underlying channel's UDP connection.
 	Conn() *net.UDPConn
 
+	// Relayed reports whether channel traffic is routed through relay server since direct NAT traversal failed.
+	Relayed() bool
+
+	// Capabilities returns p2p protocol version and features negotiated with the peer.
+	Capabilities() Capabilities
+
+	// Metrics returns transport statistics of the channel.
+	Metrics() ChannelMetrics
+
+	// Rebind moves channel to a new local socket after host network changed and lets peer learn the new address.
+	Rebind(ctx context.Context) error
+
 	// Close closes p2p communication channel.
 	Close() error
 }
@@ -137,6 +152,9 @@ type channel struct {
 	mu   sync.RWMutex
 	once sync.Once
 
+	// connMu guards remote conn of the transport which is replaced when channel is rebound.
+	connMu sync.RWMutex
+
 	// tr is transport containing network related connections for p2p to work.
 	tr *transport
 
@@ -168,9 +186,27 @@ type channel struct {
 	// but to proxy conn which is when responsible for sending to remote
 	sendQueue chan *transportMsg
 
+	// prioritySendQueue is a queue for messages of priority topics and replies to them, it is drained before sendQueue.
+	prioritySendQueue chan *transportMsg
+
 	// upnpPortsRelease should be called to close mapped upnp ports when channel is closed.
 	upnpPortsRelease []func()
 
+	// relayed is set when channel traffic goes through relay server instead of directly to the peer.
+	relayed bool
+
+	// capabilities are p2p protocol features negotiated with the peer.
+	capabilities Capabilities
+
+	// metrics collects transport statistics of the channel.
+	metrics *channelMetrics
+
+	// crypt encrypts channel packets, its keys are rotated during long sessions.
+	crypt *rotatingBlockCrypt
+
+	// obfuscator disguises channel packets on the wire, nil if obfuscation was not negotiated with the peer.
+	obfuscator *obfuscator
+
 	// stop is used to stop all running goroutines.
 	stop chan struct{}
 
@@ -198,7 +234,13 @@ func newChannel(remoteConn *net.UDPConn, privateKey PrivateKey, peerPubKey Publi
 	}
 
 	// Setup KCP session. It will write to proxy conn only.
-	udpSession, sessAddr, err := listenUDPSession(proxyConn.LocalAddr(), privateKey, peerPubKey)
+	blockCrypt, err := newBlockCrypt(privateKey, peerPubKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not create block crypt: %w", err)
+	}
+	crypt := newRotatingBlockCrypt(blockCrypt)
+	metrics := &channelMetrics{}
+	udpSession, sessAddr, err := listenUDPSession(proxyConn.LocalAddr(), &metricsBlockCrypt{BlockCrypt: crypt, metrics: metrics})
 	if err != nil {
 		return nil, fmt.Errorf("could not create KCP UDP session: %w", err)
 	}
@@ -219,36 +261,42 @@ func newChannel(remoteConn *net.UDPConn, privateKey PrivateKey, peerPubKey Publi
 	}
 
 	c := channel{
-		tr:               &tr,
-		topicHandlers:    make(map[string]HandlerFunc),
-		streams:          make(map[uint64]*stream),
-		privateKey:       privateKey,
-		peer:             &peer,
-		localSessionAddr: sessAddr,
-		serviceConn:      nil,
-		stop:             make(chan struct{}, 1),
-		sendQueue:        make(chan *transportMsg, 100),
-		remoteAlive:      make(chan struct{}, 1),
+		tr:                &tr,
+		topicHandlers:     make(map[string]HandlerFunc),
+		streams:           make(map[uint64]*stream),
+		privateKey:        privateKey,
+		peer:              &peer,
+		localSessionAddr:  sessAddr,
+		serviceConn:       nil,
+		stop:              make(chan struct{}, 1),
+		sendQueue:         make(chan *transportMsg, 100),
+		prioritySendQueue: make(chan *transportMsg, 100),
+		remoteAlive:       make(chan struct{}, 1),
+		metrics:           metrics,
+		crypt:             crypt,
 	}
+	c.topicHandlers[TopicChannelRekey] = c.handleRekey
+	c.topicHandlers[TopicChannelRebind] = c.handleRebind
 
 	return &c, nil
 }
 
 func (c *channel) launchReadSendLoops() {
-	go c.remoteReadLoop()
+	go c.checkIfChannelAlive()
+	go c.remoteReadLoop(c.tr.remoteConn)
 	go c.remoteSendLoop()
 	go c.localReadLoop()
 	go c.localSendLoop()
 }
 
-// remoteReadLoop reads from remote conn and writes to local KCP UDP conn.
-// If remote peer addr changes it will be updated and next send will use new addr.
-func (c *channel) remoteReadLoop() {
+// remoteReadLoop reads from remote conn and writes to local KCP UDP conn. It returns once conn is closed,
+// either by closing the channel or by rebinding it to a new conn.
+// If remote peer addr changes it will be updated and next send will use new addr. Address is updated only
+// by packets encrypted with the channel key, so that nobody else can redirect channel traffic.
+func (c *channel) remoteReadLoop(conn *net.UDPConn) {
 	buf := make([]byte, mtuLimit)
 	latestPeerAddr := c.peer.addr()
 
-	go c.checkIfChannelAlive()
-
 	for {
 		select {
 		case <-c.stop:
@@ -256,7 +304,7 @@ func (c *channel) remoteReadLoop() {
 		default:
 		}
 
-		n, addr, err := c.tr.remoteConn.ReadFrom(buf)
+		n, addr, err := conn.ReadFrom(buf)
 		if err != nil {
 			if !errNetClose(err) {
 				log.Error().Err(err).Msg("Read from remote conn failed")
@@ -264,6 +312,15 @@ func (c *channel) remoteReadLoop() {
 			return
 		}
 
+		c.metrics.packetReceived(n)
+		packet := buf[:n]
+		if c.obfuscator != nil {
+			unwrapped, ok := c.obfuscator.unwrap(packet)
+			if !ok {
+				continue
+			}
+			packet = unwrapped
+		}
 		c.remoteAliveOnce.Do(func() {
 			close(c.remoteAlive)
 		})
@@ -271,13 +328,17 @@ func (c *channel) remoteReadLoop() {
 		// Check if peer address changed.
 		if addr, ok := addr.(*net.UDPAddr); ok {
 			if !addr.IP.Equal(latestPeerAddr.IP) || addr.Port != latestPeerAddr.Port {
+				if !c.crypt.authentic(packet) {
+					log.Trace().Msgf("Dropping unauthenticated packet from %v", addr)
+					continue
+				}
 				log.Debug().Msgf("Peer address changed from %v to %v", latestPeerAddr, addr)
 				c.peer.updateAddr(addr)
 				latestPeerAddr = addr
 			}
 		}
 
-		_, err = c.tr.proxyConn.WriteToUDP(buf[:n], c.localSessionAddr)
+		_, err = c.tr.proxyConn.WriteToUDP(packet, c.localSessionAddr)
 		if err != nil {
 			if !errNetClose(err) {
 				log.Error().Err(err).Msg("Write to local udp session failed")
@@ -306,13 +367,22 @@ func (c *channel) remoteSendLoop() {
 			return
 		}
 
-		_, err = c.tr.remoteConn.WriteToUDP(buf[:n], c.peer.addr())
+		packet := buf[:n]
+		if c.obfuscator != nil {
+			packet = c.obfuscator.wrap(packet)
+		}
+		_, err = c.remoteConn().WriteToUDP(packet, c.peer.addr())
 		if err != nil {
-			if !errNetClose(err) {
-				log.Error().Err(err).Msgf("Write to remote peer conn failed")
+			select {
+			case <-c.stop:
+				return
+			default:
 			}
-			return
+			// Writes fail while host is moving to another network, KCP retransmits the packet once channel is rebound.
+			log.Debug().Err(err).Msgf("Write to remote peer conn failed")
+			continue
 		}
+		c.metrics.packetSent(len(packet))
 	}
 }
 
@@ -333,6 +403,11 @@ func (c *channel) localReadLoop() {
 			return
 		}
 
+		if err := msg.decompress(); err != nil {
+			log.Err(err).Msgf("Dropping message %d", msg.id)
+			continue
+		}
+
 		if debugTransport {
 			fmt.Printf("recv from %s: %+v\n", c.tr.session.RemoteAddr(), msg)
 		}
@@ -351,28 +426,60 @@ func (c *channel) localReadLoop() {
 // localSendLoop sends data to local proxy conn.
 func (c *channel) localSendLoop() {
 	for {
-		select {
-		case <-c.stop:
+		msg, ok := c.nextSendMsg()
+		if !ok {
 			return
-		case msg, more := <-c.sendQueue:
-			if !more {
-				return
-			}
+		}
+
+		if debugTransport {
+			fmt.Printf("send to %s: %+v\n", c.tr.session.RemoteAddr(), msg)
+		}
 
-			if debugTransport {
-				fmt.Printf("send to %s: %+v\n", c.tr.session.RemoteAddr(), msg)
+		if c.Capabilities().Has(CapabilityCompression) {
+			if err := msg.compress(); err != nil {
+				log.Warn().Err(err).Msgf("Sending message %d uncompressed", msg.id)
 			}
+		}
 
-			if err := msg.writeTo(c.tr.textWriter); err != nil {
-				if !errPipeClosed(err) && !errNetClose(err) {
-					log.Err(err).Msg("Write to textproto writer failed")
-				}
-				return
+		if err := msg.writeTo(c.tr.textWriter); err != nil {
+			if !errPipeClosed(err) && !errNetClose(err) {
+				log.Err(err).Msg("Write to textproto writer failed")
 			}
+			return
 		}
 	}
 }
 
+// nextSendMsg waits for the next message to send, messages from priority queue are taken first.
+// It returns false once channel is stopped.
+func (c *channel) nextSendMsg() (*transportMsg, bool) {
+	select {
+	case <-c.stop:
+		return nil, false
+	case msg := <-c.prioritySendQueue:
+		return msg, true
+	default:
+	}
+
+	select {
+	case <-c.stop:
+		return nil, false
+	case msg := <-c.prioritySendQueue:
+		return msg, true
+	case msg := <-c.sendQueue:
+		return msg, true
+	}
+}
+
+// enqueue schedules message for sending, messages of priority topics skip ahead of other queued messages.
+func (c *channel) enqueue(msg *transportMsg, topic string) {
+	if priorityTopics[topic] {
+		c.prioritySendQueue <- msg
+		return
+	}
+	c.sendQueue <- msg
+}
+
 // handleReply forwards reply message to associated stream result channel.
 func (c *channel) handleReply(msg *transportMsg) {
 	c.mu.RLock()
@@ -398,13 +505,17 @@ func (c *channel) handleRequest(msg *transportMsg) {
 		errMsg := fmt.Sprintf("handler %q not found", msg.topic)
 		log.Err(errors.New(errMsg))
 		resMsg.data = []byte(errMsg)
-		c.sendQueue <- &resMsg
+		c.enqueue(&resMsg, msg.topic)
 		return
 	}
 
 	ctx := defaultContext{req: &Message{Data: msg.data}}
 	err := handler(&ctx)
-	if err != nil {
+	if errors.Is(err, ErrPeerAtCapacity) {
+		log.Warn().Err(err).Msgf("Handler %q is at capacity", msg.topic)
+		resMsg.statusCode = statusCodeAtCapacityErr
+		resMsg.msg = err.Error()
+	} else if err != nil {
 		log.Err(err).Msgf("Handler %q internal error", msg.topic)
 		resMsg.statusCode = statusCodeInternalErr
 		resMsg.msg = err.Error()
@@ -418,7 +529,7 @@ func (c *channel) handleRequest(msg *transportMsg) {
 			resMsg.data = ctx.res.Data
 		}
 	}
-	c.sendQueue <- &resMsg
+	c.enqueue(&resMsg, msg.topic)
 }
 
 // Tracer returns tracer which tracks channel establishment
@@ -443,7 +554,7 @@ func (c *channel) Close() error {
 			release()
 		}
 
-		if err := c.tr.remoteConn.Close(); err != nil {
+		if err := c.remoteConn().Close(); err != nil {
 			closeErr = fmt.Errorf("could not close remote conn: %w", err)
 		}
 
@@ -469,9 +580,37 @@ func (c *channel) Close() error {
 
 // Conn returns underlying channel's UDP connection.
 func (c *channel) Conn() *net.UDPConn {
+	return c.remoteConn()
+}
+
+func (c *channel) remoteConn() *net.UDPConn {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+
 	return c.tr.remoteConn
 }
 
+// Relayed reports whether channel traffic is routed through relay server.
+func (c *channel) Relayed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.relayed
+}
+
+// Capabilities returns p2p protocol features negotiated with the peer.
+func (c *channel) Capabilities() Capabilities {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.capabilities
+}
+
+// Metrics returns transport statistics of the channel.
+func (c *channel) Metrics() ChannelMetrics {
+	return c.metrics.snapshot()
+}
+
 // Send sends message to given topic. Peer listening to topic will receive message.
 func (c *channel) Send(ctx context.Context, topic string, msg *Message) (*Message, error) {
 	reply, err := c.sendRequest(ctx, topic, msg)
@@ -495,13 +634,15 @@ func (c *channel) sendRequest(ctx context.Context, topic string, m *Message) (*M
 	defer c.deleteStream(s.id)
 
 	// Send request.
-	c.sendQueue <- &transportMsg{id: s.id, topic: topic, data: m.Data}
+	sent := time.Now()
+	c.enqueue(&transportMsg{id: s.id, topic: topic, data: m.Data}, topic)
 
 	// Wait for response.
 	select {
 	case <-ctx.Done():
 		return nil, fmt.Errorf("timeout waiting for reply to %q: %w", topic, ErrSendTimeout)
 	case res := <-s.resCh:
+		c.metrics.rttSample(time.Since(sent))
 		if res.statusCode != statusCodeOK {
 			if res.statusCode == statusCodePublicErr {
 				return nil, fmt.Errorf("public peer error: %s", string(res.data))
@@ -509,6 +650,9 @@ func (c *channel) sendRequest(ctx context.Context, topic string, m *Message) (*M
 			if res.statusCode == statusCodeHandlerNotFoundErr {
 				return nil, fmt.Errorf("%s: %w", string(res.data), ErrHandlerNotFound)
 			}
+			if res.statusCode == statusCodeAtCapacityErr {
+				return nil, fmt.Errorf("%s: %w", res.msg, ErrPeerAtCapacity)
+			}
 			return nil, fmt.Errorf("peer error: %w", errors.New(res.msg))
 		}
 		return &Message{Data: res.data}, nil
@@ -547,6 +691,27 @@ func (c *channel) setServiceConn(conn *net.UDPConn) {
 	c.serviceConn = conn
 }
 
+func (c *channel) setRelayed(relayed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.relayed = relayed
+}
+
+func (c *channel) setCapabilities(capabilities Capabilities) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.capabilities = capabilities
+}
+
+func (c *channel) setObfuscator(obfuscator *obfuscator) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.obfuscator = obfuscator
+}
+
 func (c *channel) setUpnpPortsRelease(release []func()) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -571,18 +736,19 @@ func (c *channel) checkIfChannelAlive() {
 func reopenConn(conn *net.UDPConn) (*net.UDPConn, error) {
 	// conn first must be closed to prevent use of WriteTo with pre-connected connection error.
 	conn.Close()
-	conn, err := net.ListenUDP("udp4", conn.LocalAddr().(*net.UDPAddr))
+	localAddr := conn.LocalAddr().(*net.UDPAddr)
+	network := "udp4"
+	if localAddr.IP != nil && localAddr.IP.To4() == nil {
+		network = "udp6"
+	}
+	conn, err := net.ListenUDP(network, localAddr)
 	if err != nil {
 		return nil, fmt.Errorf("could not listen UDP: %w", err)
 	}
 	return conn, nil
 }
 
-func listenUDPSession(proxyAddr net.Addr, privateKey PrivateKey, peerPubKey PublicKey) (sess *kcp.UDPSession, localAddr *net.UDPAddr, err error) {
-	blockCrypt, err := newBlockCrypt(privateKey, peerPubKey)
-	if err != nil {
-		return nil, nil, fmt.Errorf("could not create block crypt: %w", err)
-	}
+func listenUDPSession(proxyAddr net.Addr, blockCrypt kcp.BlockCrypt) (sess *kcp.UDPSession, localAddr *net.UDPAddr, err error) {
 
 	localConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
 	if err != nil {
@@ -20,6 +20,7 @@ package p2p
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"sync"
 	"testing"
@@ -175,6 +176,17 @@ func TestChannelFullCommunicationFlow(t *testing.T) {
 			t.Fatalf("expect handler not found err, got %v", err)
 		}
 	})
+
+	t.Run("Test peer returns at capacity error", func(t *testing.T) {
+		provider.Handle("get-capacity", func(c Context) error {
+			return fmt.Errorf("too many sessions: %w", ErrPeerAtCapacity)
+		})
+
+		_, err := consumer.Send(context.Background(), "get-capacity", &Message{Data: []byte("hello")})
+		if !errors.Is(err, ErrPeerAtCapacity) {
+			t.Fatalf("expect peer at capacity err, got %v", err)
+		}
+	})
 }
 
 func TestChannel_Send_Timeout(t *testing.T) {
@@ -209,6 +221,29 @@ func TestChannel_Send_Timeout(t *testing.T) {
 	})
 }
 
+func TestChannel_PriorityMessagesAreSentFirst(t *testing.T) {
+	c := &channel{
+		stop:              make(chan struct{}),
+		sendQueue:         make(chan *transportMsg, 10),
+		prioritySendQueue: make(chan *transportMsg, 10),
+	}
+	c.enqueue(&transportMsg{id: 1, topic: "bulk"}, "bulk")
+	c.enqueue(&transportMsg{id: 2, topic: TopicPaymentInvoice}, TopicPaymentInvoice)
+	c.enqueue(&transportMsg{id: 3}, TopicKeepAlive)
+
+	var ids []uint64
+	for i := 0; i < 3; i++ {
+		msg, ok := c.nextSendMsg()
+		require.True(t, ok)
+		ids = append(ids, msg.id)
+	}
+	assert.Equal(t, []uint64{2, 3, 1}, ids)
+
+	close(c.stop)
+	_, ok := c.nextSendMsg()
+	assert.False(t, ok)
+}
+
 func TestChannel_Send_To_When_Peer_Starts_Later(t *testing.T) {
 	provider, consumer, err := createTestChannels()
 	require.NoError(t, err)
@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	// compressionMinSize is data size from which messages are compressed, smaller ones are not worth it.
+	compressionMinSize = 512
+
+	encodingGzip = "gzip"
+)
+
+var crlf = []byte("\r\n")
+
+// compress compresses message data if it's large enough and compression makes it smaller.
+func (m *transportMsg) compress() error {
+	if m.encoding != "" || len(m.data) < compressionMinSize {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(m.data); err != nil {
+		return fmt.Errorf("could not compress message data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("could not compress message data: %w", err)
+	}
+	// Text protocol dot encoding turns CRLF into LF on read, compressed data would not survive it.
+	if buf.Len() >= len(m.data) || bytes.Contains(buf.Bytes(), crlf) {
+		return nil
+	}
+
+	m.data = buf.Bytes()
+	m.encoding = encodingGzip
+	return nil
+}
+
+// decompress restores compressed message data.
+func (m *transportMsg) decompress() error {
+	switch m.encoding {
+	case "":
+		return nil
+	case encodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(m.data))
+		if err != nil {
+			return fmt.Errorf("could not decompress message data: %w", err)
+		}
+		defer r.Close()
+
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("could not decompress message data: %w", err)
+		}
+		m.data = data
+		m.encoding = ""
+		return nil
+	default:
+		return fmt.Errorf("unknown message encoding %q", m.encoding)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportMsg_Compress(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"config":"wireguard"}`), 100)
+	msg := transportMsg{data: data}
+
+	require.NoError(t, msg.compress())
+	assert.Equal(t, encodingGzip, msg.encoding)
+	assert.True(t, len(msg.data) < len(data))
+
+	require.NoError(t, msg.decompress())
+	assert.Equal(t, "", msg.encoding)
+	assert.Equal(t, data, msg.data)
+}
+
+func TestTransportMsg_Compress_SkipsSmallData(t *testing.T) {
+	msg := transportMsg{data: []byte("ping")}
+
+	require.NoError(t, msg.compress())
+	assert.Equal(t, "", msg.encoding)
+	assert.Equal(t, []byte("ping"), msg.data)
+}
+
+func TestTransportMsg_Decompress_UnknownEncoding(t *testing.T) {
+	msg := transportMsg{data: []byte("data"), encoding: "br"}
+
+	assert.EqualError(t, msg.decompress(), `unknown message encoding "br"`)
+}
+
+func TestChannel_Send_Compressed(t *testing.T) {
+	provider, consumer, err := createTestChannels()
+	require.NoError(t, err)
+	defer provider.Close()
+	defer consumer.Close()
+	provider.(*channel).setCapabilities(Capabilities{Names: []string{CapabilityCompression}})
+	consumer.(*channel).setCapabilities(Capabilities{Names: []string{CapabilityCompression}})
+
+	config := bytes.Repeat([]byte("endpoint=1.1.1.1:51820\n"), 200)
+	provider.Handle("config", func(c Context) error {
+		if !bytes.Equal(config, c.Request().Data) {
+			return c.Error(errors.New("corrupted request"))
+		}
+		return c.OkWithReply(&Message{Data: config})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := consumer.Send(ctx, "config", &Message{Data: config})
+	require.NoError(t, err)
+	assert.Equal(t, config, res.Data)
+}
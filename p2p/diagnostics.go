@@ -0,0 +1,232 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/eventbus"
+)
+
+// Roles of the peer p2p diagnostics report is recorded by.
+const (
+	DiagnosticsRoleConsumer = "consumer"
+	DiagnosticsRoleProvider = "provider"
+)
+
+// Methods used to establish p2p connections with the peer.
+const (
+	TraversalMethodIPv6   = "ipv6"
+	TraversalMethodDirect = "direct"
+	TraversalMethodPinger = "pinger"
+	TraversalMethodRelay  = "relay"
+)
+
+// DiagnosticsReport describes how the last p2p channel was established or why establishing it failed.
+type DiagnosticsReport struct {
+	Role         string
+	ServiceType  string
+	PublicIP     string
+	PeerPublicIP string
+	NATType      string
+	PeerNATType  string
+	StartedAt    time.Time
+	Duration     time.Duration
+	Steps        []DiagnosticsStep
+	Attempts     []TraversalAttempt
+	Relayed      bool
+	Error        string
+}
+
+// DiagnosticsStep describes single step of p2p channel establishment.
+type DiagnosticsStep struct {
+	Name     string
+	Duration time.Duration
+	Error    string
+}
+
+// TraversalAttempt describes single attempt to establish connections with the peer.
+type TraversalAttempt struct {
+	Method         string
+	PeerIP         string
+	Relay          string
+	CandidatePairs []CandidatePair
+	Duration       time.Duration
+	Error          string
+}
+
+// CandidatePair is a pair of local and peer ports connection is attempted through.
+type CandidatePair struct {
+	LocalPort int
+	PeerPort  int
+}
+
+// natTypeDetector tells type of NAT the node is behind, empty type is returned when it is unknown.
+type natTypeDetector interface {
+	NATType() string
+}
+
+// Diagnostics keeps the last p2p diagnostics report of each role, so connectivity issues can be investigated.
+// Outcome of each traversal attempt is published as AppEventTraversal.
+type Diagnostics struct {
+	mu        sync.Mutex
+	reports   map[string]DiagnosticsReport
+	publisher eventbus.Publisher
+	detector  natTypeDetector
+}
+
+// NewDiagnostics creates empty p2p diagnostics, publisher and detector are optional.
+func NewDiagnostics(publisher eventbus.Publisher, detector natTypeDetector) *Diagnostics {
+	return &Diagnostics{
+		reports:   make(map[string]DiagnosticsReport),
+		publisher: publisher,
+		detector:  detector,
+	}
+}
+
+// natType returns type of NAT the node is behind, it is exchanged with the peer for traversal telemetry.
+func (d *Diagnostics) natType() string {
+	if d.detector == nil {
+		return ""
+	}
+	return d.detector.NATType()
+}
+
+// Reports returns the last recorded reports, consumer's one goes first.
+func (d *Diagnostics) Reports() []DiagnosticsReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var reports []DiagnosticsReport
+	for _, role := range []string{DiagnosticsRoleConsumer, DiagnosticsRoleProvider} {
+		if report, ok := d.reports[role]; ok {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+func (d *Diagnostics) store(report DiagnosticsReport) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.reports[report.Role] = report
+}
+
+// diagnosticsRecorder collects report of single p2p channel establishment.
+type diagnosticsRecorder struct {
+	diagnostics *Diagnostics
+	report      DiagnosticsReport
+}
+
+func (d *Diagnostics) newRecorder(role, serviceType string) *diagnosticsRecorder {
+	return &diagnosticsRecorder{
+		diagnostics: d,
+		report: DiagnosticsReport{
+			Role:        role,
+			ServiceType: serviceType,
+			StartedAt:   time.Now(),
+		},
+	}
+}
+
+func (r *diagnosticsRecorder) step(name string, startedAt time.Time, err error) {
+	r.report.Steps = append(r.report.Steps, DiagnosticsStep{
+		Name:     name,
+		Duration: time.Since(startedAt),
+		Error:    errString(err),
+	})
+}
+
+func (r *diagnosticsRecorder) attempt(attempt TraversalAttempt, startedAt time.Time, err error) {
+	attempt.Duration = time.Since(startedAt)
+	attempt.Error = errString(err)
+	r.report.Attempts = append(r.report.Attempts, attempt)
+
+	if r.diagnostics.publisher != nil {
+		r.diagnostics.publisher.Publish(AppTopicTraversal, AppEventTraversal{
+			Role:        r.report.Role,
+			ServiceType: r.report.ServiceType,
+			Method:      attempt.Method,
+			Outcome:     traversalOutcome(attempt.Method, err),
+			NATType:     r.report.NATType,
+			PeerNATType: r.report.PeerNATType,
+			Duration:    attempt.Duration,
+			Error:       attempt.Error,
+		})
+	}
+}
+
+func traversalOutcome(method string, err error) string {
+	switch {
+	case err != nil:
+		return TraversalOutcomeFailed
+	case method == TraversalMethodPinger:
+		return TraversalOutcomePunched
+	case method == TraversalMethodRelay:
+		return TraversalOutcomeRelayed
+	default:
+		return TraversalOutcomeDirect
+	}
+}
+
+func (r *diagnosticsRecorder) peers(config *p2pConnectConfig) {
+	if config == nil {
+		return
+	}
+	r.report.PublicIP = config.publicIP
+	r.report.PeerPublicIP = config.peerPublicIP
+	r.report.NATType = config.natType
+	r.report.PeerNATType = config.peerNATType
+}
+
+// finish stores the report, err is the reason establishment failed, nil if channel was established.
+func (r *diagnosticsRecorder) finish(relayed bool, err error) {
+	r.report.Duration = time.Since(r.report.StartedAt)
+	r.report.Relayed = relayed
+	r.report.Error = errString(err)
+	r.diagnostics.store(r.report)
+}
+
+func traversalAttempt(method string, config *p2pConnectConfig) TraversalAttempt {
+	attempt := TraversalAttempt{Method: method}
+	if method == TraversalMethodRelay {
+		attempt.Relay = config.relay.Address
+		return attempt
+	}
+
+	attempt.PeerIP = config.peerIP()
+	if method == TraversalMethodIPv6 {
+		attempt.PeerIP = config.peerPublicIPv6
+	}
+	for i := 0; i < len(config.localPorts) && i < len(config.peerPorts); i++ {
+		attempt.CandidatePairs = append(attempt.CandidatePairs, CandidatePair{
+			LocalPort: config.localPorts[i],
+			PeerPort:  config.peerPorts[i],
+		})
+	}
+	return attempt
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnostics_KeepsLastReportOfEachRole(t *testing.T) {
+	diagnostics := NewDiagnostics(nil, nil)
+	assert.Empty(t, diagnostics.Reports())
+
+	provider := diagnostics.newRecorder(DiagnosticsRoleProvider, "wireguard")
+	provider.finish(false, nil)
+
+	consumer := diagnostics.newRecorder(DiagnosticsRoleConsumer, "wireguard")
+	consumer.step("config exchange", time.Now(), errors.New("timeout"))
+	consumer.finish(false, errors.New("could not exchange config: timeout"))
+
+	reports := diagnostics.Reports()
+	assert.Len(t, reports, 2)
+	assert.Equal(t, DiagnosticsRoleConsumer, reports[0].Role)
+	assert.Equal(t, "could not exchange config: timeout", reports[0].Error)
+	assert.Equal(t, []DiagnosticsStep{{Name: "config exchange", Duration: reports[0].Steps[0].Duration, Error: "timeout"}}, reports[0].Steps)
+	assert.Equal(t, DiagnosticsRoleProvider, reports[1].Role)
+	assert.Empty(t, reports[1].Error)
+
+	consumer = diagnostics.newRecorder(DiagnosticsRoleConsumer, "openvpn")
+	consumer.finish(true, nil)
+	reports = diagnostics.Reports()
+	assert.Equal(t, "openvpn", reports[0].ServiceType)
+	assert.True(t, reports[0].Relayed)
+}
+
+func TestTraversalAttempt_ListsCandidatePairs(t *testing.T) {
+	config := &p2pConnectConfig{
+		publicIP:     "1.1.1.1",
+		peerPublicIP: "2.2.2.2",
+		localPorts:   []int{1000, 1001, 1002},
+		peerPorts:    []int{2000, 2001, 2002},
+		relay:        RelayConfig{Address: "3.3.3.3:3478"},
+	}
+
+	assert.Equal(t, TraversalAttempt{
+		Method: TraversalMethodPinger,
+		PeerIP: "2.2.2.2",
+		CandidatePairs: []CandidatePair{
+			{LocalPort: 1000, PeerPort: 2000},
+			{LocalPort: 1001, PeerPort: 2001},
+			{LocalPort: 1002, PeerPort: 2002},
+		},
+	}, traversalAttempt(TraversalMethodPinger, config))
+	assert.Equal(t, TraversalAttempt{Method: TraversalMethodRelay, Relay: "3.3.3.3:3478"}, traversalAttempt(TraversalMethodRelay, config))
+}
+
+type mockPublisher struct {
+	topic string
+	event interface{}
+}
+
+func (p *mockPublisher) Publish(topic string, data interface{}) {
+	p.topic = topic
+	p.event = data
+}
+
+func TestDiagnostics_PublishesTraversalOutcome(t *testing.T) {
+	publisher := &mockPublisher{}
+	diagnostics := NewDiagnostics(publisher, nil)
+
+	recorder := diagnostics.newRecorder(DiagnosticsRoleConsumer, "wireguard")
+	recorder.peers(&p2pConnectConfig{natType: "symmetric", peerNATType: "public"})
+	recorder.attempt(TraversalAttempt{Method: TraversalMethodPinger}, time.Now(), errors.New("no ping received"))
+
+	assert.Equal(t, AppTopicTraversal, publisher.topic)
+	event := publisher.event.(AppEventTraversal)
+	assert.Equal(t, TraversalOutcomeFailed, event.Outcome)
+	assert.Equal(t, "symmetric", event.NATType)
+	assert.Equal(t, "public", event.PeerNATType)
+	assert.Equal(t, "no ping received", event.Error)
+
+	recorder.attempt(TraversalAttempt{Method: TraversalMethodRelay}, time.Now(), nil)
+	assert.Equal(t, TraversalOutcomeRelayed, publisher.event.(AppEventTraversal).Outcome)
+}
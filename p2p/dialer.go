@@ -49,7 +49,9 @@ type Dialer interface {
 }
 
 // NewDialer creates new p2p communication dialer which is used on consumer side.
-func NewDialer(broker brokerConnector, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, consumerPinger natConsumerPinger, portPool port.ServicePortSupplier) Dialer {
+// Channel keys are rotated every keyRotationInterval if peer supports it, zero interval disables the rotation.
+// Channel traffic is obfuscated if obfuscation is set or provider asks for it.
+func NewDialer(broker brokerConnector, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, consumerPinger natConsumerPinger, portPool port.ServicePortSupplier, relay RelayConfig, diagnostics *Diagnostics, keyRotationInterval time.Duration, obfuscation bool) Dialer {
 	return &dialer{
 		broker:         broker,
 		ipResolver:     ipResolver,
@@ -57,6 +59,11 @@ func NewDialer(broker brokerConnector, signer identity.SignerFactory, verifier i
 		verifier:       verifier,
 		portPool:       portPool,
 		consumerPinger: consumerPinger,
+		relay:          relay,
+		diagnostics:    diagnostics,
+		ipv6Resolver:   localIPv6,
+		keyRotation:    keyRotationInterval,
+		obfuscation:    obfuscation,
 	}
 }
 
@@ -68,15 +75,28 @@ type dialer struct {
 	signer         identity.SignerFactory
 	verifier       identity.Verifier
 	ipResolver     ip.Resolver
+	relay          RelayConfig
+	diagnostics    *Diagnostics
+	ipv6Resolver   func() string
+	keyRotation    time.Duration
+	obfuscation    bool
 }
 
 // Dial exchanges p2p configuration via broker, performs NAT pinging if needed
 // and create p2p channel which is ready for communication.
-func (m *dialer) Dial(ctx context.Context, consumerID, providerID identity.Identity, serviceType string, contactDef ContactDefinition, tracer *trace.Tracer) (Channel, error) {
+func (m *dialer) Dial(ctx context.Context, consumerID, providerID identity.Identity, serviceType string, contactDef ContactDefinition, tracer *trace.Tracer) (_ Channel, err error) {
 	config := &p2pConnectConfig{tracer: tracer}
+	var relayed bool
+	report := m.diagnostics.newRecorder(DiagnosticsRoleConsumer, serviceType)
+	defer func() {
+		report.peers(config)
+		report.finish(relayed, err)
+	}()
 
 	// Send initial exchange with signed consumer public key.
+	started := time.Now()
 	brokerConn, err := m.connect(contactDef, tracer)
+	report.step("broker connect", started, err)
 	if err != nil {
 		return nil, fmt.Errorf("could not open broker conn: %w", err)
 	}
@@ -92,38 +112,72 @@ func (m *dialer) Dial(ctx context.Context, consumerID, providerID identity.Ident
 		}
 	})
 
+	started = time.Now()
 	config, err = m.startConfigExchange(config, ctx, brokerConn, providerID, serviceType, consumerID)
+	report.step("config exchange", started, err)
 	if err != nil {
 		return nil, fmt.Errorf("could not exchange config: %w", err)
 	}
 
+	started = time.Now()
 	config.publicIP, config.localPorts, err = m.prepareLocalPorts(config)
+	report.step("local ports", started, err)
 	if err != nil {
 		return nil, fmt.Errorf("could not prepare ports: %w", err)
 	}
+	config.publicIPv6 = m.ipv6Resolver()
+	config.natType = m.diagnostics.natType()
+	report.peers(config)
 
 	// Finally send consumer encrypted and signed connect config in ack message.
+	started = time.Now()
 	err = m.ackConfigExchange(config, ctx, brokerConn, providerID, serviceType, consumerID)
+	report.step("config ack", started, err)
 	if err != nil {
 		return nil, fmt.Errorf("could not ack config: %w", err)
 	}
 
-	dial := m.dialPinger
-	if len(config.peerPorts) == requiredConnCount {
-		dial = m.dialDirect
+	var conn1, conn2 *net.UDPConn
+	if config.ipv6() {
+		started = time.Now()
+		conn1, conn2, err = m.dialIPv6(ctx, providerID, config)
+		report.attempt(traversalAttempt(TraversalMethodIPv6, config), started, err)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Could not connect to provider %s over IPv6, falling back to IPv4", providerID.Address)
+		}
+	}
+	started = time.Now()
+	if conn1 != nil {
+		log.Debug().Msgf("Connected to provider %s over IPv6", providerID.Address)
+	} else if len(config.peerPorts) == requiredConnCount {
+		conn1, conn2, err = m.dialDirect(ctx, providerID, config)
+		report.attempt(traversalAttempt(TraversalMethodDirect, config), started, err)
+	} else {
+		conn1, conn2, err = m.dialPinger(ctx, providerID, config)
+		report.attempt(traversalAttempt(TraversalMethodPinger, config), started, err)
+		if err != nil && config.relay.enabled() {
+			log.Warn().Err(err).Msgf("Could not traverse NAT to provider %s, falling back to relay %s", providerID.Address, config.relay.Address)
+			started = time.Now()
+			conn1, conn2, err = m.dialRelay(ctx, providerID, config)
+			report.attempt(traversalAttempt(TraversalMethodRelay, config), started, err)
+			relayed = err == nil
+		}
 	}
-	conn1, conn2, err := dial(ctx, providerID, config)
 	if err != nil {
 		return nil, fmt.Errorf("could not dial p2p channel: %w", err)
 	}
 
 	// Wait until provider confirms that channel handlers are ready.
 	traceAck := config.tracer.StartStage("Consumer P2P dial ack")
+	started = time.Now()
 	select {
 	case <-peerReady:
 		log.Debug().Msg("Received handlers ready message from provider")
+		report.step("peer ready", started, nil)
 	case <-ctx.Done():
-		return nil, errors.New("timeout while performing configuration exchange")
+		err = errors.New("timeout while performing configuration exchange")
+		report.step("peer ready", started, err)
+		return nil, err
 	}
 
 	channel, err := newChannel(conn1, config.privateKey, config.peerPubKey)
@@ -131,8 +185,18 @@ func (m *dialer) Dial(ctx context.Context, consumerID, providerID identity.Ident
 		return nil, fmt.Errorf("could not create p2p channel during dial: %w", err)
 	}
 	channel.setTracer(tracer)
-	channel.setServiceConn(conn2)
+	if conn2 != nil {
+		channel.setServiceConn(conn2)
+	}
+	channel.setRelayed(relayed)
+	channel.setCapabilities(config.capabilities)
+	if config.obfuscation {
+		channel.setObfuscator(newObfuscator(true))
+	}
 	channel.launchReadSendLoops()
+	if m.keyRotation > 0 && channel.Capabilities().Has(CapabilityChannelRekey) {
+		go channel.rotateKeys(m.keyRotation)
+	}
 	config.tracer.EndStage(traceAck)
 
 	return channel, nil
@@ -201,6 +265,11 @@ func (m *dialer) startConfigExchange(config *p2pConnectConfig, ctx context.Conte
 	config.peerPubKey = peerPubKey
 	config.peerPublicIP = peerConnConfig.PublicIP
 	config.peerPorts = int32ToIntSlice(peerConnConfig.Ports)
+	config.relay = negotiateRelay(relayConfigFromProto(peerConnConfig), m.relay)
+	config.capabilities = negotiateCapabilities(peerConnConfig)
+	config.peerPublicIPv6 = peerConnConfig.GetPublicIPv6()
+	config.peerNATType = peerConnConfig.GetNatType()
+	config.obfuscation = negotiateObfuscation(m.obfuscation, peerConnConfig, config.capabilities)
 	return config, nil
 }
 
@@ -209,8 +278,16 @@ func (m *dialer) ackConfigExchange(config *p2pConnectConfig, ctx context.Context
 	defer config.tracer.EndStage(trace)
 
 	connConfig := &pb.P2PConnectConfig{
-		PublicIP: config.publicIP,
-		Ports:    intToInt32Slice(config.localPorts),
+		PublicIP:            config.publicIP,
+		Ports:               intToInt32Slice(config.localPorts),
+		Relay:               m.relay.Address,
+		RelayServiceTraffic: m.relay.ServiceTraffic,
+		Compression:         true,
+		ProtocolVersion:     ProtocolVersion,
+		Capabilities:        localCapabilities,
+		PublicIPv6:          config.publicIPv6,
+		NatType:             config.natType,
+		Obfuscation:         m.obfuscation,
 	}
 	connConfigCiphertext, err := encryptConnConfigMsg(connConfig, config.privateKey, config.peerPubKey)
 	if err != nil {
@@ -277,6 +354,18 @@ func (m *dialer) dialDirect(ctx context.Context, providerID identity.Identity, c
 	return conn1, conn2, err
 }
 
+func (m *dialer) dialIPv6(ctx context.Context, providerID identity.Identity, config *p2pConnectConfig) (*net.UDPConn, *net.UDPConn, error) {
+	trace := config.tracer.StartStage("Consumer P2P dial (ipv6)")
+	defer config.tracer.EndStage(trace)
+
+	if _, err := firewall.AllowIPAccess(config.peerPublicIPv6); err != nil {
+		return nil, nil, fmt.Errorf("could not add peer IPv6 firewall rule: %w", err)
+	}
+
+	log.Debug().Msgf("Connecting to provider %s with IPv6 %s using ports %v:%v", providerID.Address, config.peerPublicIPv6, config.localPorts[:requiredConnCount], config.peerPorts[:requiredConnCount])
+	return dialIPv6(ctx, config)
+}
+
 func (m *dialer) dialPinger(ctx context.Context, providerID identity.Identity, config *p2pConnectConfig) (*net.UDPConn, *net.UDPConn, error) {
 	trace := config.tracer.StartStage("Consumer P2P dial (pinger)")
 	defer config.tracer.EndStage(trace)
@@ -293,6 +382,18 @@ func (m *dialer) dialPinger(ctx context.Context, providerID identity.Identity, c
 	return conns[0], conns[1], nil
 }
 
+func (m *dialer) dialRelay(ctx context.Context, providerID identity.Identity, config *p2pConnectConfig) (*net.UDPConn, *net.UDPConn, error) {
+	trace := config.tracer.StartStage("Consumer P2P dial (relay)")
+	defer config.tracer.EndStage(trace)
+
+	log.Debug().Msgf("Binding to relay %s together with provider %s", config.relay.Address, providerID.Address)
+	conn1, conn2, err := dialRelay(ctx, config, m.portPool)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not dial peer through relay: %w", err)
+	}
+	return conn1, conn2, nil
+}
+
 func (m *dialer) sendSignedMsg(ctx context.Context, subject string, msg []byte, brokerConn nats.Connection) ([]byte, error) {
 	reply, err := brokerConn.RequestWithContext(ctx, subject, msg)
 	if err != nil {
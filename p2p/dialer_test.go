@@ -19,6 +19,7 @@ package p2p
 
 import (
 	"context"
+	"errors"
 	"net"
 	"testing"
 	"time"
@@ -42,6 +43,10 @@ func TestDialer_Exchange_And_Communication_With_Provider(t *testing.T) {
 		natProviderPinger natProviderPinger
 		natConsumerPinger natConsumerPinger
 		portMapper        mapping.PortMapper
+		relayed           bool
+		method            string
+		ipv6              string
+		obfuscation       bool
 	}{
 		{
 			name:              "Provider with public IP",
@@ -49,6 +54,7 @@ func TestDialer_Exchange_And_Communication_With_Provider(t *testing.T) {
 			natProviderPinger: &mockProviderNATPinger{},
 			natConsumerPinger: &mockConsumerNATPinger{},
 			portMapper:        &mockPortMapper{},
+			method:            TraversalMethodDirect,
 		},
 		{
 			name:              "Provider behind NAT",
@@ -56,6 +62,7 @@ func TestDialer_Exchange_And_Communication_With_Provider(t *testing.T) {
 			natProviderPinger: providerPinger,
 			natConsumerPinger: consumerPinger,
 			portMapper:        &mockPortMapper{},
+			method:            TraversalMethodPinger,
 		},
 		{
 			name:              "Provider behind NAT with Upnp enabled",
@@ -63,12 +70,38 @@ func TestDialer_Exchange_And_Communication_With_Provider(t *testing.T) {
 			natProviderPinger: &mockProviderNATPinger{},
 			natConsumerPinger: &mockConsumerNATPinger{},
 			portMapper:        &mockPortMapper{enabled: true},
+			method:            TraversalMethodDirect,
 		}, {
 			name:              "Provider behind NAT with manual port forwarding and noop pinger",
 			ipResolver:        ip.NewResolverMockMultiple("127.0.0.1", "1.1.1.1"),
 			natProviderPinger: traversal.NewNoopPinger(),
 			natConsumerPinger: traversal.NewNoopPinger(),
 			portMapper:        &mockPortMapper{enabled: false},
+			method:            TraversalMethodDirect,
+		}, {
+			name:              "Provider behind NAT which can't be traversed",
+			ipResolver:        ip.NewResolverMockMultiple("127.0.0.1", "1.1.1.1"),
+			natProviderPinger: &mockProviderNATPinger{err: errors.New("ping failed")},
+			natConsumerPinger: &mockConsumerNATPinger{err: errors.New("ping failed")},
+			portMapper:        &mockPortMapper{},
+			method:            TraversalMethodRelay,
+			relayed:           true,
+		}, {
+			name:              "Peers with IPv6",
+			ipResolver:        ip.NewResolverMockMultiple("127.0.0.1", "1.1.1.1"),
+			natProviderPinger: &mockProviderNATPinger{err: errors.New("ping failed")},
+			natConsumerPinger: &mockConsumerNATPinger{err: errors.New("ping failed")},
+			portMapper:        &mockPortMapper{},
+			method:            TraversalMethodIPv6,
+			ipv6:              "::1",
+		}, {
+			name:              "Consumer asking for obfuscation",
+			ipResolver:        ip.NewResolverMock("127.0.0.1"),
+			natProviderPinger: &mockProviderNATPinger{},
+			natConsumerPinger: &mockConsumerNATPinger{},
+			portMapper:        &mockPortMapper{},
+			method:            TraversalMethodDirect,
+			obfuscation:       true,
 		},
 	}
 
@@ -83,9 +116,13 @@ func TestDialer_Exchange_And_Communication_With_Provider(t *testing.T) {
 			defer brokerConn.Close()
 			mockBroker := &mockBroker{conn: brokerConn}
 			portPool := port.NewPool()
+			relay := startTestRelay(t)
+			defer relay.Stop()
+			relayConfig := RelayConfig{Address: relay.conn.LocalAddr().String(), ServiceTraffic: true}
 
 			// Provider starts listening.
-			channelListener := NewListener(brokerConn, signerFactory, verifier, test.ipResolver, test.natProviderPinger, portPool, test.portMapper)
+			channelListener := NewListener(brokerConn, signerFactory, verifier, test.ipResolver, test.natProviderPinger, portPool, test.portMapper, relayConfig, NewDiagnostics(nil, nil), false)
+			channelListener.(*listener).ipv6Resolver = func() string { return test.ipv6 }
 			_, err := channelListener.Listen(providerID, "wireguard", func(ch Channel) {
 				ch.Handle("test", func(c Context) error {
 					return c.OkWithReply(&Message{Data: []byte("pong")})
@@ -94,7 +131,9 @@ func TestDialer_Exchange_And_Communication_With_Provider(t *testing.T) {
 			assert.NoError(t, err)
 
 			// Consumer starts dialing provider.
-			channelDialer := NewDialer(mockBroker, signerFactory, verifier, test.ipResolver, test.natConsumerPinger, portPool)
+			diagnostics := NewDiagnostics(nil, nil)
+			channelDialer := NewDialer(mockBroker, signerFactory, verifier, test.ipResolver, test.natConsumerPinger, portPool, RelayConfig{}, diagnostics, 0, test.obfuscation)
+			channelDialer.(*dialer).ipv6Resolver = func() string { return test.ipv6 }
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 			consumerChannel, err := channelDialer.Dial(ctx, identity.FromAddress("0x2"), providerID, "wireguard", ContactDefinition{BrokerAddresses: []string{"broker"}}, trace.NewTracer("Dial"))
@@ -104,6 +143,18 @@ func TestDialer_Exchange_And_Communication_With_Provider(t *testing.T) {
 			res, err := consumerChannel.Send(context.Background(), "test", &Message{Data: []byte("ping")})
 			assert.NoError(t, err)
 			assert.Equal(t, "pong", string(res.Data))
+			assert.Equal(t, test.relayed, consumerChannel.Relayed())
+			assert.NotNil(t, consumerChannel.ServiceConn())
+			assert.Equal(t, ProtocolVersion, consumerChannel.Capabilities().Version)
+			assert.True(t, consumerChannel.Capabilities().Has(CapabilitySessionShutdown))
+			assert.Equal(t, test.obfuscation, consumerChannel.(*channel).obfuscator != nil)
+
+			reports := diagnostics.Reports()
+			assert.Len(t, reports, 1)
+			assert.Equal(t, DiagnosticsRoleConsumer, reports[0].Role)
+			assert.Empty(t, reports[0].Error)
+			assert.Equal(t, test.relayed, reports[0].Relayed)
+			assert.Equal(t, test.method, reports[0].Attempts[len(reports[0].Attempts)-1].Method)
 		})
 	}
 }
@@ -124,18 +175,20 @@ func natTestPingers(t *testing.T) (providerPinger natProviderPinger, consumerPin
 
 type mockConsumerNATPinger struct {
 	conns []*net.UDPConn
+	err   error
 }
 
 func (m *mockConsumerNATPinger) PingProviderPeer(ctx context.Context, ip string, localPorts, remotePorts []int, initialTTL int, n int) (conns []*net.UDPConn, err error) {
-	return m.conns, nil
+	return m.conns, m.err
 }
 
 type mockProviderNATPinger struct {
 	conns []*net.UDPConn
+	err   error
 }
 
 func (m *mockProviderNATPinger) PingConsumerPeer(ctx context.Context, ip string, localPorts, remotePorts []int, initialTTL int, n int) (conns []*net.UDPConn, err error) {
-	return m.conns, nil
+	return m.conns, m.err
 }
 
 type mockBroker struct {
@@ -153,3 +206,7 @@ type mockPortMapper struct {
 func (m mockPortMapper) Map(protocol string, port int, name string) (release func(), ok bool) {
 	return func() {}, m.enabled
 }
+
+func (m mockPortMapper) Leases() []mapping.Lease {
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+)
+
+// AppTopicTraversal is a topic for events about outcomes of attempts to establish p2p connections with the peer.
+const AppTopicTraversal = "p2p traversal"
+
+// Outcomes of attempts to establish p2p connections with the peer.
+const (
+	TraversalOutcomeDirect  = "direct"
+	TraversalOutcomePunched = "punched"
+	TraversalOutcomeRelayed = "relayed"
+	TraversalOutcomeFailed  = "failed"
+)
+
+// AppEventTraversal describes outcome of single attempt to establish p2p connections with the peer.
+type AppEventTraversal struct {
+	// Role is the side of the channel this node is on, "consumer" or "provider".
+	Role        string
+	ServiceType string
+	Method      string
+	Outcome     string
+	// NATType and PeerNATType are types of NAT this node and the peer are behind, empty if unknown.
+	NATType     string
+	PeerNATType string
+	Duration    time.Duration
+	Error       string
+}
+
+// AppTopicPeerUnreachable is a topic for events about p2p peers which stopped replying to keepalive pings.
+const AppTopicPeerUnreachable = "p2p peer unreachable"
+
+// AppEventPeerUnreachable announces that peer did not reply to consecutive keepalive pings
+// and sessions with it are being closed.
+type AppEventPeerUnreachable struct {
+	// Role is the side of the channel this node is on, "consumer" or "provider".
+	Role        string
+	PeerID      identity.Identity
+	SessionID   string
+	ServiceType string
+	// FailedPings is count of consecutive keepalive pings peer did not reply to.
+	FailedPings int
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ipv6DialTimeout limits IPv6 attempt, peers fall back to IPv4 candidates after it.
+	ipv6DialTimeout = 5 * time.Second
+	// ipv6PunchInterval is how often punch packets are resent until the peer's one arrives.
+	ipv6PunchInterval = 200 * time.Millisecond
+	// ipv6AckCount is how many acks are sent, peer waits for one of them if our pings were dropped by its firewall.
+	ipv6AckCount = 3
+)
+
+var (
+	ipv6Ping = []byte("MYSTV6PING")
+	ipv6Ack  = []byte("MYSTV6ACK")
+)
+
+// localIPv6 returns global IPv6 address of the host or empty string if it has none.
+// IPv6 addresses are not translated, so peers can reach the host at its local address.
+func localIPv6() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Debug().Err(err).Msg("Could not list interface addresses")
+		return ""
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && isGlobalIPv6(ipNet.IP) {
+			return ipNet.IP.String()
+		}
+	}
+	return ""
+}
+
+// isGlobalIPv6 reports whether ip is IPv6 address routable in the internet, unique local addresses are not.
+func isGlobalIPv6(ip net.IP) bool {
+	return ip.To4() == nil && len(ip) == net.IPv6len && ip.IsGlobalUnicast() && ip[0]&0xfe != 0xfc
+}
+
+// dialIPv6 connects to the peer's IPv6 address from the first local ports to the first peer ports.
+// Both peers send packets to each other, so that stateful firewalls in front of them let the peer's packets through.
+func dialIPv6(ctx context.Context, config *p2pConnectConfig) (*net.UDPConn, *net.UDPConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, ipv6DialTimeout)
+	defer cancel()
+
+	localIP := net.ParseIP(config.publicIPv6)
+	peerIP := net.ParseIP(config.peerPublicIPv6)
+	var conns []*net.UDPConn
+	for i := 0; i < requiredConnCount; i++ {
+		conn, err := net.DialUDP("udp6", &net.UDPAddr{IP: localIP, Port: config.localPorts[i]}, &net.UDPAddr{IP: peerIP, Port: config.peerPorts[i]})
+		if err == nil {
+			err = punchIPv6(ctx, conn)
+		}
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return nil, nil, fmt.Errorf("could not connect to peer over IPv6: %w", err)
+		}
+		conns = append(conns, conn)
+	}
+	return conns[0], conns[1], nil
+}
+
+// punchIPv6 sends ping packets to the peer until its ping or ack arrives.
+func punchIPv6(ctx context.Context, conn *net.UDPConn) error {
+	buf := make([]byte, len(ipv6Ping))
+	for {
+		if _, err := conn.Write(ipv6Ping); err != nil {
+			log.Debug().Err(err).Msg("Could not send IPv6 ping")
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(ipv6PunchInterval)); err != nil {
+			conn.Close()
+			return fmt.Errorf("could not set read deadline: %w", err)
+		}
+		n, err := conn.Read(buf)
+		if err == nil && (bytes.Equal(buf[:n], ipv6Ping) || bytes.Equal(buf[:n], ipv6Ack)) {
+			if bytes.Equal(buf[:n], ipv6Ping) {
+				for i := 0; i < ipv6AckCount; i++ {
+					conn.Write(ipv6Ack)
+				}
+			}
+			if err := conn.SetReadDeadline(time.Time{}); err != nil {
+				conn.Close()
+				return fmt.Errorf("could not reset read deadline: %w", err)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return fmt.Errorf("peer did not reply: %w", ctx.Err())
+		default:
+		}
+	}
+}
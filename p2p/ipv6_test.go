@@ -0,0 +1,40 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGlobalIPv6(t *testing.T) {
+	for ip, global := range map[string]bool{
+		"2001:db8::1":    true,
+		"2a02:1::5":      true,
+		"::1":            false,
+		"fe80::1":        false,
+		"fd00::2":        false,
+		"ff02::1":        false,
+		"1.1.1.1":        false,
+		"::ffff:1.1.1.1": false,
+	} {
+		assert.Equal(t, global, isGlobalIPv6(net.ParseIP(ip)), ip)
+	}
+}
@@ -51,7 +51,8 @@ type Listener interface {
 }
 
 // NewListener creates new p2p communication listener which is used on provider side.
-func NewListener(brokerConn nats.Connection, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, providerPinger natProviderPinger, portPool port.ServicePortSupplier, portMapper mapping.PortMapper) Listener {
+// Channel traffic is obfuscated if obfuscation is set or consumer asks for it.
+func NewListener(brokerConn nats.Connection, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, providerPinger natProviderPinger, portPool port.ServicePortSupplier, portMapper mapping.PortMapper, relay RelayConfig, diagnostics *Diagnostics, obfuscation bool) Listener {
 	return &listener{
 		brokerConn:     brokerConn,
 		pendingConfigs: map[PublicKey]p2pConnectConfig{},
@@ -61,6 +62,10 @@ func NewListener(brokerConn nats.Connection, signer identity.SignerFactory, veri
 		portPool:       portPool,
 		providerPinger: providerPinger,
 		portMapper:     portMapper,
+		relay:          relay,
+		diagnostics:    diagnostics,
+		ipv6Resolver:   localIPv6,
+		obfuscation:    obfuscation,
 	}
 }
 
@@ -73,6 +78,10 @@ type listener struct {
 	verifier       identity.Verifier
 	ipResolver     ip.Resolver
 	portMapper     mapping.PortMapper
+	relay          RelayConfig
+	diagnostics    *Diagnostics
+	ipv6Resolver   func() string
+	obfuscation    bool
 
 	// Keys holds pendingConfigs temporary configs for provider side since it
 	// need to handle key exchange in two steps.
@@ -90,6 +99,24 @@ type p2pConnectConfig struct {
 	peerPubKey       PublicKey
 	tracer           *trace.Tracer
 	upnpPortsRelease []func()
+	// relay is relay server both peers fall back to when NAT traversal fails.
+	relay RelayConfig
+	// capabilities are p2p protocol features negotiated with the peer.
+	capabilities Capabilities
+	// publicIPv6 and peerPublicIPv6 are global IPv6 addresses of peers, empty if peer has none.
+	publicIPv6     string
+	peerPublicIPv6 string
+	// natType and peerNATType are types of NAT peers are behind, they are used only for telemetry.
+	natType     string
+	peerNATType string
+	// obfuscation is set when channel packets are disguised on the wire.
+	obfuscation bool
+}
+
+// ipv6 reports whether both peers have IPv6 addresses, connections over IPv6 are preferred then.
+func (c *p2pConnectConfig) ipv6() bool {
+	return c.publicIPv6 != "" && c.peerPublicIPv6 != "" &&
+		len(c.localPorts) >= requiredConnCount && len(c.peerPorts) >= requiredConnCount
 }
 
 func (c *p2pConnectConfig) peerIP() string {
@@ -125,81 +152,12 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 	}
 
 	ackSub, err := m.brokerConn.Subscribe(configExchangeACKSubject(providerID, serviceType), func(msg *nats_lib.Msg) {
-		config, err := m.providerAckConfigExchange(msg)
+		report := m.diagnostics.newRecorder(DiagnosticsRoleProvider, serviceType)
+		relayed, err := m.providerConnect(providerID, serviceType, msg, channelHandlers, report)
+		report.finish(relayed, err)
 		if err != nil {
-			log.Err(err).Msg("Could not handle exchange ack")
-			return
+			log.Err(err).Msg("Could not establish p2p channel with consumer")
 		}
-
-		trace := config.tracer.StartStage("Provider P2P exchange ack")
-		// Send ack in separate goroutine and start pinging.
-		// It is important that provider starts sending pings first otherwise
-		// providers router can think that consumer is sending DDoS packets.
-		go func(reply string) {
-			// race condition still happens when consumer starts to ping until provider did not manage to complete required number of pings
-			// this might be provider / consumer performance dependent
-			// make sleep time dependent on pinger interval and wait for 2 ping iterations
-			// TODO: either reintroduce eventual increase of TTL on consumer or maintain some sane delay
-			dur := traversal.DefaultPingConfig().Interval.Milliseconds() * int64(len(config.localPorts)) / 2
-			log.Debug().Msgf("Delaying pings from consumer for %v ms", dur)
-			time.Sleep(time.Duration(dur) * time.Millisecond)
-
-			if err := m.brokerConn.Publish(reply, []byte("OK")); err != nil {
-				log.Err(err).Msg("Could not publish exchange ack")
-			}
-			config.tracer.EndStage(trace)
-		}(msg.Reply)
-
-		var conn1, conn2 *net.UDPConn
-		if len(config.peerPorts) == requiredConnCount {
-			traceDial := config.tracer.StartStage("Provider P2P dial (upnp)")
-			log.Debug().Msg("Skipping consumer ping")
-			conn1, err = net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[0]}, &net.UDPAddr{IP: net.ParseIP(config.peerIP()), Port: config.peerPorts[0]})
-			if err != nil {
-				log.Err(err).Msg("Could not create UDP conn for p2p channel")
-				return
-			}
-			conn2, err = net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[1]}, &net.UDPAddr{IP: net.ParseIP(config.peerIP()), Port: config.peerPorts[1]})
-			if err != nil {
-				log.Err(err).Msg("Could not create UDP conn for service")
-				return
-			}
-			config.tracer.EndStage(traceDial)
-		} else {
-			traceDial := config.tracer.StartStage("Provider P2P dial (pinger)")
-			log.Debug().Msgf("Pinging consumer with IP %s using ports %v:%v initial ttl: %v",
-				config.peerIP(), config.localPorts, config.peerPorts, providerInitialTTL)
-			conns, err := m.providerPinger.PingConsumerPeer(context.Background(), config.peerIP(), config.localPorts, config.peerPorts, providerInitialTTL, requiredConnCount)
-			if err != nil {
-				log.Err(err).Msg("Could not ping peer")
-				return
-			}
-			conn1 = conns[0]
-			conn2 = conns[1]
-			config.tracer.EndStage(traceDial)
-		}
-
-		traceAck := config.tracer.StartStage("Provider P2P dial ack")
-		channel, err := newChannel(conn1, config.privateKey, config.peerPubKey)
-		if err != nil {
-			log.Err(err).Msg("Could not create channel")
-			return
-		}
-		channel.setTracer(config.tracer)
-		channel.setServiceConn(conn2)
-		channel.setUpnpPortsRelease(config.upnpPortsRelease)
-
-		channelHandlers(channel)
-
-		channel.launchReadSendLoops()
-
-		// Send handlers ready to consumer.
-		if err := m.providerChannelHandlersReady(providerID, serviceType); err != nil {
-			log.Err(err).Msg("Could not handle channel handlers ready")
-			channel.Close()
-			return
-		}
-		config.tracer.EndStage(traceAck)
 	})
 
 	if err != nil {
@@ -219,6 +177,130 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 	}, nil
 }
 
+// providerConnect completes config exchange acknowledged by consumer, connects to it and passes established channel to channelHandlers.
+func (m *listener) providerConnect(providerID identity.Identity, serviceType string, msg *nats_lib.Msg, channelHandlers func(ch Channel), report *diagnosticsRecorder) (relayed bool, err error) {
+	started := time.Now()
+	config, err := m.providerAckConfigExchange(msg)
+	report.step("config ack", started, err)
+	if err != nil {
+		return false, fmt.Errorf("could not handle exchange ack: %w", err)
+	}
+	report.peers(config)
+
+	trace := config.tracer.StartStage("Provider P2P exchange ack")
+	// Send ack in separate goroutine and start pinging.
+	// It is important that provider starts sending pings first otherwise
+	// providers router can think that consumer is sending DDoS packets.
+	go func(reply string) {
+		// race condition still happens when consumer starts to ping until provider did not manage to complete required number of pings
+		// this might be provider / consumer performance dependent
+		// make sleep time dependent on pinger interval and wait for 2 ping iterations
+		// TODO: either reintroduce eventual increase of TTL on consumer or maintain some sane delay
+		dur := traversal.DefaultPingConfig().Interval.Milliseconds() * int64(len(config.localPorts)) / 2
+		log.Debug().Msgf("Delaying pings from consumer for %v ms", dur)
+		time.Sleep(time.Duration(dur) * time.Millisecond)
+
+		if err := m.brokerConn.Publish(reply, []byte("OK")); err != nil {
+			log.Err(err).Msg("Could not publish exchange ack")
+		}
+		config.tracer.EndStage(trace)
+	}(msg.Reply)
+
+	var conn1, conn2 *net.UDPConn
+	if config.ipv6() {
+		traceDial := config.tracer.StartStage("Provider P2P dial (ipv6)")
+		started = time.Now()
+		conn1, conn2, err = dialIPv6(context.Background(), config)
+		report.attempt(traversalAttempt(TraversalMethodIPv6, config), started, err)
+		config.tracer.EndStage(traceDial)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Could not connect to consumer %s over IPv6, falling back to IPv4", config.peerPublicIPv6)
+		}
+	}
+	started = time.Now()
+	if conn1 != nil {
+		log.Debug().Msg("Connected to consumer over IPv6")
+	} else if len(config.peerPorts) == requiredConnCount {
+		traceDial := config.tracer.StartStage("Provider P2P dial (upnp)")
+		log.Debug().Msg("Skipping consumer ping")
+		conn1, conn2, err = m.providerDialDirect(config)
+		report.attempt(traversalAttempt(TraversalMethodDirect, config), started, err)
+		config.tracer.EndStage(traceDial)
+		if err != nil {
+			return false, err
+		}
+	} else {
+		traceDial := config.tracer.StartStage("Provider P2P dial (pinger)")
+		log.Debug().Msgf("Pinging consumer with IP %s using ports %v:%v initial ttl: %v",
+			config.peerIP(), config.localPorts, config.peerPorts, providerInitialTTL)
+		conns, err := m.providerPinger.PingConsumerPeer(context.Background(), config.peerIP(), config.localPorts, config.peerPorts, providerInitialTTL, requiredConnCount)
+		report.attempt(traversalAttempt(TraversalMethodPinger, config), started, err)
+		config.tracer.EndStage(traceDial)
+		switch {
+		case err == nil:
+			conn1 = conns[0]
+			conn2 = conns[1]
+		case config.relay.enabled():
+			log.Warn().Err(err).Msgf("Could not traverse NAT to consumer, falling back to relay %s", config.relay.Address)
+			traceRelay := config.tracer.StartStage("Provider P2P dial (relay)")
+			started = time.Now()
+			conn1, conn2, err = dialRelay(context.Background(), config, m.portPool)
+			report.attempt(traversalAttempt(TraversalMethodRelay, config), started, err)
+			config.tracer.EndStage(traceRelay)
+			if err != nil {
+				return false, fmt.Errorf("could not dial peer through relay: %w", err)
+			}
+			relayed = true
+		default:
+			return false, fmt.Errorf("could not ping peer: %w", err)
+		}
+	}
+
+	traceAck := config.tracer.StartStage("Provider P2P dial ack")
+	channel, err := newChannel(conn1, config.privateKey, config.peerPubKey)
+	if err != nil {
+		return relayed, fmt.Errorf("could not create channel: %w", err)
+	}
+	channel.setTracer(config.tracer)
+	if conn2 != nil {
+		channel.setServiceConn(conn2)
+	}
+	channel.setRelayed(relayed)
+	channel.setCapabilities(config.capabilities)
+	if config.obfuscation {
+		channel.setObfuscator(newObfuscator(false))
+	}
+	channel.setUpnpPortsRelease(config.upnpPortsRelease)
+
+	channelHandlers(channel)
+
+	channel.launchReadSendLoops()
+
+	// Send handlers ready to consumer.
+	started = time.Now()
+	err = m.providerChannelHandlersReady(providerID, serviceType)
+	report.step("handlers ready", started, err)
+	if err != nil {
+		channel.Close()
+		return relayed, fmt.Errorf("could not handle channel handlers ready: %w", err)
+	}
+	config.tracer.EndStage(traceAck)
+	return relayed, nil
+}
+
+func (m *listener) providerDialDirect(config *p2pConnectConfig) (*net.UDPConn, *net.UDPConn, error) {
+	conn1, err := net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[0]}, &net.UDPAddr{IP: net.ParseIP(config.peerIP()), Port: config.peerPorts[0]})
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create UDP conn for p2p channel: %w", err)
+	}
+	conn2, err := net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[1]}, &net.UDPAddr{IP: net.ParseIP(config.peerIP()), Port: config.peerPorts[1]})
+	if err != nil {
+		conn1.Close()
+		return nil, nil, fmt.Errorf("could not create UDP conn for service: %w", err)
+	}
+	return conn1, conn2, nil
+}
+
 func (m *listener) providerStartConfigExchange(signerID identity.Identity, msg *nats_lib.Msg, outboundIP string) error {
 	tracer := trace.NewTracer("Provider whole Connect")
 
@@ -249,6 +331,8 @@ func (m *listener) providerStartConfigExchange(signerID identity.Identity, msg *
 	if err != nil {
 		return fmt.Errorf("could not prepare ports: %w", err)
 	}
+	publicIPv6 := m.ipv6Resolver()
+	natType := m.diagnostics.natType()
 
 	m.setPendingConfig(p2pConnectConfig{
 		publicIP:         publicIP,
@@ -260,11 +344,21 @@ func (m *listener) providerStartConfigExchange(signerID identity.Identity, msg *
 		upnpPortsRelease: portsRelease,
 		peerPublicIP:     "",
 		peerPorts:        nil,
+		publicIPv6:       publicIPv6,
+		natType:          natType,
 	})
 
 	config := pb.P2PConnectConfig{
-		PublicIP: publicIP,
-		Ports:    intToInt32Slice(localPorts),
+		PublicIP:            publicIP,
+		Ports:               intToInt32Slice(localPorts),
+		Relay:               m.relay.Address,
+		RelayServiceTraffic: m.relay.ServiceTraffic,
+		Compression:         true,
+		ProtocolVersion:     ProtocolVersion,
+		Capabilities:        localCapabilities,
+		PublicIPv6:          publicIPv6,
+		NatType:             natType,
+		Obfuscation:         m.obfuscation,
 	}
 	configCiphertext, err := encryptConnConfigMsg(&config, privateKey, peerPubKey)
 	if err != nil {
@@ -317,6 +411,9 @@ func (m *listener) prepareLocalPorts(outboundIP string, tracer *trace.Tracer) (s
 	for _, p := range localPorts {
 		portRelease, portMappingOk = m.portMapper.Map("UDP", p, "Myst node p2p port mapping")
 		if !portMappingOk {
+			for _, release := range portsRelease {
+				release()
+			}
 			break
 		}
 		portsRelease = append(portsRelease, portRelease)
@@ -369,6 +466,7 @@ func (m *listener) providerAckConfigExchange(msg *nats_lib.Msg) (*p2pConnectConf
 
 	log.Debug().Msgf("Decrypted consumer config: %v", peerConfig)
 
+	capabilities := negotiateCapabilities(peerConfig)
 	return &p2pConnectConfig{
 		peerPublicIP:     peerConfig.PublicIP,
 		peerPorts:        int32ToIntSlice(peerConfig.Ports),
@@ -379,6 +477,13 @@ func (m *listener) providerAckConfigExchange(msg *nats_lib.Msg) (*p2pConnectConf
 		publicIP:         config.publicIP,
 		tracer:           config.tracer,
 		upnpPortsRelease: config.upnpPortsRelease,
+		relay:            negotiateRelay(m.relay, relayConfigFromProto(peerConfig)),
+		capabilities:     capabilities,
+		publicIPv6:       config.publicIPv6,
+		peerPublicIPv6:   peerConfig.GetPublicIPv6(),
+		natType:          config.natType,
+		peerNATType:      peerConfig.GetNatType(),
+		obfuscation:      negotiateObfuscation(m.obfuscation, peerConfig, capabilities),
 	}, nil
 }
 
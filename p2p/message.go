@@ -37,6 +37,12 @@ func init() {
 const (
 	// TopicKeepAlive is keep alive endpoint.
 	TopicKeepAlive = "p2p-keepalive"
+	// TopicChannelRekey is a channel keys rotation endpoint, it is handled by the channel itself.
+	TopicChannelRekey = "p2p-channel-rekey"
+	// TopicChannelRebind is sent by peer which moved to another network, it is handled by the channel itself.
+	TopicChannelRebind = "p2p-channel-rebind"
+	// TopicProbe is a latency probe endpoint answered before session is created.
+	TopicProbe = "p2p-probe"
 
 	// TopicSessionCreate is a session create endpoint for p2p communication.
 	TopicSessionCreate = "p2p-session-create"
@@ -46,6 +52,10 @@ const (
 	TopicSessionStatus = "p2p-session-connectivity-status"
 	// TopicSessionDestroy is a session destroy endpoint for p2p communication.
 	TopicSessionDestroy = "p2p-session-destroy"
+	// TopicSessionShutdown is a notification sent by provider before it stops the service serving the session.
+	TopicSessionShutdown = "p2p-session-shutdown"
+	// TopicSessionRenegotiate is a session tunnel keys rotation endpoint, keys are replaced without tearing down the session.
+	TopicSessionRenegotiate = "p2p-session-renegotiate"
 
 	// TopicPaymentMessage is a payment messages endpoint for p2p communication.
 	TopicPaymentMessage = "p2p-payment-message"
@@ -53,6 +63,14 @@ const (
 	TopicPaymentInvoice = "p2p-payment-invoice"
 )
 
+// priorityTopics are sent ahead of other queued messages, so that payments and keepalives are not delayed
+// by bulk messages and peer does not time them out.
+var priorityTopics = map[string]bool{
+	TopicKeepAlive:      true,
+	TopicPaymentMessage: true,
+	TopicPaymentInvoice: true,
+}
+
 // Message represent message with data bytes.
 type Message struct {
 	Data []byte
@@ -80,11 +98,13 @@ const (
 	headerFieldTopic     = "Topic"
 	headerStatusCode     = "Status-Code"
 	headerMsg            = "Message"
+	headerEncoding       = "Content-Encoding"
 
 	statusCodeOK                 = 1
 	statusCodePublicErr          = 2
 	statusCodeInternalErr        = 3
 	statusCodeHandlerNotFoundErr = 4
+	statusCodeAtCapacityErr      = 5
 )
 
 // transportMsg is internal structure for sending and receiving messages.
@@ -94,6 +114,8 @@ type transportMsg struct {
 	statusCode uint64
 	topic      string
 	msg        string
+	// encoding is set when data is compressed.
+	encoding string
 
 	// Data field.
 	data []byte
@@ -117,6 +139,7 @@ func (m *transportMsg) readFrom(conn *textproto.Reader) error {
 	m.statusCode = statusCode
 	m.topic = header.Get(headerFieldTopic)
 	m.msg = header.Get(headerMsg)
+	m.encoding = header.Get(headerEncoding)
 
 	// Read data.
 	data, err := conn.ReadDotBytes()
@@ -136,6 +159,9 @@ func (m *transportMsg) writeTo(conn *textproto.Writer) error {
 	header.WriteString(fmt.Sprintf("%s:%s\r\n", headerFieldTopic, m.topic))
 	header.WriteString(fmt.Sprintf("%s:%d\r\n", headerStatusCode, m.statusCode))
 	header.WriteString(fmt.Sprintf("%s:%s\r\n", headerMsg, m.msg))
+	if m.encoding != "" {
+		header.WriteString(fmt.Sprintf("%s:%s\r\n", headerEncoding, m.encoding))
+	}
 	header.WriteByte('\n')
 	w.Write(header.Bytes())
 	w.Write(m.data)
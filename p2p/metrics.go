@@ -0,0 +1,205 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	kcp "github.com/xtaci/kcp-go/v5"
+)
+
+// AppTopicChannelMetrics is a topic for events with transport metrics of p2p channels.
+const AppTopicChannelMetrics = "p2p channel metrics"
+
+// AppEventChannelMetrics carries transport metrics of the p2p channel with the peer.
+type AppEventChannelMetrics struct {
+	// Role is the side of the channel this node is on, "consumer" or "provider".
+	Role        string
+	PeerID      identity.Identity
+	SessionID   string
+	ServiceType string
+	Metrics     ChannelMetrics
+	// SendRate and ReceiveRate are channel throughput in bytes per second since the previous event.
+	SendRate    float64
+	ReceiveRate float64
+}
+
+// ChannelMetrics are transport level statistics of the p2p channel since it was created.
+type ChannelMetrics struct {
+	// RTT is smoothed round trip time of requests sent to the peer.
+	RTT             time.Duration
+	BytesSent       uint64
+	BytesReceived   uint64
+	PacketsSent     uint64
+	PacketsReceived uint64
+	// Segments is count of data segments sent by reliable transport, Retransmits is count of them sent again
+	// since the peer did not acknowledge them in time.
+	Segments    uint64
+	Retransmits uint64
+	// At is time metrics were taken at.
+	At time.Time
+}
+
+// channelMetrics collects channel metrics, counters are updated from transport loops concurrently.
+type channelMetrics struct {
+	bytesSent, bytesReceived     uint64
+	packetsSent, packetsReceived uint64
+	segments, retransmits        uint64
+
+	mu      sync.Mutex
+	rtt     time.Duration
+	lastSeq uint32
+	sentSeq bool
+}
+
+func (m *channelMetrics) packetSent(n int) {
+	atomic.AddUint64(&m.packetsSent, 1)
+	atomic.AddUint64(&m.bytesSent, uint64(n))
+}
+
+func (m *channelMetrics) packetReceived(n int) {
+	atomic.AddUint64(&m.packetsReceived, 1)
+	atomic.AddUint64(&m.bytesReceived, uint64(n))
+}
+
+// rttSample updates smoothed RTT the same way TCP does it, each sample contributing 1/8.
+func (m *channelMetrics) rttSample(rtt time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.rtt == 0 {
+		m.rtt = rtt
+		return
+	}
+	m.rtt += (rtt - m.rtt) / 8
+}
+
+// segmentSent counts data segment with given sequence number, segments not newer than the latest one are retransmits.
+func (m *channelMetrics) segmentSent(seq uint32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.segments++
+	if m.sentSeq && int32(seq-m.lastSeq) <= 0 {
+		m.retransmits++
+		return
+	}
+	m.lastSeq = seq
+	m.sentSeq = true
+}
+
+func (m *channelMetrics) snapshot() ChannelMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return ChannelMetrics{
+		RTT:             m.rtt,
+		BytesSent:       atomic.LoadUint64(&m.bytesSent),
+		BytesReceived:   atomic.LoadUint64(&m.bytesReceived),
+		PacketsSent:     atomic.LoadUint64(&m.packetsSent),
+		PacketsReceived: atomic.LoadUint64(&m.packetsReceived),
+		Segments:        m.segments,
+		Retransmits:     m.retransmits,
+		At:              time.Now(),
+	}
+}
+
+// Offsets of KCP packet fields, see kcp-go output and segment encoding.
+const (
+	kcpCryptHeaderSize = 16 + 4 // nonce and crc32
+	kcpFECHeaderSize   = 4 + 2 + 2
+	kcpFECTypeData     = 0xf1
+	kcpSegmentHeader   = 24
+	kcpCmdPush         = 81
+)
+
+// metricsBlockCrypt counts data segments KCP session sends, it is the last place KCP packets are seen unencrypted.
+type metricsBlockCrypt struct {
+	kcp.BlockCrypt
+	metrics *channelMetrics
+}
+
+func (b *metricsBlockCrypt) Encrypt(dst, src []byte) {
+	b.countSegments(src)
+	b.BlockCrypt.Encrypt(dst, src)
+}
+
+func (b *metricsBlockCrypt) countSegments(packet []byte) {
+	if len(packet) < kcpCryptHeaderSize+kcpFECHeaderSize {
+		return
+	}
+	// Parity packets only protect data packets which are counted themselves.
+	fec := packet[kcpCryptHeaderSize:]
+	if binary.LittleEndian.Uint16(fec[4:]) != kcpFECTypeData {
+		return
+	}
+	segments := fec[kcpFECHeaderSize:]
+	if size := int(binary.LittleEndian.Uint16(fec[6:])) - 2; size >= 0 && size < len(segments) {
+		segments = segments[:size]
+	}
+	for len(segments) >= kcpSegmentHeader {
+		cmd := segments[4]
+		seq := binary.LittleEndian.Uint32(segments[12:])
+		length := int(binary.LittleEndian.Uint32(segments[20:]))
+		if cmd == kcpCmdPush {
+			b.metrics.segmentSent(seq)
+		}
+		if length < 0 || kcpSegmentHeader+length > len(segments) {
+			return
+		}
+		segments = segments[kcpSegmentHeader+length:]
+	}
+}
+
+// MetricsReporter publishes metrics of the channel, throughput is measured between reports.
+type MetricsReporter struct {
+	publisher eventbus.Publisher
+	channel   Channel
+	event     AppEventChannelMetrics
+}
+
+// NewMetricsReporter creates reporter of channel metrics for the session with given peer.
+func NewMetricsReporter(publisher eventbus.Publisher, channel Channel, role string, peerID identity.Identity, sessionID, serviceType string) *MetricsReporter {
+	return &MetricsReporter{
+		publisher: publisher,
+		channel:   channel,
+		event: AppEventChannelMetrics{
+			Role:        role,
+			PeerID:      peerID,
+			SessionID:   sessionID,
+			ServiceType: serviceType,
+		},
+	}
+}
+
+// Report publishes current channel metrics.
+func (r *MetricsReporter) Report() {
+	metrics := r.channel.Metrics()
+	prev := r.event.Metrics
+	if elapsed := metrics.At.Sub(prev.At).Seconds(); !prev.At.IsZero() && elapsed > 0 {
+		r.event.SendRate = float64(metrics.BytesSent-prev.BytesSent) / elapsed
+		r.event.ReceiveRate = float64(metrics.BytesReceived-prev.BytesReceived) / elapsed
+	}
+	r.event.Metrics = metrics
+	r.publisher.Publish(AppTopicChannelMetrics, r.event)
+}
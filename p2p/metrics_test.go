@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannel_Metrics(t *testing.T) {
+	provider, consumer, err := createTestChannels()
+	require.NoError(t, err)
+	defer provider.Close()
+	defer consumer.Close()
+
+	provider.Handle("test", func(c Context) error {
+		return c.OkWithReply(&Message{Data: []byte("pong")})
+	})
+	for i := 0; i < 3; i++ {
+		_, err := consumer.Send(context.Background(), "test", &Message{Data: []byte("ping")})
+		require.NoError(t, err)
+	}
+
+	metrics := consumer.Metrics()
+	assert.True(t, metrics.RTT > 0)
+	assert.True(t, metrics.BytesSent > 0)
+	assert.True(t, metrics.BytesReceived > 0)
+	assert.True(t, metrics.PacketsSent > 0)
+	assert.True(t, metrics.PacketsReceived > 0)
+	assert.True(t, metrics.Segments >= 3)
+}
+
+func TestChannelMetrics_CountsRetransmits(t *testing.T) {
+	var metrics channelMetrics
+	for _, seq := range []uint32{1, 2, 2, 3, 1, 4} {
+		metrics.segmentSent(seq)
+	}
+
+	snapshot := metrics.snapshot()
+	assert.Equal(t, uint64(6), snapshot.Segments)
+	assert.Equal(t, uint64(2), snapshot.Retransmits)
+}
+
+func TestMetricsBlockCrypt_CountsPushSegments(t *testing.T) {
+	segment := func(cmd byte, seq uint32, data []byte) []byte {
+		seg := make([]byte, kcpSegmentHeader)
+		seg[4] = cmd
+		binary.LittleEndian.PutUint32(seg[12:], seq)
+		binary.LittleEndian.PutUint32(seg[20:], uint32(len(data)))
+		return append(seg, data...)
+	}
+	payload := append(segment(kcpCmdPush, 7, []byte("abc")), segment(82, 9, nil)...)
+	payload = append(payload, segment(kcpCmdPush, 8, []byte("de"))...)
+	packet := make([]byte, kcpCryptHeaderSize+kcpFECHeaderSize)
+	binary.LittleEndian.PutUint16(packet[kcpCryptHeaderSize+4:], kcpFECTypeData)
+	binary.LittleEndian.PutUint16(packet[kcpCryptHeaderSize+6:], uint16(len(payload)+2))
+	packet = append(packet, payload...)
+
+	metrics := &channelMetrics{}
+	crypt := &metricsBlockCrypt{metrics: metrics}
+	crypt.countSegments(packet)
+
+	assert.Equal(t, uint64(2), metrics.snapshot().Segments)
+	assert.Equal(t, uint32(8), metrics.lastSeq)
+}
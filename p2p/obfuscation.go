@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"time"
+
+	"github.com/mysteriumnetwork/node/pb"
+)
+
+// DTLS 1.2 record layout obfuscated channel packets mimic, so that channel looks like WebRTC traffic.
+const (
+	dtlsRecordHeaderSize    = 13
+	dtlsHandshakeHeaderSize = 12
+	dtlsContentHandshake    = 22
+	dtlsContentAppData      = 23
+	dtlsVersionMajor        = 0xfe
+	dtlsVersionMinor        = 0xfd
+	dtlsClientHello         = 1
+	dtlsServerHello         = 2
+)
+
+const (
+	// obfuscationHandshakeRecords is how many first packets of the channel are disguised as DTLS handshake.
+	obfuscationHandshakeRecords = 4
+	// obfuscationMaxPadding limits random padding, padded KCP packets still fit into mtuLimit.
+	obfuscationMaxPadding = 128
+)
+
+// negotiateObfuscation enables obfuscation if any of the peers asks for it and both of them support it.
+func negotiateObfuscation(local bool, peerConfig *pb.P2PConnectConfig, capabilities Capabilities) bool {
+	return (local || peerConfig.GetObfuscation()) && capabilities.Has(CapabilityObfuscation)
+}
+
+// obfuscator disguises channel packets as DTLS records padded to random sizes, so that
+// networks blocking or throttling VPN signaling can not identify the channel by packet contents or sizes.
+// Packets are already encrypted by the channel, obfuscator only hides their structure.
+type obfuscator struct {
+	helloType byte
+	seq       uint64
+	rand      *rand.Rand
+}
+
+// newObfuscator creates obfuscator, dialing peer mimics DTLS client and listening peer mimics DTLS server.
+func newObfuscator(client bool) *obfuscator {
+	helloType := byte(dtlsServerHello)
+	if client {
+		helloType = dtlsClientHello
+	}
+	return &obfuscator{
+		helloType: helloType,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// wrap frames packet as DTLS record. It is not safe for concurrent use, packets are wrapped by remote send loop only.
+func (o *obfuscator) wrap(packet []byte) []byte {
+	seq := o.seq
+	o.seq++
+	handshake := seq < obfuscationHandshakeRecords
+	padding := o.rand.Intn(obfuscationMaxPadding + 1)
+
+	size := dtlsRecordHeaderSize + 2 + len(packet) + padding
+	if handshake {
+		size += dtlsHandshakeHeaderSize
+	}
+	record := make([]byte, size)
+	record[1], record[2] = dtlsVersionMajor, dtlsVersionMinor
+	binary.BigEndian.PutUint64(record[3:11], seq) // 16 bit epoch is followed by 48 bit sequence number.
+	binary.BigEndian.PutUint16(record[11:13], uint16(size-dtlsRecordHeaderSize))
+
+	body := record[dtlsRecordHeaderSize:]
+	if handshake {
+		record[0] = dtlsContentHandshake
+		length := uint32(len(body) - dtlsHandshakeHeaderSize)
+		binary.BigEndian.PutUint32(body[0:4], length)
+		body[0] = o.helloType
+		binary.BigEndian.PutUint16(body[4:6], uint16(seq))
+		binary.BigEndian.PutUint32(body[8:12], length)
+		body = body[dtlsHandshakeHeaderSize:]
+	} else {
+		record[0] = dtlsContentAppData
+		binary.BigEndian.PutUint16(record[3:5], 1)
+	}
+
+	binary.BigEndian.PutUint16(body[0:2], uint16(len(packet)))
+	copy(body[2:], packet)
+	o.rand.Read(body[2+len(packet):])
+	return record
+}
+
+// unwrap returns packet framed in DTLS record, false is returned for anything else.
+func (o *obfuscator) unwrap(record []byte) ([]byte, bool) {
+	if len(record) < dtlsRecordHeaderSize || record[1] != dtlsVersionMajor || record[2] != dtlsVersionMinor {
+		return nil, false
+	}
+	body := record[dtlsRecordHeaderSize:]
+	if int(binary.BigEndian.Uint16(record[11:13])) != len(body) {
+		return nil, false
+	}
+
+	switch record[0] {
+	case dtlsContentHandshake:
+		if len(body) < dtlsHandshakeHeaderSize {
+			return nil, false
+		}
+		body = body[dtlsHandshakeHeaderSize:]
+	case dtlsContentAppData:
+	default:
+		return nil, false
+	}
+
+	if len(body) < 2 {
+		return nil, false
+	}
+	n := int(binary.BigEndian.Uint16(body[0:2]))
+	if 2+n > len(body) {
+		return nil, false
+	}
+	return body[2 : 2+n], true
+}
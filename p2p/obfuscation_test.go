@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscator_WrapsPacketsAsDTLSRecords(t *testing.T) {
+	client := newObfuscator(true)
+	server := newObfuscator(false)
+	packet := bytes.Repeat([]byte{0xaa}, kcpMTUSize)
+
+	for i := 0; i < obfuscationHandshakeRecords+2; i++ {
+		record := client.wrap(packet)
+		assert.LessOrEqual(t, len(record), mtuLimit)
+		assert.Equal(t, []byte{dtlsVersionMajor, dtlsVersionMinor}, record[1:3])
+		if i < obfuscationHandshakeRecords {
+			assert.Equal(t, byte(dtlsContentHandshake), record[0])
+			assert.Equal(t, byte(dtlsClientHello), record[dtlsRecordHeaderSize])
+		} else {
+			assert.Equal(t, byte(dtlsContentAppData), record[0])
+		}
+
+		unwrapped, ok := server.unwrap(record)
+		assert.True(t, ok)
+		assert.Equal(t, packet, unwrapped)
+	}
+}
+
+func TestObfuscator_RejectsOtherPackets(t *testing.T) {
+	o := newObfuscator(false)
+	record := o.wrap([]byte("packet"))
+
+	for _, packet := range [][]byte{nil, []byte("OK"), record[:len(record)-1], append([]byte{0x15}, record[1:]...)} {
+		_, ok := o.unwrap(packet)
+		assert.False(t, ok)
+	}
+}
+
+func TestNegotiateObfuscation(t *testing.T) {
+	supported := Capabilities{Names: []string{CapabilityObfuscation}}
+
+	assert.False(t, negotiateObfuscation(false, &pb.P2PConnectConfig{}, supported))
+	assert.True(t, negotiateObfuscation(true, &pb.P2PConnectConfig{}, supported))
+	assert.True(t, negotiateObfuscation(false, &pb.P2PConnectConfig{Obfuscation: true}, supported))
+	assert.False(t, negotiateObfuscation(true, &pb.P2PConnectConfig{Obfuscation: true}, Capabilities{}))
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrRebindNotSupported indicates that channel can not be moved to another network and has to be re-created.
+var ErrRebindNotSupported = errors.New("p2p channel rebind is not supported")
+
+// handleRebind confirms that peer reached the channel from its new address.
+// Address itself is updated by remote read loop once authenticated packets arrive from it.
+func (c *channel) handleRebind(ctx Context) error {
+	log.Debug().Msgf("P2P channel rebound by peer to %v", c.peer.addr())
+	return ctx.OK()
+}
+
+// Rebind moves channel to a new local socket, since socket bound to the address of the previous network
+// can no longer reach the peer, and sends rebind request through it. Request is encrypted with the channel key,
+// so peer starts using the new address right away and its reply confirms that channel works over the new network.
+func (c *channel) Rebind(ctx context.Context) error {
+	if c.Relayed() {
+		return fmt.Errorf("channel is relayed: %w", ErrRebindNotSupported)
+	}
+	if !c.Capabilities().Has(CapabilityChannelRebind) {
+		return fmt.Errorf("peer does not support %s: %w", CapabilityChannelRebind, ErrRebindNotSupported)
+	}
+
+	conn, err := c.rebindConn()
+	if err != nil {
+		return err
+	}
+	go c.remoteReadLoop(conn)
+
+	if _, err := c.Send(ctx, TopicChannelRebind, &Message{}); err != nil {
+		return fmt.Errorf("could not send rebind request: %w", err)
+	}
+	log.Debug().Msgf("P2P channel rebound to local addr %v", conn.LocalAddr())
+	return nil
+}
+
+// rebindConn replaces remote conn with a new one listening on all interfaces.
+func (c *channel) rebindConn() (*net.UDPConn, error) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	select {
+	case <-c.stop:
+		return nil, errors.New("channel is closed")
+	default:
+	}
+
+	network := "udp4"
+	if localAddr := c.tr.remoteConn.LocalAddr().(*net.UDPAddr); localAddr.IP != nil && localAddr.IP.To4() == nil {
+		network = "udp6"
+	}
+	conn, err := net.ListenUDP(network, &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("could not listen UDP: %w", err)
+	}
+
+	c.tr.remoteConn.Close()
+	c.tr.remoteConn = conn
+	return conn, nil
+}
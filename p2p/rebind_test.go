@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannel_Rebind(t *testing.T) {
+	provider, consumer, err := createTestChannels()
+	require.NoError(t, err)
+	defer provider.Close()
+	defer consumer.Close()
+
+	provider.Handle("test", func(c Context) error {
+		return c.OkWithReply(&Message{Data: []byte("pong")})
+	})
+	consumer.Handle("test", func(c Context) error {
+		return c.OkWithReply(&Message{Data: []byte("pong")})
+	})
+	send := func(from Channel) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		res, err := from.Send(ctx, "test", &Message{Data: []byte("ping")})
+		require.NoError(t, err)
+		assert.Equal(t, "pong", string(res.Data))
+	}
+	send(consumer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.True(t, errors.Is(consumer.Rebind(ctx), ErrRebindNotSupported))
+
+	consumer.(*channel).setCapabilities(Capabilities{Names: []string{CapabilityChannelRebind}})
+	oldAddr := consumer.Conn().LocalAddr().(*net.UDPAddr)
+	require.NoError(t, consumer.Rebind(ctx))
+
+	newAddr := consumer.Conn().LocalAddr().(*net.UDPAddr)
+	assert.NotEqual(t, oldAddr.Port, newAddr.Port)
+	assert.Equal(t, newAddr.Port, provider.(*channel).peer.addr().Port)
+	send(consumer)
+	send(provider)
+}
+
+func TestChannel_IgnoresUnauthenticatedAddressChange(t *testing.T) {
+	provider, consumer, err := createTestChannels()
+	require.NoError(t, err)
+	defer provider.Close()
+	defer consumer.Close()
+
+	peerAddr := provider.(*channel).peer.addr()
+	conn, err := net.DialUDP("udp4", nil, provider.Conn().LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write(make([]byte, 100))
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, peerAddr, provider.(*channel).peer.addr())
+}
@@ -0,0 +1,209 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/pb"
+	"github.com/rs/zerolog/log"
+	kcp "github.com/xtaci/kcp-go/v5"
+)
+
+// rekeyTimeout limits how long channel waits for peer to reply to the rekey request.
+const rekeyTimeout = 10 * time.Second
+
+// rotatingBlockCrypt encrypts channel packets with the current key and lets keys be replaced without losing packets.
+// Packets encrypted with the previous key are still accepted, so that packets in flight during rotation are not dropped.
+// Peer answering rekey request keeps sending with the current key until the first packet encrypted with the next key
+// arrives, this way peers switch over only once both of them know the new key.
+type rotatingBlockCrypt struct {
+	mu       sync.RWMutex
+	current  kcp.BlockCrypt
+	previous kcp.BlockCrypt
+	next     kcp.BlockCrypt
+}
+
+func newRotatingBlockCrypt(crypt kcp.BlockCrypt) *rotatingBlockCrypt {
+	return &rotatingBlockCrypt{current: crypt}
+}
+
+func (b *rotatingBlockCrypt) Encrypt(dst, src []byte) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	b.current.Encrypt(dst, src)
+}
+
+func (b *rotatingBlockCrypt) Decrypt(dst, src []byte) {
+	b.mu.RLock()
+	current, next, previous := b.current, b.next, b.previous
+	b.mu.RUnlock()
+
+	if next == nil && previous == nil {
+		current.Decrypt(dst, src)
+		return
+	}
+
+	packet := make([]byte, len(src))
+	for _, crypt := range []kcp.BlockCrypt{current, next, previous} {
+		if crypt == nil {
+			continue
+		}
+		crypt.Decrypt(packet, src)
+		if !validPacket(packet) {
+			continue
+		}
+		if crypt == next {
+			b.rotate(next)
+		}
+		copy(dst, packet)
+		return
+	}
+	// Let KCP session drop the packet on checksum mismatch.
+	current.Decrypt(dst, src)
+}
+
+// authentic reports whether packet is encrypted with any of the channel keys.
+func (b *rotatingBlockCrypt) authentic(src []byte) bool {
+	if len(src) < kcpCryptHeaderSize {
+		return false
+	}
+
+	b.mu.RLock()
+	current, next, previous := b.current, b.next, b.previous
+	b.mu.RUnlock()
+
+	packet := make([]byte, len(src))
+	for _, crypt := range []kcp.BlockCrypt{current, next, previous} {
+		if crypt == nil {
+			continue
+		}
+		crypt.Decrypt(packet, src)
+		if validPacket(packet) {
+			return true
+		}
+	}
+	return false
+}
+
+// rotate makes crypt the current one, the current one is kept to decrypt packets in flight.
+func (b *rotatingBlockCrypt) rotate(crypt kcp.BlockCrypt) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.current == crypt {
+		return
+	}
+	b.previous = b.current
+	b.current = crypt
+	b.next = nil
+}
+
+// setNext accepts packets encrypted with crypt, it becomes the current one once peer starts using it.
+func (b *rotatingBlockCrypt) setNext(crypt kcp.BlockCrypt) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.next = crypt
+}
+
+// validPacket checks checksum of decrypted KCP packet.
+func validPacket(packet []byte) bool {
+	if len(packet) < kcpCryptHeaderSize {
+		return false
+	}
+	checksum := binary.LittleEndian.Uint32(packet[kcpCryptHeaderSize-4:])
+	return checksum == crc32.ChecksumIEEE(packet[kcpCryptHeaderSize:])
+}
+
+// handleRekey derives next channel key from the new peer's public key and replies with its own new public key.
+func (c *channel) handleRekey(ctx Context) error {
+	var req pb.P2PChannelRekey
+	if err := ctx.Request().UnmarshalProto(&req); err != nil {
+		return err
+	}
+	peerPubKey, err := DecodePublicKey(req.GetPublicKey())
+	if err != nil {
+		return fmt.Errorf("could not decode peer public key: %w", err)
+	}
+	pubKey, privateKey, err := GenerateKey()
+	if err != nil {
+		return fmt.Errorf("could not generate channel keys: %w", err)
+	}
+	crypt, err := newBlockCrypt(privateKey, peerPubKey)
+	if err != nil {
+		return err
+	}
+
+	c.crypt.setNext(crypt)
+	log.Debug().Msg("Rotating p2p channel keys on peer request")
+	return ctx.OkWithReply(ProtoMessage(&pb.P2PChannelRekey{PublicKey: pubKey.Hex()}))
+}
+
+// rekey exchanges new public keys with the peer and switches channel to the key derived from them.
+func (c *channel) rekey(ctx context.Context) error {
+	pubKey, privateKey, err := GenerateKey()
+	if err != nil {
+		return fmt.Errorf("could not generate channel keys: %w", err)
+	}
+	res, err := c.Send(ctx, TopicChannelRekey, ProtoMessage(&pb.P2PChannelRekey{PublicKey: pubKey.Hex()}))
+	if err != nil {
+		return fmt.Errorf("could not send rekey request: %w", err)
+	}
+	var reply pb.P2PChannelRekey
+	if err := res.UnmarshalProto(&reply); err != nil {
+		return fmt.Errorf("could not unmarshal rekey reply: %w", err)
+	}
+	peerPubKey, err := DecodePublicKey(reply.GetPublicKey())
+	if err != nil {
+		return fmt.Errorf("could not decode peer public key: %w", err)
+	}
+	crypt, err := newBlockCrypt(privateKey, peerPubKey)
+	if err != nil {
+		return err
+	}
+
+	c.crypt.rotate(crypt)
+	return nil
+}
+
+// rotateKeys periodically replaces channel keys until channel is closed. Only one of the peers rotates the keys.
+func (c *channel) rotateKeys(interval time.Duration) {
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(interval):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), rekeyTimeout)
+		err := c.rekey(ctx)
+		cancel()
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not rotate p2p channel keys")
+			continue
+		}
+		log.Debug().Msg("Rotated p2p channel keys")
+	}
+}
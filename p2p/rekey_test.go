@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannel_Rekey(t *testing.T) {
+	provider, consumer, err := createTestChannels()
+	require.NoError(t, err)
+	defer provider.Close()
+	defer consumer.Close()
+
+	provider.Handle("test", func(c Context) error {
+		return c.OkWithReply(&Message{Data: []byte("pong")})
+	})
+	consumer.Handle("test", func(c Context) error {
+		return c.OkWithReply(&Message{Data: []byte("pong")})
+	})
+	send := func(from Channel) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		res, err := from.Send(ctx, "test", &Message{Data: []byte("ping")})
+		require.NoError(t, err)
+		assert.Equal(t, "pong", string(res.Data))
+	}
+	send(consumer)
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = consumer.(*channel).rekey(ctx)
+		cancel()
+		require.NoError(t, err)
+
+		send(consumer)
+		send(provider)
+	}
+
+	crypt := provider.(*channel).crypt
+	crypt.mu.RLock()
+	defer crypt.mu.RUnlock()
+	assert.Nil(t, crypt.next)
+	assert.NotNil(t, crypt.previous)
+}
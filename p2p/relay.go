@@ -0,0 +1,330 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/firewall"
+	"github.com/mysteriumnetwork/node/pb"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+	relayBindInterval = 200 * time.Millisecond
+	relayBindTimeout  = 10 * time.Second
+	relayTokenSize    = sha256.Size
+)
+
+// relayMagic prefixes bind packets peers send to the relay server.
+var relayMagic = []byte("MYSTRLY1")
+
+// RelayConfig describes relay server p2p traffic is routed through when direct NAT traversal fails.
+type RelayConfig struct {
+	// Address is host:port of UDP relay server, relaying is disabled when empty.
+	Address string
+	// ServiceTraffic routes service connection through the relay too, otherwise only p2p channel is relayed
+	// and service traffic goes directly to the provider.
+	ServiceTraffic bool
+}
+
+func (c RelayConfig) enabled() bool {
+	return c.Address != ""
+}
+
+func relayConfigFromProto(config *pb.P2PConnectConfig) RelayConfig {
+	return RelayConfig{
+		Address:        config.GetRelay(),
+		ServiceTraffic: config.GetRelayServiceTraffic(),
+	}
+}
+
+// negotiateRelay picks relay both peers fall back to, relay of the provider takes precedence over consumer's one.
+func negotiateRelay(provider, consumer RelayConfig) RelayConfig {
+	if provider.enabled() {
+		return provider
+	}
+	return consumer
+}
+
+type relayToken [relayTokenSize]byte
+
+// newRelayToken derives token peers bind their connection to the relay with. It is computed from
+// p2p keys shared secret so only the two peers know it.
+func newRelayToken(privateKey PrivateKey, peerPubKey PublicKey, connIndex int) relayToken {
+	var sharedKey [32]byte
+	box.Precompute(&sharedKey, (*[32]byte)(&peerPubKey), (*[32]byte)(&privateKey))
+	return sha256.Sum256(append(sharedKey[:], byte(connIndex)))
+}
+
+func relayBindPacket(token relayToken) []byte {
+	return append(append([]byte{}, relayMagic...), token[:]...)
+}
+
+func parseRelayBindPacket(packet []byte) (relayToken, bool) {
+	var token relayToken
+	if len(packet) != len(relayMagic)+relayTokenSize || !bytes.HasPrefix(packet, relayMagic) {
+		return token, false
+	}
+	copy(token[:], packet[len(relayMagic):])
+	return token, true
+}
+
+// dialRelay binds p2p channel connection and, if service traffic is relayed, service connection to the relay
+// server and waits until the peer binds its connections too. Service connection is nil when it is not relayed.
+// Connections use fresh ports of the pool as ports used for NAT traversal may still be held by the pinger.
+func dialRelay(ctx context.Context, config *p2pConnectConfig, portPool port.ServicePortSupplier) (*net.UDPConn, *net.UDPConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, relayBindTimeout)
+	defer cancel()
+
+	relayAddr, err := net.ResolveUDPAddr("udp4", config.relay.Address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not resolve relay address: %w", err)
+	}
+	if _, err := firewall.AllowIPAccess(relayAddr.IP.String()); err != nil {
+		return nil, nil, fmt.Errorf("could not add relay IP firewall rule: %w", err)
+	}
+
+	localPorts, err := acquireLocalPorts(portPool, requiredConnCount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not acquire local ports: %w", err)
+	}
+
+	conn1, err := bindRelay(ctx, localPorts[0], relayAddr, newRelayToken(config.privateKey, config.peerPubKey, 0))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not bind p2p channel conn to relay: %w", err)
+	}
+	if !config.relay.ServiceTraffic {
+		return conn1, nil, nil
+	}
+
+	conn2, err := bindRelay(ctx, localPorts[1], relayAddr, newRelayToken(config.privateKey, config.peerPubKey, 1))
+	if err != nil {
+		conn1.Close()
+		return nil, nil, fmt.Errorf("could not bind service conn to relay: %w", err)
+	}
+	return conn1, conn2, nil
+}
+
+// bindRelay sends bind packets to the relay until it acknowledges that the peer bound with the same token.
+func bindRelay(ctx context.Context, localPort int, relayAddr *net.UDPAddr, token relayToken) (*net.UDPConn, error) {
+	conn, err := net.DialUDP("udp4", &net.UDPAddr{Port: localPort}, relayAddr)
+	if err != nil {
+		return nil, fmt.Errorf("could not create UDP conn: %w", err)
+	}
+
+	bind := relayBindPacket(token)
+	buf := make([]byte, len(bind))
+	for {
+		if _, err := conn.Write(bind); err != nil {
+			log.Debug().Err(err).Msg("Could not send bind packet to relay")
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(relayBindInterval)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("could not set read deadline: %w", err)
+		}
+		n, err := conn.Read(buf)
+		if err == nil && bytes.Equal(buf[:n], bind) {
+			if err := conn.SetReadDeadline(time.Time{}); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("could not reset read deadline: %w", err)
+			}
+			return conn, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, fmt.Errorf("relay did not pair connection: %w", ctx.Err())
+		default:
+		}
+	}
+}
+
+// relayPair holds addresses of two peers bound to the relay with the same token.
+type relayPair struct {
+	addrs    [2]*net.UDPAddr
+	lastSeen time.Time
+}
+
+func (p *relayPair) paired() bool {
+	return p.addrs[1] != nil
+}
+
+func (p *relayPair) has(addr *net.UDPAddr) bool {
+	for _, a := range p.addrs {
+		if a != nil && a.String() == addr.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *relayPair) peerOf(addr *net.UDPAddr) *net.UDPAddr {
+	if p.addrs[0].String() == addr.String() {
+		return p.addrs[1]
+	}
+	return p.addrs[0]
+}
+
+// RelayServer forwards UDP packets between peers which failed to traverse NATs to reach each other.
+// Peers bind to the relay by sending the same token, packets of one peer are forwarded to the other one afterwards.
+// Relay can't read relayed traffic as p2p channel and service traffic are end-to-end encrypted.
+type RelayServer struct {
+	conn        *net.UDPConn
+	idleTimeout time.Duration
+
+	mu      sync.Mutex
+	byToken map[relayToken]*relayPair
+	byAddr  map[string]*relayPair
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRelayServer creates relay server serving peers on the given connection. Pairs which
+// have not relayed any traffic for idleTimeout are forgotten.
+func NewRelayServer(conn *net.UDPConn, idleTimeout time.Duration) *RelayServer {
+	return &RelayServer{
+		conn:        conn,
+		idleTimeout: idleTimeout,
+		byToken:     make(map[relayToken]*relayPair),
+		byAddr:      make(map[string]*relayPair),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Serve relays packets until the server is stopped.
+func (s *RelayServer) Serve() error {
+	go s.expireIdle()
+
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-s.stop:
+				return nil
+			default:
+			}
+			if errNetClose(err) {
+				return nil
+			}
+			return fmt.Errorf("could not read from relay conn: %w", err)
+		}
+
+		if token, ok := parseRelayBindPacket(buf[:n]); ok {
+			s.bind(token, addr, buf[:n])
+			continue
+		}
+		s.forward(addr, buf[:n])
+	}
+}
+
+// Stop stops relaying and closes relay connection.
+func (s *RelayServer) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		if err := s.conn.Close(); err != nil {
+			log.Err(err).Msg("Could not close relay conn")
+		}
+	})
+}
+
+func (s *RelayServer) bind(token relayToken, addr *net.UDPAddr, packet []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pair, ok := s.byToken[token]
+	switch {
+	case !ok:
+		pair = &relayPair{addrs: [2]*net.UDPAddr{addr}, lastSeen: time.Now()}
+		s.byToken[token] = pair
+		s.byAddr[addr.String()] = pair
+		return
+	case pair.has(addr):
+		if !pair.paired() {
+			return
+		}
+	case !pair.paired():
+		pair.addrs[1] = addr
+		pair.lastSeen = time.Now()
+		s.byAddr[addr.String()] = pair
+		s.ack(pair.addrs[0], packet)
+	default:
+		// Token is already used by other two peers.
+		return
+	}
+	s.ack(addr, packet)
+}
+
+func (s *RelayServer) ack(addr *net.UDPAddr, packet []byte) {
+	if _, err := s.conn.WriteToUDP(packet, addr); err != nil {
+		log.Debug().Err(err).Msgf("Could not acknowledge relay bind of %s", addr)
+	}
+}
+
+func (s *RelayServer) forward(addr *net.UDPAddr, packet []byte) {
+	s.mu.Lock()
+	pair, ok := s.byAddr[addr.String()]
+	if !ok || !pair.paired() {
+		s.mu.Unlock()
+		return
+	}
+	pair.lastSeen = time.Now()
+	peerAddr := pair.peerOf(addr)
+	s.mu.Unlock()
+
+	if _, err := s.conn.WriteToUDP(packet, peerAddr); err != nil {
+		log.Debug().Err(err).Msgf("Could not relay packet to %s", peerAddr)
+	}
+}
+
+func (s *RelayServer) expireIdle() {
+	ticker := time.NewTicker(s.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			for token, pair := range s.byToken {
+				if time.Since(pair.lastSeen) < s.idleTimeout {
+					continue
+				}
+				delete(s.byToken, token)
+				for _, addr := range pair.addrs {
+					if addr != nil {
+						delete(s.byAddr, addr.String())
+					}
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
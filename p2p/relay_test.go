@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayServer_RelaysTrafficBetweenPeersBoundWithSameToken(t *testing.T) {
+	relay := startTestRelay(t)
+	defer relay.Stop()
+	relayAddr := relay.conn.LocalAddr().(*net.UDPAddr)
+
+	_, consumerKey, err := GenerateKey()
+	require.NoError(t, err)
+	providerPubKey, _, err := GenerateKey()
+	require.NoError(t, err)
+	token := newRelayToken(consumerKey, providerPubKey, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conns := make(chan *net.UDPConn, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			conn, err := bindRelay(ctx, 0, relayAddr, token)
+			assert.NoError(t, err)
+			conns <- conn
+		}()
+	}
+	conn1, conn2 := <-conns, <-conns
+	require.NotNil(t, conn1)
+	require.NotNil(t, conn2)
+	defer conn1.Close()
+	defer conn2.Close()
+
+	_, err = conn1.Write([]byte("ping"))
+	require.NoError(t, err)
+	assert.Equal(t, "ping", readPacket(t, conn2))
+
+	_, err = conn2.Write([]byte("pong"))
+	require.NoError(t, err)
+	assert.Equal(t, "pong", readPacket(t, conn1))
+}
+
+func TestRelayServer_DoesNotPairPeersWithDifferentTokens(t *testing.T) {
+	relay := startTestRelay(t)
+	defer relay.Stop()
+	relayAddr := relay.conn.LocalAddr().(*net.UDPAddr)
+
+	_, key1, err := GenerateKey()
+	require.NoError(t, err)
+	pubKey2, _, err := GenerateKey()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func(connIndex int) {
+			_, err := bindRelay(ctx, 0, relayAddr, newRelayToken(key1, pubKey2, connIndex))
+			errs <- err
+		}(i)
+	}
+	assert.Error(t, <-errs)
+	assert.Error(t, <-errs)
+}
+
+func TestNewRelayToken_IsSameForBothPeers(t *testing.T) {
+	pubKey1, key1, err := GenerateKey()
+	require.NoError(t, err)
+	pubKey2, key2, err := GenerateKey()
+	require.NoError(t, err)
+
+	assert.Equal(t, newRelayToken(key1, pubKey2, 0), newRelayToken(key2, pubKey1, 0))
+	assert.NotEqual(t, newRelayToken(key1, pubKey2, 0), newRelayToken(key1, pubKey2, 1))
+}
+
+func TestNegotiateRelay(t *testing.T) {
+	provider := RelayConfig{Address: "1.1.1.1:3478", ServiceTraffic: true}
+	consumer := RelayConfig{Address: "2.2.2.2:3478"}
+
+	assert.Equal(t, provider, negotiateRelay(provider, consumer))
+	assert.Equal(t, consumer, negotiateRelay(RelayConfig{}, consumer))
+	assert.False(t, negotiateRelay(RelayConfig{}, RelayConfig{}).enabled())
+}
+
+func startTestRelay(t *testing.T) *RelayServer {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+
+	relay := NewRelayServer(conn, time.Minute)
+	go relay.Serve()
+	return relay
+}
+
+func readPacket(t *testing.T, conn *net.UDPConn) string {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	buf := make([]byte, 100)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package requests
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/proxy"
+)
+
+// ProxyDialer dials TCP connections through the upstream proxy.
+type ProxyDialer interface {
+	Dial(network, address string) (net.Conn, error)
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// ParseProxyURL validates address of the upstream HTTP or SOCKS5 proxy, e.g. "socks5://127.0.0.1:1080".
+func ParseProxyURL(address string) (*url.URL, error) {
+	proxyURL, err := url.Parse(address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid proxy address %q", address)
+	}
+	switch proxyURL.Scheme {
+	case "http", "socks5":
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme %q, use http or socks5", proxyURL.Scheme)
+	}
+	if proxyURL.Hostname() == "" || proxyURL.Port() == "" {
+		return nil, errors.Errorf("proxy host and port are required in %q", address)
+	}
+	return proxyURL, nil
+}
+
+// NewProxiedHTTPClient creates a new HTTP client sending requests through the upstream proxy.
+func NewProxiedHTTPClient(srcIP string, proxyURL *url.URL, timeout time.Duration) *HTTPClient {
+	c := &HTTPClient{
+		clientFactory: func() *http.Client {
+			transport := GetDefaultTransport(srcIP)
+			transport.Proxy = http.ProxyURL(proxyURL)
+			return &http.Client{
+				Timeout:   timeout,
+				Transport: transport,
+			}
+		},
+	}
+	c.client = c.clientFactory()
+	return c
+}
+
+// NewProxyDialer returns dialer tunneling TCP connections through the upstream proxy,
+// HTTP proxies are asked to tunnel connections using CONNECT method.
+func NewProxyDialer(proxyURL *url.URL, forward *net.Dialer) (ProxyDialer, error) {
+	switch proxyURL.Scheme {
+	case "http":
+		return &connectDialer{proxyURL: proxyURL, forward: forward}, nil
+	case "socks5":
+		dialer, err := proxy.FromURL(proxyURL, forward)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create SOCKS5 proxy dialer")
+		}
+		socksDialer, ok := dialer.(ProxyDialer)
+		if !ok {
+			return nil, errors.New("SOCKS5 proxy dialer does not support context")
+		}
+		return socksDialer, nil
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+}
+
+// connectDialer tunnels TCP connections through HTTP proxy using CONNECT method.
+type connectDialer struct {
+	proxyURL *url.URL
+	forward  *net.Dialer
+}
+
+// Dial connects to the address through the proxy.
+func (d *connectDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext connects to the address through the proxy.
+func (d *connectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.forward.DialContext(ctx, "tcp", d.proxyURL.Host)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to proxy")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if user := d.proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not send CONNECT request to proxy")
+	}
+
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not read CONNECT response of proxy")
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused to connect to %s: %s", address, res.Status)
+	}
+
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// bufferedConn reads data the proxy sent right after CONNECT response before reading the connection,
+// e.g. greeting of the server which talks first.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read reads data from the connection.
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,295 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/firewall"
+	"github.com/mysteriumnetwork/node/services/httpproxy"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Options represents connection options.
+type Options struct {
+	// ListenAddress is the address local applications use as HTTP proxy.
+	ListenAddress string
+	DialTimeout   time.Duration
+}
+
+// NewConnection returns new HTTP proxy connection.
+// It serves local HTTP proxy which forwards requests of local applications to the provider's proxy.
+func NewConnection(opts Options) (connection.Connection, error) {
+	return &Connection{
+		done:    make(chan struct{}),
+		stateCh: make(chan connectionstate.State, 100),
+		opts:    opts,
+	}, nil
+}
+
+// Connection which proxies local HTTP traffic through the provider.
+type Connection struct {
+	// sent and received are accessed atomically, they are kept first to be 64-bit aligned.
+	sent     uint64
+	received uint64
+
+	stopOnce sync.Once
+	done     chan struct{}
+	stateCh  chan connectionstate.State
+
+	opts                Options
+	config              httpproxy.ServiceConfig
+	server              *http.Server
+	transport           *http.Transport
+	removeAllowedIPRule func()
+}
+
+var _ connection.Connection = &Connection{}
+
+// State returns connection state channel.
+func (c *Connection) State() <-chan connectionstate.State {
+	return c.stateCh
+}
+
+// Statistics returns traffic proxied through the provider.
+func (c *Connection) Statistics() (connectionstate.Statistics, error) {
+	return connectionstate.Statistics{
+		At:            time.Now(),
+		BytesSent:     atomic.LoadUint64(&c.sent),
+		BytesReceived: atomic.LoadUint64(&c.received),
+	}, nil
+}
+
+// Start starts local HTTP proxy once provider's proxy is reachable.
+func (c *Connection) Start(ctx context.Context, options connection.ConnectOptions) (err error) {
+	if err := json.Unmarshal(options.SessionConfig, &c.config); err != nil {
+		return errors.Wrap(err, "failed to unmarshal connection config")
+	}
+	// Proxy does not use NAT traversed connection, provider's proxy port is reached directly.
+	if options.ProviderNATConn != nil {
+		options.ProviderNATConn.Close()
+	}
+
+	providerIP, _, err := net.SplitHostPort(c.config.Endpoint)
+	if err != nil {
+		return errors.Wrap(err, "invalid provider endpoint")
+	}
+	removeAllowedIPRule, err := firewall.AllowIPAccess(providerIP)
+	if err != nil {
+		return errors.Wrap(err, "failed to add firewall exception for HTTP proxy remote IP")
+	}
+	c.removeAllowedIPRule = removeAllowedIPRule
+
+	defer func() {
+		if err != nil {
+			c.Stop()
+		}
+	}()
+
+	c.stateCh <- connectionstate.Connecting
+
+	dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.config.Endpoint)
+	if err != nil {
+		return errors.Wrap(err, "provider's HTTP proxy is not reachable")
+	}
+	conn.Close()
+
+	listener, err := net.Listen("tcp", c.opts.ListenAddress)
+	if err != nil {
+		return errors.Wrap(err, "could not listen for local proxy connections")
+	}
+	c.transport = &http.Transport{DialContext: c.dialThroughProvider}
+	c.server = &http.Server{Handler: http.HandlerFunc(c.serveHTTP)}
+	go func() {
+		if err := c.server.Serve(listener); err != http.ErrServerClosed {
+			log.Error().Err(err).Msg("Local HTTP proxy failed")
+		}
+	}()
+
+	log.Info().Msgf("HTTP proxy is available at %s", listener.Addr())
+	c.stateCh <- connectionstate.Connected
+	return nil
+}
+
+func (c *Connection) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		c.serveConnect(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "only proxy requests are supported", http.StatusBadRequest)
+		return
+	}
+
+	outreq := r.WithContext(r.Context())
+	outreq.RequestURI = ""
+	outreq.Header.Del("Proxy-Connection")
+	outreq.Header.Del("Proxy-Authorization")
+	resp, err := c.transport.RoundTrip(outreq)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+func (c *Connection) serveConnect(w http.ResponseWriter, r *http.Request) {
+	target, err := c.dialThroughProvider(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		target.Close()
+		http.Error(w, "connection can not be hijacked", http.StatusInternalServerError)
+		return
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		target.Close()
+		log.Warn().Err(err).Msg("Could not hijack local proxy connection")
+		return
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		target.Close()
+		conn.Close()
+		return
+	}
+
+	go func() {
+		io.Copy(target, buf.Reader)
+		target.Close()
+	}()
+	io.Copy(conn, target)
+	conn.Close()
+}
+
+// dialThroughProvider opens a tunnel to the address through provider's proxy, authenticated as the session.
+func (c *Connection) dialThroughProvider(ctx context.Context, _, addr string) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.config.Endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to provider's HTTP proxy")
+	}
+
+	credentials := base64.StdEncoding.EncodeToString([]byte(c.config.Username + ":" + c.config.Password))
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{"Proxy-Authorization": []string{"Basic " + credentials}},
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not send request to provider's HTTP proxy")
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not read response of provider's HTTP proxy")
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, errors.Errorf("provider's HTTP proxy refused to connect to %s: %s", addr, resp.Status)
+	}
+
+	return &countingConn{Conn: conn, reader: reader, sent: &c.sent, received: &c.received}, nil
+}
+
+// Wait blocks until connection is stopped.
+func (c *Connection) Wait() error {
+	<-c.done
+	return nil
+}
+
+// GetConfig returns the consumer configuration for session creation, HTTP proxy does not need any.
+func (c *Connection) GetConfig() (connection.ConsumerConfig, error) {
+	return nil, nil
+}
+
+// Stop stops local HTTP proxy.
+func (c *Connection) Stop() {
+	c.stopOnce.Do(func() {
+		log.Info().Msg("Stopping HTTP proxy connection")
+		c.stateCh <- connectionstate.Disconnecting
+
+		if c.server != nil {
+			if err := c.server.Close(); err != nil {
+				log.Error().Err(err).Msg("Failed to close local HTTP proxy")
+			}
+		}
+		if c.transport != nil {
+			c.transport.CloseIdleConnections()
+		}
+		if c.removeAllowedIPRule != nil {
+			c.removeAllowedIPRule()
+		}
+
+		c.stateCh <- connectionstate.NotConnected
+
+		close(c.stateCh)
+		close(c.done)
+	})
+}
+
+// countingConn counts traffic of the tunnel established through provider's proxy.
+type countingConn struct {
+	net.Conn
+	reader   io.Reader
+	sent     *uint64
+	received *uint64
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.reader.Read(b)
+	atomic.AddUint64(c.received, uint64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddUint64(c.sent, uint64(n))
+	return n, err
+}
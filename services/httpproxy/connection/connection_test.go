@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/services/httpproxy"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProviderProxy tunnels CONNECT requests authenticated with the given credentials.
+func fakeProviderProxy(username, password string) *httptest.Server {
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect || r.Header.Get("Proxy-Authorization") != expected {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, buf, _ := w.(http.Hijacker).Hijack()
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go io.Copy(target, buf.Reader)
+		io.Copy(conn, target)
+		conn.Close()
+		target.Close()
+	}))
+}
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func startConnection(t *testing.T, config httpproxy.ServiceConfig) (*Connection, string) {
+	address := freeAddress(t)
+	conn, err := NewConnection(Options{ListenAddress: address, DialTimeout: time.Second})
+	assert.NoError(t, err)
+
+	sessionConfig, err := json.Marshal(config)
+	assert.NoError(t, err)
+	err = conn.Start(context.Background(), connection.ConnectOptions{SessionConfig: sessionConfig})
+	assert.NoError(t, err)
+	return conn.(*Connection), address
+}
+
+func Test_Connection_ProxiesRequestsThroughProvider(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	defer target.Close()
+	provider := fakeProviderProxy("session-1", "password")
+	defer provider.Close()
+
+	conn, address := startConnection(t, httpproxy.ServiceConfig{
+		Endpoint: provider.Listener.Addr().String(),
+		Username: "session-1",
+		Password: "password",
+	})
+	defer conn.Stop()
+	assert.Equal(t, connectionstate.Connecting, <-conn.State())
+	assert.Equal(t, connectionstate.Connected, <-conn.State())
+
+	proxyURL, _ := url.Parse("http://" + address)
+	client := http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	resp, err := client.Get(target.URL)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	stats, err := conn.Statistics()
+	assert.NoError(t, err)
+	assert.NotZero(t, stats.BytesSent)
+	assert.NotZero(t, stats.BytesReceived)
+}
+
+func Test_Connection_TunnelsConnectRequestsThroughProvider(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer target.Close()
+	go func() {
+		conn, err := target.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("pong"))
+		conn.Close()
+	}()
+	provider := fakeProviderProxy("session-1", "password")
+	defer provider.Close()
+
+	conn, address := startConnection(t, httpproxy.ServiceConfig{
+		Endpoint: provider.Listener.Addr().String(),
+		Username: "session-1",
+		Password: "password",
+	})
+	defer conn.Stop()
+
+	client, err := net.Dial("tcp", address)
+	assert.NoError(t, err)
+	defer client.Close()
+	fmt.Fprintf(client, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", target.Addr(), target.Addr())
+
+	reply, err := ioutil.ReadAll(client)
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 200 Connection established\r\n\r\npong", string(reply))
+}
+
+func Test_Connection_ReportsRefusedTunnels(t *testing.T) {
+	provider := fakeProviderProxy("session-1", "password")
+	defer provider.Close()
+
+	conn, address := startConnection(t, httpproxy.ServiceConfig{
+		Endpoint: provider.Listener.Addr().String(),
+		Username: "session-1",
+		Password: "wrong",
+	})
+	defer conn.Stop()
+
+	proxyURL, _ := url.Parse("http://" + address)
+	client := http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	resp, err := client.Get("http://example.com")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+}
+
+func Test_Connection_StartFailsWhenProviderIsUnreachable(t *testing.T) {
+	conn, err := NewConnection(Options{ListenAddress: freeAddress(t), DialTimeout: time.Second})
+	assert.NoError(t, err)
+
+	sessionConfig, _ := json.Marshal(httpproxy.ServiceConfig{Endpoint: freeAddress(t)})
+	err = conn.Start(context.Background(), connection.ConnectOptions{SessionConfig: sessionConfig})
+	assert.Error(t, err)
+	assert.NoError(t, conn.Wait())
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/services/httpproxy"
+	"github.com/mysteriumnetwork/node/session/event"
+	"github.com/mysteriumnetwork/node/utils/netutil"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// errDestinationForbidden is returned for destinations service policies do not allow.
+var errDestinationForbidden = errors.New("destination is not allowed")
+
+// ServeHTTP tunnels CONNECT requests of authenticated sessions to the requested destination.
+func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		http.Error(w, "only CONNECT method is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := m.authenticate(r)
+	if !ok {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="mysterium"`)
+		http.Error(w, "proxy authentication required", http.StatusProxyAuthRequired)
+		return
+	}
+
+	addr, err := m.resolveDestination(r.Context(), r.Host)
+	if err == errDestinationForbidden || err == netutil.ErrProtectedDestination {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	target, err := net.DialTimeout("tcp", addr, m.dialTimeout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		target.Close()
+		http.Error(w, "connection can not be hijacked", http.StatusInternalServerError)
+		return
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		target.Close()
+		log.Warn().Err(err).Msg("Could not hijack proxy connection")
+		return
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		target.Close()
+		conn.Close()
+		return
+	}
+
+	session.tunnel(conn, buf.Reader, target)
+}
+
+// authenticate finds the session CONNECT request was made for, password is derived from the session ID.
+func (m *Manager) authenticate(r *http.Request) (*proxySession, bool) {
+	username, password, ok := parseProxyAuthorization(r.Header.Get("Proxy-Authorization"))
+	if !ok {
+		return nil, false
+	}
+
+	secret := m.getSecret()
+	if secret == nil {
+		return nil, false
+	}
+	expected := httpproxy.SessionPassword(secret, username)
+	if subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+		return nil, false
+	}
+	return m.findSession(username)
+}
+
+// resolveDestination checks if the destination is allowed by service policies and resolves it to unprotected address.
+func (m *Manager) resolveDestination(ctx context.Context, hostport string) (string, error) {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return "", err
+	}
+
+	m.startStopMu.Lock()
+	instance := m.serviceInstance
+	m.startStopMu.Unlock()
+	if instance != nil && !instance.Policies().IsHostAllowed(host) {
+		return "", errDestinationForbidden
+	}
+
+	return m.protected.Resolve(ctx, hostport)
+}
+
+func parseProxyAuthorization(header string) (username, password string, ok bool) {
+	const prefix = "Basic "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(header[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	credentials := string(decoded)
+	separator := strings.IndexByte(credentials, ':')
+	if separator < 0 {
+		return "", "", false
+	}
+	return credentials[:separator], credentials[separator+1:], true
+}
+
+// proxySession counts traffic of session tunnels, which are closed together with the session.
+type proxySession struct {
+	// sent and received are accessed atomically, they are kept first to be 64-bit aligned.
+	sent     uint64
+	received uint64
+
+	id   string
+	bus  eventbus.Publisher
+	done chan struct{}
+
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+	closed  bool
+}
+
+func newProxySession(id string, bus eventbus.Publisher) *proxySession {
+	return &proxySession{
+		id:    id,
+		bus:   bus,
+		done:  make(chan struct{}),
+		conns: make(map[net.Conn]struct{}),
+	}
+}
+
+// tunnel copies data between consumer and destination until either side closes the connection.
+func (s *proxySession) tunnel(conn net.Conn, consumer io.Reader, target net.Conn) {
+	if !s.track(conn, target) {
+		conn.Close()
+		target.Close()
+		return
+	}
+	defer s.untrack(conn, target)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		copyCounting(target, consumer, &s.received)
+		target.Close()
+	}()
+	copyCounting(conn, target, &s.sent)
+	conn.Close()
+	wg.Wait()
+}
+
+func (s *proxySession) track(conns ...net.Conn) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	for _, conn := range conns {
+		s.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (s *proxySession) untrack(conns ...net.Conn) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	for _, conn := range conns {
+		delete(s.conns, conn)
+	}
+}
+
+// publishStats publishes session traffic for payments until the session is closed.
+func (s *proxySession) publishStats(frequency time.Duration) {
+	for {
+		select {
+		case <-time.After(frequency):
+			s.publish()
+		case <-s.done:
+			s.publish()
+			log.Info().Msgf("Stopped publishing statistics for session %s", s.id)
+			return
+		}
+	}
+}
+
+func (s *proxySession) publish() {
+	s.bus.Publish(event.AppTopicDataTransferred, event.AppEventDataTransferred{
+		ID:   s.id,
+		Up:   atomic.LoadUint64(&s.sent),
+		Down: atomic.LoadUint64(&s.received),
+	})
+}
+
+func (s *proxySession) close() {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	close(s.done)
+}
+
+func copyCounting(dst io.Writer, src io.Reader, counter *uint64) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return
+			}
+			atomic.AddUint64(counter, uint64(n))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
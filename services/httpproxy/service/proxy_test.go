@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/mocks"
+	"github.com/mysteriumnetwork/node/services/httpproxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestManager() *Manager {
+	m := NewManager(ip.NewResolverMock("1.2.3.4"), mocks.NewEventBus(), Options{Port: 4480}, []string{"10.0.0.0/8", "invalid"})
+	m.secret = []byte("secret")
+	return m
+}
+
+func connectRequest(host, username, password string) *http.Request {
+	req := httptest.NewRequest(http.MethodConnect, "http://"+host, nil)
+	req.Host = host
+	if username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	return req
+}
+
+func Test_Manager_ProvideConfig(t *testing.T) {
+	m := newTestManager()
+
+	params, err := m.ProvideConfig("session-1", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, httpproxy.ServiceConfig{
+		Endpoint: "1.2.3.4:4480",
+		Username: "session-1",
+		Password: httpproxy.SessionPassword([]byte("secret"), "session-1"),
+	}, params.SessionServiceConfig)
+
+	_, ok := m.findSession("session-1")
+	assert.True(t, ok)
+
+	params.SessionDestroyCallback()
+	_, ok = m.findSession("session-1")
+	assert.False(t, ok)
+}
+
+func Test_Manager_ServeHTTP_RejectsNonConnectRequests(t *testing.T) {
+	m := newTestManager()
+
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+}
+
+func Test_Manager_ServeHTTP_RequiresSessionCredentials(t *testing.T) {
+	m := newTestManager()
+	params, err := m.ProvideConfig("session-1", nil, nil)
+	assert.NoError(t, err)
+	config := params.SessionServiceConfig.(httpproxy.ServiceConfig)
+
+	for name, req := range map[string]*http.Request{
+		"no credentials":    connectRequest("example.com:443", "", ""),
+		"wrong password":    connectRequest("example.com:443", config.Username, "wrong"),
+		"unknown session":   connectRequest("example.com:443", "session-2", httpproxy.SessionPassword([]byte("secret"), "session-2")),
+		"malformed session": connectRequest("example.com:443", "session-1:", config.Password),
+	} {
+		resp := httptest.NewRecorder()
+		m.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusProxyAuthRequired, resp.Code, name)
+	}
+
+	params.SessionDestroyCallback()
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, connectRequest("example.com:443", config.Username, config.Password))
+	assert.Equal(t, http.StatusProxyAuthRequired, resp.Code, "destroyed session")
+}
+
+func Test_Manager_ServeHTTP_ForbidsProtectedDestinations(t *testing.T) {
+	m := newTestManager()
+	params, err := m.ProvideConfig("session-1", nil, nil)
+	assert.NoError(t, err)
+	config := params.SessionServiceConfig.(httpproxy.ServiceConfig)
+
+	for _, host := range []string{"127.0.0.1:80", "10.1.2.3:443", "169.254.169.254:80", "[::1]:80"} {
+		resp := httptest.NewRecorder()
+		m.ServeHTTP(resp, connectRequest(host, config.Username, config.Password))
+		assert.Equal(t, http.StatusForbidden, resp.Code, host)
+	}
+}
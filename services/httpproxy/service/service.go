@@ -0,0 +1,203 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/core/location/locationstate"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/services/httpproxy"
+	"github.com/mysteriumnetwork/node/utils/netutil"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// GetProposal returns the proposal for HTTP proxy service.
+func GetProposal(location locationstate.Location) market.ServiceProposal {
+	return market.ServiceProposal{
+		ServiceType: httpproxy.ServiceType,
+		ServiceDefinition: httpproxy.ServiceDefinition{
+			Location: market.Location{
+				Continent: location.Continent,
+				Country:   location.Country,
+				City:      location.City,
+
+				ASN:      location.ASN,
+				ISP:      location.ISP,
+				NodeType: location.NodeType,
+			},
+		},
+	}
+}
+
+// NewManager creates new instance of HTTP proxy service.
+// Consumers are not allowed to reach protected networks through the proxy.
+func NewManager(ipResolver ip.Resolver, eventBus eventbus.Publisher, options Options, protectedNetworks []string) *Manager {
+	return &Manager{
+		ipResolver:  ipResolver,
+		eventBus:    eventBus,
+		options:     options,
+		protected:   netutil.ParseProtectedNetworks(protectedNetworks),
+		dialTimeout: 10 * time.Second,
+		sessions:    make(map[string]*proxySession),
+	}
+}
+
+// Manager represents an instance of HTTP proxy service
+type Manager struct {
+	ipResolver  ip.Resolver
+	eventBus    eventbus.Publisher
+	options     Options
+	protected   netutil.ProtectedNetworks
+	dialTimeout time.Duration
+
+	startStopMu     sync.Mutex
+	stopped         bool
+	server          *http.Server
+	serviceInstance *service.Instance
+	// secret derives session passwords, it is regenerated every time service starts.
+	secret []byte
+
+	sessionsMu sync.Mutex
+	sessions   map[string]*proxySession
+}
+
+// ProvideConfig provides proxy endpoint and credentials of the session to the consumer.
+func (m *Manager) ProvideConfig(sessionID string, _ json.RawMessage, remoteConn *net.UDPConn) (*service.ConfigParams, error) {
+	// Proxy does not use NAT traversed connection, consumers connect to the proxy port directly.
+	if remoteConn != nil {
+		remoteConn.Close()
+	}
+
+	secret := m.getSecret()
+	if secret == nil {
+		return nil, errors.New("HTTP proxy is not running")
+	}
+
+	publicIP, err := m.ipResolver.GetPublicIP()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get public IP")
+	}
+
+	session := newProxySession(sessionID, m.eventBus)
+	m.sessionsMu.Lock()
+	m.sessions[sessionID] = session
+	m.sessionsMu.Unlock()
+	go session.publishStats(time.Second)
+
+	destroy := func() {
+		log.Info().Msgf("Cleaning up session %s", sessionID)
+		m.sessionsMu.Lock()
+		delete(m.sessions, sessionID)
+		m.sessionsMu.Unlock()
+
+		session.close()
+	}
+
+	config := httpproxy.ServiceConfig{
+		Endpoint: net.JoinHostPort(publicIP, strconv.Itoa(m.options.Port)),
+		Username: sessionID,
+		Password: httpproxy.SessionPassword(secret, sessionID),
+	}
+	return &service.ConfigParams{SessionServiceConfig: config, SessionDestroyCallback: destroy}, nil
+}
+
+// Serve starts service - does block
+func (m *Manager) Serve(instance *service.Instance) error {
+	log.Info().Msg("HTTP proxy: starting")
+	m.startStopMu.Lock()
+	if m.stopped {
+		m.startStopMu.Unlock()
+		return nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		m.startStopMu.Unlock()
+		return errors.Wrap(err, "could not generate session secret")
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", m.options.Port))
+	if err != nil {
+		m.startStopMu.Unlock()
+		return errors.Wrap(err, "could not listen for proxy connections")
+	}
+
+	m.serviceInstance = instance
+	m.secret = secret
+	m.server = &http.Server{Handler: m}
+	server := m.server
+	m.startStopMu.Unlock()
+
+	log.Info().Msgf("HTTP proxy: started on port %d", m.options.Port)
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return errors.Wrap(err, "HTTP proxy failed")
+	}
+	return nil
+}
+
+// Stop stops service.
+func (m *Manager) Stop() error {
+	log.Info().Msg("HTTP proxy: stopping")
+	m.startStopMu.Lock()
+	defer m.startStopMu.Unlock()
+
+	m.stopped = true
+	m.secret = nil
+
+	m.sessionsMu.Lock()
+	for sessionID, session := range m.sessions {
+		delete(m.sessions, sessionID)
+		session.close()
+	}
+	m.sessionsMu.Unlock()
+
+	if m.server != nil {
+		if err := m.server.Close(); err != nil {
+			return errors.Wrap(err, "could not stop HTTP proxy")
+		}
+	}
+	log.Info().Msg("HTTP proxy: stopped")
+	return nil
+}
+
+func (m *Manager) getSecret() []byte {
+	m.startStopMu.Lock()
+	defer m.startStopMu.Unlock()
+
+	return m.secret
+}
+
+func (m *Manager) findSession(sessionID string) (*proxySession, bool) {
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+
+	session, ok := m.sessions[sessionID]
+	return session, ok
+}
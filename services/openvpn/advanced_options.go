@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package openvpn
+
+import (
+	"github.com/mysteriumnetwork/go-openvpn/openvpn/config"
+	"github.com/pkg/errors"
+)
+
+// Defaults of advanced options, used when operator does not set them.
+const (
+	DefaultCipher        = "AES-256-GCM"
+	DefaultAuth          = "none"
+	DefaultTLSVersionMin = "1.2"
+)
+
+// Values advanced options are allowed to take.
+var (
+	allowedCiphers        = []string{"AES-256-GCM", "AES-128-GCM", "CHACHA20-POLY1305", "AES-256-CBC"}
+	allowedAuths          = []string{"none", "SHA256", "SHA384", "SHA512"}
+	allowedCompressions   = []string{"lz4-v2", "lzo"}
+	allowedTLSVersionsMin = []string{"1.2", "1.3"}
+	// aeadCiphers authenticate tunnel packets themselves, other ciphers require HMAC auth digest.
+	aeadCiphers = []string{"AES-256-GCM", "AES-128-GCM", "CHACHA20-POLY1305"}
+)
+
+// AdvancedOptions are the vetted OpenVPN options operators may tune, empty values keep defaults.
+// They are shared with consumers in the session config, since both ends have to agree on them.
+type AdvancedOptions struct {
+	Cipher string `json:"cipher,omitempty"`
+	Auth   string `json:"auth,omitempty"`
+	// Compression is disabled by default, since compressing encrypted traffic leaks information about it.
+	Compression   string `json:"compression,omitempty"`
+	TLSVersionMin string `json:"tls_version_min,omitempty"`
+}
+
+// Validate checks that options take allowed values and are consistent with each other.
+func (o AdvancedOptions) Validate() error {
+	if o.Cipher != "" && !contains(allowedCiphers, o.Cipher) {
+		return errors.Errorf("unsupported cipher %q, allowed: %v", o.Cipher, allowedCiphers)
+	}
+	if o.Auth != "" && !contains(allowedAuths, o.Auth) {
+		return errors.Errorf("unsupported auth digest %q, allowed: %v", o.Auth, allowedAuths)
+	}
+	if o.Compression != "" && !contains(allowedCompressions, o.Compression) {
+		return errors.Errorf("unsupported compression %q, allowed: %v", o.Compression, allowedCompressions)
+	}
+	if o.TLSVersionMin != "" && !contains(allowedTLSVersionsMin, o.TLSVersionMin) {
+		return errors.Errorf("unsupported minimum TLS version %q, allowed: %v", o.TLSVersionMin, allowedTLSVersionsMin)
+	}
+	if !contains(aeadCiphers, o.cipher()) && o.auth() == "none" {
+		return errors.Errorf("cipher %s requires auth digest", o.cipher())
+	}
+	return nil
+}
+
+// Apply sets the options to the configuration, defaults are set for empty values.
+func (o AdvancedOptions) Apply(c *config.GenericConfig) {
+	c.SetParam("cipher", o.cipher())
+	c.SetParam("auth", o.auth())
+	c.SetParam("tls-version-min", o.tlsVersionMin())
+	if o.Compression != "" {
+		c.SetParam("compress", o.Compression)
+	}
+}
+
+func (o AdvancedOptions) cipher() string {
+	if o.Cipher == "" {
+		return DefaultCipher
+	}
+	return o.Cipher
+}
+
+func (o AdvancedOptions) auth() string {
+	if o.Auth == "" {
+		return DefaultAuth
+	}
+	return o.Auth
+}
+
+func (o AdvancedOptions) tlsVersionMin() string {
+	if o.TLSVersionMin == "" {
+		return DefaultTLSVersionMin
+	}
+	return o.TLSVersionMin
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
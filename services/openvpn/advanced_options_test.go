@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package openvpn
+
+import (
+	"testing"
+
+	"github.com/mysteriumnetwork/go-openvpn/openvpn/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdvancedOptions_Validate(t *testing.T) {
+	for name, options := range map[string]AdvancedOptions{
+		"defaults":      {},
+		"aead cipher":   {Cipher: "CHACHA20-POLY1305", TLSVersionMin: "1.3"},
+		"cbc with auth": {Cipher: "AES-256-CBC", Auth: "SHA256"},
+		"compression":   {Compression: "lz4-v2"},
+	} {
+		assert.NoError(t, options.Validate(), name)
+	}
+
+	for name, options := range map[string]AdvancedOptions{
+		"unknown cipher":      {Cipher: "BF-CBC", Auth: "SHA256"},
+		"unknown auth":        {Auth: "MD5"},
+		"unknown compression": {Compression: "stub"},
+		"old tls":             {TLSVersionMin: "1.0"},
+		"cbc without auth":    {Cipher: "AES-256-CBC"},
+	} {
+		assert.Error(t, options.Validate(), name)
+	}
+}
+
+func TestAdvancedOptions_Apply(t *testing.T) {
+	defaults := config.NewConfig("", "")
+	AdvancedOptions{}.Apply(defaults)
+	args, err := defaults.ToArguments()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--cipher", "AES-256-GCM", "--auth", "none", "--tls-version-min", "1.2"}, args)
+
+	custom := config.NewConfig("", "")
+	AdvancedOptions{Cipher: "AES-256-CBC", Auth: "SHA512", Compression: "lzo", TLSVersionMin: "1.3"}.Apply(custom)
+	args, err = custom.ToArguments()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--cipher", "AES-256-CBC", "--auth", "SHA512", "--tls-version-min", "1.3", "--compress", "lzo"}, args)
+}
@@ -20,6 +20,7 @@ package openvpn
 import (
 	"context"
 	"encoding/json"
+	"net"
 	"sync"
 	"time"
 
@@ -117,6 +118,13 @@ func (c *Client) Start(ctx context.Context, options connection.ConnectOptions) e
 	if err != nil {
 		return errors.Wrap(err, "failed to unmarshal session config")
 	}
+	if options.ProviderNATConn != nil {
+		// Tunnel goes to the relay server which forwards it to the provider
+		// or to the provider's IPv6 address which is not in the session config.
+		if remoteIP := options.ProviderNATConn.RemoteAddr().(*net.UDPAddr).IP; options.Relayed || remoteIP.To4() == nil {
+			sessionConfig.RemoteIP = remoteIP.String()
+		}
+	}
 
 	c.removeAllowedIPRule, err = firewall.AllowIPAccess(sessionConfig.RemoteIP)
 	if err != nil {
@@ -181,6 +189,7 @@ type VPNConfig struct {
 	RemoteProtocol  string `json:"protocol"`
 	TLSPresharedKey string `json:"TLSPresharedKey"`
 	CACertificate   string `json:"CACertificate"`
+	AdvancedOptions
 }
 
 func newAuthMiddleware(sessionID session.ID, signer identity.Signer) management.Middleware {
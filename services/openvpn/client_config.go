@@ -59,18 +59,14 @@ func defaultClientConfig(runtimeDir string, scriptSearchPath string) *ClientConf
 	clientConfig := ClientConfig{GenericConfig: config.NewConfig(runtimeDir, scriptSearchPath), VpnConfig: nil}
 
 	clientConfig.SetDevice("tun")
-	clientConfig.SetParam("cipher", "AES-256-GCM")
 	clientConfig.SetParam("verb", "3")
 	clientConfig.SetParam("tls-cipher", "TLS-ECDHE-ECDSA-WITH-AES-256-GCM-SHA384")
 	clientConfig.SetKeepAlive(10, 60)
 	clientConfig.SetPingTimerRemote()
 	clientConfig.SetPersistKey()
 
-	clientConfig.SetParam("auth", "none")
-
 	clientConfig.SetParam("reneg-sec", "0")
 	clientConfig.SetParam("resolv-retry", "infinite")
-	clientConfig.SetParam("redirect-gateway", "def1", "bypass-dhcp")
 
 	return &clientConfig
 }
@@ -91,6 +87,9 @@ func NewClientConfigFromSession(vpnConfig VPNConfig, scriptDir string, runtimeDi
 	}
 
 	clientFileConfig := newClientConfig(runtimeDir, scriptDir)
+	if !options.Params.KeepDefaultRoute {
+		clientFileConfig.SetParam("redirect-gateway", "def1", "ipv6", "bypass-dhcp")
+	}
 	dnsIPs, err := options.Params.DNS.ResolveIPs(vpnConfig.DNSIPs)
 	if err != nil {
 		return nil, err
@@ -115,6 +114,7 @@ func NewClientConfigFromSession(vpnConfig VPNConfig, scriptDir string, runtimeDi
 	clientFileConfig.SetProtocol(vpnConfig.RemoteProtocol)
 	clientFileConfig.SetTLSCACertificate(vpnConfig.CACertificate)
 	clientFileConfig.SetTLSCrypt(vpnConfig.TLSPresharedKey)
+	vpnConfig.AdvancedOptions.Apply(clientFileConfig.GenericConfig)
 
 	return clientFileConfig, nil
 }
@@ -46,6 +46,7 @@ func NewDefaultValidator() *ConfigValidator {
 			validIPFormat,
 			validTLSPresharedKey,
 			validCACertificate,
+			validAdvancedOptions,
 		},
 	}
 }
@@ -77,6 +78,10 @@ func validPort(config VPNConfig) error {
 	return nil
 }
 
+func validAdvancedOptions(config VPNConfig) error {
+	return config.AdvancedOptions.Validate()
+}
+
 func validIPFormat(config VPNConfig) error {
 	parsed := net.ParseIP(config.RemoteIP)
 	if parsed == nil {
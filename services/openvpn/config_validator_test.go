@@ -100,3 +100,8 @@ func TestCACertificateIsValid(t *testing.T) {
 	vpnConfig := VPNConfig{CACertificate: caCertificate}
 	assert.NoError(t, validCACertificate(vpnConfig))
 }
+
+func TestAdvancedOptionsAreValidated(t *testing.T) {
+	vpnConfig := VPNConfig{AdvancedOptions: AdvancedOptions{Cipher: "AES-256-CBC", Auth: "none"}}
+	assert.Error(t, validAdvancedOptions(vpnConfig))
+}
@@ -25,11 +25,21 @@ import (
 	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 )
 
-// NewServiceProposalWithLocation creates service proposal description for openvpn service
+// defaultSessionBandwidth is advertised when provider does not advertise its session bandwidth limit.
+const defaultSessionBandwidth = dto.Bandwidth(10 * datasize.MiB)
+
+// NewServiceProposalWithLocation creates service proposal description for openvpn service,
+// advertising given session bandwidth unless it is zero.
 func NewServiceProposalWithLocation(
 	loc locationstate.Location,
 	protocol string,
+	sessionBandwidth datasize.BitSpeed,
 ) market.ServiceProposal {
+	bandwidth := defaultSessionBandwidth
+	if sessionBandwidth > 0 {
+		bandwidth = dto.Bandwidth(sessionBandwidth)
+	}
+
 	serviceLocation := market.Location{
 		Continent: loc.Continent,
 		Country:   loc.Country,
@@ -44,7 +54,7 @@ func NewServiceProposalWithLocation(
 		ServiceDefinition: dto.ServiceDefinition{
 			Location:          serviceLocation,
 			LocationOriginate: serviceLocation,
-			SessionBandwidth:  dto.Bandwidth(10 * datasize.MiB),
+			SessionBandwidth:  bandwidth,
 			Protocol:          protocol,
 		},
 	}
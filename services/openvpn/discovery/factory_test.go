@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/mysteriumnetwork/node/core/location/locationstate"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 	"github.com/stretchr/testify/assert"
@@ -39,7 +40,7 @@ var (
 )
 
 func Test_NewServiceProposalWithLocation(t *testing.T) {
-	proposal := NewServiceProposalWithLocation(locationLTTelia, protocol)
+	proposal := NewServiceProposalWithLocation(locationLTTelia, protocol, 0)
 
 	assert.Exactly(
 		t,
@@ -55,3 +56,9 @@ func Test_NewServiceProposalWithLocation(t *testing.T) {
 		proposal,
 	)
 }
+
+func Test_NewServiceProposalWithLocation_AdvertisesSessionBandwidth(t *testing.T) {
+	proposal := NewServiceProposalWithLocation(locationLTTelia, protocol, 10*datasize.BitSpeed(datasize.MiB))
+
+	assert.Equal(t, dto.Bandwidth(10*datasize.MiB), proposal.ServiceDefinition.(dto.ServiceDefinition).SessionBandwidth)
+}
@@ -81,6 +81,10 @@ type Manager struct {
 
 // Serve starts service - does block
 func (m *Manager) Serve(instance *service.Instance) (err error) {
+	if err := m.serviceOptions.Validate(); err != nil {
+		return fmt.Errorf("invalid OpenVPN options: %w", err)
+	}
+
 	m.vpnNetwork = net.IPNet{
 		IP:   net.ParseIP(m.serviceOptions.Subnet),
 		Mask: net.IPMask(net.ParseIP(m.serviceOptions.Netmask).To4()),
@@ -197,13 +201,17 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 		RemoteProtocol:  m.serviceOptions.Protocol,
 		TLSPresharedKey: m.tlsPrimitives.PresharedKey.ToPEMFormat(),
 		CACertificate:   m.tlsPrimitives.CertificateAuthority.ToPEMFormat(),
+		AdvancedOptions: m.serviceOptions.AdvancedOptions,
 	}
 	if m.dnsOK {
 		vpnConfig.DNSIPs = m.dnsIP.String()
 	}
 
-	if err := proxyOpenVPN(conn, m.vpnServerPort); err != nil {
-		return nil, fmt.Errorf("could not proxy connection to OpenVPN server: %w", err)
+	// Consumer connects to OpenVPN server directly when service traffic is not relayed together with p2p channel.
+	if conn != nil {
+		if err := proxyOpenVPN(conn, m.vpnServerPort); err != nil {
+			return nil, fmt.Errorf("could not proxy connection to OpenVPN server: %w", err)
+		}
 	}
 
 	destroy := func() {
@@ -230,12 +238,16 @@ func (m *Manager) startServer() error {
 		m.nodeOptions.BindAddress,
 		m.vpnServerPort,
 		m.serviceOptions.Protocol,
+		m.serviceOptions.AdvancedOptions,
 	)
+	if m.serviceOptions.SessionBandwidth > 0 {
+		vpnServerConfig.SetShaper(m.serviceOptions.SessionBandwidth)
+	}
 
 	openvpnFilterDeny := stringutil.Split(config.GetString(config.FlagFirewallProtectedNetworks), ',')
-	var openvpnFilterAllow []string
+	openvpnFilterAllow := stringutil.Split(config.GetString(config.FlagFirewallAllowedNetworks), ',')
 	if m.dnsOK {
-		openvpnFilterAllow = []string{m.dnsIP.String()}
+		openvpnFilterAllow = append(openvpnFilterAllow, m.dnsIP.String())
 	}
 
 	stateChannel := make(chan openvpn.State, 10)
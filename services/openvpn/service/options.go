@@ -22,6 +22,9 @@ import (
 
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/datasize"
+	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
@@ -31,6 +34,9 @@ type Options struct {
 	Port     int    `json:"port"`
 	Subnet   string `json:"subnet"`
 	Netmask  string `json:"netmask"`
+	// SessionBandwidth limits speed of each session, zero value means unlimited.
+	SessionBandwidth datasize.BitSpeed `json:"-"`
+	openvpn_service.AdvancedOptions
 }
 
 // GetOptions returns effective OpenVPN service options from application configuration.
@@ -40,9 +46,23 @@ func GetOptions() Options {
 		Port:     config.GetInt(config.FlagOpenvpnPort),
 		Subnet:   config.GetString(config.FlagOpenvpnSubnet),
 		Netmask:  config.GetString(config.FlagOpenvpnNetmask),
+		AdvancedOptions: openvpn_service.AdvancedOptions{
+			Cipher:        config.GetString(config.FlagOpenvpnCipher),
+			Auth:          config.GetString(config.FlagOpenvpnAuth),
+			Compression:   config.GetString(config.FlagOpenvpnCompression),
+			TLSVersionMin: config.GetString(config.FlagOpenvpnTLSVersionMin),
+		},
 	}
 }
 
+// Validate checks that options are the ones consumers accept.
+func (o Options) Validate() error {
+	if o.Port != 0 && (o.Port < 1024 || o.Port > 65535) {
+		return errors.Errorf("invalid port %d, should fall within 1024 .. 65535 range", o.Port)
+	}
+	return o.AdvancedOptions.Validate()
+}
+
 // ParseJSONOptions function fills in OpenVPN options from JSON request, falling back to configured options for
 // missing values
 func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
@@ -55,5 +75,8 @@ func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
 		log.Warn().Err(err).Msg("Failed to parse options from request, using effective options")
 		return &Options{}, err
 	}
+	if err := requestOptions.Validate(); err != nil {
+		return &Options{}, err
+	}
 	return requestOptions, nil
 }
@@ -73,3 +73,20 @@ func configureDefaults() {
 func emptyContext() *cli.Context {
 	return cli.NewContext(nil, flag.NewFlagSet("", flag.ContinueOnError), nil)
 }
+
+func Test_ParseJSONOptions_ValidatesAdvancedOptions(t *testing.T) {
+	configureDefaults()
+	request := json.RawMessage(`{"cipher": "AES-256-CBC", "auth": "SHA256", "tls_version_min": "1.3"}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "AES-256-CBC", options.(Options).Cipher)
+	assert.Equal(t, "SHA256", options.(Options).Auth)
+	assert.Equal(t, "1.3", options.(Options).TLSVersionMin)
+
+	for _, invalid := range []string{`{"cipher": "BF-CBC"}`, `{"cipher": "AES-256-CBC"}`, `{"compression": "stub"}`, `{"port": 443}`} {
+		request := json.RawMessage(invalid)
+		_, err := ParseJSONOptions(&request)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -18,8 +18,18 @@
 package service
 
 import (
+	"strconv"
+
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/config"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/tls"
+	"github.com/mysteriumnetwork/node/datasize"
+	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn"
+)
+
+// OpenVPN accepts shaper rates between 100B/s and 100MB/s.
+const (
+	minShaperBytes = 100
+	maxShaperBytes = 100000000
 )
 
 // ServerConfig defines openvpn in server mode configuration structure
@@ -49,6 +59,18 @@ func (c *ServerConfig) SetProtocol(protocol string) {
 	}
 }
 
+// SetShaper limits speed of tunnel data sent to each client, traffic received from clients is not limited.
+func (c *ServerConfig) SetShaper(limit datasize.BitSpeed) {
+	bytes := datasize.BitSize(limit).Bytes()
+	if bytes < minShaperBytes {
+		bytes = minShaperBytes
+	}
+	if bytes > maxShaperBytes {
+		bytes = maxShaperBytes
+	}
+	c.SetParam("shaper", strconv.FormatUint(bytes, 10))
+}
+
 // NewServerConfig creates server configuration structure from given basic parameters
 func NewServerConfig(
 	runtimeDir string,
@@ -58,6 +80,7 @@ func NewServerConfig(
 	bindAddress string,
 	port int,
 	protocol string,
+	advanced openvpn_service.AdvancedOptions,
 ) *ServerConfig {
 	serverConfig := ServerConfig{config.NewConfig(runtimeDir, scriptDir)}
 	serverConfig.SetServerMode(port, network, netmask)
@@ -70,9 +93,7 @@ func NewServerConfig(
 	)
 	serverConfig.SetTLSCrypt(secPrimitives.PresharedKey.ToPEMFormat())
 
-	serverConfig.SetParam("cipher", "AES-256-GCM")
 	serverConfig.SetParam("verb", "3")
-	serverConfig.SetParam("tls-version-min", "1.2")
 	serverConfig.SetFlag("management-client-pf")
 	serverConfig.SetFlag("management-client-auth")
 	serverConfig.SetParam("verify-client-cert", "none")
@@ -82,7 +103,7 @@ func NewServerConfig(
 	serverConfig.SetPingTimerRemote()
 	serverConfig.SetPersistKey()
 
-	serverConfig.SetParam("auth", "none")
+	advanced.Apply(serverConfig.GenericConfig)
 	serverConfig.SetParam("local", bindAddress)
 	return &serverConfig
 }
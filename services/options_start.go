@@ -24,8 +24,10 @@ import (
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/node/services/httpproxy"
 	"github.com/mysteriumnetwork/node/services/noop"
 	"github.com/mysteriumnetwork/node/services/openvpn"
+	"github.com/mysteriumnetwork/node/services/shadowsocks"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/urfave/cli/v2"
 )
@@ -50,6 +52,14 @@ func GetStartOptions(serviceType string) (opts StartOptions, err error) {
 		opts.PaymentPricePerGB = getPrice(config.FlagNoopPriceGB, config.FlagPaymentPricePerGB)
 		opts.PaymentPricePerMinute = getPrice(config.FlagNoopPriceMinute, config.FlagPaymentPricePerMinute)
 		opts.AccessPolicyList = getPolicies(config.FlagNoopAccessPolicies, config.FlagAccessPolicyList)
+	case httpproxy.ServiceType:
+		opts.PaymentPricePerGB = getPrice(config.FlagHTTPProxyPriceGB, config.FlagPaymentPricePerGB)
+		opts.PaymentPricePerMinute = getPrice(config.FlagHTTPProxyPriceMinute, config.FlagPaymentPricePerMinute)
+		opts.AccessPolicyList = getPolicies(config.FlagHTTPProxyAccessPolicies, config.FlagAccessPolicyList)
+	case shadowsocks.ServiceType:
+		opts.PaymentPricePerGB = getPrice(config.FlagShadowsocksPriceGB, config.FlagPaymentPricePerGB)
+		opts.PaymentPricePerMinute = getPrice(config.FlagShadowsocksPriceMinute, config.FlagPaymentPricePerMinute)
+		opts.AccessPolicyList = getPolicies(config.FlagShadowsocksAccessPolicies, config.FlagAccessPolicyList)
 	}
 	return opts, nil
 }
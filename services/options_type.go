@@ -21,9 +21,13 @@ import (
 	"encoding/json"
 
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/services/httpproxy"
+	httpproxy_service "github.com/mysteriumnetwork/node/services/httpproxy/service"
 	"github.com/mysteriumnetwork/node/services/noop"
 	"github.com/mysteriumnetwork/node/services/openvpn"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn/service"
+	"github.com/mysteriumnetwork/node/services/shadowsocks"
+	shadowsocks_service "github.com/mysteriumnetwork/node/services/shadowsocks/service"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
 	"github.com/pkg/errors"
@@ -32,9 +36,11 @@ import (
 var (
 	// JSONParsersByType parsers of service specific options from JSON request.
 	JSONParsersByType = map[string]ServiceOptionsParser{
-		noop.ServiceType:      noop.ParseJSONOptions,
-		openvpn.ServiceType:   openvpn_service.ParseJSONOptions,
-		wireguard.ServiceType: wireguard_service.ParseJSONOptions,
+		noop.ServiceType:        noop.ParseJSONOptions,
+		openvpn.ServiceType:     openvpn_service.ParseJSONOptions,
+		wireguard.ServiceType:   wireguard_service.ParseJSONOptions,
+		httpproxy.ServiceType:   httpproxy_service.ParseJSONOptions,
+		shadowsocks.ServiceType: shadowsocks_service.ParseJSONOptions,
 	}
 )
 
@@ -43,7 +49,7 @@ type ServiceOptionsParser func(*json.RawMessage) (service.Options, error)
 
 // Types returns all possible service types.
 func Types() []string {
-	return []string{openvpn.ServiceType, wireguard.ServiceType, noop.ServiceType}
+	return []string{openvpn.ServiceType, wireguard.ServiceType, noop.ServiceType, httpproxy.ServiceType, shadowsocks.ServiceType}
 }
 
 // TypeConfiguredOptions returns specific service options.
@@ -55,6 +61,10 @@ func TypeConfiguredOptions(serviceType string) (service.Options, error) {
 		return wireguard_service.GetOptions(), nil
 	case noop.ServiceType:
 		return noop.GetOptions(), nil
+	case httpproxy.ServiceType:
+		return httpproxy_service.GetOptions(), nil
+	case shadowsocks.ServiceType:
+		return shadowsocks_service.GetOptions(), nil
 	default:
 		return nil, errors.Errorf("unknown service type: %q", serviceType)
 	}
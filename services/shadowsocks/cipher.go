@@ -0,0 +1,242 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package shadowsocks
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"io"
+	"net"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Ciphers supported by the service, they are AEAD ciphers of shadowsocks protocol.
+const (
+	CipherChacha20Poly1305 = "chacha20-ietf-poly1305"
+	CipherAES256GCM        = "aes-256-gcm"
+	CipherAES128GCM        = "aes-128-gcm"
+)
+
+// maxPayloadSize is the maximum size of a single encrypted chunk payload.
+const maxPayloadSize = 0x3FFF
+
+// ErrUnsupportedCipher indicates that cipher is not one of supported AEAD ciphers.
+var ErrUnsupportedCipher = errors.New("unsupported cipher")
+
+// Cipher encrypts connections with AEAD cipher keyed by the password, compatible with shadowsocks clients.
+type Cipher struct {
+	key     []byte
+	newAEAD func(key []byte) (cipher.AEAD, error)
+}
+
+// NewCipher creates cipher of the given type deriving its key from the password.
+func NewCipher(name, password string) (*Cipher, error) {
+	switch name {
+	case CipherChacha20Poly1305:
+		return &Cipher{key: deriveKey(password, chacha20poly1305.KeySize), newAEAD: chacha20poly1305.New}, nil
+	case CipherAES256GCM:
+		return &Cipher{key: deriveKey(password, 32), newAEAD: newGCM}, nil
+	case CipherAES128GCM:
+		return &Cipher{key: deriveKey(password, 16), newAEAD: newGCM}, nil
+	default:
+		return nil, ErrUnsupportedCipher
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey derives master key from the password the same way as OpenSSL EVP_BytesToKey with MD5 does.
+func deriveKey(password string, size int) []byte {
+	var key, prev []byte
+	h := md5.New()
+	for len(key) < size {
+		h.Write(prev)
+		h.Write([]byte(password))
+		key = h.Sum(key)
+		prev = key[len(key)-h.Size():]
+		h.Reset()
+	}
+	return key[:size]
+}
+
+// aead returns cipher of a single connection direction, keyed by subkey derived from its salt.
+func (c *Cipher) aead(salt []byte) (cipher.AEAD, error) {
+	subkey := make([]byte, len(c.key))
+	if _, err := io.ReadFull(hkdf.New(sha1.New, c.key, salt, []byte("ss-subkey")), subkey); err != nil {
+		return nil, err
+	}
+	return c.newAEAD(subkey)
+}
+
+// HeaderSize returns size of the salt and the first encrypted length chunk, which are enough to check the key.
+func (c *Cipher) HeaderSize() int {
+	// Overhead of all supported ciphers is 16 bytes.
+	return len(c.key) + 2 + 16
+}
+
+// Matches checks if connection header is encrypted with the key of this cipher.
+func (c *Cipher) Matches(header []byte) bool {
+	if len(header) < c.HeaderSize() {
+		return false
+	}
+	aead, err := c.aead(header[:len(c.key)])
+	if err != nil {
+		return false
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = aead.Open(nil, nonce, header[len(c.key):c.HeaderSize()], nil)
+	return err == nil
+}
+
+// Conn wraps the connection, so that data written to it is encrypted and data read from it is decrypted.
+func (c *Cipher) Conn(conn net.Conn) net.Conn {
+	return &streamConn{Conn: conn, cipher: c, src: conn}
+}
+
+// ConnWithHeader wraps the connection which header was already read from it.
+func (c *Cipher) ConnWithHeader(conn net.Conn, header []byte) net.Conn {
+	return &streamConn{Conn: conn, cipher: c, src: io.MultiReader(bytes.NewReader(header), conn)}
+}
+
+// streamConn encrypts each direction of the connection as a stream of chunks, prefixed by a random salt.
+type streamConn struct {
+	net.Conn
+	cipher *Cipher
+	src    io.Reader
+
+	writer      cipher.AEAD
+	writeNonce  []byte
+	reader      cipher.AEAD
+	readNonce   []byte
+	readPending []byte
+}
+
+func (s *streamConn) Write(b []byte) (int, error) {
+	if s.writer == nil {
+		salt := make([]byte, len(s.cipher.key))
+		if _, err := rand.Read(salt); err != nil {
+			return 0, err
+		}
+		aead, err := s.cipher.aead(salt)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := s.Conn.Write(salt); err != nil {
+			return 0, err
+		}
+		s.writer = aead
+		s.writeNonce = make([]byte, aead.NonceSize())
+	}
+
+	var written int
+	for len(b) > 0 {
+		payload := b
+		if len(payload) > maxPayloadSize {
+			payload = payload[:maxPayloadSize]
+		}
+		overhead := s.writer.Overhead()
+		chunk := make([]byte, 0, 2+overhead+len(payload)+overhead)
+		length := make([]byte, 2)
+		binary.BigEndian.PutUint16(length, uint16(len(payload)))
+		chunk = s.seal(chunk, length)
+		chunk = s.seal(chunk, payload)
+		if _, err := s.Conn.Write(chunk); err != nil {
+			return written, err
+		}
+		written += len(payload)
+		b = b[len(payload):]
+	}
+	return written, nil
+}
+
+func (s *streamConn) seal(dst, plaintext []byte) []byte {
+	dst = s.writer.Seal(dst, s.writeNonce, plaintext, nil)
+	incrementNonce(s.writeNonce)
+	return dst
+}
+
+func (s *streamConn) Read(b []byte) (int, error) {
+	if len(s.readPending) == 0 {
+		if err := s.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, s.readPending)
+	s.readPending = s.readPending[n:]
+	return n, nil
+}
+
+func (s *streamConn) readChunk() error {
+	if s.reader == nil {
+		salt := make([]byte, len(s.cipher.key))
+		if _, err := io.ReadFull(s.src, salt); err != nil {
+			return err
+		}
+		aead, err := s.cipher.aead(salt)
+		if err != nil {
+			return err
+		}
+		s.reader = aead
+		s.readNonce = make([]byte, aead.NonceSize())
+	}
+
+	length, err := s.open(2)
+	if err != nil {
+		return err
+	}
+	size := int(binary.BigEndian.Uint16(length)) & maxPayloadSize
+	s.readPending, err = s.open(size)
+	return err
+}
+
+func (s *streamConn) open(size int) ([]byte, error) {
+	buf := make([]byte, size+s.reader.Overhead())
+	if _, err := io.ReadFull(s.src, buf); err != nil {
+		return nil, err
+	}
+	plaintext, err := s.reader.Open(buf[:0], s.readNonce, buf, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decrypt chunk")
+	}
+	incrementNonce(s.readNonce)
+	return plaintext, nil
+}
+
+// incrementNonce increments nonce as a little endian number.
+func incrementNonce(nonce []byte) {
+	for i := range nonce {
+		nonce[i]++
+		if nonce[i] != 0 {
+			return
+		}
+	}
+}
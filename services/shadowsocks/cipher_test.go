@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package shadowsocks
+
+import (
+	"bytes"
+	"crypto/md5"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveKey(t *testing.T) {
+	first := md5.Sum([]byte("password"))
+	second := md5.Sum(append(first[:], "password"...))
+
+	assert.Equal(t, first[:], deriveKey("password", 16))
+	assert.Equal(t, append(first[:], second[:]...), deriveKey("password", 32))
+}
+
+func TestCipher_Conn(t *testing.T) {
+	for _, name := range []string{CipherChacha20Poly1305, CipherAES256GCM, CipherAES128GCM} {
+		t.Run(name, func(t *testing.T) {
+			c, err := NewCipher(name, "password")
+			assert.NoError(t, err)
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+
+			message := make([]byte, 3*maxPayloadSize)
+			for i := range message {
+				message[i] = byte(i)
+			}
+			go func() {
+				c.Conn(client).Write(message)
+			}()
+
+			header := make([]byte, c.HeaderSize())
+			_, err = io.ReadFull(server, header)
+			assert.NoError(t, err)
+			assert.True(t, c.Matches(header))
+			other, _ := NewCipher(name, "other")
+			assert.False(t, other.Matches(header))
+
+			received := make([]byte, len(message))
+			_, err = io.ReadFull(c.ConnWithHeader(server, header), received)
+			assert.NoError(t, err)
+			assert.Equal(t, message, received)
+		})
+	}
+}
+
+func TestNewCipher_Unsupported(t *testing.T) {
+	_, err := NewCipher("rc4-md5", "password")
+	assert.Equal(t, ErrUnsupportedCipher, err)
+}
+
+func TestAddress(t *testing.T) {
+	for _, address := range []string{"1.2.3.4:80", "[2001:db8::1]:443", "example.com:8080"} {
+		encoded, err := EncodeAddress(address)
+		assert.NoError(t, err)
+
+		decoded, err := ReadAddress(bytes.NewReader(encoded))
+		assert.NoError(t, err)
+		assert.Equal(t, address, decoded)
+	}
+}
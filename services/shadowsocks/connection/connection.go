@@ -0,0 +1,335 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/firewall"
+	"github.com/mysteriumnetwork/node/services/shadowsocks"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// SOCKS5 protocol constants used by the local proxy.
+const (
+	socksVersion          = 0x05
+	socksMethodNoAuth     = 0x00
+	socksMethodNoneUsable = 0xFF
+	socksCommandConnect   = 0x01
+	socksReplySucceeded   = 0x00
+	socksReplyFailure     = 0x01
+	socksReplyUnsupported = 0x07
+)
+
+// Options represents connection options.
+type Options struct {
+	// ListenAddress is the address local applications use as SOCKS5 proxy.
+	ListenAddress string
+	DialTimeout   time.Duration
+}
+
+// NewConnection returns new shadowsocks connection.
+// It serves local SOCKS5 proxy which tunnels connections of local applications through the provider.
+func NewConnection(opts Options) (connection.Connection, error) {
+	return &Connection{
+		done:    make(chan struct{}),
+		stateCh: make(chan connectionstate.State, 100),
+		opts:    opts,
+		conns:   make(map[net.Conn]struct{}),
+	}, nil
+}
+
+// Connection which tunnels local SOCKS5 traffic through the provider.
+type Connection struct {
+	// sent and received are accessed atomically, they are kept first to be 64-bit aligned.
+	sent     uint64
+	received uint64
+
+	stopOnce sync.Once
+	done     chan struct{}
+	stateCh  chan connectionstate.State
+
+	opts                Options
+	config              shadowsocks.ServiceConfig
+	cipher              *shadowsocks.Cipher
+	listener            net.Listener
+	removeAllowedIPRule func()
+
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+}
+
+var _ connection.Connection = &Connection{}
+
+// State returns connection state channel.
+func (c *Connection) State() <-chan connectionstate.State {
+	return c.stateCh
+}
+
+// Statistics returns traffic tunneled through the provider.
+func (c *Connection) Statistics() (connectionstate.Statistics, error) {
+	return connectionstate.Statistics{
+		At:            time.Now(),
+		BytesSent:     atomic.LoadUint64(&c.sent),
+		BytesReceived: atomic.LoadUint64(&c.received),
+	}, nil
+}
+
+// Start starts local SOCKS5 proxy once provider's shadowsocks service is reachable.
+func (c *Connection) Start(ctx context.Context, options connection.ConnectOptions) (err error) {
+	if err := json.Unmarshal(options.SessionConfig, &c.config); err != nil {
+		return errors.Wrap(err, "failed to unmarshal connection config")
+	}
+	// Shadowsocks does not use NAT traversed connection, provider's service port is reached directly.
+	if options.ProviderNATConn != nil {
+		options.ProviderNATConn.Close()
+	}
+
+	c.cipher, err = shadowsocks.NewCipher(c.config.Cipher, c.config.Password)
+	if err != nil {
+		return errors.Wrapf(err, "could not use cipher %q", c.config.Cipher)
+	}
+
+	providerIP, _, err := net.SplitHostPort(c.config.Endpoint)
+	if err != nil {
+		return errors.Wrap(err, "invalid provider endpoint")
+	}
+	removeAllowedIPRule, err := firewall.AllowIPAccess(providerIP)
+	if err != nil {
+		return errors.Wrap(err, "failed to add firewall exception for shadowsocks remote IP")
+	}
+	c.removeAllowedIPRule = removeAllowedIPRule
+
+	defer func() {
+		if err != nil {
+			c.Stop()
+		}
+	}()
+
+	c.stateCh <- connectionstate.Connecting
+
+	dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.config.Endpoint)
+	if err != nil {
+		return errors.Wrap(err, "provider's shadowsocks service is not reachable")
+	}
+	conn.Close()
+
+	c.listener, err = net.Listen("tcp", c.opts.ListenAddress)
+	if err != nil {
+		return errors.Wrap(err, "could not listen for local proxy connections")
+	}
+	go c.serve(c.listener)
+
+	log.Info().Msgf("SOCKS5 proxy is available at %s", c.listener.Addr())
+	c.stateCh <- connectionstate.Connected
+	return nil
+}
+
+func (c *Connection) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-c.done:
+			default:
+				log.Error().Err(err).Msg("Local SOCKS5 proxy failed")
+			}
+			return
+		}
+		go c.serveConn(conn)
+	}
+}
+
+func (c *Connection) serveConn(conn net.Conn) {
+	if !c.track(conn) {
+		conn.Close()
+		return
+	}
+	defer c.untrack(conn)
+	defer conn.Close()
+
+	addr, err := socksHandshake(conn)
+	if err != nil {
+		log.Debug().Err(err).Msg("SOCKS5 handshake failed")
+		return
+	}
+
+	target, err := c.dialThroughProvider(addr)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Could not connect to %s", addr)
+		conn.Write([]byte{socksVersion, socksReplyFailure, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	if !c.track(target) {
+		target.Close()
+		return
+	}
+	defer c.untrack(target)
+
+	if _, err := conn.Write([]byte{socksVersion, socksReplySucceeded, 0x00, 0x01, 0, 0, 0, 0, 0, 0}); err != nil {
+		target.Close()
+		return
+	}
+
+	go func() {
+		io.Copy(target, conn)
+		target.Close()
+	}()
+	io.Copy(conn, target)
+}
+
+// socksHandshake negotiates SOCKS5 session without authentication and returns the requested destination.
+func socksHandshake(conn net.Conn) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != socksVersion {
+		return "", errors.Errorf("unsupported SOCKS version %d", header[0])
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+	method := byte(socksMethodNoneUsable)
+	for _, m := range methods {
+		if m == socksMethodNoAuth {
+			method = socksMethodNoAuth
+		}
+	}
+	if _, err := conn.Write([]byte{socksVersion, method}); err != nil {
+		return "", err
+	}
+	if method == socksMethodNoneUsable {
+		return "", errors.New("client does not support SOCKS5 without authentication")
+	}
+
+	request := make([]byte, 3)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+	if request[1] != socksCommandConnect {
+		conn.Write([]byte{socksVersion, socksReplyUnsupported, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return "", errors.Errorf("unsupported SOCKS command %d", request[1])
+	}
+	return shadowsocks.ReadAddress(conn)
+}
+
+// dialThroughProvider opens encrypted tunnel to the address through provider's shadowsocks service.
+func (c *Connection) dialThroughProvider(addr string) (net.Conn, error) {
+	encodedAddr, err := shadowsocks.EncodeAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("tcp", c.config.Endpoint, c.opts.DialTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to provider's shadowsocks service")
+	}
+	stream := c.cipher.Conn(&countingConn{Conn: conn, sent: &c.sent, received: &c.received})
+	if _, err := stream.Write(encodedAddr); err != nil {
+		stream.Close()
+		return nil, errors.Wrap(err, "could not send destination to provider's shadowsocks service")
+	}
+	return stream, nil
+}
+
+func (c *Connection) track(conn net.Conn) bool {
+	c.connsMu.Lock()
+	defer c.connsMu.Unlock()
+
+	if c.conns == nil {
+		return false
+	}
+	c.conns[conn] = struct{}{}
+	return true
+}
+
+func (c *Connection) untrack(conn net.Conn) {
+	c.connsMu.Lock()
+	defer c.connsMu.Unlock()
+
+	delete(c.conns, conn)
+}
+
+// Wait blocks until connection is stopped.
+func (c *Connection) Wait() error {
+	<-c.done
+	return nil
+}
+
+// GetConfig returns the consumer configuration for session creation, shadowsocks does not need any.
+func (c *Connection) GetConfig() (connection.ConsumerConfig, error) {
+	return nil, nil
+}
+
+// Stop stops local SOCKS5 proxy and closes tunnels established through it.
+func (c *Connection) Stop() {
+	c.stopOnce.Do(func() {
+		log.Info().Msg("Stopping shadowsocks connection")
+		c.stateCh <- connectionstate.Disconnecting
+
+		close(c.done)
+		if c.listener != nil {
+			if err := c.listener.Close(); err != nil {
+				log.Error().Err(err).Msg("Failed to close local SOCKS5 proxy")
+			}
+		}
+		c.connsMu.Lock()
+		for conn := range c.conns {
+			conn.Close()
+		}
+		c.conns = nil
+		c.connsMu.Unlock()
+		if c.removeAllowedIPRule != nil {
+			c.removeAllowedIPRule()
+		}
+
+		c.stateCh <- connectionstate.NotConnected
+		close(c.stateCh)
+	})
+}
+
+// countingConn counts traffic exchanged with provider's shadowsocks service.
+type countingConn struct {
+	net.Conn
+	sent     *uint64
+	received *uint64
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddUint64(c.received, uint64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddUint64(c.sent, uint64(n))
+	return n, err
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/services/shadowsocks"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider tunnels connections encrypted with the password to the requested destinations.
+func fakeProvider(t *testing.T, password string) net.Listener {
+	cipher, err := shadowsocks.NewCipher(shadowsocks.CipherChacha20Poly1305, password)
+	assert.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				stream := cipher.Conn(conn)
+				addr, err := shadowsocks.ReadAddress(stream)
+				if err != nil {
+					return
+				}
+				target, err := net.Dial("tcp", addr)
+				if err != nil {
+					return
+				}
+				defer target.Close()
+				go io.Copy(target, stream)
+				io.Copy(stream, target)
+			}()
+		}
+	}()
+	return listener
+}
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func startConnection(t *testing.T, config shadowsocks.ServiceConfig) (*Connection, string) {
+	address := freeAddress(t)
+	conn, err := NewConnection(Options{ListenAddress: address, DialTimeout: time.Second})
+	assert.NoError(t, err)
+
+	sessionConfig, err := json.Marshal(config)
+	assert.NoError(t, err)
+	err = conn.Start(context.Background(), connection.ConnectOptions{SessionConfig: sessionConfig})
+	assert.NoError(t, err)
+	return conn.(*Connection), address
+}
+
+// socksConnect connects to the destination through SOCKS5 proxy and returns the reply code.
+func socksConnect(t *testing.T, proxy, destination string) (net.Conn, byte) {
+	client, err := net.Dial("tcp", proxy)
+	assert.NoError(t, err)
+
+	_, err = client.Write([]byte{socksVersion, 1, socksMethodNoAuth})
+	assert.NoError(t, err)
+	method := make([]byte, 2)
+	_, err = io.ReadFull(client, method)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{socksVersion, socksMethodNoAuth}, method)
+
+	addr, err := shadowsocks.EncodeAddress(destination)
+	assert.NoError(t, err)
+	_, err = client.Write(append([]byte{socksVersion, socksCommandConnect, 0x00}, addr...))
+	assert.NoError(t, err)
+	reply := make([]byte, 10)
+	_, err = io.ReadFull(client, reply)
+	assert.NoError(t, err)
+	return client, reply[1]
+}
+
+func Test_Connection_TunnelsThroughProvider(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer target.Close()
+	go func() {
+		conn, err := target.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("pong"))
+		conn.Close()
+	}()
+	provider := fakeProvider(t, "password")
+	defer provider.Close()
+
+	conn, address := startConnection(t, shadowsocks.ServiceConfig{
+		Endpoint: provider.Addr().String(),
+		Cipher:   shadowsocks.CipherChacha20Poly1305,
+		Password: "password",
+	})
+	defer conn.Stop()
+	assert.Equal(t, connectionstate.Connecting, <-conn.State())
+	assert.Equal(t, connectionstate.Connected, <-conn.State())
+
+	client, code := socksConnect(t, address, target.Addr().String())
+	defer client.Close()
+	assert.Equal(t, byte(socksReplySucceeded), code)
+
+	reply, err := ioutil.ReadAll(client)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", string(reply))
+
+	stats, err := conn.Statistics()
+	assert.NoError(t, err)
+	assert.NotZero(t, stats.BytesSent)
+	assert.NotZero(t, stats.BytesReceived)
+}
+
+func Test_Connection_RejectsUnsupportedCommands(t *testing.T) {
+	provider := fakeProvider(t, "password")
+	defer provider.Close()
+
+	conn, address := startConnection(t, shadowsocks.ServiceConfig{
+		Endpoint: provider.Addr().String(),
+		Cipher:   shadowsocks.CipherChacha20Poly1305,
+		Password: "password",
+	})
+	defer conn.Stop()
+
+	client, err := net.Dial("tcp", address)
+	assert.NoError(t, err)
+	defer client.Close()
+	client.Write([]byte{socksVersion, 1, socksMethodNoAuth})
+	io.ReadFull(client, make([]byte, 2))
+
+	// UDP associate is not supported.
+	client.Write([]byte{socksVersion, 0x03, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+	reply := make([]byte, 2)
+	_, err = io.ReadFull(client, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(socksReplyUnsupported), reply[1])
+}
+
+func Test_Connection_StartFailsWithUnsupportedCipher(t *testing.T) {
+	conn, err := NewConnection(Options{ListenAddress: freeAddress(t), DialTimeout: time.Second})
+	assert.NoError(t, err)
+
+	sessionConfig, _ := json.Marshal(shadowsocks.ServiceConfig{Endpoint: freeAddress(t), Cipher: "rc4-md5"})
+	err = conn.Start(context.Background(), connection.ConnectOptions{SessionConfig: sessionConfig})
+	assert.Equal(t, shadowsocks.ErrUnsupportedCipher, errors.Cause(err))
+}
+
+func Test_Connection_StartFailsWhenProviderIsUnreachable(t *testing.T) {
+	conn, err := NewConnection(Options{ListenAddress: freeAddress(t), DialTimeout: time.Second})
+	assert.NoError(t, err)
+
+	sessionConfig, _ := json.Marshal(shadowsocks.ServiceConfig{Endpoint: freeAddress(t), Cipher: shadowsocks.CipherChacha20Poly1305})
+	err = conn.Start(context.Background(), connection.ConnectOptions{SessionConfig: sessionConfig})
+	assert.Error(t, err)
+	assert.NoError(t, conn.Wait())
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/services/shadowsocks"
+)
+
+// Options describes options which are required to start shadowsocks service.
+type Options struct {
+	// Port is TCP port consumers connect to, it has to be reachable from the internet.
+	Port   int    `json:"port"`
+	Cipher string `json:"cipher"`
+}
+
+// DefaultOptions is a shadowsocks service configuration that will be used if no options provided.
+var DefaultOptions = Options{
+	Port:   8388,
+	Cipher: shadowsocks.CipherChacha20Poly1305,
+}
+
+// GetOptions returns effective shadowsocks service options from application configuration.
+func GetOptions() Options {
+	return Options{
+		Port:   config.GetInt(config.FlagShadowsocksPort),
+		Cipher: config.GetString(config.FlagShadowsocksCipher),
+	}
+}
+
+// ParseJSONOptions function fills in shadowsocks options from JSON request
+func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
+	var requestOptions = GetOptions()
+	if request == nil {
+		return requestOptions, nil
+	}
+
+	opts := DefaultOptions
+	err := json.Unmarshal(*request, &opts)
+	return opts, err
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/core/location/locationstate"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/services/shadowsocks"
+	"github.com/mysteriumnetwork/node/utils/netutil"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// GetProposal returns the proposal for shadowsocks service advertising the cipher it uses.
+func GetProposal(location locationstate.Location, cipher string) market.ServiceProposal {
+	return market.ServiceProposal{
+		ServiceType: shadowsocks.ServiceType,
+		ServiceDefinition: shadowsocks.ServiceDefinition{
+			Location: market.Location{
+				Continent: location.Continent,
+				Country:   location.Country,
+				City:      location.City,
+
+				ASN:      location.ASN,
+				ISP:      location.ISP,
+				NodeType: location.NodeType,
+			},
+			Cipher: cipher,
+		},
+	}
+}
+
+// NewManager creates new instance of shadowsocks service.
+// Consumers are not allowed to reach protected networks through the service.
+func NewManager(ipResolver ip.Resolver, eventBus eventbus.Publisher, options Options, protectedNetworks []string) *Manager {
+	return &Manager{
+		ipResolver:    ipResolver,
+		eventBus:      eventBus,
+		options:       options,
+		protected:     netutil.ParseProtectedNetworks(protectedNetworks),
+		dialTimeout:   10 * time.Second,
+		headerTimeout: 30 * time.Second,
+		sessions:      make(map[string]*tunnelSession),
+	}
+}
+
+// Manager represents an instance of shadowsocks service
+type Manager struct {
+	ipResolver    ip.Resolver
+	eventBus      eventbus.Publisher
+	options       Options
+	protected     netutil.ProtectedNetworks
+	dialTimeout   time.Duration
+	headerTimeout time.Duration
+
+	startStopMu     sync.Mutex
+	stopped         bool
+	listener        net.Listener
+	serviceInstance *service.Instance
+	// secret derives session passwords, it is regenerated every time service starts.
+	secret []byte
+
+	sessionsMu sync.Mutex
+	sessions   map[string]*tunnelSession
+}
+
+// ProvideConfig provides server endpoint, cipher and password of the session to the consumer.
+func (m *Manager) ProvideConfig(sessionID string, _ json.RawMessage, remoteConn *net.UDPConn) (*service.ConfigParams, error) {
+	// Shadowsocks does not use NAT traversed connection, consumers connect to the service port directly.
+	if remoteConn != nil {
+		remoteConn.Close()
+	}
+
+	m.startStopMu.Lock()
+	secret := m.secret
+	m.startStopMu.Unlock()
+	if secret == nil {
+		return nil, errors.New("shadowsocks service is not running")
+	}
+
+	password := shadowsocks.SessionPassword(secret, sessionID)
+	cipher, err := shadowsocks.NewCipher(m.options.Cipher, password)
+	if err != nil {
+		return nil, err
+	}
+
+	publicIP, err := m.ipResolver.GetPublicIP()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get public IP")
+	}
+
+	session := newTunnelSession(sessionID, cipher, m.eventBus)
+	m.sessionsMu.Lock()
+	m.sessions[sessionID] = session
+	m.sessionsMu.Unlock()
+	go session.publishStats(time.Second)
+
+	destroy := func() {
+		log.Info().Msgf("Cleaning up session %s", sessionID)
+		m.sessionsMu.Lock()
+		delete(m.sessions, sessionID)
+		m.sessionsMu.Unlock()
+
+		session.close()
+	}
+
+	config := shadowsocks.ServiceConfig{
+		Endpoint: net.JoinHostPort(publicIP, strconv.Itoa(m.options.Port)),
+		Cipher:   m.options.Cipher,
+		Password: password,
+	}
+	return &service.ConfigParams{SessionServiceConfig: config, SessionDestroyCallback: destroy}, nil
+}
+
+// Serve starts service - does block
+func (m *Manager) Serve(instance *service.Instance) error {
+	log.Info().Msg("Shadowsocks: starting")
+	// Validate cipher before accepting sessions.
+	if _, err := shadowsocks.NewCipher(m.options.Cipher, ""); err != nil {
+		return errors.Wrapf(err, "could not start shadowsocks with cipher %q", m.options.Cipher)
+	}
+
+	m.startStopMu.Lock()
+	if m.stopped {
+		m.startStopMu.Unlock()
+		return nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		m.startStopMu.Unlock()
+		return errors.Wrap(err, "could not generate session secret")
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", m.options.Port))
+	if err != nil {
+		m.startStopMu.Unlock()
+		return errors.Wrap(err, "could not listen for shadowsocks connections")
+	}
+
+	m.serviceInstance = instance
+	m.secret = secret
+	m.listener = listener
+	m.startStopMu.Unlock()
+
+	log.Info().Msgf("Shadowsocks: started on port %d", m.options.Port)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if m.isStopped() {
+				return nil
+			}
+			return errors.Wrap(err, "shadowsocks failed")
+		}
+		go m.handleConn(conn)
+	}
+}
+
+// Stop stops service.
+func (m *Manager) Stop() error {
+	log.Info().Msg("Shadowsocks: stopping")
+	m.startStopMu.Lock()
+	defer m.startStopMu.Unlock()
+
+	m.stopped = true
+	m.secret = nil
+
+	m.sessionsMu.Lock()
+	for sessionID, session := range m.sessions {
+		delete(m.sessions, sessionID)
+		session.close()
+	}
+	m.sessionsMu.Unlock()
+
+	if m.listener != nil {
+		if err := m.listener.Close(); err != nil {
+			return errors.Wrap(err, "could not stop shadowsocks")
+		}
+	}
+	log.Info().Msg("Shadowsocks: stopped")
+	return nil
+}
+
+func (m *Manager) isStopped() bool {
+	m.startStopMu.Lock()
+	defer m.startStopMu.Unlock()
+
+	return m.stopped
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/mocks"
+	"github.com/mysteriumnetwork/node/services/shadowsocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestManager() *Manager {
+	options := Options{Port: 8388, Cipher: shadowsocks.CipherChacha20Poly1305}
+	m := NewManager(ip.NewResolverMock("1.2.3.4"), mocks.NewEventBus(), options, []string{"10.0.0.0/8"})
+	m.secret = []byte("secret")
+	m.headerTimeout = 100 * time.Millisecond
+	return m
+}
+
+func Test_Manager_ProvideConfig(t *testing.T) {
+	m := newTestManager()
+
+	params, err := m.ProvideConfig("session-1", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, shadowsocks.ServiceConfig{
+		Endpoint: "1.2.3.4:8388",
+		Cipher:   shadowsocks.CipherChacha20Poly1305,
+		Password: shadowsocks.SessionPassword([]byte("secret"), "session-1"),
+	}, params.SessionServiceConfig)
+	assert.Len(t, m.sessions, 1)
+
+	params.SessionDestroyCallback()
+	assert.Len(t, m.sessions, 0)
+}
+
+func Test_Manager_ProvideConfig_RequiresRunningService(t *testing.T) {
+	m := newTestManager()
+	m.secret = nil
+
+	_, err := m.ProvideConfig("session-1", nil, nil)
+	assert.Error(t, err)
+}
+
+func Test_Manager_MatchesSessionByHeader(t *testing.T) {
+	m := newTestManager()
+	for _, sessionID := range []string{"session-1", "session-2"} {
+		_, err := m.ProvideConfig(sessionID, nil, nil)
+		assert.NoError(t, err)
+	}
+
+	cipher, err := shadowsocks.NewCipher(shadowsocks.CipherChacha20Poly1305, shadowsocks.SessionPassword([]byte("secret"), "session-2"))
+	assert.NoError(t, err)
+	header := encryptedHeader(t, cipher, "example.com:443")
+
+	session, ok := m.matchSession(header)
+	assert.True(t, ok)
+	assert.Equal(t, "session-2", session.id)
+
+	unknown, err := shadowsocks.NewCipher(shadowsocks.CipherChacha20Poly1305, "unknown")
+	assert.NoError(t, err)
+	_, ok = m.matchSession(encryptedHeader(t, unknown, "example.com:443"))
+	assert.False(t, ok)
+}
+
+func Test_Manager_RefusesProtectedDestinations(t *testing.T) {
+	m := newTestManager()
+	params, err := m.ProvideConfig("session-1", nil, nil)
+	assert.NoError(t, err)
+	config := params.SessionServiceConfig.(shadowsocks.ServiceConfig)
+	cipher, err := shadowsocks.NewCipher(config.Cipher, config.Password)
+	assert.NoError(t, err)
+
+	for _, destination := range []string{"127.0.0.1:80", "10.1.2.3:443", "169.254.169.254:80", "[::1]:80"} {
+		client, server := net.Pipe()
+		go m.handleConn(server)
+
+		stream := cipher.Conn(client)
+		addr, err := shadowsocks.EncodeAddress(destination)
+		assert.NoError(t, err)
+		_, err = stream.Write(addr)
+		assert.NoError(t, err)
+
+		reply, _ := ioutil.ReadAll(client)
+		assert.Empty(t, reply, destination)
+	}
+}
+
+func Test_Manager_KeepsUnknownClientsUntilTimeout(t *testing.T) {
+	m := newTestManager()
+
+	client, server := net.Pipe()
+	go m.handleConn(server)
+
+	start := time.Now()
+	go client.Write(make([]byte, 100))
+	ioutil.ReadAll(client)
+	assert.True(t, time.Since(start) >= m.headerTimeout)
+}
+
+// encryptedHeader returns the header of connection encrypted with the cipher, which requests the destination.
+func encryptedHeader(t *testing.T, cipher *shadowsocks.Cipher, destination string) []byte {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	addr, err := shadowsocks.EncodeAddress(destination)
+	assert.NoError(t, err)
+	go cipher.Conn(client).Write(addr)
+
+	header := make([]byte, cipher.HeaderSize())
+	_, err = io.ReadFull(server, header)
+	assert.NoError(t, err)
+	return header
+}
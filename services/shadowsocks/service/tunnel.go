@@ -0,0 +1,250 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/services/shadowsocks"
+	"github.com/mysteriumnetwork/node/session/event"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// errDestinationForbidden is returned for destinations service policies do not allow.
+var errDestinationForbidden = errors.New("destination is not allowed")
+
+// handleConn finds the session the connection is encrypted for and tunnels it to the requested destination.
+func (m *Manager) handleConn(conn net.Conn) {
+	if err := conn.SetReadDeadline(time.Now().Add(m.headerTimeout)); err != nil {
+		conn.Close()
+		return
+	}
+
+	header := make([]byte, m.headerSize())
+	if _, err := io.ReadFull(conn, header); err != nil {
+		conn.Close()
+		return
+	}
+
+	session, ok := m.matchSession(header)
+	if !ok {
+		// Connections of unknown clients are not closed immediately, so that probes can not tell the service apart.
+		log.Debug().Msgf("Shadowsocks: unknown client %s", conn.RemoteAddr())
+		io.Copy(ioutil.Discard, conn)
+		conn.Close()
+		return
+	}
+
+	stream := session.cipher.ConnWithHeader(conn, header)
+	destination, err := shadowsocks.ReadAddress(stream)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Shadowsocks: could not read destination of session %s", session.id)
+		conn.Close()
+		return
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.dialTimeout)
+	defer cancel()
+	addr, err := m.resolveDestination(ctx, destination)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Shadowsocks: refused destination %s of session %s", destination, session.id)
+		conn.Close()
+		return
+	}
+
+	var dialer net.Dialer
+	target, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Shadowsocks: could not reach %s", destination)
+		conn.Close()
+		return
+	}
+
+	session.tunnel(stream, target)
+}
+
+// headerSize returns size of connection header, it is the same for all sessions since they share the cipher type.
+func (m *Manager) headerSize() int {
+	cipher, err := shadowsocks.NewCipher(m.options.Cipher, "")
+	if err != nil {
+		return 0
+	}
+	return cipher.HeaderSize()
+}
+
+func (m *Manager) matchSession(header []byte) (*tunnelSession, bool) {
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+
+	for _, session := range m.sessions {
+		if session.cipher.Matches(header) {
+			return session, true
+		}
+	}
+	return nil, false
+}
+
+// resolveDestination checks if the destination is allowed by service policies and resolves it to unprotected address.
+func (m *Manager) resolveDestination(ctx context.Context, hostport string) (string, error) {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return "", err
+	}
+
+	m.startStopMu.Lock()
+	instance := m.serviceInstance
+	m.startStopMu.Unlock()
+	if instance != nil && !instance.Policies().IsHostAllowed(host) {
+		return "", errDestinationForbidden
+	}
+
+	return m.protected.Resolve(ctx, hostport)
+}
+
+// tunnelSession counts traffic of session tunnels, which are closed together with the session.
+type tunnelSession struct {
+	// sent and received are accessed atomically, they are kept first to be 64-bit aligned.
+	sent     uint64
+	received uint64
+
+	id     string
+	cipher *shadowsocks.Cipher
+	bus    eventbus.Publisher
+	done   chan struct{}
+
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+	closed  bool
+}
+
+func newTunnelSession(id string, cipher *shadowsocks.Cipher, bus eventbus.Publisher) *tunnelSession {
+	return &tunnelSession{
+		id:     id,
+		cipher: cipher,
+		bus:    bus,
+		done:   make(chan struct{}),
+		conns:  make(map[net.Conn]struct{}),
+	}
+}
+
+// tunnel copies data between consumer and destination until either side closes the connection.
+func (s *tunnelSession) tunnel(consumer, target net.Conn) {
+	if !s.track(consumer, target) {
+		consumer.Close()
+		target.Close()
+		return
+	}
+	defer s.untrack(consumer, target)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		copyCounting(target, consumer, &s.received)
+		target.Close()
+	}()
+	copyCounting(consumer, target, &s.sent)
+	consumer.Close()
+	wg.Wait()
+}
+
+func (s *tunnelSession) track(conns ...net.Conn) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	for _, conn := range conns {
+		s.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (s *tunnelSession) untrack(conns ...net.Conn) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	for _, conn := range conns {
+		delete(s.conns, conn)
+	}
+}
+
+// publishStats publishes session traffic for payments until the session is closed.
+func (s *tunnelSession) publishStats(frequency time.Duration) {
+	for {
+		select {
+		case <-time.After(frequency):
+			s.publish()
+		case <-s.done:
+			s.publish()
+			log.Info().Msgf("Stopped publishing statistics for session %s", s.id)
+			return
+		}
+	}
+}
+
+func (s *tunnelSession) publish() {
+	s.bus.Publish(event.AppTopicDataTransferred, event.AppEventDataTransferred{
+		ID:   s.id,
+		Up:   atomic.LoadUint64(&s.sent),
+		Down: atomic.LoadUint64(&s.received),
+	})
+}
+
+func (s *tunnelSession) close() {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	close(s.done)
+}
+
+func copyCounting(dst io.Writer, src io.Reader, counter *uint64) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return
+			}
+			atomic.AddUint64(counter, uint64(n))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package shadowsocks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// ServiceType indicates "shadowsocks" service type
+const ServiceType = "shadowsocks"
+
+// ServiceDefinition structure represents "shadowsocks" service parameters
+type ServiceDefinition struct {
+	// Approximate information on location where the service is provided from
+	Location market.Location `json:"location"`
+
+	// Cipher used to obfuscate the traffic, consumers pick providers supporting the cipher of their client.
+	Cipher string `json:"cipher"`
+}
+
+// GetLocation returns geographic location of service definition provider
+func (service ServiceDefinition) GetLocation() market.Location {
+	return service.Location
+}
+
+// ServiceConfig represents shadowsocks service provider configuration passed to the consumer for establishing a connection.
+type ServiceConfig struct {
+	// Endpoint is the host:port address of the provider's shadowsocks server.
+	Endpoint string `json:"endpoint"`
+	Cipher   string `json:"cipher"`
+	// Password is unique to the session, provider tells sessions apart by the key their connections are encrypted with.
+	Password string `json:"password"`
+}
+
+// SessionPassword derives password of the session from its ID, so that provider does not need to store it.
+func SessionPassword(secret []byte, sessionID string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(sessionID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
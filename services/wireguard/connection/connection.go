@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/json"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -74,9 +75,59 @@ type Connection struct {
 	opts                Options
 	connEndpointFactory wg.EndpointFactory
 	handshakeWaiter     HandshakeWaiter
+	tunnelPeerIP        net.IP
+	providerIP          net.IP
 }
 
 var _ connection.Connection = &Connection{}
+var _ connection.TunnelPeer = &Connection{}
+var _ connection.TunnelInterface = &Connection{}
+var _ connection.DefaultRouteTaker = &Connection{}
+var _ connection.DefaultRouteReleaser = &Connection{}
+var _ connection.ProviderEndpoint = &Connection{}
+var _ connection.KeyRotator = &Connection{}
+
+// TunnelPeerIP returns provider's address inside the tunnel, nil if provider does not serve DNS there.
+func (c *Connection) TunnelPeerIP() net.IP {
+	return c.tunnelPeerIP
+}
+
+// ProviderIP returns address of the provider, traffic to which is encapsulated.
+func (c *Connection) ProviderIP() net.IP {
+	return c.providerIP
+}
+
+// InterfaceName returns name of the tunnel network interface.
+func (c *Connection) InterfaceName() string {
+	if c.connectionEndpoint == nil {
+		return ""
+	}
+	return c.connectionEndpoint.InterfaceName()
+}
+
+// TakeDefaultRoute routes all traffic through the tunnel established with default route kept intact.
+// Route to the provider is excluded again, since stopping the previous connection clears excluded routes.
+func (c *Connection) TakeDefaultRoute() error {
+	iface := c.InterfaceName()
+	if iface == "" {
+		return errors.New("connection is not established")
+	}
+
+	if err := netutil.ExcludeRoute(c.providerIP); err != nil {
+		log.Warn().Err(err).Msgf("Could not exclude route to the provider %s", c.providerIP)
+	}
+	return netutil.AddDefaultRoute(iface)
+}
+
+// ReleaseDefaultRoute routes traffic around the tunnel, which stays up to be taken back with TakeDefaultRoute.
+func (c *Connection) ReleaseDefaultRoute() error {
+	iface := c.InterfaceName()
+	if iface == "" {
+		return errors.New("connection is not established")
+	}
+
+	return netutil.DeleteDefaultRoute(iface)
+}
 
 // State returns connection state channel.
 func (c *Connection) State() <-chan connectionstate.State {
@@ -102,12 +153,20 @@ func (c *Connection) Start(ctx context.Context, options connection.ConnectOption
 	if err := json.Unmarshal(options.SessionConfig, &config); err != nil {
 		return errors.Wrap(err, "failed to unmarshal connection config")
 	}
+	if options.ProviderNATConn != nil {
+		// Tunnel goes to the relay server which forwards it to the provider
+		// or to the provider's IPv6 address which is not in the session config.
+		if remoteIP := options.ProviderNATConn.RemoteAddr().(*net.UDPAddr).IP; options.Relayed || remoteIP.To4() == nil {
+			config.Provider.Endpoint.IP = remoteIP
+		}
+	}
 
 	removeAllowedIPRule, err := firewall.AllowIPAccess(config.Provider.Endpoint.IP.String())
 	if err != nil {
 		return errors.Wrap(err, "failed to add firewall exception for wireguard remote IP")
 	}
 	c.removeAllowedIPRule = removeAllowedIPRule
+	c.providerIP = config.Provider.Endpoint.IP
 
 	defer func() {
 		if err != nil {
@@ -117,6 +176,8 @@ func (c *Connection) Start(ctx context.Context, options connection.ConnectOption
 
 	c.stateCh <- connectionstate.Connecting
 
+	c.tunnelPeerIP = tunnelPeerIP(config)
+
 	if options.ProviderNATConn != nil {
 		options.ProviderNATConn.Close()
 		config.LocalPort = options.ProviderNATConn.LocalAddr().(*net.UDPAddr).Port
@@ -132,10 +193,13 @@ func (c *Connection) Start(ctx context.Context, options connection.ConnectOption
 	conn, err := c.startConn(wgcfg.DeviceConfig{
 		IfaceName:    "", // Interface name will be generated by connection endpoint.
 		Subnet:       config.Consumer.IPAddress,
+		IPv6Subnet:   config.Consumer.IPv6Address,
 		PrivateKey:   c.privateKey,
 		ListenPort:   config.LocalPort,
 		DNS:          dnsIPs,
 		DNSScriptDir: c.opts.DNSScriptDir,
+		// Additional sessions carry only traffic bound to their interface.
+		KeepDefaultRoute: options.Params.KeepDefaultRoute,
 		Peer: wgcfg.Peer{
 			Endpoint:               &config.Provider.Endpoint,
 			PublicKey:              config.Provider.PublicKey,
@@ -192,6 +256,38 @@ func (c *Connection) GetConfig() (connection.ConsumerConfig, error) {
 	}, nil
 }
 
+// RotateKeys generates a new private key, exchanges its public key for the new provider one using renegotiate and
+// applies both keys to the established tunnel.
+func (c *Connection) RotateKeys(renegotiate func(config connection.ConsumerConfig) ([]byte, error)) error {
+	if c.connectionEndpoint == nil {
+		return errors.New("connection is not established")
+	}
+
+	privateKey, err := key.GeneratePrivateKey()
+	if err != nil {
+		return errors.Wrap(err, "could not generate private key")
+	}
+	publicKey, err := key.PrivateKeyToPublicKey(privateKey)
+	if err != nil {
+		return errors.Wrap(err, "could not get public key from private key")
+	}
+
+	reply, err := renegotiate(wg.ConsumerConfig{PublicKey: publicKey})
+	if err != nil {
+		return err
+	}
+	var config wg.ServiceConfig
+	if err := json.Unmarshal(reply, &config); err != nil {
+		return errors.Wrap(err, "failed to unmarshal renegotiated config")
+	}
+
+	if err := c.connectionEndpoint.RotateKeys(privateKey, config.Provider.PublicKey); err != nil {
+		return err
+	}
+	c.privateKey = privateKey
+	return nil
+}
+
 // Stop stops wireguard connection and closes connection endpoint.
 func (c *Connection) Stop() {
 	c.stopOnce.Do(func() {
@@ -216,3 +312,15 @@ func (c *Connection) Stop() {
 
 	netutil.ClearStaleRoutes()
 }
+
+// tunnelPeerIP returns provider's address inside the tunnel if provider serves DNS on it, nil otherwise.
+// Other DNS servers, e.g. public resolvers, are reachable outside of the tunnel, so they do not prove it works.
+func tunnelPeerIP(config wg.ServiceConfig) net.IP {
+	peerIP := netutil.FirstIP(config.Consumer.IPAddress)
+	for _, dnsIP := range strings.Split(config.Consumer.DNSIPs, ",") {
+		if net.ParseIP(strings.TrimSpace(dnsIP)).Equal(peerIP) {
+			return peerIP
+		}
+	}
+	return nil
+}
@@ -93,6 +93,23 @@ func TestConnectionStopOnceAfterHandshakeErrorAndStopCall(t *testing.T) {
 	assert.Equal(t, connectionstate.NotConnected, <-conn.State())
 }
 
+func TestTunnelPeerIP(t *testing.T) {
+	config := newServiceConfig()
+	config.Consumer.IPAddress = net.IPNet{IP: net.IPv4(10, 182, 0, 2), Mask: net.CIDRMask(24, 32)}
+
+	config.Consumer.DNSIPs = "10.182.0.1"
+	assert.Equal(t, "10.182.0.1", tunnelPeerIP(config).String())
+
+	config.Consumer.DNSIPs = "1.1.1.1, 10.182.0.1"
+	assert.Equal(t, "10.182.0.1", tunnelPeerIP(config).String())
+
+	config.Consumer.DNSIPs = "1.1.1.1"
+	assert.Nil(t, tunnelPeerIP(config))
+
+	config.Consumer.DNSIPs = ""
+	assert.Nil(t, tunnelPeerIP(config))
+}
+
 func newConn(t *testing.T) *Connection {
 	endpointFactory := func() (wg.ConnectionEndpoint, error) {
 		return &mockConnectionEndpoint{}, nil
@@ -118,8 +135,9 @@ func newServiceConfig() wg.ServiceConfig {
 			Endpoint:  *endpoint,
 		},
 		Consumer: struct {
-			IPAddress net.IPNet
-			DNSIPs    string
+			IPAddress   net.IPNet
+			IPv6Address net.IPNet
+			DNSIPs      string
 		}{
 			IPAddress: net.IPNet{
 				IP:   net.IPv4(127, 0, 0, 1),
@@ -141,6 +159,7 @@ func (mce *mockConnectionEndpoint) Stop() error                          { retur
 func (mce *mockConnectionEndpoint) Config() (wg.ServiceConfig, error)    { return wg.ServiceConfig{}, nil }
 func (mce *mockConnectionEndpoint) AddPeer(_ string, _ wgcfg.Peer) error { return nil }
 func (mce *mockConnectionEndpoint) RemovePeer(_ string) error            { return nil }
+func (mce *mockConnectionEndpoint) RotateKeys(_, _ string) error         { return nil }
 func (mce *mockConnectionEndpoint) ConfigureRoutes(_ net.IP) error       { return nil }
 func (mce *mockConnectionEndpoint) PeerStats() (*wgcfg.Stats, error) {
 	return &wgcfg.Stats{LastHandshake: time.Now(), BytesSent: 10, BytesReceived: 11}, nil
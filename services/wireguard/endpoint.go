@@ -21,6 +21,16 @@ import (
 	"github.com/mysteriumnetwork/node/services/wireguard/wgcfg"
 )
 
+// WireGuard implementations connection endpoints can run on.
+const (
+	// BackendAuto uses kernel module when it is available and falls back to userspace implementation otherwise.
+	BackendAuto = "auto"
+	// BackendKernel uses kernel module.
+	BackendKernel = "kernel"
+	// BackendUserspace uses userspace implementation.
+	BackendUserspace = "userspace"
+)
+
 // EndpointFactory creates new connection endpoint.
 type EndpointFactory func() (ConnectionEndpoint, error)
 
@@ -31,6 +41,7 @@ type ConnectionEndpoint interface {
 	StartProviderMode(publicIP string, config wgcfg.DeviceConfig) error
 	PeerStats() (*wgcfg.Stats, error)
 	Config() (ServiceConfig, error)
+	RotateKeys(privateKey string, peerPublicKey string) error
 	InterfaceName() string
 	Stop() error
 }
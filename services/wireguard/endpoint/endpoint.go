@@ -29,9 +29,9 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-// NewConnectionEndpoint returns new connection endpoint instance.
-func NewConnectionEndpoint(resourceAllocator *resources.Allocator) (wg.ConnectionEndpoint, error) {
-	wgClient, err := newWGClient()
+// NewConnectionEndpoint returns new connection endpoint instance running on the given WireGuard backend.
+func NewConnectionEndpoint(resourceAllocator *resources.Allocator, backend string) (wg.ConnectionEndpoint, error) {
+	wgClient, err := newWGClient(backend)
 	if err != nil {
 		return nil, err
 	}
@@ -122,6 +122,19 @@ func (ce *connectionEndpoint) Config() (wg.ServiceConfig, error) {
 	return config, nil
 }
 
+// RotateKeys replaces private key of the endpoint and public key of its peer, peers handshake again using the new keys.
+func (ce *connectionEndpoint) RotateKeys(privateKey string, peerPublicKey string) error {
+	cfg := ce.cfg
+	cfg.PrivateKey = privateKey
+	cfg.Peer.PublicKey = peerPublicKey
+	if err := ce.wgClient.RotateKeys(cfg.IfaceName, cfg.PrivateKey, cfg.Peer); err != nil {
+		return errors.Wrap(err, "could not rotate device keys")
+	}
+
+	ce.cfg = cfg
+	return nil
+}
+
 // Stop closes wireguard client and destroys wireguard network interface.
 func (ce *connectionEndpoint) Stop() error {
 	if err := ce.wgClient.Close(); err != nil {
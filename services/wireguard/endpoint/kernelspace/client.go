@@ -64,9 +64,14 @@ func (c *client) ConfigureDevice(config wgcfg.DeviceConfig) error {
 	if err := c.up(config.IfaceName, config.Subnet); err != nil {
 		return err
 	}
+	if config.IPv6Subnet.IP != nil {
+		if err := netutil.AssignIP(config.IfaceName, config.IPv6Subnet); err != nil {
+			return fmt.Errorf("could not assign IPv6 address: %w", err)
+		}
+	}
 
 	if config.Peer.Endpoint != nil {
-		if err := configureRoutes(config.IfaceName, config.Peer.Endpoint.IP); err != nil {
+		if err := configureRoutes(config.IfaceName, config.Peer.Endpoint.IP, config.KeepDefaultRoute); err != nil {
 			return err
 		}
 	}
@@ -122,6 +127,27 @@ func addPeerConfig(peer wgcfg.Peer) (wgtypes.PeerConfig, error) {
 	}, nil
 }
 
+// RotateKeys sets device private key and replaces its peer.
+func (c *client) RotateKeys(iface string, privateKey string, peer wgcfg.Peer) error {
+	key, err := stringToKey(privateKey)
+	if err != nil {
+		return err
+	}
+	peerConfig, err := addPeerConfig(peer)
+	if err != nil {
+		return err
+	}
+
+	if err := c.wgClient.ConfigureDevice(iface, wgtypes.Config{
+		PrivateKey:   &key,
+		ReplacePeers: true,
+		Peers:        []wgtypes.PeerConfig{peerConfig},
+	}); err != nil {
+		return fmt.Errorf("could not rotate kernel space device keys: %w", err)
+	}
+	return nil
+}
+
 func (c *client) PeerStats(string) (*wgcfg.Stats, error) {
 	d, err := c.wgClient.Device(c.iface)
 	if err != nil {
@@ -174,10 +200,13 @@ func (c *client) Close() (err error) {
 	return nil
 }
 
-func configureRoutes(iface string, ip net.IP) error {
+func configureRoutes(iface string, ip net.IP, keepDefaultRoute bool) error {
 	if err := netutil.ExcludeRoute(ip); err != nil {
 		return err
 	}
+	if keepDefaultRoute {
+		return nil
+	}
 	return netutil.AddDefaultRoute(iface)
 }
 
@@ -20,6 +20,7 @@ package remoteclient
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/user"
 	"sync"
@@ -87,6 +88,11 @@ func (c *client) PeerStats(iface string) (*wgcfg.Stats, error) {
 	return &stats, nil
 }
 
+// RotateKeys fails, since supervisor does not support changing keys of the running interface.
+func (c *client) RotateKeys(string, string, wgcfg.Peer) error {
+	return errors.New("key rotation is not supported by supervisor")
+}
+
 func (c *client) Close() (err error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -47,6 +47,11 @@ func (c *client) ConfigureDevice(config wgcfg.DeviceConfig) (err error) {
 	if c.tun, err = CreateTUN(config.IfaceName, config.Subnet); err != nil {
 		return errors.Wrap(err, "failed to create TUN device")
 	}
+	if config.IPv6Subnet.IP != nil {
+		if err := netutil.AssignIP(config.IfaceName, config.IPv6Subnet); err != nil {
+			return errors.Wrap(err, "failed to assign IPv6 address")
+		}
+	}
 
 	c.devAPI = device.NewDevice(c.tun, device.NewLogger(device.LogLevelDebug, "[userspace-wg]"))
 	if err := c.setDeviceConfig(config.Encode()); err != nil {
@@ -61,8 +66,10 @@ func (c *client) ConfigureDevice(config wgcfg.DeviceConfig) (err error) {
 		if err := netutil.ExcludeRoute(config.Peer.Endpoint.IP); err != nil {
 			return fmt.Errorf("could not exclude route %s: %w", config.Peer.Endpoint.IP.String(), err)
 		}
-		if err := netutil.AddDefaultRoute(config.IfaceName); err != nil {
-			return fmt.Errorf("could not add default route for %s: %w", config.IfaceName, err)
+		if !config.KeepDefaultRoute {
+			if err := netutil.AddDefaultRoute(config.IfaceName); err != nil {
+				return fmt.Errorf("could not add default route for %s: %w", config.IfaceName, err)
+			}
 		}
 	}
 
@@ -97,6 +104,12 @@ func (c *client) PeerStats(string) (*wgcfg.Stats, error) {
 	return stats, nil
 }
 
+// RotateKeys sets device private key and replaces its peer.
+func (c *client) RotateKeys(_ string, privateKey string, peer wgcfg.Peer) error {
+	config := wgcfg.DeviceConfig{PrivateKey: privateKey, Peer: peer}
+	return c.setDeviceConfig(config.EncodeKeyRotation())
+}
+
 func (c *client) DestroyDevice(name string) error {
 	return destroyDevice(name)
 }
@@ -19,13 +19,16 @@ package endpoint
 
 import (
 	"runtime"
+	"sync"
 
 	"github.com/mysteriumnetwork/node/config"
+	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/mysteriumnetwork/node/services/wireguard/endpoint/kernelspace"
 	"github.com/mysteriumnetwork/node/services/wireguard/endpoint/remoteclient"
 	"github.com/mysteriumnetwork/node/services/wireguard/endpoint/userspace"
 	"github.com/mysteriumnetwork/node/services/wireguard/wgcfg"
 	"github.com/mysteriumnetwork/node/utils/cmdutil"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
@@ -34,22 +37,64 @@ type WgClient interface {
 	ConfigureDevice(config wgcfg.DeviceConfig) error
 	DestroyDevice(name string) error
 	PeerStats(iface string) (*wgcfg.Stats, error)
+	RotateKeys(iface string, privateKey string, peer wgcfg.Peer) error
 	Close() error
 }
 
-func newWGClient() (WgClient, error) {
+// ErrKernelUnsupported indicates that kernel backend was requested, but WireGuard kernel module is not available.
+var ErrKernelUnsupported = errors.New("wireguard kernel module is not available")
+
+var (
+	kernelSupportOnce sync.Once
+	kernelSupported   bool
+)
+
+// ResolveBackend returns WireGuard implementation endpoints use for the given backend setting.
+// Automatic selection is resolved to the kernel module when it is available, to userspace implementation otherwise.
+func ResolveBackend(backend string) (string, error) {
+	switch backend {
+	case wg.BackendAuto, "":
+		if isKernelSpaceSupported() {
+			return wg.BackendKernel, nil
+		}
+		log.Info().Msg("Wireguard kernel space is not supported. Switching to user space implementation.")
+		return wg.BackendUserspace, nil
+	case wg.BackendKernel:
+		if !isKernelSpaceSupported() {
+			return "", ErrKernelUnsupported
+		}
+		return wg.BackendKernel, nil
+	case wg.BackendUserspace:
+		return wg.BackendUserspace, nil
+	default:
+		return "", errors.Errorf("unknown wireguard backend %q", backend)
+	}
+}
+
+func newWGClient(backend string) (WgClient, error) {
 	if config.GetBool(config.FlagUserMode) {
 		return remoteclient.New()
 	}
-	if isKernelSpaceSupported() {
+
+	backend, err := ResolveBackend(backend)
+	if err != nil {
+		return nil, err
+	}
+	if backend == wg.BackendKernel {
 		return kernelspace.NewWireguardClient()
 	}
-
-	log.Info().Msg("Wireguard kernel space is not supported. Switching to user space implementation.")
 	return userspace.NewWireguardClient()
 }
 
+// isKernelSpaceSupported checks for the kernel module once, since the check creates a network interface.
 func isKernelSpaceSupported() bool {
+	kernelSupportOnce.Do(func() {
+		kernelSupported = checkKernelSpace()
+	})
+	return kernelSupported
+}
+
+func checkKernelSpace() bool {
 	if runtime.GOOS != "linux" {
 		return false
 	}
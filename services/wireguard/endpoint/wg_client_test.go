@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoint
+
+import (
+	"testing"
+
+	wg "github.com/mysteriumnetwork/node/services/wireguard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveBackend(t *testing.T) {
+	// Pretend kernel module check was already done and module is not available.
+	kernelSupportOnce.Do(func() {})
+	kernelSupported = false
+	defer func() { kernelSupported = false }()
+
+	backend, err := ResolveBackend(wg.BackendAuto)
+	assert.NoError(t, err)
+	assert.Equal(t, wg.BackendUserspace, backend)
+
+	backend, err = ResolveBackend(wg.BackendUserspace)
+	assert.NoError(t, err)
+	assert.Equal(t, wg.BackendUserspace, backend)
+
+	_, err = ResolveBackend(wg.BackendKernel)
+	assert.Equal(t, ErrKernelUnsupported, err)
+
+	_, err = ResolveBackend("boringtun")
+	assert.Error(t, err)
+
+	kernelSupported = true
+	backend, err = ResolveBackend(wg.BackendAuto)
+	assert.NoError(t, err)
+	assert.Equal(t, wg.BackendKernel, backend)
+}
@@ -37,10 +37,18 @@ type portSupplier interface {
 	Acquire() (port.Port, error)
 }
 
+// Interface names are system wide and addresses of the subnet are shared by all allocators,
+// so that several wireguard services running side by side do not hand out the same resources.
+var (
+	sharedLock        sync.Mutex
+	sharedIfaces      = make(map[int]struct{})
+	sharedIPAddresses = make(map[string]map[int]struct{})
+)
+
 // Allocator is mock wireguard resource handler.
 // It will manage lists of network interfaces names, IP addresses and port for endpoints.
 type Allocator struct {
-	mu          sync.Mutex
+	mu          *sync.Mutex
 	Ifaces      map[int]struct{}
 	IPAddresses map[int]struct{}
 
@@ -50,9 +58,19 @@ type Allocator struct {
 
 // NewAllocator creates new resource pool for wireguard connection.
 func NewAllocator(ports portSupplier, subnet net.IPNet) *Allocator {
+	sharedLock.Lock()
+	defer sharedLock.Unlock()
+
+	ipAddresses, ok := sharedIPAddresses[subnet.String()]
+	if !ok {
+		ipAddresses = make(map[int]struct{})
+		sharedIPAddresses[subnet.String()] = ipAddresses
+	}
+
 	return &Allocator{
-		Ifaces:      make(map[int]struct{}),
-		IPAddresses: make(map[int]struct{}),
+		mu:          &sharedLock,
+		Ifaces:      sharedIfaces,
+		IPAddresses: ipAddresses,
 
 		portSupplier: ports,
 		subnet:       subnet,
@@ -135,6 +153,14 @@ func (a *Allocator) AllocatePort() (int, error) {
 	return port.Num(), nil
 }
 
+// SetPortSupplier replaces supplier of ports allocated for new wireguard endpoints, e.g. once service port range changes.
+func (a *Allocator) SetPortSupplier(ports portSupplier) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.portSupplier = ports
+}
+
 // ReleaseInterface releases name for the wireguard network interface.
 func (a *Allocator) ReleaseInterface(iface string) error {
 	a.mu.Lock()
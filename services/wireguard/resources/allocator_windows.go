@@ -33,11 +33,18 @@ type portSupplier interface {
 	Acquire() (port.Port, error)
 }
 
+// Addresses of the subnet are shared by all allocators,
+// so that several wireguard services running side by side do not hand out the same addresses.
+var (
+	sharedLock        sync.Mutex
+	sharedIPAddresses = make(map[string]map[int]struct{})
+)
+
 // Allocator is mock wireguard resource handler.
 // It will manage lists of network interfaces names, IP addresses and port for endpoints.
 type Allocator struct {
 	IPAddresses map[int]struct{}
-	mu          sync.Mutex
+	mu          *sync.Mutex
 
 	portSupplier portSupplier
 	subnet       net.IPNet
@@ -45,8 +52,18 @@ type Allocator struct {
 
 // NewAllocator creates new resource pool for wireguard connection.
 func NewAllocator(portSupplier portSupplier, subnet net.IPNet) *Allocator {
+	sharedLock.Lock()
+	defer sharedLock.Unlock()
+
+	ipAddresses, ok := sharedIPAddresses[subnet.String()]
+	if !ok {
+		ipAddresses = make(map[int]struct{})
+		sharedIPAddresses[subnet.String()] = ipAddresses
+	}
+
 	return &Allocator{
-		IPAddresses: make(map[int]struct{}),
+		mu:          &sharedLock,
+		IPAddresses: ipAddresses,
 
 		portSupplier: portSupplier,
 		subnet:       subnet,
@@ -82,10 +99,21 @@ func (a *Allocator) AllocateIPNet() (net.IPNet, error) {
 
 // AllocatePort provides available UDP port for the wireguard endpoint.
 func (a *Allocator) AllocatePort() (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	p, err := a.portSupplier.Acquire()
 	return int(p), err
 }
 
+// SetPortSupplier replaces supplier of ports allocated for new wireguard endpoints, e.g. once service port range changes.
+func (a *Allocator) SetPortSupplier(ports portSupplier) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.portSupplier = ports
+}
+
 // ReleaseInterface is not required for Windows implementation and left here just to satisfy the interface.
 func (a *Allocator) ReleaseInterface(iface string) error {
 	return nil
@@ -19,12 +19,16 @@ package service
 
 import (
 	"encoding/json"
+	"fmt"
 	"net"
 
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/datasize"
+	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
@@ -32,6 +36,10 @@ import (
 type Options struct {
 	Ports  *port.Range
 	Subnet net.IPNet
+	// SessionBandwidth limits speed of each session, zero value means unlimited.
+	SessionBandwidth datasize.BitSpeed
+	// Backend selects WireGuard implementation, kernel module is used when it is available by default.
+	Backend string
 }
 
 // DefaultOptions is a wireguard service configuration that will be used if no options provided.
@@ -41,6 +49,7 @@ var DefaultOptions = Options{
 		IP:   net.ParseIP("10.182.0.0").To4(),
 		Mask: net.IPv4Mask(255, 255, 0, 0),
 	},
+	Backend: wg.BackendAuto,
 }
 
 // GetOptions returns effective Wireguard service options from application configuration.
@@ -62,11 +71,36 @@ func GetOptions() Options {
 		portRange = port.UnspecifiedRange()
 	}
 	return Options{
-		Ports:  portRange,
-		Subnet: *ipnet,
+		Ports:            portRange,
+		Subnet:           *ipnet,
+		SessionBandwidth: sessionBandwidthOption(),
+		Backend:          config.GetString(config.FlagWireguardBackend),
 	}
 }
 
+// sessionBandwidthOption returns session speed limit configured for all services of the node.
+func sessionBandwidthOption() datasize.BitSpeed {
+	value := config.GetString(config.FlagServiceSessionBandwidth)
+	if value == "" {
+		return 0
+	}
+
+	bandwidth, err := datasize.ParseBitSpeed(value)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to parse session bandwidth option, sessions are not limited")
+		return 0
+	}
+	return bandwidth
+}
+
+// NewPortPool returns supplier of ports from the configured range, any free port is supplied when range is not specified.
+func NewPortPool(ports *port.Range) port.ServicePortSupplier {
+	if ports.IsSpecified() {
+		return port.NewFixedRangePool(*ports)
+	}
+	return port.NewPool()
+}
+
 // ParseJSONOptions function fills in Wireguard options from JSON request
 func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
 	var requestOptions = GetOptions()
@@ -75,26 +109,37 @@ func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
 	}
 
 	opts := DefaultOptions
+	opts.SessionBandwidth = requestOptions.SessionBandwidth
 	err := json.Unmarshal(*request, &opts)
 	return opts, err
 }
 
 // MarshalJSON implements json.Marshaler interface to provide human readable configuration.
 func (o Options) MarshalJSON() ([]byte, error) {
+	var sessionBandwidth string
+	if o.SessionBandwidth > 0 {
+		sessionBandwidth = fmt.Sprintf("%dbps", uint64(o.SessionBandwidth))
+	}
 	return json.Marshal(&struct {
-		Ports  string `json:"ports"`
-		Subnet string `json:"subnet"`
+		Ports            string `json:"ports"`
+		Subnet           string `json:"subnet"`
+		SessionBandwidth string `json:"session_bandwidth,omitempty"`
+		Backend          string `json:"backend"`
 	}{
-		Ports:  o.Ports.String(),
-		Subnet: o.Subnet.String(),
+		Ports:            o.Ports.String(),
+		Subnet:           o.Subnet.String(),
+		SessionBandwidth: sessionBandwidth,
+		Backend:          o.Backend,
 	})
 }
 
 // UnmarshalJSON implements json.Unmarshaler interface to receive human readable configuration.
 func (o *Options) UnmarshalJSON(data []byte) error {
 	var options struct {
-		Ports  string `json:"ports"`
-		Subnet string `json:"subnet"`
+		Ports            string `json:"ports"`
+		Subnet           string `json:"subnet"`
+		SessionBandwidth string `json:"session_bandwidth"`
+		Backend          string `json:"backend"`
 	}
 
 	if err := json.Unmarshal(data, &options); err != nil {
@@ -115,6 +160,20 @@ func (o *Options) UnmarshalJSON(data []byte) error {
 		}
 		o.Subnet = *ipnet
 	}
+	if options.SessionBandwidth != "" {
+		bandwidth, err := datasize.ParseBitSpeed(options.SessionBandwidth)
+		if err != nil {
+			return err
+		}
+		o.SessionBandwidth = bandwidth
+	}
+	switch options.Backend {
+	case "":
+	case wg.BackendAuto, wg.BackendKernel, wg.BackendUserspace:
+		o.Backend = options.Backend
+	default:
+		return errors.Errorf("unknown wireguard backend %q", options.Backend)
+	}
 
 	return nil
 }
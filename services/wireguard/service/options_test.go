@@ -48,7 +48,7 @@ func Test_ParseJSONOptions_HandlesEmptyRequest(t *testing.T) {
 
 func Test_ParseJSONOptions_ValidRequest(t *testing.T) {
 	configureDefaults()
-	request := json.RawMessage(`{"ports": "52820:53075", "subnet":"10.10.0.0/16"}`)
+	request := json.RawMessage(`{"ports": "52820:53075", "subnet":"10.10.0.0/16", "backend": "userspace"}`)
 	options, err := ParseJSONOptions(&request)
 
 	assert.NoError(t, err)
@@ -58,9 +58,18 @@ func Test_ParseJSONOptions_ValidRequest(t *testing.T) {
 			IP:   net.ParseIP("10.10.0.0").To4(),
 			Mask: net.IPv4Mask(255, 255, 0, 0),
 		},
+		Backend: "userspace",
 	}, options)
 }
 
+func Test_ParseJSONOptions_RejectsUnknownBackend(t *testing.T) {
+	configureDefaults()
+	request := json.RawMessage(`{"backend": "boringtun"}`)
+	_, err := ParseJSONOptions(&request)
+
+	assert.Error(t, err)
+}
+
 func configureDefaults() {
 	ctx := emptyContext()
 	config.ParseFlagsServiceWireguard(ctx)
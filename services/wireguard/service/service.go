@@ -19,12 +19,13 @@ package service
 
 import (
 	"github.com/mysteriumnetwork/node/core/location/locationstate"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/market"
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
 )
 
-// GetProposal returns the proposal for wireguard service
-func GetProposal(location locationstate.Location) market.ServiceProposal {
+// GetProposal returns the proposal for wireguard service, advertising given session bandwidth unless it is zero.
+func GetProposal(location locationstate.Location, sessionBandwidth datasize.BitSpeed) market.ServiceProposal {
 	marketLocation := market.Location{
 		Continent: location.Continent,
 		Country:   location.Country,
@@ -40,6 +41,7 @@ func GetProposal(location locationstate.Location) market.ServiceProposal {
 		ServiceDefinition: wg.ServiceDefinition{
 			Location:          marketLocation,
 			LocationOriginate: marketLocation,
+			SessionBandwidth:  sessionBandwidth,
 		},
 	}
 }
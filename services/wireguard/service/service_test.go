@@ -27,6 +27,7 @@ import (
 	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/nat"
@@ -54,10 +55,16 @@ func Test_GetProposal(t *testing.T) {
 				LocationOriginate: market.Location{Country: country},
 			},
 		},
-		GetProposal(locationstate.Location{Country: country}),
+		GetProposal(locationstate.Location{Country: country}, 0),
 	)
 }
 
+func Test_GetProposal_AdvertisesSessionBandwidth(t *testing.T) {
+	proposal := GetProposal(locationstate.Location{Country: country}, 5*1000*1000)
+
+	assert.Equal(t, datasize.BitSpeed(5*1000*1000), proposal.ServiceDefinition.(wg.ServiceDefinition).SessionBandwidth)
+}
+
 func Test_Manager_Stop(t *testing.T) {
 	manager := newManagerStub(pubIP, outIP, country)
 	service := service.NewInstance(
@@ -106,6 +113,7 @@ func (mce *mockConnectionEndpoint) Stop() error                          { retur
 func (mce *mockConnectionEndpoint) Config() (wg.ServiceConfig, error)    { return wg.ServiceConfig{}, nil }
 func (mce *mockConnectionEndpoint) AddPeer(_ string, _ wgcfg.Peer) error { return nil }
 func (mce *mockConnectionEndpoint) RemovePeer(_ string) error            { return nil }
+func (mce *mockConnectionEndpoint) RotateKeys(_, _ string) error         { return nil }
 func (mce *mockConnectionEndpoint) ConfigureRoutes(_ net.IP) error       { return nil }
 func (mce *mockConnectionEndpoint) PeerStats() (*wgcfg.Stats, error) {
 	return &wgcfg.Stats{LastHandshake: time.Now()}, nil
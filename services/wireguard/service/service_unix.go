@@ -30,18 +30,23 @@ import (
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/mysteriumnetwork/node/core/shaper"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/dns"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/firewall"
+	"github.com/mysteriumnetwork/node/logconfig"
 	"github.com/mysteriumnetwork/node/nat"
 	natevent "github.com/mysteriumnetwork/node/nat/event"
+	"github.com/mysteriumnetwork/node/nat/mapping"
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/mysteriumnetwork/node/services/wireguard/endpoint"
 	"github.com/mysteriumnetwork/node/services/wireguard/key"
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
 	"github.com/mysteriumnetwork/node/services/wireguard/wgcfg"
+	sevent "github.com/mysteriumnetwork/node/session/event"
 	"github.com/mysteriumnetwork/node/utils/netutil"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -60,10 +65,11 @@ func NewManager(
 	options Options,
 	portSupplier port.ServicePortSupplier,
 	trafficFirewall firewall.IncomingTrafficFirewall,
+	portMapper mapping.PortMapper,
 ) *Manager {
 	resourcesAllocator := resources.NewAllocator(portSupplier, options.Subnet)
 
-	return &Manager{
+	m := &Manager{
 		done:               make(chan struct{}),
 		resourcesAllocator: resourcesAllocator,
 		ipResolver:         ipResolver,
@@ -71,13 +77,20 @@ func NewManager(
 		natEventGetter:     natEventGetter,
 		eventBus:           eventBus,
 		trafficFirewall:    trafficFirewall,
-
-		connEndpointFactory: func() (wg.ConnectionEndpoint, error) {
-			return endpoint.NewConnectionEndpoint(resourcesAllocator)
-		},
-		country:        country,
-		sessionCleanup: map[string]func(){},
+		portMapper:         portMapper,
+
+		country:          country,
+		sessionCleanup:   map[string]func(){},
+		sessionShapers:   map[string]sessionShaper{},
+		sessionEndpoints: map[string]wg.ConnectionEndpoint{},
+		sessionBandwidth: options.SessionBandwidth,
+		subnet:           options.Subnet,
+		backendOption:    options.Backend,
+	}
+	m.connEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		return endpoint.NewConnectionEndpoint(resourcesAllocator, m.Backend())
 	}
+	return m
 }
 
 // Manager represents an instance of Wireguard service
@@ -91,6 +104,7 @@ type Manager struct {
 	natEventGetter  NATEventGetter
 	eventBus        eventbus.EventBus
 	trafficFirewall firewall.IncomingTrafficFirewall
+	portMapper      mapping.PortMapper
 
 	dnsOK    bool
 	dnsPort  int
@@ -102,23 +116,50 @@ type Manager struct {
 
 	serviceInstance  *service.Instance
 	sessionCleanup   map[string]func()
+	sessionShapers   map[string]sessionShaper
+	sessionEndpoints map[string]wg.ConnectionEndpoint
 	sessionCleanupMu sync.Mutex
 
 	country    string
 	outboundIP string
+
+	sessionBandwidth datasize.BitSpeed
+	subnet           net.IPNet
+
+	// backendOption is the configured WireGuard implementation, backend is the one it was resolved to on start.
+	backendOption string
+	backend       string
+}
+
+// Backend returns WireGuard implementation the service runs on, it is known once the service is started.
+func (m *Manager) Backend() string {
+	m.startStopMu.Lock()
+	defer m.startStopMu.Unlock()
+
+	return m.backend
 }
 
 // ProvideConfig provides the config for consumer and handles new WireGuard connection.
 func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage, remoteConn *net.UDPConn) (*service.ConfigParams, error) {
-	log.Info().Msg("Accepting new WireGuard connection")
+	logger := m.sessionLogger(sessionID)
+	logger.Info().Msg("Accepting new WireGuard connection")
 	consumerConfig := wg.ConsumerConfig{}
 	err := json.Unmarshal(sessionConfig, &consumerConfig)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not unmarshal wg consumer config")
 	}
 
-	remoteConn.Close()
-	listenPort := remoteConn.LocalAddr().(*net.UDPAddr).Port
+	var listenPort int
+	if remoteConn != nil {
+		remoteConn.Close()
+		listenPort = remoteConn.LocalAddr().(*net.UDPAddr).Port
+	} else {
+		// Service traffic is not relayed together with p2p channel, consumer connects to the service port directly.
+		listenPort, err = m.resourcesAllocator.AllocatePort()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not allocate service port")
+		}
+	}
 	providerConfig, err := m.createProviderConfig(listenPort, consumerConfig.PublicKey)
 	if err != nil {
 		return nil, fmt.Errorf("could not create provider mode wg config: %w", err)
@@ -168,50 +209,175 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 	go statsPublisher.start(sessionID, conn)
 
 	ifaceName := conn.InterfaceName()
-	s := shaper.New(m.eventBus)
+	s := m.newSessionShaper()
 	err = s.Start(ifaceName)
 	if err != nil {
-		log.Error().Err(err).Msg("Could not start traffic shaper")
+		logger.Error().Err(err).Msg("Could not start traffic shaper")
+	}
+
+	// Service port allocated outside of p2p channel is not reachable behind NAT unless mapped on the router.
+	var releasePortMapping func()
+	if remoteConn == nil && m.portMapper != nil && m.outboundIP != publicIP {
+		if release, ok := m.portMapper.Map("UDP", listenPort, "Myst node wireguard port mapping"); ok {
+			releasePortMapping = release
+		}
 	}
 
 	destroy := func() {
-		log.Info().Msgf("Cleaning up session %s", sessionID)
+		logger.Info().Msgf("Cleaning up session %s", sessionID)
 		m.sessionCleanupMu.Lock()
 		delete(m.sessionCleanup, sessionID)
+		shaped := m.sessionShapers[sessionID]
+		delete(m.sessionShapers, sessionID)
+		delete(m.sessionEndpoints, sessionID)
 		m.sessionCleanupMu.Unlock()
 
 		statsPublisher.stop()
 
-		s.Clear(ifaceName)
+		shaped.shaper.Clear(shaped.iface)
 
 		if releaseTrafficFirewall != nil {
 			if err := releaseTrafficFirewall(); err != nil {
-				log.Warn().Err(err).Msg("failed to disable traffic blocking")
+				logger.Warn().Err(err).Msg("failed to disable traffic blocking")
 			}
 		}
 
-		log.Trace().Msg("Deleting nat rules")
+		logger.Trace().Msg("Deleting nat rules")
 		if err := m.natService.Del(natRules); err != nil {
-			log.Error().Err(err).Msg("Failed to delete NAT rules")
+			logger.Error().Err(err).Msg("Failed to delete NAT rules")
 		}
 
-		log.Trace().Msg("Stopping connection endpoint")
+		logger.Trace().Msg("Stopping connection endpoint")
 		if err := conn.Stop(); err != nil {
-			log.Error().Err(err).Msg("Failed to stop connection endpoint")
+			logger.Error().Err(err).Msg("Failed to stop connection endpoint")
 		}
 
 		if err := m.resourcesAllocator.ReleaseIPNet(providerConfig.Subnet); err != nil {
-			log.Error().Err(err).Msg("Failed to release IP network")
+			logger.Error().Err(err).Msg("Failed to release IP network")
+		}
+
+		if releasePortMapping != nil {
+			releasePortMapping()
 		}
 	}
 
 	m.sessionCleanupMu.Lock()
 	m.sessionCleanup[sessionID] = destroy
+	m.sessionShapers[sessionID] = sessionShaper{iface: ifaceName, shaper: s}
+	m.sessionEndpoints[sessionID] = conn
 	m.sessionCleanupMu.Unlock()
 
+	m.eventBus.Publish(sevent.AppTopicSessionNetwork, sevent.AppEventSessionNetwork{
+		ID:      sessionID,
+		Network: config.Consumer.IPAddress,
+	})
+
 	return &service.ConfigParams{SessionServiceConfig: config, SessionDestroyCallback: destroy}, nil
 }
 
+// RenegotiateConfig rotates tunnel keys of the session: provider key is regenerated and consumer peer is replaced
+// with the one of the new consumer public key, session interface and its traffic are kept.
+func (m *Manager) RenegotiateConfig(sessionID string, sessionConfig json.RawMessage) (service.ServiceConfiguration, error) {
+	consumerConfig := wg.ConsumerConfig{}
+	if err := json.Unmarshal(sessionConfig, &consumerConfig); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal wg consumer config")
+	}
+
+	m.sessionCleanupMu.Lock()
+	defer m.sessionCleanupMu.Unlock()
+
+	conn, ok := m.sessionEndpoints[sessionID]
+	if !ok {
+		return nil, service.ErrorSessionNotExists
+	}
+
+	privateKey, err := key.GeneratePrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate private key: %w", err)
+	}
+	if err := conn.RotateKeys(privateKey, consumerConfig.PublicKey); err != nil {
+		return nil, err
+	}
+
+	config, err := conn.Config()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get peer config")
+	}
+	m.sessionLogger(sessionID).Info().Msgf("Rotated keys of session %s", sessionID)
+	return config, nil
+}
+
+// sessionLogger returns logger tagging records with the service instance and session IDs.
+func (m *Manager) sessionLogger(sessionID string) *zerolog.Logger {
+	var serviceID string
+	if m.serviceInstance != nil {
+		serviceID = string(m.serviceInstance.ID)
+	}
+	return logconfig.SessionLogger(serviceID, sessionID)
+}
+
+// sessionShaper is the traffic shaper of the session interface.
+type sessionShaper struct {
+	iface  string
+	shaper shaper.Shaper
+}
+
+// ThrottleSession limits speed of the session, replacing the configured session bandwidth.
+func (m *Manager) ThrottleSession(sessionID string, bandwidth datasize.BitSpeed) error {
+	m.sessionCleanupMu.Lock()
+	defer m.sessionCleanupMu.Unlock()
+
+	shaped, ok := m.sessionShapers[sessionID]
+	if !ok {
+		return service.ErrorSessionNotExists
+	}
+
+	shaped.shaper.Clear(shaped.iface)
+	shaped.shaper = shaper.NewLimitShaper(bandwidth)
+	m.sessionShapers[sessionID] = shaped
+	if err := shaped.shaper.Start(shaped.iface); err != nil {
+		if err == shaper.ErrUnsupported {
+			return service.ErrThrottlingNotSupported
+		}
+		return err
+	}
+	return nil
+}
+
+// ReloadOptions applies changed port range and session bandwidth to new sessions of the running service,
+// established sessions keep ports and limits they were started with.
+func (m *Manager) ReloadOptions(options service.Options) error {
+	wgOptions, ok := options.(Options)
+	if !ok {
+		return errors.New("invalid wireguard service options")
+	}
+	if wgOptions.Subnet.String() != m.subnet.String() || wgOptions.Backend != m.backendOption {
+		return service.ErrRestartRequired
+	}
+
+	m.resourcesAllocator.SetPortSupplier(NewPortPool(wgOptions.Ports))
+
+	m.sessionCleanupMu.Lock()
+	m.sessionBandwidth = wgOptions.SessionBandwidth
+	m.sessionCleanupMu.Unlock()
+
+	log.Info().Msgf("Wireguard service options reloaded, ports: %s, session bandwidth: %s", wgOptions.Ports, wgOptions.SessionBandwidth)
+	return nil
+}
+
+// newSessionShaper returns shaper of a single session. Each session has its own interface,
+// so configured session bandwidth is enforced by limiting the interface.
+func (m *Manager) newSessionShaper() shaper.Shaper {
+	m.sessionCleanupMu.Lock()
+	sessionBandwidth := m.sessionBandwidth
+	m.sessionCleanupMu.Unlock()
+
+	if sessionBandwidth == 0 {
+		return shaper.New(m.eventBus)
+	}
+	return shaper.NewLimitShaper(sessionBandwidth)
+}
+
 func (m *Manager) createProviderConfig(listenPort int, peerPublicKey string) (wgcfg.DeviceConfig, error) {
 	network, err := m.resourcesAllocator.AllocateIPNet()
 	if err != nil {
@@ -254,12 +420,18 @@ func (m *Manager) startNewConnection(publicIP string, config wgcfg.DeviceConfig)
 // Serve starts service - does block
 func (m *Manager) Serve(instance *service.Instance) error {
 	log.Info().Msg("Wireguard: starting")
+	backend, err := endpoint.ResolveBackend(m.backendOption)
+	if err != nil {
+		return errors.Wrap(err, "could not select wireguard backend")
+	}
+
 	m.startStopMu.Lock()
 	m.serviceInstance = instance
+	m.backend = backend
 
-	var err error
 	m.outboundIP, err = m.ipResolver.GetOutboundIP()
 	if err != nil {
+		m.startStopMu.Unlock()
 		return errors.Wrap(err, "could not get outbound IP")
 	}
 
@@ -284,7 +456,7 @@ func (m *Manager) Serve(instance *service.Instance) error {
 	}
 
 	m.startStopMu.Unlock()
-	log.Info().Msg("Wireguard: started")
+	log.Info().Msgf("Wireguard: started on %s backend", backend)
 	<-m.done
 	return nil
 }
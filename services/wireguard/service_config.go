@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"net"
 
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/market"
 )
 
@@ -35,6 +36,9 @@ type ServiceDefinition struct {
 	// Approximate information on location where the actual tunnelled traffic will originate from.
 	// This is used by providers having their own means of setting tunnels to other remote exit points.
 	LocationOriginate market.Location `json:"location_originate"`
+
+	// Per session bandwidth limit advertised by provider, zero value means unlimited or not advertised
+	SessionBandwidth datasize.BitSpeed `json:"session_bandwidth,omitempty"`
 }
 
 // GetLocation returns geographic location of service definition provider
@@ -55,7 +59,9 @@ type ServiceConfig struct {
 	}
 	Consumer struct {
 		IPAddress net.IPNet
-		DNSIPs    string
+		// IPv6Address is assigned by providers able to carry consumer IPv6 traffic.
+		IPv6Address net.IPNet
+		DNSIPs      string
 	}
 }
 
@@ -74,8 +80,14 @@ func (s ServiceConfig) MarshalJSON() ([]byte, error) {
 		Endpoint  string `json:"endpoint"`
 	}
 	type consumer struct {
-		IPAddress string `json:"ip_address"`
-		DNSIPs    string `json:"dns_ips"`
+		IPAddress   string `json:"ip_address"`
+		IPv6Address string `json:"ipv6_address,omitempty"`
+		DNSIPs      string `json:"dns_ips"`
+	}
+
+	var ipv6Address string
+	if s.Consumer.IPv6Address.IP != nil {
+		ipv6Address = s.Consumer.IPv6Address.String()
 	}
 
 	return json.Marshal(&struct {
@@ -93,8 +105,9 @@ func (s ServiceConfig) MarshalJSON() ([]byte, error) {
 			Endpoint:  s.Provider.Endpoint.String(),
 		},
 		Consumer: consumer{
-			IPAddress: s.Consumer.IPAddress.String(),
-			DNSIPs:    s.Consumer.DNSIPs,
+			IPAddress:   s.Consumer.IPAddress.String(),
+			IPv6Address: ipv6Address,
+			DNSIPs:      s.Consumer.DNSIPs,
 		},
 	})
 }
@@ -106,8 +119,9 @@ func (s *ServiceConfig) UnmarshalJSON(data []byte) error {
 		Endpoint  string `json:"endpoint"`
 	}
 	type consumer struct {
-		IPAddress string `json:"ip_address"`
-		DNSIPs    string `json:"dns_ips"`
+		IPAddress   string `json:"ip_address"`
+		IPv6Address string `json:"ipv6_address"`
+		DNSIPs      string `json:"dns_ips"`
 	}
 	var config struct {
 		LocalPort  int      `json:"local_port"`
@@ -139,5 +153,14 @@ func (s *ServiceConfig) UnmarshalJSON(data []byte) error {
 	s.Consumer.IPAddress = *ipnet
 	s.Consumer.IPAddress.IP = ip
 
+	if config.Consumer.IPv6Address != "" {
+		ip, ipnet, err := net.ParseCIDR(config.Consumer.IPv6Address)
+		if err != nil {
+			return err
+		}
+		s.Consumer.IPv6Address = *ipnet
+		s.Consumer.IPv6Address.IP = ip
+	}
+
 	return nil
 }
@@ -130,8 +130,9 @@ func TestServiceConfig_MarshalJSON(t *testing.T) {
 			Endpoint:  *endpoint,
 		},
 		Consumer: struct {
-			IPAddress net.IPNet
-			DNSIPs    string
+			IPAddress   net.IPNet
+			IPv6Address net.IPNet
+			DNSIPs      string
 		}{
 			IPAddress: net.IPNet{
 				IP:   net.IPv4(127, 0, 0, 1),
@@ -164,8 +165,9 @@ func TestServiceConfig_UnmarshalJSON(t *testing.T) {
 			Endpoint:  *endpoint,
 		},
 		Consumer: struct {
-			IPAddress net.IPNet
-			DNSIPs    string
+			IPAddress   net.IPNet
+			IPv6Address net.IPNet
+			DNSIPs      string
 		}{
 			IPAddress: net.IPNet{
 				IP:   net.IPv4(127, 0, 0, 1),
@@ -181,3 +183,19 @@ func TestServiceConfig_UnmarshalJSON(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expecteConfig, actualConfig)
 }
+
+func TestServiceConfig_IPv6AddressSurvivesSerialization(t *testing.T) {
+	endpoint, _ := net.ResolveUDPAddr("udp4", "127.0.0.1:51001")
+	var config ServiceConfig
+	config.Provider.Endpoint = *endpoint
+	config.Consumer.IPAddress = net.IPNet{IP: net.IPv4(10, 182, 0, 2).To4(), Mask: net.CIDRMask(24, 32)}
+	config.Consumer.IPv6Address = net.IPNet{IP: net.ParseIP("fd00:182::2"), Mask: net.CIDRMask(64, 128)}
+
+	configBytes, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.Contains(t, string(configBytes), `"ipv6_address":"fd00:182::2/64"`)
+
+	var actualConfig ServiceConfig
+	assert.NoError(t, json.Unmarshal(configBytes, &actualConfig))
+	assert.Equal(t, "fd00:182::2/64", actualConfig.Consumer.IPv6Address.String())
+}
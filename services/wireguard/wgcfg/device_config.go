@@ -43,8 +43,12 @@ type DeviceConfig struct {
 	PrivateKey string    `json:"private_key"`
 	ListenPort int       `json:"listen_port"`
 	DNS        []string  `json:"dns"`
+	// IPv6Subnet is assigned in addition to IPv4 subnet if tunnel carries IPv6 traffic.
+	IPv6Subnet net.IPNet `json:"ipv6_subnet"`
 	// Used only for unix.
 	DNSScriptDir string `json:"dns_script_dir"`
+	// KeepDefaultRoute leaves default route untouched for consumer mode.
+	KeepDefaultRoute bool `json:"keep_default_route"`
 
 	Peer Peer `json:"peer"`
 }
@@ -59,13 +63,15 @@ func (dc DeviceConfig) MarshalJSON() ([]byte, error) {
 	}
 
 	type deviceConfig struct {
-		IfaceName    string   `json:"iface_name"`
-		Subnet       string   `json:"subnet"`
-		PrivateKey   string   `json:"private_key"`
-		ListenPort   int      `json:"listen_port"`
-		DNS          []string `json:"dns"`
-		DNSScriptDir string   `json:"dns_script_dir"`
-		Peer         peer     `json:"peer"`
+		IfaceName        string   `json:"iface_name"`
+		Subnet           string   `json:"subnet"`
+		IPv6Subnet       string   `json:"ipv6_subnet,omitempty"`
+		PrivateKey       string   `json:"private_key"`
+		ListenPort       int      `json:"listen_port"`
+		DNS              []string `json:"dns"`
+		DNSScriptDir     string   `json:"dns_script_dir"`
+		KeepDefaultRoute bool     `json:"keep_default_route"`
+		Peer             peer     `json:"peer"`
 	}
 
 	var peerEndpoint string
@@ -73,13 +79,20 @@ func (dc DeviceConfig) MarshalJSON() ([]byte, error) {
 		peerEndpoint = dc.Peer.Endpoint.String()
 	}
 
+	var ipv6Subnet string
+	if dc.IPv6Subnet.IP != nil {
+		ipv6Subnet = dc.IPv6Subnet.String()
+	}
+
 	return json.Marshal(&deviceConfig{
-		IfaceName:    dc.IfaceName,
-		Subnet:       dc.Subnet.String(),
-		PrivateKey:   dc.PrivateKey,
-		ListenPort:   dc.ListenPort,
-		DNS:          dc.DNS,
-		DNSScriptDir: dc.DNSScriptDir,
+		IfaceName:        dc.IfaceName,
+		Subnet:           dc.Subnet.String(),
+		IPv6Subnet:       ipv6Subnet,
+		PrivateKey:       dc.PrivateKey,
+		ListenPort:       dc.ListenPort,
+		DNS:              dc.DNS,
+		DNSScriptDir:     dc.DNSScriptDir,
+		KeepDefaultRoute: dc.KeepDefaultRoute,
 		Peer: peer{
 			PublicKey:              dc.Peer.PublicKey,
 			Endpoint:               peerEndpoint,
@@ -99,13 +112,15 @@ func (dc *DeviceConfig) UnmarshalJSON(data []byte) error {
 	}
 
 	type deviceConfig struct {
-		IfaceName    string   `json:"iface_name"`
-		Subnet       string   `json:"subnet"`
-		PrivateKey   string   `json:"private_key"`
-		ListenPort   int      `json:"listen_port"`
-		DNS          []string `json:"dns"`
-		DNSScriptDir string   `json:"dns_script_dir"`
-		Peer         peer     `json:"peer"`
+		IfaceName        string   `json:"iface_name"`
+		Subnet           string   `json:"subnet"`
+		IPv6Subnet       string   `json:"ipv6_subnet,omitempty"`
+		PrivateKey       string   `json:"private_key"`
+		ListenPort       int      `json:"listen_port"`
+		DNS              []string `json:"dns"`
+		DNSScriptDir     string   `json:"dns_script_dir"`
+		KeepDefaultRoute bool     `json:"keep_default_route"`
+		Peer             peer     `json:"peer"`
 	}
 
 	cfg := deviceConfig{}
@@ -130,10 +145,19 @@ func (dc *DeviceConfig) UnmarshalJSON(data []byte) error {
 	dc.IfaceName = cfg.IfaceName
 	dc.Subnet = *ipnet
 	dc.Subnet.IP = ip
+	if cfg.IPv6Subnet != "" {
+		ip, ipnet, err := net.ParseCIDR(cfg.IPv6Subnet)
+		if err != nil {
+			return fmt.Errorf("could not parse IPv6 subnet: %w", err)
+		}
+		dc.IPv6Subnet = *ipnet
+		dc.IPv6Subnet.IP = ip
+	}
 	dc.PrivateKey = cfg.PrivateKey
 	dc.ListenPort = cfg.ListenPort
 	dc.DNS = cfg.DNS
 	dc.DNSScriptDir = cfg.DNSScriptDir
+	dc.KeepDefaultRoute = cfg.KeepDefaultRoute
 	dc.Peer = Peer{
 		PublicKey:              cfg.Peer.PublicKey,
 		Endpoint:               peerEndpoint,
@@ -161,6 +185,22 @@ func (dc *DeviceConfig) Encode() string {
 	return res.String()
 }
 
+// EncodeKeyRotation encodes device private key and its peer replacing the current one, other device settings are kept.
+func (dc *DeviceConfig) EncodeKeyRotation() string {
+	var res strings.Builder
+	keyBytes, err := base64.StdEncoding.DecodeString(dc.PrivateKey)
+	if err != nil {
+		log.Err(err).Msg("Could not decode device private key. Will use empty config.")
+		return ""
+	}
+	hexKey := hex.EncodeToString(keyBytes)
+
+	res.WriteString(fmt.Sprintf("private_key=%s\n", hexKey))
+	res.WriteString("replace_peers=true\n")
+	res.WriteString(dc.Peer.Encode())
+	return res.String()
+}
+
 // Peer represents wireguard peer.
 type Peer struct {
 	PublicKey              string       `json:"public_key"`
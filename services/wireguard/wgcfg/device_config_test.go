@@ -73,6 +73,25 @@ persistent_keepalive_interval=0
 	}
 }
 
+func TestDeviceConfig_EncodeKeyRotation(t *testing.T) {
+	config := DeviceConfig{
+		PrivateKey: "DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=",
+		ListenPort: 53511,
+		Peer: Peer{
+			PublicKey:              "DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=",
+			AllowedIPs:             []string{"0.0.0.0/0"},
+			KeepAlivePeriodSeconds: 18,
+		},
+	}
+
+	assert.Equal(t, `private_key=0f2c702c9fbe8d53be6b3bacbbbacf127cdd81f9bed1f88e050d464db924dd04
+replace_peers=true
+public_key=0f2c702c9fbe8d53be6b3bacbbbacf127cdd81f9bed1f88e050d464db924dd04
+persistent_keepalive_interval=18
+allowed_ip=0.0.0.0/0
+`, config.EncodeKeyRotation())
+}
+
 func TestDeviceConfig_MarshalJSON(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -84,6 +103,7 @@ func TestDeviceConfig_MarshalJSON(t *testing.T) {
 			config: DeviceConfig{
 				IfaceName:    "myst0",
 				Subnet:       net.IPNet{IP: net.ParseIP("10.0.182.2"), Mask: net.IPv4Mask(255, 255, 255, 0)},
+				IPv6Subnet:   net.IPNet{IP: net.ParseIP("fd00:182::2"), Mask: net.CIDRMask(64, 128)},
 				PrivateKey:   "DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=",
 				ListenPort:   53511,
 				DNS:          []string{"1.1.1.1"},
@@ -95,7 +115,7 @@ func TestDeviceConfig_MarshalJSON(t *testing.T) {
 					KeepAlivePeriodSeconds: 20,
 				},
 			},
-			expected: `{"iface_name":"myst0","subnet":"10.0.182.2/24","private_key":"DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=","listen_port":53511,"dns":["1.1.1.1"],"dns_script_dir":"/etc/resolv.conf","peer":{"public_key":"DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=","endpoint":"182.122.22.19:3233","allowed_i_ps":["192.168.4.10/32","192.168.4.11/32"],"keep_alive_period_seconds":20}}`,
+			expected: `{"iface_name":"myst0","subnet":"10.0.182.2/24","ipv6_subnet":"fd00:182::2/64","private_key":"DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=","listen_port":53511,"dns":["1.1.1.1"],"dns_script_dir":"/etc/resolv.conf","keep_default_route":false,"peer":{"public_key":"DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=","endpoint":"182.122.22.19:3233","allowed_i_ps":["192.168.4.10/32","192.168.4.11/32"],"keep_alive_period_seconds":20}}`,
 		},
 		{
 			name: "Test marshal default values",
@@ -116,7 +136,7 @@ func TestDeviceConfig_MarshalJSON(t *testing.T) {
 					KeepAlivePeriodSeconds: 0,
 				},
 			},
-			expected: `{"iface_name":"","subnet":"\u003cnil\u003e","private_key":"","listen_port":0,"dns":[],"dns_script_dir":"","peer":{"public_key":"","endpoint":"","allowed_i_ps":null,"keep_alive_period_seconds":0}}`,
+			expected: `{"iface_name":"","subnet":"\u003cnil\u003e","private_key":"","listen_port":0,"dns":[],"dns_script_dir":"","keep_default_route":false,"peer":{"public_key":"","endpoint":"","allowed_i_ps":null,"keep_alive_period_seconds":0}}`,
 		},
 	}
 
@@ -137,10 +157,11 @@ func TestDeviceConfig_UnmarshalJSON(t *testing.T) {
 	}{
 		{
 			name:   "Test unmarshal all filled values",
-			config: `{"iface_name":"myst0","subnet":"10.0.182.2/24","private_key":"DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=","listen_port":53511,"peer":{"public_key":"DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=","endpoint":"182.122.22.19:3233","allowed_i_ps":["192.168.4.10/32","192.168.4.11/32"],"keep_alive_period_seconds":20}}`,
+			config: `{"iface_name":"myst0","subnet":"10.0.182.2/24","ipv6_subnet":"fd00:182::2/64","private_key":"DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=","listen_port":53511,"peer":{"public_key":"DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=","endpoint":"182.122.22.19:3233","allowed_i_ps":["192.168.4.10/32","192.168.4.11/32"],"keep_alive_period_seconds":20}}`,
 			expected: DeviceConfig{
 				IfaceName:  "myst0",
 				Subnet:     net.IPNet{IP: net.ParseIP("10.0.182.2"), Mask: net.IPv4Mask(255, 255, 255, 0)},
+				IPv6Subnet: net.IPNet{IP: net.ParseIP("fd00:182::2"), Mask: net.CIDRMask(64, 128)},
 				PrivateKey: "DyxwLJ++jVO+azusu7rPEnzdgfm+0fiOBQ1GTbkk3QQ=",
 				ListenPort: 53511,
 				Peer: Peer{
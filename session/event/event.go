@@ -19,6 +19,7 @@ package event
 
 import (
 	"math/big"
+	"net"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -33,8 +34,16 @@ const (
 	AppTopicDataTransferred = "Session data transferred"
 	// AppTopicTokensEarned is a topic for publish events about tokens earned as a provider.
 	AppTopicTokensEarned = "SessionTokensEarned"
+	// AppTopicSessionNetwork is a topic for publish events about tunnel networks assigned to provided sessions.
+	AppTopicSessionNetwork = "Session network"
 )
 
+// AppEventSessionNetwork announces the tunnel network consumer traffic of the session originates from
+type AppEventSessionNetwork struct {
+	ID      string
+	Network net.IPNet
+}
+
 // AppEventDataTransferred represents the data transfer event
 type AppEventDataTransferred struct {
 	ID       string
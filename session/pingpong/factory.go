@@ -104,6 +104,11 @@ func (pm PaymentMethod) GetRate() market.PaymentRate {
 	return market.PaymentRate{PerByte: pm.Bytes, PerTime: pm.Duration}
 }
 
+// hermesResolver resolves hermes the provider settles with.
+type hermesResolver interface {
+	HermesID(id identity.Identity) common.Address
+}
+
 // InvoiceFactoryCreator returns a payment engine factory.
 func InvoiceFactoryCreator(
 	channel p2p.Channel,
@@ -118,14 +123,15 @@ func InvoiceFactoryCreator(
 	eventBus eventbus.EventBus,
 	proposal market.ServiceProposal,
 	promiseHandler promiseHandler,
-	providersHermes common.Address,
+	providersHermes hermesResolver,
+	sessionKeys *identity.SessionKeys,
 ) func(identity.Identity, identity.Identity, common.Address, string, chan crypto.ExchangeMessage) (service.PaymentEngine, error) {
 	return func(providerID, consumerID identity.Identity, hermesID common.Address, sessionID string, exchangeChan chan crypto.ExchangeMessage) (service.PaymentEngine, error) {
 		timeTracker := session.NewTracker(mbtime.Now)
 		deps := InvoiceTrackerDeps{
 			Proposal:                   proposal,
 			Peer:                       consumerID,
-			PeerInvoiceSender:          NewInvoiceSender(channel),
+			PeerInvoiceSender:          NewInvoiceSender(channel, sessionKeys),
 			InvoiceStorage:             invoiceStorage,
 			TimeTracker:                &timeTracker,
 			ChargePeriod:               balanceSendPeriod,
@@ -134,7 +140,7 @@ func InvoiceFactoryCreator(
 			ExchangeMessageWaitTimeout: promiseTimeout,
 			ProviderID:                 providerID,
 			ConsumersHermesID:          hermesID,
-			ProvidersHermesID:          providersHermes,
+			ProvidersHermesID:          providersHermes.HermesID(providerID),
 			Registry:                   registryAddress,
 			MaxHermesFailureCount:      maxHermesFailureCount,
 			MaxAllowedHermesFee:        maxAllowedHermesFee,
@@ -160,7 +166,7 @@ func ExchangeFactoryFunc(
 	eventBus eventbus.EventBus,
 	dataLeewayMegabytes uint64) func(channel p2p.Channel, consumer, provider identity.Identity, hermes common.Address, proposal market.ServiceProposal) (connection.PaymentIssuer, error) {
 	return func(channel p2p.Channel, consumer, provider identity.Identity, hermes common.Address, proposal market.ServiceProposal) (connection.PaymentIssuer, error) {
-		invoices, err := invoiceReceiver(channel)
+		invoices, err := invoiceReceiver(channel, provider, channel.Capabilities().Has(p2p.CapabilitySignedInvoices))
 		if err != nil {
 			return nil, err
 		}
@@ -183,7 +189,7 @@ func ExchangeFactoryFunc(
 	}
 }
 
-func invoiceReceiver(channel p2p.ChannelHandler) (chan crypto.Invoice, error) {
+func invoiceReceiver(channel p2p.ChannelHandler, provider identity.Identity, requireSignature bool) (chan crypto.Invoice, error) {
 	invoices := make(chan crypto.Invoice)
 
 	channel.Handle(p2p.TopicPaymentInvoice, func(c p2p.Context) error {
@@ -193,6 +199,10 @@ func invoiceReceiver(channel p2p.ChannelHandler) (chan crypto.Invoice, error) {
 		}
 		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicPaymentInvoice, msg.String())
 
+		if err := verifyInvoice(&msg, provider, requireSignature); err != nil {
+			return fmt.Errorf("invalid invoice signature: %w", err)
+		}
+
 		agreementID, ok := new(big.Int).SetString(msg.GetAgreementID(), bigIntBase)
 		if !ok {
 			return fmt.Errorf("could not unmarshal field agreementID of value %v", agreementID)
@@ -101,7 +101,6 @@ type hermesPromiseSettler struct {
 
 // HermesPromiseSettlerConfig configures the hermes promise settler accordingly.
 type HermesPromiseSettlerConfig struct {
-	HermesAddress        common.Address
 	Threshold            float64
 	MaxWaitForSettlement time.Duration
 }
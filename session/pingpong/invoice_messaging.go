@@ -19,8 +19,13 @@ package pingpong
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/p2p"
 	"github.com/mysteriumnetwork/node/pb"
 	"github.com/mysteriumnetwork/payments/crypto"
@@ -34,13 +39,16 @@ type InvoiceRequest struct {
 
 // InvoiceSender is responsible for sending the invoice messages.
 type InvoiceSender struct {
-	ch p2p.ChannelSender
+	ch          p2p.ChannelSender
+	sessionKeys *identity.SessionKeys
 }
 
 // NewInvoiceSender returns a new instance of the invoice sender.
-func NewInvoiceSender(ch p2p.ChannelSender) *InvoiceSender {
+// Invoices are signed with session key of the provider, as consumers require signatures from peers announcing p2p.CapabilitySignedInvoices.
+func NewInvoiceSender(ch p2p.ChannelSender, sessionKeys *identity.SessionKeys) *InvoiceSender {
 	return &InvoiceSender{
-		ch: ch,
+		ch:          ch,
+		sessionKeys: sessionKeys,
 	}
 }
 
@@ -53,9 +61,75 @@ func (is *InvoiceSender) Send(invoice crypto.Invoice) error {
 		Hashlock:       invoice.Hashlock,
 		Provider:       invoice.Provider,
 	}
+	if err := signInvoice(pInvoice, is.sessionKeys); err != nil {
+		return err
+	}
 	log.Debug().Msgf("Sending P2P message to %q: %s", p2p.TopicPaymentInvoice, pInvoice.String())
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	_, err := is.ch.Send(ctx, p2p.TopicPaymentInvoice, p2p.ProtoMessage(pInvoice))
 	return err
 }
+
+// invoiceMessage returns invoice fields covered by the signature.
+func invoiceMessage(invoice *pb.Invoice) []byte {
+	return []byte(strings.Join([]string{
+		invoice.GetAgreementID(),
+		invoice.GetAgreementTotal(),
+		invoice.GetTransactorFee(),
+		invoice.GetHashlock(),
+		invoice.GetProvider(),
+	}, "|"))
+}
+
+// signInvoice signs invoice with session key of the provider, so that consumers can authenticate invoices.
+func signInvoice(invoice *pb.Invoice, sessionKeys *identity.SessionKeys) error {
+	key, err := sessionKeys.Get(identity.FromAddress(invoice.GetProvider()))
+	if err != nil {
+		return fmt.Errorf("could not get session key: %w", err)
+	}
+	signature, err := key.Sign(invoiceMessage(invoice))
+	if err != nil {
+		return fmt.Errorf("could not sign invoice: %w", err)
+	}
+
+	auth := key.Authorization()
+	invoice.Signature = signature.Bytes()
+	invoice.SessionKey = &pb.SessionKeyAuthorization{
+		Identity:   auth.Identity.Address,
+		SessionKey: auth.SessionKey.Hex(),
+		ExpiresAt:  auth.ExpiresAt.Unix(),
+		Signature:  auth.Signature.Bytes(),
+	}
+	return nil
+}
+
+// verifyInvoice checks invoice signature of the provider. Unsigned invoices are accepted only
+// from providers predating session keys, which do not require signature.
+func verifyInvoice(invoice *pb.Invoice, provider identity.Identity, requireSignature bool) error {
+	if len(invoice.GetSignature()) == 0 && invoice.GetSessionKey() == nil {
+		if requireSignature {
+			return errors.New("invoice is not signed")
+		}
+		return nil
+	}
+
+	sk := invoice.GetSessionKey()
+	if sk == nil {
+		return errors.New("invoice signature has no session key authorization")
+	}
+	if identity.FromAddress(sk.GetIdentity()) != provider {
+		return fmt.Errorf("invoice session key is authorized by %s instead of provider %s", sk.GetIdentity(), provider.Address)
+	}
+	if !common.IsHexAddress(sk.GetSessionKey()) {
+		return fmt.Errorf("invalid invoice session key %q", sk.GetSessionKey())
+	}
+
+	auth := identity.SessionKeyAuthorization{
+		Identity:   provider,
+		SessionKey: common.HexToAddress(sk.GetSessionKey()),
+		ExpiresAt:  time.Unix(sk.GetExpiresAt(), 0),
+		Signature:  identity.SignatureBytes(sk.GetSignature()),
+	}
+	return identity.VerifySessionSignature(auth, invoiceMessage(invoice), identity.SignatureBytes(invoice.GetSignature()), time.Now())
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestSessionKeys(t *testing.T) (*identity.SessionKeys, identity.Identity) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+
+	ks := identity.NewKeystoreMemory()
+	account, err := ks.ImportECDSA(key, "")
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Unlock(account, ""))
+
+	signerFactory := func(id identity.Identity) identity.Signer { return identity.NewSigner(ks, id) }
+	return identity.NewSessionKeys(signerFactory, time.Hour), identity.FromAddress(account.Address.Hex())
+}
+
+func Test_InvoiceSignature(t *testing.T) {
+	sessionKeys, provider := newTestSessionKeys(t)
+	invoice := &pb.Invoice{
+		AgreementID:    "1",
+		AgreementTotal: "100",
+		TransactorFee:  "1",
+		Hashlock:       "0xabc",
+		Provider:       provider.Address,
+	}
+
+	assert.NoError(t, verifyInvoice(invoice, provider, false), "unsigned invoice of provider predating session keys should be accepted")
+	assert.Error(t, verifyInvoice(invoice, provider, true), "unsigned invoice of provider announcing signed invoices")
+
+	assert.NoError(t, signInvoice(invoice, sessionKeys))
+	assert.NotEmpty(t, invoice.Signature)
+	assert.Equal(t, provider.Address, invoice.SessionKey.Identity)
+	assert.NoError(t, verifyInvoice(invoice, provider, true))
+
+	assert.Error(t, verifyInvoice(invoice, identity.FromAddress("0x000000000000000000000000000000000000beef"), true), "invoice of other provider")
+
+	tampered := proto.Clone(invoice).(*pb.Invoice)
+	tampered.AgreementTotal = "1000"
+	assert.Error(t, verifyInvoice(tampered, provider, false), "tampered invoice")
+
+	noAuth := proto.Clone(invoice).(*pb.Invoice)
+	noAuth.SessionKey = nil
+	assert.Error(t, verifyInvoice(noAuth, provider, false), "signature without authorization")
+}
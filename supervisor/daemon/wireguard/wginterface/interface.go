@@ -127,13 +127,20 @@ func configureNetwork(cfg wgcfg.DeviceConfig, dnsManager dns.Manager) error {
 	if err := netutil.AssignIP(cfg.IfaceName, cfg.Subnet); err != nil {
 		return fmt.Errorf("failed to assign IP address: %w", err)
 	}
+	if cfg.IPv6Subnet.IP != nil {
+		if err := netutil.AssignIP(cfg.IfaceName, cfg.IPv6Subnet); err != nil {
+			return fmt.Errorf("failed to assign IPv6 address: %w", err)
+		}
+	}
 
 	if cfg.Peer.Endpoint != nil {
 		if err := netutil.ExcludeRoute(cfg.Peer.Endpoint.IP); err != nil {
 			return fmt.Errorf("could not exclude route %s: %w", cfg.Peer.Endpoint.IP.String(), err)
 		}
-		if err := netutil.AddDefaultRoute(cfg.IfaceName); err != nil {
-			return fmt.Errorf("could not add default route for %s: %w", cfg.IfaceName, err)
+		if !cfg.KeepDefaultRoute {
+			if err := netutil.AddDefaultRoute(cfg.IfaceName); err != nil {
+				return fmt.Errorf("could not add default route for %s: %w", cfg.IfaceName, err)
+			}
 		}
 	}
 
@@ -127,6 +127,33 @@ func (client *Client) NewIdentity(passphrase string) (id contract.IdentityRefDTO
 	return id, err
 }
 
+// NewIdentityWithMnemonic creates identity from a new seed phrase, the phrase is returned only once
+func (client *Client) NewIdentityWithMnemonic(passphrase string) (id contract.IdentityMnemonicDTO, err error) {
+	response, err := client.http.Post("identities/mnemonic", contract.IdentityCreateRequest{Passphrase: &passphrase})
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &id)
+	return id, err
+}
+
+// ImportIdentity restores identity from its seed phrase
+func (client *Client) ImportIdentity(mnemonic, passphrase string) (id contract.IdentityRefDTO, err error) {
+	response, err := client.http.Post("identities/import", contract.IdentityImportRequest{
+		Mnemonic:   &mnemonic,
+		Passphrase: &passphrase,
+	})
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &id)
+	return id, err
+}
+
 // CurrentIdentity unlocks and returns the last used, new or first identity
 func (client *Client) CurrentIdentity(identity, passphrase string) (id contract.IdentityRefDTO, err error) {
 	response, err := client.http.Put("identities/current", contract.IdentityCurrentRequest{
@@ -183,6 +210,20 @@ func (client *Client) GetTransactorFees() (contract.FeesDTO, error) {
 	return fees, err
 }
 
+// GetRegistrationQuote returns the current cost of registering the given identity
+func (client *Client) GetRegistrationQuote(address string) (contract.RegistrationQuoteDTO, error) {
+	quote := contract.RegistrationQuoteDTO{}
+
+	res, err := client.http.Get("identities/"+address+"/register/quote", nil)
+	if err != nil {
+		return quote, err
+	}
+	defer res.Body.Close()
+
+	err = parseResponseJSON(res, &quote)
+	return quote, err
+}
+
 // RegisterIdentity registers identity
 func (client *Client) RegisterIdentity(address, beneficiary string, stake, fee *big.Int, token *string) error {
 	payload := contract.IdentityRegisterRequest{
@@ -205,6 +246,44 @@ func (client *Client) RegisterIdentity(address, beneficiary string, stake, fee *
 	return nil
 }
 
+// RegisterIdentitySponsored registers identity with the transaction paid by the sponsor
+func (client *Client) RegisterIdentitySponsored(address, beneficiary, sponsor string) error {
+	payload := contract.IdentitySponsoredRegisterRequest{
+		Beneficiary: beneficiary,
+		Sponsor:     sponsor,
+	}
+
+	response, err := client.http.Post("identities/"+address+"/register/sponsored", payload)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("expected 202 got %v", response.StatusCode)
+	}
+
+	return nil
+}
+
+// GetRegistrationAuthorization returns registration request signed by identity, which third-party payer may submit
+func (client *Client) GetRegistrationAuthorization(address, beneficiary string) (contract.RegistrationAuthorizationDTO, error) {
+	auth := contract.RegistrationAuthorizationDTO{}
+
+	params := url.Values{}
+	if beneficiary != "" {
+		params.Add("beneficiary", beneficiary)
+	}
+	res, err := client.http.Get("identities/"+address+"/register/authorization", params)
+	if err != nil {
+		return auth, err
+	}
+	defer res.Body.Close()
+
+	err = parseResponseJSON(res, &auth)
+	return auth, err
+}
+
 // ConnectionCreate initiates a new connection to a host identified by providerID
 func (client *Client) ConnectionCreate(consumerID, providerID, hermesID, serviceType string, options contract.ConnectOptions) (status contract.ConnectionInfoDTO, err error) {
 	response, err := client.http.Put("connection", contract.ConnectionCreateRequest{
@@ -223,6 +302,24 @@ func (client *Client) ConnectionCreate(consumerID, providerID, hermesID, service
 	return status, err
 }
 
+// ConnectionSwitch switches current connection to the new provider, keeping it alive until the new one is ready
+func (client *Client) ConnectionSwitch(consumerID, providerID, hermesID, serviceType string, options contract.ConnectOptions) (status contract.ConnectionInfoDTO, err error) {
+	response, err := client.http.Post("connection/switch", contract.ConnectionCreateRequest{
+		ConsumerID:     consumerID,
+		ProviderID:     providerID,
+		HermesID:       hermesID,
+		ServiceType:    serviceType,
+		ConnectOptions: options,
+	})
+	if err != nil {
+		return contract.ConnectionInfoDTO{}, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &status)
+	return status, err
+}
+
 // ConnectionDestroy terminates current connection
 func (client *Client) ConnectionDestroy() (err error) {
 	response, err := client.http.Delete("connection", nil)
@@ -234,6 +331,235 @@ func (client *Client) ConnectionDestroy() (err error) {
 	return nil
 }
 
+// ConnectionSplitTunnelUpdate replaces split tunnel rules of current connection
+func (client *Client) ConnectionSplitTunnelUpdate(splitTunnel contract.SplitTunnelDTO) error {
+	response, err := client.http.Put("connection/split-tunnel", splitTunnel)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// ConnectionBandwidthLimitSet changes consumer tunnel speed limit, empty limit removes it
+func (client *Client) ConnectionBandwidthLimitSet(limit contract.BandwidthLimitDTO) error {
+	response, err := client.http.Put("connection/bandwidth-limit", limit)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// ConnectionPause routes traffic around the tunnel of current connection, keeping the session alive
+func (client *Client) ConnectionPause() error {
+	response, err := client.http.Put("connection/pause", nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// ConnectionResume routes traffic back into the tunnel of paused connection
+func (client *Client) ConnectionResume() error {
+	response, err := client.http.Put("connection/resume", nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// ConnectionSessions returns additional consumer sessions kept alive alongside the main connection
+func (client *Client) ConnectionSessions() ([]contract.ConnectionSessionDTO, error) {
+	response, err := client.http.Get("connection/sessions", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var sessions contract.ListConnectionSessionsResponse
+	err = parseResponseJSON(response, &sessions)
+	return sessions.Sessions, err
+}
+
+// ConnectionSessionCreate starts additional consumer session with the given name
+func (client *Client) ConnectionSessionCreate(name, consumerID, providerID, hermesID, serviceType string, options contract.ConnectOptions) (session contract.ConnectionSessionDTO, err error) {
+	response, err := client.http.Put("connection/sessions/"+url.PathEscape(name), contract.ConnectionCreateRequest{
+		ConsumerID:     consumerID,
+		ProviderID:     providerID,
+		HermesID:       hermesID,
+		ServiceType:    serviceType,
+		ConnectOptions: options,
+	})
+	if err != nil {
+		return contract.ConnectionSessionDTO{}, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &session)
+	return session, err
+}
+
+// ConnectionSessionDestroy terminates additional consumer session with the given name
+func (client *Client) ConnectionSessionDestroy(name string) error {
+	response, err := client.http.Delete("connection/sessions/"+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// Profiles returns saved connection profiles
+func (client *Client) Profiles() ([]contract.ProfileDTO, error) {
+	response, err := client.http.Get("profiles", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var profiles contract.ListProfilesResponse
+	err = parseResponseJSON(response, &profiles)
+	return profiles.Profiles, err
+}
+
+// ProfileSave creates or replaces connection profile
+func (client *Client) ProfileSave(p contract.ProfileDTO) error {
+	response, err := client.http.Put("profiles/"+url.PathEscape(p.Name), p)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// ProfileDelete removes connection profile
+func (client *Client) ProfileDelete(name string) error {
+	response, err := client.http.Delete("profiles/"+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// ConsumerACL returns provider allow and block lists of consumer identities
+func (client *Client) ConsumerACL() ([]contract.ConsumerACLEntryDTO, error) {
+	response, err := client.http.Get("acl", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var entries contract.ListConsumerACLResponse
+	err = parseResponseJSON(response, &entries)
+	return entries.Entries, err
+}
+
+// ConsumerACLSave puts consumer identity into provider allow or block list
+func (client *Client) ConsumerACLSave(e contract.ConsumerACLEntryDTO) error {
+	response, err := client.http.Put("acl/"+url.PathEscape(e.Identity), e)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// ConsumerACLDelete removes consumer identity from provider allow or block list
+func (client *Client) ConsumerACLDelete(consumerID string) error {
+	response, err := client.http.Delete("acl/"+url.PathEscape(consumerID), nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// ProfileConnect starts new connection configured by the profile
+func (client *Client) ProfileConnect(name, consumerID, hermesID string) (status contract.ConnectionInfoDTO, err error) {
+	response, err := client.http.Put("profiles/"+url.PathEscape(name)+"/connection", contract.ProfileConnectRequest{
+		ConsumerID: consumerID,
+		HermesID:   hermesID,
+	})
+	if err != nil {
+		return contract.ConnectionInfoDTO{}, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &status)
+	return status, err
+}
+
+// Schedules returns connect and disconnect schedules
+func (client *Client) Schedules() ([]contract.ScheduleDTO, error) {
+	response, err := client.http.Get("schedules", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var schedules contract.ListSchedulesResponse
+	err = parseResponseJSON(response, &schedules)
+	return schedules.Schedules, err
+}
+
+// ScheduleSave creates or replaces connect and disconnect schedule
+func (client *Client) ScheduleSave(s contract.ScheduleDTO) error {
+	response, err := client.http.Put("schedules/"+url.PathEscape(s.Name), s)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// ScheduleDelete removes connect and disconnect schedule
+func (client *Client) ScheduleDelete(name string) error {
+	response, err := client.http.Delete("schedules/"+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// SelectionPolicy returns provider selection policy
+func (client *Client) SelectionPolicy() (policy contract.SelectionPolicyDTO, err error) {
+	response, err := client.http.Get("selection/policy", url.Values{})
+	if err != nil {
+		return policy, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &policy)
+	return policy, err
+}
+
+// SetSelectionPolicy replaces provider selection policy
+func (client *Client) SetSelectionPolicy(policy contract.SelectionPolicyDTO) error {
+	response, err := client.http.Put("selection/policy", policy)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
 // ConnectionStatistics returns statistics about current connection
 func (client *Client) ConnectionStatistics() (statistics contract.ConnectionStatisticsDTO, err error) {
 	response, err := client.http.Get("connection/statistics", url.Values{})
@@ -318,6 +644,65 @@ func (client *Client) Proposals() ([]contract.ProposalDTO, error) {
 	return client.proposals(url.Values{})
 }
 
+// ProposalsBySavedFilter returns proposals narrowed by the named saved filter
+func (client *Client) ProposalsBySavedFilter(name string) ([]contract.ProposalDTO, error) {
+	queryParams := url.Values{}
+	queryParams.Add("filter", name)
+	return client.proposals(queryParams)
+}
+
+// ProposalCounts returns numbers of proposals grouped by country and service type
+func (client *Client) ProposalCounts(serviceType string) ([]contract.ProposalCountDTO, error) {
+	queryParams := url.Values{}
+	if serviceType != "" {
+		queryParams.Add("service_type", serviceType)
+	}
+	response, err := client.http.Get("proposals/counts", queryParams)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var counts contract.ProposalCountsResponse
+	err = parseResponseJSON(response, &counts)
+	return counts.Counts, err
+}
+
+// SavedFilters returns saved proposal filters
+func (client *Client) SavedFilters() ([]contract.SavedFilterDTO, error) {
+	response, err := client.http.Get("proposals/filters", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var filters contract.ListSavedFiltersResponse
+	err = parseResponseJSON(response, &filters)
+	return filters.Filters, err
+}
+
+// SaveFilter creates or replaces the named proposal filter
+func (client *Client) SaveFilter(name string, filter contract.SavedFilterRequest) error {
+	response, err := client.http.Put("proposals/filters/"+url.PathEscape(name), filter)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// DeleteFilter deletes the named proposal filter
+func (client *Client) DeleteFilter(name string) error {
+	response, err := client.http.Delete("proposals/filters/"+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
 func (client *Client) proposals(query url.Values) ([]contract.ProposalDTO, error) {
 	response, err := client.http.Get("proposals", query)
 	if err != nil {
@@ -353,6 +738,52 @@ func (client *Client) Unlock(identity, passphrase string) error {
 	return nil
 }
 
+// ChangePassphrase re-encrypts identity with the new passphrase
+func (client *Client) ChangePassphrase(identity, passphrase, newPassphrase string) error {
+	path := fmt.Sprintf("identities/%s/passphrase", identity)
+
+	response, err := client.http.Put(path, contract.IdentityPassphraseChangeRequest{
+		CurrentPassphrase: &passphrase,
+		NewPassphrase:     &newPassphrase,
+	})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// GetPaymentConfig returns hermes the identity pays and settles with by default
+func (client *Client) GetPaymentConfig(identity string) (contract.IdentityPaymentConfigDTO, error) {
+	paymentConfig := contract.IdentityPaymentConfigDTO{}
+
+	response, err := client.http.Get(fmt.Sprintf("identities/%s/payment-config", identity), nil)
+	if err != nil {
+		return paymentConfig, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &paymentConfig)
+	return paymentConfig, err
+}
+
+// SetPaymentConfig stores preferred hermes of the identity, empty value resets it to node default
+func (client *Client) SetPaymentConfig(identity, hermesID string) (contract.IdentityPaymentConfigDTO, error) {
+	paymentConfig := contract.IdentityPaymentConfigDTO{}
+
+	response, err := client.http.Put(fmt.Sprintf("identities/%s/payment-config", identity), contract.IdentityPaymentConfigRequest{
+		HermesID: hermesID,
+	})
+	if err != nil {
+		return paymentConfig, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &paymentConfig)
+	return paymentConfig, err
+}
+
 // Payout registers payout address for identity
 func (client *Client) Payout(identity, ethAddress string) error {
 	path := fmt.Sprintf("identities/%s/payout", identity)
@@ -457,6 +888,61 @@ func (client *Client) ServiceStop(id string) error {
 	return nil
 }
 
+// ServiceUpdate changes options and access policies of the running service instance by the requested id.
+func (client *Client) ServiceUpdate(id string, request contract.ServiceUpdateRequest) (service contract.ServiceInfoDTO, err error) {
+	response, err := client.http.Put(fmt.Sprintf("services/%s", id), request)
+	if err != nil {
+		return service, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &service)
+	return service, err
+}
+
+// ServicePaymentMethodUpdate changes prices of the running service instance by the requested id.
+func (client *Client) ServicePaymentMethodUpdate(id string, pm contract.ServicePaymentMethod) (service contract.ServiceInfoDTO, err error) {
+	response, err := client.http.Put(fmt.Sprintf("services/%s/payment-method", id), pm)
+	if err != nil {
+		return service, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &service)
+	return service, err
+}
+
+// ServiceStats returns hourly statistics of the service instance within the given dates, formatted e.g. 2020-07-30.
+func (client *Client) ServiceStats(id, dateFrom, dateTo string) (stats contract.ServiceStatsResponse, err error) {
+	params := url.Values{}
+	if dateFrom != "" {
+		params.Set("date_from", dateFrom)
+	}
+	if dateTo != "" {
+		params.Set("date_to", dateTo)
+	}
+	response, err := client.http.Get(fmt.Sprintf("services/%s/stats", id), params)
+	if err != nil {
+		return stats, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &stats)
+	return stats, err
+}
+
+// ServiceSessionTerminate terminates a single session of the running service instance.
+func (client *Client) ServiceSessionTerminate(id, sessionID string) error {
+	path := fmt.Sprintf("services/%s/sessions/%s", id, sessionID)
+	response, err := client.http.Delete(path, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
 // NATStatus returns status of NAT traversal
 func (client *Client) NATStatus() (status contract.NATStatusDTO, err error) {
 	response, err := client.http.Get("nat/status", nil)
@@ -469,6 +955,30 @@ func (client *Client) NATStatus() (status contract.NATStatusDTO, err error) {
 	return status, err
 }
 
+// P2PDiagnostics returns reports of the last p2p channel establishments
+func (client *Client) P2PDiagnostics() (diagnostics contract.P2PDiagnosticsResponse, err error) {
+	response, err := client.http.Get("nat/p2p-diagnostics", nil)
+	if err != nil {
+		return diagnostics, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &diagnostics)
+	return diagnostics, err
+}
+
+// NATPortMappings returns port mappings held on the router
+func (client *Client) NATPortMappings() (mappings contract.NATPortMappingsResponse, err error) {
+	response, err := client.http.Get("nat/port-mappings", nil)
+	if err != nil {
+		return mappings, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &mappings)
+	return mappings, err
+}
+
 // filterSessionsByType removes all sessions of irrelevant types
 func filterSessionsByType(serviceType string, sessions contract.SessionListResponse) contract.SessionListResponse {
 	matches := 0
@@ -0,0 +1,70 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package contract
+
+import (
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/acl"
+)
+
+// ConsumerACLEntryDTO puts consumer identity into provider allow or block list
+// swagger:model ConsumerACLEntryDTO
+type ConsumerACLEntryDTO struct {
+	// consumer identity, taken from the path when saving
+	// example: 0x0000000000000000000000000000000000000001
+	Identity string `json:"identity"`
+	// list consumer belongs to, once any consumer is allowed all the others are rejected
+	// required: true
+	// example: block
+	List acl.List `json:"list"`
+	// expiration time of temporary entries, e.g. automatic bans of abusive consumers
+	// example: 2020-11-04T10:00:00Z
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// NewConsumerACLEntryDTO maps access control entry to DTO
+func NewConsumerACLEntryDTO(e acl.Entry) ConsumerACLEntryDTO {
+	dto := ConsumerACLEntryDTO{
+		Identity: e.Identity,
+		List:     e.List,
+	}
+	if !e.Expires.IsZero() {
+		expires := e.Expires.UTC()
+		dto.Expires = &expires
+	}
+	return dto
+}
+
+// ToEntry converts DTO to access control entry
+func (dto ConsumerACLEntryDTO) ToEntry() acl.Entry {
+	e := acl.Entry{
+		Identity: dto.Identity,
+		List:     dto.List,
+	}
+	if dto.Expires != nil {
+		e.Expires = *dto.Expires
+	}
+	return e
+}
+
+// ListConsumerACLResponse holds provider allow and block lists of consumer identities
+// swagger:model ListConsumerACLResponse
+type ListConsumerACLResponse struct {
+	Entries []ConsumerACLEntryDTO `json:"entries"`
+}
@@ -18,7 +18,9 @@
 package contract
 
 import (
+	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/mysteriumnetwork/node/consumer/bandwidth"
@@ -35,12 +37,40 @@ var emptyAddress = common.Address{}
 func NewConnectionInfoDTO(session connectionstate.Status) ConnectionInfoDTO {
 	response := ConnectionInfoDTO{
 		Status:     string(session.State),
+		Phase:      string(session.Phase),
 		ConsumerID: session.ConsumerID.Address,
 		SessionID:  string(session.SessionID),
+
+		DNSLeakDetected: session.DNSLeakDetected,
+		Paused:          session.Paused,
+		MTU:             session.MTU,
+		Relayed:         session.Relayed,
+
+		DisconnectReason: string(session.DisconnectReason),
 	}
 	if session.HermesID != emptyAddress {
 		response.HermesID = session.HermesID.Hex()
 	}
+	if session.FailoverFrom.Address != "" {
+		response.FailoverFrom = session.FailoverFrom.Address
+	}
+	if session.EntryProposal != nil {
+		entryProposalRes := NewProposalDTO(*session.EntryProposal)
+		response.EntryProposal = &entryProposalRes
+	}
+	for _, probe := range session.Probes {
+		response.Probes = append(response.Probes, ProbeResultDTO{
+			ProviderID: probe.ProviderID,
+			Latency:    int(probe.Latency.Milliseconds()),
+			Error:      probe.Error,
+		})
+	}
+	for _, phase := range session.Phases {
+		response.Phases = append(response.Phases, ConnectionPhaseDTO{
+			Phase:     string(phase.Phase),
+			StartedAt: phase.StartedAt.Format(time.RFC3339Nano),
+		})
+	}
 	// None exists, for not started connection
 	if session.Proposal.ProviderID != "" {
 		proposalRes := NewProposalDTO(session.Proposal)
@@ -55,6 +85,13 @@ type ConnectionInfoDTO struct {
 	// example: Connected
 	Status string `json:"status"`
 
+	// latest phase of establishing the connection, more detailed than status while connecting
+	// example: NegotiatingSession
+	Phase string `json:"phase,omitempty"`
+
+	// phases the connection went through, in order
+	Phases []ConnectionPhaseDTO `json:"phases,omitempty"`
+
 	// example: 0x00
 	ConsumerID string `json:"consumer_id,omitempty"`
 
@@ -66,15 +103,72 @@ type ConnectionInfoDTO struct {
 
 	// example: 4cfb0324-daf6-4ad8-448b-e61fe0a1f918
 	SessionID string `json:"session_id,omitempty"`
+
+	// provider which failed and was substituted by the current one
+	// example: 0x00
+	FailoverFrom string `json:"failover_from,omitempty"`
+
+	// entry hop of multi-hop connection
+	EntryProposal *ProposalDTO `json:"entry_proposal,omitempty"`
+
+	// set when DNS queries were found to bypass the tunnel
+	// example: false
+	DNSLeakDetected bool `json:"dns_leak_detected,omitempty"`
+
+	// set while traffic is routed around the tunnel of paused connection
+	// example: false
+	Paused bool `json:"paused,omitempty"`
+
+	// why the last connection was closed, kept until the next connection.
+	// Possible values are "UserRequested", "Reconnect", "PaymentFailed", "ProviderGone", "ProviderShutdown", "KillSwitch", "ConnectionLost",
+	// "DNSLeakDetected", "DataCapReached", "SpendCapReached" and "Idle"
+	// example: UserRequested
+	DisconnectReason string `json:"disconnect_reason,omitempty"`
+
+	// MTU of the tunnel interface, if set by connect options or lowered after probing path to the provider
+	// example: 1400
+	MTU int `json:"mtu,omitempty"`
+
+	// results of probing candidate providers before connecting
+	Probes []ProbeResultDTO `json:"probes,omitempty"`
+
+	// set when traffic goes through p2p relay server since provider could not be reached directly
+	// example: false
+	Relayed bool `json:"relayed,omitempty"`
+}
+
+// ConnectionPhaseDTO holds the time connection entered the phase at.
+// swagger:model ConnectionPhaseDTO
+type ConnectionPhaseDTO struct {
+	// example: DialingP2P
+	Phase string `json:"phase"`
+
+	// example: 2020-07-01T10:11:12.123Z
+	StartedAt string `json:"started_at"`
+}
+
+// ProbeResultDTO holds outcome of probing a candidate provider before connecting.
+// swagger:model ProbeResultDTO
+type ProbeResultDTO struct {
+	// example: 0x0000000000000000000000000000000000000002
+	ProviderID string `json:"provider_id"`
+
+	// round trip time of the probe in milliseconds
+	// example: 45
+	Latency int `json:"latency,omitempty"`
+
+	// set when provider did not respond to the probe
+	// example: p2p dialer failed
+	Error string `json:"error,omitempty"`
 }
 
 // NewConnectionDTO maps to API connection.
-func NewConnectionDTO(session connectionstate.Status, statistics connectionstate.Statistics, throughput bandwidth.Throughput, invoice crypto.Invoice) ConnectionDTO {
+func NewConnectionDTO(session connectionstate.Status, statistics connectionstate.Statistics, throughput bandwidth.Throughput, invoice crypto.Invoice, health connectionstate.Health) ConnectionDTO {
 	dto := ConnectionDTO{
 		ConnectionInfoDTO: NewConnectionInfoDTO(session),
 	}
 	if !statistics.At.IsZero() {
-		statsDto := NewConnectionStatisticsDTO(session, statistics, throughput, invoice)
+		statsDto := NewConnectionStatisticsDTO(session, statistics, throughput, invoice, health)
 		dto.Statistics = &statsDto
 	}
 	return dto
@@ -88,12 +182,12 @@ type ConnectionDTO struct {
 }
 
 // NewConnectionStatisticsDTO maps to API connection stats.
-func NewConnectionStatisticsDTO(session connectionstate.Status, statistics connectionstate.Statistics, throughput bandwidth.Throughput, invoice crypto.Invoice) ConnectionStatisticsDTO {
+func NewConnectionStatisticsDTO(session connectionstate.Status, statistics connectionstate.Statistics, throughput bandwidth.Throughput, invoice crypto.Invoice, health connectionstate.Health) ConnectionStatisticsDTO {
 	agreementTotal := new(big.Int)
 	if invoice.AgreementTotal != nil {
 		agreementTotal = invoice.AgreementTotal
 	}
-	return ConnectionStatisticsDTO{
+	dto := ConnectionStatisticsDTO{
 		Duration:           int(session.Duration().Seconds()),
 		BytesSent:          statistics.BytesSent,
 		BytesReceived:      statistics.BytesReceived,
@@ -101,6 +195,30 @@ func NewConnectionStatisticsDTO(session connectionstate.Status, statistics conne
 		ThroughputReceived: datasize.BitSize(throughput.Down).Bits(),
 		TokensSpent:        agreementTotal,
 	}
+	if health.Samples > 0 {
+		dto.Health = &ConnectionHealthDTO{
+			RTT:        int(health.RTT.Milliseconds()),
+			PacketLoss: health.PacketLoss,
+			Samples:    health.Samples,
+		}
+	}
+	return dto
+}
+
+// ConnectionHealthDTO holds tunnel health measured by pinging the provider through the tunnel.
+// swagger:model ConnectionHealthDTO
+type ConnectionHealthDTO struct {
+	// average round trip time in milliseconds
+	// example: 45
+	RTT int `json:"rtt"`
+
+	// ratio of lost pings, from 0 to 1
+	// example: 0.1
+	PacketLoss float64 `json:"packet_loss"`
+
+	// count of pings measurement is based on
+	// example: 30
+	Samples int `json:"samples"`
 }
 
 // ConnectionStatisticsDTO holds consumer connection statistics.
@@ -126,6 +244,9 @@ type ConnectionStatisticsDTO struct {
 
 	// example: 500000
 	TokensSpent *big.Int `json:"tokens_spent"`
+
+	// tunnel health, present once provider was pinged through the tunnel
+	Health *ConnectionHealthDTO `json:"health,omitempty"`
 }
 
 // ConnectionCreateRequest request used to start a connection.
@@ -141,16 +262,27 @@ type ConnectionCreateRequest struct {
 	// example: 0x0000000000000000000000000000000000000002
 	ProviderID string `json:"provider_id"`
 
-	// hermes identity
+	// hermes identity, preferred hermes of consumer identity is used if omitted
 	// example: 0x0000000000000000000000000000000000000003
 	HermesID string `json:"hermes_id"`
 
-	// service type. Possible values are "openvpn", "wireguard" and "noop"
+	// service type. Possible values are "openvpn", "wireguard", "http-proxy", "shadowsocks" and "noop"
 	// required: false
 	// default: openvpn
 	// example: openvpn
 	ServiceType string `json:"service_type"`
 
+	// serial number of the provider's service of the given type, for providers running several of them
+	// required: false
+	// default: 1
+	// example: 2
+	ProposalID int `json:"proposal_id,omitempty"`
+
+	// fallback provider identities, tried in order if connection to the provider fails
+	// required: false
+	// example: ["0x0000000000000000000000000000000000000004"]
+	FallbackProviderIDs []string `json:"fallback_provider_ids,omitempty"`
+
 	// connect options
 	// required: false
 	ConnectOptions ConnectOptions `json:"connect_options,omitempty"`
@@ -165,6 +297,26 @@ func (cr ConnectionCreateRequest) Validate() *validation.FieldErrorMap {
 	if len(cr.ProviderID) == 0 {
 		errs.ForField("provider_id").AddError("required", "Field is required")
 	}
+	if cr.ConnectOptions.DataCap != nil {
+		if err := cr.ConnectOptions.DataCap.ToDataCap().Validate(); err != nil {
+			errs.ForField("connect_options.data_cap.period").AddError("invalid", err.Error())
+		}
+	}
+	if cr.ConnectOptions.Retry != nil {
+		if err := cr.ConnectOptions.Retry.ToRetryPolicy().Validate(); err != nil {
+			errs.ForField("connect_options.retry").AddError("invalid", err.Error())
+		}
+	}
+	if cr.ConnectOptions.PolicyRouting != nil {
+		if cr.ConnectOptions.PolicyRouting.FwMark == 0 {
+			errs.ForField("connect_options.policy_routing.fw_mark").AddError("required", "Field is required")
+		} else if err := cr.ConnectOptions.PolicyRouting.ToPolicyRouting().Validate(); err != nil {
+			errs.ForField("connect_options.policy_routing").AddError("invalid", err.Error())
+		}
+	}
+	if mtu := cr.ConnectOptions.MTU; mtu != 0 && (mtu < connection.MinMTU || mtu > connection.MaxMTU) {
+		errs.ForField("connect_options.mtu").AddError("invalid", fmt.Sprintf("MTU should be between %d and %d", connection.MinMTU, connection.MaxMTU))
+	}
 	return errs
 }
 
@@ -175,9 +327,259 @@ type ConnectOptions struct {
 	// required: false
 	// example: true
 	DisableKillSwitch bool `json:"kill_switch"`
+	// kill switch scope, ignored when kill switch is disabled
+	// required: false
+	KillSwitchScope *KillSwitchScopeDTO `json:"kill_switch_scope,omitempty"`
 	// DNS to use
 	// required: false
 	// default: auto
 	// example: auto, provider, system, "1.1.1.1,8.8.8.8"
 	DNS connection.DNSOption `json:"dns"`
+	// entry provider identity, makes connection multi-hop with traffic entering through the entry provider
+	// required: false
+	// example: 0x0000000000000000000000000000000000000005
+	EntryProviderID string `json:"entry_provider_id,omitempty"`
+	// split tunnel rules selecting traffic which bypasses or exclusively uses the tunnel
+	// required: false
+	SplitTunnel *SplitTunnelDTO `json:"split_tunnel,omitempty"`
+	// disconnect when DNS queries are found to bypass the tunnel
+	// required: false
+	// example: false
+	DisconnectOnDNSLeak bool `json:"disconnect_on_dns_leak,omitempty"`
+	// data cap, connection is closed once it is reached
+	// required: false
+	DataCap *DataCapDTO `json:"data_cap,omitempty"`
+	// retry policy of failed connect attempts, replaces node retry policy for this connection
+	// required: false
+	Retry *ConnectRetryDTO `json:"retry,omitempty"`
+	// count of candidate providers (the provider and its fallbacks) to probe before connecting to the fastest one,
+	// replaces node setting for this connection, values below 2 disable probing
+	// required: false
+	// example: 3
+	ProbeCandidates int `json:"probe_candidates,omitempty"`
+	// installs the tunnel into a dedicated routing table, so that only marked traffic uses it (Linux only)
+	// required: false
+	PolicyRouting *PolicyRoutingDTO `json:"policy_routing,omitempty"`
+	// MTU of the tunnel interface, path to the provider is probed after connecting and MTU is lowered if needed when omitted
+	// required: false
+	// example: 1400
+	MTU int `json:"mtu,omitempty"`
+}
+
+// PolicyRoutingDTO holds policy routing options, default route of the host is left untouched
+// swagger:model PolicyRoutingDTO
+type PolicyRoutingDTO struct {
+	// firewall mark of the traffic routed through the tunnel
+	// required: true
+	// example: 51820
+	FwMark uint32 `json:"fw_mark"`
+	// routing table holding the tunnel route, defaults to the firewall mark
+	// required: false
+	// example: 100
+	Table int `json:"table,omitempty"`
+}
+
+// ToPolicyRouting maps DTO to policy routing options
+func (dto PolicyRoutingDTO) ToPolicyRouting() connection.PolicyRouting {
+	return connection.PolicyRouting{
+		FwMark: dto.FwMark,
+		Table:  dto.Table,
+	}
+}
+
+// ConnectRetryDTO holds retry policy of failed connect attempts
+// swagger:model ConnectRetryDTO
+type ConnectRetryDTO struct {
+	// count of connect attempts to each provider, 1 disables retries
+	// required: true
+	// example: 3
+	Attempts int `json:"attempts"`
+	// delay in seconds before the second attempt, doubled before each next attempt
+	// required: false
+	// example: 2
+	Backoff float64 `json:"backoff,omitempty"`
+	// fraction of the delay to randomize it by, from 0 to 1
+	// required: false
+	// example: 0.2
+	Jitter float64 `json:"jitter,omitempty"`
+}
+
+// ToRetryPolicy maps DTO to connect retry policy
+func (dto ConnectRetryDTO) ToRetryPolicy() connection.RetryPolicy {
+	return connection.RetryPolicy{
+		Attempts: dto.Attempts,
+		Backoff:  time.Duration(dto.Backoff * float64(time.Second)),
+		Jitter:   dto.Jitter,
+	}
+}
+
+// DataCapDTO holds data cap of the connection
+// swagger:model DataCapDTO
+type DataCapDTO struct {
+	// sent and received data limit in megabytes
+	// required: true
+	// example: 1024
+	Megabytes uint64 `json:"megabytes"`
+	// "connection" counts data of this connection only, "day" counts data of all connections started since midnight
+	// required: false
+	// default: connection
+	// example: day
+	Period string `json:"period,omitempty"`
+}
+
+// DataCapWarningDTO is sent when connection data usage crosses a warning threshold of the data cap
+// swagger:model DataCapWarningDTO
+type DataCapWarningDTO struct {
+	// example: 4cfb0324-daf6-4ad8-448b-e61fe0a1f918
+	SessionID string `json:"session_id"`
+	// bytes counted towards the data cap
+	// example: 858993459
+	Used uint64 `json:"used"`
+	// data cap in bytes
+	// example: 1073741824
+	Limit uint64 `json:"limit"`
+	// crossed warning threshold
+	// example: 80
+	Percent int `json:"percent"`
+}
+
+// DNSLeakDTO is sent when DNS queries of the connection are found bypassing the tunnel
+// swagger:model DNSLeakDTO
+type DNSLeakDTO struct {
+	// example: 4cfb0324-daf6-4ad8-448b-e61fe0a1f918
+	SessionID string `json:"session_id"`
+	// addresses of DNS resolvers which queried the canary domain
+	// example: ["192.0.2.53"]
+	Resolvers []string `json:"resolvers"`
+}
+
+// AutoSwitchDTO is sent when connection is switched to another provider because of degraded tunnel health
+// swagger:model AutoSwitchDTO
+type AutoSwitchDTO struct {
+	// example: 0x0000000000000000000000000000000000000001
+	FromProviderID string `json:"from_provider_id"`
+	// example: 0x0000000000000000000000000000000000000002
+	ToProviderID string `json:"to_provider_id"`
+	// exceeded threshold
+	// example: PacketLoss, Latency
+	Reason string `json:"reason"`
+	// tunnel health which triggered the switch
+	Health ConnectionHealthDTO `json:"health"`
+	// seconds tunnel health stayed degraded
+	// example: 180
+	Degraded int `json:"degraded"`
+}
+
+// ToDataCap converts DTO to connection data cap
+func (dto DataCapDTO) ToDataCap() connection.DataCap {
+	return connection.DataCap{
+		Bytes:  (datasize.MiB * datasize.BitSize(dto.Megabytes)).Bytes(),
+		Period: connection.DataCapPeriod(dto.Period),
+	}
+}
+
+// KillSwitchScopeDTO holds kill switch scope options
+// swagger:model KillSwitchScopeDTO
+type KillSwitchScopeDTO struct {
+	// "on-disconnect" lifts traffic block after disconnect, "always" keeps traffic blocked until the node is stopped,
+	// exceptions of the connection are lifted on disconnect in both modes
+	// required: false
+	// default: on-disconnect
+	// example: on-disconnect
+	Mode string `json:"mode,omitempty"`
+	// allow traffic to local networks
+	// required: false
+	// example: true
+	AllowLAN bool `json:"allow_lan,omitempty"`
+	// destination ports allowed outside of the tunnel, supported on Linux only and rejected on other platforms
+	// required: false
+	// example: [22]
+	AllowedPorts []int `json:"allowed_ports,omitempty"`
+}
+
+// ToKillSwitchOptions converts DTO to connection kill switch options
+func (dto KillSwitchScopeDTO) ToKillSwitchOptions() connection.KillSwitchOptions {
+	return connection.KillSwitchOptions{
+		Mode:         connection.KillSwitchMode(dto.Mode),
+		AllowLAN:     dto.AllowLAN,
+		AllowedPorts: dto.AllowedPorts,
+	}
+}
+
+// SplitTunnelDTO holds split tunnel rules
+// swagger:model SplitTunnelDTO
+type SplitTunnelDTO struct {
+	// "bypass" routes matching traffic outside of the tunnel, "exclusive" routes only matching traffic through the tunnel.
+	// Exclusive mode keeps the default route and disables the kill switch, it can not be switched while connected.
+	// required: true
+	// example: bypass
+	Mode string `json:"mode"`
+	// networks in CIDR notation, IPv4 prefix must be at least /2
+	// required: false
+	// example: ["192.168.0.0/16"]
+	Networks []string `json:"networks,omitempty"`
+	// domains, resolved to addresses when rules are applied
+	// required: false
+	// example: ["example.com"]
+	Domains []string `json:"domains,omitempty"`
+	// process names, per application split tunneling is not supported yet and requests with apps are rejected
+	// required: false
+	// example: ["firefox"]
+	Apps []string `json:"apps,omitempty"`
+}
+
+// BandwidthLimitDTO holds consumer tunnel speed limit
+// swagger:model BandwidthLimitDTO
+type BandwidthLimitDTO struct {
+	// speed limit with unit, empty value removes the limit
+	// required: false
+	// example: 10mbps
+	MaxBandwidth string `json:"max_bandwidth"`
+}
+
+// ToBitSpeed parses speed limit, empty value means unlimited
+func (dto BandwidthLimitDTO) ToBitSpeed() (datasize.BitSpeed, error) {
+	if dto.MaxBandwidth == "" {
+		return 0, nil
+	}
+	return datasize.ParseBitSpeed(dto.MaxBandwidth)
+}
+
+// ToSplitTunnel converts DTO to connection split tunnel rules
+func (dto SplitTunnelDTO) ToSplitTunnel() connection.SplitTunnel {
+	return connection.SplitTunnel{
+		Mode:     connection.SplitTunnelMode(dto.Mode),
+		Networks: dto.Networks,
+		Domains:  dto.Domains,
+		Apps:     dto.Apps,
+	}
+}
+
+// ConnectionSessionDTO holds details of additional consumer session kept alive alongside the main connection.
+// swagger:model ConnectionSessionDTO
+type ConnectionSessionDTO struct {
+	// additional session name
+	// example: streaming
+	Name string `json:"name"`
+
+	// tunnel network interface carrying traffic of the session
+	// example: myst1
+	InterfaceName string `json:"interface_name,omitempty"`
+
+	ConnectionDTO
+}
+
+// NewConnectionSessionDTO maps additional session status to DTO.
+func NewConnectionSessionDTO(name string, status connection.SessionStatus) ConnectionSessionDTO {
+	return ConnectionSessionDTO{
+		Name:          name,
+		InterfaceName: status.Status.InterfaceName,
+		ConnectionDTO: NewConnectionDTO(status.Status, status.Statistics, bandwidth.Throughput{}, status.Invoice, connectionstate.Health{}),
+	}
+}
+
+// ListConnectionSessionsResponse holds list of additional consumer sessions.
+// swagger:model ListConnectionSessionsResponse
+type ListConnectionSessionsResponse struct {
+	Sessions []ConnectionSessionDTO `json:"sessions"`
 }
@@ -17,6 +17,8 @@
 
 package contract
 
+import "github.com/mysteriumnetwork/node/core/discovery/apidiscovery"
+
 // HealthCheckDTO holds API healthcheck.
 // swagger:model HealthCheckDTO
 type HealthCheckDTO struct {
@@ -29,6 +31,41 @@ type HealthCheckDTO struct {
 	// example: 0.0.6
 	Version   string       `json:"version"`
 	BuildInfo BuildInfoDTO `json:"build_info"`
+
+	// present only when discovery API fallback addresses are configured
+	Discovery *DiscoveryHealthDTO `json:"discovery,omitempty"`
+}
+
+// DiscoveryHealthDTO holds health of discovery API endpoints.
+// swagger:model DiscoveryHealthDTO
+type DiscoveryHealthDTO struct {
+	// example: https://testnet2-api.mysterium.network/v1/
+	ActiveEndpoint string `json:"active_endpoint"`
+
+	// discovery API endpoints in priority order
+	Endpoints []DiscoveryEndpointDTO `json:"endpoints"`
+}
+
+// DiscoveryEndpointDTO holds health of a single discovery API endpoint.
+// swagger:model DiscoveryEndpointDTO
+type DiscoveryEndpointDTO struct {
+	// example: https://testnet2-api.mysterium.network/v1/
+	Address string `json:"address"`
+
+	// example: true
+	Healthy bool `json:"healthy"`
+}
+
+// NewDiscoveryHealthDTO maps discovery API failover status to DTO.
+func NewDiscoveryHealthDTO(status apidiscovery.FailoverStatus) *DiscoveryHealthDTO {
+	dto := &DiscoveryHealthDTO{
+		ActiveEndpoint: status.Active,
+		Endpoints:      make([]DiscoveryEndpointDTO, len(status.Endpoints)),
+	}
+	for i, endpoint := range status.Endpoints {
+		dto.Endpoints[i] = DiscoveryEndpointDTO{Address: endpoint.Address, Healthy: endpoint.Healthy}
+	}
+	return dto
 }
 
 // BuildInfoDTO holds info about build.
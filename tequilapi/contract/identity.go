@@ -20,6 +20,8 @@ package contract
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/consumer/session"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/tequilapi/validation"
 )
@@ -31,6 +33,25 @@ type IdentityRefDTO struct {
 	// required: true
 	// example: 0x0000000000000000000000000000000000000001
 	Address string `json:"id"`
+	// usage statistics of identity, present in identity listing only
+	Usage *IdentityUsageDTO `json:"usage,omitempty"`
+}
+
+// IdentityUsageDTO holds aggregated session statistics of identity.
+// swagger:model IdentityUsageDTO
+type IdentityUsageDTO struct {
+	// sessions consumed by identity, tokens are the spendings
+	Consumed SessionStatsDTO `json:"consumed"`
+	// sessions provided by identity, tokens are the earnings
+	Provided SessionStatsDTO `json:"provided"`
+}
+
+// NewIdentityUsageDTO maps to API identity usage.
+func NewIdentityUsageDTO(stats session.IdentityStats) *IdentityUsageDTO {
+	return &IdentityUsageDTO{
+		Consumed: NewSessionStatsDTO(stats.Consumed),
+		Provided: NewSessionStatsDTO(stats.Provided),
+	}
 }
 
 // IdentityDTO holds identity information.
@@ -85,6 +106,37 @@ func (r IdentityCreateRequest) Validate() *validation.FieldErrorMap {
 	return errors
 }
 
+// IdentityMnemonicDTO holds identity created from a new seed phrase.
+// swagger:model IdentityMnemonicDTO
+type IdentityMnemonicDTO struct {
+	// identity in Ethereum address format
+	// required: true
+	// example: 0x0000000000000000000000000000000000000001
+	Address string `json:"id"`
+	// BIP39 seed phrase restoring the identity, it is shown only once
+	// required: true
+	Mnemonic string `json:"mnemonic"`
+}
+
+// IdentityImportRequest request used for identity restoring from its seed phrase.
+// swagger:model IdentityImportRequestDTO
+type IdentityImportRequest struct {
+	Mnemonic   *string `json:"mnemonic"`
+	Passphrase *string `json:"passphrase"`
+}
+
+// Validate validates fields in request
+func (r IdentityImportRequest) Validate() *validation.FieldErrorMap {
+	errors := validation.NewErrorMap()
+	if r.Mnemonic == nil {
+		errors.ForField("mnemonic").AddError("required", "Field is required")
+	}
+	if r.Passphrase == nil {
+		errors.ForField("passphrase").AddError("required", "Field is required")
+	}
+	return errors
+}
+
 // IdentityUnlockRequest request used for identity unlocking.
 // swagger:model IdentityUnlockRequestDTO
 type IdentityUnlockRequest struct {
@@ -100,6 +152,48 @@ func (r IdentityUnlockRequest) Validate() *validation.FieldErrorMap {
 	return errors
 }
 
+// IdentityPassphraseChangeRequest request used for changing passphrase of identity.
+// swagger:model IdentityPassphraseChangeRequestDTO
+type IdentityPassphraseChangeRequest struct {
+	CurrentPassphrase *string `json:"current_passphrase"`
+	NewPassphrase     *string `json:"new_passphrase"`
+}
+
+// Validate validates fields in request
+func (r IdentityPassphraseChangeRequest) Validate() *validation.FieldErrorMap {
+	errors := validation.NewErrorMap()
+	if r.CurrentPassphrase == nil {
+		errors.ForField("current_passphrase").AddError("required", "Field is required")
+	}
+	if r.NewPassphrase == nil {
+		errors.ForField("new_passphrase").AddError("required", "Field is required")
+	}
+	return errors
+}
+
+// IdentityPaymentConfigDTO holds hermes the identity pays and settles with by default.
+// swagger:model IdentityPaymentConfigDTO
+type IdentityPaymentConfigDTO struct {
+	// example: 0x42a537D649d6853C0a866470f2d084DA0f73b5E4
+	HermesID string `json:"hermes_id"`
+}
+
+// IdentityPaymentConfigRequest request used for setting preferred hermes of identity.
+// Omitted hermes resets to node-wide default.
+// swagger:model IdentityPaymentConfigRequestDTO
+type IdentityPaymentConfigRequest struct {
+	HermesID string `json:"hermes_id,omitempty"`
+}
+
+// Validate validates fields in request
+func (r IdentityPaymentConfigRequest) Validate() *validation.FieldErrorMap {
+	errors := validation.NewErrorMap()
+	if r.HermesID != "" && !common.IsHexAddress(r.HermesID) {
+		errors.ForField("hermes_id").AddError("invalid", "Field must be a hex address")
+	}
+	return errors
+}
+
 // IdentityCurrentRequest request used for current identity remembering.
 // swagger:model IdentityCurrentRequestDTO
 type IdentityCurrentRequest struct {
@@ -129,6 +223,15 @@ type IdentityRegisterRequest struct {
 	ReferralToken *string `json:"token,omitempty"`
 }
 
+// IdentitySponsoredRegisterRequest represents the sponsored identity registration user input parameters
+// swagger:model IdentitySponsoredRegisterRequestDTO
+type IdentitySponsoredRegisterRequest struct {
+	// Cache out address for Provider
+	Beneficiary string `json:"beneficiary,omitempty"`
+	// Sponsor: third-party paying for the registration, default sponsor of transactor is used if empty
+	Sponsor string `json:"sponsor,omitempty"`
+}
+
 // IdentityRegistrationResponse represents registration status and needed data for registering of given identity
 // swagger:model IdentityRegistrationResponseDTO
 type IdentityRegistrationResponse struct {
@@ -137,6 +240,14 @@ type IdentityRegistrationResponse struct {
 	Registered bool `json:"registered"`
 }
 
+// IdentityRegistrationEventDTO represents registration status change of identity
+// swagger:model IdentityRegistrationEventDTO
+type IdentityRegistrationEventDTO struct {
+	Address    string `json:"id"`
+	Status     string `json:"status"`
+	Registered bool   `json:"registered"`
+}
+
 // IdentityBeneficiaryResponse represents the provider beneficiary address.
 // swagger:model IdentityBeneficiaryResponseDTO
 type IdentityBeneficiaryResponse struct {
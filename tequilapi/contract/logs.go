@@ -0,0 +1,25 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package contract
+
+// LogsResponse represents recent log lines of a single service instance or session.
+// swagger:model LogsResponseDTO
+type LogsResponse struct {
+	// example: ["2020-10-14T10:00:00.000 INF core/service/session_manager.go:192 Using new payments service_id=6ba7b810-9dad-11d1-80b4-00c04fd430c8"]
+	Lines []string `json:"lines"`
+}
@@ -17,9 +17,76 @@
 
 package contract
 
+import (
+	"time"
+
+	"github.com/mysteriumnetwork/node/nat/mapping"
+)
+
 // NATStatusDTO gives information about NAT traversal success or failure
 // swagger:model NATStatusDTO
 type NATStatusDTO struct {
 	Status string `json:"status"`
 	Error  string `json:"error"`
 }
+
+// NewNATPortMappingsResponse maps router port mapping leases to API response.
+func NewNATPortMappingsResponse(leases []mapping.Lease) NATPortMappingsResponse {
+	response := NATPortMappingsResponse{Mappings: []NATPortMappingDTO{}}
+	for _, lease := range leases {
+		dto := NATPortMappingDTO{
+			Protocol:  lease.Protocol,
+			Port:      lease.Port,
+			Name:      lease.Name,
+			Method:    lease.Method,
+			Permanent: lease.Permanent,
+			RenewedAt: lease.RenewedAt.Format(time.RFC3339Nano),
+		}
+		if !lease.ExpiresAt.IsZero() {
+			dto.ExpiresAt = lease.ExpiresAt.Format(time.RFC3339Nano)
+		}
+		if lease.Error != nil {
+			dto.Error = lease.Error.Error()
+		}
+		response.Mappings = append(response.Mappings, dto)
+	}
+	return response
+}
+
+// NATPortMappingsResponse holds port mappings currently held on the router.
+// swagger:model NATPortMappingsResponse
+type NATPortMappingsResponse struct {
+	Mappings []NATPortMappingDTO `json:"mappings"`
+}
+
+// NATPortMappingDTO describes port mapping added on the router via UPnP IGD or NAT-PMP.
+// swagger:model NATPortMappingDTO
+type NATPortMappingDTO struct {
+	// example: UDP
+	Protocol string `json:"protocol"`
+
+	// example: 51820
+	Port int `json:"port"`
+
+	// example: Myst node p2p port mapping
+	Name string `json:"name"`
+
+	// router protocol the port is mapped with
+	// example: UPNP IGDv2-IP1
+	Method string `json:"method"`
+
+	// set when router supports permanent leases only, those are not renewed
+	// example: false
+	Permanent bool `json:"permanent,omitempty"`
+
+	// example: 2020-07-01T10:11:12.123Z
+	RenewedAt string `json:"renewed_at"`
+
+	// empty for permanent leases
+	// example: 2020-07-01T10:31:12.123Z
+	ExpiresAt string `json:"expires_at,omitempty"`
+
+	// error of the last lease renewal
+	// example: AddPortMapping: 718 ConflictInMappingEntry
+	Error string `json:"error,omitempty"`
+}
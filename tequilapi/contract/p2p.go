@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package contract
+
+import (
+	"time"
+
+	"github.com/mysteriumnetwork/node/p2p"
+)
+
+// NewP2PDiagnosticsResponse maps p2p diagnostics reports to API response.
+func NewP2PDiagnosticsResponse(reports []p2p.DiagnosticsReport) P2PDiagnosticsResponse {
+	response := P2PDiagnosticsResponse{Reports: []P2PDiagnosticsReportDTO{}}
+	for _, report := range reports {
+		dto := P2PDiagnosticsReportDTO{
+			Role:         report.Role,
+			ServiceType:  report.ServiceType,
+			PublicIP:     report.PublicIP,
+			PeerPublicIP: report.PeerPublicIP,
+			StartedAt:    report.StartedAt.Format(time.RFC3339Nano),
+			Duration:     int(report.Duration.Milliseconds()),
+			Relayed:      report.Relayed,
+			Error:        report.Error,
+		}
+		for _, step := range report.Steps {
+			dto.Steps = append(dto.Steps, P2PDiagnosticsStepDTO{
+				Name:     step.Name,
+				Duration: int(step.Duration.Milliseconds()),
+				Error:    step.Error,
+			})
+		}
+		for _, attempt := range report.Attempts {
+			attemptDTO := P2PTraversalAttemptDTO{
+				Method:   attempt.Method,
+				PeerIP:   attempt.PeerIP,
+				Relay:    attempt.Relay,
+				Duration: int(attempt.Duration.Milliseconds()),
+				Error:    attempt.Error,
+			}
+			for _, pair := range attempt.CandidatePairs {
+				attemptDTO.CandidatePairs = append(attemptDTO.CandidatePairs, P2PCandidatePairDTO{
+					LocalPort: pair.LocalPort,
+					PeerPort:  pair.PeerPort,
+				})
+			}
+			dto.Attempts = append(dto.Attempts, attemptDTO)
+		}
+		response.Reports = append(response.Reports, dto)
+	}
+	return response
+}
+
+// P2PDiagnosticsResponse holds reports of the last p2p channel establishments.
+// swagger:model P2PDiagnosticsResponse
+type P2PDiagnosticsResponse struct {
+	Reports []P2PDiagnosticsReportDTO `json:"reports"`
+}
+
+// P2PDiagnosticsReportDTO describes how p2p channel was established or why establishing it failed.
+// swagger:model P2PDiagnosticsReportDTO
+type P2PDiagnosticsReportDTO struct {
+	// side of the channel this node was on, "consumer" or "provider"
+	// example: consumer
+	Role string `json:"role"`
+
+	// example: wireguard
+	ServiceType string `json:"service_type"`
+
+	// example: 1.1.1.1
+	PublicIP string `json:"public_ip,omitempty"`
+
+	// example: 2.2.2.2
+	PeerPublicIP string `json:"peer_public_ip,omitempty"`
+
+	// example: 2020-07-01T10:11:12.123Z
+	StartedAt string `json:"started_at"`
+
+	// duration of the whole establishment in milliseconds
+	// example: 1200
+	Duration int `json:"duration"`
+
+	// steps of the establishment, in order
+	Steps []P2PDiagnosticsStepDTO `json:"steps,omitempty"`
+
+	// attempts to connect to the peer, in order
+	Attempts []P2PTraversalAttemptDTO `json:"attempts,omitempty"`
+
+	// set when channel goes through relay server
+	// example: false
+	Relayed bool `json:"relayed,omitempty"`
+
+	// why establishment failed, empty if channel was established
+	// example: could not dial p2p channel: could not ping peer: ping failed: context deadline exceeded
+	Error string `json:"error,omitempty"`
+}
+
+// P2PDiagnosticsStepDTO describes single step of p2p channel establishment.
+// swagger:model P2PDiagnosticsStepDTO
+type P2PDiagnosticsStepDTO struct {
+	// example: config exchange
+	Name string `json:"name"`
+
+	// step duration in milliseconds
+	// example: 150
+	Duration int `json:"duration"`
+
+	// example: could not send broker request to subject: nats: timeout
+	Error string `json:"error,omitempty"`
+}
+
+// P2PTraversalAttemptDTO describes single attempt to connect to the peer.
+// swagger:model P2PTraversalAttemptDTO
+type P2PTraversalAttemptDTO struct {
+	// method of the attempt, "direct", "pinger" or "relay"
+	// example: pinger
+	Method string `json:"method"`
+
+	// example: 2.2.2.2
+	PeerIP string `json:"peer_ip,omitempty"`
+
+	// relay server address, set for relay attempts
+	// example: 3.3.3.3:3478
+	Relay string `json:"relay,omitempty"`
+
+	// local and peer ports connections were attempted through
+	CandidatePairs []P2PCandidatePairDTO `json:"candidate_pairs,omitempty"`
+
+	// attempt duration in milliseconds
+	// example: 10000
+	Duration int `json:"duration"`
+
+	// example: ping failed: context deadline exceeded
+	Error string `json:"error,omitempty"`
+}
+
+// P2PCandidatePairDTO is a pair of local and peer ports connection was attempted through.
+// swagger:model P2PCandidatePairDTO
+type P2PCandidatePairDTO struct {
+	// example: 51820
+	LocalPort int `json:"local_port"`
+
+	// example: 51821
+	PeerPort int `json:"peer_port"`
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package contract
+
+import (
+	"math/big"
+
+	"github.com/mysteriumnetwork/node/consumer/profile"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+)
+
+// ProfileDTO holds saved connection profile
+// swagger:model ProfileDTO
+type ProfileDTO struct {
+	// profile name, taken from the path when saving
+	// example: work
+	Name string `json:"name"`
+	// provider to connect to, takes precedence over country
+	// required: false
+	// example: 0x0000000000000000000000000000000000000002
+	ProviderID string `json:"provider_id,omitempty"`
+	// connect to any provider located in the country
+	// required: false
+	// example: DE
+	Country string `json:"country,omitempty"`
+	// required: true
+	// example: wireguard
+	ServiceType string `json:"service_type"`
+	// DNS to use
+	// required: false
+	// default: auto
+	// example: auto, provider, system, "1.1.1.1,8.8.8.8"
+	DNS connection.DNSOption `json:"dns,omitempty"`
+	// required: false
+	// example: false
+	DisableKillSwitch bool `json:"disable_kill_switch,omitempty"`
+	// connection is closed once consumer pays provider this amount during the session
+	// required: false
+	// example: 500000000000000000
+	SpendCap *big.Int `json:"spend_cap,omitempty"`
+}
+
+// NewProfileDTO maps profile to DTO
+func NewProfileDTO(p profile.Profile) ProfileDTO {
+	return ProfileDTO{
+		Name:              p.Name,
+		ProviderID:        p.ProviderID,
+		Country:           p.Country,
+		ServiceType:       p.ServiceType,
+		DNS:               p.DNS,
+		DisableKillSwitch: p.DisableKillSwitch,
+		SpendCap:          p.SpendCap,
+	}
+}
+
+// ToProfile converts DTO to profile
+func (dto ProfileDTO) ToProfile() profile.Profile {
+	return profile.Profile{
+		Name:              dto.Name,
+		ProviderID:        dto.ProviderID,
+		Country:           dto.Country,
+		ServiceType:       dto.ServiceType,
+		DNS:               dto.DNS,
+		DisableKillSwitch: dto.DisableKillSwitch,
+		SpendCap:          dto.SpendCap,
+	}
+}
+
+// ListProfilesResponse holds list of saved connection profiles
+// swagger:model ListProfilesResponse
+type ListProfilesResponse struct {
+	Profiles []ProfileDTO `json:"profiles"`
+}
+
+// ProfileConnectRequest request used to connect with a saved profile
+// swagger:model ProfileConnectRequestDTO
+type ProfileConnectRequest struct {
+	// consumer identity
+	// required: true
+	// example: 0x0000000000000000000000000000000000000001
+	ConsumerID string `json:"consumer_id"`
+
+	// hermes identity, preferred hermes of consumer identity is used if omitted
+	// example: 0x0000000000000000000000000000000000000003
+	HermesID string `json:"hermes_id"`
+}
+
+// Validate validates fields in request
+func (r ProfileConnectRequest) Validate() *validation.FieldErrorMap {
+	errs := validation.NewErrorMap()
+	if len(r.ConsumerID) == 0 {
+		errs.ForField("consumer_id").AddError("required", "Field is required")
+	}
+	return errs
+}
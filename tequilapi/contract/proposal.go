@@ -20,6 +20,7 @@ package contract
 import (
 	"fmt"
 
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
 	"github.com/mysteriumnetwork/node/core/quality"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
@@ -27,14 +28,30 @@ import (
 
 // NewProposalDTO maps to API service proposal.
 func NewProposalDTO(p market.ServiceProposal) ProposalDTO {
-	return ProposalDTO{
+	dto := ProposalDTO{
 		ID:                p.ID,
 		ProviderID:        p.ProviderID,
 		ServiceType:       p.ServiceType,
 		ServiceDefinition: NewServiceDefinitionDTO(p.ServiceDefinition),
 		AccessPolicies:    p.AccessPolicies,
 		PaymentMethod:     NewPaymentMethodDTO(p.PaymentMethod),
+		NATType:           p.NATType,
+		MeasuredBandwidth: uint64(p.MeasuredBandwidth),
+		SignatureVerified: p.SignatureVerified,
+		PriceOutlier:      p.PriceOutlier,
+		Latency:           int(p.Latency.Milliseconds()),
 	}
+	if p.Quality != nil {
+		dto.Metrics = &QualityMetricsDTO{
+			MonitoringFailed: p.Quality.MonitoringFailed,
+			ConnectCount: QualityMetricConnectsDTO{
+				Success: p.Quality.ConnectSuccess,
+				Timeout: p.Quality.ConnectTimeout,
+				Fail:    p.Quality.ConnectFail,
+			},
+		}
+	}
+	return dto
 }
 
 // NewPaymentMethodDTO maps to API payment method.
@@ -99,7 +116,7 @@ type ProposalDTO struct {
 	// qualitative service definition
 	ServiceDefinition ServiceDefinitionDTO `json:"service_definition"`
 
-	// Metrics of the service
+	// Metrics of the service, included when known to the node or requested with fetch_metrics
 	Metrics *QualityMetricsDTO `json:"metrics,omitempty"`
 
 	// AccessPolicies
@@ -107,6 +124,26 @@ type ProposalDTO struct {
 
 	// PaymentMethod
 	PaymentMethod PaymentMethodDTO `json:"payment_method"`
+
+	// type of NAT the provider is behind, empty when unknown
+	// example: port_restricted
+	NATType string `json:"nat_type,omitempty"`
+
+	// provider bandwidth in bits per second measured by speed test, omitted when not measured
+	// example: 104857600
+	MeasuredBandwidth uint64 `json:"measured_bandwidth,omitempty"`
+
+	// true when the proposal is signed by its provider and the signature is valid
+	// example: true
+	SignatureVerified bool `json:"signature_verified,omitempty"`
+
+	// true when price per GiB or per minute is far above the network median
+	// example: false
+	PriceOutlier bool `json:"price_outlier,omitempty"`
+
+	// round trip time to the provider in milliseconds probed by the node, omitted when not probed
+	// example: 45
+	Latency int `json:"latency,omitempty"`
 }
 
 func (p ProposalDTO) String() string {
@@ -209,3 +246,36 @@ type QualityMetricConnectsDTO struct {
 	Fail    int `json:"fail" example:"50" format:"int64"`
 	Timeout int `json:"timeout" example:"10" format:"int64"`
 }
+
+// ProposalCountDTO holds number of proposals of the service type in the country.
+// swagger:model ProposalCountDTO
+type ProposalCountDTO struct {
+	// provider country, empty when unknown
+	// example: DE
+	Country string `json:"country"`
+
+	// example: wireguard
+	ServiceType string `json:"service_type"`
+
+	// example: 42
+	Count int `json:"count"`
+}
+
+// ProposalCountsResponse holds numbers of proposals grouped by country and service type.
+// swagger:model ProposalCountsResponse
+type ProposalCountsResponse struct {
+	Counts []ProposalCountDTO `json:"counts"`
+}
+
+// NewProposalCountsResponse maps to API proposal counts.
+func NewProposalCountsResponse(counts []proposal.Count) ProposalCountsResponse {
+	res := ProposalCountsResponse{Counts: make([]ProposalCountDTO, len(counts))}
+	for i, c := range counts {
+		res.Counts[i] = ProposalCountDTO{
+			Country:     c.Country,
+			ServiceType: c.ServiceType,
+			Count:       c.Count,
+		}
+	}
+	return res
+}
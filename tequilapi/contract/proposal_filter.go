@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package contract
+
+import (
+	"math/big"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+)
+
+// SavedFilterRequest request used for saving named proposal filter.
+// swagger:model SavedFilterRequestDTO
+type SavedFilterRequest struct {
+	// accepted provider countries, any country is accepted if empty
+	// example: ["DE", "NL"]
+	Countries []string `json:"countries,omitempty"`
+	// accepted provider IP type
+	// example: residential
+	IPType string `json:"ip_type,omitempty"`
+	// price ceiling per minute
+	UpperTimePrice *big.Int `json:"upper_time_price,omitempty"`
+	// price ceiling per GiB
+	UpperGBPrice *big.Int `json:"upper_gb_price,omitempty"`
+	// minimal quality rating from 0 to 1
+	// example: 0.8
+	QualityMin float64 `json:"quality_min,omitempty"`
+}
+
+// Validate validates fields in request
+func (r SavedFilterRequest) Validate() *validation.FieldErrorMap {
+	errors := validation.NewErrorMap()
+	if r.QualityMin < 0 || r.QualityMin > 1 {
+		errors.ForField("quality_min").AddError("invalid", "Field must be between 0 and 1")
+	}
+	if r.UpperTimePrice != nil && r.UpperTimePrice.Sign() < 0 {
+		errors.ForField("upper_time_price").AddError("invalid", "Field must not be negative")
+	}
+	if r.UpperGBPrice != nil && r.UpperGBPrice.Sign() < 0 {
+		errors.ForField("upper_gb_price").AddError("invalid", "Field must not be negative")
+	}
+	return errors
+}
+
+// ToSavedFilter maps request to saved filter with the given name.
+func (r SavedFilterRequest) ToSavedFilter(name string) proposal.SavedFilter {
+	return proposal.SavedFilter{
+		Name:           name,
+		Countries:      r.Countries,
+		IPType:         r.IPType,
+		UpperTimePrice: r.UpperTimePrice,
+		UpperGBPrice:   r.UpperGBPrice,
+		QualityMin:     r.QualityMin,
+	}
+}
+
+// SavedFilterDTO represents named proposal filter.
+// swagger:model SavedFilterDTO
+type SavedFilterDTO struct {
+	// example: streaming-eu
+	Name string `json:"name"`
+	SavedFilterRequest
+}
+
+// NewSavedFilterDTO maps to API saved filter.
+func NewSavedFilterDTO(filter proposal.SavedFilter) SavedFilterDTO {
+	return SavedFilterDTO{
+		Name: filter.Name,
+		SavedFilterRequest: SavedFilterRequest{
+			Countries:      filter.Countries,
+			IPType:         filter.IPType,
+			UpperTimePrice: filter.UpperTimePrice,
+			UpperGBPrice:   filter.UpperGBPrice,
+			QualityMin:     filter.QualityMin,
+		},
+	}
+}
+
+// ListSavedFiltersResponse holds list of saved proposal filters.
+// swagger:model ListSavedFiltersResponse
+type ListSavedFiltersResponse struct {
+	Filters []SavedFilterDTO `json:"filters"`
+}
+
+// NewListSavedFiltersResponse maps to API saved filter list.
+func NewListSavedFiltersResponse(filters []proposal.SavedFilter) ListSavedFiltersResponse {
+	res := ListSavedFiltersResponse{Filters: make([]SavedFilterDTO, len(filters))}
+	for i, filter := range filters {
+		res.Filters[i] = NewSavedFilterDTO(filter)
+	}
+	return res
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package contract
+
+import (
+	"strings"
+
+	"github.com/mysteriumnetwork/node/consumer/schedule"
+)
+
+// ScheduleDTO holds connect and disconnect schedule
+// swagger:model ScheduleDTO
+type ScheduleDTO struct {
+	// schedule name, taken from the path when saving
+	// example: work-hours
+	Name string `json:"name"`
+	// week days on which schedule is active, empty value means every day
+	// required: false
+	// example: ["monday", "tuesday", "wednesday", "thursday", "friday"]
+	Days []string `json:"days,omitempty"`
+	// local time to connect at
+	// required: false
+	// example: 09:00
+	ConnectAt string `json:"connect_at,omitempty"`
+	// local time to disconnect at
+	// required: false
+	// example: 17:00
+	DisconnectAt string `json:"disconnect_at,omitempty"`
+	// saved connection profile to connect with, required when schedule connects
+	// required: false
+	// example: work
+	Profile string `json:"profile,omitempty"`
+	// consumer identity to connect with, last used identity by default
+	// required: false
+	// example: 0x0000000000000000000000000000000000000001
+	ConsumerID string `json:"consumer_id,omitempty"`
+}
+
+// NewScheduleDTO maps schedule to DTO
+func NewScheduleDTO(s schedule.Schedule) ScheduleDTO {
+	dto := ScheduleDTO{
+		Name:       s.Name,
+		Profile:    s.Profile,
+		ConsumerID: s.ConsumerID,
+	}
+	for _, day := range s.Days {
+		dto.Days = append(dto.Days, strings.ToLower(day.String()))
+	}
+	if s.ConnectAt != nil {
+		dto.ConnectAt = s.ConnectAt.String()
+	}
+	if s.DisconnectAt != nil {
+		dto.DisconnectAt = s.DisconnectAt.String()
+	}
+	return dto
+}
+
+// ToSchedule parses DTO to schedule
+func (dto ScheduleDTO) ToSchedule() (schedule.Schedule, error) {
+	s := schedule.Schedule{
+		Name:       dto.Name,
+		Profile:    dto.Profile,
+		ConsumerID: dto.ConsumerID,
+	}
+	for _, name := range dto.Days {
+		day, err := schedule.ParseWeekday(name)
+		if err != nil {
+			return s, err
+		}
+		s.Days = append(s.Days, day)
+	}
+	var err error
+	if s.ConnectAt, err = parseTimeOfDay(dto.ConnectAt); err != nil {
+		return s, err
+	}
+	if s.DisconnectAt, err = parseTimeOfDay(dto.DisconnectAt); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+func parseTimeOfDay(value string) (*schedule.TimeOfDay, error) {
+	if value == "" {
+		return nil, nil
+	}
+	tod, err := schedule.ParseTimeOfDay(value)
+	if err != nil {
+		return nil, err
+	}
+	return &tod, nil
+}
+
+// ListSchedulesResponse holds list of connect and disconnect schedules
+// swagger:model ListSchedulesResponse
+type ListSchedulesResponse struct {
+	Schedules []ScheduleDTO `json:"schedules"`
+}
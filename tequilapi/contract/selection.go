@@ -0,0 +1,54 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package contract
+
+import "github.com/mysteriumnetwork/node/core/discovery/selection"
+
+// SelectionPolicyDTO tunes how providers are selected when connecting without explicit provider
+// swagger:model SelectionPolicyDTO
+type SelectionPolicyDTO struct {
+	// weights of rating criteria, criteria missing or weighted 0 are not used. Built-in criteria are price, quality, latency, country and history
+	// example: {"price": 2, "quality": 1, "latency": 1, "country": 0.5, "history": 1}
+	Weights map[string]float64 `json:"weights"`
+	// preferred provider countries, most preferred first
+	// required: false
+	// example: ["DE", "NL"]
+	Countries []string `json:"countries,omitempty"`
+	// name of saved proposal filter providers must match
+	// required: false
+	// example: streaming-eu
+	Filter string `json:"filter,omitempty"`
+}
+
+// NewSelectionPolicyDTO maps selection policy to DTO
+func NewSelectionPolicyDTO(policy selection.Policy) SelectionPolicyDTO {
+	return SelectionPolicyDTO{
+		Weights:   policy.Weights,
+		Countries: policy.Countries,
+		Filter:    policy.Filter,
+	}
+}
+
+// ToPolicy maps DTO to selection policy
+func (dto SelectionPolicyDTO) ToPolicy() selection.Policy {
+	return selection.Policy{
+		Weights:   dto.Weights,
+		Countries: dto.Countries,
+		Filter:    dto.Filter,
+	}
+}
@@ -27,7 +27,7 @@ type ServiceStartRequest struct {
 	// example: 0x0000000000000000000000000000000000000002
 	ProviderID string `json:"provider_id"`
 
-	// service type. Possible values are "openvpn", "wireguard" and "noop"
+	// service type. Possible values are "openvpn", "wireguard", "http-proxy", "shadowsocks" and "noop"
 	// required: true
 	// example: openvpn
 	Type string `json:"type"`
@@ -46,6 +46,19 @@ type ServiceStartRequest struct {
 	Options interface{} `json:"options"`
 }
 
+// ServiceUpdateRequest request used to change options of the running service.
+// swagger:model ServiceUpdateRequestDTO
+type ServiceUpdateRequest struct {
+	// access list which determines which identities will be able to receive the service, current one is kept when omitted
+	// required: false
+	AccessPolicies *ServiceAccessPolicies `json:"access_policies"`
+
+	// service options. Every service has a unique list of allowed options, current ones are kept when omitted.
+	// required: false
+	// example: {"ports": "52820:53075", "session_bandwidth": "10mbps"}
+	Options interface{} `json:"options"`
+}
+
 // ServicePaymentMethod payment parameters for service start.
 // swagger:model ServicePaymentMethod
 type ServicePaymentMethod struct {
@@ -73,7 +86,7 @@ type ServiceInfoDTO struct {
 	// example: 0x0000000000000000000000000000000000000002
 	ProviderID string `json:"provider_id"`
 
-	// service type. Possible values are "openvpn", "wireguard" and "noop"
+	// service type. Possible values are "openvpn", "wireguard", "http-proxy", "shadowsocks" and "noop"
 	// example: openvpn
 	Type string `json:"type"`
 
@@ -84,6 +97,10 @@ type ServiceInfoDTO struct {
 	// example: Running
 	Status string `json:"status"`
 
+	// implementation the service runs on, reported by services having several of them
+	// example: kernel
+	Backend string `json:"backend,omitempty"`
+
 	Proposal ProposalDTO `json:"proposal"`
 
 	ConnectionStatistics ServiceStatisticsDTO `json:"connection_statistics"`
@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package contract
+
+import (
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+
+	"github.com/mysteriumnetwork/node/core/stats"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+)
+
+// ServiceStatsQuery allows to limit period of requested service statistics.
+// swagger:parameters serviceStats
+type ServiceStatsQuery struct {
+	// Statistics from this date (today, by default). Formatted in RFC3339 e.g. 2020-07-01.
+	// in: query
+	DateFrom *strfmt.Date `json:"date_from"`
+
+	// Statistics until this date (today, by default). Formatted in RFC3339 e.g. 2020-07-30.
+	// in: query
+	DateTo *strfmt.Date `json:"date_to"`
+}
+
+// Bind creates and validates query from API request.
+func (q *ServiceStatsQuery) Bind(request *http.Request) *validation.FieldErrorMap {
+	errs := validation.NewErrorMap()
+
+	qs := request.URL.Query()
+	if qStr := qs.Get("date_from"); qStr != "" {
+		if qVal, err := parseDate(qStr); err != nil {
+			errs.ForField("date_from").Add(err)
+		} else {
+			q.DateFrom = qVal
+		}
+	}
+	if qStr := qs.Get("date_to"); qStr != "" {
+		if qVal, err := parseDate(qStr); err != nil {
+			errs.ForField("date_to").Add(err)
+		} else {
+			q.DateTo = qVal
+		}
+	}
+
+	return errs
+}
+
+// Period returns start and end of the requested period, days are taken whole.
+func (q *ServiceStatsQuery) Period(now time.Time) (from, to time.Time) {
+	from = now.UTC().Truncate(24 * time.Hour)
+	if q.DateFrom != nil {
+		from = time.Time(*q.DateFrom).UTC().Truncate(24 * time.Hour)
+	}
+	to = now.UTC().Truncate(24 * time.Hour)
+	if q.DateTo != nil {
+		to = time.Time(*q.DateTo).UTC().Truncate(24 * time.Hour)
+	}
+	return from, to.Add(24*time.Hour - time.Second)
+}
+
+// NewServiceStatsResponse maps to API service statistics.
+func NewServiceStatsResponse(hours []stats.Hour) ServiceStatsResponse {
+	dtoArray := make([]ServiceStatsHourDTO, len(hours))
+	for i, h := range hours {
+		dtoArray[i] = ServiceStatsHourDTO{
+			At:              h.At.Format(time.RFC3339),
+			Sessions:        h.Sessions,
+			UniqueConsumers: len(h.Consumers),
+			BytesSent:       h.BytesSent,
+			BytesReceived:   h.BytesReceived,
+			Tokens:          h.Tokens,
+		}
+	}
+	return ServiceStatsResponse{Hours: dtoArray}
+}
+
+// ServiceStatsResponse defines hourly statistics of the provided service.
+// swagger:model ServiceStatsResponse
+type ServiceStatsResponse struct {
+	Hours []ServiceStatsHourDTO `json:"hours"`
+}
+
+// ServiceStatsHourDTO represents service statistics aggregated over an hour.
+// swagger:model ServiceStatsHourDTO
+type ServiceStatsHourDTO struct {
+	// example: 2020-10-16T12:00:00Z
+	At string `json:"at"`
+
+	// number of sessions started during the hour
+	// example: 3
+	Sessions int `json:"sessions"`
+
+	// example: 2
+	UniqueConsumers int `json:"unique_consumers"`
+
+	// traffic sent to consumers
+	// example: 1048576
+	BytesSent uint64 `json:"bytes_sent"`
+
+	// traffic received from consumers
+	// example: 65536
+	BytesReceived uint64 `json:"bytes_received"`
+
+	// earned in the smallest MYST units
+	// example: 500000000000000000
+	Tokens *big.Int `json:"tokens"`
+}
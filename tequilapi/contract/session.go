@@ -243,20 +243,21 @@ type SessionStatsDTO struct {
 // NewSessionDTO maps to API session.
 func NewSessionDTO(se session.History) SessionDTO {
 	return SessionDTO{
-		ID:              string(se.SessionID),
-		Direction:       se.Direction,
-		ConsumerID:      se.ConsumerID.Address,
-		HermesID:        se.HermesID,
-		ProviderID:      se.ProviderID.Address,
-		ServiceType:     se.ServiceType,
-		ConsumerCountry: se.ConsumerCountry,
-		ProviderCountry: se.ProviderCountry,
-		CreatedAt:       se.Started.Format(time.RFC3339),
-		BytesReceived:   se.DataReceived,
-		BytesSent:       se.DataSent,
-		Duration:        uint64(se.GetDuration().Seconds()),
-		Tokens:          se.Tokens,
-		Status:          se.Status,
+		ID:               string(se.SessionID),
+		Direction:        se.Direction,
+		ConsumerID:       se.ConsumerID.Address,
+		HermesID:         se.HermesID,
+		ProviderID:       se.ProviderID.Address,
+		ServiceType:      se.ServiceType,
+		ConsumerCountry:  se.ConsumerCountry,
+		ProviderCountry:  se.ProviderCountry,
+		CreatedAt:        se.Started.Format(time.RFC3339),
+		BytesReceived:    se.DataReceived,
+		BytesSent:        se.DataSent,
+		Duration:         uint64(se.GetDuration().Seconds()),
+		Tokens:           se.Tokens,
+		Status:           se.Status,
+		DisconnectReason: se.DisconnectReason,
 	}
 }
 
@@ -305,4 +306,8 @@ type SessionDTO struct {
 
 	// example: Completed
 	Status string `json:"status"`
+
+	// set when node closed the session by itself
+	// example: DataCapReached
+	DisconnectReason string `json:"disconnect_reason,omitempty"`
 }
@@ -39,6 +39,31 @@ type FeesDTO struct {
 	DecreaseStake *big.Int `json:"decreaseStake"`
 }
 
+// RegistrationQuoteDTO represents the current cost of identity registration
+// swagger:model RegistrationQuoteDTO
+type RegistrationQuoteDTO struct {
+	Fee *big.Int `json:"fee"`
+	// Time until which the quoted fee is valid, fee has to be quoted anew afterwards.
+	ValidUntil time.Time `json:"valid_until"`
+	// Whether registration of the identity is covered by registration bounty.
+	BountyEligible bool `json:"bounty_eligible"`
+	// Estimated registration confirmation time in seconds, 0 when unknown.
+	EstimatedConfirmationSeconds int64 `json:"estimated_confirmation_seconds"`
+}
+
+// RegistrationAuthorizationDTO represents registration request signed by identity with zero stake and fee,
+// which third-party payer may submit to the registry on behalf of the identity.
+// swagger:model RegistrationAuthorizationDTO
+type RegistrationAuthorizationDTO struct {
+	Identity        string   `json:"identity"`
+	RegistryAddress string   `json:"registry_address"`
+	HermesID        string   `json:"hermes_id"`
+	Stake           *big.Int `json:"stake"`
+	Fee             *big.Int `json:"fee"`
+	Beneficiary     string   `json:"beneficiary"`
+	Signature       string   `json:"signature"`
+}
+
 // NewSettlementListQuery creates settlement list query with default values.
 func NewSettlementListQuery() SettlementListQuery {
 	return SettlementListQuery{
@@ -184,6 +209,7 @@ type SettlementDTO struct {
 // SettleRequest represents the request to settle hermes promises
 // swagger:model SettleRequestDTO
 type SettleRequest struct {
+	// hermes to settle with, preferred hermes of provider identity is used if omitted
 	HermesID   string `json:"hermes_id"`
 	ProviderID string `json:"provider_id"`
 }
@@ -0,0 +1,163 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/mysteriumnetwork/node/core/acl"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+type consumerACLStorage interface {
+	Save(e acl.Entry) error
+	List() ([]acl.Entry, error)
+	Delete(consumerID string) error
+}
+
+// ConsumerACLEndpoint struct represents /acl resource and it's subresources
+type ConsumerACLEndpoint struct {
+	storage consumerACLStorage
+}
+
+// NewConsumerACLEndpoint creates and returns consumer access control list endpoint
+func NewConsumerACLEndpoint(storage consumerACLStorage) *ConsumerACLEndpoint {
+	return &ConsumerACLEndpoint{storage: storage}
+}
+
+// List returns consumer identities allowed or blocked by provider
+// swagger:operation GET /acl ConsumerACL listConsumerACL
+// ---
+// summary: Returns provider allow and block lists of consumer identities
+// responses:
+//   200:
+//     description: List of access control entries
+//     schema:
+//       "$ref": "#/definitions/ListConsumerACLResponse"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ae *ConsumerACLEndpoint) List(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	entries, err := ae.storage.List()
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	res := contract.ListConsumerACLResponse{Entries: []contract.ConsumerACLEntryDTO{}}
+	for _, e := range entries {
+		res.Entries = append(res.Entries, contract.NewConsumerACLEntryDTO(e))
+	}
+	utils.WriteAsJSON(res, resp)
+}
+
+// Save puts consumer identity into allow or block list
+// swagger:operation PUT /acl/{identity} ConsumerACL saveConsumerACLEntry
+// ---
+// summary: Allows or blocks consumer identity
+// description: Puts consumer into the given list, removing it from the other one. New sessions of blocked consumers are rejected, once any consumer is allowed, sessions of all consumers not in allow list are rejected too.
+// parameters:
+//   - name: identity
+//     in: path
+//     description: consumer identity
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Access control entry
+//     schema:
+//       $ref: "#/definitions/ConsumerACLEntryDTO"
+// responses:
+//   200:
+//     description: Access control entry saved
+//     schema:
+//       "$ref": "#/definitions/ConsumerACLEntryDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ae *ConsumerACLEndpoint) Save(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	var dto contract.ConsumerACLEntryDTO
+	if err := json.NewDecoder(req.Body).Decode(&dto); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	dto.Identity = params.ByName("identity")
+
+	e := dto.ToEntry()
+	if err := e.Validate(); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	if err := ae.storage.Save(e); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	utils.WriteAsJSON(contract.NewConsumerACLEntryDTO(e), resp)
+}
+
+// Delete removes consumer identity from the list it belongs to
+// swagger:operation DELETE /acl/{identity} ConsumerACL deleteConsumerACLEntry
+// ---
+// summary: Removes consumer identity from allow or block list
+// parameters:
+//   - name: identity
+//     in: path
+//     description: consumer identity
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: Access control entry removed
+//   404:
+//     description: Consumer is in neither list
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ae *ConsumerACLEndpoint) Delete(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	err := ae.storage.Delete(params.ByName("identity"))
+	if err == acl.ErrNotFound {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// AddRoutesForConsumerACL attaches consumer access control list endpoints to router
+func AddRoutesForConsumerACL(router *httprouter.Router, storage consumerACLStorage) {
+	aclEndpoint := NewConsumerACLEndpoint(storage)
+	router.GET("/acl", aclEndpoint.List)
+	router.PUT("/acl/:identity", aclEndpoint.Save)
+	router.DELETE("/acl/:identity", aclEndpoint.Delete)
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/acl"
+)
+
+func TestConsumerACLSaveAndList(t *testing.T) {
+	storage := newMockConsumerACLStorage()
+	router := httprouter.New()
+	AddRoutesForConsumerACL(router, storage)
+
+	req := httptest.NewRequest(http.MethodPut, "/acl/0x1", strings.NewReader(`{"list": "block"}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, acl.Entry{Identity: "0x1", List: acl.ListBlock}, storage.entries["0x1"])
+
+	req = httptest.NewRequest(http.MethodGet, "/acl", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"entries": [{"identity": "0x1", "list": "block"}]}`, resp.Body.String())
+}
+
+func TestConsumerACLSaveRejectsUnknownList(t *testing.T) {
+	storage := newMockConsumerACLStorage()
+	router := httprouter.New()
+	AddRoutesForConsumerACL(router, storage)
+
+	req := httptest.NewRequest(http.MethodPut, "/acl/0x1", strings.NewReader(`{"list": "deny"}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Empty(t, storage.entries)
+}
+
+func TestConsumerACLDelete(t *testing.T) {
+	storage := newMockConsumerACLStorage()
+	storage.entries["0x1"] = acl.Entry{Identity: "0x1", List: acl.ListAllow}
+	router := httprouter.New()
+	AddRoutesForConsumerACL(router, storage)
+
+	req := httptest.NewRequest(http.MethodDelete, "/acl/0x1", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Empty(t, storage.entries)
+
+	req = httptest.NewRequest(http.MethodDelete, "/acl/0x1", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+type mockConsumerACLStorage struct {
+	entries map[string]acl.Entry
+}
+
+func newMockConsumerACLStorage() *mockConsumerACLStorage {
+	return &mockConsumerACLStorage{entries: make(map[string]acl.Entry)}
+}
+
+func (s *mockConsumerACLStorage) Save(e acl.Entry) error {
+	s.entries[e.Identity] = e
+	return nil
+}
+
+func (s *mockConsumerACLStorage) List() ([]acl.Entry, error) {
+	var res []acl.Entry
+	for _, e := range s.entries {
+		res = append(res, e)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Identity < res[j].Identity })
+	return res, nil
+}
+
+func (s *mockConsumerACLStorage) Delete(consumerID string) error {
+	if _, ok := s.entries[consumerID]; !ok {
+		return acl.ErrNotFound
+	}
+	delete(s.entries, consumerID)
+	return nil
+}
@@ -19,12 +19,13 @@ package endpoints
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/julienschmidt/httprouter"
-	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
 	"github.com/mysteriumnetwork/node/identity"
@@ -32,7 +33,6 @@ import (
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
-	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
@@ -56,6 +56,10 @@ type ConnectionEndpoint struct {
 	//TODO connection should use concrete proposal from connection params and avoid going to marketplace
 	proposalRepository proposal.Repository
 	identityRegistry   identityRegistry
+	// proposalFetchTimeout limits proposal fetch during connect, zero value disables it
+	proposalFetchTimeout time.Duration
+	// hermesResolver resolves hermes of consumer when connection request does not specify it
+	hermesResolver hermesResolver
 }
 
 // NewConnectionEndpoint creates and returns connection endpoint
@@ -108,6 +112,10 @@ func (ce *ConnectionEndpoint) Status(resp http.ResponseWriter, _ *http.Request,
 //     description: Bad request
 //     schema:
 //       "$ref": "#/definitions/ErrorMessageDTO"
+//   403:
+//     description: Daily data cap is already reached
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
 //   409:
 //     description: Conflict. Connection already exists
 //     schema:
@@ -124,67 +132,184 @@ func (ce *ConnectionEndpoint) Status(resp http.ResponseWriter, _ *http.Request,
 //     description: Internal server error
 //     schema:
 //       "$ref": "#/definitions/ErrorMessageDTO"
+//   504:
+//     description: Connect timed out
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
 func (ce *ConnectionEndpoint) Create(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	cr, ok := ce.resolveConnectRequest(resp, req)
+	if !ok {
+		return
+	}
+	ce.connect(resp, req, params, cr.consumerID, cr.hermesID, cr.proposal, cr.params)
+}
+
+// connectRequest holds connect arguments resolved from connection create request.
+type connectRequest struct {
+	consumerID identity.Identity
+	hermesID   common.Address
+	proposal   market.ServiceProposal
+	params     connection.ConnectParams
+}
+
+// resolveConnectRequest validates connection create request and fetches proposals it refers to.
+// It responds with an error and returns false when the request can not be served.
+func (ce *ConnectionEndpoint) resolveConnectRequest(resp http.ResponseWriter, req *http.Request) (connectRequest, bool) {
 	cr, err := toConnectionRequest(req)
 	if err != nil {
 		utils.SendError(resp, err, http.StatusBadRequest)
-		return
+		return connectRequest{}, false
 	}
 
 	if errorMap := cr.Validate(); errorMap.HasErrors() {
 		utils.SendValidationErrorMessage(resp, errorMap)
-		return
+		return connectRequest{}, false
 	}
 
 	// TODO Validate for account existence
 	consumerID := identity.FromAddress(cr.ConsumerID)
+	if !ce.checkRegistration(resp, consumerID) {
+		return connectRequest{}, false
+	}
+
+	// TODO Pass proposal ID directly in request
+	proposal, err := ce.fetchProposal(market.NewProposalID(cr.ProviderID, cr.ServiceType, cr.ProposalID))
+	if err != nil {
+		sendProposalFetchError(resp, err)
+		return connectRequest{}, false
+	}
+	if proposal == nil {
+		utils.SendError(resp, errors.New("provider has no service proposals"), http.StatusBadRequest)
+		return connectRequest{}, false
+	}
+
+	connectOptions := getConnectOptions(cr)
+	for _, fallbackProviderID := range cr.FallbackProviderIDs {
+		fallbackProposal, err := ce.fetchProposal(market.ProposalID{
+			ProviderID:  fallbackProviderID,
+			ServiceType: cr.ServiceType,
+		})
+		if err != nil {
+			sendProposalFetchError(resp, err)
+			return connectRequest{}, false
+		}
+		if fallbackProposal == nil {
+			utils.SendError(resp, fmt.Errorf("fallback provider %q has no service proposals", fallbackProviderID), http.StatusBadRequest)
+			return connectRequest{}, false
+		}
+		connectOptions.FallbackProposals = append(connectOptions.FallbackProposals, *fallbackProposal)
+	}
+
+	if cr.ConnectOptions.EntryProviderID != "" {
+		entryProposal, err := ce.fetchProposal(market.ProposalID{
+			ProviderID:  cr.ConnectOptions.EntryProviderID,
+			ServiceType: cr.ServiceType,
+		})
+		if err != nil {
+			sendProposalFetchError(resp, err)
+			return connectRequest{}, false
+		}
+		if entryProposal == nil {
+			utils.SendError(resp, fmt.Errorf("entry provider %q has no service proposals", cr.ConnectOptions.EntryProviderID), http.StatusBadRequest)
+			return connectRequest{}, false
+		}
+		connectOptions.EntryProposal = entryProposal
+	}
+
+	hermesID := resolveHermesID(ce.hermesResolver, consumerID)
+	if cr.HermesID != "" {
+		hermesID = common.HexToAddress(cr.HermesID)
+	}
+
+	return connectRequest{
+		consumerID: consumerID,
+		hermesID:   hermesID,
+		proposal:   *proposal,
+		params:     connectOptions,
+	}, true
+}
+
+// checkRegistration refuses to connect with identities which are not registered.
+func (ce *ConnectionEndpoint) checkRegistration(resp http.ResponseWriter, consumerID identity.Identity) bool {
 	status, err := ce.identityRegistry.GetRegistrationStatus(consumerID)
 	if err != nil {
 		log.Error().Err(err).Stack().Msg("could not check registration status")
 		utils.SendError(resp, err, http.StatusInternalServerError)
-		return
+		return false
 	}
 	switch status {
 	case registry.Unregistered, registry.RegistrationError:
-		log.Warn().Msgf("identity %q is not registered, aborting...", cr.ConsumerID)
-		utils.SendError(resp, fmt.Errorf("identity %q is not registered. Please register the identity first", cr.ConsumerID), http.StatusExpectationFailed)
-		return
+		log.Warn().Msgf("identity %q is not registered, aborting...", consumerID.Address)
+		utils.SendError(resp, fmt.Errorf("identity %q is not registered. Please register the identity first", consumerID.Address), http.StatusExpectationFailed)
+		return false
 	case registry.InProgress:
-		log.Info().Msgf("identity %q registration is in progress, continuing...", cr.ConsumerID)
+		log.Info().Msgf("identity %q registration is in progress, continuing...", consumerID.Address)
 	default:
-		log.Info().Msgf("identity %q is registered, continuing...", cr.ConsumerID)
+		log.Info().Msgf("identity %q is registered, continuing...", consumerID.Address)
 	}
+	return true
+}
 
-	// TODO Pass proposal ID directly in request
-	proposal, err := ce.proposalRepository.Proposal(market.ProposalID{
-		ProviderID:  cr.ProviderID,
-		ServiceType: cr.ServiceType,
-	})
+// connect connects to the proposal and responds with the connection status.
+func (ce *ConnectionEndpoint) connect(resp http.ResponseWriter, req *http.Request, params httprouter.Params,
+	consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, connectOptions connection.ConnectParams) {
+	err := ce.manager.Connect(consumerID, hermesID, proposal, connectOptions)
 	if err != nil {
-		utils.SendError(resp, err, http.StatusInternalServerError)
+		sendConnectError(resp, err)
 		return
 	}
-	if proposal == nil {
-		utils.SendError(resp, errors.New("provider has no service proposals"), http.StatusBadRequest)
-		return
+	resp.WriteHeader(http.StatusCreated)
+	ce.Status(resp, req, params)
+}
+
+func sendConnectError(resp http.ResponseWriter, err error) {
+	switch err {
+	case connection.ErrAlreadyExists:
+		utils.SendError(resp, err, http.StatusConflict)
+	case connection.ErrConnectionCancelled:
+		utils.SendError(resp, err, statusConnectCancelled)
+	case connection.ErrDataCapReached:
+		utils.SendError(resp, err, http.StatusForbidden)
+	default:
+		log.Error().Err(err).Msg("")
+		if errors.Is(err, connection.ErrConnectTimeout) {
+			utils.SendError(resp, err, http.StatusGatewayTimeout)
+			return
+		}
+		utils.SendError(resp, err, http.StatusInternalServerError)
 	}
+}
 
-	err = ce.manager.Connect(consumerID, common.HexToAddress(cr.HermesID), *proposal, getConnectOptions(cr))
+// fetchProposal fetches proposal, giving up after proposal fetch timeout.
+func (ce *ConnectionEndpoint) fetchProposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	if ce.proposalFetchTimeout <= 0 {
+		return ce.proposalRepository.Proposal(id)
+	}
 
-	if err != nil {
-		switch err {
-		case connection.ErrAlreadyExists:
-			utils.SendError(resp, err, http.StatusConflict)
-		case connection.ErrConnectionCancelled:
-			utils.SendError(resp, err, statusConnectCancelled)
-		default:
-			log.Error().Err(err).Msg("")
-			utils.SendError(resp, err, http.StatusInternalServerError)
-		}
+	type result struct {
+		proposal *market.ServiceProposal
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		proposal, err := ce.proposalRepository.Proposal(id)
+		done <- result{proposal: proposal, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.proposal, res.err
+	case <-time.After(ce.proposalFetchTimeout):
+		return nil, &connection.StageTimeoutError{Stage: connection.StageProposalFetch}
+	}
+}
+
+func sendProposalFetchError(resp http.ResponseWriter, err error) {
+	if errors.Is(err, connection.ErrConnectTimeout) {
+		utils.SendError(resp, err, http.StatusGatewayTimeout)
 		return
 	}
-	resp.WriteHeader(http.StatusCreated)
-	ce.Status(resp, req, params)
+	utils.SendError(resp, err, http.StatusInternalServerError)
 }
 
 // Kill stops connection
@@ -207,7 +332,7 @@ func (ce *ConnectionEndpoint) Kill(resp http.ResponseWriter, req *http.Request,
 	err := ce.manager.Disconnect()
 	if err != nil {
 		switch err {
-		case connection.ErrNoConnection:
+		case connection.ErrNoConnection, connection.ErrSplitTunnelModeChange:
 			utils.SendError(resp, err, http.StatusConflict)
 		default:
 			utils.SendError(resp, err, http.StatusInternalServerError)
@@ -233,19 +358,170 @@ func (ce *ConnectionEndpoint) Kill(resp http.ResponseWriter, req *http.Request,
 //       "$ref": "#/definitions/ErrorMessageDTO"
 func (ce *ConnectionEndpoint) GetStatistics(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 	connection := ce.stateProvider.GetState().Connection
-	response := contract.NewConnectionStatisticsDTO(connection.Session, connection.Statistics, connection.Throughput, connection.Invoice)
+	response := contract.NewConnectionStatisticsDTO(connection.Session, connection.Statistics, connection.Throughput, connection.Invoice, connection.Health)
 
 	utils.WriteAsJSON(response, writer)
 }
 
+// UpdateSplitTunnel replaces split tunnel rules of current connection
+// swagger:operation PUT /connection/split-tunnel Connection connectionUpdateSplitTunnel
+// ---
+// summary: Updates split tunnel rules
+// description: Replaces split tunnel rules of current connection
+// parameters:
+//   - in: body
+//     name: body
+//     description: Split tunnel rules
+//     schema:
+//       $ref: "#/definitions/SplitTunnelDTO"
+// responses:
+//   202:
+//     description: Split tunnel rules updated
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Conflict. No connection exists or exclusive mode is switched while connected
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ce *ConnectionEndpoint) UpdateSplitTunnel(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var dto contract.SplitTunnelDTO
+	if err := json.NewDecoder(req.Body).Decode(&dto); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	splitTunnel := dto.ToSplitTunnel()
+	if err := splitTunnel.Validate(); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ce.manager.UpdateSplitTunnel(splitTunnel)
+	if err != nil {
+		switch err {
+		case connection.ErrNoConnection, connection.ErrSplitTunnelModeChange:
+			utils.SendError(resp, err, http.StatusConflict)
+		default:
+			utils.SendError(resp, err, http.StatusInternalServerError)
+		}
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// SetBandwidthLimit changes consumer tunnel speed limit
+// swagger:operation PUT /connection/bandwidth-limit Connection connectionSetBandwidthLimit
+// ---
+// summary: Sets bandwidth limit
+// description: Limits speed of current and next connections, empty limit removes it. Supported on Linux only
+// parameters:
+//   - in: body
+//     name: body
+//     description: Bandwidth limit
+//     schema:
+//       $ref: "#/definitions/BandwidthLimitDTO"
+// responses:
+//   202:
+//     description: Bandwidth limit updated
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ce *ConnectionEndpoint) SetBandwidthLimit(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var dto contract.BandwidthLimitDTO
+	if err := json.NewDecoder(req.Body).Decode(&dto); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	limit, err := dto.ToBitSpeed()
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := ce.manager.SetMaxBandwidth(limit); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// Pause routes consumer traffic around the tunnel
+// swagger:operation PUT /connection/pause Connection connectionPause
+// ---
+// summary: Pauses current connection
+// description: Routes traffic around the tunnel and lifts the kill switch, session and payments are kept alive
+// responses:
+//   202:
+//     description: Connection paused
+//   409:
+//     description: Conflict. No connection exists or it can not be paused
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ce *ConnectionEndpoint) Pause(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	ce.sendPauseResult(resp, ce.manager.Pause())
+}
+
+// Resume routes consumer traffic back into the tunnel
+// swagger:operation PUT /connection/resume Connection connectionResume
+// ---
+// summary: Resumes paused connection
+// description: Routes traffic back into the tunnel and restores the kill switch
+// responses:
+//   202:
+//     description: Connection resumed
+//   409:
+//     description: Conflict. No connection exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ce *ConnectionEndpoint) Resume(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	ce.sendPauseResult(resp, ce.manager.Resume())
+}
+
+func (ce *ConnectionEndpoint) sendPauseResult(resp http.ResponseWriter, err error) {
+	switch err {
+	case nil:
+		resp.WriteHeader(http.StatusAccepted)
+	case connection.ErrNoConnection, connection.ErrPauseNotSupported:
+		utils.SendError(resp, err, http.StatusConflict)
+	default:
+		utils.SendError(resp, err, http.StatusInternalServerError)
+	}
+}
+
 // AddRoutesForConnection adds connections routes to given router
 func AddRoutesForConnection(router *httprouter.Router, manager connection.Manager,
-	stateProvider stateProvider, proposalRepository proposal.Repository, identityRegistry identityRegistry) {
+	stateProvider stateProvider, proposalRepository proposal.Repository, identityRegistry identityRegistry, proposalFetchTimeout time.Duration, hermesResolver hermesResolver) {
 	connectionEndpoint := NewConnectionEndpoint(manager, stateProvider, proposalRepository, identityRegistry)
+	connectionEndpoint.proposalFetchTimeout = proposalFetchTimeout
+	connectionEndpoint.hermesResolver = hermesResolver
 	router.GET("/connection", connectionEndpoint.Status)
 	router.PUT("/connection", connectionEndpoint.Create)
 	router.DELETE("/connection", connectionEndpoint.Kill)
 	router.GET("/connection/statistics", connectionEndpoint.GetStatistics)
+	router.PUT("/connection/split-tunnel", connectionEndpoint.UpdateSplitTunnel)
+	router.PUT("/connection/bandwidth-limit", connectionEndpoint.SetBandwidthLimit)
+	router.PUT("/connection/pause", connectionEndpoint.Pause)
+	router.PUT("/connection/resume", connectionEndpoint.Resume)
 }
 
 func toConnectionRequest(req *http.Request) (*contract.ConnectionCreateRequest, error) {
@@ -254,7 +530,6 @@ func toConnectionRequest(req *http.Request) (*contract.ConnectionCreateRequest,
 			DisableKillSwitch: false,
 			DNS:               connection.DNSOptionAuto,
 		},
-		HermesID: config.GetString(config.FlagHermesID),
 	}
 	err := json.NewDecoder(req.Body).Decode(&connectionRequest)
 	if err != nil {
@@ -269,8 +544,28 @@ func getConnectOptions(cr *contract.ConnectionCreateRequest) connection.ConnectP
 		dns = cr.ConnectOptions.DNS
 	}
 
-	return connection.ConnectParams{
-		DisableKillSwitch: cr.ConnectOptions.DisableKillSwitch,
-		DNS:               dns,
+	params := connection.ConnectParams{
+		DisableKillSwitch:   cr.ConnectOptions.DisableKillSwitch,
+		DNS:                 dns,
+		DisconnectOnDNSLeak: cr.ConnectOptions.DisconnectOnDNSLeak,
+		ProbeCandidates:     cr.ConnectOptions.ProbeCandidates,
+		MTU:                 cr.ConnectOptions.MTU,
+	}
+	if cr.ConnectOptions.KillSwitchScope != nil {
+		params.KillSwitch = cr.ConnectOptions.KillSwitchScope.ToKillSwitchOptions()
+	}
+	if cr.ConnectOptions.SplitTunnel != nil {
+		params.SplitTunnel = cr.ConnectOptions.SplitTunnel.ToSplitTunnel()
+	}
+	if cr.ConnectOptions.DataCap != nil {
+		params.DataCap = cr.ConnectOptions.DataCap.ToDataCap()
+	}
+	if cr.ConnectOptions.Retry != nil {
+		retry := cr.ConnectOptions.Retry.ToRetryPolicy()
+		params.Retry = &retry
+	}
+	if cr.ConnectOptions.PolicyRouting != nil {
+		params.PolicyRouting = cr.ConnectOptions.PolicyRouting.ToPolicyRouting()
 	}
+	return params
 }
@@ -0,0 +1,193 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+type sessionManager interface {
+	connection.Manager
+	ConnectSession(name string, consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params connection.ConnectParams) error
+	DisconnectSession(name string) error
+	SessionStatus(name string) (connection.SessionStatus, error)
+	Sessions() map[string]connection.SessionStatus
+}
+
+// ConnectionSessionsEndpoint struct represents /connection/sessions resource and it's subresources
+type ConnectionSessionsEndpoint struct {
+	manager    sessionManager
+	connection *ConnectionEndpoint
+}
+
+// NewConnectionSessionsEndpoint creates and returns additional sessions endpoint
+func NewConnectionSessionsEndpoint(manager sessionManager, connectionEndpoint *ConnectionEndpoint) *ConnectionSessionsEndpoint {
+	return &ConnectionSessionsEndpoint{
+		manager:    manager,
+		connection: connectionEndpoint,
+	}
+}
+
+// List returns additional consumer sessions
+// swagger:operation GET /connection/sessions Connection listConnectionSessions
+// ---
+// summary: Returns additional consumer sessions
+// description: Returns sessions kept alive alongside the main connection, each with its own statistics and payments
+// responses:
+//   200:
+//     description: List of additional sessions
+//     schema:
+//       "$ref": "#/definitions/ListConnectionSessionsResponse"
+func (se *ConnectionSessionsEndpoint) List(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	sessions := se.manager.Sessions()
+
+	res := contract.ListConnectionSessionsResponse{Sessions: []contract.ConnectionSessionDTO{}}
+	for name, status := range sessions {
+		res.Sessions = append(res.Sessions, contract.NewConnectionSessionDTO(name, status))
+	}
+	sort.Slice(res.Sessions, func(i, j int) bool {
+		return res.Sessions[i].Name < res.Sessions[j].Name
+	})
+	utils.WriteAsJSON(res, resp)
+}
+
+// Create starts additional consumer session
+// swagger:operation PUT /connection/sessions/{name} Connection createConnectionSession
+// ---
+// summary: Starts additional consumer session
+// description: Consumer opens additional session alongside the main connection. Session tunnel carries only traffic bound to its network interface,
+//   kill switch and DNS of the main connection are left intact.
+// parameters:
+//   - name: name
+//     in: path
+//     description: session name
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Parameters in body (consumer_id, provider_id, service_type) required for creating new session
+//     schema:
+//       $ref: "#/definitions/ConnectionCreateRequestDTO"
+// responses:
+//   201:
+//     description: Session started
+//     schema:
+//       "$ref": "#/definitions/ConnectionSessionDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Conflict. Session already exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   504:
+//     description: Connect timed out
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ConnectionSessionsEndpoint) Create(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	cr, ok := se.connection.resolveConnectRequest(resp, req)
+	if !ok {
+		return
+	}
+
+	name := params.ByName("name")
+	if err := se.manager.ConnectSession(name, cr.consumerID, cr.hermesID, cr.proposal, cr.params); err != nil {
+		sendConnectError(resp, err)
+		return
+	}
+
+	status, err := se.manager.SessionStatus(name)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	utils.WriteAsJSON(contract.NewConnectionSessionDTO(name, status), resp)
+}
+
+// Kill stops additional consumer session
+// swagger:operation DELETE /connection/sessions/{name} Connection cancelConnectionSession
+// ---
+// summary: Stops additional consumer session
+// parameters:
+//   - name: name
+//     in: path
+//     description: session name
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: Session stopped
+//   404:
+//     description: Session not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Conflict. Session is not connected
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ConnectionSessionsEndpoint) Kill(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	err := se.manager.DisconnectSession(params.ByName("name"))
+	switch err {
+	case nil:
+		resp.WriteHeader(http.StatusAccepted)
+	case connection.ErrSessionNotFound:
+		utils.SendError(resp, err, http.StatusNotFound)
+	case connection.ErrNoConnection:
+		utils.SendError(resp, err, http.StatusConflict)
+	default:
+		utils.SendError(resp, err, http.StatusInternalServerError)
+	}
+}
+
+// AddRoutesForConnectionSessions attaches additional session endpoints to router
+func AddRoutesForConnectionSessions(router *httprouter.Router, manager sessionManager,
+	stateProvider stateProvider, proposalRepository proposal.Repository, identityRegistry identityRegistry, proposalFetchTimeout time.Duration, hermesResolver hermesResolver) {
+	connectionEndpoint := NewConnectionEndpoint(manager, stateProvider, proposalRepository, identityRegistry)
+	connectionEndpoint.proposalFetchTimeout = proposalFetchTimeout
+	connectionEndpoint.hermesResolver = hermesResolver
+	sessionsEndpoint := NewConnectionSessionsEndpoint(manager, connectionEndpoint)
+	router.GET("/connection/sessions", sessionsEndpoint.List)
+	router.PUT("/connection/sessions/:name", sessionsEndpoint.Create)
+	router.DELETE("/connection/sessions/:name", sessionsEndpoint.Kill)
+}
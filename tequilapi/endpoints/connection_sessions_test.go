@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+type mockSessionManager struct {
+	mockConnectionManager
+	sessions map[string]connection.SessionStatus
+}
+
+func newMockSessionManager() *mockSessionManager {
+	return &mockSessionManager{sessions: make(map[string]connection.SessionStatus)}
+}
+
+func (m *mockSessionManager) ConnectSession(name string, consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params connection.ConnectParams) error {
+	if err := m.Connect(consumerID, hermesID, proposal, params); err != nil {
+		return err
+	}
+	m.sessions[name] = connection.SessionStatus{
+		Status: connectionstate.Status{State: connectionstate.Connected, ConsumerID: consumerID, Proposal: proposal, InterfaceName: "myst1"},
+	}
+	return nil
+}
+
+func (m *mockSessionManager) DisconnectSession(name string) error {
+	if _, ok := m.sessions[name]; !ok {
+		return connection.ErrSessionNotFound
+	}
+	delete(m.sessions, name)
+	return nil
+}
+
+func (m *mockSessionManager) SessionStatus(name string) (connection.SessionStatus, error) {
+	status, ok := m.sessions[name]
+	if !ok {
+		return connection.SessionStatus{}, connection.ErrSessionNotFound
+	}
+	return status, nil
+}
+
+func (m *mockSessionManager) Sessions() map[string]connection.SessionStatus {
+	return m.sessions
+}
+
+func TestConnectionSessionsCreateAndList(t *testing.T) {
+	manager := newMockSessionManager()
+	router := httprouter.New()
+	AddRoutesForConnectionSessions(router, manager, &mockStateProvider{}, mockRepositoryWithProposal("required-node", "wireguard"), mockIdentityRegistryInstance, 0, nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/connection/sessions/streaming", strings.NewReader(
+		`{"consumer_id": "my-identity", "provider_id": "required-node", "hermes_id": "hermes", "service_type": "wireguard"}`,
+	))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, "required-node", manager.requestedProvider.Address)
+	assert.Contains(t, resp.Body.String(), `"name":"streaming"`)
+	assert.Contains(t, resp.Body.String(), `"interface_name":"myst1"`)
+
+	req = httptest.NewRequest(http.MethodGet, "/connection/sessions", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"status":"Connected"`)
+	assert.Contains(t, resp.Body.String(), `"name":"streaming"`)
+}
+
+func TestConnectionSessionsCreateReturnsConflict(t *testing.T) {
+	manager := newMockSessionManager()
+	manager.onConnectReturn = connection.ErrAlreadyExists
+	router := httprouter.New()
+	AddRoutesForConnectionSessions(router, manager, &mockStateProvider{}, mockRepositoryWithProposal("required-node", "wireguard"), mockIdentityRegistryInstance, 0, nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/connection/sessions/streaming", strings.NewReader(
+		`{"consumer_id": "my-identity", "provider_id": "required-node", "hermes_id": "hermes", "service_type": "wireguard"}`,
+	))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+}
+
+func TestConnectionSessionsKill(t *testing.T) {
+	manager := newMockSessionManager()
+	manager.sessions["streaming"] = connection.SessionStatus{}
+	router := httprouter.New()
+	AddRoutesForConnectionSessions(router, manager, &mockStateProvider{}, &mockProposalRepository{}, mockIdentityRegistryInstance, 0, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/connection/sessions/streaming", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/connection/sessions/streaming", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+type connectionSwitcher interface {
+	connection.Manager
+	Switch(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params connection.ConnectParams) error
+}
+
+// ConnectionSwitchEndpoint struct represents /connection/switch resource
+type ConnectionSwitchEndpoint struct {
+	manager    connectionSwitcher
+	connection *ConnectionEndpoint
+}
+
+// NewConnectionSwitchEndpoint creates and returns connection switch endpoint
+func NewConnectionSwitchEndpoint(manager connectionSwitcher, connectionEndpoint *ConnectionEndpoint) *ConnectionSwitchEndpoint {
+	return &ConnectionSwitchEndpoint{
+		manager:    manager,
+		connection: connectionEndpoint,
+	}
+}
+
+// Switch switches established connection to the new provider
+// swagger:operation POST /connection/switch Connection connectionSwitch
+// ---
+// summary: Switches connection to the new provider
+// description: Consumer connects to the new provider while the current connection is kept alive,
+//   the current connection is torn down only once the new one is ready to carry traffic.
+//   Current connection is left intact if the new provider can not be reached.
+// parameters:
+//   - in: body
+//     name: body
+//     description: Parameters in body (consumer_id, provider_id, service_type) required for connecting to the new provider
+//     schema:
+//       $ref: "#/definitions/ConnectionCreateRequestDTO"
+// responses:
+//   200:
+//     description: Connection switched
+//     schema:
+//       "$ref": "#/definitions/ConnectionInfoDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Conflict. There is no connection, or it can not be switched seamlessly
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   504:
+//     description: Connect timed out
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ConnectionSwitchEndpoint) Switch(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	cr, ok := se.connection.resolveConnectRequest(resp, req)
+	if !ok {
+		return
+	}
+
+	err := se.manager.Switch(cr.consumerID, cr.hermesID, cr.proposal, cr.params)
+	switch err {
+	case nil:
+		se.connection.Status(resp, req, params)
+	case connection.ErrNoConnection, connection.ErrSwitchNotSupported:
+		utils.SendError(resp, err, http.StatusConflict)
+	default:
+		sendConnectError(resp, err)
+	}
+}
+
+// AddRoutesForConnectionSwitch attaches connection switch endpoint to router
+func AddRoutesForConnectionSwitch(router *httprouter.Router, manager connectionSwitcher,
+	stateProvider stateProvider, proposalRepository proposal.Repository, identityRegistry identityRegistry, proposalFetchTimeout time.Duration, hermesResolver hermesResolver) {
+	connectionEndpoint := NewConnectionEndpoint(manager, stateProvider, proposalRepository, identityRegistry)
+	connectionEndpoint.proposalFetchTimeout = proposalFetchTimeout
+	connectionEndpoint.hermesResolver = hermesResolver
+	switchEndpoint := NewConnectionSwitchEndpoint(manager, connectionEndpoint)
+	router.POST("/connection/switch", switchEndpoint.Switch)
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+type mockConnectionSwitcher struct {
+	mockConnectionManager
+	onSwitchReturn error
+}
+
+func (m *mockConnectionSwitcher) Switch(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, params connection.ConnectParams) error {
+	if m.onSwitchReturn != nil {
+		return m.onSwitchReturn
+	}
+	m.onStatusReturn = connectionstate.Status{State: connectionstate.Connected, ConsumerID: consumerID, Proposal: proposal}
+	return m.Connect(consumerID, hermesID, proposal, params)
+}
+
+func TestConnectionSwitch(t *testing.T) {
+	manager := &mockConnectionSwitcher{}
+	router := httprouter.New()
+	AddRoutesForConnectionSwitch(router, manager, &mockStateProvider{}, mockRepositoryWithProposal("required-node", "wireguard"), mockIdentityRegistryInstance, 0, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/connection/switch", strings.NewReader(
+		`{"consumer_id": "my-identity", "provider_id": "required-node", "hermes_id": "hermes", "service_type": "wireguard"}`,
+	))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "required-node", manager.requestedProvider.Address)
+	assert.Contains(t, resp.Body.String(), `"status":"Connected"`)
+	assert.Contains(t, resp.Body.String(), `"provider_id":"required-node"`)
+}
+
+func TestConnectionSwitchReturnsConflict(t *testing.T) {
+	for _, err := range []error{connection.ErrNoConnection, connection.ErrSwitchNotSupported} {
+		manager := &mockConnectionSwitcher{onSwitchReturn: err}
+		router := httprouter.New()
+		AddRoutesForConnectionSwitch(router, manager, &mockStateProvider{}, mockRepositoryWithProposal("required-node", "wireguard"), mockIdentityRegistryInstance, 0, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/connection/switch", strings.NewReader(
+			`{"consumer_id": "my-identity", "provider_id": "required-node", "hermes_id": "hermes", "service_type": "wireguard"}`,
+		))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code, err.Error())
+	}
+}
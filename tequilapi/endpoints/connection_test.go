@@ -19,6 +19,7 @@ package endpoints
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
@@ -41,15 +42,22 @@ import (
 )
 
 type mockConnectionManager struct {
-	onConnectReturn      error
-	onDisconnectReturn   error
-	onCheckChannelReturn error
-	onStatusReturn       connectionstate.Status
-	disconnectCount      int
-	requestedConsumerID  identity.Identity
-	requestedProvider    identity.Identity
-	requestedHermesID    common.Address
-	requestedServiceType string
+	onConnectReturn           error
+	onDisconnectReturn        error
+	onCheckChannelReturn      error
+	onUpdateSplitTunnelReturn error
+	onSetMaxBandwidthReturn   error
+	onPauseReturn             error
+	onResumeReturn            error
+	onStatusReturn            connectionstate.Status
+	disconnectCount           int
+	requestedConsumerID       identity.Identity
+	requestedProvider         identity.Identity
+	requestedHermesID         common.Address
+	requestedServiceType      string
+	requestedSplitTunnel      connection.SplitTunnel
+	requestedParams           connection.ConnectParams
+	requestedMaxBandwidth     datasize.BitSpeed
 }
 
 func (cm *mockConnectionManager) Connect(consumerID identity.Identity, hermesID common.Address, proposal market.ServiceProposal, options connection.ConnectParams) error {
@@ -57,6 +65,7 @@ func (cm *mockConnectionManager) Connect(consumerID identity.Identity, hermesID
 	cm.requestedHermesID = hermesID
 	cm.requestedProvider = identity.FromAddress(proposal.ProviderID)
 	cm.requestedServiceType = proposal.ServiceType
+	cm.requestedParams = options
 	return cm.onConnectReturn
 }
 
@@ -77,6 +86,28 @@ func (cm *mockConnectionManager) Reconnect() {
 	return
 }
 
+func (cm *mockConnectionManager) Rebind(context.Context) error {
+	return nil
+}
+
+func (cm *mockConnectionManager) UpdateSplitTunnel(splitTunnel connection.SplitTunnel) error {
+	cm.requestedSplitTunnel = splitTunnel
+	return cm.onUpdateSplitTunnelReturn
+}
+
+func (cm *mockConnectionManager) SetMaxBandwidth(limit datasize.BitSpeed) error {
+	cm.requestedMaxBandwidth = limit
+	return cm.onSetMaxBandwidthReturn
+}
+
+func (cm *mockConnectionManager) Pause() error {
+	return cm.onPauseReturn
+}
+
+func (cm *mockConnectionManager) Resume() error {
+	return cm.onResumeReturn
+}
+
 func (cm *mockConnectionManager) Wait() error {
 	return nil
 }
@@ -105,7 +136,7 @@ func TestAddRoutesForConnectionAddsRoutes(t *testing.T) {
 	fakeState.stateToReturn.Connection.Statistics = connectionstate.Statistics{BytesSent: 1, BytesReceived: 2}
 
 	mockedProposalProvider := mockRepositoryWithProposal("node1", "noop")
-	AddRoutesForConnection(router, fakeManager, fakeState, mockedProposalProvider, mockIdentityRegistryInstance)
+	AddRoutesForConnection(router, fakeManager, fakeState, mockedProposalProvider, mockIdentityRegistryInstance, time.Minute, nil)
 
 	tests := []struct {
 		method         string
@@ -181,6 +212,98 @@ func TestStateIsReturnedFromStore(t *testing.T) {
 	)
 }
 
+func TestStatusReturnsDisconnectReason(t *testing.T) {
+	manager := &mockConnectionManager{
+		onStatusReturn: connectionstate.Status{
+			State:            connectionstate.NotConnected,
+			SessionID:        "1",
+			DisconnectReason: connectionstate.DisconnectReasonPaymentFailed,
+		},
+	}
+
+	connEndpoint := NewConnectionEndpoint(manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Status(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"status" : "NotConnected",
+			"session_id" : "1",
+			"disconnect_reason": "PaymentFailed"
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func TestStatusReturnsProbeResults(t *testing.T) {
+	manager := &mockConnectionManager{
+		onStatusReturn: connectionstate.Status{
+			State: connectionstate.Probing,
+			Probes: []connectionstate.ProbeResult{
+				{ProviderID: "0x2", Latency: 45 * time.Millisecond},
+				{ProviderID: "0x3", Error: "p2p dialer failed"},
+			},
+		},
+	}
+
+	connEndpoint := NewConnectionEndpoint(manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Status(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"status" : "Probing",
+			"probes": [
+				{"provider_id": "0x2", "latency": 45},
+				{"provider_id": "0x3", "error": "p2p dialer failed"}
+			]
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func TestStatusReturnsConnectionPhases(t *testing.T) {
+	startedAt := time.Date(2020, 7, 1, 10, 11, 12, 0, time.UTC)
+	manager := &mockConnectionManager{
+		onStatusReturn: connectionstate.Status{
+			State: connectionstate.Connecting,
+			Phase: connectionstate.PhaseDialingP2P,
+			Phases: []connectionstate.PhaseTiming{
+				{Phase: connectionstate.PhaseResolvingProposal, StartedAt: startedAt},
+				{Phase: connectionstate.PhaseDialingP2P, StartedAt: startedAt.Add(150 * time.Millisecond)},
+			},
+		},
+	}
+
+	connEndpoint := NewConnectionEndpoint(manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Status(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"status" : "Connecting",
+			"phase": "DialingP2P",
+			"phases": [
+				{"phase": "ResolvingProposal", "started_at": "2020-07-01T10:11:12Z"},
+				{"phase": "DialingP2P", "started_at": "2020-07-01T10:11:12.15Z"}
+			]
+		}`,
+		resp.Body.String(),
+	)
+}
+
 func TestPutReturns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
@@ -347,6 +470,254 @@ func TestPutWithServiceTypeOverridesDefault(t *testing.T) {
 	assert.Equal(t, "noop", fakeManager.requestedServiceType)
 }
 
+func TestPutWithKillSwitchScope(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	mystAPI := mockRepositoryWithProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"hermes_id": "hermes",
+				"connect_options": {
+					"kill_switch_scope": {"mode": "always", "allow_lan": true, "allowed_ports": [22]}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(
+		t,
+		connection.KillSwitchOptions{Mode: connection.KillSwitchModeAlways, AllowLAN: true, AllowedPorts: []int{22}},
+		fakeManager.requestedParams.KillSwitch,
+	)
+}
+
+func TestPutWithDataCap(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	mystAPI := mockRepositoryWithProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"hermes_id": "hermes",
+				"connect_options": {
+					"data_cap": {"megabytes": 2, "period": "day"}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(
+		t,
+		connection.DataCap{Bytes: 2 * 1024 * 1024, Period: connection.DataCapPerDay},
+		fakeManager.requestedParams.DataCap,
+	)
+}
+
+func TestPutWithRetryPolicy(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	mystAPI := mockRepositoryWithProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"hermes_id": "hermes",
+				"connect_options": {
+					"retry": {"attempts": 5, "backoff": 0.5, "jitter": 0.1}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(
+		t,
+		&connection.RetryPolicy{Attempts: 5, Backoff: 500 * time.Millisecond, Jitter: 0.1},
+		fakeManager.requestedParams.Retry,
+	)
+}
+
+func TestPutWithProbeCandidates(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	mystAPI := mockRepositoryWithProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"hermes_id": "hermes",
+				"connect_options": {"probe_candidates": 3}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, 3, fakeManager.requestedParams.ProbeCandidates)
+}
+
+func TestPutWithPolicyRouting(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	mystAPI := mockRepositoryWithProposal("required-node", "wireguard")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"hermes_id": "hermes",
+				"service_type": "wireguard",
+				"connect_options": {
+					"policy_routing": {"fw_mark": 51820, "table": 100}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, connection.PolicyRouting{FwMark: 51820, Table: 100}, fakeManager.requestedParams.PolicyRouting)
+}
+
+func TestPutWithPolicyRoutingWithoutMark(t *testing.T) {
+	mystAPI := mockRepositoryWithProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&mockConnectionManager{}, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"connect_options": {
+					"policy_routing": {"table": 100}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(t,
+		`{
+			"message": "validation_error",
+			"errors": {
+				"connect_options.policy_routing.fw_mark": [{"code": "required", "message": "Field is required"}]
+			}
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func TestPutWithInvalidMTU(t *testing.T) {
+	mystAPI := mockRepositoryWithProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&mockConnectionManager{}, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"connect_options": {"mtu": 9000}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(t,
+		`{
+			"message": "validation_error",
+			"errors": {
+				"connect_options.mtu": [{"code": "invalid", "message": "MTU should be between 1280 and 1500"}]
+			}
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func TestPutWithInvalidRetryPolicy(t *testing.T) {
+	mystAPI := mockRepositoryWithProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&mockConnectionManager{}, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"connect_options": {
+					"retry": {"attempts": -1}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(t,
+		`{
+			"message": "validation_error",
+			"errors": {
+				"connect_options.retry": [{"code": "invalid", "message": "connect attempts can not be negative: -1"}]
+			}
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func TestPutReturnsForbiddenWhenDataCapIsReached(t *testing.T) {
+	fakeManager := mockConnectionManager{onConnectReturn: connection.ErrDataCapReached}
+
+	mystAPI := mockRepositoryWithProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"hermes_id": "hermes",
+				"connect_options": {
+					"data_cap": {"megabytes": 2, "period": "day"}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
 func TestDeleteCallsDisconnect(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
@@ -470,6 +841,34 @@ func TestConnectReturnsConnectCancelledStatusWhenErrConnectionCancelledIsEncount
 	)
 }
 
+func TestConnectReturnsGatewayTimeoutWhenConnectStageTimesOut(t *testing.T) {
+	manager := mockConnectionManager{}
+	manager.onConnectReturn = fmt.Errorf("could not create p2p channel: %w", &connection.StageTimeoutError{Stage: connection.StageP2PDial})
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, mockRepositoryWithProposal("required-node", "openvpn"), mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"hermes_id" : "hermes"
+			}`))
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.Create(resp, req, nil)
+
+	assert.Equal(t, http.StatusGatewayTimeout, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message" : "could not create p2p channel: connect timed out during p2p-dial stage"
+		}`,
+		resp.Body.String(),
+	)
+}
+
 func TestConnectReturnsErrorIfNoProposals(t *testing.T) {
 	manager := mockConnectionManager{}
 	manager.onConnectReturn = connection.ErrConnectionCancelled
@@ -498,4 +897,114 @@ func TestConnectReturnsErrorIfNoProposals(t *testing.T) {
 	)
 }
 
+func TestUpdateSplitTunnel(t *testing.T) {
+	manager := mockConnectionManager{}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(`{"mode": "bypass", "networks": ["192.168.0.0/16"], "domains": ["example.com"]}`),
+	)
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.UpdateSplitTunnel(resp, req, nil)
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(
+		t,
+		connection.SplitTunnel{
+			Mode:     connection.SplitTunnelBypass,
+			Networks: []string{"192.168.0.0/16"},
+			Domains:  []string{"example.com"},
+		},
+		manager.requestedSplitTunnel,
+	)
+}
+
+func TestUpdateSplitTunnelReturnsConflictWhenNotConnected(t *testing.T) {
+	manager := mockConnectionManager{onUpdateSplitTunnelReturn: connection.ErrNoConnection}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader(`{"mode": "bypass", "networks": ["10.0.0.0/8"]}`))
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.UpdateSplitTunnel(resp, req, nil)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+}
+
+func TestUpdateSplitTunnelValidatesRules(t *testing.T) {
+	manager := mockConnectionManager{}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader(`{"mode": "bypass", "networks": ["not-a-network"]}`))
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.UpdateSplitTunnel(resp, req, nil)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestSetBandwidthLimit(t *testing.T) {
+	manager := mockConnectionManager{}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader(`{"max_bandwidth": "10mbps"}`))
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.SetBandwidthLimit(resp, req, nil)
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, datasize.BitSpeed(10*1000*1000), manager.requestedMaxBandwidth)
+}
+
+func TestSetBandwidthLimitValidatesSpeed(t *testing.T) {
+	manager := mockConnectionManager{}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader(`{"max_bandwidth": "10 apples"}`))
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.SetBandwidthLimit(resp, req, nil)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestPauseConnection(t *testing.T) {
+	manager := mockConnectionManager{}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.Pause(resp, req, nil)
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+}
+
+func TestPauseReturnsConflictWhenNotSupported(t *testing.T) {
+	manager := mockConnectionManager{onPauseReturn: connection.ErrPauseNotSupported}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.Pause(resp, req, nil)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+}
+
+func TestResumeReturnsConflictWhenNotConnected(t *testing.T) {
+	manager := mockConnectionManager{onResumeReturn: connection.ErrNoConnection}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.Resume(resp, req, nil)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+}
+
 var mockIdentityRegistryInstance = &registry.FakeRegistry{RegistrationStatus: registry.Registered}
@@ -22,27 +22,34 @@ import (
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/discovery/apidiscovery"
 	"github.com/mysteriumnetwork/node/metadata"
 	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
 )
 
+type discoveryStatus interface {
+	Status() apidiscovery.FailoverStatus
+}
+
 type healthCheckEndpoint struct {
 	startTime       time.Time
 	currentTimeFunc func() time.Time
 	processNumber   int
+	discovery       discoveryStatus
 }
 
 /*
 HealthCheckEndpointFactory creates a structure with single HealthCheck method for healthcheck serving as http,
-currentTimeFunc is injected for easier testing
+currentTimeFunc is injected for easier testing, discovery is optional
 */
-func HealthCheckEndpointFactory(currentTimeFunc func() time.Time, procID func() int) *healthCheckEndpoint {
+func HealthCheckEndpointFactory(currentTimeFunc func() time.Time, procID func() int, discovery discoveryStatus) *healthCheckEndpoint {
 	startTime := currentTimeFunc()
 	return &healthCheckEndpoint{
 		startTime,
 		currentTimeFunc,
 		procID(),
+		discovery,
 	}
 }
 
@@ -70,5 +77,8 @@ func (hce *healthCheckEndpoint) HealthCheck(writer http.ResponseWriter, request
 			BuildNumber: metadata.BuildNumber,
 		},
 	}
+	if hce.discovery != nil {
+		status.Discovery = contract.NewDiscoveryHealthDTO(hce.discovery.Status())
+	}
 	utils.WriteAsJSON(status, writer)
 }
@@ -18,12 +18,15 @@
 package endpoints
 
 import (
+	"encoding/json"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/discovery/apidiscovery"
 	"github.com/mysteriumnetwork/node/metadata"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,6 +45,7 @@ func TestHealthCheckReturnsExpectedJSONObject(t *testing.T) {
 	handlerFunc := HealthCheckEndpointFactory(
 		newMockTimer([]time.Time{tick1, tick2}).Now,
 		func() int { return 1 },
+		nil,
 	).HealthCheck
 	handlerFunc(resp, req, httprouter.Params{})
 
@@ -60,6 +64,41 @@ func TestHealthCheckReturnsExpectedJSONObject(t *testing.T) {
 		resp.Body.String())
 }
 
+type mockDiscoveryStatus apidiscovery.FailoverStatus
+
+func (m mockDiscoveryStatus) Status() apidiscovery.FailoverStatus {
+	return apidiscovery.FailoverStatus(m)
+}
+
+func TestHealthCheckReturnsActiveDiscoveryEndpoint(t *testing.T) {
+	req := httptest.NewRequest("GET", "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	discovery := mockDiscoveryStatus{
+		Active: "http://fallback",
+		Endpoints: []apidiscovery.EndpointStatus{
+			{Address: "http://primary", Healthy: false},
+			{Address: "http://fallback", Healthy: true},
+		},
+	}
+	handlerFunc := HealthCheckEndpointFactory(time.Now, func() int { return 1 }, discovery).HealthCheck
+	handlerFunc(resp, req, httprouter.Params{})
+
+	var status contract.HealthCheckDTO
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+	assert.Equal(
+		t,
+		&contract.DiscoveryHealthDTO{
+			ActiveEndpoint: "http://fallback",
+			Endpoints: []contract.DiscoveryEndpointDTO{
+				{Address: "http://primary", Healthy: false},
+				{Address: "http://fallback", Healthy: true},
+			},
+		},
+		status.Discovery,
+	)
+}
+
 type mockTimer struct {
 	values  []time.Time
 	current int
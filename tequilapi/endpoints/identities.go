@@ -23,9 +23,11 @@ import (
 	"math/big"
 	"net/http"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/julienschmidt/httprouter"
-	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/consumer/session"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
 	identity_selector "github.com/mysteriumnetwork/node/identity/selector"
@@ -49,6 +51,10 @@ type providerChannel interface {
 	GetProviderChannel(hermesAddress common.Address, provider common.Address, pending bool) (client.ProviderChannel, error)
 }
 
+type identityUsageProvider interface {
+	StatsByIdentity(filter *session.Filter) (map[identity.Identity]session.IdentityStats, error)
+}
+
 type identitiesAPI struct {
 	idm               identity.Manager
 	selector          identity_selector.Handler
@@ -58,12 +64,15 @@ type identitiesAPI struct {
 	earningsProvider  earningsProvider
 	bc                providerChannel
 	transactor        Transactor
+	stateProvider     stateProvider
+	hermesResolver    hermesResolver
+	usageProvider     identityUsageProvider
 }
 
 // swagger:operation GET /identities Identity listIdentities
 // ---
 // summary: Returns identities
-// description: Returns list of identities
+// description: Returns list of identities with their session usage statistics
 // responses:
 //   200:
 //     description: List of identities
@@ -76,6 +85,20 @@ type identitiesAPI struct {
 func (endpoint *identitiesAPI) List(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	ids := endpoint.idm.GetIdentities()
 	idsDTO := contract.NewIdentityListResponse(ids)
+	if endpoint.usageProvider != nil {
+		usage, err := endpoint.usageProvider.StatsByIdentity(session.NewFilter())
+		if err != nil {
+			utils.SendError(resp, err, http.StatusInternalServerError)
+			return
+		}
+		for i, id := range ids {
+			stats, found := usage[id]
+			if !found {
+				stats = session.NewIdentityStats()
+			}
+			idsDTO.Identities[i].Usage = contract.NewIdentityUsageDTO(stats)
+		}
+	}
 	utils.WriteAsJSON(idsDTO, resp)
 }
 
@@ -184,6 +207,116 @@ func (endpoint *identitiesAPI) Create(resp http.ResponseWriter, httpReq *http.Re
 	utils.WriteAsJSON(idDTO, resp)
 }
 
+// swagger:operation POST /identities/mnemonic Identity createIdentityWithMnemonic
+// ---
+// summary: Create new identity from a seed phrase
+// description: Creates identity with the key derived from a new BIP39 seed phrase. The phrase is not stored by the node, it is returned only once.
+// parameters:
+//   - in: body
+//     name: body
+//     description: Parameter in body (passphrase) required for creating new identity
+//     schema:
+//       $ref: "#/definitions/IdentityCreateRequestDTO"
+// responses:
+//   200:
+//     description: Identity created
+//     schema:
+//       "$ref": "#/definitions/IdentityMnemonicDTO"
+//   400:
+//     description: Bad Request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *identitiesAPI) CreateWithMnemonic(resp http.ResponseWriter, httpReq *http.Request, _ httprouter.Params) {
+	var req contract.IdentityCreateRequest
+	err := json.NewDecoder(httpReq.Body).Decode(&req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	if errorMap := req.Validate(); errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	id, mnemonic, err := endpoint.idm.CreateNewIdentityWithMnemonic(*req.Passphrase)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(contract.IdentityMnemonicDTO{Address: id.Address, Mnemonic: mnemonic}, resp)
+}
+
+// swagger:operation POST /identities/import Identity importIdentity
+// ---
+// summary: Import identity from its seed phrase
+// description: Restores identity from BIP39 seed phrase and stores it in keystore protected with the passphrase
+// parameters:
+//   - in: body
+//     name: body
+//     description: Seed phrase and passphrase protecting the imported identity
+//     schema:
+//       $ref: "#/definitions/IdentityImportRequestDTO"
+// responses:
+//   200:
+//     description: Identity imported
+//     schema:
+//       "$ref": "#/definitions/IdentityRefDTO"
+//   400:
+//     description: Bad Request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Identity already exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *identitiesAPI) Import(resp http.ResponseWriter, httpReq *http.Request, _ httprouter.Params) {
+	var req contract.IdentityImportRequest
+	err := json.NewDecoder(httpReq.Body).Decode(&req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	if errorMap := req.Validate(); errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	id, err := endpoint.idm.ImportIdentityFromMnemonic(*req.Mnemonic, *req.Passphrase)
+	switch errors.Cause(err) {
+	case nil:
+	case identity.ErrInvalidMnemonic:
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	case keystore.ErrAccountAlreadyExists:
+		utils.SendError(resp, err, http.StatusConflict)
+		return
+	default:
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(contract.NewIdentityDTO(id), resp)
+}
+
 // swagger:operation PUT /identities/{id}/unlock Identity unlockIdentity
 // ---
 // summary: Unlocks identity
@@ -242,6 +375,144 @@ func (endpoint *identitiesAPI) Unlock(resp http.ResponseWriter, httpReq *http.Re
 	resp.WriteHeader(http.StatusAccepted)
 }
 
+// swagger:operation PUT /identities/{id}/passphrase Identity changeIdentityPassphrase
+// ---
+// summary: Changes identity passphrase
+// description: Re-encrypts keystore file of the identity with the new passphrase
+// parameters:
+// - in: path
+//   name: id
+//   description: Identity stored in keystore
+//   type: string
+//   required: true
+// - in: body
+//   name: body
+//   description: Current and new passphrase of the identity
+//   schema:
+//     $ref: "#/definitions/IdentityPassphraseChangeRequestDTO"
+// responses:
+//   202:
+//     description: Passphrase changed
+//   400:
+//     description: Body parsing error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   403:
+//     description: Current passphrase is wrong
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: Identity not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *identitiesAPI) ChangePassphrase(resp http.ResponseWriter, httpReq *http.Request, params httprouter.Params) {
+	id, err := endpoint.idm.GetIdentity(params.ByName("id"))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+
+	var req contract.IdentityPassphraseChangeRequest
+	if err := json.NewDecoder(httpReq.Body).Decode(&req); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	if errorMap := req.Validate(); errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	err = endpoint.idm.ChangePassphrase(id.Address, *req.CurrentPassphrase, *req.NewPassphrase)
+	switch errors.Cause(err) {
+	case nil:
+	case keystore.ErrDecrypt:
+		utils.SendError(resp, err, http.StatusForbidden)
+		return
+	default:
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// swagger:operation DELETE /identities/{id} Identity deleteIdentity
+// ---
+// summary: Delete identity
+// description: Archives keystore file of the identity and purges data stored for it.
+//   Identity having unsettled earnings or active sessions is not deleted unless forced.
+// parameters:
+//   - in: path
+//     name: id
+//     description: hex address of identity
+//     type: string
+//     required: true
+//   - in: query
+//     name: force
+//     description: delete identity even if it has unsettled earnings or active sessions
+//     type: boolean
+// responses:
+//   202:
+//     description: Identity deleted
+//   404:
+//     description: Identity not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Identity has unsettled earnings or active sessions
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *identitiesAPI) Delete(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := endpoint.idm.GetIdentity(params.ByName("id"))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+
+	if request.URL.Query().Get("force") != "true" {
+		if err := endpoint.checkDeletable(id); err != nil {
+			utils.SendError(resp, err, http.StatusConflict)
+			return
+		}
+	}
+
+	if err := endpoint.idm.DeleteIdentity(id.Address); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// checkDeletable makes sure identity does not have unsettled earnings or active sessions, which would be lost with it.
+func (endpoint *identitiesAPI) checkDeletable(id identity.Identity) error {
+	if unsettled := endpoint.earningsProvider.GetEarnings(id).UnsettledBalance; unsettled != nil && unsettled.Sign() > 0 {
+		return fmt.Errorf("identity has unsettled earnings of %s, settle them or delete with force", unsettled)
+	}
+
+	state := endpoint.stateProvider.GetState()
+	if session := state.Connection.Session; session.State != connectionstate.NotConnected && session.ConsumerID.Address == id.Address {
+		return errors.New("identity has active connection, disconnect or delete with force")
+	}
+	for _, session := range state.Sessions {
+		if session.ProviderID.Address == id.Address {
+			return errors.New("identity has active sessions, stop services or delete with force")
+		}
+	}
+	return nil
+}
+
 // swagger:operation GET /identities/{id} Identity getIdentity
 // ---
 // summary: Get identity
@@ -283,7 +554,7 @@ func (endpoint *identitiesAPI) Get(resp http.ResponseWriter, _ *http.Request, pa
 
 	var stake = new(big.Int)
 	if regStatus == registry.Registered {
-		data, err := endpoint.bc.GetProviderChannel(common.HexToAddress(config.GetString(config.FlagHermesID)), common.HexToAddress(address), false)
+		data, err := endpoint.bc.GetProviderChannel(resolveHermesID(endpoint.hermesResolver, id), common.HexToAddress(address), false)
 		if err != nil {
 			utils.SendError(resp, fmt.Errorf("failed to check identity registration status: %w", err), http.StatusInternalServerError)
 			return
@@ -366,7 +637,7 @@ func (endpoint *identitiesAPI) RegistrationStatus(resp http.ResponseWriter, _ *h
 //       "$ref": "#/definitions/ErrorMessageDTO"
 func (endpoint *identitiesAPI) Beneficiary(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
 	address := params.ByName("id")
-	data, err := endpoint.bc.GetProviderChannel(common.HexToAddress(config.GetString(config.FlagHermesID)), common.HexToAddress(address), false)
+	data, err := endpoint.bc.GetProviderChannel(resolveHermesID(endpoint.hermesResolver, identity.FromAddress(address)), common.HexToAddress(address), false)
 	if err != nil {
 		utils.SendError(resp, fmt.Errorf("failed to check identity registration status: %w", err), http.StatusInternalServerError)
 		return
@@ -418,6 +689,9 @@ func AddRoutesForIdentities(
 	earningsProvider earningsProvider,
 	bc providerChannel,
 	transactor Transactor,
+	stateProvider stateProvider,
+	hermesResolver hermesResolver,
+	usageProvider identityUsageProvider,
 ) {
 	idmEnd := &identitiesAPI{
 		idm:               idm,
@@ -428,9 +702,14 @@ func AddRoutesForIdentities(
 		earningsProvider:  earningsProvider,
 		bc:                bc,
 		transactor:        transactor,
+		stateProvider:     stateProvider,
+		hermesResolver:    hermesResolver,
+		usageProvider:     usageProvider,
 	}
 	router.GET("/identities", idmEnd.List)
 	router.POST("/identities", idmEnd.Create)
+	router.POST("/identities/mnemonic", idmEnd.CreateWithMnemonic)
+	router.POST("/identities/import", idmEnd.Import)
 	router.PUT("/identities/:id", func(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
 		// TODO: remove this hack when we replace our router
 		switch params.ByName("id") {
@@ -441,8 +720,10 @@ func AddRoutesForIdentities(
 		}
 	})
 	router.GET("/identities/:id", idmEnd.Get)
+	router.DELETE("/identities/:id", idmEnd.Delete)
 	router.GET("/identities/:id/status", idmEnd.Get)
 	router.PUT("/identities/:id/unlock", idmEnd.Unlock)
+	router.PUT("/identities/:id/passphrase", idmEnd.ChangePassphrase)
 	router.GET("/identities/:id/registration", idmEnd.RegistrationStatus)
 	router.GET("/identities/:id/beneficiary", idmEnd.Beneficiary)
 	router.GET("/identities/:id/referral", idmEnd.GetReferralToken)
@@ -19,15 +19,21 @@ package endpoints
 
 import (
 	"bytes"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/consumer/session"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
+	stateEvent "github.com/mysteriumnetwork/node/core/state/event"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
 	"github.com/mysteriumnetwork/node/mocks"
 	"github.com/mysteriumnetwork/node/requests"
+	pingpong_event "github.com/mysteriumnetwork/node/session/pingpong/event"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -165,6 +171,44 @@ func TestUnlockFailure(t *testing.T) {
 	assert.Equal(t, "mypassphrase", mockIdm.LastUnlockPassphrase)
 }
 
+func TestChangePassphrase(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body         string
+		failChange   bool
+		expectedCode int
+	}{
+		"changes passphrase": {
+			body:         `{"current_passphrase": "old", "new_passphrase": "new"}`,
+			expectedCode: http.StatusAccepted,
+		},
+		"rejects wrong passphrase": {
+			body:         `{"current_passphrase": "old", "new_passphrase": "new"}`,
+			failChange:   true,
+			expectedCode: http.StatusForbidden,
+		},
+		"requires new passphrase": {
+			body:         `{"current_passphrase": "old"}`,
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockIdm := identity.NewIdentityManagerFake(existingIdentities, newIdentity)
+			if tc.failChange {
+				mockIdm.MarkUnlockToFail()
+			}
+			req, err := http.NewRequest(http.MethodPut, identityUrl, bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			params := httprouter.Params{{Key: "id", Value: "0x000000000000000000000000000000000000000a"}}
+
+			resp := httptest.NewRecorder()
+			endpoint := &identitiesAPI{idm: mockIdm}
+			endpoint.ChangePassphrase(resp, req, params)
+
+			assert.Equal(t, tc.expectedCode, resp.Code)
+		})
+	}
+}
+
 func TestCreateNewIdentityEmptyPassphrase(t *testing.T) {
 	mockIdm := identity.NewIdentityManagerFake(existingIdentities, newIdentity)
 	resp := httptest.NewRecorder()
@@ -229,6 +273,104 @@ func TestCreateNewIdentity(t *testing.T) {
 	)
 }
 
+func TestImportIdentity(t *testing.T) {
+	mockIdm := identity.NewIdentityManagerFake(existingIdentities, newIdentity)
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"/identities/import",
+		bytes.NewBufferString(`{"mnemonic": "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "passphrase": "mypass"}`),
+	)
+	assert.Nil(t, err)
+
+	endpoint := &identitiesAPI{idm: mockIdm}
+	endpoint.Import(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+            "id": "0x000000000000000000000000000000000000aaac"
+        }`,
+		resp.Body.String(),
+	)
+}
+
+func TestImportIdentityInvalidMnemonic(t *testing.T) {
+	mockIdm := identity.NewIdentityManagerFake(existingIdentities, newIdentity)
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"/identities/import",
+		bytes.NewBufferString(`{"mnemonic": "abandon abandon abandon", "passphrase": "mypass"}`),
+	)
+	assert.Nil(t, err)
+
+	endpoint := &identitiesAPI{idm: mockIdm}
+	endpoint.Import(resp, req, nil)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+type earningsProviderFake struct {
+	unsettled *big.Int
+}
+
+func (ep *earningsProviderFake) GetEarnings(_ identity.Identity) pingpong_event.Earnings {
+	return pingpong_event.Earnings{LifetimeBalance: ep.unsettled, UnsettledBalance: ep.unsettled}
+}
+
+func TestDeleteIdentity(t *testing.T) {
+	id := existingIdentities[1]
+	activeSessions := stateEvent.State{
+		Sessions: []session.History{{ProviderID: id}},
+	}
+	activeConnection := stateEvent.State{
+		Connection: stateEvent.Connection{Session: connectionstate.Status{State: connectionstate.Connected, ConsumerID: id}},
+	}
+
+	tests := []struct {
+		name      string
+		unsettled int64
+		state     stateEvent.State
+		query     string
+		code      int
+	}{
+		{name: "Deletes idle identity", code: http.StatusAccepted},
+		{name: "Refuses to delete identity with unsettled earnings", unsettled: 10, code: http.StatusConflict},
+		{name: "Refuses to delete identity with active sessions", state: activeSessions, code: http.StatusConflict},
+		{name: "Refuses to delete identity with active connection", state: activeConnection, code: http.StatusConflict},
+		{name: "Deletes busy identity with force", unsettled: 10, state: activeSessions, query: "?force=true", code: http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockIdm := identity.NewIdentityManagerFake(existingIdentities, newIdentity)
+			endpoint := &identitiesAPI{
+				idm:              mockIdm,
+				earningsProvider: &earningsProviderFake{unsettled: big.NewInt(tt.unsettled)},
+				stateProvider:    &mockStateProvider{stateToReturn: tt.state},
+			}
+
+			resp := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/identities/"+id.Address+tt.query, nil)
+			endpoint.Delete(resp, req, httprouter.Params{{Key: "id", Value: id.Address}})
+
+			assert.Equal(t, tt.code, resp.Code)
+			assert.Equal(t, tt.code != http.StatusAccepted, len(mockIdm.GetIdentities()) == 2)
+		})
+	}
+
+	t.Run("Not found", func(t *testing.T) {
+		endpoint := &identitiesAPI{idm: identity.NewIdentityManagerFake(existingIdentities, newIdentity)}
+
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/identities/0x1", nil)
+		endpoint.Delete(resp, req, httprouter.Params{{Key: "id", Value: "0x1"}})
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
 func TestListIdentities(t *testing.T) {
 	mockIdm := identity.NewIdentityManagerFake(existingIdentities, newIdentity)
 	req := httptest.NewRequest("GET", "/irrelevant", nil)
@@ -249,6 +391,59 @@ func TestListIdentities(t *testing.T) {
 	)
 }
 
+type mockIdentityUsageProvider struct {
+	stats map[identity.Identity]session.IdentityStats
+}
+
+func (m *mockIdentityUsageProvider) StatsByIdentity(_ *session.Filter) (map[identity.Identity]session.IdentityStats, error) {
+	return m.stats, nil
+}
+
+func TestListIdentitiesWithUsage(t *testing.T) {
+	mockIdm := identity.NewIdentityManagerFake(existingIdentities, newIdentity)
+	req := httptest.NewRequest("GET", "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	stats := session.NewIdentityStats()
+	stats.Provided.Add(session.History{
+		Direction:    session.DirectionProvided,
+		ConsumerID:   identity.FromAddress("0x1"),
+		DataSent:     10,
+		DataReceived: 20,
+		Tokens:       big.NewInt(30),
+		Started:      time.Date(2020, 6, 17, 10, 0, 0, 0, time.UTC),
+		Updated:      time.Date(2020, 6, 17, 10, 0, 40, 0, time.UTC),
+	})
+	endpoint := &identitiesAPI{
+		idm: mockIdm,
+		usageProvider: &mockIdentityUsageProvider{stats: map[identity.Identity]session.IdentityStats{
+			existingIdentities[0]: stats,
+		}},
+	}
+	endpoint.List(resp, req, nil)
+
+	zeroStats := `{"count": 0, "count_consumers": 0, "sum_bytes_received": 0, "sum_bytes_sent": 0, "sum_duration": 0, "sum_tokens": 0}`
+	assert.JSONEq(
+		t,
+		`{
+            "identities": [
+                {
+                    "id": "0x000000000000000000000000000000000000000a",
+                    "usage": {
+                        "consumed": `+zeroStats+`,
+                        "provided": {"count": 1, "count_consumers": 1, "sum_bytes_received": 20, "sum_bytes_sent": 10, "sum_duration": 40, "sum_tokens": 30}
+                    }
+                },
+                {
+                    "id": "0x000000000000000000000000000000000000beef",
+                    "usage": {"consumed": `+zeroStats+`, "provided": `+zeroStats+`}
+                }
+            ]
+        }`,
+		resp.Body.String(),
+	)
+}
+
 func Test_ReferralTokenGet(t *testing.T) {
 	router := httprouter.New()
 
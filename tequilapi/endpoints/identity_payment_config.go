@@ -0,0 +1,204 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+// hermesResolver resolves hermes the identity pays and settles with by default.
+type hermesResolver interface {
+	HermesID(id identity.Identity) common.Address
+}
+
+// resolveHermesID returns preferred hermes of the identity, falling back to the node-wide one if resolver is not set.
+func resolveHermesID(resolver hermesResolver, id identity.Identity) common.Address {
+	if resolver == nil {
+		return common.HexToAddress(config.GetString(config.FlagHermesID))
+	}
+	return resolver.HermesID(id)
+}
+
+type paymentDefaults interface {
+	hermesResolver
+	Get(id identity.Identity) registry.PaymentPreferences
+	Set(id identity.Identity, hermesID common.Address) error
+	Reset(id identity.Identity) error
+}
+
+type paymentConfigEndpoint struct {
+	idm      identity.Manager
+	defaults paymentDefaults
+}
+
+// swagger:operation GET /identities/{id}/payment-config Identity getIdentityPaymentConfig
+// ---
+// summary: Returns payment configuration of identity
+// description: Returns hermes the identity pays and settles with by default
+// parameters:
+// - name: id
+//   in: path
+//   description: hex address of identity
+//   type: string
+//   required: true
+// responses:
+//   200:
+//     description: Payment configuration of identity
+//     schema:
+//       "$ref": "#/definitions/IdentityPaymentConfigDTO"
+//   404:
+//     description: Identity not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pce *paymentConfigEndpoint) Get(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	id, err := pce.idm.GetIdentity(params.ByName("id"))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+
+	pce.respond(resp, id)
+}
+
+// swagger:operation PUT /identities/{id}/payment-config Identity setIdentityPaymentConfig
+// ---
+// summary: Sets payment configuration of identity
+// description: Stores preferred hermes of identity, omitted hermes resets to node-wide default
+// parameters:
+// - name: id
+//   in: path
+//   description: hex address of identity
+//   type: string
+//   required: true
+// - in: body
+//   name: body
+//   description: Preferred hermes of identity
+//   schema:
+//     $ref: "#/definitions/IdentityPaymentConfigRequestDTO"
+// responses:
+//   200:
+//     description: Effective payment configuration of identity
+//     schema:
+//       "$ref": "#/definitions/IdentityPaymentConfigDTO"
+//   400:
+//     description: Body parsing error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: Identity not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pce *paymentConfigEndpoint) Set(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := pce.idm.GetIdentity(params.ByName("id"))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+
+	var req contract.IdentityPaymentConfigRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	if errorMap := req.Validate(); errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	var hermesID common.Address
+	if req.HermesID != "" {
+		hermesID = common.HexToAddress(req.HermesID)
+	}
+	if err := pce.defaults.Set(id, hermesID); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	pce.respond(resp, id)
+}
+
+// swagger:operation DELETE /identities/{id}/payment-config Identity resetIdentityPaymentConfig
+// ---
+// summary: Resets payment configuration of identity
+// description: Removes preferred hermes of identity, so node-wide default is used
+// parameters:
+// - name: id
+//   in: path
+//   description: hex address of identity
+//   type: string
+//   required: true
+// responses:
+//   202:
+//     description: Payment configuration reset
+//   404:
+//     description: Identity not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pce *paymentConfigEndpoint) Reset(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	id, err := pce.idm.GetIdentity(params.ByName("id"))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+
+	if err := pce.defaults.Reset(id); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+func (pce *paymentConfigEndpoint) respond(resp http.ResponseWriter, id identity.Identity) {
+	preferences := pce.defaults.Get(id)
+	utils.WriteAsJSON(contract.IdentityPaymentConfigDTO{
+		HermesID: preferences.HermesID.Hex(),
+	}, resp)
+}
+
+// AddRoutesForPaymentConfig adds identity payment configuration routes to given router.
+func AddRoutesForPaymentConfig(router *httprouter.Router, idm identity.Manager, defaults paymentDefaults) {
+	pce := &paymentConfigEndpoint{
+		idm:      idm,
+		defaults: defaults,
+	}
+	router.GET("/identities/:id/payment-config", pce.Get)
+	router.PUT("/identities/:id/payment-config", pce.Set)
+	router.DELETE("/identities/:id/payment-config", pce.Reset)
+}
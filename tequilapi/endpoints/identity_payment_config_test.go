@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHermesResolver struct {
+	hermesID common.Address
+}
+
+func (mhr *mockHermesResolver) HermesID(_ identity.Identity) common.Address {
+	return mhr.hermesID
+}
+
+type mockPaymentPreferencesStorage struct {
+	preferences map[identity.Identity]registry.PaymentPreferences
+}
+
+func (mpps *mockPaymentPreferencesStorage) Store(preferences registry.PaymentPreferences) error {
+	mpps.preferences[preferences.Identity] = preferences
+	return nil
+}
+
+func (mpps *mockPaymentPreferencesStorage) Get(id identity.Identity) (registry.PaymentPreferences, error) {
+	preferences, ok := mpps.preferences[id]
+	if !ok {
+		return registry.PaymentPreferences{}, registry.ErrNotFound
+	}
+	return preferences, nil
+}
+
+func (mpps *mockPaymentPreferencesStorage) Delete(id identity.Identity) error {
+	delete(mpps.preferences, id)
+	return nil
+}
+
+func TestPaymentConfigEndpoint(t *testing.T) {
+	defaults := registry.NewPaymentDefaults(
+		&mockPaymentPreferencesStorage{preferences: make(map[identity.Identity]registry.PaymentPreferences)},
+		common.HexToAddress("0x0214281cf15C1a66b51990e2E65e1f7b7C363318"),
+	)
+	router := httprouter.New()
+	AddRoutesForPaymentConfig(router, identity.NewIdentityManagerFake(existingIdentities, newIdentity), defaults)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		assert.NoError(t, err)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	path := "/identities/0x000000000000000000000000000000000000000a/payment-config"
+
+	resp := serve(http.MethodGet, path, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"hermes_id": "0x0214281cf15C1a66b51990e2E65e1f7b7C363318"}`, resp.Body.String())
+
+	resp = serve(http.MethodPut, path, `{"hermes_id": "0x42a537D649d6853C0a866470f2d084DA0f73b5E4"}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"hermes_id": "0x42a537D649d6853C0a866470f2d084DA0f73b5E4"}`, resp.Body.String())
+
+	resp = serve(http.MethodPut, path, `{"hermes_id": "not-an-address"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	resp = serve(http.MethodGet, "/identities/0x00000000000000000000000000000000000000ff/payment-config", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	resp = serve(http.MethodDelete, path, "")
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+
+	resp = serve(http.MethodGet, path, "")
+	assert.JSONEq(t, `{"hermes_id": "0x0214281cf15C1a66b51990e2E65e1f7b7C363318"}`, resp.Body.String())
+}
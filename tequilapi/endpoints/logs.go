@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+type logStreams interface {
+	Service(serviceID string) []string
+	Session(sessionID string) []string
+}
+
+type logsEndpoint struct {
+	streams logStreams
+}
+
+// Logs returns recent log lines of a single service instance or session.
+// swagger:operation GET /logs Logs logs
+// ---
+// summary: Returns service or session logs
+// description: Returns recent log lines tagged with the given service instance or session ID
+// parameters:
+//   - in: query
+//     name: service_id
+//     description: Service ID
+//     type: string
+//   - in: query
+//     name: session_id
+//     description: Session ID, takes precedence over service ID
+//     type: string
+// responses:
+//   200:
+//     description: Log lines
+//     schema:
+//       "$ref": "#/definitions/LogsResponseDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (le *logsEndpoint) Logs(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	query := req.URL.Query()
+
+	var lines []string
+	switch {
+	case query.Get("session_id") != "":
+		lines = le.streams.Session(query.Get("session_id"))
+	case query.Get("service_id") != "":
+		lines = le.streams.Service(query.Get("service_id"))
+	default:
+		utils.SendErrorMessage(resp, "Service or session ID is required", http.StatusBadRequest)
+		return
+	}
+
+	utils.WriteAsJSON(contract.LogsResponse{Lines: lines}, resp)
+}
+
+// AddRoutesForLogs adds logs routes to given router
+func AddRoutesForLogs(router *httprouter.Router, streams logStreams) {
+	le := &logsEndpoint{streams: streams}
+
+	router.GET("/logs", le.Logs)
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLogStreams struct{}
+
+func (m *mockLogStreams) Service(serviceID string) []string {
+	return []string{"service " + serviceID}
+}
+
+func (m *mockLogStreams) Session(sessionID string) []string {
+	return []string{"session " + sessionID}
+}
+
+func TestLogsEndpoint_Logs(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForLogs(router, &mockLogStreams{})
+
+	tests := []struct {
+		query        string
+		expectedCode int
+		expectedJSON string
+	}{
+		{query: "?service_id=1", expectedCode: http.StatusOK, expectedJSON: `{"lines": ["service 1"]}`},
+		{query: "?service_id=1&session_id=2", expectedCode: http.StatusOK, expectedJSON: `{"lines": ["session 2"]}`},
+		{query: "", expectedCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/logs"+tt.query, nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, tt.expectedCode, resp.Code, tt.query)
+		if tt.expectedJSON != "" {
+			assert.JSONEq(t, tt.expectedJSON, resp.Body.String())
+		}
+	}
+}
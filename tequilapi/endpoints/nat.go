@@ -21,18 +21,33 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/nat/mapping"
+	"github.com/mysteriumnetwork/node/p2p"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
 )
 
+type p2pDiagnostics interface {
+	Reports() []p2p.DiagnosticsReport
+}
+
+type portMappings interface {
+	Leases() []mapping.Lease
+}
+
 // NATEndpoint struct represents endpoints about NAT traversal
 type NATEndpoint struct {
-	stateProvider stateProvider
+	stateProvider  stateProvider
+	p2pDiagnostics p2pDiagnostics
+	portMappings   portMappings
 }
 
 // NewNATEndpoint creates and returns nat endpoint
-func NewNATEndpoint(stateProvider stateProvider) *NATEndpoint {
+func NewNATEndpoint(stateProvider stateProvider, p2pDiagnostics p2pDiagnostics, portMappings portMappings) *NATEndpoint {
 	return &NATEndpoint{
-		stateProvider: stateProvider,
+		stateProvider:  stateProvider,
+		p2pDiagnostics: p2pDiagnostics,
+		portMappings:   portMappings,
 	}
 }
 
@@ -50,9 +65,39 @@ func (ne *NATEndpoint) NATStatus(resp http.ResponseWriter, _ *http.Request, _ ht
 	utils.WriteAsJSON(ne.stateProvider.GetState().NATStatus, resp)
 }
 
+// P2PDiagnostics provides reports of the last p2p channel establishments
+// swagger:operation GET /nat/p2p-diagnostics NAT P2PDiagnosticsResponse
+// ---
+// summary: Shows p2p connectivity diagnostics
+// description: Returns steps, NAT traversal attempts and failure causes of the last p2p channel established as consumer and as provider
+// responses:
+//   200:
+//     description: Last p2p diagnostics reports
+//     schema:
+//       "$ref": "#/definitions/P2PDiagnosticsResponse"
+func (ne *NATEndpoint) P2PDiagnostics(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	utils.WriteAsJSON(contract.NewP2PDiagnosticsResponse(ne.p2pDiagnostics.Reports()), resp)
+}
+
+// PortMappings provides port mappings held on the router
+// swagger:operation GET /nat/port-mappings NAT NATPortMappingsResponse
+// ---
+// summary: Shows router port mappings
+// description: Returns ports of services and p2p channels mapped on the router via UPnP IGD or NAT-PMP together with their lease status
+// responses:
+//   200:
+//     description: Router port mappings
+//     schema:
+//       "$ref": "#/definitions/NATPortMappingsResponse"
+func (ne *NATEndpoint) PortMappings(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	utils.WriteAsJSON(contract.NewNATPortMappingsResponse(ne.portMappings.Leases()), resp)
+}
+
 // AddRoutesForNAT adds nat routes to given router
-func AddRoutesForNAT(router *httprouter.Router, stateProvider stateProvider) {
-	natEndpoint := NewNATEndpoint(stateProvider)
+func AddRoutesForNAT(router *httprouter.Router, stateProvider stateProvider, p2pDiagnostics p2pDiagnostics, portMappings portMappings) {
+	natEndpoint := NewNATEndpoint(stateProvider, p2pDiagnostics, portMappings)
 
 	router.GET("/nat/status", natEndpoint.NATStatus)
+	router.GET("/nat/p2p-diagnostics", natEndpoint.P2PDiagnostics)
+	router.GET("/nat/port-mappings", natEndpoint.PortMappings)
 }
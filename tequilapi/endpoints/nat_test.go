@@ -19,12 +19,16 @@ package endpoints
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	stateEvent "github.com/mysteriumnetwork/node/core/state/event"
+	"github.com/mysteriumnetwork/node/nat/mapping"
+	"github.com/mysteriumnetwork/node/p2p"
 	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/stretchr/testify/assert"
 )
@@ -44,10 +48,130 @@ func Test_NATStatus_ReturnsStatusSuccessful_WithSuccessfulEvent(t *testing.T) {
 	assert.Nil(t, err)
 	resp := httptest.NewRecorder()
 	router := httprouter.New()
-	AddRoutesForNAT(router, provider)
+	AddRoutesForNAT(router, provider, p2p.NewDiagnostics(nil, nil), &mockPortMappings{})
 
 	router.ServeHTTP(resp, req)
 
 	assert.Equal(t, http.StatusOK, resp.Code)
 	assert.JSONEq(t, string(expectedJSON), resp.Body.String())
 }
+
+func Test_P2PDiagnostics_ReturnsLastReports(t *testing.T) {
+	startedAt := time.Date(2020, 7, 1, 10, 11, 12, 0, time.UTC)
+	diagnostics := &mockP2PDiagnostics{reports: []p2p.DiagnosticsReport{{
+		Role:         p2p.DiagnosticsRoleConsumer,
+		ServiceType:  "wireguard",
+		PublicIP:     "1.1.1.1",
+		PeerPublicIP: "2.2.2.2",
+		StartedAt:    startedAt,
+		Duration:     11 * time.Second,
+		Steps:        []p2p.DiagnosticsStep{{Name: "config exchange", Duration: 100 * time.Millisecond}},
+		Attempts: []p2p.TraversalAttempt{{
+			Method:         p2p.TraversalMethodPinger,
+			PeerIP:         "2.2.2.2",
+			CandidatePairs: []p2p.CandidatePair{{LocalPort: 1000, PeerPort: 2000}},
+			Duration:       10 * time.Second,
+			Error:          "ping failed",
+		}},
+		Error: "could not dial p2p channel: ping failed",
+	}}}
+
+	req, err := http.NewRequest(http.MethodGet, "/nat/p2p-diagnostics", nil)
+	assert.Nil(t, err)
+	resp := httptest.NewRecorder()
+	router := httprouter.New()
+	AddRoutesForNAT(router, &mockStateProvider{}, diagnostics, &mockPortMappings{})
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{
+		"reports": [{
+			"role": "consumer",
+			"service_type": "wireguard",
+			"public_ip": "1.1.1.1",
+			"peer_public_ip": "2.2.2.2",
+			"started_at": "2020-07-01T10:11:12Z",
+			"duration": 11000,
+			"steps": [{"name": "config exchange", "duration": 100}],
+			"attempts": [{
+				"method": "pinger",
+				"peer_ip": "2.2.2.2",
+				"candidate_pairs": [{"local_port": 1000, "peer_port": 2000}],
+				"duration": 10000,
+				"error": "ping failed"
+			}],
+			"error": "could not dial p2p channel: ping failed"
+		}]
+	}`, resp.Body.String())
+}
+
+func Test_PortMappings_ReturnsLeases(t *testing.T) {
+	renewedAt := time.Date(2020, 7, 1, 10, 11, 12, 0, time.UTC)
+	mappings := &mockPortMappings{leases: []mapping.Lease{
+		{
+			Protocol:  "UDP",
+			Port:      51820,
+			Name:      "Myst node p2p port mapping",
+			Method:    "UPNP IGDv2-IP1",
+			RenewedAt: renewedAt,
+			ExpiresAt: renewedAt.Add(20 * time.Minute),
+		},
+		{
+			Protocol:  "UDP",
+			Port:      51821,
+			Name:      "Myst node wireguard port mapping",
+			Method:    "NAT-PMP(192.168.1.1)",
+			Permanent: true,
+			RenewedAt: renewedAt,
+			Error:     errors.New("renewal failed"),
+		},
+	}}
+
+	req, err := http.NewRequest(http.MethodGet, "/nat/port-mappings", nil)
+	assert.Nil(t, err)
+	resp := httptest.NewRecorder()
+	router := httprouter.New()
+	AddRoutesForNAT(router, &mockStateProvider{}, p2p.NewDiagnostics(nil, nil), mappings)
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{
+		"mappings": [
+			{
+				"protocol": "UDP",
+				"port": 51820,
+				"name": "Myst node p2p port mapping",
+				"method": "UPNP IGDv2-IP1",
+				"renewed_at": "2020-07-01T10:11:12Z",
+				"expires_at": "2020-07-01T10:31:12Z"
+			},
+			{
+				"protocol": "UDP",
+				"port": 51821,
+				"name": "Myst node wireguard port mapping",
+				"method": "NAT-PMP(192.168.1.1)",
+				"permanent": true,
+				"renewed_at": "2020-07-01T10:11:12Z",
+				"error": "renewal failed"
+			}
+		]
+	}`, resp.Body.String())
+}
+
+type mockPortMappings struct {
+	leases []mapping.Lease
+}
+
+func (m *mockPortMappings) Leases() []mapping.Lease {
+	return m.leases
+}
+
+type mockP2PDiagnostics struct {
+	reports []p2p.DiagnosticsReport
+}
+
+func (m *mockP2PDiagnostics) Reports() []p2p.DiagnosticsReport {
+	return m.reports
+}
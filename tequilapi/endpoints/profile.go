@@ -0,0 +1,295 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/mysteriumnetwork/node/consumer/profile"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+type profileStorage interface {
+	Save(p profile.Profile) error
+	Get(name string) (profile.Profile, error)
+	List() ([]profile.Profile, error)
+	Delete(name string) error
+}
+
+// ProfileEndpoint struct represents /profiles resource and it's subresources
+type ProfileEndpoint struct {
+	storage    profileStorage
+	connection *ConnectionEndpoint
+}
+
+// NewProfileEndpoint creates and returns profile endpoint
+func NewProfileEndpoint(storage profileStorage, connectionEndpoint *ConnectionEndpoint) *ProfileEndpoint {
+	return &ProfileEndpoint{
+		storage:    storage,
+		connection: connectionEndpoint,
+	}
+}
+
+// List returns saved connection profiles
+// swagger:operation GET /profiles Profile listProfiles
+// ---
+// summary: Returns saved connection profiles
+// responses:
+//   200:
+//     description: List of profiles
+//     schema:
+//       "$ref": "#/definitions/ListProfilesResponse"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pe *ProfileEndpoint) List(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	profiles, err := pe.storage.List()
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	res := contract.ListProfilesResponse{Profiles: []contract.ProfileDTO{}}
+	for _, p := range profiles {
+		res.Profiles = append(res.Profiles, contract.NewProfileDTO(p))
+	}
+	utils.WriteAsJSON(res, resp)
+}
+
+// Get returns saved connection profile
+// swagger:operation GET /profiles/{name} Profile getProfile
+// ---
+// summary: Returns saved connection profile
+// parameters:
+//   - name: name
+//     in: path
+//     description: profile name
+//     type: string
+//     required: true
+// responses:
+//   200:
+//     description: Profile
+//     schema:
+//       "$ref": "#/definitions/ProfileDTO"
+//   404:
+//     description: Profile not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pe *ProfileEndpoint) Get(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	p, err := pe.storage.Get(params.ByName("name"))
+	if err != nil {
+		sendProfileError(resp, err)
+		return
+	}
+	utils.WriteAsJSON(contract.NewProfileDTO(p), resp)
+}
+
+// Save creates or replaces connection profile
+// swagger:operation PUT /profiles/{name} Profile saveProfile
+// ---
+// summary: Saves connection profile
+// description: Creates new or replaces existing connection profile with the given name
+// parameters:
+//   - name: name
+//     in: path
+//     description: profile name
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Profile
+//     schema:
+//       $ref: "#/definitions/ProfileDTO"
+// responses:
+//   200:
+//     description: Profile saved
+//     schema:
+//       "$ref": "#/definitions/ProfileDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pe *ProfileEndpoint) Save(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	var dto contract.ProfileDTO
+	if err := json.NewDecoder(req.Body).Decode(&dto); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	dto.Name = params.ByName("name")
+
+	p := dto.ToProfile()
+	if err := p.Validate(); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	if err := pe.storage.Save(p); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	utils.WriteAsJSON(contract.NewProfileDTO(p), resp)
+}
+
+// Delete removes connection profile
+// swagger:operation DELETE /profiles/{name} Profile deleteProfile
+// ---
+// summary: Removes connection profile
+// parameters:
+//   - name: name
+//     in: path
+//     description: profile name
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: Profile removed
+//   404:
+//     description: Profile not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pe *ProfileEndpoint) Delete(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	if err := pe.storage.Delete(params.ByName("name")); err != nil {
+		sendProfileError(resp, err)
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// Connect starts new connection configured by the profile
+// swagger:operation PUT /profiles/{name}/connection Profile connectWithProfile
+// ---
+// summary: Starts new connection with the profile
+// description: Consumer opens connection to the profile provider, or to a provider located in the profile country
+// parameters:
+//   - name: name
+//     in: path
+//     description: profile name
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Consumer identity to connect with
+//     schema:
+//       $ref: "#/definitions/ProfileConnectRequestDTO"
+// responses:
+//   201:
+//     description: Connection started
+//     schema:
+//       "$ref": "#/definitions/ConnectionInfoDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: Profile not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Conflict. Connection already exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pe *ProfileEndpoint) Connect(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	var cr contract.ProfileConnectRequest
+	if err := json.NewDecoder(req.Body).Decode(&cr); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	if errorMap := cr.Validate(); errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	p, err := pe.storage.Get(params.ByName("name"))
+	if err != nil {
+		sendProfileError(resp, err)
+		return
+	}
+
+	consumerID := identity.FromAddress(cr.ConsumerID)
+	if !pe.connection.checkRegistration(resp, consumerID) {
+		return
+	}
+
+	proposals, err := p.Proposals(pe.connection.proposalRepository)
+	if err == profile.ErrNoProposals {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendProposalFetchError(resp, err)
+		return
+	}
+
+	hermesID := resolveHermesID(pe.connection.hermesResolver, consumerID)
+	if cr.HermesID != "" {
+		hermesID = common.HexToAddress(cr.HermesID)
+	}
+
+	connectOptions := p.ConnectParams()
+	connectOptions.FallbackProposals = proposals[1:]
+	pe.connection.connect(resp, req, params, consumerID, hermesID, proposals[0], connectOptions)
+}
+
+func sendProfileError(resp http.ResponseWriter, err error) {
+	if err == profile.ErrNotFound {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+	utils.SendError(resp, err, http.StatusInternalServerError)
+}
+
+// AddRoutesForProfiles attaches profile endpoints to router
+func AddRoutesForProfiles(router *httprouter.Router, storage profileStorage, manager connection.Manager,
+	stateProvider stateProvider, proposalRepository proposal.Repository, identityRegistry identityRegistry, hermesResolver hermesResolver) {
+	connectionEndpoint := NewConnectionEndpoint(manager, stateProvider, proposalRepository, identityRegistry)
+	connectionEndpoint.hermesResolver = hermesResolver
+	profileEndpoint := NewProfileEndpoint(storage, connectionEndpoint)
+	router.GET("/profiles", profileEndpoint.List)
+	router.GET("/profiles/:name", profileEndpoint.Get)
+	router.PUT("/profiles/:name", profileEndpoint.Save)
+	router.DELETE("/profiles/:name", profileEndpoint.Delete)
+	router.PUT("/profiles/:name/connection", profileEndpoint.Connect)
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/consumer/profile"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+func TestProfileSaveAndList(t *testing.T) {
+	storage := newMockProfileStorage()
+	router := httprouter.New()
+	AddRoutesForProfiles(router, storage, &mockConnectionManager{}, &mockStateProvider{}, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/profiles/work", strings.NewReader(
+		`{"provider_id": "0x2", "service_type": "wireguard", "dns": "provider", "disable_kill_switch": true, "spend_cap": 100}`,
+	))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t,
+		profile.Profile{Name: "work", ProviderID: "0x2", ServiceType: "wireguard", DNS: connection.DNSOptionProvider, DisableKillSwitch: true, SpendCap: big.NewInt(100)},
+		storage.profiles["work"],
+	)
+
+	req = httptest.NewRequest(http.MethodGet, "/profiles", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t,
+		`{"profiles": [{"name": "work", "provider_id": "0x2", "service_type": "wireguard", "dns": "provider", "disable_kill_switch": true, "spend_cap": 100}]}`,
+		resp.Body.String(),
+	)
+}
+
+func TestProfileSaveValidatesProfile(t *testing.T) {
+	endpoint := NewProfileEndpoint(newMockProfileStorage(), nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/profiles/work", strings.NewReader(`{"service_type": "wireguard"}`))
+	resp := httptest.NewRecorder()
+	endpoint.Save(resp, req, httprouter.Params{{Key: "name", Value: "work"}})
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.JSONEq(t, `{"message": "profile requires provider or country"}`, resp.Body.String())
+}
+
+func TestProfileGetAndDeleteReturnNotFound(t *testing.T) {
+	endpoint := NewProfileEndpoint(newMockProfileStorage(), nil)
+	params := httprouter.Params{{Key: "name", Value: "work"}}
+
+	resp := httptest.NewRecorder()
+	endpoint.Get(resp, httptest.NewRequest(http.MethodGet, "/profiles/work", nil), params)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	resp = httptest.NewRecorder()
+	endpoint.Delete(resp, httptest.NewRequest(http.MethodDelete, "/profiles/work", nil), params)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestProfileDelete(t *testing.T) {
+	storage := newMockProfileStorage()
+	storage.profiles["work"] = profile.Profile{Name: "work", Country: "DE", ServiceType: "wireguard"}
+	endpoint := NewProfileEndpoint(storage, nil)
+
+	resp := httptest.NewRecorder()
+	endpoint.Delete(resp, httptest.NewRequest(http.MethodDelete, "/profiles/work", nil), httprouter.Params{{Key: "name", Value: "work"}})
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Empty(t, storage.profiles)
+}
+
+func TestProfileConnect(t *testing.T) {
+	storage := newMockProfileStorage()
+	storage.profiles["work"] = profile.Profile{Name: "work", Country: "DE", ServiceType: "wireguard", SpendCap: big.NewInt(100)}
+	manager := &mockConnectionManager{}
+	repository := &mockProposalRepository{proposals: []market.ServiceProposal{
+		{ProviderID: "0x1", ServiceType: "wireguard"},
+		{ProviderID: "0x2", ServiceType: "wireguard"},
+	}}
+	endpoint := NewProfileEndpoint(storage, NewConnectionEndpoint(manager, &mockStateProvider{}, repository, mockIdentityRegistryInstance))
+
+	req := httptest.NewRequest(http.MethodPut, "/profiles/work/connection", strings.NewReader(`{"consumer_id": "my-identity", "hermes_id": "0x3"}`))
+	resp := httptest.NewRecorder()
+	endpoint.Connect(resp, req, httprouter.Params{{Key: "name", Value: "work"}})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, "DE", repository.recordedFilter.LocationCountry)
+	assert.Equal(t, "wireguard", repository.recordedFilter.ServiceType)
+	assert.Equal(t, identity.FromAddress("my-identity"), manager.requestedConsumerID)
+	assert.Equal(t, identity.FromAddress("0x1"), manager.requestedProvider)
+	assert.Equal(t, connection.DNSOptionAuto, manager.requestedParams.DNS)
+	assert.Equal(t, big.NewInt(100), manager.requestedParams.SpendCap)
+	assert.Equal(t, []market.ServiceProposal{{ProviderID: "0x2", ServiceType: "wireguard"}}, manager.requestedParams.FallbackProposals)
+}
+
+func TestProfileConnectReturnsErrors(t *testing.T) {
+	storage := newMockProfileStorage()
+	storage.profiles["work"] = profile.Profile{Name: "work", Country: "DE", ServiceType: "wireguard"}
+	endpoint := NewProfileEndpoint(storage, NewConnectionEndpoint(&mockConnectionManager{}, &mockStateProvider{}, &mockProposalRepository{}, mockIdentityRegistryInstance))
+
+	req := httptest.NewRequest(http.MethodPut, "/profiles/home/connection", strings.NewReader(`{"consumer_id": "my-identity"}`))
+	resp := httptest.NewRecorder()
+	endpoint.Connect(resp, req, httprouter.Params{{Key: "name", Value: "home"}})
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/profiles/work/connection", strings.NewReader(`{}`))
+	resp = httptest.NewRecorder()
+	endpoint.Connect(resp, req, httprouter.Params{{Key: "name", Value: "work"}})
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/profiles/work/connection", strings.NewReader(`{"consumer_id": "my-identity"}`))
+	resp = httptest.NewRecorder()
+	endpoint.Connect(resp, req, httprouter.Params{{Key: "name", Value: "work"}})
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.JSONEq(t, `{"message": "no service proposals match the profile"}`, resp.Body.String())
+}
+
+type mockProfileStorage struct {
+	profiles map[string]profile.Profile
+}
+
+func newMockProfileStorage() *mockProfileStorage {
+	return &mockProfileStorage{profiles: make(map[string]profile.Profile)}
+}
+
+func (s *mockProfileStorage) Save(p profile.Profile) error {
+	s.profiles[p.Name] = p
+	return nil
+}
+
+func (s *mockProfileStorage) Get(name string) (profile.Profile, error) {
+	p, ok := s.profiles[name]
+	if !ok {
+		return profile.Profile{}, profile.ErrNotFound
+	}
+	return p, nil
+}
+
+func (s *mockProfileStorage) List() ([]profile.Profile, error) {
+	var res []profile.Profile
+	for _, p := range s.profiles {
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+func (s *mockProfileStorage) Delete(name string) error {
+	if _, ok := s.profiles[name]; !ok {
+		return profile.ErrNotFound
+	}
+	delete(s.profiles, name)
+	return nil
+}
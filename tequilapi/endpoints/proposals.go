@@ -18,6 +18,7 @@
 package endpoints
 
 import (
+	"encoding/json"
 	"math/big"
 	"net/http"
 
@@ -34,9 +35,17 @@ type QualityFinder interface {
 	ProposalsMetrics() []quality.ConnectMetric
 }
 
+type savedFilterStorage interface {
+	Get(name string) (proposal.SavedFilter, error)
+	List() ([]proposal.SavedFilter, error)
+	Store(filter proposal.SavedFilter) error
+	Delete(name string) error
+}
+
 type proposalsEndpoint struct {
 	proposalRepository proposal.Repository
 	qualityProvider    QualityFinder
+	savedFilters       savedFilterStorage
 }
 
 // NewProposalsEndpoint creates and returns proposal creation endpoint
@@ -58,7 +67,7 @@ func NewProposalsEndpoint(proposalRepository proposal.Repository, qualityProvide
 //     type: string
 //   - in: query
 //     name: service_type
-//     description: the service type of the proposal. Possible values are "openvpn", "wireguard" and "noop"
+//     description: the service type of the proposal. Possible values are "openvpn", "wireguard", "http-proxy", "shadowsocks" and "noop"
 //     type: string
 //   - in: query
 //     name: access_policy_id
@@ -70,53 +79,32 @@ func NewProposalsEndpoint(proposalRepository proposal.Repository, qualityProvide
 //     type: string
 //   - in: query
 //     name: fetch_metrics
-//     description: if set to true, fetches the connection success metrics for nodes. False by default.
+//     description: if set to true, fetches the connection success metrics for nodes which proposals are not enriched with them yet. False by default.
 //     type: boolean
+//   - in: query
+//     name: filter
+//     description: name of the saved filter to narrow the proposals by
+//     type: string
 // responses:
 //   200:
 //     description: List of proposals
 //     schema:
 //       "$ref": "#/definitions/ListProposalsResponse"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
 //   500:
 //     description: Internal server error
 //     schema:
 //       "$ref": "#/definitions/ErrorMessageDTO"
 func (pe *proposalsEndpoint) List(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
-	upperTimePriceBound, err := parsePriceBound(req, "upper_time_price_bound")
-	if err != nil {
-		utils.SendError(resp, err, http.StatusBadRequest)
-		return
-	}
-	lowerTimePriceBound, err := parsePriceBound(req, "lower_time_price_bound")
-	if err != nil {
-		utils.SendError(resp, err, http.StatusBadRequest)
-		return
-	}
-
-	upperGBPriceBound, err := parsePriceBound(req, "upper_gb_price_bound")
-	if err != nil {
-		utils.SendError(resp, err, http.StatusBadRequest)
-		return
-	}
-	lowerGBPriceBound, err := parsePriceBound(req, "lower_gb_price_bound")
-	if err != nil {
-		utils.SendError(resp, err, http.StatusBadRequest)
+	filter, ok := pe.parseFilter(resp, req)
+	if !ok {
 		return
 	}
 
-	proposals, err := pe.proposalRepository.Proposals(&proposal.Filter{
-		ProviderID:          req.URL.Query().Get("provider_id"),
-		ServiceType:         req.URL.Query().Get("service_type"),
-		AccessPolicyID:      req.URL.Query().Get("access_policy_id"),
-		AccessPolicySource:  req.URL.Query().Get("access_policy_source"),
-		LowerGBPriceBound:   lowerGBPriceBound,
-		UpperGBPriceBound:   upperGBPriceBound,
-		LowerTimePriceBound: lowerTimePriceBound,
-		UpperTimePriceBound: upperTimePriceBound,
-		ExcludeUnsupported:  true,
-		IncludeFailed:       req.URL.Query().Get("monitoring_failed") == "true",
-	})
-
+	proposals, err := pe.proposalRepository.Proposals(filter)
 	if err != nil {
 		utils.SendError(resp, err, http.StatusInternalServerError)
 		return
@@ -136,6 +124,46 @@ func (pe *proposalsEndpoint) List(resp http.ResponseWriter, req *http.Request, p
 	utils.WriteAsJSON(proposalsRes, resp)
 }
 
+// swagger:operation GET /proposals/counts Proposal proposalCounts
+// ---
+// summary: Returns proposal counts
+// description: Returns numbers of proposals grouped by provider country and service type, accepts the same filters as proposals listing
+// parameters:
+//   - in: query
+//     name: service_type
+//     description: the service type of the proposal. Possible values are "openvpn", "wireguard", "http-proxy", "shadowsocks" and "noop"
+//     type: string
+//   - in: query
+//     name: filter
+//     description: name of the saved filter to narrow the proposals by
+//     type: string
+// responses:
+//   200:
+//     description: Proposal counts
+//     schema:
+//       "$ref": "#/definitions/ProposalCountsResponse"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pe *proposalsEndpoint) Counts(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	filter, ok := pe.parseFilter(resp, req)
+	if !ok {
+		return
+	}
+
+	counts, err := proposal.Counts(pe.proposalRepository, filter)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	utils.WriteAsJSON(contract.NewProposalCountsResponse(counts), resp)
+}
+
 // swagger:operation GET /proposals/quality Proposal quality metrics
 // ---
 // summary: Returns proposals quality metrics
@@ -150,6 +178,169 @@ func (pe *proposalsEndpoint) Quality(resp http.ResponseWriter, req *http.Request
 	utils.WriteAsJSON(contract.NewProposalMetricsResponse(metrics), resp)
 }
 
+// swagger:operation GET /proposals/filters Proposal listSavedFilters
+// ---
+// summary: Returns saved proposal filters
+// description: Returns list of named proposal filters stored by the node
+// responses:
+//   200:
+//     description: List of saved filters
+//     schema:
+//       "$ref": "#/definitions/ListSavedFiltersResponse"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pe *proposalsEndpoint) ListFilters(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	filters, err := pe.savedFilters.List()
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	utils.WriteAsJSON(contract.NewListSavedFiltersResponse(filters), resp)
+}
+
+// swagger:operation PUT /proposals/filters/{name} Proposal saveFilter
+// ---
+// summary: Saves proposal filter
+// description: Creates or replaces named proposal filter
+// parameters:
+//   - in: path
+//     name: name
+//     description: name of the filter
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: filter conditions
+//     schema:
+//       $ref: "#/definitions/SavedFilterRequestDTO"
+// responses:
+//   200:
+//     description: Saved filter
+//     schema:
+//       "$ref": "#/definitions/SavedFilterDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pe *proposalsEndpoint) SaveFilter(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	var request contract.SavedFilterRequest
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	if errorMap := request.Validate(); errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	filter := request.ToSavedFilter(params.ByName("name"))
+	if err := pe.savedFilters.Store(filter); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	utils.WriteAsJSON(contract.NewSavedFilterDTO(filter), resp)
+}
+
+// swagger:operation DELETE /proposals/filters/{name} Proposal deleteFilter
+// ---
+// summary: Deletes proposal filter
+// description: Deletes named proposal filter
+// parameters:
+//   - in: path
+//     name: name
+//     description: name of the filter
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: Filter deleted
+//   404:
+//     description: Filter not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (pe *proposalsEndpoint) DeleteFilter(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	err := pe.savedFilters.Delete(params.ByName("name"))
+	if errors.Cause(err) == proposal.ErrSavedFilterNotFound {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// parseFilter builds proposal filter from query parameters, responding with error when they are invalid.
+func (pe *proposalsEndpoint) parseFilter(resp http.ResponseWriter, req *http.Request) (*proposal.Filter, bool) {
+	upperTimePriceBound, err := parsePriceBound(req, "upper_time_price_bound")
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return nil, false
+	}
+	lowerTimePriceBound, err := parsePriceBound(req, "lower_time_price_bound")
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return nil, false
+	}
+
+	upperGBPriceBound, err := parsePriceBound(req, "upper_gb_price_bound")
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return nil, false
+	}
+	lowerGBPriceBound, err := parsePriceBound(req, "lower_gb_price_bound")
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return nil, false
+	}
+
+	filter := &proposal.Filter{
+		ProviderID:          req.URL.Query().Get("provider_id"),
+		ServiceType:         req.URL.Query().Get("service_type"),
+		AccessPolicyID:      req.URL.Query().Get("access_policy_id"),
+		AccessPolicySource:  req.URL.Query().Get("access_policy_source"),
+		LowerGBPriceBound:   lowerGBPriceBound,
+		UpperGBPriceBound:   upperGBPriceBound,
+		LowerTimePriceBound: lowerTimePriceBound,
+		UpperTimePriceBound: upperTimePriceBound,
+		ExcludeUnsupported:  true,
+		IncludeFailed:       req.URL.Query().Get("monitoring_failed") == "true",
+	}
+	if name := req.URL.Query().Get("filter"); name != "" {
+		if pe.savedFilters == nil {
+			utils.SendError(resp, proposal.ErrSavedFilterNotFound, http.StatusBadRequest)
+			return nil, false
+		}
+		savedFilter, err := pe.savedFilters.Get(name)
+		if errors.Cause(err) == proposal.ErrSavedFilterNotFound {
+			utils.SendError(resp, err, http.StatusBadRequest)
+			return nil, false
+		}
+		if err != nil {
+			utils.SendError(resp, err, http.StatusInternalServerError)
+			return nil, false
+		}
+		savedFilter.ApplyTo(filter)
+	}
+
+	return filter, true
+}
+
 func parsePriceBound(req *http.Request, key string) (*big.Int, error) {
 	bound := req.URL.Query().Get(key)
 	if bound == "" {
@@ -163,10 +354,15 @@ func parsePriceBound(req *http.Request, key string) (*big.Int, error) {
 }
 
 // AddRoutesForProposals attaches proposals endpoints to router
-func AddRoutesForProposals(router *httprouter.Router, proposalRepository proposal.Repository, qualityProvider QualityFinder) {
+func AddRoutesForProposals(router *httprouter.Router, proposalRepository proposal.Repository, qualityProvider QualityFinder, savedFilters savedFilterStorage) {
 	pe := NewProposalsEndpoint(proposalRepository, qualityProvider)
+	pe.savedFilters = savedFilters
 	router.GET("/proposals", pe.List)
+	router.GET("/proposals/counts", pe.Counts)
 	router.GET("/proposals/quality", pe.Quality)
+	router.GET("/proposals/filters", pe.ListFilters)
+	router.PUT("/proposals/filters/:name", pe.SaveFilter)
+	router.DELETE("/proposals/filters/:name", pe.DeleteFilter)
 }
 
 // addProposalMetrics adds quality metrics to proposals.
@@ -18,17 +18,21 @@
 package endpoints
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
 	"github.com/mysteriumnetwork/node/core/quality"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/mocks"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -345,6 +349,131 @@ func TestProposalsEndpointListFetchConnectCounts(t *testing.T) {
 	)
 }
 
+func TestProposalsEndpointListIncludesKnownQuality(t *testing.T) {
+	p := serviceProposals[0]
+	p.Quality = &market.ProposalQuality{ConnectSuccess: 5, ConnectFail: 3, ConnectTimeout: 2}
+	repository := &mockProposalRepository{proposals: []market.ServiceProposal{p}}
+
+	req := httptest.NewRequest(http.MethodGet, "/proposals", nil)
+	resp := httptest.NewRecorder()
+	NewProposalsEndpoint(repository, &mockQualityProvider{}).List(resp, req, nil)
+
+	parsed := contract.ListProposalsResponse{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Proposals, 1)
+	assert.Equal(t,
+		&contract.QualityMetricsDTO{ConnectCount: contract.QualityMetricConnectsDTO{Success: 5, Fail: 3, Timeout: 2}},
+		parsed.Proposals[0].Metrics,
+	)
+}
+
+func TestProposalsEndpointListAppliesSavedFilter(t *testing.T) {
+	repository := &mockProposalRepository{}
+	endpoint := NewProposalsEndpoint(repository, &mockQualityProvider{})
+	endpoint.savedFilters = &mockSavedFilterStorage{filters: map[string]proposal.SavedFilter{
+		"streaming": {Name: "streaming", Countries: []string{"DE"}, QualityMin: 0.5},
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/proposals?filter=streaming&service_type=wireguard", nil)
+	resp := httptest.NewRecorder()
+	endpoint.List(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t,
+		&proposal.Filter{
+			ServiceType:        "wireguard",
+			LocationCountries:  []string{"DE"},
+			QualityMin:         0.5,
+			ExcludeUnsupported: true,
+		},
+		repository.recordedFilter,
+	)
+
+	req = httptest.NewRequest(http.MethodGet, "/proposals?filter=unknown", nil)
+	resp = httptest.NewRecorder()
+	endpoint.List(resp, req, nil)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestProposalsEndpointSavedFilters(t *testing.T) {
+	storage := &mockSavedFilterStorage{filters: map[string]proposal.SavedFilter{}}
+	router := httprouter.New()
+	AddRoutesForProposals(router, &mockProposalRepository{}, &mockQualityProvider{}, storage)
+
+	req := httptest.NewRequest(http.MethodPut, "/proposals/filters/streaming", strings.NewReader(`{"countries": ["DE"], "quality_min": 1.5}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/proposals/filters/streaming", strings.NewReader(`{"countries": ["DE"], "upper_gb_price": 100}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, proposal.SavedFilter{Name: "streaming", Countries: []string{"DE"}, UpperGBPrice: big.NewInt(100)}, storage.filters["streaming"])
+
+	req = httptest.NewRequest(http.MethodGet, "/proposals/filters", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"filters": [{"name": "streaming", "countries": ["DE"], "upper_gb_price": 100}]}`, resp.Body.String())
+
+	req = httptest.NewRequest(http.MethodDelete, "/proposals/filters/streaming", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/proposals/filters/streaming", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestProposalsEndpointCounts(t *testing.T) {
+	repository := &mockProposalRepository{proposals: serviceProposals}
+
+	req := httptest.NewRequest(http.MethodGet, "/proposals/counts?service_type=testprotocol", nil)
+	resp := httptest.NewRecorder()
+	NewProposalsEndpoint(repository, &mockQualityProvider{}).Counts(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"counts": [{"country": "Lithuania", "service_type": "testprotocol", "count": 2}]}`, resp.Body.String())
+	assert.Equal(t, "testprotocol", repository.recordedFilter.ServiceType)
+}
+
+type mockSavedFilterStorage struct {
+	filters map[string]proposal.SavedFilter
+}
+
+func (m *mockSavedFilterStorage) Get(name string) (proposal.SavedFilter, error) {
+	filter, ok := m.filters[name]
+	if !ok {
+		return proposal.SavedFilter{}, proposal.ErrSavedFilterNotFound
+	}
+	return filter, nil
+}
+
+func (m *mockSavedFilterStorage) List() ([]proposal.SavedFilter, error) {
+	filters := []proposal.SavedFilter{}
+	for _, filter := range m.filters {
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+func (m *mockSavedFilterStorage) Store(filter proposal.SavedFilter) error {
+	m.filters[filter.Name] = filter
+	return nil
+}
+
+func (m *mockSavedFilterStorage) Delete(name string) error {
+	if _, ok := m.filters[name]; !ok {
+		return proposal.ErrSavedFilterNotFound
+	}
+	delete(m.filters, name)
+	return nil
+}
+
 type mockQualityProvider struct{}
 
 func (m *mockQualityProvider) ProposalsMetrics() []quality.ConnectMetric {
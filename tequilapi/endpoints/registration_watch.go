@@ -0,0 +1,198 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	registrationWaitDefaultTimeout = 30 * time.Second
+	registrationWaitMaxTimeout     = 2 * time.Minute
+)
+
+// registrationWatcher holds long polling requests until registration status of identity changes.
+type registrationWatcher struct {
+	idm      identity.Manager
+	registry identityRegistry
+
+	lock    sync.Mutex
+	waiters map[string]map[chan registry.RegistrationStatus]struct{}
+}
+
+func newRegistrationWatcher(idm identity.Manager, idRegistry identityRegistry) *registrationWatcher {
+	return &registrationWatcher{
+		idm:      idm,
+		registry: idRegistry,
+		waiters:  make(map[string]map[chan registry.RegistrationStatus]struct{}),
+	}
+}
+
+// Subscribe subscribes to identity registration events.
+func (w *registrationWatcher) Subscribe(bus eventbus.Subscriber) error {
+	return bus.SubscribeAsync(registry.AppTopicIdentityRegistration, w.consumeRegistrationEvent)
+}
+
+func (w *registrationWatcher) consumeRegistrationEvent(ev registry.AppEventIdentityRegistration) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for ch := range w.waiters[strings.ToLower(ev.ID.Address)] {
+		// Only the latest status matters, replace the one not yet consumed.
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- ev.Status
+	}
+}
+
+func (w *registrationWatcher) watch(address string) (<-chan registry.RegistrationStatus, func()) {
+	key := strings.ToLower(address)
+	ch := make(chan registry.RegistrationStatus, 1)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if _, ok := w.waiters[key]; !ok {
+		w.waiters[key] = make(map[chan registry.RegistrationStatus]struct{})
+	}
+	w.waiters[key][ch] = struct{}{}
+
+	return ch, func() {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+
+		delete(w.waiters[key], ch)
+		if len(w.waiters[key]) == 0 {
+			delete(w.waiters, key)
+		}
+	}
+}
+
+// swagger:operation GET /identities/{id}/registration/wait Identity identityRegistrationWait
+// ---
+// summary: Waits for identity registration status change
+// description: Long polls registration status of given identity, responds once it differs from the given one or the timeout passes
+// parameters:
+//   - in: path
+//     name: id
+//     description: hex address of identity
+//     type: string
+//     required: true
+//   - in: query
+//     name: status
+//     description: last registration status known to the client, current status is used if omitted
+//     type: string
+//   - in: query
+//     name: timeout
+//     description: seconds to wait for status change, 30 by default and 120 at most
+//     type: integer
+// responses:
+//   200:
+//     description: Status retrieved
+//     schema:
+//       "$ref": "#/definitions/IdentityRegistrationResponseDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: Identity not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (w *registrationWatcher) WaitForRegistrationStatus(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	id, err := w.idm.GetIdentity(params.ByName("id"))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+
+	timeout := registrationWaitDefaultTimeout
+	if timeoutStr := req.URL.Query().Get("timeout"); timeoutStr != "" {
+		seconds, err := strconv.Atoi(timeoutStr)
+		if err != nil || seconds < 0 {
+			utils.SendError(resp, errors.Errorf("invalid timeout %q", timeoutStr), http.StatusBadRequest)
+			return
+		}
+		timeout = time.Duration(seconds) * time.Second
+		if timeout > registrationWaitMaxTimeout {
+			timeout = registrationWaitMaxTimeout
+		}
+	}
+
+	// Watch before checking the current status so that no change is missed in between.
+	updates, cancel := w.watch(id.Address)
+	defer cancel()
+
+	status, err := w.registry.GetRegistrationStatus(id)
+	if err != nil {
+		utils.SendError(resp, errors.Wrap(err, "failed to check identity registration status"), http.StatusInternalServerError)
+		return
+	}
+
+	known := req.URL.Query().Get("status")
+	if known == "" {
+		known = status.String()
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+wait:
+	for status.String() == known {
+		select {
+		case status = <-updates:
+		case <-timer.C:
+			break wait
+		case <-req.Context().Done():
+			return
+		}
+	}
+
+	utils.WriteAsJSON(&contract.IdentityRegistrationResponse{
+		Status:     status.String(),
+		Registered: status.Registered(),
+	}, resp)
+}
+
+// AddRoutesForRegistrationWatch adds route for long polling of identity registration status
+func AddRoutesForRegistrationWatch(router *httprouter.Router, idm identity.Manager, idRegistry identityRegistry, bus eventbus.Subscriber) error {
+	watcher := newRegistrationWatcher(idm, idRegistry)
+	if err := watcher.Subscribe(bus); err != nil {
+		return err
+	}
+	router.GET("/identities/:id/registration/wait", watcher.WaitForRegistrationStatus)
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RegistrationWait(t *testing.T) {
+	id := identity.FromAddress("0x000000000000000000000000000000000000000a")
+
+	for name, tc := range map[string]struct {
+		query    string
+		events   []registry.RegistrationStatus
+		expected string
+	}{
+		"responds at once when status differs from known one": {
+			query:    "?status=Unregistered",
+			expected: `{"status": "InProgress", "registered": false}`,
+		},
+		"responds on status change": {
+			query:    "?status=InProgress",
+			events:   []registry.RegistrationStatus{registry.Registered},
+			expected: `{"status": "Registered", "registered": true}`,
+		},
+		"responds with current status on timeout": {
+			query:    "?timeout=0",
+			expected: `{"status": "InProgress", "registered": false}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			bus := eventbus.New()
+			router := httprouter.New()
+			err := AddRoutesForRegistrationWatch(
+				router,
+				identity.NewIdentityManagerFake([]identity.Identity{id}, id),
+				&registry.FakeRegistry{RegistrationStatus: registry.InProgress},
+				bus,
+			)
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodGet, "/identities/"+id.Address+"/registration/wait"+tc.query, nil)
+			assert.NoError(t, err)
+
+			resp := httptest.NewRecorder()
+			done := make(chan struct{})
+			go func() {
+				router.ServeHTTP(resp, req)
+				close(done)
+			}()
+
+			for _, status := range tc.events {
+				time.Sleep(20 * time.Millisecond)
+				bus.Publish(registry.AppTopicIdentityRegistration, registry.AppEventIdentityRegistration{ID: id, Status: status})
+			}
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("registration wait did not respond")
+			}
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.JSONEq(t, tc.expected, resp.Body.String())
+		})
+	}
+}
+
+func Test_RegistrationWait_InvalidTimeout(t *testing.T) {
+	id := identity.FromAddress("0x000000000000000000000000000000000000000a")
+	router := httprouter.New()
+	err := AddRoutesForRegistrationWatch(
+		router,
+		identity.NewIdentityManagerFake([]identity.Identity{id}, id),
+		&registry.FakeRegistry{},
+		eventbus.New(),
+	)
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "/identities/"+id.Address+"/registration/wait?timeout=soon", nil)
+	assert.NoError(t, err)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/mysteriumnetwork/node/consumer/profile"
+	"github.com/mysteriumnetwork/node/consumer/schedule"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+type scheduleStorage interface {
+	Save(s schedule.Schedule) error
+	List() ([]schedule.Schedule, error)
+	Delete(name string) error
+}
+
+type profileGetter interface {
+	Get(name string) (profile.Profile, error)
+}
+
+// ScheduleEndpoint struct represents /schedules resource and it's subresources
+type ScheduleEndpoint struct {
+	storage  scheduleStorage
+	profiles profileGetter
+}
+
+// NewScheduleEndpoint creates and returns schedule endpoint
+func NewScheduleEndpoint(storage scheduleStorage, profiles profileGetter) *ScheduleEndpoint {
+	return &ScheduleEndpoint{
+		storage:  storage,
+		profiles: profiles,
+	}
+}
+
+// List returns connect and disconnect schedules
+// swagger:operation GET /schedules Schedule listSchedules
+// ---
+// summary: Returns connect and disconnect schedules
+// responses:
+//   200:
+//     description: List of schedules
+//     schema:
+//       "$ref": "#/definitions/ListSchedulesResponse"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ScheduleEndpoint) List(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	schedules, err := se.storage.List()
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	res := contract.ListSchedulesResponse{Schedules: []contract.ScheduleDTO{}}
+	for _, s := range schedules {
+		res.Schedules = append(res.Schedules, contract.NewScheduleDTO(s))
+	}
+	utils.WriteAsJSON(res, resp)
+}
+
+// Save creates or replaces connect and disconnect schedule
+// swagger:operation PUT /schedules/{name} Schedule saveSchedule
+// ---
+// summary: Saves connect and disconnect schedule
+// description: Creates new or replaces existing schedule with the given name. Schedule with both connect and disconnect times keeps consumer connected in between.
+// parameters:
+//   - name: name
+//     in: path
+//     description: schedule name
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Schedule
+//     schema:
+//       $ref: "#/definitions/ScheduleDTO"
+// responses:
+//   200:
+//     description: Schedule saved
+//     schema:
+//       "$ref": "#/definitions/ScheduleDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ScheduleEndpoint) Save(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	var dto contract.ScheduleDTO
+	if err := json.NewDecoder(req.Body).Decode(&dto); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	dto.Name = params.ByName("name")
+
+	s, err := dto.ToSchedule()
+	if err == nil {
+		err = s.Validate()
+	}
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	if s.Profile != "" {
+		if _, err := se.profiles.Get(s.Profile); err == profile.ErrNotFound {
+			utils.SendError(resp, fmt.Errorf("unknown profile %q", s.Profile), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			utils.SendError(resp, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := se.storage.Save(s); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	utils.WriteAsJSON(contract.NewScheduleDTO(s), resp)
+}
+
+// Delete removes connect and disconnect schedule
+// swagger:operation DELETE /schedules/{name} Schedule deleteSchedule
+// ---
+// summary: Removes connect and disconnect schedule
+// parameters:
+//   - name: name
+//     in: path
+//     description: schedule name
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: Schedule removed
+//   404:
+//     description: Schedule not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ScheduleEndpoint) Delete(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	err := se.storage.Delete(params.ByName("name"))
+	if err == schedule.ErrNotFound {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// AddRoutesForSchedules attaches schedule endpoints to router
+func AddRoutesForSchedules(router *httprouter.Router, storage scheduleStorage, profiles profileGetter) {
+	scheduleEndpoint := NewScheduleEndpoint(storage, profiles)
+	router.GET("/schedules", scheduleEndpoint.List)
+	router.PUT("/schedules/:name", scheduleEndpoint.Save)
+	router.DELETE("/schedules/:name", scheduleEndpoint.Delete)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/consumer/profile"
+	"github.com/mysteriumnetwork/node/consumer/schedule"
+)
+
+func TestScheduleSaveAndList(t *testing.T) {
+	storage := newMockScheduleStorage()
+	profiles := newMockProfileStorage()
+	profiles.profiles["work"] = profile.Profile{Name: "work", Country: "DE", ServiceType: "wireguard"}
+	router := httprouter.New()
+	AddRoutesForSchedules(router, storage, profiles)
+
+	req := httptest.NewRequest(http.MethodPut, "/schedules/office", strings.NewReader(
+		`{"days": ["monday", "fri"], "connect_at": "09:00", "disconnect_at": "17:30", "profile": "work"}`,
+	))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t,
+		schedule.Schedule{
+			Name:         "office",
+			Days:         []time.Weekday{time.Monday, time.Friday},
+			ConnectAt:    &schedule.TimeOfDay{Hour: 9},
+			DisconnectAt: &schedule.TimeOfDay{Hour: 17, Minute: 30},
+			Profile:      "work",
+		},
+		storage.schedules["office"],
+	)
+
+	req = httptest.NewRequest(http.MethodGet, "/schedules", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t,
+		`{"schedules": [{"name": "office", "days": ["monday", "friday"], "connect_at": "09:00", "disconnect_at": "17:30", "profile": "work"}]}`,
+		resp.Body.String(),
+	)
+}
+
+func TestScheduleListEmpty(t *testing.T) {
+	endpoint := NewScheduleEndpoint(newMockScheduleStorage(), newMockProfileStorage())
+
+	resp := httptest.NewRecorder()
+	endpoint.List(resp, httptest.NewRequest(http.MethodGet, "/schedules", nil), nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"schedules": []}`, resp.Body.String())
+}
+
+func TestScheduleSaveRejectsInvalidSchedule(t *testing.T) {
+	endpoint := NewScheduleEndpoint(newMockScheduleStorage(), newMockProfileStorage())
+	params := httprouter.Params{{Key: "name", Value: "office"}}
+
+	for _, body := range []string{
+		`{"connect_at": "25:00", "profile": "work"}`,
+		`{"days": ["someday"], "disconnect_at": "17:00"}`,
+		`{"profile": "work"}`,
+		`{"connect_at": "09:00", "profile": "work"}`,
+	} {
+		resp := httptest.NewRecorder()
+		endpoint.Save(resp, httptest.NewRequest(http.MethodPut, "/schedules/office", strings.NewReader(body)), params)
+		assert.Equal(t, http.StatusBadRequest, resp.Code, body)
+	}
+}
+
+func TestScheduleDelete(t *testing.T) {
+	storage := newMockScheduleStorage()
+	storage.schedules["office"] = schedule.Schedule{Name: "office", DisconnectAt: &schedule.TimeOfDay{Hour: 17}}
+	endpoint := NewScheduleEndpoint(storage, newMockProfileStorage())
+	params := httprouter.Params{{Key: "name", Value: "office"}}
+
+	resp := httptest.NewRecorder()
+	endpoint.Delete(resp, httptest.NewRequest(http.MethodDelete, "/schedules/office", nil), params)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Empty(t, storage.schedules)
+
+	resp = httptest.NewRecorder()
+	endpoint.Delete(resp, httptest.NewRequest(http.MethodDelete, "/schedules/office", nil), params)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+type mockScheduleStorage struct {
+	schedules map[string]schedule.Schedule
+}
+
+func newMockScheduleStorage() *mockScheduleStorage {
+	return &mockScheduleStorage{schedules: map[string]schedule.Schedule{}}
+}
+
+func (s *mockScheduleStorage) Save(sc schedule.Schedule) error {
+	s.schedules[sc.Name] = sc
+	return nil
+}
+
+func (s *mockScheduleStorage) List() ([]schedule.Schedule, error) {
+	var res []schedule.Schedule
+	for _, sc := range s.schedules {
+		res = append(res, sc)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+func (s *mockScheduleStorage) Delete(name string) error {
+	if _, ok := s.schedules[name]; !ok {
+		return schedule.ErrNotFound
+	}
+	delete(s.schedules, name)
+	return nil
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/core/discovery/selection"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+type selectionEngine interface {
+	Policy() selection.Policy
+	SetPolicy(policy selection.Policy) error
+}
+
+type selectionConfig interface {
+	SetUser(key string, value interface{})
+	SaveUserConfig() error
+}
+
+// SelectionEndpoint struct represents /selection resource and it's subresources
+type SelectionEndpoint struct {
+	engine selectionEngine
+	config selectionConfig
+}
+
+// NewSelectionEndpoint creates and returns provider selection endpoint
+func NewSelectionEndpoint(engine selectionEngine, config selectionConfig) *SelectionEndpoint {
+	return &SelectionEndpoint{
+		engine: engine,
+		config: config,
+	}
+}
+
+// GetPolicy returns provider selection policy
+// swagger:operation GET /selection/policy Selection getSelectionPolicy
+// ---
+// summary: Returns provider selection policy
+// description: Returns weights of criteria proposals are ranked by when connecting without explicit provider
+// responses:
+//   200:
+//     description: Selection policy
+//     schema:
+//       "$ref": "#/definitions/SelectionPolicyDTO"
+func (se *SelectionEndpoint) GetPolicy(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	utils.WriteAsJSON(contract.NewSelectionPolicyDTO(se.engine.Policy()), resp)
+}
+
+// SetPolicy replaces provider selection policy
+// swagger:operation PUT /selection/policy Selection setSelectionPolicy
+// ---
+// summary: Replaces provider selection policy
+// description: Replaces weights of criteria proposals are ranked by and saves them to user configuration
+// parameters:
+//   - in: body
+//     name: body
+//     description: Selection policy
+//     schema:
+//       $ref: "#/definitions/SelectionPolicyDTO"
+// responses:
+//   200:
+//     description: Selection policy updated
+//     schema:
+//       "$ref": "#/definitions/SelectionPolicyDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *SelectionEndpoint) SetPolicy(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var dto contract.SelectionPolicyDTO
+	if err := json.NewDecoder(req.Body).Decode(&dto); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	previous := se.engine.Policy()
+	policy := dto.ToPolicy()
+	if err := se.engine.SetPolicy(policy); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	// Criteria missing from the new policy are disabled, so that saved weights do not bring them back.
+	for name := range previous.Weights {
+		se.config.SetUser(config.SelectionWeightKey(name), 0.0)
+	}
+	for name, weight := range policy.Weights {
+		se.config.SetUser(config.SelectionWeightKey(name), weight)
+	}
+	se.config.SetUser(config.FlagSelectionCountries.Name, strings.Join(policy.Countries, ","))
+	if err := se.config.SaveUserConfig(); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(contract.NewSelectionPolicyDTO(se.engine.Policy()), resp)
+}
+
+// AddRoutesForSelection attaches provider selection endpoints to router
+func AddRoutesForSelection(router *httprouter.Router, engine selectionEngine, config selectionConfig) {
+	selectionEndpoint := NewSelectionEndpoint(engine, config)
+	router.GET("/selection/policy", selectionEndpoint.GetPolicy)
+	router.PUT("/selection/policy", selectionEndpoint.SetPolicy)
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/discovery/selection"
+)
+
+type mockSelectionConfig struct {
+	values map[string]interface{}
+	saved  bool
+}
+
+func (c *mockSelectionConfig) SetUser(key string, value interface{}) {
+	c.values[key] = value
+}
+
+func (c *mockSelectionConfig) SaveUserConfig() error {
+	c.saved = true
+	return nil
+}
+
+func newSelectionEngine(t *testing.T) *selection.Engine {
+	engine := selection.NewEngine()
+	engine.Register(selection.CriterionPrice, selection.PriceCriterion{})
+	engine.Register(selection.CriterionCountry, selection.CountryCriterion{})
+	assert.NoError(t, engine.SetPolicy(selection.Policy{Weights: map[string]float64{selection.CriterionPrice: 1, selection.CriterionCountry: 1}}))
+	return engine
+}
+
+func TestSelectionPolicyGet(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForSelection(router, newSelectionEngine(t), &mockSelectionConfig{values: map[string]interface{}{}})
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/selection/policy", nil))
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"weights": {"price": 1, "country": 1}}`, resp.Body.String())
+}
+
+func TestSelectionPolicySetSavesConfig(t *testing.T) {
+	engine := newSelectionEngine(t)
+	config := &mockSelectionConfig{values: map[string]interface{}{}}
+	router := httprouter.New()
+	AddRoutesForSelection(router, engine, config)
+
+	req := httptest.NewRequest(http.MethodPut, "/selection/policy", strings.NewReader(`{"weights": {"price": 2.5}, "countries": ["DE", "NL"]}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"weights": {"price": 2.5}, "countries": ["DE", "NL"]}`, resp.Body.String())
+	assert.Equal(t, selection.Policy{Weights: map[string]float64{"price": 2.5}, Countries: []string{"DE", "NL"}}, engine.Policy())
+	assert.True(t, config.saved)
+	assert.Equal(t,
+		map[string]interface{}{
+			"discovery.selection.weight.price":   2.5,
+			"discovery.selection.weight.country": 0.0,
+			"discovery.selection.countries":      "DE,NL",
+		},
+		config.values,
+	)
+}
+
+func TestSelectionPolicySetRejectsUnknownCriterion(t *testing.T) {
+	engine := newSelectionEngine(t)
+	config := &mockSelectionConfig{values: map[string]interface{}{}}
+	endpoint := NewSelectionEndpoint(engine, config)
+
+	req := httptest.NewRequest(http.MethodPut, "/selection/policy", strings.NewReader(`{"weights": {"speed": 1}}`))
+	resp := httptest.NewRecorder()
+	endpoint.SetPolicy(resp, req, nil)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.JSONEq(t, `{"message": "unknown selection criterion \"speed\""}`, resp.Body.String())
+	assert.False(t, config.saved)
+}
@@ -20,6 +20,7 @@ package endpoints
 import (
 	"encoding/json"
 	"net/http"
+	"reflect"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/core/service"
@@ -204,9 +205,209 @@ func (se *ServiceEndpoint) ServiceStop(resp http.ResponseWriter, _ *http.Request
 	resp.WriteHeader(http.StatusAccepted)
 }
 
+// ServiceUpdate changes options and access policies of the running service.
+// swagger:operation PUT /services/:id Service serviceUpdate
+// ---
+// summary: Updates service options
+// description: Applies changed options and access policies to the running service without restarting it and re-registers its proposal.
+// parameters:
+//   - in: path
+//     name: id
+//     description: Service ID
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Service options and access policies
+//     schema:
+//       $ref: "#/definitions/ServiceUpdateRequestDTO"
+// responses:
+//   200:
+//     description: Service updated
+//     schema:
+//       "$ref": "#/definitions/ServiceInfoDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: No service exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Options can not be changed without service restart
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ServiceEndpoint) ServiceUpdate(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	id := service.ID(params.ByName("id"))
+
+	instance := se.serviceManager.Service(id)
+	if instance == nil {
+		utils.SendErrorMessage(resp, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	var jsonData struct {
+		Options        *json.RawMessage                `json:"options"`
+		AccessPolicies *contract.ServiceAccessPolicies `json:"access_policies"`
+	}
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&jsonData); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	options := instance.CopyOptions()
+	if jsonData.Options != nil {
+		optionsParser, ok := se.optionsParser[instance.Type]
+		if !ok {
+			utils.SendErrorMessage(resp, "Service options can not be changed", http.StatusBadRequest)
+			return
+		}
+		parsed, err := optionsParser(jsonData.Options)
+		if err != nil {
+			utils.SendError(resp, err, http.StatusBadRequest)
+			return
+		}
+		options = parsed
+	}
+
+	var policyIDs []string
+	if jsonData.AccessPolicies != nil {
+		policyIDs = jsonData.AccessPolicies.IDs
+	} else if policies := instance.CopyProposal().AccessPolicies; policies != nil {
+		for _, policy := range *policies {
+			policyIDs = append(policyIDs, policy.ID)
+		}
+	}
+
+	err := se.serviceManager.Reload(id, policyIDs, options)
+	if err == service.ErrNoSuchInstance {
+		utils.SendErrorMessage(resp, "Service not found", http.StatusNotFound)
+		return
+	} else if err == service.ErrRestartRequired {
+		utils.SendError(resp, err, http.StatusConflict)
+		return
+	} else if err == service.ErrUnsupportedAccessPolicy {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(toServiceInfoResponse(id, instance), resp)
+}
+
+// ServicePaymentMethodUpdate changes prices of the running service.
+// swagger:operation PUT /services/:id/payment-method Service servicePaymentMethodUpdate
+// ---
+// summary: Updates service prices
+// description: Changes prices of the running service and re-registers its proposal. Established sessions keep their prices.
+// parameters:
+//   - in: path
+//     name: id
+//     description: Service ID
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Service prices
+//     schema:
+//       $ref: "#/definitions/ServicePaymentMethod"
+// responses:
+//   200:
+//     description: Service prices updated
+//     schema:
+//       "$ref": "#/definitions/ServiceInfoDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: No service exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ServiceEndpoint) ServicePaymentMethodUpdate(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	id := service.ID(params.ByName("id"))
+
+	var pm contract.ServicePaymentMethod
+	if err := json.NewDecoder(req.Body).Decode(&pm); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	err := se.serviceManager.UpdatePaymentMethod(id, pingpong.NewPaymentMethod(pm.PriceGB, pm.PriceMinute))
+	if err == service.ErrNoSuchInstance {
+		utils.SendErrorMessage(resp, "Service not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(toServiceInfoResponse(id, se.serviceManager.Service(id)), resp)
+}
+
+// ServiceSessionTerminate terminates a single session of the running service.
+// swagger:operation DELETE /services/:id/sessions/:session_id Service serviceSessionTerminate
+// ---
+// summary: Terminates service session
+// description: Ends a single consumer session without restarting the service
+// parameters:
+//   - in: path
+//     name: id
+//     description: Service ID
+//     type: string
+//     required: true
+//   - in: path
+//     name: session_id
+//     description: Session ID
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: Session terminated
+//   404:
+//     description: No service or session exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ServiceEndpoint) ServiceSessionTerminate(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	id := service.ID(params.ByName("id"))
+
+	err := se.serviceManager.TerminateSession(id, params.ByName("session_id"))
+	if err == service.ErrNoSuchInstance {
+		utils.SendErrorMessage(resp, "Service not found", http.StatusNotFound)
+		return
+	} else if err == service.ErrorSessionNotExists {
+		utils.SendErrorMessage(resp, "Session not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// isAlreadyRunning checks whether the same service is running already.
+// Services of the same type with different options, e.g. bound to other ports, may run side by side.
 func (se *ServiceEndpoint) isAlreadyRunning(sr contract.ServiceStartRequest) bool {
 	for _, instance := range se.serviceManager.List() {
-		if instance.ProviderID.Address == sr.ProviderID && instance.Type == sr.Type {
+		if instance.ProviderID.Address == sr.ProviderID && instance.Type == sr.Type && reflect.DeepEqual(instance.CopyOptions(), sr.Options) {
 			return true
 		}
 	}
@@ -221,6 +422,9 @@ func AddRoutesForService(router *httprouter.Router, serviceManager ServiceManage
 	router.POST("/services", serviceEndpoint.ServiceStart)
 	router.GET("/services/:id", serviceEndpoint.ServiceGet)
 	router.DELETE("/services/:id", serviceEndpoint.ServiceStop)
+	router.PUT("/services/:id", serviceEndpoint.ServiceUpdate)
+	router.PUT("/services/:id/payment-method", serviceEndpoint.ServicePaymentMethodUpdate)
+	router.DELETE("/services/:id/sessions/:session_id", serviceEndpoint.ServiceSessionTerminate)
 }
 
 func (se *ServiceEndpoint) toServiceRequest(req *http.Request) (contract.ServiceStartRequest, error) {
@@ -291,9 +495,10 @@ func toServiceInfoResponse(id service.ID, instance *service.Instance) contract.S
 		ID:         string(id),
 		ProviderID: instance.ProviderID.Address,
 		Type:       instance.Type,
-		Options:    instance.Options,
+		Options:    instance.CopyOptions(),
 		Status:     string(instance.State()),
-		Proposal:   contract.NewProposalDTO(instance.Proposal),
+		Backend:    instance.Backend(),
+		Proposal:   contract.NewProposalDTO(instance.CopyProposal()),
 	}
 }
 
@@ -326,6 +531,9 @@ func validateServiceRequest(sr contract.ServiceStartRequest) *validation.FieldEr
 type ServiceManager interface {
 	Start(providerID identity.Identity, serviceType string, policies []string, options service.Options, pm market.PaymentMethod) (service.ID, error)
 	Stop(id service.ID) error
+	Reload(id service.ID, policies []string, options service.Options) error
+	UpdatePaymentMethod(id service.ID, pm market.PaymentMethod) error
+	TerminateSession(id service.ID, sessionID string) error
 	Service(id service.ID) *service.Instance
 	Kill() error
 	List() map[service.ID]*service.Instance
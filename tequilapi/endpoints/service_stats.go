@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/mysteriumnetwork/node/core/stats"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+// ServiceStatsProvider provides hourly statistics of provided services.
+type ServiceStatsProvider interface {
+	Hours(serviceID string, from, to time.Time) ([]stats.Hour, error)
+}
+
+type serviceStatsEndpoint struct {
+	stats ServiceStatsProvider
+	now   func() time.Time
+}
+
+// ServiceStats returns hourly statistics of the service.
+// swagger:operation GET /services/:id/stats Service serviceStats
+// ---
+// summary: Returns service statistics
+// description: Returns sessions, unique consumers, traffic and earnings of the service aggregated per hour
+// parameters:
+//   - in: path
+//     name: id
+//     description: Service ID
+//     type: string
+//     required: true
+//
+// responses:
+//
+//	200:
+//	  description: Hourly service statistics
+//	  schema:
+//	    "$ref": "#/definitions/ServiceStatsResponse"
+//	422:
+//	  description: Parameters validation error
+//	  schema:
+//	    "$ref": "#/definitions/ValidationErrorDTO"
+//	500:
+//	  description: Internal server error
+//	  schema:
+//	    "$ref": "#/definitions/ErrorMessageDTO"
+func (e *serviceStatsEndpoint) ServiceStats(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	var query contract.ServiceStatsQuery
+	if errors := query.Bind(req); errors.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errors)
+		return
+	}
+
+	from, to := query.Period(e.now())
+	hours, err := e.stats.Hours(params.ByName("id"), from, to)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(contract.NewServiceStatsResponse(hours), resp)
+}
+
+// AddRoutesForServiceStats adds service statistics routes to given router
+func AddRoutesForServiceStats(router *httprouter.Router, stats ServiceStatsProvider) {
+	e := &serviceStatsEndpoint{stats: stats, now: time.Now}
+	router.GET("/services/:id/stats", e.ServiceStats)
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mysteriumnetwork/node/core/stats"
+)
+
+type mockServiceStats struct {
+	serviceID string
+	from, to  time.Time
+	hours     []stats.Hour
+}
+
+func (m *mockServiceStats) Hours(serviceID string, from, to time.Time) ([]stats.Hour, error) {
+	m.serviceID, m.from, m.to = serviceID, from, to
+	return m.hours, nil
+}
+
+func Test_ServiceStats(t *testing.T) {
+	at := time.Date(2020, 10, 16, 12, 0, 0, 0, time.UTC)
+	mock := &mockServiceStats{hours: []stats.Hour{
+		{At: at, Sessions: 2, Consumers: []string{"0x1", "0x2"}, BytesSent: 100, BytesReceived: 10, Tokens: big.NewInt(5)},
+	}}
+	router := httprouter.New()
+	AddRoutesForServiceStats(router, mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/services/service1/stats?date_from=2020-10-15&date_to=2020-10-16", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "service1", mock.serviceID)
+	assert.Equal(t, time.Date(2020, 10, 15, 0, 0, 0, 0, time.UTC), mock.from)
+	assert.Equal(t, time.Date(2020, 10, 16, 23, 59, 59, 0, time.UTC), mock.to)
+	assert.JSONEq(t, `{
+		"hours": [
+			{
+				"at": "2020-10-16T12:00:00Z",
+				"sessions": 2,
+				"unique_consumers": 2,
+				"bytes_sent": 100,
+				"bytes_received": 10,
+				"tokens": 5
+			}
+		]
+	}`, resp.Body.String())
+}
+
+func Test_ServiceStats_ValidatesDates(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForServiceStats(router, &mockServiceStats{})
+
+	req := httptest.NewRequest(http.MethodGet, "/services/service1/stats?date_from=yesterday", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+}
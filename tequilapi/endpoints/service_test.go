@@ -33,12 +33,14 @@ import (
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/mocks"
 	"github.com/mysteriumnetwork/node/services"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
 	mockServiceID             = service.ID("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 	mockAccessPolicyServiceID = service.ID("6ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	mockSessionID             = "6ba7b810-9dad-11d1-80b4-00c04fd430ca"
 	mockProviderID            = identity.FromAddress("0xproviderid")
 	mockServiceType           = "testprotocol"
 	mockServiceOptions        = fancyServiceOptions{
@@ -99,6 +101,30 @@ func (sm *mockServiceManager) Start(providerID identity.Identity, serviceType st
 	return mockServiceID, nil
 }
 func (sm *mockServiceManager) Stop(id service.ID) error { return nil }
+func (sm *mockServiceManager) Reload(id service.ID, policyIDs []string, options service.Options) error {
+	if sm.Service(id) == nil {
+		return service.ErrNoSuchInstance
+	}
+	if options != mockServiceOptions {
+		return service.ErrRestartRequired
+	}
+	return nil
+}
+func (sm *mockServiceManager) UpdatePaymentMethod(id service.ID, pm market.PaymentMethod) error {
+	if sm.Service(id) == nil {
+		return service.ErrNoSuchInstance
+	}
+	return nil
+}
+func (sm *mockServiceManager) TerminateSession(id service.ID, sessionID string) error {
+	if sm.Service(id) == nil {
+		return service.ErrNoSuchInstance
+	}
+	if sessionID != mockSessionID {
+		return service.ErrorSessionNotExists
+	}
+	return nil
+}
 func (sm *mockServiceManager) Service(id service.ID) *service.Instance {
 	if id == "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
 		return mockServiceRunning
@@ -117,7 +143,10 @@ func (sm *mockServiceManager) Kill() error { return nil }
 
 var fakeOptionsParser = map[string]services.ServiceOptionsParser{
 	"testprotocol": func(opts *json.RawMessage) (service.Options, error) {
-		return nil, nil
+		if opts != nil && string(*opts) != "{}" {
+			return fancyServiceOptions{Foo: string(*opts)}, nil
+		}
+		return mockServiceOptions, nil
 	},
 	serviceTypeWithAccessPolicy: func(opts *json.RawMessage) (service.Options, error) {
 		return nil, nil
@@ -245,6 +274,10 @@ func Test_AddRoutesForServiceAddsRoutes(t *testing.T) {
 			http.MethodDelete, "/services/00000000-9dad-11d1-80b4-00c04fd43000", "",
 			http.StatusNotFound, `{"message":"Service not found"}`,
 		},
+		{
+			http.MethodPut, "/services/00000000-9dad-11d1-80b4-00c04fd43000/payment-method", `{"price_gb": 1, "price_minute": 1}`,
+			http.StatusNotFound, `{"message":"Service not found"}`,
+		},
 	}
 
 	for _, test := range tests {
@@ -261,6 +294,81 @@ func Test_AddRoutesForServiceAddsRoutes(t *testing.T) {
 	}
 }
 
+func Test_ServicePaymentMethodUpdate(t *testing.T) {
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader(`{"price_gb": 100, "price_minute": 10}`))
+	resp := httptest.NewRecorder()
+
+	serviceEndpoint.ServicePaymentMethodUpdate(resp, req, httprouter.Params{{Key: "id", Value: string(mockServiceID)}})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var info contract.ServiceInfoDTO
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	assert.Equal(t, string(mockServiceID), info.ID)
+	assert.Equal(t, "Running", info.Status)
+}
+
+func Test_ServiceUpdate(t *testing.T) {
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+
+	tests := []struct {
+		serviceID string
+		body      string
+		code      int
+	}{
+		{serviceID: string(mockServiceID), body: `{"options": {}, "access_policies": {"ids": ["verified-traffic"]}}`, code: http.StatusOK},
+		{serviceID: string(mockServiceID), body: `{}`, code: http.StatusOK},
+		{serviceID: string(mockServiceID), body: `{"options": {"foo": "baz"}}`, code: http.StatusConflict},
+		{serviceID: string(mockServiceID), body: `{"type": "wireguard"}`, code: http.StatusBadRequest},
+		{serviceID: "unknown", body: `{}`, code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader(tt.body))
+		resp := httptest.NewRecorder()
+
+		serviceEndpoint.ServiceUpdate(resp, req, httprouter.Params{{Key: "id", Value: tt.serviceID}})
+
+		assert.Equal(t, tt.code, resp.Code, tt.body)
+	}
+}
+
+func Test_ServiceSessionTerminate(t *testing.T) {
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+
+	tests := []struct {
+		serviceID string
+		sessionID string
+		code      int
+	}{
+		{serviceID: string(mockServiceID), sessionID: mockSessionID, code: http.StatusAccepted},
+		{serviceID: string(mockServiceID), sessionID: "unknown", code: http.StatusNotFound},
+		{serviceID: "unknown", sessionID: mockSessionID, code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodDelete, "/irrelevant", nil)
+		resp := httptest.NewRecorder()
+
+		serviceEndpoint.ServiceSessionTerminate(resp, req, httprouter.Params{
+			{Key: "id", Value: tt.serviceID},
+			{Key: "session_id", Value: tt.sessionID},
+		})
+
+		assert.Equal(t, tt.code, resp.Code)
+	}
+}
+
+func Test_ServicePaymentMethodUpdate_Returns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("a"))
+	resp := httptest.NewRecorder()
+
+	serviceEndpoint.ServicePaymentMethodUpdate(resp, req, httprouter.Params{{Key: "id", Value: string(mockServiceID)}})
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
 func Test_ServiceStartInvalidType(t *testing.T) {
 	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
 
@@ -372,6 +480,25 @@ func Test_ServiceStartAlreadyRunning(t *testing.T) {
 	)
 }
 
+func Test_ServiceStartAnotherInstanceWithDifferentOptions(t *testing.T) {
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+
+	req := httptest.NewRequest(
+		http.MethodGet,
+		"/irrelevant",
+		strings.NewReader(`{
+			"type": "testprotocol",
+			"provider_id": "0xproviderid",
+			"options": {"port": 52821}
+		}`),
+	)
+	resp := httptest.NewRecorder()
+
+	serviceEndpoint.ServiceStart(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+}
+
 func Test_ServiceStatus_NotFoundIsReturnedWhenNotStarted(t *testing.T) {
 	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
 
@@ -389,7 +516,7 @@ func Test_ServiceGetReturnsServiceInfo(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
-	serviceEndpoint.ServiceGet(resp, req, httprouter.Params{{Key: "id", Value: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"}})
+	serviceEndpoint.ServiceGet(resp, req, httprouter.Params{{Key: "id", Value: string(mockServiceID)}})
 
 	assert.Equal(t, http.StatusOK, resp.Code)
 	assert.JSONEq(
@@ -25,10 +25,13 @@ import (
 	"sync"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/consumer/autoswitch"
 	"github.com/mysteriumnetwork/node/consumer/session"
+	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
 	nodeEvent "github.com/mysteriumnetwork/node/core/node/event"
 	stateEvent "github.com/mysteriumnetwork/node/core/state/event"
 	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity/registry"
 	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -50,6 +53,14 @@ const (
 	ServiceStatusEvent EventType = "service-status"
 	// StateChangeEvent represents the state change
 	StateChangeEvent EventType = "state-change"
+	// DataCapWarningEvent represents connection approaching its data cap
+	DataCapWarningEvent EventType = "data-cap-warning"
+	// DNSLeakEvent represents DNS queries of the connection found bypassing the tunnel
+	DNSLeakEvent EventType = "dns-leak"
+	// AutoSwitchEvent represents connection switched to another provider because of degraded tunnel health
+	AutoSwitchEvent EventType = "auto-switch"
+	// RegistrationEvent represents identity registration status change
+	RegistrationEvent EventType = "identity-registration"
 )
 
 // Handler represents an sse handler
@@ -86,6 +97,22 @@ func (h *Handler) Subscribe(bus eventbus.Subscriber) error {
 		return err
 	}
 	err = bus.Subscribe(stateEvent.AppTopicState, h.ConsumeStateEvent)
+	if err != nil {
+		return err
+	}
+	err = bus.Subscribe(connectionstate.AppTopicDataCap, h.ConsumeDataCapEvent)
+	if err != nil {
+		return err
+	}
+	err = bus.Subscribe(connectionstate.AppTopicDNSLeak, h.ConsumeDNSLeakEvent)
+	if err != nil {
+		return err
+	}
+	err = bus.Subscribe(autoswitch.AppTopicAutoSwitch, h.ConsumeAutoSwitchEvent)
+	if err != nil {
+		return err
+	}
+	err = bus.Subscribe(registry.AppTopicIdentityRegistration, h.ConsumeRegistrationEvent)
 	return err
 }
 
@@ -252,7 +279,7 @@ func mapState(event stateEvent.State) stateRes {
 		Sessions:      sessionsRes,
 		SessionsStats: contract.NewSessionStatsDTO(sessionsStats),
 		Consumer: consumerStateRes{
-			Connection: contract.NewConnectionDTO(event.Connection.Session, event.Connection.Statistics, event.Connection.Throughput, event.Connection.Invoice),
+			Connection: contract.NewConnectionDTO(event.Connection.Session, event.Connection.Statistics, event.Connection.Throughput, event.Connection.Invoice, event.Connection.Health),
 		},
 		Identities: identitiesRes,
 		Channels:   channelsRes,
@@ -260,6 +287,60 @@ func mapState(event stateEvent.State) stateRes {
 	return res
 }
 
+// ConsumeDataCapEvent forwards data cap usage warnings to clients
+func (h *Handler) ConsumeDataCapEvent(event connectionstate.AppEventDataCap) {
+	h.send(Event{
+		Type: DataCapWarningEvent,
+		Payload: contract.DataCapWarningDTO{
+			SessionID: string(event.SessionInfo.SessionID),
+			Used:      event.Used,
+			Limit:     event.Limit,
+			Percent:   event.Percent,
+		},
+	})
+}
+
+// ConsumeDNSLeakEvent forwards detected DNS leaks to clients
+func (h *Handler) ConsumeDNSLeakEvent(event connectionstate.AppEventDNSLeak) {
+	h.send(Event{
+		Type: DNSLeakEvent,
+		Payload: contract.DNSLeakDTO{
+			SessionID: string(event.SessionInfo.SessionID),
+			Resolvers: event.Resolvers,
+		},
+	})
+}
+
+// ConsumeAutoSwitchEvent forwards reasons of connection switches to clients
+func (h *Handler) ConsumeAutoSwitchEvent(event autoswitch.AppEventAutoSwitch) {
+	h.send(Event{
+		Type: AutoSwitchEvent,
+		Payload: contract.AutoSwitchDTO{
+			FromProviderID: event.From,
+			ToProviderID:   event.To,
+			Reason:         string(event.Reason),
+			Health: contract.ConnectionHealthDTO{
+				RTT:        int(event.Health.RTT.Milliseconds()),
+				PacketLoss: event.Health.PacketLoss,
+				Samples:    event.Health.Samples,
+			},
+			Degraded: int(event.Degraded.Seconds()),
+		},
+	})
+}
+
+// ConsumeRegistrationEvent forwards identity registration status changes to clients
+func (h *Handler) ConsumeRegistrationEvent(event registry.AppEventIdentityRegistration) {
+	h.send(Event{
+		Type: RegistrationEvent,
+		Payload: contract.IdentityRegistrationEventDTO{
+			Address:    event.ID.Address,
+			Status:     event.Status.String(),
+			Registered: event.Status.Registered(),
+		},
+	})
+}
+
 // ConsumeStateEvent consumes the state change event
 func (h *Handler) ConsumeStateEvent(event stateEvent.State) {
 	h.send(Event{
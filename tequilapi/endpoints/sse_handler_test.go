@@ -33,6 +33,7 @@ import (
 	"github.com/mysteriumnetwork/node/core/connection/connectionstate"
 	nodeEvent "github.com/mysteriumnetwork/node/core/node/event"
 	stateEvent "github.com/mysteriumnetwork/node/core/state/event"
+	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
 	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/stretchr/testify/assert"
@@ -258,3 +259,17 @@ func TestHandler_SendsInitialAndFollowingStates(t *testing.T) {
 
 	<-serveExit
 }
+
+func TestHandler_SendsRegistrationEvent(t *testing.T) {
+	h := NewSSEHandler(&mockStateProvider{})
+
+	h.ConsumeRegistrationEvent(registry.AppEventIdentityRegistration{
+		ID:     identity.FromAddress("0x000000000000000000000000000000000000000a"),
+		Status: registry.Registered,
+	})
+
+	assert.JSONEq(t, `{
+		"type": "identity-registration",
+		"payload": {"id": "0x000000000000000000000000000000000000000a", "status": "Registered", "registered": true}
+	}`, <-h.messages)
+}
@@ -40,7 +40,10 @@ type Transactor interface {
 	FetchRegistrationFees() (registry.FeesResponse, error)
 	FetchSettleFees() (registry.FeesResponse, error)
 	FetchStakeDecreaseFee() (registry.FeesResponse, error)
+	QuoteRegistration(id identity.Identity) (registry.RegistrationQuote, error)
 	RegisterIdentity(id string, stake, fee *big.Int, beneficiary string, referralToken *string) error
+	RegisterIdentitySponsored(id, beneficiary, sponsor string) error
+	RegistrationAuthorization(id, beneficiary string) (registry.IdentityRegistrationRequest, error)
 	DecreaseStake(id string, amount, transactorFee *big.Int) error
 	GetTokenReward(referralToken string) (registry.TokenRewardResponse, error)
 	GetReferralToken(id common.Address) (string, error)
@@ -63,6 +66,7 @@ type transactorEndpoint struct {
 	promiseSettler            promiseSettler
 	settlementHistoryProvider settlementHistoryProvider
 	hermesAddress             common.Address
+	hermesResolver            hermesResolver
 }
 
 // NewTransactorEndpoint creates and returns transactor endpoint
@@ -120,6 +124,130 @@ func (te *transactorEndpoint) TransactorFees(resp http.ResponseWriter, _ *http.R
 	utils.WriteAsJSON(f, resp)
 }
 
+// swagger:operation GET /identities/{id}/register/quote Identity RegistrationQuote
+// ---
+// summary: Quotes identity registration
+// description: Returns current registration fee, bounty eligibility and estimated confirmation time of identity registration
+// parameters:
+// - name: id
+//   in: path
+//   description: Identity address to quote registration for
+//   type: string
+//   required: true
+// responses:
+//   200:
+//     description: Registration quote
+//     schema:
+//       "$ref": "#/definitions/RegistrationQuoteDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (te *transactorEndpoint) RegistrationQuote(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	quote, err := te.transactor.QuoteRegistration(identity.FromAddress(params.ByName("id")))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(contract.RegistrationQuoteDTO{
+		Fee:                          quote.Fee,
+		ValidUntil:                   quote.ValidUntil,
+		BountyEligible:               quote.BountyEligible,
+		EstimatedConfirmationSeconds: int64(quote.EstimatedConfirmation.Seconds()),
+	}, resp)
+}
+
+// swagger:operation POST /identities/{id}/register/sponsored Identity RegisterIdentitySponsored
+// ---
+// summary: Registers identity paid by sponsor
+// description: Registers identity on Mysterium Network smart contracts with the transaction paid by a third-party sponsor,
+//   so identity without any funds can be registered. Identity only signs the registration authorization.
+// parameters:
+// - name: id
+//   in: path
+//   description: Identity address to register
+//   type: string
+//   required: true
+// - in: body
+//   name: body
+//   description: all body parameters are optional
+//   schema:
+//     $ref: "#/definitions/IdentitySponsoredRegisterRequestDTO"
+// responses:
+//   202:
+//     description: Sponsored registration accepted
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (te *transactorEndpoint) RegisterIdentitySponsored(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id := params.ByName("id")
+
+	req := contract.IdentitySponsoredRegisterRequest{}
+	if request.ContentLength != 0 {
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			utils.SendError(resp, errors.Wrap(err, "failed to parse sponsored identity registration request"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := te.transactor.RegisterIdentitySponsored(id, req.Beneficiary, req.Sponsor)
+	if err != nil {
+		log.Err(err).Msgf("Failed sponsored identity registration request for ID: %s, %+v", id, req)
+		utils.SendError(resp, errors.Wrap(err, "failed sponsored identity registration request"), http.StatusInternalServerError)
+		return
+	}
+
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// swagger:operation GET /identities/{id}/register/authorization Identity RegistrationAuthorization
+// ---
+// summary: Returns signed registration authorization
+// description: Returns registration request signed by identity with zero stake and fee,
+//   which third-party payer may submit to the registry on behalf of the identity.
+// parameters:
+// - name: id
+//   in: path
+//   description: Identity address to authorize registration of
+//   type: string
+//   required: true
+// - name: beneficiary
+//   in: query
+//   description: Cache out address for Provider, channel address is used by default
+//   type: string
+// responses:
+//   200:
+//     description: Signed registration authorization
+//     schema:
+//       "$ref": "#/definitions/RegistrationAuthorizationDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (te *transactorEndpoint) RegistrationAuthorization(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	auth, err := te.transactor.RegistrationAuthorization(params.ByName("id"), request.URL.Query().Get("beneficiary"))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(contract.RegistrationAuthorizationDTO{
+		Identity:        auth.Identity,
+		RegistryAddress: auth.RegistryAddress,
+		HermesID:        auth.HermesID,
+		Stake:           auth.Stake,
+		Fee:             auth.Fee,
+		Beneficiary:     auth.Beneficiary,
+		Signature:       auth.Signature,
+	}, resp)
+}
+
 // swagger:operation POST /transactor/settle/sync SettleSync
 // ---
 // summary: forces the settlement of promises for the given provider and hermes
@@ -190,7 +318,16 @@ func (te *transactorEndpoint) settle(request *http.Request, settler func(identit
 		return errors.Wrap(err, "failed to unmarshal settle request")
 	}
 
-	return errors.Wrap(settler(identity.FromAddress(req.ProviderID), common.HexToAddress(req.HermesID)), "settling failed")
+	providerID := identity.FromAddress(req.ProviderID)
+	return errors.Wrap(settler(providerID, te.settlementHermesID(providerID, req.HermesID)), "settling failed")
+}
+
+// settlementHermesID returns requested hermes, or the preferred one of identity if request does not specify it.
+func (te *transactorEndpoint) settlementHermesID(id identity.Identity, requested string) common.Address {
+	if requested != "" {
+		return common.HexToAddress(requested)
+	}
+	return resolveHermesID(te.hermesResolver, id)
 }
 
 // swagger:operation POST /identities/{id}/register Identity RegisterIdentity
@@ -264,7 +401,8 @@ func (te *transactorEndpoint) SettleWithBeneficiary(resp http.ResponseWriter, re
 		return
 	}
 
-	err = te.promiseSettler.SettleWithBeneficiary(identity.FromAddress(id), common.HexToAddress(req.Beneficiary), common.HexToAddress(req.HermesID))
+	providerID := identity.FromAddress(id)
+	err = te.promiseSettler.SettleWithBeneficiary(providerID, common.HexToAddress(req.Beneficiary), te.settlementHermesID(providerID, req.HermesID))
 	if err != nil {
 		log.Err(err).Msgf("Failed set beneficiary request for ID: %s, %+v", id, req)
 		utils.SendError(resp, fmt.Errorf("failed set beneficiary request: %w", err), http.StatusInternalServerError)
@@ -417,9 +555,13 @@ func (te *transactorEndpoint) SettleIntoStakeAsync(resp http.ResponseWriter, req
 }
 
 // AddRoutesForTransactor attaches Transactor endpoints to router
-func AddRoutesForTransactor(router *httprouter.Router, transactor Transactor, promiseSettler promiseSettler, settlementHistoryProvider settlementHistoryProvider, hermesAddress common.Address) {
+func AddRoutesForTransactor(router *httprouter.Router, transactor Transactor, promiseSettler promiseSettler, settlementHistoryProvider settlementHistoryProvider, hermesAddress common.Address, hermesResolver hermesResolver) {
 	te := NewTransactorEndpoint(transactor, promiseSettler, settlementHistoryProvider, hermesAddress)
+	te.hermesResolver = hermesResolver
 	router.POST("/identities/:id/register", te.RegisterIdentity)
+	router.POST("/identities/:id/register/sponsored", te.RegisterIdentitySponsored)
+	router.GET("/identities/:id/register/authorization", te.RegistrationAuthorization)
+	router.GET("/identities/:id/register/quote", te.RegistrationQuote)
 	router.POST("/identities/:id/beneficiary", te.SettleWithBeneficiary)
 	router.GET("/transactor/fees", te.TransactorFees)
 	router.POST("/transactor/settle/sync", te.SettleSync)
@@ -19,6 +19,7 @@ package endpoints
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"math/big"
 	"net/http"
@@ -31,6 +32,7 @@ import (
 	"github.com/mysteriumnetwork/node/mocks"
 	"github.com/mysteriumnetwork/node/requests"
 	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/mysteriumnetwork/node/identity"
@@ -50,7 +52,7 @@ func Test_RegisterIdentity(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
-	AddRoutesForTransactor(router, tr, nil, &settlementHistoryProviderMock{}, common.Address{})
+	AddRoutesForTransactor(router, tr, nil, &settlementHistoryProviderMock{}, common.Address{}, nil)
 
 	req, err := http.NewRequest(
 		http.MethodPost,
@@ -66,6 +68,63 @@ func Test_RegisterIdentity(t *testing.T) {
 	assert.Equal(t, "", resp.Body.String())
 }
 
+func Test_RegisterIdentitySponsored(t *testing.T) {
+	var received registry.IdentityRegistrationRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/register/sponsored", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	router := httprouter.New()
+	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
+	AddRoutesForTransactor(router, tr, nil, &settlementHistoryProviderMock{}, common.Address{}, nil)
+
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"/identities/0x000000000000000000000000000000000000000a/register/sponsored",
+		bytes.NewBufferString(`{"beneficiary": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "sponsor": "campaign"}`),
+	)
+	assert.NoError(t, err)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.True(t, received.Sponsored)
+	assert.Equal(t, "campaign", received.Sponsor)
+	assert.Equal(t, "0x000000000000000000000000000000000000000a", received.Identity)
+	assert.Equal(t, int64(0), received.Fee.Int64())
+	assert.Equal(t, int64(0), received.Stake.Int64())
+	assert.NotEmpty(t, received.Signature)
+}
+
+func Test_Get_RegistrationAuthorization(t *testing.T) {
+	router := httprouter.New()
+	tr := registry.NewTransactor(requests.NewHTTPClient("", requests.DefaultTimeout), "", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
+	AddRoutesForTransactor(router, tr, nil, &settlementHistoryProviderMock{}, common.Address{}, nil)
+
+	req, err := http.NewRequest(
+		http.MethodGet,
+		"/identities/0x000000000000000000000000000000000000000a/register/authorization?beneficiary=0xbe180c8CA53F280C7BE8669596fF7939d933AA10",
+		nil,
+	)
+	assert.NoError(t, err)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var auth contract.RegistrationAuthorizationDTO
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &auth))
+	assert.Equal(t, "0x000000000000000000000000000000000000000a", auth.Identity)
+	assert.Equal(t, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", auth.Beneficiary)
+	assert.Equal(t, int64(0), auth.Fee.Int64())
+	assert.NotEmpty(t, auth.Signature)
+}
+
 func Test_Get_TransactorFees(t *testing.T) {
 	mockResponse := `{ "fee": 1 }`
 	server := newTestTransactorServer(http.StatusOK, mockResponse)
@@ -75,7 +134,7 @@ func Test_Get_TransactorFees(t *testing.T) {
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "registryAddress", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "hermesID", fakeSignerFactory, mocks.NewEventBus(), nil)
 	AddRoutesForTransactor(router, tr, &mockSettler{
 		feeToReturn: 11,
-	}, &settlementHistoryProviderMock{}, common.Address{})
+	}, &settlementHistoryProviderMock{}, common.Address{}, nil)
 
 	req, err := http.NewRequest(
 		http.MethodGet,
@@ -91,6 +150,54 @@ func Test_Get_TransactorFees(t *testing.T) {
 	assert.JSONEq(t, `{"registration":1, "settlement":1, "hermes":11, "decreaseStake":1}`, resp.Body.String())
 }
 
+func Test_Get_RegistrationQuote(t *testing.T) {
+	for name, tc := range map[string]struct {
+		bountyStatus   int
+		estimateStatus int
+		expected       string
+	}{
+		"eligible for bounty": {
+			bountyStatus:   http.StatusOK,
+			estimateStatus: http.StatusOK,
+			expected:       `{"fee":5, "valid_until":"2020-10-01T10:00:00Z", "bounty_eligible":true, "estimated_confirmation_seconds":90}`,
+		},
+		"estimate unavailable": {
+			bountyStatus:   http.StatusNotFound,
+			estimateStatus: http.StatusNotFound,
+			expected:       `{"fee":5, "valid_until":"2020-10-01T10:00:00Z", "bounty_eligible":false, "estimated_confirmation_seconds":0}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/fee/register", func(w http.ResponseWriter, _ *http.Request) {
+				w.Write([]byte(`{"fee": 5, "valid_until": "2020-10-01T10:00:00Z"}`))
+			})
+			mux.HandleFunc("/identity/register/bounty", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tc.bountyStatus)
+			})
+			mux.HandleFunc("/identity/register/estimate", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tc.estimateStatus)
+				w.Write([]byte(`{"seconds": 90}`))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			router := httprouter.New()
+			tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "registryAddress", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "hermesID", fakeSignerFactory, mocks.NewEventBus(), nil)
+			AddRoutesForTransactor(router, tr, &mockSettler{}, &settlementHistoryProviderMock{}, common.Address{}, nil)
+
+			req, err := http.NewRequest(http.MethodGet, "/identities/0x000000000000000000000000000000000000000a/register/quote", nil)
+			assert.NoError(t, err)
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.JSONEq(t, tc.expected, resp.Body.String())
+		})
+	}
+}
+
 func Test_SettleAsync_OK(t *testing.T) {
 	mockResponse := ""
 	server := newTestTransactorServer(http.StatusAccepted, mockResponse)
@@ -98,7 +205,7 @@ func Test_SettleAsync_OK(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
-	AddRoutesForTransactor(router, tr, &mockSettler{}, &settlementHistoryProviderMock{}, common.Address{})
+	AddRoutesForTransactor(router, tr, &mockSettler{}, &settlementHistoryProviderMock{}, common.Address{}, nil)
 
 	settleRequest := `{"hermes_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "provider_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10"}`
 	req, err := http.NewRequest(
@@ -122,7 +229,7 @@ func Test_SettleAsync_ReturnsError(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
-	AddRoutesForTransactor(router, tr, &mockSettler{errToReturn: errors.New("explosions everywhere")}, &settlementHistoryProviderMock{}, common.Address{})
+	AddRoutesForTransactor(router, tr, &mockSettler{errToReturn: errors.New("explosions everywhere")}, &settlementHistoryProviderMock{}, common.Address{}, nil)
 
 	settleRequest := `asdasdasd`
 	req, err := http.NewRequest(
@@ -146,7 +253,7 @@ func Test_SettleSync_OK(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
-	AddRoutesForTransactor(router, tr, &mockSettler{}, &settlementHistoryProviderMock{}, common.Address{})
+	AddRoutesForTransactor(router, tr, &mockSettler{}, &settlementHistoryProviderMock{}, common.Address{}, nil)
 
 	settleRequest := `{"hermes_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "provider_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10"}`
 	req, err := http.NewRequest(
@@ -163,6 +270,32 @@ func Test_SettleSync_OK(t *testing.T) {
 	assert.Equal(t, "", resp.Body.String())
 }
 
+func Test_SettleSync_UsesPreferredHermes(t *testing.T) {
+	mockResponse := ""
+	server := newTestTransactorServer(http.StatusAccepted, mockResponse)
+
+	router := httprouter.New()
+
+	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
+	settler := &mockSettler{}
+	preferred := common.HexToAddress("0x42a537D649d6853C0a866470f2d084DA0f73b5E4")
+	AddRoutesForTransactor(router, tr, settler, &settlementHistoryProviderMock{}, common.Address{}, &mockHermesResolver{hermesID: preferred})
+
+	settleRequest := `{"provider_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10"}`
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"/transactor/settle/sync",
+		bytes.NewBufferString(settleRequest),
+	)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, preferred, settler.settledHermesID)
+}
+
 func Test_SettleSync_ReturnsError(t *testing.T) {
 	mockResponse := ""
 	server := newTestTransactorServer(http.StatusAccepted, mockResponse)
@@ -170,7 +303,7 @@ func Test_SettleSync_ReturnsError(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
-	AddRoutesForTransactor(router, tr, &mockSettler{errToReturn: errors.New("explosions everywhere")}, &settlementHistoryProviderMock{}, common.Address{})
+	AddRoutesForTransactor(router, tr, &mockSettler{errToReturn: errors.New("explosions everywhere")}, &settlementHistoryProviderMock{}, common.Address{}, nil)
 
 	settleRequest := `{"hermes_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "provider_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10"}`
 	req, err := http.NewRequest(
@@ -195,7 +328,7 @@ func Test_SettleHistory(t *testing.T) {
 
 		router := httprouter.New()
 		tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
-		AddRoutesForTransactor(router, tr, nil, &settlementHistoryProviderMock{errToReturn: errors.New("explosions everywhere")}, common.Address{})
+		AddRoutesForTransactor(router, tr, nil, &settlementHistoryProviderMock{errToReturn: errors.New("explosions everywhere")}, common.Address{}, nil)
 
 		req, err := http.NewRequest(http.MethodGet, "/transactor/settle/history", nil)
 		assert.Nil(t, err)
@@ -226,7 +359,7 @@ func Test_SettleHistory(t *testing.T) {
 
 		router := httprouter.New()
 		tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
-		AddRoutesForTransactor(router, tr, nil, mockStorage, common.Address{})
+		AddRoutesForTransactor(router, tr, nil, mockStorage, common.Address{}, nil)
 
 		req, err := http.NewRequest(http.MethodGet, "/transactor/settle/history", nil)
 		assert.Nil(t, err)
@@ -274,7 +407,7 @@ func Test_SettleHistory(t *testing.T) {
 
 		router := httprouter.New()
 		tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus(), nil)
-		AddRoutesForTransactor(router, tr, nil, mockStorage, common.Address{})
+		AddRoutesForTransactor(router, tr, nil, mockStorage, common.Address{}, nil)
 
 		req, err := http.NewRequest(
 			http.MethodGet,
@@ -337,9 +470,12 @@ type mockSettler struct {
 
 	feeToReturn      uint16
 	feeErrorToReturn error
+
+	settledHermesID common.Address
 }
 
-func (ms *mockSettler) ForceSettle(_ identity.Identity, _ common.Address) error {
+func (ms *mockSettler) ForceSettle(_ identity.Identity, hermesID common.Address) error {
+	ms.settledHermesID = hermesID
 	return ms.errToReturn
 }
 
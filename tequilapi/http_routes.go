@@ -22,15 +22,21 @@ import (
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/discovery/apidiscovery"
 	"github.com/mysteriumnetwork/node/tequilapi/endpoints"
 )
 
-// NewAPIRouter returns new api router with status endpoints
-func NewAPIRouter() *httprouter.Router {
+// NewAPIRouter returns new api router with status endpoints,
+// discovery API failover status is reported by healthcheck when it is given.
+func NewAPIRouter(discoveryAPI *apidiscovery.FailoverAPI) *httprouter.Router {
 	router := httprouter.New()
 	router.HandleMethodNotAllowed = true
 
-	router.GET("/healthcheck", endpoints.HealthCheckEndpointFactory(time.Now, os.Getpid).HealthCheck)
+	healthCheck := endpoints.HealthCheckEndpointFactory(time.Now, os.Getpid, nil)
+	if discoveryAPI != nil {
+		healthCheck = endpoints.HealthCheckEndpointFactory(time.Now, os.Getpid, discoveryAPI)
+	}
+	router.GET("/healthcheck", healthCheck.HealthCheck)
 
 	return router
 }
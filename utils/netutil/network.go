@@ -18,9 +18,13 @@
 package netutil
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
+	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/jackpal/gateway"
 	"github.com/mysteriumnetwork/node/core/storage/boltdb"
@@ -34,6 +38,8 @@ var (
 	LogNetworkStats = defaultLogNetworkStats
 )
 
+var errPolicyRoutingUnsupported = errors.New("policy routing is not supported on this platform")
+
 const (
 	routeRecordDelimeter = "|"
 	routeRecordBucket    = "exclude_route"
@@ -108,9 +114,139 @@ func ExcludeRoute(ip net.IP) error {
 	return excludeRoute(ip, gw)
 }
 
+// ExcludeNetwork excludes given network from VPN tunnel.
+func ExcludeNetwork(network net.IPNet) error {
+	gw, err := gateway.DiscoverGateway()
+	if err != nil {
+		return fmt.Errorf("failed to get default gateway: %w", err)
+	}
+
+	if defaultRouteManager != nil {
+		err := defaultRouteManager.db.Store(routeRecordBucket, &route{
+			Record: strings.Join([]string{network.String(), gw.String()}, routeRecordDelimeter),
+		})
+		if err != nil {
+			log.Error().Err(err).Msgf("Failed to save %s record", routeRecordBucket)
+		}
+	}
+
+	return excludeNetwork(network, gw)
+}
+
+// RemoveExcludedNetwork routes given network, previously excluded by ExcludeNetwork, through VPN tunnel again.
+func RemoveExcludedNetwork(network net.IPNet) error {
+	gw, err := gateway.DiscoverGateway()
+	if err != nil {
+		return fmt.Errorf("failed to get default gateway: %w", err)
+	}
+
+	if defaultRouteManager != nil {
+		err := defaultRouteManager.db.Delete(routeRecordBucket, &route{
+			Record: strings.Join([]string{network.String(), gw.String()}, routeRecordDelimeter),
+		})
+		if err != nil {
+			log.Error().Err(err).Msgf("Failed to delete %s record", routeRecordBucket)
+		}
+	}
+
+	return deleteRoute(network.String(), gw.String())
+}
+
+// RouteThroughInterface routes traffic to the host through the given interface,
+// replacing the route excluding the host from VPN tunnel if there is one.
+func RouteThroughInterface(ip net.IP, iface string) error {
+	return routeThroughInterface(hostNetwork(ip), iface)
+}
+
+// DeleteRouteThroughInterface removes route added by RouteThroughInterface.
+func DeleteRouteThroughInterface(ip net.IP, iface string) error {
+	return deleteRouteThroughInterface(hostNetwork(ip), iface)
+}
+
+// RouteNetworkThroughInterface routes traffic to the network through the given interface.
+func RouteNetworkThroughInterface(network net.IPNet, iface string) error {
+	return routeThroughInterface(network, iface)
+}
+
+// DeleteRouteNetworkThroughInterface removes route added by RouteNetworkThroughInterface.
+func DeleteRouteNetworkThroughInterface(network net.IPNet, iface string) error {
+	return deleteRouteThroughInterface(network, iface)
+}
+
+func hostNetwork(ip net.IP) net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
 // AddDefaultRoute adds default VPN tunnel route.
+// IPv6 traffic is routed through the tunnel too, so that it does not leak around it.
+// Hosts without IPv6 connectivity may refuse IPv6 routes, which is not an error.
 func AddDefaultRoute(iface string) error {
-	return addDefaultRoute(iface)
+	if err := addDefaultRoute(iface); err != nil {
+		return err
+	}
+
+	if err := addDefaultIPv6Route(iface); err != nil {
+		log.Warn().Err(err).Msgf("Failed to route IPv6 traffic through %s", iface)
+	}
+	return nil
+}
+
+// DeleteDefaultRoute removes default VPN tunnel route added by AddDefaultRoute, tunnel itself stays up.
+func DeleteDefaultRoute(iface string) error {
+	if err := deleteDefaultRoute(iface); err != nil {
+		return err
+	}
+
+	if err := deleteDefaultIPv6Route(iface); err != nil {
+		log.Warn().Err(err).Msgf("Failed to remove IPv6 route through %s", iface)
+	}
+	return nil
+}
+
+// AddPolicyRoute routes traffic marked with given firewall mark through the interface, using given routing table.
+func AddPolicyRoute(iface string, mark uint32, table int) error {
+	return addPolicyRoute(iface, mark, table)
+}
+
+// DeletePolicyRoute removes rule routing marked traffic through the table, added by AddPolicyRoute.
+func DeletePolicyRoute(mark uint32, table int) error {
+	return deletePolicyRoute(mark, table)
+}
+
+// SetMTU sets maximum transmission unit of given interface.
+func SetMTU(iface string, mtu int) error {
+	return setMTU(iface, mtu)
+}
+
+// PingNoFragment sends single ICMP echo request of given IP packet size to the host, prohibiting fragmentation.
+// It fails if either request or reply gets lost or does not arrive within the timeout.
+func PingNoFragment(ip net.IP, size int, timeout time.Duration) error {
+	// IP and ICMP headers are not part of the ping payload.
+	payload := size - 28
+	if ip.To4() == nil {
+		payload = size - 48
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	args := pingNoFragmentArgs(ip, payload, timeout)
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%q: %w output: %s", strings.Join(args, " "), err, out)
+	}
+	return nil
+}
+
+// timeoutSeconds rounds timeout up to whole seconds accepted by ping utilities.
+func timeoutSeconds(timeout time.Duration) int {
+	if timeout < time.Second {
+		return 1
+	}
+	return int((timeout + time.Second - 1) / time.Second)
 }
 
 // AssignIP assigns subnet to given interface.
@@ -20,11 +20,17 @@ package netutil
 import (
 	"net"
 	"os/exec"
+	"strconv"
+	"time"
 
 	"github.com/mysteriumnetwork/node/utils/cmdutil"
 )
 
 func assignIP(iface string, subnet net.IPNet) error {
+	if subnet.IP.To4() == nil {
+		prefixLen, _ := subnet.Mask.Size()
+		return cmdutil.SudoExec("ifconfig", iface, "inet6", subnet.IP.String(), "prefixlen", strconv.Itoa(prefixLen), "alias")
+	}
 	return cmdutil.SudoExec("ifconfig", iface, subnet.String(), peerIP(subnet).String())
 }
 
@@ -32,10 +38,34 @@ func excludeRoute(ip, gw net.IP) error {
 	return cmdutil.SudoExec("route", "add", "-host", ip.String(), gw.String())
 }
 
+func excludeNetwork(network net.IPNet, gw net.IP) error {
+	return cmdutil.SudoExec("route", "add", "-net", network.String(), gw.String())
+}
+
 func deleteRoute(ip, gw string) error {
 	return cmdutil.SudoExec("route", "delete", ip, gw)
 }
 
+func routeThroughInterface(network net.IPNet, iface string) error {
+	kind, dest := routeDestination(network)
+	// Route excluding the destination from the tunnel may exist or not, so it is deleted first ignoring the result.
+	_ = cmdutil.SudoExec("route", "delete", kind, dest)
+	return cmdutil.SudoExec("route", "add", kind, dest, "-interface", iface)
+}
+
+func deleteRouteThroughInterface(network net.IPNet, iface string) error {
+	kind, dest := routeDestination(network)
+	return cmdutil.SudoExec("route", "delete", kind, dest, "-interface", iface)
+}
+
+// routeDestination returns host route destination for single addresses, as routes excluding hosts are added that way.
+func routeDestination(network net.IPNet) (kind, dest string) {
+	if ones, bits := network.Mask.Size(); ones == bits {
+		return "-host", network.IP.String()
+	}
+	return "-net", network.String()
+}
+
 func addDefaultRoute(iface string) error {
 	if err := cmdutil.SudoExec("route", "add", "-net", "0.0.0.0/1", "-interface", iface); err != nil {
 		return err
@@ -44,6 +74,30 @@ func addDefaultRoute(iface string) error {
 	return cmdutil.SudoExec("route", "add", "-net", "128.0.0.0/1", "-interface", iface)
 }
 
+func addDefaultIPv6Route(iface string) error {
+	if err := cmdutil.SudoExec("route", "add", "-inet6", "-net", "::/1", "-interface", iface); err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("route", "add", "-inet6", "-net", "8000::/1", "-interface", iface)
+}
+
+func deleteDefaultRoute(iface string) error {
+	if err := cmdutil.SudoExec("route", "delete", "-net", "0.0.0.0/1", "-interface", iface); err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("route", "delete", "-net", "128.0.0.0/1", "-interface", iface)
+}
+
+func deleteDefaultIPv6Route(iface string) error {
+	if err := cmdutil.SudoExec("route", "delete", "-inet6", "-net", "::/1", "-interface", iface); err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("route", "delete", "-inet6", "-net", "8000::/1", "-interface", iface)
+}
+
 func peerIP(subnet net.IPNet) net.IP {
 	lastOctetID := len(subnet.IP) - 1
 	if subnet.IP[lastOctetID] == byte(1) {
@@ -54,6 +108,25 @@ func peerIP(subnet net.IPNet) net.IP {
 	return subnet.IP
 }
 
+func addPolicyRoute(iface string, mark uint32, table int) error {
+	return errPolicyRoutingUnsupported
+}
+
+func deletePolicyRoute(mark uint32, table int) error {
+	return errPolicyRoutingUnsupported
+}
+
+func setMTU(iface string, mtu int) error {
+	return cmdutil.SudoExec("ifconfig", iface, "mtu", strconv.Itoa(mtu))
+}
+
+func pingNoFragmentArgs(ip net.IP, payload int, timeout time.Duration) []string {
+	if ip.To4() == nil {
+		return []string{"ping6", "-c", "1", "-D", "-s", strconv.Itoa(payload), ip.String()}
+	}
+	return []string{"ping", "-c", "1", "-t", strconv.Itoa(timeoutSeconds(timeout)), "-D", "-s", strconv.Itoa(payload), ip.String()}
+}
+
 func logNetworkStats() {
 	for _, args := range [][]string{{"ifconfig", "-a"}, {"netstat", "-rn"}, {"pfctl", "-s", "all"}} {
 		out, err := exec.Command("sudo", args...).CombinedOutput()
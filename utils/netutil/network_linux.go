@@ -20,8 +20,11 @@ package netutil
 import (
 	"net"
 	"os/exec"
+	"strconv"
+	"time"
 
 	"github.com/mysteriumnetwork/node/utils/cmdutil"
+	"github.com/rs/zerolog/log"
 )
 
 func assignIP(iface string, subnet net.IPNet) error {
@@ -35,10 +38,22 @@ func excludeRoute(ip, gw net.IP) error {
 	return cmdutil.SudoExec("ip", "route", "add", ip.String(), "via", gw.String())
 }
 
+func excludeNetwork(network net.IPNet, gw net.IP) error {
+	return cmdutil.SudoExec("ip", "route", "add", network.String(), "via", gw.String())
+}
+
 func deleteRoute(ip, gw string) error {
 	return cmdutil.SudoExec("ip", "route", "delete", ip, "via", gw)
 }
 
+func routeThroughInterface(network net.IPNet, iface string) error {
+	return cmdutil.SudoExec("ip", "route", "replace", network.String(), "dev", iface)
+}
+
+func deleteRouteThroughInterface(network net.IPNet, iface string) error {
+	return cmdutil.SudoExec("ip", "route", "delete", network.String(), "dev", iface)
+}
+
 func addDefaultRoute(iface string) error {
 	if err := cmdutil.SudoExec("ip", "route", "add", "0.0.0.0/1", "dev", iface); err != nil {
 		return err
@@ -47,6 +62,73 @@ func addDefaultRoute(iface string) error {
 	return cmdutil.SudoExec("ip", "route", "add", "128.0.0.0/1", "dev", iface)
 }
 
+func addDefaultIPv6Route(iface string) error {
+	if err := cmdutil.SudoExec("ip", "-6", "route", "add", "::/1", "dev", iface); err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("ip", "-6", "route", "add", "8000::/1", "dev", iface)
+}
+
+func deleteDefaultRoute(iface string) error {
+	if err := cmdutil.SudoExec("ip", "route", "del", "0.0.0.0/1", "dev", iface); err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("ip", "route", "del", "128.0.0.0/1", "dev", iface)
+}
+
+func deleteDefaultIPv6Route(iface string) error {
+	if err := cmdutil.SudoExec("ip", "-6", "route", "del", "::/1", "dev", iface); err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("ip", "-6", "route", "del", "8000::/1", "dev", iface)
+}
+
+func addPolicyRoute(iface string, mark uint32, table int) error {
+	tableID, fwMark := strconv.Itoa(table), strconv.FormatUint(uint64(mark), 10)
+	if err := cmdutil.SudoExec("ip", "route", "replace", "default", "dev", iface, "table", tableID); err != nil {
+		return err
+	}
+	if err := cmdutil.SudoExec("ip", "rule", "add", "fwmark", fwMark, "table", tableID); err != nil {
+		return err
+	}
+	// Replies arrive unmarked, reverse path filter has to take the mark of the connection into account.
+	if err := cmdutil.SudoExec("sysctl", "-w", "net.ipv4.conf.all.src_valid_mark=1"); err != nil {
+		log.Warn().Err(err).Msg("Failed to enable marks for reverse path filter")
+	}
+
+	if err := cmdutil.SudoExec("ip", "-6", "route", "replace", "default", "dev", iface, "table", tableID); err != nil {
+		log.Warn().Err(err).Msgf("Failed to route marked IPv6 traffic through %s", iface)
+		return nil
+	}
+	if err := cmdutil.SudoExec("ip", "-6", "rule", "add", "fwmark", fwMark, "table", tableID); err != nil {
+		log.Warn().Err(err).Msgf("Failed to route marked IPv6 traffic through %s", iface)
+	}
+	return nil
+}
+
+func deletePolicyRoute(mark uint32, table int) error {
+	tableID, fwMark := strconv.Itoa(table), strconv.FormatUint(uint64(mark), 10)
+	// IPv6 rule might have not been added at all.
+	_ = cmdutil.SudoExec("ip", "-6", "rule", "del", "fwmark", fwMark, "table", tableID)
+
+	return cmdutil.SudoExec("ip", "rule", "del", "fwmark", fwMark, "table", tableID)
+}
+
+func setMTU(iface string, mtu int) error {
+	return cmdutil.SudoExec("ip", "link", "set", "dev", iface, "mtu", strconv.Itoa(mtu))
+}
+
+func pingNoFragmentArgs(ip net.IP, payload int, timeout time.Duration) []string {
+	family := "-4"
+	if ip.To4() == nil {
+		family = "-6"
+	}
+	return []string{"ping", family, "-c", "1", "-W", strconv.Itoa(timeoutSeconds(timeout)), "-M", "do", "-s", strconv.Itoa(payload), ip.String()}
+}
+
 func logNetworkStats() {
 	for _, args := range [][]string{{"iptables", "-L", "-n"}, {"iptables", "-L", "-n", "-t", "nat"}, {"ip", "route", "list"}, {"ip", "address", "list"}} {
 		out, err := exec.Command("sudo", args...).CombinedOutput()
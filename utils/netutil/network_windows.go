@@ -22,12 +22,18 @@ import (
 	"net"
 	"os/exec"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
 func assignIP(iface string, subnet net.IPNet) error {
+	if subnet.IP.To4() == nil {
+		out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 add address interface=\""+iface+"\" address="+subnet.String()).CombinedOutput()
+		return errors.Wrap(err, string(out))
+	}
 	out, err := exec.Command("powershell", "-Command", "netsh interface ip set address name=\""+iface+"\" source=static "+subnet.String()).CombinedOutput()
 	return errors.Wrap(err, string(out))
 }
@@ -37,8 +43,16 @@ func excludeRoute(ip, gw net.IP) error {
 	return errors.Wrap(err, string(out))
 }
 
+func excludeNetwork(network net.IPNet, gw net.IP) error {
+	out, err := exec.Command("powershell", "-Command", "route add "+network.String()+" "+gw.String()).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
 func deleteRoute(ip, gw string) error {
-	out, err := exec.Command("powershell", "-Command", "route delete "+ip+"/32").CombinedOutput()
+	if !strings.Contains(ip, "/") {
+		ip += "/32"
+	}
+	out, err := exec.Command("powershell", "-Command", "route delete "+ip).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to delete route: %w, %s", err, string(out))
 	}
@@ -46,6 +60,23 @@ func deleteRoute(ip, gw string) error {
 	return nil
 }
 
+func routeThroughInterface(network net.IPNet, name string) error {
+	id, gw, err := interfaceInfo(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to get info of interface: "+name)
+	}
+
+	// Route excluding the destination from the tunnel may exist or not, so it is deleted first ignoring the result.
+	_ = exec.Command("powershell", "-Command", "route delete "+network.String()).Run()
+	out, err := exec.Command("powershell", "-Command", "route add "+network.String()+" "+gw+" if "+id).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
+func deleteRouteThroughInterface(network net.IPNet, name string) error {
+	out, err := exec.Command("powershell", "-Command", "route delete "+network.String()).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
 func addDefaultRoute(name string) error {
 	id, gw, err := interfaceInfo(name)
 	if err != nil {
@@ -60,6 +91,50 @@ func addDefaultRoute(name string) error {
 	return errors.Wrap(err, string(out))
 }
 
+func addDefaultIPv6Route(name string) error {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to get interface "+name)
+	}
+	id := strconv.Itoa(iface.Index)
+
+	if out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 add route ::/1 interface="+id).CombinedOutput(); err != nil {
+		return errors.Wrap(err, string(out))
+	}
+
+	out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 add route 8000::/1 interface="+id).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
+func deleteDefaultRoute(name string) error {
+	id, gw, err := interfaceInfo(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to get info of interface: "+name)
+	}
+
+	if out, err := exec.Command("powershell", "-Command", "route delete 0.0.0.0/1 "+gw+" if "+id).CombinedOutput(); err != nil {
+		return errors.Wrap(err, string(out))
+	}
+
+	out, err := exec.Command("powershell", "-Command", "route delete 128.0.0.0/1 "+gw+" if "+id).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
+func deleteDefaultIPv6Route(name string) error {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to get interface "+name)
+	}
+	id := strconv.Itoa(iface.Index)
+
+	if out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 delete route ::/1 interface="+id).CombinedOutput(); err != nil {
+		return errors.Wrap(err, string(out))
+	}
+
+	out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 delete route 8000::/1 interface="+id).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
 func interfaceInfo(name string) (id, gw string, err error) {
 	iface, err := net.InterfaceByName(name)
 	if err != nil {
@@ -93,6 +168,33 @@ func interfaceInfo(name string) (id, gw string, err error) {
 	return strconv.Itoa(iface.Index), ipv4.String(), nil
 }
 
+func addPolicyRoute(iface string, mark uint32, table int) error {
+	return errPolicyRoutingUnsupported
+}
+
+func deletePolicyRoute(mark uint32, table int) error {
+	return errPolicyRoutingUnsupported
+}
+
+func setMTU(name string, mtu int) error {
+	for _, family := range []string{"ipv4", "ipv6"} {
+		out, err := exec.Command("powershell", "-Command", "netsh interface "+family+" set subinterface \""+name+"\" mtu="+strconv.Itoa(mtu)+" store=active").CombinedOutput()
+		if err != nil {
+			return errors.Wrap(err, string(out))
+		}
+	}
+	return nil
+}
+
+func pingNoFragmentArgs(ip net.IP, payload int, timeout time.Duration) []string {
+	timeoutMillis := strconv.Itoa(int(timeout / time.Millisecond))
+	// IPv6 packets are never fragmented by routers, -f applies to IPv4 only.
+	if ip.To4() == nil {
+		return []string{"ping", "-6", "-n", "1", "-w", timeoutMillis, "-l", strconv.Itoa(payload), ip.String()}
+	}
+	return []string{"ping", "-4", "-n", "1", "-w", timeoutMillis, "-f", "-l", strconv.Itoa(payload), ip.String()}
+}
+
 func logNetworkStats() {
 	for _, args := range []string{"ipconfig /all", "netstat -r"} {
 		out, err := exec.Command("powershell", "-Command", args).CombinedOutput()
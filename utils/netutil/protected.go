@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrProtectedDestination indicates that destination resolves to a protected address.
+var ErrProtectedDestination = errors.New("destination is protected")
+
+// ProtectedNetworks are destinations consumers are not allowed to reach through the services proxying their traffic.
+type ProtectedNetworks []*net.IPNet
+
+// ParseProtectedNetworks parses networks given in CIDR notation, invalid ones are skipped.
+func ParseProtectedNetworks(networks []string) ProtectedNetworks {
+	var protected ProtectedNetworks
+	for _, network := range networks {
+		_, ipnet, err := net.ParseCIDR(network)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Ignoring invalid protected network %q", network)
+			continue
+		}
+		protected = append(protected, ipnet)
+	}
+	return protected
+}
+
+// Contains checks if the IP is protected, loopback, link local and other host scoped addresses are always protected.
+func (p ProtectedNetworks) Contains(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve resolves host of the host:port destination, failing if any of its addresses is protected.
+// Destination is resolved once, so that it can not be rebound to a protected address after the check.
+func (p ProtectedNetworks) Resolve(ctx context.Context, hostport string) (string, error) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return "", err
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("could not resolve %s", host)
+	}
+	for _, addr := range addrs {
+		if p.Contains(addr.IP) {
+			return "", ErrProtectedDestination
+		}
+	}
+	return net.JoinHostPort(addrs[0].IP.String(), port), nil
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netutil
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtectedNetworks_Contains(t *testing.T) {
+	protected := ParseProtectedNetworks([]string{"10.0.0.0/8", "invalid"})
+	assert.Len(t, protected, 1)
+
+	assert.True(t, protected.Contains(net.ParseIP("10.0.0.1")))
+	assert.True(t, protected.Contains(net.ParseIP("127.0.0.1")))
+	assert.True(t, protected.Contains(net.ParseIP("::1")))
+	assert.True(t, protected.Contains(net.ParseIP("0.0.0.0")))
+	assert.True(t, protected.Contains(net.ParseIP("169.254.169.254")))
+	assert.False(t, protected.Contains(net.ParseIP("192.168.0.1")))
+	assert.False(t, protected.Contains(net.ParseIP("8.8.8.8")))
+}